@@ -59,6 +59,52 @@ type EnvironmentSpec struct {
 // EnvironmentStatus is the status for an Environment resource
 type EnvironmentStatus struct {
 	Version string `json:"version,omitempty"`
+
+	// Remote is the status reported back by the environment controller agent running inside a remote cluster
+	Remote *RemoteEnvironmentStatus `json:"remote,omitempty" protobuf:"bytes,2,opt,name=remote"`
+}
+
+// RemoteSyncStatusType is the status of applying the environment git repository to a remote cluster
+type RemoteSyncStatusType string
+
+const (
+	// RemoteSyncStatusUnknown no pipeline has been detected yet for the environment git repository
+	RemoteSyncStatusUnknown RemoteSyncStatusType = ""
+	// RemoteSyncStatusSyncing the environment pipeline is currently applying the git repository
+	RemoteSyncStatusSyncing RemoteSyncStatusType = "Syncing"
+	// RemoteSyncStatusSynced the last environment pipeline applied the git repository successfully
+	RemoteSyncStatusSynced RemoteSyncStatusType = "Synced"
+	// RemoteSyncStatusFailed the last environment pipeline failed to apply the git repository
+	RemoteSyncStatusFailed RemoteSyncStatusType = "Failed"
+)
+
+// RemoteEnvironmentStatus is the status of an Environment which is deployed in a remote cluster
+type RemoteEnvironmentStatus struct {
+	// SyncStatus the status of the last environment pipeline in the remote cluster
+	SyncStatus RemoteSyncStatusType `json:"syncStatus,omitempty" protobuf:"bytes,1,opt,name=syncStatus"`
+	// Revision the git commit SHA of the environment repository last applied to the remote cluster
+	Revision string `json:"revision,omitempty" protobuf:"bytes,2,opt,name=revision"`
+	// Message an optional message describing the sync status
+	Message string `json:"message,omitempty" protobuf:"bytes,3,opt,name=message"`
+	// Cluster the name of the remote cluster reporting the status
+	Cluster string `json:"cluster,omitempty" protobuf:"bytes,4,opt,name=cluster"`
+	// LastSyncTimestamp the time the last environment pipeline completed
+	LastSyncTimestamp *metav1.Time `json:"lastSyncTimestamp,omitempty" protobuf:"bytes,5,opt,name=lastSyncTimestamp"`
+	// LastReportTimestamp the time the agent last reported the status
+	LastReportTimestamp *metav1.Time `json:"lastReportTimestamp,omitempty" protobuf:"bytes,6,opt,name=lastReportTimestamp"`
+	// Applications the applications deployed in the remote environment
+	Applications []RemoteApplicationStatus `json:"applications,omitempty" protobuf:"bytes,7,opt,name=applications"`
+}
+
+// RemoteApplicationStatus is the status of an application deployed in a remote cluster
+type RemoteApplicationStatus struct {
+	Name          string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	Deployment    string `json:"deployment,omitempty" protobuf:"bytes,2,opt,name=deployment"`
+	Version       string `json:"version,omitempty" protobuf:"bytes,3,opt,name=version"`
+	Replicas      int32  `json:"replicas,omitempty" protobuf:"bytes,4,opt,name=replicas"`
+	ReadyReplicas int32  `json:"readyReplicas,omitempty" protobuf:"bytes,5,opt,name=readyReplicas"`
+	Healthy       bool   `json:"healthy,omitempty" protobuf:"bytes,6,opt,name=healthy"`
+	URL           string `json:"url,omitempty" protobuf:"bytes,7,opt,name=url"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -126,6 +126,16 @@ type PromoteActivityStep struct {
 	PullRequest    *PromotePullRequestStep `json:"pullRequest,omitempty" protobuf:"bytes,2,opt,name=pullRequest"`
 	Update         *PromoteUpdateStep      `json:"update,omitempty" protobuf:"bytes,3,opt,name=update"`
 	ApplicationURL string                  `json:"applicationURL,omitempty" protobuf:"bytes,4,opt,name=environment"`
+	// Applications are the applications promoted together as a release train
+	Applications []PromoteApplication `json:"applications,omitempty" protobuf:"bytes,5,opt,name=applications"`
+	// Canary is the progressive delivery of the promotion via a Flagger Canary
+	Canary *PromoteCanaryStep `json:"canary,omitempty" protobuf:"bytes,6,opt,name=canary"`
+}
+
+// PromoteApplication is an application version which is promoted as part of a release train
+type PromoteApplication struct {
+	Name    string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	Version string `json:"version,omitempty" protobuf:"bytes,2,opt,name=version"`
 }
 
 // GitStatus the status of a git commit in terms of CI/CD
@@ -143,6 +153,24 @@ type PromotePullRequestStep struct {
 	MergeCommitSHA string `json:"mergeCommitSHA,omitempty" protobuf:"bytes,2,opt,name=mergeCommitSHA"`
 }
 
+// PromoteCanaryStep is the step for the progressive delivery of a promotion by a Flagger Canary analysis
+type PromoteCanaryStep struct {
+	CoreActivityStep `json:",inline"`
+
+	// Canary is the name of the Flagger Canary resource
+	Canary string `json:"canary,omitempty" protobuf:"bytes,1,opt,name=canary"`
+	// Phase is the phase of the Canary analysis
+	Phase string `json:"phase,omitempty" protobuf:"bytes,2,opt,name=phase"`
+	// Weight is the percentage of traffic routed to the new version
+	Weight int32 `json:"weight,omitempty" protobuf:"bytes,3,opt,name=weight"`
+	// MaxWeight is the percentage of traffic at which the new version is promoted
+	MaxWeight int32 `json:"maxWeight,omitempty" protobuf:"bytes,4,opt,name=maxWeight"`
+	// Iterations is the number of iterations of the Canary analysis
+	Iterations int32 `json:"iterations,omitempty" protobuf:"bytes,5,opt,name=iterations"`
+	// FailedChecks is the number of failed metric checks of the Canary analysis
+	FailedChecks int32 `json:"failedChecks,omitempty" protobuf:"bytes,6,opt,name=failedChecks"`
+}
+
 // PromoteUpdateStep is the step for updating a promotion after the Pull Request merges to master
 type PromoteUpdateStep struct {
 	CoreActivityStep `json:",inline"`
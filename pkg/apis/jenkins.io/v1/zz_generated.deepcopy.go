@@ -735,7 +735,7 @@ func (in *Environment) DeepCopyInto(out *Environment) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -960,6 +960,11 @@ func (in *EnvironmentSpec) DeepCopy() *EnvironmentSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvironmentStatus) DeepCopyInto(out *EnvironmentStatus) {
 	*out = *in
+	if in.Remote != nil {
+		in, out := &in.Remote, &out.Remote
+		*out = new(RemoteEnvironmentStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -2558,6 +2563,16 @@ func (in *PromoteActivityStep) DeepCopyInto(out *PromoteActivityStep) {
 		*out = new(PromoteUpdateStep)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Applications != nil {
+		in, out := &in.Applications, &out.Applications
+		*out = make([]PromoteApplication, len(*in))
+		copy(*out, *in)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(PromoteCanaryStep)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -2571,6 +2586,39 @@ func (in *PromoteActivityStep) DeepCopy() *PromoteActivityStep {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromoteApplication) DeepCopyInto(out *PromoteApplication) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromoteApplication.
+func (in *PromoteApplication) DeepCopy() *PromoteApplication {
+	if in == nil {
+		return nil
+	}
+	out := new(PromoteApplication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromoteCanaryStep) DeepCopyInto(out *PromoteCanaryStep) {
+	*out = *in
+	in.CoreActivityStep.DeepCopyInto(&out.CoreActivityStep)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromoteCanaryStep.
+func (in *PromoteCanaryStep) DeepCopy() *PromoteCanaryStep {
+	if in == nil {
+		return nil
+	}
+	out := new(PromoteCanaryStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PromotePullRequestStep) DeepCopyInto(out *PromotePullRequestStep) {
 	*out = *in
@@ -2933,6 +2981,51 @@ func (in *ReleaseStatus) DeepCopy() *ReleaseStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteApplicationStatus) DeepCopyInto(out *RemoteApplicationStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteApplicationStatus.
+func (in *RemoteApplicationStatus) DeepCopy() *RemoteApplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteApplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteEnvironmentStatus) DeepCopyInto(out *RemoteEnvironmentStatus) {
+	*out = *in
+	if in.LastSyncTimestamp != nil {
+		in, out := &in.LastSyncTimestamp, &out.LastSyncTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastReportTimestamp != nil {
+		in, out := &in.LastReportTimestamp, &out.LastReportTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.Applications != nil {
+		in, out := &in.Applications, &out.Applications
+		*out = make([]RemoteApplicationStatus, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteEnvironmentStatus.
+func (in *RemoteEnvironmentStatus) DeepCopy() *RemoteEnvironmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteEnvironmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplaceableMapOfStringContextPolicy) DeepCopyInto(out *ReplaceableMapOfStringContextPolicy) {
 	*out = *in
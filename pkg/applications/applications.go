@@ -7,6 +7,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
 	"github.com/jenkins-x/jx/v2/pkg/kube/services"
+	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
@@ -17,6 +18,12 @@ import (
 // Deployment represents an application deployment in a single environment
 type Deployment struct {
 	*appsv1.Deployment
+
+	// RemoteURL is the URL reported by the environment controller for deployments in a remote cluster
+	RemoteURL string
+
+	// Canary is the status of the Flagger Canary of the deployment if it uses progressive delivery
+	Canary *flagger.CanaryStatus
 }
 
 // Environment represents an environment in which an application has been
@@ -81,8 +88,31 @@ func (d Deployment) Pods() string {
 	return pods
 }
 
+// CanaryStatus returns the progress of the Flagger Canary analysis of the deployment or blank if it has no Canary
+func (d Deployment) CanaryStatus() string {
+	if d.Canary == nil {
+		return ""
+	}
+	return d.Canary.Progress()
+}
+
+// HasCanaries returns true if any of the applications use a Flagger Canary in the given environment
+func (l List) HasCanaries(envName string) bool {
+	for _, a := range l.Items {
+		for _, d := range a.Environments[envName].Deployments {
+			if d.Canary != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // URL returns a deployment URL
 func (d Deployment) URL(kc kubernetes.Interface, a Application) string {
+	if d.RemoteURL != "" {
+		return d.RemoteURL
+	}
 	url, _ := services.FindServiceURL(kc, d.Deployment.Namespace, a.Name())
 	return url
 }
@@ -130,7 +160,14 @@ func GetApplications(factory clients.Factory) (List, error) {
 
 	// fetch deployments by environment (excluding dev)
 	deployments := make(map[string]map[string]appsv1.Deployment)
+	remoteURLs := map[string]map[string]string{}
+	canaries := map[string]map[string]*flagger.CanaryStatus{}
 	for _, env := range permanentEnvsMap {
+		if env.Spec.RemoteCluster {
+			// the deployments live in another cluster so lets use the status reported by the environment controller
+			deployments[env.Spec.Namespace], remoteURLs[env.Spec.Namespace] = remoteDeployments(env)
+			continue
+		}
 		if env.Spec.Kind != v1.EnvironmentKindTypeDevelopment {
 			envDeployments, err := kube.GetDeployments(kubeClient, env.Spec.Namespace)
 			if err != nil {
@@ -138,17 +175,96 @@ func GetApplications(factory clients.Factory) (List, error) {
 			}
 
 			deployments[env.Spec.Namespace] = envDeployments
+			canaries[env.Spec.Namespace] = getCanaries(factory, env.Spec.Namespace)
 		}
 	}
 
-	err = list.appendMatchingDeployments(permanentEnvsMap, deployments)
+	err = list.appendMatchingDeployments(permanentEnvsMap, deployments, remoteURLs)
 	if err != nil {
 		return list, err
 	}
+	list.appendCanaries(permanentEnvsMap, canaries)
 
 	return list, nil
 }
 
+// remoteDeployments creates the deployments from the status reported by the environment controller
+// running in the remote cluster of the environment along with the URLs of the applications
+func remoteDeployments(env *v1.Environment) (map[string]appsv1.Deployment, map[string]string) {
+	deployments := map[string]appsv1.Deployment{}
+	urls := map[string]string{}
+	if env.Status.Remote == nil {
+		return deployments, urls
+	}
+	for _, app := range env.Status.Remote.Applications {
+		name := app.Deployment
+		if name == "" {
+			name = app.Name
+		}
+		replicas := app.Replicas
+		deployments[name] = appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: env.Spec.Namespace,
+				Labels: map[string]string{
+					"app":     app.Name,
+					"version": app.Version,
+				},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"app": app.Name,
+					},
+				},
+			},
+			Status: appsv1.DeploymentStatus{
+				Replicas:      replicas,
+				ReadyReplicas: app.ReadyReplicas,
+			},
+		}
+		urls[name] = app.URL
+	}
+	return deployments, urls
+}
+
+// getCanaries returns the Flagger Canaries in the namespace indexed by their target deployment name.
+// Any failure is ignored as Flagger is optional
+func getCanaries(factory clients.Factory, ns string) map[string]*flagger.CanaryStatus {
+	dynamicClient, _, err := factory.CreateDynamicClient()
+	if err != nil {
+		log.Logger().Debugf("failed to create the dynamic client: %s", err.Error())
+		return nil
+	}
+	canaries, err := flagger.GetCanaries(dynamicClient, ns)
+	if err != nil {
+		log.Logger().Debugf("failed to get the Flagger Canaries in namespace %s: %s", ns, err.Error())
+		return nil
+	}
+	return canaries
+}
+
+// appendCanaries adds the status of the Flagger Canary of each deployment
+func (l List) appendCanaries(envs map[string]*v1.Environment, canaries map[string]map[string]*flagger.CanaryStatus) {
+	for _, app := range l.Items {
+		for envNs, env := range envs {
+			envCanaries := canaries[envNs]
+			if len(envCanaries) == 0 {
+				continue
+			}
+			appEnv, ok := app.Environments[env.Name]
+			if !ok {
+				continue
+			}
+			for i := range appEnv.Deployments {
+				d := &appEnv.Deployments[i]
+				d.Canary = envCanaries[d.Deployment.Name]
+			}
+		}
+	}
+}
+
 func getDeploymentAppNameInEnvironment(d appsv1.Deployment, e *v1.Environment) (string, error) {
 	labels, err := metav1.LabelSelectorAsMap(d.Spec.Selector)
 	if err != nil {
@@ -159,7 +275,7 @@ func getDeploymentAppNameInEnvironment(d appsv1.Deployment, e *v1.Environment) (
 	return name, nil
 }
 
-func (l List) appendMatchingDeployments(envs map[string]*v1.Environment, deps map[string]map[string]appsv1.Deployment) error {
+func (l List) appendMatchingDeployments(envs map[string]*v1.Environment, deps map[string]map[string]appsv1.Deployment, remoteURLs map[string]map[string]string) error {
 	for _, app := range l.Items {
 		for envName, env := range envs {
 			for _, dep := range deps[envName] {
@@ -171,7 +287,7 @@ func (l List) appendMatchingDeployments(envs map[string]*v1.Environment, deps ma
 					depCopy := dep
 					app.Environments[env.Name] = Environment{
 						*env,
-						[]Deployment{{&depCopy}},
+						[]Deployment{{Deployment: &depCopy, RemoteURL: remoteURLs[envName][dep.Name]}},
 					}
 				}
 			}
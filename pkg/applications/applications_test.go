@@ -146,7 +146,7 @@ func TestAppendMatchingDeployments(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		err := test.list.appendMatchingDeployments(test.environments, test.deployments)
+		err := test.list.appendMatchingDeployments(test.environments, test.deployments, nil)
 
 		assert.NoError(t, err, test.name)
 		assert.Equal(t, test.wantApplications, len(test.list.Items), test.name)
@@ -159,3 +159,72 @@ func TestAppendMatchingDeployments(t *testing.T) {
 		}
 	}
 }
+
+func TestRemoteDeployments(t *testing.T) {
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "production",
+		},
+		Spec: v1.EnvironmentSpec{
+			Namespace:     "jx-production",
+			Kind:          v1.EnvironmentKindTypePermanent,
+			RemoteCluster: true,
+		},
+		Status: v1.EnvironmentStatus{
+			Remote: &v1.RemoteEnvironmentStatus{
+				SyncStatus: v1.RemoteSyncStatusSynced,
+				Applications: []v1.RemoteApplicationStatus{
+					{
+						Name:          "my-repo-name",
+						Deployment:    "jx-my-repo-name",
+						Version:       "1.2.3",
+						Replicas:      2,
+						ReadyReplicas: 1,
+						Healthy:       false,
+						URL:           "http://my-repo-name.jx-production.example.com",
+					},
+				},
+			},
+		},
+	}
+	list := List{
+		[]Application{
+			{
+				&v1.SourceRepository{
+					Spec: v1.SourceRepositorySpec{
+						Repo: "my-repo-name",
+					},
+				},
+				make(map[string]Environment),
+			},
+		},
+	}
+
+	deployments, urls := remoteDeployments(env)
+	assert.Len(t, deployments, 1)
+
+	err := list.appendMatchingDeployments(map[string]*v1.Environment{"jx-production": env},
+		map[string]map[string]appsv1.Deployment{"jx-production": deployments},
+		map[string]map[string]string{"jx-production": urls})
+	assert.NoError(t, err)
+
+	appEnv, ok := list.Items[0].Environments["production"]
+	assert.True(t, ok, "should have found the remote environment")
+	if assert.Len(t, appEnv.Deployments, 1) {
+		d := appEnv.Deployments[0]
+		assert.Equal(t, "1.2.3", d.Version())
+		assert.Equal(t, "1/2", d.Pods())
+		assert.Equal(t, "http://my-repo-name.jx-production.example.com", d.URL(nil, list.Items[0]))
+	}
+}
+
+func TestRemoteDeploymentsWithoutStatus(t *testing.T) {
+	deployments, urls := remoteDeployments(&v1.Environment{
+		Spec: v1.EnvironmentSpec{
+			Namespace:     "jx-production",
+			RemoteCluster: true,
+		},
+	})
+	assert.Empty(t, deployments)
+	assert.Empty(t, urls)
+}
@@ -13,4 +13,9 @@ const (
 	GitAuthConfigFile = "gitAuth.yaml"
 	// ChartmuseumAuthConfigFile config file for chartmusuem auth credentials
 	ChartmuseumAuthConfigFile = "chartmuseumAuth.yaml"
+	// OIDCAuthConfigFile config file for the OIDC sessions of users who logged in via `jx login --oidc`
+	OIDCAuthConfigFile = "oidcAuth.yaml"
+
+	// OIDCUIServerKind the kind of the servers in the OIDC sessions which are Jenkins X UI URLs rather than git servers
+	OIDCUIServerKind = "jx-ui"
 )
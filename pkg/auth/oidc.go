@@ -0,0 +1,418 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// OIDCDefaultClientID the default OIDC client ID used by the jx CLI
+	OIDCDefaultClientID = "jx"
+
+	oidcDiscoveryPath    = "/.well-known/openid-configuration"
+	oidcCallbackPath     = "/callback"
+	oidcDeviceGrantType  = "urn:ietf:params:oauth:grant-type:device_code"
+	oidcIDTokenKey       = "id_token"
+	oidcRefreshThreshold = time.Minute
+)
+
+// OIDCDefaultScopes the default scopes requested when logging in via OIDC. The offline_access scope is required to
+// get a refresh token
+var OIDCDefaultScopes = []string{"openid", "profile", "email", "offline_access"}
+
+// OIDCProvider the endpoints of an OIDC provider loaded from its discovery document
+type OIDCProvider struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint,omitempty"`
+	UserinfoEndpoint            string `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                     string `json:"jwks_uri"`
+}
+
+// OIDCClaims the claims of an ID token which are used to identify the user
+type OIDCClaims struct {
+	Issuer            string `json:"iss"`
+	Subject           string `json:"sub"`
+	Email             string `json:"email,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Name              string `json:"name,omitempty"`
+	Expiry            int64  `json:"exp,omitempty"`
+}
+
+// OIDCDeviceAuthorization the response of the device authorization endpoint telling the user where to login
+type OIDCDeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// oidcTokenResponse the token endpoint response used when polling for the device flow
+type oidcTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+	Description  string `json:"error_description"`
+}
+
+// DiscoverOIDCProvider loads the OIDC discovery document of the given issuer
+func DiscoverOIDCProvider(ctx context.Context, issuer string) (*OIDCProvider, error) {
+	u := strings.TrimSuffix(issuer, "/") + oidcDiscoveryPath
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the OIDC discovery request for %s", u)
+	}
+	resp, err := oidcHTTPClient(ctx).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading the OIDC discovery document %s", u)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the OIDC discovery document %s", u)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("loading the OIDC discovery document %s returned status %d: %s", u, resp.StatusCode, string(data))
+	}
+	provider := &OIDCProvider{}
+	err = json.Unmarshal(data, provider)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshaling the OIDC discovery document %s", u)
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("the OIDC discovery document %s is for issuer %s rather than %s", u, provider.Issuer, issuer)
+	}
+	if provider.TokenEndpoint == "" {
+		return nil, fmt.Errorf("the OIDC discovery document %s has no token_endpoint", u)
+	}
+	return provider, nil
+}
+
+// OAuth2Config returns the OAuth2 configuration for the given client and scopes
+func (p *OIDCProvider) OAuth2Config(clientID string, redirectURL string, scopes []string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:    clientID,
+		RedirectURL: redirectURL,
+		Scopes:      scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   p.AuthorizationEndpoint,
+			TokenURL:  p.TokenEndpoint,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+// LoopbackLogin performs the authorization code flow with PKCE using a HTTP server on the loopback interface to
+// receive the authorization code. The openURL function is used to open the authorization URL in a browser
+func (p *OIDCProvider) LoopbackLogin(ctx context.Context, clientID string, scopes []string, openURL func(string) error) (*oauth2.Token, error) {
+	if p.AuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("the OIDC issuer %s does not support the authorization code flow", p.Issuer)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "listening on the loopback interface")
+	}
+	redirectURL := fmt.Sprintf("http://%s%s", listener.Addr().String(), oidcCallbackPath)
+	config := p.OAuth2Config(clientID, redirectURL, scopes)
+
+	state, err := randomURLSafeString(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := NewPKCEVerifier()
+	if err != nil {
+		return nil, err
+	}
+	authURL := config.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("code_challenge", PKCEChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"))
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidcCallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		result := callbackResult{code: query.Get("code")}
+		switch {
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("login failed: %s %s", query.Get("error"), query.Get("error_description"))
+		case query.Get("state") != state:
+			result.err = fmt.Errorf("login failed: the state parameter does not match")
+		case result.code == "":
+			result.err = fmt.Errorf("login failed: no authorization code returned")
+		}
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Login successful. You can close this window and return to the terminal.")
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener) //nolint:errcheck
+	defer server.Close()
+
+	err = openURL(authURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening the login URL %s", authURL)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "waiting for the login to complete")
+	case result := <-results:
+		if result.err != nil {
+			return nil, result.err
+		}
+		token, err := config.Exchange(oidcContext(ctx), result.code, oauth2.SetAuthURLParam("code_verifier", verifier))
+		if err != nil {
+			return nil, errors.Wrap(err, "exchanging the authorization code for a token")
+		}
+		return token, nil
+	}
+}
+
+// StartDeviceLogin starts the device authorization flow returning the code the user needs to enter
+func (p *OIDCProvider) StartDeviceLogin(ctx context.Context, clientID string, scopes []string) (*OIDCDeviceAuthorization, error) {
+	if p.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("the OIDC issuer %s does not support the device authorization flow", p.Issuer)
+	}
+	data := url.Values{
+		"client_id": {clientID},
+		"scope":     {strings.Join(scopes, " ")},
+	}
+	body, status, err := postForm(ctx, p.DeviceAuthorizationEndpoint, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting the device authorization")
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("starting the device authorization returned status %d: %s", status, string(body))
+	}
+	answer := &OIDCDeviceAuthorization{}
+	err = json.Unmarshal(body, answer)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshaling the device authorization response")
+	}
+	if answer.DeviceCode == "" || answer.VerificationURI == "" {
+		return nil, fmt.Errorf("the device authorization response is missing the device_code or verification_uri")
+	}
+	return answer, nil
+}
+
+// PollDeviceLogin polls the token endpoint until the user has completed the device login, it is denied or expires
+func (p *OIDCProvider) PollDeviceLogin(ctx context.Context, clientID string, device *OIDCDeviceAuthorization) (*oauth2.Token, error) {
+	interval := time.Duration(device.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	if device.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(device.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+	data := url.Values{
+		"grant_type":  {oidcDeviceGrantType},
+		"device_code": {device.DeviceCode},
+		"client_id":   {clientID},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "waiting for the device login to complete")
+		case <-time.After(interval):
+		}
+		body, _, err := postForm(ctx, p.TokenEndpoint, data)
+		if err != nil {
+			return nil, errors.Wrap(err, "polling for the device login token")
+		}
+		resp := &oidcTokenResponse{}
+		err = json.Unmarshal(body, resp)
+		if err != nil {
+			return nil, errors.Wrap(err, "unmarshaling the device login token response")
+		}
+		switch resp.Error {
+		case "":
+			if resp.AccessToken == "" {
+				return nil, fmt.Errorf("the device login token response has no access_token")
+			}
+			token := &oauth2.Token{
+				AccessToken:  resp.AccessToken,
+				TokenType:    resp.TokenType,
+				RefreshToken: resp.RefreshToken,
+			}
+			if resp.ExpiresIn > 0 {
+				token.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+			}
+			return token.WithExtra(map[string]interface{}{oidcIDTokenKey: resp.IDToken}), nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, fmt.Errorf("device login failed: %s %s", resp.Error, resp.Description)
+		}
+	}
+}
+
+// RefreshOIDCToken uses the refresh token of the user to get a new access token from the OIDC issuer, updating the
+// tokens of the user
+func RefreshOIDCToken(ctx context.Context, user *UserAuth) error {
+	if user.OIDCIssuer == "" || user.RefreshToken == "" {
+		return fmt.Errorf("user %s has no OIDC refresh token", user.Username)
+	}
+	provider, err := DiscoverOIDCProvider(ctx, user.OIDCIssuer)
+	if err != nil {
+		return err
+	}
+	config := provider.OAuth2Config(user.OIDCClientID, "", nil)
+	token, err := config.TokenSource(oidcContext(ctx), &oauth2.Token{RefreshToken: user.RefreshToken}).Token()
+	if err != nil {
+		return errors.Wrapf(err, "refreshing the OIDC token of user %s from %s", user.Username, user.OIDCIssuer)
+	}
+	user.SetOIDCToken(user.OIDCIssuer, user.OIDCClientID, token)
+	return nil
+}
+
+// SetOIDCToken updates the tokens of the user from the given OIDC token
+func (a *UserAuth) SetOIDCToken(issuer string, clientID string, token *oauth2.Token) {
+	a.OIDCIssuer = issuer
+	a.OIDCClientID = clientID
+	a.ApiToken = token.AccessToken
+	a.BearerToken = token.AccessToken
+	if token.RefreshToken != "" {
+		a.RefreshToken = token.RefreshToken
+	}
+	a.Expiry = nil
+	if !token.Expiry.IsZero() {
+		expiry := token.Expiry.UTC()
+		a.Expiry = &expiry
+	}
+}
+
+// IsOIDC returns true if the user logged in via OIDC
+func (a *UserAuth) IsOIDC() bool {
+	return a.OIDCIssuer != ""
+}
+
+// OIDCTokenExpired returns true if the OIDC access token is missing or expires soon and so needs to be refreshed
+func (a *UserAuth) OIDCTokenExpired(now time.Time) bool {
+	if a.BearerToken == "" {
+		return true
+	}
+	return a.Expiry != nil && !now.Add(oidcRefreshThreshold).Before(*a.Expiry)
+}
+
+// ParseOIDCClaims verifies the signature, issuer, audience and expiry of the ID token of the given token using the
+// keys published by the provider and returns its claims
+func (p *OIDCProvider) ParseOIDCClaims(ctx context.Context, clientID string, token *oauth2.Token) (*OIDCClaims, error) {
+	rawIDToken, _ := token.Extra(oidcIDTokenKey).(string)
+	if rawIDToken == "" {
+		return nil, fmt.Errorf("the token has no id_token")
+	}
+	if p.JWKSURI == "" {
+		return nil, fmt.Errorf("the OIDC issuer %s has no jwks_uri to verify the id_token", p.Issuer)
+	}
+	ctx = oidc.ClientContext(ctx, oidcHTTPClient(ctx))
+	keySet := oidc.NewRemoteKeySet(ctx, p.JWKSURI)
+	verifier := oidc.NewVerifier(p.Issuer, keySet, &oidc.Config{ClientID: clientID})
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, errors.Wrapf(err, "verifying the id_token from %s", p.Issuer)
+	}
+	if idToken.AccessTokenHash != "" {
+		err = idToken.VerifyAccessToken(token.AccessToken)
+		if err != nil {
+			return nil, errors.Wrap(err, "verifying the access token hash of the id_token")
+		}
+	}
+	claims := &OIDCClaims{}
+	err = idToken.Claims(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshaling the id_token claims")
+	}
+	return claims, nil
+}
+
+// Username returns the user name to use for the claims
+func (c *OIDCClaims) Username() string {
+	if c.PreferredUsername != "" {
+		return c.PreferredUsername
+	}
+	if c.Email != "" {
+		return c.Email
+	}
+	return c.Subject
+}
+
+// NewPKCEVerifier creates a new random PKCE code verifier
+func NewPKCEVerifier() (string, error) {
+	return randomURLSafeString(32)
+}
+
+// PKCEChallenge returns the S256 PKCE code challenge for the verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomURLSafeString(size int) (string, error) {
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", errors.Wrap(err, "generating random data")
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func postForm(ctx context.Context, u string, data url.Values) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := oidcHTTPClient(ctx).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return body, resp.StatusCode, err
+}
+
+// oidcContext returns a context with the HTTP client used by the oauth2 library
+func oidcContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, oidcHTTPClient(ctx))
+}
+
+func oidcHTTPClient(ctx context.Context) *http.Client {
+	if client, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && client != nil {
+		return client
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
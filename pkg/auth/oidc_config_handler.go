@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// oidcSessionFilePermissions the sessions file contains refresh tokens so is only readable by the user
+	oidcSessionFilePermissions = 0600
+
+	// oidcRefreshTimeout the maximum time spent refreshing the expired OIDC tokens when loading the config
+	oidcRefreshTimeout = 30 * time.Second
+)
+
+// NewOIDCAuthConfigService creates a config service which only loads/saves the OIDC sessions file
+func NewOIDCAuthConfigService(fileName string) (ConfigService, error) {
+	handler, err := NewOIDCAuthConfigHandler(nil, fileName)
+	return NewAuthConfigService(handler), err
+}
+
+// NewOIDCAuthConfigHandler creates a config handler which merges the OIDC sessions stored in the given file into the
+// auth config loaded by the given handler. If the handler is nil only the OIDC sessions are loaded/saved. If the
+// fileName is a simple filename it is stored in the default config directory
+func NewOIDCAuthConfigHandler(handler ConfigHandler, fileName string) (*OIDCAuthConfigHandler, error) {
+	h := &OIDCAuthConfigHandler{
+		handler:  handler,
+		fileName: fileName,
+		shadowed: map[string]UserAuth{},
+		kept:     &AuthConfig{},
+	}
+	if fileName == filepath.Base(fileName) {
+		dir, err := util.ConfigDir()
+		if err != nil {
+			return h, err
+		}
+		h.fileName = filepath.Join(dir, fileName)
+	}
+	return h, nil
+}
+
+// WithOIDCSessions wraps the handler of the given auth config service so that the OIDC sessions stored in the given
+// file are merged into its config, replacing any static tokens for the same servers
+func WithOIDCSessions(svc ConfigService, fileName string) (ConfigService, error) {
+	s, ok := svc.(*AuthConfigService)
+	if !ok {
+		return svc, nil
+	}
+	if _, ok := s.handler.(*OIDCAuthConfigHandler); ok {
+		return svc, nil
+	}
+	h, err := NewOIDCAuthConfigHandler(s.handler, fileName)
+	if err != nil {
+		return svc, err
+	}
+	s.handler = h
+	if s.config != nil {
+		sessions, err := h.loadSessions()
+		if err != nil {
+			return svc, err
+		}
+		h.mergeSessions(s.config, sessions)
+	}
+	return svc, nil
+}
+
+// LoadConfig loads the config from the underlying handler merging in the OIDC sessions
+func (h *OIDCAuthConfigHandler) LoadConfig() (*AuthConfig, error) {
+	sessions, err := h.loadSessions()
+	if err != nil {
+		return nil, err
+	}
+	config := &AuthConfig{}
+	if h.handler != nil {
+		config, err = h.handler.LoadConfig()
+	}
+	if err != nil {
+		if len(sessions.Servers) == 0 {
+			return config, err
+		}
+		log.Logger().Debugf("using only the OIDC sessions as the auth config could not be loaded: %s", err)
+		config = &AuthConfig{}
+	}
+	if config == nil {
+		config = &AuthConfig{}
+	}
+	h.mergeSessions(config, sessions)
+	return config, nil
+}
+
+// SaveConfig saves the OIDC users to the sessions file and the rest of the config via the underlying handler
+func (h *OIDCAuthConfigHandler) SaveConfig(config *AuthConfig) error {
+	delegate := &AuthConfig{
+		DefaultUsername:  config.DefaultUsername,
+		CurrentServer:    config.CurrentServer,
+		PipeLineUsername: config.PipeLineUsername,
+		PipeLineServer:   config.PipeLineServer,
+	}
+	sessions := &AuthConfig{}
+	for _, server := range config.Servers {
+		ds := *server
+		ds.Users = []*UserAuth{}
+		oidcUsers := false
+		for _, user := range server.Users {
+			if !user.IsOIDC() {
+				u := *user
+				ds.Users = append(ds.Users, &u)
+				continue
+			}
+			oidcUsers = true
+			ss := sessions.GetOrCreateServerName(server.URL, server.Name, server.Kind)
+			u := *user
+			ss.Users = append(ss.Users, &u)
+			ss.CurrentUser = u.Username
+			if shadowed, ok := h.shadowed[oidcUserKey(server.URL, user.Username)]; ok {
+				ds.Users = append(ds.Users, &shadowed)
+			}
+		}
+		if !oidcUsers {
+			delegate.Servers = append(delegate.Servers, &ds)
+			continue
+		}
+		if len(ds.Users) == 0 {
+			continue
+		}
+		if ds.GetUserAuth(ds.CurrentUser) == nil {
+			ds.CurrentUser = ds.Users[0].Username
+		}
+		delegate.Servers = append(delegate.Servers, &ds)
+	}
+	for _, server := range h.kept.Servers {
+		ss := sessions.GetOrCreateServerName(server.URL, server.Name, server.Kind)
+		for _, user := range server.Users {
+			if ss.GetUserAuth(user.Username) == nil {
+				ss.Users = append(ss.Users, user)
+			}
+		}
+	}
+	if h.handler != nil {
+		err := h.handler.SaveConfig(delegate)
+		if err != nil {
+			return err
+		}
+	}
+	return h.saveSessions(sessions)
+}
+
+// mergeSessions refreshes any expired OIDC access tokens and adds the OIDC users to the config as the current users of
+// their servers. The static user auths they replace are kept so that they are not lost when the config is saved. The
+// sessions for the Jenkins X UI are only merged when there is no underlying handler
+func (h *OIDCAuthConfigHandler) mergeSessions(config *AuthConfig, sessions *AuthConfig) {
+	ctx, cancel := context.WithTimeout(context.Background(), oidcRefreshTimeout)
+	defer cancel()
+	now := time.Now()
+	refreshedUsers := map[string]*UserAuth{}
+	refreshed := false
+	for _, server := range sessions.Servers {
+		if server.Kind == OIDCUIServerKind && h.handler != nil {
+			h.keepSessions(server, server.Users)
+			continue
+		}
+		for _, user := range server.Users {
+			if user.OIDCTokenExpired(now) {
+				err := refreshOIDCSession(ctx, user, refreshedUsers)
+				if err != nil {
+					log.Logger().Warnf("failed to refresh the OIDC token of user %s for %s so please run 'jx login --oidc %s': %s",
+						user.Username, server.URL, user.OIDCIssuer, err)
+					// lets keep the session so that it is not lost when the config is saved
+					h.keepSessions(server, []*UserAuth{user})
+					continue
+				}
+				refreshed = true
+			}
+			cs := config.GetOrCreateServerName(server.URL, server.Name, server.Kind)
+			u := *user
+			existing := cs.GetUserAuth(user.Username)
+			if existing == nil {
+				cs.Users = append(cs.Users, &u)
+			} else {
+				if !existing.IsOIDC() {
+					h.shadowed[oidcUserKey(server.URL, user.Username)] = *existing
+				}
+				*existing = u
+			}
+			cs.CurrentUser = user.Username
+		}
+	}
+	if refreshed {
+		err := h.saveSessions(sessions)
+		if err != nil {
+			log.Logger().Warnf("failed to save the refreshed OIDC tokens: %s", err)
+		}
+	}
+}
+
+// keepSessions keeps the given sessions of the server which are not merged into the config
+func (h *OIDCAuthConfigHandler) keepSessions(server *AuthServer, users []*UserAuth) {
+	ks := h.kept.GetOrCreateServerName(server.URL, server.Name, server.Kind)
+	if ks.CurrentUser == "" {
+		ks.CurrentUser = server.CurrentUser
+	}
+	for _, user := range users {
+		if ks.GetUserAuth(user.Username) == nil {
+			ks.Users = append(ks.Users, user)
+		}
+	}
+}
+
+// refreshOIDCSession refreshes the expired OIDC token of the user. The same login is stored for each of its servers so
+// the users already refreshed, or which failed to refresh, are indexed by their refresh token so it is only used once
+func refreshOIDCSession(ctx context.Context, user *UserAuth, refreshedUsers map[string]*UserAuth) error {
+	refreshToken := user.RefreshToken
+	if refreshToken == "" {
+		return fmt.Errorf("user %s has no OIDC refresh token", user.Username)
+	}
+	if r, ok := refreshedUsers[refreshToken]; ok {
+		if r == nil {
+			return fmt.Errorf("the OIDC refresh token of user %s already failed to refresh", user.Username)
+		}
+		user.ApiToken = r.ApiToken
+		user.BearerToken = r.BearerToken
+		user.RefreshToken = r.RefreshToken
+		user.Expiry = r.Expiry
+		return nil
+	}
+	err := RefreshOIDCToken(ctx, user)
+	if err != nil {
+		refreshedUsers[refreshToken] = nil
+		return err
+	}
+	refreshedUsers[refreshToken] = user
+	return nil
+}
+
+func (h *OIDCAuthConfigHandler) loadSessions() (*AuthConfig, error) {
+	sessions := &AuthConfig{}
+	exists, err := util.FileExists(h.fileName)
+	if err != nil {
+		return sessions, errors.Wrapf(err, "checking if the OIDC sessions file exists %s", h.fileName)
+	}
+	if !exists {
+		return sessions, nil
+	}
+	data, err := ioutil.ReadFile(h.fileName)
+	if err != nil {
+		return sessions, errors.Wrapf(err, "loading the OIDC sessions from file %q", h.fileName)
+	}
+	err = yaml.Unmarshal(data, sessions)
+	if err != nil {
+		return sessions, errors.Wrapf(err, "unmarshaling the OIDC sessions YAML from file %q", h.fileName)
+	}
+	return sessions, nil
+}
+
+func (h *OIDCAuthConfigHandler) saveSessions(sessions *AuthConfig) error {
+	if h.fileName == "" {
+		return fmt.Errorf("no filename defined for the OIDC sessions")
+	}
+	data, err := yaml.Marshal(sessions)
+	if err != nil {
+		return errors.Wrap(err, "marshaling the OIDC sessions")
+	}
+	err = ioutil.WriteFile(h.fileName, data, oidcSessionFilePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "saving the OIDC sessions to file %q", h.fileName)
+	}
+	return nil
+}
+
+func oidcUserKey(serverURL string, username string) string {
+	return serverURL + "\n" + username
+}
@@ -0,0 +1,378 @@
+// +build unit
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// fakeOIDCIssuer a minimal OIDC provider supporting the authorization code flow with PKCE and refresh tokens
+type fakeOIDCIssuer struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	challenge     string
+	refreshes     int
+	refreshFailed bool
+}
+
+func newFakeOIDCIssuer(t *testing.T) *fakeOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	issuer := &fakeOIDCIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidcDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, &OIDCProvider{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, &jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{{Key: &issuer.key.PublicKey, KeyID: "test", Algorithm: string(jose.RS256), Use: "sig"}},
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+		issuer.challenge = query.Get("code_challenge")
+		redirect := fmt.Sprintf("%s?code=abc&state=%s", query.Get("redirect_uri"), url.QueryEscape(query.Get("state")))
+		http.Redirect(w, r, redirect, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			assert.Equal(t, "abc", r.PostForm.Get("code"))
+			assert.Equal(t, issuer.challenge, PKCEChallenge(r.PostForm.Get("code_verifier")), "the PKCE verifier should match the challenge")
+			writeJSON(t, w, map[string]interface{}{
+				"access_token":  "access-1",
+				"refresh_token": "refresh-1",
+				"token_type":    "Bearer",
+				"expires_in":    3600,
+				"id_token":      issuer.idToken(t, issuer.key, map[string]interface{}{"sub": "123", "preferred_username": "jstrachan"}),
+			})
+		case "refresh_token":
+			if issuer.refreshFailed {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+			issuer.refreshes++
+			assert.Equal(t, "refresh-1", r.PostForm.Get("refresh_token"))
+			writeJSON(t, w, map[string]interface{}{
+				"access_token": fmt.Sprintf("access-%d", issuer.refreshes+1),
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+			})
+		default:
+			http.Error(w, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+		}
+	})
+	issuer.server = httptest.NewServer(mux)
+	return issuer
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(value))
+}
+
+// idToken returns an ID token for the default client signed with the given key
+func (i *fakeOIDCIssuer) idToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	claims["iss"] = i.server.URL
+	claims["aud"] = OIDCDefaultClientID
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: "test"}}, nil)
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed, err := signer.Sign(payload)
+	require.NoError(t, err)
+	token, err := signed.CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func TestOIDCLoopbackLogin(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	defer issuer.server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, err := DiscoverOIDCProvider(ctx, issuer.server.URL)
+	require.NoError(t, err)
+
+	browser := func(u string) error {
+		// the fake issuer redirects straight back to the loopback callback
+		resp, err := http.Get(u)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, err = ioutil.ReadAll(resp.Body)
+		return err
+	}
+	token, err := provider.LoopbackLogin(ctx, OIDCDefaultClientID, OIDCDefaultScopes, browser)
+	require.NoError(t, err)
+	assert.Equal(t, "access-1", token.AccessToken)
+	assert.Equal(t, "refresh-1", token.RefreshToken)
+
+	claims, err := provider.ParseOIDCClaims(ctx, OIDCDefaultClientID, token)
+	require.NoError(t, err)
+	assert.Equal(t, "jstrachan", claims.Username())
+
+	_, err = provider.ParseOIDCClaims(ctx, "other", token)
+	assert.Error(t, err, "the audience of the id_token should be verified")
+}
+
+func TestParseOIDCClaimsVerifiesSignature(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	defer issuer.server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, err := DiscoverOIDCProvider(ctx, issuer.server.URL)
+	require.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	token := (&oauth2.Token{AccessToken: "access-1"}).WithExtra(map[string]interface{}{
+		oidcIDTokenKey: issuer.idToken(t, otherKey, map[string]interface{}{"sub": "123", "preferred_username": "jstrachan"}),
+	})
+	_, err = provider.ParseOIDCClaims(ctx, OIDCDefaultClientID, token)
+	assert.Error(t, err, "an id_token not signed by the issuer should be rejected")
+
+	token = (&oauth2.Token{AccessToken: "access-1"}).WithExtra(map[string]interface{}{
+		oidcIDTokenKey: issuer.idToken(t, issuer.key, map[string]interface{}{"sub": "123", "preferred_username": "jstrachan"}),
+	})
+	claims, err := provider.ParseOIDCClaims(ctx, OIDCDefaultClientID, token)
+	require.NoError(t, err)
+	assert.Equal(t, "123", claims.Subject)
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	defer issuer.server.Close()
+
+	_, err := DiscoverOIDCProvider(context.Background(), issuer.server.URL+"/other")
+	assert.Error(t, err)
+}
+
+func TestOIDCAuthConfigHandler(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	defer issuer.server.Close()
+
+	dir, err := ioutil.TempDir("", "test-oidc-auth-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, OIDCAuthConfigFile)
+
+	gitServer := "https://git.example.com"
+	expired := time.Now().Add(-time.Hour).UTC()
+	sessions := &AuthConfig{}
+	sessions.SetUserAuth(gitServer, &UserAuth{
+		Username:     "jstrachan",
+		ApiToken:     "access-1",
+		BearerToken:  "access-1",
+		RefreshToken: "refresh-1",
+		OIDCIssuer:   issuer.server.URL,
+		OIDCClientID: OIDCDefaultClientID,
+		Expiry:       &expired,
+	})
+	h, err := NewOIDCAuthConfigHandler(newMemoryAuthHandler(), fileName)
+	require.NoError(t, err)
+	require.NoError(t, h.saveSessions(sessions))
+
+	delegate := &MemoryAuthConfigHandler{}
+	delegate.config.SetUserAuth(gitServer, &UserAuth{Username: "jstrachan", ApiToken: "shared-pat"})
+	delegate.config.SetUserAuth("https://github.com", &UserAuth{Username: "bot", ApiToken: "github-pat"})
+
+	svc, err := WithOIDCSessions(NewAuthConfigService(delegate), fileName)
+	require.NoError(t, err)
+	config, err := svc.LoadConfig()
+	require.NoError(t, err)
+
+	user := config.GetServer(gitServer).CurrentAuth()
+	require.NotNil(t, user)
+	assert.Equal(t, "access-2", user.ApiToken, "the expired access token should be refreshed")
+	assert.Equal(t, "access-2", user.BearerToken)
+	assert.Equal(t, 1, issuer.refreshes)
+	assert.Equal(t, "github-pat", config.GetServer("https://github.com").CurrentAuth().ApiToken)
+
+	saved, err := h.loadSessions()
+	require.NoError(t, err)
+	assert.Equal(t, "access-2", saved.GetServer(gitServer).CurrentAuth().ApiToken, "the refreshed token should be saved")
+
+	_, err = svc.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 1, issuer.refreshes, "a valid access token should not be refreshed")
+
+	err = svc.SaveConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "shared-pat", delegate.config.GetServer(gitServer).CurrentAuth().ApiToken, "the OIDC session should not be saved to the delegate")
+	assert.Empty(t, delegate.config.GetServer(gitServer).CurrentAuth().RefreshToken)
+
+	saved, err = h.loadSessions()
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-1", saved.GetServer(gitServer).CurrentAuth().RefreshToken)
+
+	info, err := os.Stat(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(oidcSessionFilePermissions), info.Mode().Perm())
+}
+
+func TestOIDCAuthConfigHandlerKeepsFailedSessions(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	defer issuer.server.Close()
+	issuer.refreshFailed = true
+
+	dir, err := ioutil.TempDir("", "test-oidc-auth-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, OIDCAuthConfigFile)
+
+	gitServer := "https://git.example.com"
+	sessions := &AuthConfig{}
+	sessions.SetUserAuth(gitServer, &UserAuth{
+		Username:     "jstrachan",
+		RefreshToken: "refresh-1",
+		OIDCIssuer:   issuer.server.URL,
+		OIDCClientID: OIDCDefaultClientID,
+	})
+	svc, err := NewOIDCAuthConfigService(fileName)
+	require.NoError(t, err)
+	h := svc.(*AuthConfigService).handler.(*OIDCAuthConfigHandler)
+	require.NoError(t, h.saveSessions(sessions))
+
+	config, err := svc.LoadConfig()
+	require.NoError(t, err)
+	assert.Nil(t, config.GetServer(gitServer), "sessions which fail to refresh should not be used")
+
+	require.NoError(t, svc.SaveConfig())
+	saved, err := h.loadSessions()
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-1", saved.GetServer(gitServer).CurrentAuth().RefreshToken, "the failed session should be kept")
+}
+
+func TestOIDCAuthConfigHandlerRefreshesSharedSessionsOnce(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	defer issuer.server.Close()
+
+	dir, err := ioutil.TempDir("", "test-oidc-auth-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, OIDCAuthConfigFile)
+
+	servers := []string{"https://git.example.com", "https://jx.example.com"}
+	expired := time.Now().Add(-time.Hour).UTC()
+	sessions := &AuthConfig{}
+	for _, server := range servers {
+		sessions.SetUserAuth(server, &UserAuth{
+			Username:     "jstrachan",
+			ApiToken:     "access-1",
+			BearerToken:  "access-1",
+			RefreshToken: "refresh-1",
+			OIDCIssuer:   issuer.server.URL,
+			OIDCClientID: OIDCDefaultClientID,
+			Expiry:       &expired,
+		})
+	}
+	sessions.SetUserAuth("https://other.example.com", &UserAuth{
+		Username:     "jstrachan",
+		OIDCIssuer:   issuer.server.URL,
+		OIDCClientID: OIDCDefaultClientID,
+	})
+	svc, err := NewOIDCAuthConfigService(fileName)
+	require.NoError(t, err)
+	h := svc.(*AuthConfigService).handler.(*OIDCAuthConfigHandler)
+	require.NoError(t, h.saveSessions(sessions))
+
+	config, err := svc.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 1, issuer.refreshes, "the refresh token shared by the servers should only be used once")
+	for _, server := range servers {
+		assert.Equal(t, "access-2", config.GetServer(server).CurrentAuth().ApiToken)
+	}
+	assert.Nil(t, config.GetServer("https://other.example.com"), "a session without a refresh token cannot be refreshed")
+
+	_, err = svc.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 1, issuer.refreshes, "the refreshed tokens should not be refreshed again")
+	assert.Len(t, h.kept.GetServer("https://other.example.com").Users, 1)
+}
+
+func TestOIDCAuthConfigHandlerKeepsUISessions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-oidc-auth-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, OIDCAuthConfigFile)
+
+	gitServer := "https://git.example.com"
+	uiServer := "https://jx.example.com"
+	valid := time.Now().Add(time.Hour).UTC()
+	sessions := &AuthConfig{}
+	for _, server := range []string{gitServer, uiServer} {
+		sessions.SetUserAuth(server, &UserAuth{
+			Username:     "jstrachan",
+			ApiToken:     "access-1",
+			BearerToken:  "access-1",
+			RefreshToken: "refresh-1",
+			OIDCIssuer:   "https://sso.example.com",
+			OIDCClientID: OIDCDefaultClientID,
+			Expiry:       &valid,
+		})
+	}
+	sessions.GetServer(uiServer).Kind = OIDCUIServerKind
+	h, err := NewOIDCAuthConfigHandler(nil, fileName)
+	require.NoError(t, err)
+	require.NoError(t, h.saveSessions(sessions))
+
+	svc, err := WithOIDCSessions(NewAuthConfigService(&MemoryAuthConfigHandler{}), fileName)
+	require.NoError(t, err)
+	config, err := svc.LoadConfig()
+	require.NoError(t, err)
+	assert.NotNil(t, config.GetServer(gitServer))
+	assert.Nil(t, config.GetServer(uiServer), "the Jenkins X UI sessions should not be merged into the git auth config")
+
+	require.NoError(t, svc.SaveConfig())
+	saved, err := h.loadSessions()
+	require.NoError(t, err)
+	require.NotNil(t, saved.GetServer(uiServer), "the Jenkins X UI session should be kept")
+	assert.Equal(t, OIDCUIServerKind, saved.GetServer(uiServer).Kind)
+
+	uiSvc, err := NewOIDCAuthConfigService(fileName)
+	require.NoError(t, err)
+	config, err = uiSvc.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "access-1", config.GetServer(uiServer).CurrentAuth().BearerToken)
+}
+
+func TestPKCEChallenge(t *testing.T) {
+	// the example from RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := NewPKCEVerifier()
+	require.NoError(t, err)
+	assert.Len(t, verifier, 43)
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/jenkins-x/jx/v2/pkg/secreturl"
 	"github.com/jenkins-x/jx/v2/pkg/vault"
 	"k8s.io/client-go/kubernetes"
@@ -25,6 +27,15 @@ type UserAuth struct {
 	// GithubAppOwner if using GitHub Apps this represents the owner organisation/user which owns this token.
 	// we need to maintain a different token per owner
 	GithubAppOwner string `json:"appOwner,omitempty"`
+
+	// RefreshToken the OIDC refresh token used to renew the access token if the user logged in via `jx login --oidc`
+	RefreshToken string `json:"refreshtoken,omitempty"`
+	// OIDCIssuer the issuer URL of the OIDC provider which issued the tokens
+	OIDCIssuer string `json:"oidcissuer,omitempty"`
+	// OIDCClientID the OIDC client ID used to refresh the tokens
+	OIDCClientID string `json:"oidcclientid,omitempty"`
+	// Expiry when the OIDC access token expires
+	Expiry *time.Time `json:"expiry,omitempty"`
 }
 
 type AuthConfig struct {
@@ -66,6 +77,17 @@ type ConfigMapVaultConfigHandler struct {
 	secretURLClient secreturl.Client
 }
 
+// OIDCAuthConfigHandler stores the OIDC sessions of users who logged in via `jx login --oidc` in a local file and
+// merges them into the auth config loaded by another handler, refreshing any expired access tokens
+type OIDCAuthConfigHandler struct {
+	handler  ConfigHandler
+	fileName string
+	shadowed map[string]UserAuth
+	// kept the sessions which are not merged into the config, as they failed to refresh or are for the Jenkins X UI
+	// rather than the servers of the underlying handler, so that they are not lost when the config is saved
+	kept *AuthConfig
+}
+
 // KubeAuthConfigHandler loads/save the auth config from/into a kubernetes secret
 type KubeAuthConfigHandler struct {
 	client      kubernetes.Interface
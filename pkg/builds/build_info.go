@@ -24,23 +24,23 @@ type BaseBuildInfo interface {
 }
 
 type BuildPodInfo struct {
-	PodName           string
-	Name              string
-	Organisation      string
-	Repository        string
-	Branch            string
-	Build             string
-	Context           string
-	BuildNumber       int
-	Pipeline          string
-	LastCommitSHA     string
-	LastCommitMessage string
-	LastCommitURL     string
-	GitURL            string
-	FirstStepImage    string
-	CreatedTime       time.Time
-	GitInfo           *gits.GitRepository
-	Pod               *corev1.Pod
+	PodName           string              `json:"podName"`
+	Name              string              `json:"name"`
+	Organisation      string              `json:"organisation"`
+	Repository        string              `json:"repository"`
+	Branch            string              `json:"branch"`
+	Build             string              `json:"build"`
+	Context           string              `json:"context,omitempty"`
+	BuildNumber       int                 `json:"buildNumber"`
+	Pipeline          string              `json:"pipeline"`
+	LastCommitSHA     string              `json:"lastCommitSHA,omitempty"`
+	LastCommitMessage string              `json:"lastCommitMessage,omitempty"`
+	LastCommitURL     string              `json:"lastCommitURL,omitempty"`
+	GitURL            string              `json:"gitURL,omitempty"`
+	FirstStepImage    string              `json:"firstStepImage,omitempty"`
+	CreatedTime       time.Time           `json:"createdTime"`
+	GitInfo           *gits.GitRepository `json:"-"`
+	Pod               *corev1.Pod         `json:"-"`
 }
 
 // GetBuild gets the build identifier
@@ -105,6 +105,8 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PreviewActivityStep":                 schema_pkg_apis_jenkinsio_v1_PreviewActivityStep(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PreviewGitSpec":                      schema_pkg_apis_jenkinsio_v1_PreviewGitSpec(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteActivityStep":                 schema_pkg_apis_jenkinsio_v1_PromoteActivityStep(ref),
+		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteApplication":                  schema_pkg_apis_jenkinsio_v1_PromoteApplication(ref),
+		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteCanaryStep":                   schema_pkg_apis_jenkinsio_v1_PromoteCanaryStep(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromotePullRequestStep":              schema_pkg_apis_jenkinsio_v1_PromotePullRequestStep(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteUpdateStep":                   schema_pkg_apis_jenkinsio_v1_PromoteUpdateStep(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteWorkflowStep":                 schema_pkg_apis_jenkinsio_v1_PromoteWorkflowStep(ref),
@@ -118,6 +120,8 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ReleaseList":                         schema_pkg_apis_jenkinsio_v1_ReleaseList(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ReleaseSpec":                         schema_pkg_apis_jenkinsio_v1_ReleaseSpec(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ReleaseStatus":                       schema_pkg_apis_jenkinsio_v1_ReleaseStatus(ref),
+		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.RemoteApplicationStatus":             schema_pkg_apis_jenkinsio_v1_RemoteApplicationStatus(ref),
+		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.RemoteEnvironmentStatus":             schema_pkg_apis_jenkinsio_v1_RemoteEnvironmentStatus(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ReplaceableMapOfStringContextPolicy": schema_pkg_apis_jenkinsio_v1_ReplaceableMapOfStringContextPolicy(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ReplaceableMapOfStringString":        schema_pkg_apis_jenkinsio_v1_ReplaceableMapOfStringString(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ReplaceableSliceOfExternalPlugins":   schema_pkg_apis_jenkinsio_v1_ReplaceableSliceOfExternalPlugins(ref),
@@ -1773,10 +1777,17 @@ func schema_pkg_apis_jenkinsio_v1_EnvironmentStatus(ref common.ReferenceCallback
 							Format: "",
 						},
 					},
+					"remote": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Remote is the status reported back by the environment controller agent running inside a remote cluster",
+							Ref:         ref("github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.RemoteEnvironmentStatus"),
+						},
+					},
 				},
 			},
 		},
-		Dependencies: []string{},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.RemoteEnvironmentStatus"},
 	}
 }
 
@@ -4441,11 +4452,141 @@ func schema_pkg_apis_jenkinsio_v1_PromoteActivityStep(ref common.ReferenceCallba
 							Format: "",
 						},
 					},
+					"applications": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Applications are the applications promoted together as a release train",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteApplication"),
+									},
+								},
+							},
+						},
+					},
+					"canary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Canary is the progressive delivery of the promotion via a Flagger Canary",
+							Ref:         ref("github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteCanaryStep"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteApplication", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteCanaryStep", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromotePullRequestStep", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteUpdateStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_PromoteApplication(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PromoteApplication is an application version which is promoted as part of a release train",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_PromoteCanaryStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PromoteCanaryStep is the step for the progressive delivery of a promotion by a Flagger Canary analysis",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"description": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"startedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"canary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Canary is the name of the Flagger Canary resource",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase is the phase of the Canary analysis",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"weight": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Weight is the percentage of traffic routed to the new version",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxWeight": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxWeight is the percentage of traffic at which the new version is promoted",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"iterations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Iterations is the number of iterations of the Canary analysis",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"failedChecks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailedChecks is the number of failed metric checks of the Canary analysis",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromotePullRequestStep", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PromoteUpdateStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -5041,6 +5182,130 @@ func schema_pkg_apis_jenkinsio_v1_ReleaseStatus(ref common.ReferenceCallback) co
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_RemoteApplicationStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "RemoteApplicationStatus is the status of an application deployed in a remote cluster",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"deployment": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"replicas": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"readyReplicas": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"healthy": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"boolean"},
+							Format: "",
+						},
+					},
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_RemoteEnvironmentStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "RemoteEnvironmentStatus is the status of an Environment which is deployed in a remote cluster",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"syncStatus": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncStatus the status of the last environment pipeline in the remote cluster",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"revision": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Revision the git commit SHA of the environment repository last applied to the remote cluster",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message an optional message describing the sync status",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"cluster": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Cluster the name of the remote cluster reporting the status",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastSyncTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastSyncTimestamp the time the last environment pipeline completed",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"lastReportTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastReportTimestamp the time the agent last reported the status",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"applications": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Applications the applications deployed in the remote environment",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.RemoteApplicationStatus"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.RemoteApplicationStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_ReplaceableMapOfStringContextPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
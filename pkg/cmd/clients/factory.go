@@ -367,6 +367,18 @@ func (f *factory) createAuthConfigServiceFile(fileName string, serverKind string
 // CreateAuthConfigService creates a new service which loads/saves the auth config from/to different sources depending
 // on the current secrets location and cluster context. The sources can be vault, kubernetes secrets or local file.
 func (f *factory) CreateAuthConfigService(fileName string, namespace string,
+	serverKind string, serviceKind string) (auth.ConfigService, error) {
+	authService, err := f.createAuthConfigService(fileName, namespace, serverKind, serviceKind)
+	if err != nil {
+		return nil, err
+	}
+	if serverKind != kube.ValueKindGit {
+		return authService, nil
+	}
+	return f.withOIDCSessions(authService)
+}
+
+func (f *factory) createAuthConfigService(fileName string, namespace string,
 	serverKind string, serviceKind string) (auth.ConfigService, error) {
 	if f.SecretsLocation() == secrets.VaultLocationKind {
 		if authService, err := f.createAuthConfigServiceVault(fileName, namespace); err == nil {
@@ -396,13 +408,26 @@ func (f *factory) CreateAuthConfigService(fileName string, namespace string,
 func (f *factory) CreateLocalGitAuthConfigService() (auth.ConfigService, error) {
 
 	if authService, err := f.createAuthConfigServiceFile(auth.GitAuthConfigFile, kube.ValueKindGit); err == nil {
-		return authService, nil
+		return f.withOIDCSessions(authService)
 	}
 	log.Logger().Debugf("No auth config found in file %s", auth.GitAuthConfigFile)
 
 	return nil, fmt.Errorf("no auth config found for secret %q", auth.GitAuthConfigFile)
 }
 
+// withOIDCSessions merges the OIDC sessions of users who logged in via `jx login --oidc` into the git auth config when
+// running outside of a cluster. The sessions for the Jenkins X UI are not merged as they are used by `jx ui` instead
+func (f *factory) withOIDCSessions(authService auth.ConfigService) (auth.ConfigService, error) {
+	if cluster.IsInCluster() {
+		return authService, nil
+	}
+	authService, err := auth.WithOIDCSessions(authService, auth.OIDCAuthConfigFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading the OIDC sessions")
+	}
+	return authService, nil
+}
+
 // SecretsLocation indicates the location where the secrets are stored
 func (f *factory) SecretsLocation() secrets.SecretsLocationKind {
 	client, namespace, err := f.CreateKubeClient()
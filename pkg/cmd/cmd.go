@@ -53,6 +53,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/upgrade"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/add"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/login"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/namespace"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/promote"
 
@@ -156,6 +157,7 @@ func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWr
 				NewCmdCompletion(commonOpts),
 				NewCmdContext(commonOpts),
 				NewCmdEnvironment(commonOpts),
+				login.NewCmdLogin(commonOpts),
 				NewCmdTeam(commonOpts),
 				namespace.NewCmdNamespace(commonOpts),
 				NewCmdPrompt(commonOpts),
@@ -46,6 +46,8 @@ func NewCmdController(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdControllerBuild(commonOpts))
 	cmd.AddCommand(NewCmdControllerBuildNumbers(commonOpts))
 	cmd.AddCommand(NewCmdControllerEnvironment(commonOpts))
+	cmd.AddCommand(NewCmdControllerMetrics(commonOpts))
+	cmd.AddCommand(NewCmdControllerPipelineEvents(commonOpts))
 	cmd.AddCommand(pipeline.NewCmdControllerPipelineRunner(commonOpts))
 	cmd.AddCommand(NewCmdControllerRole(commonOpts))
 	cmd.AddCommand(NewCmdControllerTeam(commonOpts))
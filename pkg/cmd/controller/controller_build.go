@@ -17,6 +17,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/logs"
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
 	"k8s.io/apimachinery/pkg/fields"
 
 	"github.com/jenkins-x/jx/v2/pkg/collector"
@@ -24,6 +25,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/tekton"
 	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
 	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/v2/pkg/tracing"
 	"github.com/pkg/errors"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
 	"k8s.io/client-go/kubernetes"
@@ -34,12 +36,14 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/spf13/cobra"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
+	knativeapis "knative.dev/pkg/apis"
 
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 )
@@ -59,6 +63,10 @@ type ControllerBuildOptions struct {
 	TargetURLTemplate   string
 	FailIfNoGitProvider bool
 	JobURLBase          string
+	MetricsPort         int
+	MetricsBindAddress  string
+	TraceEndpoint       string
+	TraceFile           string
 
 	EnvironmentCache *kube.EnvironmentNamespaceCache
 
@@ -69,12 +77,15 @@ type ControllerBuildOptions struct {
 
 	// private field to record whether the lighthouse-foghorn deployment is present - if so, we skip status reporting
 	foghornPresent bool
+
+	pipelineMetrics *metrics.PipelineCollector
+	pipelineTracer  *tracing.PipelineTracer
 }
 
-// LongTermStorageLogWriter is an implementation of logs.LogWriter that saves the obtained log lines
-// and sends them to a Collector when the channel is closed
+// LongTermStorageLogWriter is an implementation of logs.LogWriter that saves the obtained log lines, along with the
+// log of each step and an index of the steps, and sends them to a Collector when the channel is closed
 type LongTermStorageLogWriter struct {
-	data       []byte
+	archive    *logs.LogArchive
 	kubeClient kubernetes.Interface
 	logMasker  *kube.LogMasker
 }
@@ -97,9 +108,10 @@ func (w *LongTermStorageLogWriter) StreamLog(lch <-chan logs.LogLine, ech <-chan
 			if w.logMasker != nil && l.ShouldMask {
 				l.Line = w.logMasker.MaskLog(l.Line)
 			}
-			line := []byte(l.Line)
-			line = append(line, '\n')
-			w.data = append(w.data, line...)
+			err := w.archive.Add(l)
+			if err != nil {
+				return err
+			}
 		case err := <-ech:
 			return err
 		}
@@ -137,6 +149,10 @@ func NewCmdControllerBuild(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace to watch or defaults to the current namespace")
 	cmd.Flags().BoolVarP(&options.InitGitCredentials, "git-credentials", "", false, "If enable then lets run the 'jx step git credentials' step to initialise git credentials")
 	cmd.Flags().BoolVarP(&options.FailIfNoGitProvider, "fail-on-git-provider-error", "", false, "If enable then lets terminate quickly if we cannot create a git provider")
+	cmd.Flags().IntVarP(&options.MetricsPort, "metrics-port", "", 0, "The TCP port to serve the Prometheus pipeline metrics on. The metrics are disabled by default")
+	cmd.Flags().StringVarP(&options.MetricsBindAddress, "metrics-bind", "", "", "The interface address to bind the metrics server to (by default, will listen on all interfaces/addresses)")
+	cmd.Flags().StringVarP(&options.TraceEndpoint, "trace-endpoint", "", "", "The OTLP/HTTP endpoint of the OpenTelemetry collector to export the pipeline traces to. Defaults to $"+tracing.EnvOTLPTracesEndpoint+" as is or $"+tracing.EnvOTLPEndpoint+" with /v1/traces appended")
+	cmd.Flags().StringVarP(&options.TraceFile, "trace-file", "", "", "The file to append the pipeline traces to as OTLP JSON lines, which is useful for testing")
 
 	// optional git reporting flags
 	cmd.Flags().StringVarP(&options.TargetURLTemplate, "target-url-template", "", "", "The Go template for generating the target URL of pipeline logs/views if git reporting is enabled. If unspecified, a default will be used based on `--job-url-base`.")
@@ -208,6 +224,15 @@ func (o *ControllerBuildOptions) Run() error {
 		log.Logger().Warnf("failed to label the legacy PipelineActivity resources: %s", err)
 	}
 
+	if o.MetricsPort > 0 {
+		err = o.startMetricsServer()
+		if err != nil {
+			return errors.Wrap(err, "starting the metrics server")
+		}
+	}
+	stop := make(chan struct{})
+	o.startTracing(ns, stop)
+
 	pod := &corev1.Pod{}
 	log.Logger().Infof("Watching for Pods in namespace %s", util.ColorInfo(ns))
 	listWatch := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "pods", ns, fields.Everything())
@@ -228,9 +253,37 @@ func (o *ControllerBuildOptions) Run() error {
 		},
 	)
 
-	stop := make(chan struct{})
 	go controller.Run(stop)
 
+	o.revokeOrphanedDynamicSecrets(kubeClient, ns)
+
+	pipelineRun := &v1alpha1.PipelineRun{}
+	log.Logger().Infof("Watching for PipelineRuns in namespace %s", util.ColorInfo(ns))
+	prListWatch := cache.NewListWatchFromClient(tektonClient.TektonV1alpha1().RESTClient(), "pipelineruns", ns, fields.Everything())
+	_, prController := cache.NewInformer(
+		prListWatch,
+		pipelineRun,
+		time.Minute*10,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.onPipelineRun(obj, kubeClient, ns)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				o.onPipelineRun(newObj, kubeClient, ns)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				if pr, ok := obj.(*v1alpha1.PipelineRun); ok {
+					o.revokeDynamicSecrets(kubeClient, ns, pr.Name)
+				}
+			},
+		},
+	)
+
+	go prController.Run(stop)
+
 	// Wait forever
 	select {}
 }
@@ -297,6 +350,10 @@ func (o *ControllerBuildOptions) onPipelinePod(obj interface{}, kubeClient kuber
 					log.Logger().Warnf("Error getting PipelineRun for name %s: %s", prName, err)
 					return
 				}
+				// lets lease the dynamic secrets once the first pod of the PipelineRun is scheduled as it cannot start without them
+				if pr.Annotations[tekton.AnnotationDynamicSecretRefs] != "" && pod.Spec.NodeName != "" && !isPipelineRunDone(pr) {
+					o.leaseDynamicSecrets(kubeClient, ns, pr)
+				}
 				// Get the Pod for this PipelineRun
 				podList, err := kubeClient.CoreV1().Pods(ns).List(metav1.ListOptions{
 					LabelSelector: builds.LabelPipelineRunName + "=" + prName,
@@ -322,11 +379,13 @@ func (o *ControllerBuildOptions) onPipelinePod(obj interface{}, kubeClient kuber
 				}
 
 				log.Logger().Debugf("Found pipeline run %s", pri.Name)
+				o.observePipelineRun(pr, pri, podList.Items)
 
 				activities := jxClient.JenkinsV1().PipelineActivities(ns)
 				key := o.createPromoteStepActivityKeyFromRun(pri)
 				if key != nil {
 					name := ""
+					var activity *v1.PipelineActivity
 					err := util.Retry(time.Second*20, func() error {
 						a, created, err := key.GetOrCreate(jxClient, ns)
 						if err != nil {
@@ -346,10 +405,13 @@ func (o *ControllerBuildOptions) onPipelinePod(obj interface{}, kubeClient kuber
 								return err
 							}
 						}
+						activity = a
 						return nil
 					})
 					if err != nil {
 						log.Logger().Warnf("Failed to update PipelineActivities %s: %s", name, err)
+					} else {
+						o.tracePipelineRun(pri, activity)
 					}
 				}
 			} else {
@@ -359,6 +421,85 @@ func (o *ControllerBuildOptions) onPipelinePod(obj interface{}, kubeClient kuber
 	}
 }
 
+// onPipelineRun revokes the leases of the dynamic secrets of a PipelineRun once it is done
+func (o *ControllerBuildOptions) onPipelineRun(obj interface{}, kubeClient kubernetes.Interface, ns string) {
+	pr, ok := obj.(*v1alpha1.PipelineRun)
+	if !ok {
+		log.Logger().Infof("Object is not a PipelineRun %#v", obj)
+		return
+	}
+	if pr.Annotations[tekton.AnnotationDynamicSecretRefs] != "" && isPipelineRunDone(pr) {
+		o.revokeDynamicSecrets(kubeClient, ns, pr.Name)
+	}
+}
+
+// isPipelineRunDone returns true if the PipelineRun has succeeded or failed
+func isPipelineRunDone(pr *v1alpha1.PipelineRun) bool {
+	cond := pr.Status.GetCondition(knativeapis.ConditionSucceeded)
+	return cond != nil && !cond.IsUnknown()
+}
+
+// leaseDynamicSecrets leases the dynamic secrets of a PipelineRun unless they have already been leased
+func (o *ControllerBuildOptions) leaseDynamicSecrets(kubeClient kubernetes.Interface, ns string, pr *v1alpha1.PipelineRun) {
+	_, err := kubeClient.CoreV1().Secrets(ns).Get(tekton.DynamicSecretName(pr.Name), metav1.GetOptions{})
+	if err == nil {
+		return
+	}
+	if !k8sErrors.IsNotFound(err) {
+		log.Logger().Warnf("Failed to get the dynamic secrets of PipelineRun %s: %s", pr.Name, err)
+		return
+	}
+	vaultClient, err := o.SystemVaultClient(ns)
+	if err != nil {
+		log.Logger().Warnf("Failed to create the vault client to lease the dynamic secrets of PipelineRun %s: %s", pr.Name, err)
+		return
+	}
+	err = tekton.LeaseDynamicSecrets(vaultClient, kubeClient, ns, pr)
+	if err != nil {
+		log.Logger().Warnf("Failed to lease the dynamic secrets of PipelineRun %s: %s", pr.Name, err)
+	}
+}
+
+// revokeDynamicSecrets revokes the leases of any dynamic secrets of a completed or deleted PipelineRun
+func (o *ControllerBuildOptions) revokeDynamicSecrets(kubeClient kubernetes.Interface, ns string, prName string) {
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(tekton.DynamicSecretName(prName), metav1.GetOptions{})
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			log.Logger().Warnf("Failed to get the dynamic secrets of PipelineRun %s: %s", prName, err)
+		}
+		return
+	}
+	if len(tekton.DynamicSecretLeases(secret)) == 0 && len(secret.Finalizers) == 0 {
+		return
+	}
+	vaultClient, err := o.SystemVaultClient(ns)
+	if err != nil {
+		log.Logger().Warnf("Failed to create the vault client to revoke the dynamic secrets of PipelineRun %s: %s", prName, err)
+		return
+	}
+	err = tekton.RevokeDynamicSecrets(vaultClient, kubeClient, ns, prName)
+	if err != nil {
+		log.Logger().Warnf("Failed to revoke the dynamic secrets of PipelineRun %s: %s", prName, err)
+	}
+}
+
+// revokeOrphanedDynamicSecrets revokes the leases of the dynamic secrets whose PipelineRun was deleted while the
+// controller was not running
+func (o *ControllerBuildOptions) revokeOrphanedDynamicSecrets(kubeClient kubernetes.Interface, ns string) {
+	secrets, err := kubeClient.CoreV1().Secrets(ns).List(metav1.ListOptions{
+		LabelSelector: tekton.LabelDynamicSecretPipelineRun,
+	})
+	if err != nil {
+		log.Logger().Warnf("Failed to list the dynamic secrets in namespace %s: %s", ns, err)
+		return
+	}
+	for _, secret := range secrets.Items {
+		if secret.DeletionTimestamp != nil {
+			o.revokeDynamicSecrets(kubeClient, ns, secret.Labels[tekton.LabelDynamicSecretPipelineRun])
+		}
+	}
+}
+
 // createPromoteStepActivityKey deduces the pipeline metadata from the build pod
 func (o *ControllerBuildOptions) createPromoteStepActivityKey(buildName string, pod *corev1.Pod) *kube.PromoteStepActivityKey {
 
@@ -615,10 +756,7 @@ func (o *ControllerBuildOptions) updatePipelineActivity(kubeClient kubernetes.In
 					log.Logger().Warnf("No GitURL on PipelineActivity %s", activity.Name)
 				}
 			}
-			masker, err := kube.NewLogMasker(kubeClient, ns)
-			if err != nil {
-				log.Logger().Warnf("Failed to create LogMasker in namespace %s: %s", ns, err.Error())
-			}
+			masker := o.createLogMasker(kubeClient, ns, pod)
 			logURL, err := o.generateBuildLogURL(podInterface, ns, activity, buildName, pod, location, settings, o.InitGitCredentials, masker)
 			if err != nil {
 				log.Logger().Warnf("%s", err)
@@ -748,10 +886,7 @@ func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kuberne
 					}
 				}
 
-				masker, err := kube.NewLogMasker(kubeClient, ns)
-				if err != nil {
-					log.Logger().Warnf("Failed to create LogMasker in namespace %s: %s", ns, err.Error())
-				}
+				masker := o.createLogMasker(kubeClient, ns, pod)
 
 				logURL, err := o.generateBuildLogURL(podInterface, ns, activity, pri.PipelineRun, pod, location, settings, o.InitGitCredentials, masker)
 				if err != nil {
@@ -1080,11 +1215,17 @@ func (o *ControllerBuildOptions) generateBuildLogURL(podInterface typedcorev1.Po
 
 	var logWriter logs.LogWriter
 	w := LongTermStorageLogWriter{
-		data:       []byte{},
+		archive:    logs.NewLogArchive(),
 		kubeClient: kubeClient,
 		logMasker:  logMasker,
 	}
 	logWriter = &w
+	defer func() {
+		err := w.archive.Close()
+		if err != nil {
+			log.Logger().Warnf("failed to remove the temporary step logs for activity %s: %s", activity.Name, err.Error())
+		}
+	}()
 
 	tektonLogger := logs.TektonLogger{
 		JXClient:     jx,
@@ -1092,6 +1233,8 @@ func (o *ControllerBuildOptions) generateBuildLogURL(podInterface typedcorev1.Po
 		TektonClient: tektonClient,
 		Namespace:    ns,
 		LogWriter:    logWriter,
+		LogMasker:    logMasker,
+		Timestamps:   true,
 	}
 
 	log.Logger().Debugf("Capturing running build logs for %s", activity.Name)
@@ -1113,15 +1256,64 @@ func (o *ControllerBuildOptions) generateBuildLogURL(podInterface typedcorev1.Po
 	}
 
 	log.Logger().Infof("storing logs for activity %s into storage at %s", activity.Name, fileName)
-	answer, err := coll.CollectData(w.data, fileName)
+	answer, err := coll.CollectData(w.archive.Data(), fileName)
 	if err != nil {
 		log.Logger().Errorf("failed to store logs for activity %s into storage at %s: %s", activity.Name, fileName, err.Error())
 		return answer, err
 	}
+	err = collectLogArchiveFiles(coll, w.archive, fileName)
+	if err != nil {
+		log.Logger().Warnf("failed to store the step logs and index for activity %s into storage: %s", activity.Name, err.Error())
+	}
 	log.Logger().Infof("stored logs for activity %s into storage at %s", activity.Name, fileName)
 	return answer, nil
 }
 
+// collectLogArchiveFiles stores the index and the step logs of the build log with the given file name in a single
+// collection so that git based storage only needs one commit
+func collectLogArchiveFiles(coll collector.Collector, archive *logs.LogArchive, fileName string) error {
+	dir, err := archive.WriteFiles(fileName)
+	if err != nil {
+		return err
+	}
+	_, err = coll.CollectFiles([]string{filepath.Join(dir, "*")}, "", dir)
+	return err
+}
+
+// createLogMasker creates the LogMasker for the logs of the given pod. Along with the Secrets in the namespace it masks
+// the values of any secret URIs referenced by the environment variables of the pod
+func (o *ControllerBuildOptions) createLogMasker(kubeClient kubernetes.Interface, ns string, pod *corev1.Pod) *kube.LogMasker {
+	masker, err := kube.NewLogMasker(kubeClient, ns)
+	if err != nil {
+		log.Logger().Warnf("Failed to create LogMasker in namespace %s: %s", ns, err.Error())
+	}
+	if pod == nil {
+		return masker
+	}
+	values := []string{}
+	containers, _, _ := kube.GetContainersWithStatusAndIsInit(pod)
+	for _, c := range containers {
+		for _, env := range c.Env {
+			if kube.ContainsSecretURI(env.Value) {
+				values = append(values, env.Value)
+			}
+		}
+	}
+	if len(values) == 0 {
+		return masker
+	}
+	secretClient, err := o.GetSecretURLClient(o.GetSecretsLocation())
+	if err != nil {
+		log.Logger().Debugf("Failed to create the secret URL client to mask the secrets of pod %s: %s", pod.Name, err.Error())
+		return masker
+	}
+	err = masker.LoadSecretURIs(secretClient, values...)
+	if err != nil {
+		log.Logger().Warnf("Failed to load the secrets referenced by pod %s into the LogMasker: %s", pod.Name, err.Error())
+	}
+	return masker
+}
+
 // ensurePipelineActivityHasLabels older versions of controller build did not add labels properly
 // so lets enrich PipelineActivity on startup
 func (o *ControllerBuildOptions) ensurePipelineActivityHasLabels(jxClient versioned.Interface, ns string) error {
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	knativeapis "knative.dev/pkg/apis"
+)
+
+// startMetricsServer serves the pipeline metrics of the build controller on the /metrics endpoint
+func (o *ControllerBuildOptions) startMetricsServer() error {
+	o.pipelineMetrics = metrics.NewPipelineCollector(time.Now())
+	registry := prometheus.NewRegistry()
+	err := registry.Register(o.pipelineMetrics)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	address := fmt.Sprintf("%s:%d", o.MetricsBindAddress, o.MetricsPort)
+	log.Logger().Infof("serving the pipeline metrics at http://%s%s", util.ColorInfo(address), metricsPath)
+	go func() {
+		err := http.ListenAndServe(address, mux)
+		if err != nil {
+			log.Logger().Errorf("failed to serve the pipeline metrics: %s", err)
+		}
+	}()
+	return nil
+}
+
+// observePipelineRun records the metrics of the PipelineRun if the metrics are enabled
+func (o *ControllerBuildOptions) observePipelineRun(pr *v1alpha1.PipelineRun, pri *tekton.PipelineRunInfo, pods []corev1.Pod) {
+	if o.pipelineMetrics != nil {
+		o.pipelineMetrics.Observe(pipelineRunState(pr, pri, pods))
+	}
+}
+
+// pipelineRunState converts the PipelineRun and its pods into the state used to record its metrics
+func pipelineRunState(pr *v1alpha1.PipelineRun, pri *tekton.PipelineRunInfo, pods []corev1.Pod) *metrics.PipelineRunState {
+	state := &metrics.PipelineRunState{
+		Name:       pr.Name,
+		Repository: pri.Organisation + "/" + pri.Repository,
+		BranchKind: metrics.BranchKind(pri.Branch),
+		Type:       pri.Type,
+		Created:    pr.CreationTimestamp.Time,
+	}
+	for i := range pods {
+		started, _ := podTimes(&pods[i])
+		if started != nil && (state.Running == nil || started.Before(*state.Running)) {
+			state.Running = started
+		}
+	}
+	if pr.Status.StartTime != nil {
+		state.Started = &pr.Status.StartTime.Time
+	}
+	if cond := pr.Status.GetCondition(knativeapis.ConditionSucceeded); cond != nil && !cond.IsUnknown() {
+		state.Status = metrics.PipelineStatusFailed
+		if cond.IsTrue() {
+			state.Status = metrics.PipelineStatusSucceeded
+		}
+		completed := cond.LastTransitionTime.Inner.Time
+		if pr.Status.CompletionTime != nil {
+			completed = pr.Status.CompletionTime.Time
+		}
+		state.Completed = &completed
+	}
+	for _, si := range pri.GetOrderedTaskStages() {
+		if si.Pod == nil {
+			continue
+		}
+		stage := &metrics.StageState{
+			Name: si.GetStageNameIncludingParents(),
+		}
+		stage.Started, stage.Completed = podTimes(si.Pod)
+		switch si.Pod.Status.Phase {
+		case corev1.PodSucceeded:
+			stage.Status = metrics.PipelineStatusSucceeded
+		case corev1.PodFailed:
+			stage.Status = metrics.PipelineStatusFailed
+		default:
+			stage.Completed = nil
+		}
+		state.Stages = append(state.Stages, stage)
+	}
+	return state
+}
+
+// podTimes returns the time the first container of the pod started running and the time the last one terminated
+func podTimes(pod *corev1.Pod) (*time.Time, *time.Time) {
+	var started, completed *time.Time
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, s := range statuses {
+		var start *time.Time
+		if s.State.Running != nil {
+			start = &s.State.Running.StartedAt.Time
+		}
+		if t := s.State.Terminated; t != nil {
+			start = &t.StartedAt.Time
+			if completed == nil || t.FinishedAt.Time.After(*completed) {
+				finished := t.FinishedAt.Time
+				completed = &finished
+			}
+		}
+		if start != nil && !start.IsZero() && (started == nil || start.Before(*started)) {
+			t := *start
+			started = &t
+		}
+	}
+	return started, completed
+}
@@ -0,0 +1,88 @@
+// +build unit
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	knativeapis "knative.dev/pkg/apis"
+)
+
+func TestPipelineRunState(t *testing.T) {
+	created := time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(seconds int) metav1.Time {
+		return metav1.NewTime(created.Add(time.Duration(seconds) * time.Second))
+	}
+	terminated := func(started int, finished int) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{StartedAt: at(started), FinishedAt: at(finished)},
+			},
+		}
+	}
+	buildPod := &corev1.Pod{
+		Status: corev1.PodStatus{
+			Phase:                 corev1.PodSucceeded,
+			InitContainerStatuses: []corev1.ContainerStatus{terminated(20, 25)},
+			ContainerStatuses:     []corev1.ContainerStatus{terminated(25, 60), terminated(60, 120)},
+		},
+	}
+	promotePod := &corev1.Pod{
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{StartedAt: at(130)},
+				},
+			}},
+		},
+	}
+
+	pr := &v1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "jstrachan-myapp-master-1", CreationTimestamp: at(0)},
+	}
+	pri := &tekton.PipelineRunInfo{
+		Organisation: "jstrachan",
+		Repository:   "myapp",
+		Branch:       "master",
+		Type:         tekton.BuildPipeline.String(),
+		Stages: []*tekton.StageInfo{
+			{Name: "build", Task: "jstrachan-myapp-master-build", Pod: buildPod},
+			{Name: "promote", Task: "jstrachan-myapp-master-promote", Pod: promotePod},
+		},
+	}
+	pods := []corev1.Pod{*promotePod, *buildPod}
+
+	state := pipelineRunState(pr, pri, pods)
+	assert.Equal(t, "jstrachan/myapp", state.Repository)
+	assert.Equal(t, metrics.BranchKindRelease, state.BranchKind)
+	require.NotNil(t, state.Running)
+	assert.Equal(t, at(20).Time, *state.Running, "the first container of any pod starting is when the run is no longer queued")
+	assert.Nil(t, state.Completed)
+	require.Len(t, state.Stages, 2)
+	assert.Equal(t, metrics.PipelineStatusSucceeded, state.Stages[0].Status)
+	assert.Equal(t, 100*time.Second, state.Stages[0].Completed.Sub(*state.Stages[0].Started))
+	assert.Nil(t, state.Stages[1].Completed, "a running stage should not be completed")
+
+	startTime := at(5)
+	completionTime := at(200)
+	pr.Status.StartTime = &startTime
+	pr.Status.CompletionTime = &completionTime
+	pr.Status.SetCondition(&knativeapis.Condition{
+		Type:   knativeapis.ConditionSucceeded,
+		Status: corev1.ConditionFalse,
+	})
+	state = pipelineRunState(pr, pri, pods)
+	assert.Equal(t, metrics.PipelineStatusFailed, state.Status)
+	require.NotNil(t, state.Completed)
+	assert.Equal(t, completionTime.Time, *state.Completed)
+	assert.Equal(t, startTime.Time, *state.Started)
+}
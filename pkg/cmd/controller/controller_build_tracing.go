@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/jenkins-x/jx/v2/pkg/tracing"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+)
+
+const (
+	// traceServiceName the name of the service the pipeline traces are reported as
+	traceServiceName = "jx-pipelines"
+)
+
+// multiExporter exports each trace to all of its exporters
+type multiExporter []tracing.Exporter
+
+// Export implements tracing.Exporter
+func (m multiExporter) Export(trace *tracing.Trace) error {
+	var errs []error
+	for _, e := range m {
+		err := e.Export(trace)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return util.CombineErrors(errs...)
+}
+
+// startTracing creates the tracer of the pipeline runs and starts its exporter if a collector endpoint or trace file
+// is configured
+func (o *ControllerBuildOptions) startTracing(ns string, stop <-chan struct{}) {
+	var exporters multiExporter
+	exporter := tracing.NewOTLPExporterFromEnv()
+	if o.TraceEndpoint != "" {
+		exporter = tracing.NewOTLPExporter(o.TraceEndpoint)
+	}
+	if exporter != nil {
+		log.Logger().Infof("exporting the pipeline traces to %s", util.ColorInfo(exporter.URL()))
+		exporters = append(exporters, exporter)
+	}
+	if o.TraceFile != "" {
+		log.Logger().Infof("writing the pipeline traces to %s", util.ColorInfo(o.TraceFile))
+		exporters = append(exporters, tracing.NewFileExporter(o.TraceFile))
+	}
+	if len(exporters) == 0 {
+		return
+	}
+	o.pipelineTracer = tracing.NewPipelineTracer(exporters, map[string]string{
+		tracing.AttributeServiceName:      traceServiceName,
+		tracing.AttributeServiceNamespace: ns,
+	}, time.Now())
+	go o.pipelineTracer.Run(stop, func(runName string, err error) {
+		if err != nil {
+			log.Logger().Warnf("failed to export the trace of PipelineRun %s: %s", runName, err)
+			return
+		}
+		log.Logger().Debugf("exported the trace of PipelineRun %s", runName)
+	})
+}
+
+// tracePipelineRun queues the trace of the pipeline run to be exported once its activity has completed if tracing is
+// enabled
+func (o *ControllerBuildOptions) tracePipelineRun(pri *tekton.PipelineRunInfo, activity *v1.PipelineActivity) {
+	if o.pipelineTracer == nil || activity == nil {
+		return
+	}
+	o.pipelineTracer.Enqueue(pri.PipelineRun, activity)
+}
@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/git/credentials"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/controller/pipeline"
@@ -49,6 +50,9 @@ const (
 	environmentControllerHmacSecret    = "environment-controller-hmac"
 	environmentControllerHmacSecretKey = "hmac"
 	helloMessage                       = "hello from the Jenkins X Environment Controller\n"
+
+	optionEnvironment   = "environment"
+	optionDevKubeConfig = "dev-kubeconfig"
 )
 
 // ControllerEnvironmentOptions holds the command line arguments
@@ -70,8 +74,17 @@ type ControllerEnvironmentOptions struct {
 	PushRef               string
 	Labels                map[string]string
 
+	// status agent options for reporting back to the development cluster
+	ReportStatus    bool
+	DevKubeConfig   string
+	DevNamespace    string
+	EnvironmentName string
+	ClusterName     string
+	ReportInterval  time.Duration
+
 	StepCreateTaskOptions create.StepCreateTaskOptions
 	secret                []byte
+	devClient             versioned.Interface
 }
 
 var (
@@ -80,6 +93,9 @@ var (
 	controllerEnvironmentsExample = templates.Examples(`
 			# run the environment controller
 			jx controller environment
+
+			# run the environment controller reporting the status of the environment back to the development cluster
+			jx controller environment --report-status --environment production --dev-kubeconfig /secrets/dev/kubeconfig
 		`)
 
 	pipelineLock sync.Mutex
@@ -118,6 +134,12 @@ func NewCmdControllerEnvironment(commonOpts *opts.CommonOptions) *cobra.Command
 	cmd.Flags().StringVarP(&options.GitRepo, "repo", "", "", "The git repository name. If not specified defaults to $REPO")
 	cmd.Flags().StringVarP(&options.WebHookURL, "webhook-url", "w", "", "The external WebHook URL of this controller to register with the git provider. If not specified defaults to $WEBHOOK_URL")
 	cmd.Flags().StringVarP(&options.PushRef, "push-ref", "", "refs/heads/master", "The git ref passed from the WebHook which should trigger a new deploy pipeline to trigger. Defaults to only webhooks from the master branch")
+	cmd.Flags().BoolVarP(&options.ReportStatus, "report-status", "", false, "Enables reporting the sync status, deployed versions and pod health of this environment back to the Environment and Release resources in the development cluster")
+	cmd.Flags().StringVarP(&options.DevKubeConfig, optionDevKubeConfig, "", "", "The kube config file used to connect to the development cluster when reporting status. If not specified defaults to $DEV_KUBECONFIG")
+	cmd.Flags().StringVarP(&options.DevNamespace, "dev-namespace", "", "", "The namespace of the development environment in the development cluster. If not specified defaults to $DEV_NAMESPACE or 'jx'")
+	cmd.Flags().StringVarP(&options.EnvironmentName, optionEnvironment, "", "", "The name of the Environment resource in the development cluster to report the status to. If not specified defaults to $ENVIRONMENT")
+	cmd.Flags().StringVarP(&options.ClusterName, opts.OptionClusterName, "", "", "The name of this cluster to include in the reported status. If not specified defaults to $CLUSTER_NAME")
+	cmd.Flags().DurationVarP(&options.ReportInterval, "report-interval", "", time.Minute, "How often the status of the environment is reported to the development cluster")
 
 	so := &options.StepCreateTaskOptions
 	so.CommonOptions = commonOpts
@@ -233,6 +255,14 @@ func (o *ControllerEnvironmentOptions) Run() error {
 		}
 	}
 
+	if o.ReportStatus {
+		err = o.defaultStatusAgentOptions()
+		if err != nil {
+			return err
+		}
+		go o.runStatusAgent()
+	}
+
 	mux := http.NewServeMux()
 	mux.Handle(healthPath, http.HandlerFunc(o.health))
 	mux.Handle(readyPath, http.HandlerFunc(o.ready))
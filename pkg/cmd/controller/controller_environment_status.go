@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"os"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/jxfactory/connector"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// runStatusAgent periodically reports the status of the environment in this remote cluster
+// back to the Environment and Release resources in the development cluster
+func (o *ControllerEnvironmentOptions) runStatusAgent() {
+	if o.ReportInterval <= 0 {
+		o.ReportInterval = time.Minute
+	}
+	log.Logger().Infof("reporting the status of environment %s to namespace %s in the development cluster every %s",
+		util.ColorInfo(o.EnvironmentName), util.ColorInfo(o.DevNamespace), o.ReportInterval.String())
+	for {
+		err := o.reportStatus()
+		if err != nil {
+			log.Logger().Warnf("failed to report the status of environment %s: %s", o.EnvironmentName, err.Error())
+		}
+		time.Sleep(o.ReportInterval)
+	}
+}
+
+// reportStatus collects the status of the environment in this cluster and stores it in the development cluster
+func (o *ControllerEnvironmentOptions) reportStatus() error {
+	kubeClient, ns, err := o.KubeClientAndNamespace()
+	if err != nil {
+		return err
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return err
+	}
+	devJXClient, err := o.devJXClient()
+	if err != nil {
+		return err
+	}
+	collector := &environments.RemoteStatusCollector{
+		KubeClient:    kubeClient,
+		JXClient:      jxClient,
+		Namespace:     ns,
+		GitOwner:      o.GitOwner,
+		GitRepository: o.GitRepo,
+		Branch:        o.Branch,
+		Cluster:       o.ClusterName,
+	}
+	status, err := collector.Collect()
+	if err != nil {
+		return errors.Wrapf(err, "collecting the status of namespace %s", ns)
+	}
+	env, err := environments.UpdateRemoteEnvironmentStatus(devJXClient, o.DevNamespace, o.EnvironmentName, status)
+	if err != nil {
+		return err
+	}
+	log.Logger().Debugf("reported status %s for environment %s", environments.RemoteStatusSummary(status), o.EnvironmentName)
+	return environments.UpdateRemoteReleases(jxClient, ns, devJXClient, env, status)
+}
+
+// devJXClient lazily creates the client for the Jenkins X resources in the development cluster
+func (o *ControllerEnvironmentOptions) devJXClient() (versioned.Interface, error) {
+	if o.devClient != nil {
+		return o.devClient, nil
+	}
+	if o.DevKubeConfig == "" {
+		return nil, util.MissingOption(optionDevKubeConfig)
+	}
+	config, err := clientcmd.BuildConfigFromFlags("", o.DevKubeConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the development cluster kube config %s", o.DevKubeConfig)
+	}
+	o.devClient, err = connector.NewConfigClientFactory("dev", config).CreateJXClient()
+	if err != nil {
+		return nil, err
+	}
+	return o.devClient, nil
+}
+
+// defaultStatusAgentOptions defaults the status agent options from environment variables
+func (o *ControllerEnvironmentOptions) defaultStatusAgentOptions() error {
+	if o.DevKubeConfig == "" {
+		o.DevKubeConfig = os.Getenv("DEV_KUBECONFIG")
+	}
+	if o.DevNamespace == "" {
+		o.DevNamespace = os.Getenv("DEV_NAMESPACE")
+		if o.DevNamespace == "" {
+			o.DevNamespace = "jx"
+		}
+	}
+	if o.EnvironmentName == "" {
+		o.EnvironmentName = os.Getenv("ENVIRONMENT")
+		if o.EnvironmentName == "" {
+			return util.MissingOption(optionEnvironment)
+		}
+	}
+	if o.ClusterName == "" {
+		o.ClusterName = os.Getenv("CLUSTER_NAME")
+	}
+	if o.DevKubeConfig == "" {
+		return util.MissingOption(optionDevKubeConfig)
+	}
+	return nil
+}
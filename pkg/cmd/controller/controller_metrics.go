@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// metricsPath is the URL path for the HTTP endpoint that returns the Prometheus metrics
+	metricsPath = "/metrics"
+)
+
+// ControllerMetricsOptions holds the command line arguments
+type ControllerMetricsOptions struct {
+	*opts.CommonOptions
+	BindAddress    string
+	Port           int
+	Teams          []string
+	Since          time.Duration
+	ResyncInterval time.Duration
+	BuildCosts     bool
+
+	collector          *metrics.DeliveryCollector
+	buildCostCollector *metrics.BuildCostCollector
+}
+
+var (
+	controllerMetricsLong = templates.LongDesc(`
+		Runs the delivery metrics controller which exposes the DORA metrics of each app and environment as Prometheus
+		metrics on the /metrics endpoint.
+
+		The deployment frequency, lead time for changes, change failure rate and mean time to restore are computed
+		from the PipelineActivity and Release resources of the teams and refreshed periodically.
+
+		With --build-costs the cost of the builds of each team, repository and branch type over the same window is
+		exposed too, priced with the rate card of each team. See 'jx get build costs'.
+`)
+
+	controllerMetricsExample = templates.Examples(`
+		# expose the delivery metrics of the current team over the last 30 days
+		jx controller metrics
+
+		# expose the delivery metrics of several teams over the last week
+		jx controller metrics --team jx --team cheese --since 168h
+
+		# expose the delivery metrics and the build costs of the current team
+		jx controller metrics --build-costs
+	`)
+)
+
+// NewCmdControllerMetrics creates the command to run the delivery metrics controller
+func NewCmdControllerMetrics(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ControllerMetricsOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "metrics",
+		Short:   "Runs the controller which exposes the delivery metrics to Prometheus",
+		Long:    controllerMetricsLong,
+		Example: controllerMetricsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().IntVarP(&options.Port, optionPort, "", 8080, "The TCP port to listen on.")
+	cmd.Flags().StringVarP(&options.BindAddress, optionBind, "", "",
+		"The interface address to bind to (by default, will listen on all interfaces/addresses).")
+	cmd.Flags().StringArrayVarP(&options.Teams, "team", "", nil, "The teams to compute the metrics of. Defaults to the current team")
+	cmd.Flags().DurationVarP(&options.Since, "since", "", 30*24*time.Hour, "The window of deployments the metrics are computed over")
+	cmd.Flags().DurationVarP(&options.ResyncInterval, "resync-interval", "", time.Minute, "How often the metrics are recomputed")
+	cmd.Flags().BoolVarP(&options.BuildCosts, "build-costs", "", false, "Also exposes the cost of the builds of the teams")
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerMetricsOptions) Run() error {
+	if len(o.Teams) == 0 {
+		_, ns, err := o.JXClientAndDevNamespace()
+		if err != nil {
+			return err
+		}
+		o.Teams = []string{ns}
+	}
+	o.collector = metrics.NewDeliveryCollector()
+	registry := prometheus.NewRegistry()
+	err := registry.Register(o.collector)
+	if err != nil {
+		return err
+	}
+	if o.BuildCosts {
+		o.buildCostCollector = metrics.NewBuildCostCollector()
+		err = registry.Register(o.buildCostCollector)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = o.UpdateMetrics()
+	if err != nil {
+		return err
+	}
+	go func() {
+		for range time.Tick(o.ResyncInterval) {
+			err := o.UpdateMetrics()
+			if err != nil {
+				log.Logger().Warnf("failed to update the delivery metrics: %s", err)
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	mux.Handle(healthPath, http.HandlerFunc(o.health))
+	address := fmt.Sprintf("%s:%d", o.BindAddress, o.Port)
+	log.Logger().Infof("serving the delivery metrics of teams %s at http://%s%s", util.ColorInfo(o.Teams), address, metricsPath)
+	return http.ListenAndServe(address, mux)
+}
+
+// UpdateMetrics recomputes the delivery metrics of all the teams
+func (o *ControllerMetricsOptions) UpdateMetrics() error {
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return err
+	}
+	filter := &metrics.DeliveryFilter{
+		Since: time.Now().Add(-o.Since),
+	}
+	answer := []*metrics.DeliveryMetrics{}
+	for _, team := range o.Teams {
+		m, err := metrics.LoadDeliveryMetrics(jxClient, team, filter)
+		if err != nil {
+			return err
+		}
+		answer = append(answer, m...)
+	}
+	if o.collector == nil {
+		o.collector = metrics.NewDeliveryCollector()
+	}
+	o.collector.Update(answer)
+	if o.buildCostCollector != nil {
+		return o.updateBuildCosts(filter.Since)
+	}
+	return nil
+}
+
+// updateBuildCosts recomputes the cost of the builds of all the teams
+func (o *ControllerMetricsOptions) updateBuildCosts(since time.Time) error {
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	costs := []*metrics.BuildCost{}
+	for _, team := range o.Teams {
+		rateCard, err := metrics.LoadRateCard(kubeClient, team)
+		if err != nil {
+			return err
+		}
+		c, err := metrics.LoadBuildCosts(jxClient, kubeClient, team, since, rateCard)
+		if err != nil {
+			return err
+		}
+		costs = append(costs, c...)
+	}
+	groupBy := []string{metrics.GroupByTeam, metrics.GroupByRepository, metrics.GroupByBranchKind}
+	o.buildCostCollector.Update(metrics.GroupBuildCosts(costs, groupBy))
+	return nil
+}
+
+// Collector returns the collector of the delivery metrics
+func (o *ControllerMetricsOptions) Collector() *metrics.DeliveryCollector {
+	return o.collector
+}
+
+// health returns HTTP 204 if the service is up
+func (o *ControllerMetricsOptions) health(w http.ResponseWriter, r *http.Request) {
+	log.Logger().Debug("Health check")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,58 @@
+// +build unit
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeEventsProvider records the statuses of the activities it sent and fails while failing is true
+type fakeEventsProvider struct {
+	failing bool
+	sent    []v1.ActivityStatusType
+}
+
+func (p *fakeEventsProvider) SendActivity(a *v1.PipelineActivity) error {
+	if p.failing {
+		return errors.New("receiver unavailable")
+	}
+	p.sent = append(p.sent, a.Spec.Status)
+	return nil
+}
+
+func (p *fakeEventsProvider) SendRelease(r *v1.Release) error {
+	return nil
+}
+
+func (p *fakeEventsProvider) SendPromotion(a *v1.PipelineActivity, promote *v1.PromoteActivityStep) error {
+	return nil
+}
+
+func TestSendActivityEventsRetriesFailedEvents(t *testing.T) {
+	t.Parallel()
+
+	provider := &fakeEventsProvider{failing: true}
+	o := &ControllerPipelineEventsOptions{}
+	o.providers = append(o.providers, provider)
+
+	a := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "jstrachan-myapp-master-1"},
+		Spec:       v1.PipelineActivitySpec{Status: v1.ActivityStatusTypeRunning},
+	}
+	o.SendActivityEvents(a)
+	assert.Empty(t, provider.sent)
+
+	provider.failing = false
+	o.SendActivityEvents(a)
+	o.SendActivityEvents(a)
+	assert.Equal(t, []v1.ActivityStatusType{v1.ActivityStatusTypeRunning}, provider.sent, "a failed event should be sent again on the next update and only once")
+
+	a.Spec.Status = v1.ActivityStatusTypeSucceeded
+	o.SendActivityEvents(a)
+	assert.Equal(t, []v1.ActivityStatusType{v1.ActivityStatusTypeRunning, v1.ActivityStatusTypeSucceeded}, provider.sent)
+}
@@ -73,6 +73,7 @@ func NewCmdCreate(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdCreatePullRequest(commonOpts))
 	cmd.AddCommand(NewCmdCreateQuickstart(commonOpts))
 	cmd.AddCommand(NewCmdCreateQuickstartLocation(commonOpts))
+	cmd.AddCommand(NewCmdCreateSecret(commonOpts))
 	cmd.AddCommand(NewCmdCreateMLQuickstart(commonOpts))
 	cmd.AddCommand(NewCmdCreateSpring(commonOpts))
 	cmd.AddCommand(NewCmdCreateStep(commonOpts))
@@ -1,6 +1,8 @@
 package create
 
 import (
+	"io/ioutil"
+	"os"
 	"strings"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/create/options"
@@ -19,15 +21,18 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/prow"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
 )
 
 const (
 	optionPullSecrets = "pull-secrets"
+	optionOrder       = "order"
 )
 
 var (
@@ -49,6 +54,9 @@ var (
 
 		# Creates a new Environment passing in the required data on the command line
 		jx create env -n prod -l Production --no-gitops --namespace my-prod
+
+		# Creates a new Environment from the 'perf-test' blueprint in the version stream
+		jx create env -n perf --blueprint perf-test
 	`)
 )
 
@@ -71,6 +79,7 @@ type CreateEnvOptions struct {
 	Vault                  bool
 	PullSecrets            string
 	Update                 bool
+	Blueprint              string
 }
 
 // NewCmdCreateEnv creates a command object for the "create" command
@@ -109,7 +118,7 @@ func NewCmdCreateEnv(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Options.Spec.Source.URL, "git-url", "g", "", "The Git clone URL for the source code for GitOps based Environments")
 	cmd.Flags().StringVarP(&options.Options.Spec.Source.Ref, "git-ref", "r", "", "The Git repo reference for the source code for GitOps based Environments")
 	cmd.Flags().StringVarP(&options.GitRepositoryOptions.Owner, "git-owner", "", "", "Git organisation / owner")
-	cmd.Flags().Int32VarP(&options.Options.Spec.Order, "order", "o", 100, "The order weighting of the Environment so that they can be sorted by this order before name")
+	cmd.Flags().Int32VarP(&options.Options.Spec.Order, optionOrder, "o", 100, "The order weighting of the Environment so that they can be sorted by this order before name")
 	cmd.Flags().StringVarP(&options.Prefix, "prefix", "", "jx", "Environment repo prefix, your Git repo will be of the form 'environment-$prefix-$envName'")
 
 	cmd.Flags().StringVarP(&options.PromotionStrategy, "promotion", "p", "", "The promotion strategy")
@@ -121,6 +130,7 @@ func NewCmdCreateEnv(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.Prow, "prow", "", false, "Install and use Prow for environment promotion")
 	cmd.Flags().BoolVarP(&options.Vault, "vault", "", false, "Sets up a Hashicorp Vault for storing secrets during the cluster creation")
 	cmd.Flags().StringVarP(&options.PullSecrets, optionPullSecrets, "", "", "A list of Kubernetes secret names that will be attached to the service account (e.g. foo, bar, baz)")
+	cmd.Flags().StringVarP(&options.Blueprint, "blueprint", "", "", "The name of the environment blueprint in the version stream used to create the charts, values, role bindings and resource quota of the Environment")
 
 	opts.AddGitRepoOptionsArguments(cmd, &options.GitRepositoryOptions)
 	options.HelmValuesConfig.AddExposeControllerValues(cmd, false)
@@ -190,11 +200,23 @@ func (o *CreateEnvOptions) Run() error {
 
 	env := v1.Environment{}
 	o.Options.Spec.PromotionStrategy = v1.PromotionStrategyType(o.PromotionStrategy)
+
+	var blueprint *versionstream.EnvironmentBlueprint
+	if o.Blueprint != "" {
+		blueprint, err = o.loadBlueprint()
+		if err != nil {
+			return err
+		}
+	}
+
 	gitProvider, err := kube.CreateEnvironmentSurvey(o.BatchMode, authConfigSvc, devEnv, &env, &o.Options, o.Update, o.ForkEnvironmentGitRepo, ns,
 		jxClient, kubeClient, envDir, &o.GitRepositoryOptions, o.HelmValuesConfig, o.Prefix, o.Git(), o.ResolveChartMuseumURL, o.GetIOFileHandles())
 	if err != nil {
 		return err
 	}
+	if blueprint != nil {
+		environments.SetBlueprintAnnotations(&env, blueprint)
+	}
 
 	err = o.ModifyEnvironment(env.Name, func(env2 *v1.Environment) error {
 		env2.Name = env.Name
@@ -224,6 +246,13 @@ func (o *CreateEnvOptions) Run() error {
 		}
 	}
 
+	if blueprint != nil {
+		err = o.applyBlueprint(&env, blueprint, gitProvider)
+		if err != nil {
+			return errors.Wrapf(err, "applying blueprint %s to environment %s", blueprint.Name, env.Name)
+		}
+	}
+
 	/* It is important this pull secret handling goes after any namespace creation code; the service account exists in the created namespace */
 	if o.PullSecrets != "" {
 		// We need the namespace to be created first - do the check
@@ -259,6 +288,100 @@ func (o *CreateEnvOptions) Run() error {
 	return nil
 }
 
+// loadBlueprint loads the blueprint from the version stream and uses it to default the environment configuration
+func (o *CreateEnvOptions) loadBlueprint() (*versionstream.EnvironmentBlueprint, error) {
+	resolver, err := o.GetVersionResolver()
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the version resolver")
+	}
+	blueprint, err := versionstream.LoadEnvironmentBlueprint(resolver.VersionsDir, o.Blueprint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading blueprint %s", o.Blueprint)
+	}
+	if o.Cmd == nil || !o.Cmd.Flags().Changed(optionOrder) {
+		o.Options.Spec.Order = 0
+	}
+	environments.ApplyBlueprintDefaults(&o.Options, blueprint)
+	log.Logger().Infof("Using blueprint %s version %s", util.ColorInfo(blueprint.Name), util.ColorInfo(blueprint.Version))
+	return blueprint, nil
+}
+
+// applyBlueprint adds the charts and values of the blueprint to the environment git repository and creates the
+// role bindings and resource quota of the blueprint
+func (o *CreateEnvOptions) applyBlueprint(env *v1.Environment, blueprint *versionstream.EnvironmentBlueprint, gitProvider gits.GitProvider) error {
+	gitURL := env.Spec.Source.URL
+	if gitURL != "" {
+		err := o.pushBlueprintToGitRepository(gitURL, blueprint, gitProvider)
+		if err != nil {
+			return err
+		}
+	}
+	if o.GitOpsMode {
+		log.Logger().Warnf("Please add the role bindings and resource quota of blueprint %s to the development environment git repository", blueprint.Name)
+		return nil
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	return environments.ApplyBlueprintResources(kubeClient, jxClient, ns, env, blueprint)
+}
+
+// pushBlueprintToGitRepository adds the charts and values of the blueprint to the newly created environment git repository
+func (o *CreateEnvOptions) pushBlueprintToGitRepository(gitURL string, blueprint *versionstream.EnvironmentBlueprint, gitProvider gits.GitProvider) error {
+	var err error
+	if gitProvider == nil {
+		gitProvider, err = o.GitProviderForURL(gitURL, "environment git repository")
+		if err != nil {
+			return errors.Wrapf(err, "creating git provider for %s", gitURL)
+		}
+	}
+	userAuth := gitProvider.UserAuth()
+	pushURL, err := o.Git().CreateAuthenticatedURL(gitURL, &userAuth)
+	if err != nil {
+		return errors.Wrapf(err, "creating push URL for %s", gitURL)
+	}
+	dir, err := ioutil.TempDir("", "jx-blueprint")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	err = o.Git().Clone(pushURL, dir)
+	if err != nil {
+		return errors.Wrapf(err, "cloning %s", gitURL)
+	}
+	err = environments.ApplyBlueprintToDir(dir, blueprint)
+	if err != nil {
+		return err
+	}
+	err = o.Git().Add(dir, "*")
+	if err != nil {
+		return err
+	}
+	changes, err := o.Git().HasChanges(dir)
+	if err != nil {
+		return err
+	}
+	if !changes {
+		return nil
+	}
+	err = o.Git().CommitDir(dir, fmt.Sprintf("chore: apply blueprint %s version %s", blueprint.Name, blueprint.Version))
+	if err != nil {
+		return err
+	}
+	err = o.Git().PushMaster(dir)
+	if err != nil {
+		return errors.Wrapf(err, "pushing blueprint changes to %s", gitURL)
+	}
+	log.Logger().Infof("Applied blueprint %s to %s", util.ColorInfo(blueprint.Name), util.ColorInfo(gitURL))
+	return nil
+}
+
 // RegisterEnvironment performs the environment registration
 func (o *CreateEnvOptions) RegisterEnvironment(env *v1.Environment, gitProvider gits.GitProvider, authConfigSvc auth.ConfigService) error {
 	gitURL := env.Spec.Source.URL
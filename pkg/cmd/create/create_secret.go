@@ -0,0 +1,251 @@
+package create
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/create/options"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/sopsvault"
+	"github.com/jenkins-x/jx/v2/pkg/sops"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+var (
+	createSecretLong = templates.LongDesc(`
+		Creates or updates a secret in an Environment git repository which is encrypted with SOPS.
+
+		The secret is stored in the 'secrets' folder of the environment chart and can be referenced from the values files
+		via a 'sops:name:key' URI which is decrypted when the environment is applied via 'jx step helm apply'.
+
+		The keys used to encrypt the secret are taken from the .sops.yaml file of the repository unless they are
+		specified via the --age, --pgp, --kms, --gcp-kms or --azure-kv options.
+`)
+
+	createSecretExample = templates.Examples(`
+		# Creates a secret in the environment git repository in the current directory
+		jx create secret mysecret --data username=admin --data password=s3cr3t
+
+		# Creates a secret in the staging environment via a Pull Request
+		jx create secret mysecret --env staging --data password=s3cr3t
+
+		# Creates a secret encrypted with an age key, prompting for the value
+		jx create secret mysecret --data password --age age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+	`)
+)
+
+// CreateSecretOptions the options for the create secret command
+type CreateSecretOptions struct {
+	options.CreateOptions
+
+	Name      string
+	Data      []string
+	Dir       string
+	Env       string
+	AutoMerge bool
+	Keys      sops.Keys
+
+	// Encrypter encrypts the secrets, it defaults to the sops binary
+	Encrypter sops.Encrypter
+}
+
+// NewCmdCreateSecret creates a command object for the "create secret" command
+func NewCmdCreateSecret(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &CreateSecretOptions{
+		CreateOptions: options.CreateOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "secret [name]",
+		Short:   "Creates a SOPS encrypted secret in an Environment git repository",
+		Aliases: []string{"secrets"},
+		Long:    createSecretLong,
+		Example: createSecretExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Name, "name", "n", "", "The name of the secret")
+	cmd.Flags().StringArrayVarP(&options.Data, "data", "", nil, "The key=value pairs of the secret. If the value is omitted you are prompted for it")
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory of the Environment git repository. Defaults to the current directory")
+	cmd.Flags().StringVarP(&options.Env, "env", "e", "", "The Environment to create the secret in via a Pull Request on its git repository")
+	cmd.Flags().BoolVarP(&options.AutoMerge, "auto-merge", "", false, "Automatically merge the Pull Request if it passes the pipeline checks")
+	cmd.Flags().StringArrayVarP(&options.Keys.Age, "age", "", nil, "The age recipients to encrypt the secret with")
+	cmd.Flags().StringArrayVarP(&options.Keys.PGP, "pgp", "", nil, "The PGP fingerprints to encrypt the secret with")
+	cmd.Flags().StringArrayVarP(&options.Keys.KMS, "kms", "", nil, "The AWS KMS ARNs to encrypt the secret with")
+	cmd.Flags().StringArrayVarP(&options.Keys.GCPKMS, "gcp-kms", "", nil, "The GCP KMS resource IDs to encrypt the secret with")
+	cmd.Flags().StringArrayVarP(&options.Keys.AzureKV, "azure-kv", "", nil, "The Azure Key Vault key URLs to encrypt the secret with")
+	return cmd
+}
+
+// Run implements the command
+func (o *CreateSecretOptions) Run() error {
+	name := o.Name
+	if name == "" && len(o.Args) > 0 {
+		name = o.Args[0]
+	}
+	if name == "" {
+		return util.MissingArgument("name")
+	}
+	data, err := o.secretData()
+	if err != nil {
+		return err
+	}
+	if o.Encrypter == nil {
+		o.Encrypter = sops.NewCLI(o.Keys)
+	}
+
+	if o.Env != "" {
+		return o.createSecretInEnvironment(name, data)
+	}
+	dir := o.Dir
+	if dir == "" {
+		dir = "."
+	}
+	err = WriteSopsSecret(o.Encrypter, EnvironmentChartDir(dir), name, data)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Wrote secret %s to %s", util.ColorInfo(name), util.ColorInfo(filepath.Join(EnvironmentChartDir(dir), sops.SecretsDirName)))
+	o.logReferences(name, data)
+	return nil
+}
+
+// createSecretInEnvironment creates a Pull Request on the environment git repository with the encrypted secret
+func (o *CreateSecretOptions) createSecretInEnvironment(name string, data map[string]interface{}) error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	env, err := kube.GetEnvironment(jxClient, ns, o.Env)
+	if err != nil {
+		return errors.Wrapf(err, "getting Environment %s", o.Env)
+	}
+	gitURL := env.Spec.Source.URL
+	if gitURL == "" {
+		return fmt.Errorf("Environment %s does not have a git repository", o.Env)
+	}
+	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(gitURL)
+	if err != nil {
+		return errors.Wrapf(err, "creating git provider for %s", gitURL)
+	}
+	prOptions := environments.EnvironmentPullRequestOptions{
+		Gitter:      o.Git(),
+		GitProvider: gitProvider,
+		ModifyChartFn: func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+			templates map[string]string, dir string, details *gits.PullRequestDetails) error {
+			return WriteSopsSecret(o.Encrypter, EnvironmentChartDir(dir), name, data)
+		},
+	}
+	details := gits.PullRequestDetails{
+		BranchName: "secret-" + name,
+		Title:      fmt.Sprintf("chore: update secret %s", name),
+		Message:    fmt.Sprintf("chore: update the SOPS encrypted secret %s", name),
+	}
+	info, err := prOptions.Create(env, "", &details, nil, "", o.AutoMerge)
+	if err != nil {
+		return errors.Wrapf(err, "creating Pull Request on %s", gitURL)
+	}
+	if info != nil && info.PullRequest != nil {
+		log.Logger().Infof("Created Pull Request %s to update secret %s in Environment %s", util.ColorInfo(info.PullRequest.URL),
+			util.ColorInfo(name), util.ColorInfo(o.Env))
+	}
+	o.logReferences(name, data)
+	return nil
+}
+
+// secretData parses the key=value pairs prompting for any missing values
+func (o *CreateSecretOptions) secretData() (map[string]interface{}, error) {
+	if len(o.Data) == 0 {
+		return nil, util.MissingOption("data")
+	}
+	data := map[string]interface{}{}
+	for _, kv := range o.Data {
+		parts := strings.SplitN(kv, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			return nil, util.InvalidOptionf("data", kv, "should be of the form key=value")
+		}
+		value := ""
+		if len(parts) == 2 {
+			value = parts[1]
+		} else {
+			if o.BatchMode {
+				return nil, util.InvalidOptionf("data", kv, "no value specified for %s in batch mode", key)
+			}
+			var err error
+			value, err = util.PickPassword(fmt.Sprintf("value of %s:", key), "", o.GetIOFileHandles())
+			if err != nil {
+				return nil, err
+			}
+		}
+		data[key] = value
+	}
+	return data, nil
+}
+
+func (o *CreateSecretOptions) logReferences(name string, data map[string]interface{}) {
+	keys := []string{}
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	log.Logger().Info("The values can be referenced from the environment values files via:")
+	for _, k := range keys {
+		log.Logger().Infof("  %s", util.ColorInfo(secreturl.ToURI(name, k, strings.TrimSuffix(sopsvault.URIScheme, ":"))))
+	}
+}
+
+// EnvironmentChartDir returns the directory of the environment chart in the given git repository directory
+func EnvironmentChartDir(dir string) string {
+	chartDir := filepath.Join(dir, helm.DefaultEnvironmentChartDir)
+	exists, err := util.DirExists(chartDir)
+	if err == nil && exists {
+		return chartDir
+	}
+	return dir
+}
+
+// WriteSopsSecret merges the data into the SOPS encrypted secret in the secrets folder of the given chart directory
+func WriteSopsSecret(encrypter sops.Encrypter, chartDir string, name string, data map[string]interface{}) error {
+	secretsDir := filepath.Join(chartDir, sops.SecretsDirName)
+	client := sopsvault.NewClient(secretsDir, encrypter, nil)
+	values := map[string]interface{}{}
+	exists, err := util.FileExists(filepath.Join(secretsDir, name+".yaml"))
+	if err != nil {
+		return err
+	}
+	if exists {
+		existing, err := client.Read(name)
+		if err != nil {
+			return errors.Wrapf(err, "reading the existing secret %s", name)
+		}
+		for k, v := range existing {
+			values[k] = v
+		}
+	}
+	for k, v := range data {
+		values[k] = v
+	}
+	_, err = client.Write(name, values)
+	return err
+}
@@ -205,6 +205,14 @@ func (o *RequirementsOptions) applyDefaults() error {
 			r.SecretStorage = config.SecretStorageTypeLocal
 		case "vault":
 			r.SecretStorage = config.SecretStorageTypeVault
+		case "kube":
+			r.SecretStorage = config.SecretStorageTypeKube
+		case "awssm":
+			r.SecretStorage = config.SecretStorageTypeAWSSecretsManager
+		case "gcpsm":
+			r.SecretStorage = config.SecretStorageTypeGCPSecretManager
+		case "azurekv":
+			r.SecretStorage = config.SecretStorageTypeAzureKeyVault
 		default:
 			return util.InvalidOption("secret", o.SecretStorage, config.SecretStorageTypeValues)
 		}
@@ -1,7 +1,6 @@
 package get
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 
@@ -9,7 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/auth"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/util"
@@ -89,6 +88,7 @@ func NewCmdGet(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdGetIssues(commonOpts))
 	cmd.AddCommand(NewCmdGetLimits(commonOpts))
 	cmd.AddCommand(NewCmdGetLang(commonOpts))
+	cmd.AddCommand(NewCmdGetMetrics(commonOpts))
 	cmd.AddCommand(NewCmdGetPipeline(commonOpts))
 	cmd.AddCommand(NewCmdGetPostPreviewJob(commonOpts))
 	cmd.AddCommand(NewCmdGetPreview(commonOpts))
@@ -98,6 +98,7 @@ func NewCmdGet(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdGetStorage(commonOpts))
 	cmd.AddCommand(NewCmdGetTeam(commonOpts))
 	cmd.AddCommand(NewCmdGetTeamRole(commonOpts))
+	cmd.AddCommand(NewCmdGetTests(commonOpts))
 	cmd.AddCommand(NewCmdGetToken(commonOpts))
 	cmd.AddCommand(NewCmdGetTracker(commonOpts))
 	cmd.AddCommand(NewCmdGetURL(commonOpts))
@@ -121,31 +122,46 @@ func outputEmptyListWarning(out io.Writer) error {
 	return err
 }
 
+// AddGetFlags adds the flags common to all get commands
 func (o *GetOptions) AddGetFlags(cmd *cobra.Command) {
 	o.Cmd = cmd
-	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "The output format such as 'yaml'")
+	opts.AddOutputFlag(cmd, &o.Output)
 }
 
 // renderResult renders the result in a given output format
 func (o *GetOptions) renderResult(value interface{}, format string) error {
-	switch format {
-	case "json":
-		data, err := json.Marshal(value)
-		if err != nil {
-			return err
+	return opts.RenderOutput(o.Out, value, format)
+}
+
+// AuthServerOutput the server of an auth config rendered by --output. The users are listed without their tokens
+type AuthServerOutput struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	URL         string   `json:"url"`
+	CurrentUser string   `json:"currentUser,omitempty"`
+	Users       []string `json:"users"`
+}
+
+// authServersOutput returns the servers of the given kind, or all the servers if no kind is given, without their tokens
+func authServersOutput(servers []*auth.AuthServer, kind string) []AuthServerOutput {
+	answer := []AuthServerOutput{}
+	for _, s := range servers {
+		if kind != "" && s.Kind != kind {
+			continue
 		}
-		_, e := o.Out.Write(data)
-		return e
-	case "yaml":
-		data, err := yaml.Marshal(value)
-		if err != nil {
-			return err
+		users := []string{}
+		for _, u := range s.Users {
+			users = append(users, u.Username)
 		}
-		_, e := o.Out.Write(data)
-		return e
-	default:
-		return fmt.Errorf("Unsupported output format: %s", format)
+		answer = append(answer, AuthServerOutput{
+			Name:        s.Name,
+			Kind:        s.Kind,
+			URL:         s.URL,
+			CurrentUser: s.CurrentUser,
+			Users:       users,
+		})
 	}
+	return answer
 }
 
 func formatInt32(n int32) string {
@@ -33,6 +33,7 @@ type GetActivityOptions struct {
 	BuildNumber string
 	Watch       bool
 	Sort        bool
+	Output      string
 }
 
 var (
@@ -49,6 +50,9 @@ var (
 
 		# Watch the activities for application 'foo'
 		jx get act -f foo -w
+
+		# List the pipelines and build numbers of the activities as JSONPath
+		jx get act -o jsonpath='{range .items[*]}{.spec.pipeline} #{.spec.build}{"\n"}{end}'
 	`)
 )
 
@@ -74,11 +78,18 @@ func NewCmdGetActivity(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.BuildNumber, "build", "", "", "The build number to filter on")
 	cmd.Flags().BoolVarP(&options.Watch, "watch", "w", false, "Whether to watch the activities for changes")
 	cmd.Flags().BoolVarP(&options.Sort, "sort", "s", false, "Sort activities by timestamp")
+	opts.AddOutputFlag(cmd, &options.Output)
 	return cmd
 }
 
 // Run implements this command
 func (o *GetActivityOptions) Run() error {
+	if o.Output != "" {
+		err := opts.ValidateOutputFormat(o.Output)
+		if err != nil {
+			return err
+		}
+	}
 	client, currentNs, err := o.JXClientAndDevNamespace()
 	if err != nil {
 		return err
@@ -107,6 +118,16 @@ func (o *GetActivityOptions) Run() error {
 	if o.Sort {
 		kube.SortActivities(list.Items)
 	}
+	if o.Output != "" {
+		items := []v1.PipelineActivity{}
+		for _, activity := range list.Items {
+			if o.matches(&activity) {
+				items = append(items, activity)
+			}
+		}
+		list.Items = items
+		return opts.RenderOutput(o.Out, list, o.Output)
+	}
 
 	for _, activity := range list.Items {
 		o.addTableRow(&table, &activity)
@@ -186,6 +207,15 @@ func (o *GetActivityOptions) onActivity(table *tbl.Table, obj interface{}, yamlS
 		old := yamlSpecMap[name]
 		if old == "" || old != text {
 			yamlSpecMap[name] = text
+			if o.Output != "" {
+				if o.matches(activity) {
+					err = opts.RenderOutput(o.Out, activity, o.Output)
+					if err != nil {
+						log.Logger().Warnf("Failed to render Activity %s: %s", name, err)
+					}
+				}
+				return
+			}
 			if o.addTableRow(table, activity) {
 				table.Render()
 				table.Clear()
@@ -16,6 +16,15 @@ type GetAddonOptions struct {
 	GetOptions
 }
 
+// AddonInfo the details of an installed addon
+type AddonInfo struct {
+	Name    string `json:"name"`
+	Chart   string `json:"chart"`
+	Enabled bool   `json:"enabled"`
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
 var (
 	get_addon_long = templates.LongDesc(`
 		Display the available addons
@@ -25,6 +34,9 @@ var (
 	get_addon_example = templates.Examples(`
 		# List all the possible addons
 		jx get addon
+
+		# List the addons as JSON
+		jx get addon -o json
 	`)
 )
 
@@ -50,6 +62,7 @@ func NewCmdGetAddon(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 
+	options.AddGetFlags(cmd)
 	return cmd
 }
 
@@ -75,17 +88,31 @@ func (o *GetAddonOptions) Run() error {
 		log.Logger().Warnf("Failed to find Helm installs: %s", err)
 	}
 
-	table := o.CreateTable()
-	table.AddRow("NAME", "CHART", "ENABLED", "STATUS", "VERSION")
+	addons := []AddonInfo{}
 	for _, k := range sortedKeys {
 		release := releases[k]
 		if addonName, ok := kube.AddonCharts[release.ReleaseName]; ok {
-			enableText := ""
-			if addonEnabled[release.ReleaseName] {
-				enableText = "yes"
-			}
-			table.AddRow(release.ReleaseName, addonName, enableText, release.Status, release.ChartVersion)
+			addons = append(addons, AddonInfo{
+				Name:    release.ReleaseName,
+				Chart:   addonName,
+				Enabled: addonEnabled[release.ReleaseName],
+				Status:  release.Status,
+				Version: release.ChartVersion,
+			})
+		}
+	}
+	if o.Output != "" {
+		return o.renderResult(addons, o.Output)
+	}
+
+	table := o.CreateTable()
+	table.AddRow("NAME", "CHART", "ENABLED", "STATUS", "VERSION")
+	for _, a := range addons {
+		enableText := ""
+		if a.Enabled {
+			enableText = "yes"
 		}
+		table.AddRow(a.Name, a.Chart, enableText, a.Status, a.Version)
 	}
 	table.Render()
 	return nil
@@ -31,6 +31,7 @@ type GetApplicationsOptions struct {
 	HideUrl     bool
 	HidePod     bool
 	Previews    bool
+	Output      string
 }
 
 // Applications is a map indexed by the application name then the environment name
@@ -50,6 +51,23 @@ type ApplicationEnvironmentInfo struct {
 	URL         string
 }
 
+// ApplicationOutput the deployments of an application rendered by --output
+type ApplicationOutput struct {
+	Name        string                        `json:"name"`
+	Deployments []ApplicationDeploymentOutput `json:"deployments"`
+}
+
+// ApplicationDeploymentOutput the deployment of an application in an environment rendered by --output
+type ApplicationDeploymentOutput struct {
+	Environment string `json:"environment"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	Pods        string `json:"pods,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Canary      string `json:"canary,omitempty"`
+}
+
 var (
 	getVersionLong = templates.LongDesc(`
 		Display applications across environments.
@@ -73,6 +91,9 @@ var (
 
 		# List applications just showing the versions (hiding urls and pod counts)
 		jx get applications -u -p
+
+		# List the versions of the applications in staging as JSONPath
+		jx get applications -e staging -o jsonpath='{range [*]}{.name} {.deployments[0].version}{"\n"}{end}'
 	`)
 )
 
@@ -99,6 +120,7 @@ func NewCmdGetApplications(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.Previews, "preview", "w", false, "Show preview environments only")
 	cmd.Flags().StringVarP(&options.Environment, "env", "e", "", "Filter applications in the given environment")
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "Filter applications in the given namespace")
+	opts.AddOutputFlag(cmd, &options.Output)
 	return cmd
 }
 
@@ -109,19 +131,28 @@ func (o *GetApplicationsOptions) Run() error {
 		return nil
 	}
 
+	if o.Output != "" {
+		err := opts.ValidateOutputFormat(o.Output)
+		if err != nil {
+			return err
+		}
+	}
 	list, err := applications.GetApplications(o.CommonOptions.GetFactory())
 	if err != nil {
 		return errors.Wrap(err, "fetching applications")
 	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	if o.Output != "" {
+		return opts.RenderOutput(o.Out, o.applicationsOutput(kubeClient, list), o.Output)
+	}
 	if len(list.Items) == 0 {
 		log.Logger().Infof("No applications found")
 		return nil
 	}
 
-	kubeClient, err := o.KubeClient()
-	if err != nil {
-		return err
-	}
 	table := o.generateTable(kubeClient, list)
 	table.Render()
 
@@ -156,6 +187,9 @@ func (o *GetApplicationsOptions) generateTable(kubeClient kubernetes.Interface,
 						if !o.HideUrl {
 							row = append(row, d.URL(kubeClient, a))
 						}
+						if list.HasCanaries(k) {
+							row = append(row, d.CanaryStatus())
+						}
 					}
 				} else {
 					if !ae.IsPreview() {
@@ -167,6 +201,9 @@ func (o *GetApplicationsOptions) generateTable(kubeClient kubernetes.Interface,
 					if !o.HideUrl {
 						row = append(row, "")
 					}
+					if list.HasCanaries(k) {
+						row = append(row, "")
+					}
 				}
 			}
 			row = append([]string{name}, row...)
@@ -177,6 +214,43 @@ func (o *GetApplicationsOptions) generateTable(kubeClient kubernetes.Interface,
 	return table
 }
 
+// applicationsOutput returns the deployments of the applications in the environments which match the filters
+func (o *GetApplicationsOptions) applicationsOutput(kubeClient kubernetes.Interface, list applications.List) []ApplicationOutput {
+	answer := []ApplicationOutput{}
+	for _, a := range list.Items {
+		app := ApplicationOutput{
+			Name:        a.Name(),
+			Deployments: []ApplicationDeploymentOutput{},
+		}
+		for _, k := range o.sortedKeys(list.Environments()) {
+			ae, ok := a.Environments[k]
+			if !ok {
+				continue
+			}
+			for _, d := range ae.Deployments {
+				deployment := ApplicationDeploymentOutput{
+					Environment: k,
+					Namespace:   d.Deployment.Namespace,
+					Name:        kube.GetAppName(d.Deployment.Name, d.Deployment.Namespace),
+					Version:     d.Version(),
+					Canary:      d.CanaryStatus(),
+				}
+				if !o.HidePod {
+					deployment.Pods = d.Pods()
+				}
+				if !o.HideUrl {
+					deployment.URL = d.URL(kubeClient, a)
+				}
+				app.Deployments = append(app.Deployments, deployment)
+			}
+		}
+		if len(app.Deployments) > 0 {
+			answer = append(answer, app)
+		}
+	}
+	return answer
+}
+
 func envTitleName(e v1.Environment) string {
 	if e.Spec.Kind == v1.EnvironmentKindTypeEdit {
 		return "Edit"
@@ -213,6 +287,9 @@ func (o *GetApplicationsOptions) generateTableHeaders(list applications.List) ta
 		if !o.HideUrl {
 			titles = append(titles, "URL")
 		}
+		if list.HasCanaries(k) {
+			titles = append(titles, "CANARY")
+		}
 	}
 	t.AddRow(titles...)
 	return t
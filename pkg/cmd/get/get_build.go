@@ -52,6 +52,7 @@ func NewCmdGetBuild(commonOpts *opts.CommonOptions) *cobra.Command {
 		SuggestFor: []string{"list", "ps"},
 	}
 
+	cmd.AddCommand(NewCmdGetBuildCosts(commonOpts))
 	cmd.AddCommand(NewCmdGetBuildLogs(commonOpts))
 	cmd.AddCommand(NewCmdGetBuildPods(commonOpts))
 	return cmd
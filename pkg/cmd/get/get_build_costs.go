@@ -0,0 +1,278 @@
+package get
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const optionGroupBy = "group-by"
+
+// GetBuildCostsOptions the command line options
+type GetBuildCostsOptions struct {
+	GetOptions
+
+	Teams    []string
+	Since    time.Duration
+	GroupBy  []string
+	RateCard string
+	PerBuild bool
+}
+
+var (
+	getBuildCostsLong = templates.LongDesc(`
+		Displays the cost of the builds of the teams computed from the CPU and memory requested by the pods of each
+		PipelineActivity multiplied by how long the pods ran.
+
+		The costs are priced with the rate card in the 'jx-build-rate-card' ConfigMap of the team or the --rate-card
+		file, which looks like:
+
+		    currency: USD
+		    cpuCoreHour: 0.031611
+		    memoryGiBHour: 0.004237
+		    defaultCPU: 1
+		    defaultMemoryGiB: 2
+
+		Builds whose pods have been deleted are estimated from the duration of the build and the default CPU and
+		memory of the rate card.
+
+		The costs can be output as CSV or JSON for finance and are exposed to Prometheus by
+		'jx controller metrics --build-costs'.
+`)
+
+	getBuildCostsExample = templates.Examples(`
+		# Display the cost of the builds of the current team over the last 30 days by repository and branch type
+		jx get build costs
+
+		# Display the cost of the builds of several teams over the last week by team
+		jx get build costs --team jx --team cheese --since 168h --group-by team
+
+		# Export the cost of each build as CSV using a custom rate card
+		jx get build costs --builds --rate-card rate-card.yaml -o csv > costs.csv
+	`)
+)
+
+// NewCmdGetBuildCosts creates the command object for 'jx get build costs'
+func NewCmdGetBuildCosts(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetBuildCostsOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "costs",
+		Short:   "Displays the cost of the builds by team, repository and branch type",
+		Aliases: []string{"cost"},
+		Long:    getBuildCostsLong,
+		Example: getBuildCostsExample,
+		Run: func(c *cobra.Command, args []string) {
+			options.Cmd = c
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.AddGetFlags(cmd)
+
+	cmd.Flags().StringArrayVarP(&options.Teams, "team", "t", nil, "The teams to display the costs of. Defaults to the current team")
+	cmd.Flags().DurationVarP(&options.Since, "since", "s", 30*24*time.Hour, "The window of builds the costs are computed over")
+	cmd.Flags().StringSliceVarP(&options.GroupBy, optionGroupBy, "g", []string{metrics.GroupByTeam, metrics.GroupByRepository, metrics.GroupByBranchKind},
+		fmt.Sprintf("The dimensions to group the costs by. Valid values are %s", strings.Join(metrics.GroupByValues, ", ")))
+	cmd.Flags().StringVarP(&options.RateCard, "rate-card", "", "", "The YAML file of the rate card to price the builds with. Defaults to the rate card of the team")
+	cmd.Flags().BoolVarP(&options.PerBuild, "builds", "b", false, "Displays the cost of each build rather than grouping them")
+	return cmd
+}
+
+// Run implements this command
+func (o *GetBuildCostsOptions) Run() error {
+	err := metrics.ValidateGroupBy(o.GroupBy)
+	if err != nil {
+		return util.InvalidOptionf(optionGroupBy, strings.Join(o.GroupBy, ","), err.Error())
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	if len(o.Teams) == 0 {
+		o.Teams = []string{ns}
+	}
+	var rateCard *metrics.RateCard
+	if o.RateCard != "" {
+		data, err := ioutil.ReadFile(o.RateCard)
+		if err != nil {
+			return errors.Wrapf(err, "reading the rate card %s", o.RateCard)
+		}
+		rateCard, err = metrics.ParseRateCard(data)
+		if err != nil {
+			return errors.Wrapf(err, "in the rate card %s", o.RateCard)
+		}
+	}
+
+	since := time.Now().Add(-o.Since)
+	costs := []*metrics.BuildCost{}
+	for _, team := range o.Teams {
+		teamRateCard := rateCard
+		if teamRateCard == nil {
+			teamRateCard, err = metrics.LoadRateCard(kubeClient, team)
+			if err != nil {
+				return err
+			}
+		}
+		teamCosts, err := metrics.LoadBuildCosts(jxClient, kubeClient, team, since, teamRateCard)
+		if err != nil {
+			return err
+		}
+		costs = append(costs, teamCosts...)
+	}
+
+	if o.PerBuild {
+		return o.renderBuildCosts(costs, since)
+	}
+	groups := metrics.GroupBuildCosts(costs, o.GroupBy)
+	switch o.Output {
+	case "":
+	case "csv":
+		return o.renderGroupsCSV(groups)
+	default:
+		return o.renderResult(groups, o.Output)
+	}
+	if len(groups) == 0 {
+		log.Logger().Infof("No builds found in teams %s since %s", util.ColorInfo(strings.Join(o.Teams, ", ")), util.ColorInfo(since.Format(time.RFC3339)))
+		return nil
+	}
+	table := o.CreateTable()
+	header := []string{}
+	for _, g := range o.GroupBy {
+		header = append(header, strings.ToUpper(g))
+	}
+	table.AddRow(append(header, "BUILDS", "ESTIMATED", "BUILD HOURS", "CPU HOURS", "MEMORY GIB HOURS", "COST")...)
+	totals := map[string]float64{}
+	for _, g := range groups {
+		row := groupValues(g, o.GroupBy)
+		row = append(row,
+			fmt.Sprintf("%d", g.Builds),
+			fmt.Sprintf("%d", g.Estimated),
+			fmt.Sprintf("%.1f", g.BuildHours),
+			fmt.Sprintf("%.1f", g.CPUCoreHours),
+			fmt.Sprintf("%.1f", g.MemoryGiBHours),
+			formatCost(g.Cost, g.Currency))
+		table.AddRow(row...)
+		totals[g.Currency] += g.Cost
+	}
+	table.Render()
+	for currency, total := range totals {
+		log.Logger().Infof("\nTotal cost %s", util.ColorInfo(formatCost(total, currency)))
+	}
+	return nil
+}
+
+// renderBuildCosts renders the cost of each build
+func (o *GetBuildCostsOptions) renderBuildCosts(costs []*metrics.BuildCost, since time.Time) error {
+	switch o.Output {
+	case "":
+	case "csv":
+		rows := [][]string{{"team", "pipeline", "repository", "branch", "branch_type", "build", "started", "duration_seconds", "cpu_core_hours", "memory_gib_hours", "cost", "currency", "estimated"}}
+		for _, c := range costs {
+			rows = append(rows, []string{c.Team, c.Pipeline, c.Repository, c.Branch, c.BranchKind, c.Build,
+				c.Started.UTC().Format(time.RFC3339),
+				fmt.Sprintf("%.0f", c.Duration.Seconds()),
+				fmt.Sprintf("%.4f", c.CPUCoreHours),
+				fmt.Sprintf("%.4f", c.MemoryGiBHours),
+				fmt.Sprintf("%.4f", c.Cost),
+				c.Currency,
+				fmt.Sprintf("%t", c.Estimated)})
+		}
+		return o.writeCSV(rows)
+	default:
+		return o.renderResult(costs, o.Output)
+	}
+	if len(costs) == 0 {
+		log.Logger().Infof("No builds found in teams %s since %s", util.ColorInfo(strings.Join(o.Teams, ", ")), util.ColorInfo(since.Format(time.RFC3339)))
+		return nil
+	}
+	table := o.CreateTable()
+	table.AddRow("TEAM", "PIPELINE", "BUILD", "STARTED", "DURATION", "CPU HOURS", "MEMORY GIB HOURS", "COST")
+	for _, c := range costs {
+		cost := formatCost(c.Cost, c.Currency)
+		if c.Estimated {
+			cost += " (estimated)"
+		}
+		table.AddRow(c.Team, c.Pipeline, c.Build,
+			c.Started.Format(time.RFC3339),
+			c.Duration.Round(time.Second).String(),
+			fmt.Sprintf("%.2f", c.CPUCoreHours),
+			fmt.Sprintf("%.2f", c.MemoryGiBHours),
+			cost)
+	}
+	table.Render()
+	return nil
+}
+
+// renderGroupsCSV renders the grouped costs as CSV
+func (o *GetBuildCostsOptions) renderGroupsCSV(groups []*metrics.BuildCostGroup) error {
+	header := []string{}
+	for _, g := range o.GroupBy {
+		header = append(header, strings.Replace(g, "-", "_", -1))
+	}
+	rows := [][]string{append(header, "builds", "estimated", "build_hours", "cpu_core_hours", "memory_gib_hours", "cost", "currency")}
+	for _, g := range groups {
+		row := groupValues(g, o.GroupBy)
+		rows = append(rows, append(row,
+			fmt.Sprintf("%d", g.Builds),
+			fmt.Sprintf("%d", g.Estimated),
+			fmt.Sprintf("%.4f", g.BuildHours),
+			fmt.Sprintf("%.4f", g.CPUCoreHours),
+			fmt.Sprintf("%.4f", g.MemoryGiBHours),
+			fmt.Sprintf("%.4f", g.Cost),
+			g.Currency))
+	}
+	return o.writeCSV(rows)
+}
+
+func (o *GetBuildCostsOptions) writeCSV(rows [][]string) error {
+	w := csv.NewWriter(o.Out)
+	err := w.WriteAll(rows)
+	if err != nil {
+		return errors.Wrap(err, "writing the costs as CSV")
+	}
+	return nil
+}
+
+// groupValues returns the values of the dimensions the group is grouped by
+func groupValues(g *metrics.BuildCostGroup, groupBy []string) []string {
+	answer := []string{}
+	for _, dimension := range groupBy {
+		switch dimension {
+		case metrics.GroupByTeam:
+			answer = append(answer, g.Team)
+		case metrics.GroupByRepository:
+			answer = append(answer, g.Repository)
+		case metrics.GroupByBranchKind:
+			answer = append(answer, g.BranchKind)
+		case metrics.GroupByPipeline:
+			answer = append(answer, g.Pipeline)
+		}
+	}
+	return answer
+}
+
+func formatCost(cost float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", cost, currency)
+}
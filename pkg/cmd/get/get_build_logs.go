@@ -3,9 +3,11 @@ package get
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/builds"
 	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
@@ -34,6 +36,10 @@ type GetBuildLogsOptions struct {
 	WaitForPipelineDuration time.Duration
 	TektonLogger            *logs.TektonLogger
 	FailIfPodFails          bool
+	Stage                   string
+	Step                    string
+	Grep                    string
+	Since                   time.Duration
 }
 
 // CLILogWriter is an implementation of logs.LogWriter that will show logs in the standard output
@@ -45,6 +51,9 @@ var (
 	get_build_log_long = templates.LongDesc(`
 		Display a build log
 
+		The logs of completed builds which have been archived to long term storage can be filtered by stage, step,
+		regular expression and time. Only the logs of the matching steps are downloaded if the build has a log index.
+
 `)
 
 	get_build_log_example = templates.Examples(`
@@ -65,6 +74,15 @@ var (
 
 		# View the build logs for a specific tekton build pod
 		jx get build log --pod my-pod-name
+
+		# Find the errors in the archived log of a step of build 3 of the master branch of the repo cheese
+		jx get build log --repo cheese --branch master --build 3 --stage build --step build-mvn --grep ERROR
+
+		# View the archived logs of the last 10 minutes of a build
+		jx get build log --repo cheese --branch master --build 3 --since 10m
+
+		# List the PipelineActivities of the builds of the repo cheese whose logs can be viewed as JSON
+		jx get build log --repo cheese --output json
 	`)
 )
 
@@ -103,6 +121,11 @@ func NewCmdGetBuildLogs(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.BuildFilter.GitURL, "giturl", "g", "", "The git URL to filter on. If you specify a link to a github repository or PR we can filter the query of build pods accordingly")
 	cmd.Flags().StringVarP(&options.BuildFilter.Context, "context", "", "", "Filters the context of the build")
 	cmd.Flags().BoolVarP(&options.CurrentFolder, "current", "c", false, "Display logs using current folder as repo name, and parent folder as owner")
+	cmd.Flags().StringVarP(&options.Stage, "stage", "", "", "Only display the archived logs of the given stage")
+	cmd.Flags().StringVarP(&options.Step, "step", "", "", "Only display the archived logs of the given step")
+	cmd.Flags().StringVarP(&options.Grep, "grep", "", "", "Only display the lines of the archived logs which match the given regular expression")
+	cmd.Flags().DurationVarP(&options.Since, "since", "", 0, "Only display the lines of the archived logs which were logged within the given duration, such as 30m")
+	options.AddGetFlags(cmd)
 	options.AddBaseFlags(cmd)
 
 	return cmd
@@ -114,6 +137,16 @@ func (o *GetBuildLogsOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	_, err = o.archivedLogFilter()
+	if err != nil {
+		return err
+	}
+	if o.Output != "" {
+		err = opts.ValidateOutputFormat(o.Output)
+		if err != nil {
+			return err
+		}
+	}
 	jxClient, ns, err := o.JXClientAndDevNamespace()
 	if err != nil {
 		return err
@@ -155,6 +188,10 @@ func (o *GetBuildLogsOptions) getProwBuildLog(kubeClient kubernetes.Interface, t
 	var err error
 
 	if o.TektonLogger == nil {
+		logMasker, err := kube.NewLogMasker(kubeClient, ns)
+		if err != nil {
+			log.Logger().Debugf("failed to load all of the log masker secrets and patterns in namespace %s: %s", ns, err)
+		}
 		o.TektonLogger = &logs.TektonLogger{
 			KubeClient:   kubeClient,
 			TektonClient: tektonClient,
@@ -164,6 +201,7 @@ func (o *GetBuildLogsOptions) getProwBuildLog(kubeClient kubernetes.Interface, t
 				CommonOptions: o.CommonOptions,
 			},
 			FailIfPodFails: o.FailIfPodFails,
+			LogMasker:      logMasker,
 		}
 	}
 	var waitableCondition bool
@@ -208,6 +246,16 @@ func (o *GetBuildLogsOptions) getTektonLogs(kubeClient kubernetes.Interface, tek
 		}
 	}
 
+	if o.Output != "" {
+		list := &v1.PipelineActivityList{}
+		for _, n := range filteredNames {
+			if pa := paMap[n]; pa != nil {
+				list.Items = append(list.Items, *pa)
+			}
+		}
+		return false, o.renderResult(list, o.Output)
+	}
+
 	if o.BatchMode {
 		if len(filteredNames) > 1 {
 			return false, errors.New("more than one pipeline returned in batch mode, use better filters and try again")
@@ -232,14 +280,46 @@ func (o *GetBuildLogsOptions) getTektonLogs(kubeClient kubernetes.Interface, tek
 		if err != nil {
 			return false, err
 		}
+		filter, err := o.archivedLogFilter()
+		if err != nil {
+			return false, err
+		}
+		if filter != nil {
+			return false, o.TektonLogger.StreamArchivedLogs(pa.Spec.BuildLogsURL, filter, jxClient, ns, authSvc)
+		}
 		return false, o.TektonLogger.StreamPipelinePersistentLogs(pa.Spec.BuildLogsURL, jxClient, ns, authSvc)
 	}
+	if o.Stage != "" || o.Step != "" || o.Grep != "" || o.Since > 0 {
+		log.Logger().Warnf("the logs of %s have not been archived yet so the --stage, --step, --grep and --since filters are ignored", name)
+	}
 
 	log.Logger().Infof("Build logs for %s", util.ColorInfo(name))
 	name = strings.TrimSuffix(name, " ")
 	return false, o.TektonLogger.GetRunningBuildLogs(pa, name, false)
 }
 
+// archivedLogFilter returns the filter of the archived logs or nil if no filter flags were specified
+func (o *GetBuildLogsOptions) archivedLogFilter() (*logs.ArchivedLogFilter, error) {
+	if o.Stage == "" && o.Step == "" && o.Grep == "" && o.Since <= 0 {
+		return nil, nil
+	}
+	filter := &logs.ArchivedLogFilter{
+		Stage: o.Stage,
+		Step:  o.Step,
+	}
+	if o.Grep != "" {
+		regex, err := regexp.Compile(o.Grep)
+		if err != nil {
+			return nil, util.InvalidOptionError("grep", o.Grep, err)
+		}
+		filter.Grep = regex
+	}
+	if o.Since > 0 {
+		filter.Since = time.Now().Add(-o.Since)
+	}
+	return filter, nil
+}
+
 // StreamLog implementation of LogWriter.StreamLog for CLILogWriter, this implementation will tail logs for the provided pod /container through the defined logger
 func (o *CLILogWriter) StreamLog(lch <-chan logs.LogLine, ech <-chan error) error {
 	for {
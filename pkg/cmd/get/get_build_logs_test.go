@@ -4,6 +4,7 @@ package get
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -14,6 +15,7 @@ import (
 	"testing"
 
 	"github.com/acarl005/stripansi"
+	jxv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/logs"
@@ -21,6 +23,7 @@ import (
 
 	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/testhelpers"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/tekton/tekton_helpers_test"
 	"github.com/stretchr/testify/assert"
@@ -89,6 +92,52 @@ func TestGetTektonLogsForRunningBuild(t *testing.T) {
 	}
 }
 
+func TestGetTektonLogsOutput(t *testing.T) {
+	commonOpts := opts.NewCommonOptionsWithFactory(fake.NewFakeFactory())
+	commonOpts.BatchMode = true
+	testCaseDir := path.Join("test_data", "get_build_logs", "tekton_build_logs")
+
+	activities := tekton_helpers_test.AssertLoadSinglePipelineActivity(t, testCaseDir)
+	structure := tekton_helpers_test.AssertLoadSinglePipelineStructure(t, testCaseDir)
+	jxClient := jxfake.NewSimpleClientset(activities, structure)
+
+	tektonObjects := []runtime.Object{tekton_helpers_test.AssertLoadSinglePipelineRun(t, testCaseDir)}
+	tektonClient := tektonfake.NewSimpleClientset(tektonObjects...)
+
+	pod := tekton_helpers_test.AssertLoadSinglePod(t, testCaseDir)
+	kubeClient := kubeMocks.NewSimpleClientset(pod)
+
+	ns := "jx"
+
+	out := &testhelpers.FakeOut{}
+	commonOpts.Out = out
+	o := &GetBuildLogsOptions{
+		GetOptions: GetOptions{
+			CommonOptions: &commonOpts,
+			Output:        "json",
+		},
+		TektonLogger: &logs.TektonLogger{
+			KubeClient:        kubeClient,
+			JXClient:          jxClient,
+			TektonClient:      tektonClient,
+			Namespace:         ns,
+			LogsRetrieverFunc: LogsProvider,
+		},
+	}
+
+	_, err := o.getTektonLogs(kubeClient, tektonClient, jxClient, ns)
+	assert.NoError(t, err)
+
+	list := &jxv1.PipelineActivityList{}
+	err = json.Unmarshal([]byte(out.GetOutput()), list)
+	assert.NoError(t, err)
+	if assert.Len(t, list.Items, 1) {
+		assert.Equal(t, "fakeowner-fakerepo-fakebranch-1", list.Items[0].Name)
+		assert.Equal(t, "fakeowner/fakerepo/fakebranch", list.Items[0].Spec.Pipeline)
+	}
+	assert.NotContains(t, out.GetOutput(), "Build logs for")
+}
+
 func TestGetTektonLogsForRunningBuildWithPendingPod(t *testing.T) {
 	commonOpts := opts.NewCommonOptionsWithFactory(fake.NewFakeFactory())
 	commonOpts.BatchMode = true
@@ -21,6 +21,12 @@ type GetBuildPodsOptions struct {
 	BuildFilter builds.BuildPodInfoFilter
 }
 
+// BuildPodOutput the build pod rendered by --output
+type BuildPodOutput struct {
+	*builds.BuildPodInfo
+	Status string `json:"status"`
+}
+
 var (
 	getBiuldPodsLong = templates.LongDesc(`
 		Display the Tekton build pods
@@ -75,6 +81,7 @@ func NewCmdGetBuildPods(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.BuildFilter.Build, "build", "", "", "Filter a specific build number")
 	cmd.Flags().StringVarP(&options.BuildFilter.Context, "context", "", "", "Filters the context of the build")
 	cmd.Flags().StringVarP(&options.BuildFilter.GitURL, "giturl", "g", "", "The git URL to filter on. If you specify a link to a github repository or PR we can filter the query of build pods accordingly")
+	options.AddGetFlags(cmd)
 	return cmd
 }
 
@@ -97,9 +104,6 @@ func (o *GetBuildPodsOptions) Run() error {
 		return err
 	}
 
-	table := o.CreateTable()
-	table.AddRow("OWNER", "REPOSITORY", "BRANCH", "BUILD", "CONTEXT", "AGE", "STATUS", "POD", "GIT URL")
-
 	buildInfos := []*builds.BuildPodInfo{}
 	for _, pod := range pods {
 		buildInfo := builds.CreateBuildPodInfo(pod)
@@ -109,6 +113,17 @@ func (o *GetBuildPodsOptions) Run() error {
 	}
 	builds.SortBuildPodInfos(buildInfos)
 
+	if o.Output != "" {
+		results := []BuildPodOutput{}
+		for _, build := range buildInfos {
+			results = append(results, BuildPodOutput{BuildPodInfo: build, Status: build.Status()})
+		}
+		return o.renderResult(results, o.Output)
+	}
+
+	table := o.CreateTable()
+	table.AddRow("OWNER", "REPOSITORY", "BRANCH", "BUILD", "CONTEXT", "AGE", "STATUS", "POD", "GIT URL")
+
 	now := time.Now()
 	for _, build := range buildInfos {
 		duration := strings.TrimSuffix(now.Sub(build.CreatedTime).Round(time.Minute).String(), "0s")
@@ -1,6 +1,7 @@
 package get
 
 import (
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/builds"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/v2/pkg/util"
@@ -84,6 +85,15 @@ func (o *GetBuildPackOptions) Run() error {
 		if err != nil {
 			return err
 		}
+		if o.Output != "" {
+			list := &v1.BuildPackList{}
+			for _, name := range names {
+				if bp := m[name]; bp != nil {
+					list.Items = append(list.Items, *bp)
+				}
+			}
+			return o.renderResult(list, o.Output)
+		}
 		table.AddRow("BUILD PACK", "GIT URL", "GIT REF", "DEFAULT")
 		for _, name := range names {
 			bp := m[name]
@@ -99,6 +109,13 @@ func (o *GetBuildPackOptions) Run() error {
 			}
 		}
 	} else {
+		if o.Output != "" {
+			return o.renderResult(&v1.BuildPackSpec{
+				Label:  settings.BuildPackName,
+				GitURL: settings.BuildPackURL,
+				GitRef: settings.BuildPackRef,
+			}, o.Output)
+		}
 		table.AddRow(settings.BuildPackName, settings.BuildPackURL, settings.BuildPackRef)
 	}
 	table.Render()
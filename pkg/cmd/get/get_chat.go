@@ -56,6 +56,7 @@ func NewCmdGetChat(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&options.Kind, "kind", "k", "", "Filters the chats by the kinds: "+strings.Join(chats.ChatKinds, ", "))
+	options.AddGetFlags(cmd)
 	return cmd
 }
 
@@ -66,6 +67,9 @@ func (o *GetChatOptions) Run() error {
 		return err
 	}
 	config := authConfigSvc.Config()
+	if o.Output != "" {
+		return o.renderResult(authServersOutput(config.Servers, o.Kind), o.Output)
+	}
 
 	if len(config.Servers) == 0 {
 		log.Logger().Infof("No chat servers registered. To register a new chat servers use: %s", util.ColorInfo("jx create chat server"))
@@ -57,6 +57,7 @@ func NewCmdGetConfig(commonOpts *opts.CommonOptions) *cobra.Command {
 
 func (o *GetConfigOptions) addGetConfigFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.Dir, "dir", "d", "", "The root project directory")
+	o.AddGetFlags(cmd)
 }
 
 // Run implements this command
@@ -65,6 +66,9 @@ func (o *GetConfigOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		return o.renderResult(pc, o.Output)
+	}
 	if pc.IsEmpty() {
 		log.Logger().Info("No project configuration for this directory.")
 		log.Logger().Infof("To edit the configuration use: %s", util.ColorInfo("jx edit config"))
@@ -23,6 +23,16 @@ import (
 // CRDCountOptions the command line options
 type CRDCountOptions struct {
 	*opts.CommonOptions
+
+	Output string
+}
+
+// CRDCountOutput the number of resources of a custom resource definition version rendered by --output
+type CRDCountOutput struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Count     int    `json:"count"`
+	Namespace string `json:"namespace"`
 }
 
 type tableLine struct {
@@ -63,15 +73,29 @@ func NewCmdGetCRDCount(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
+	opts.AddOutputFlag(cmd, &options.Output)
 	return cmd
 }
 
 // Run implements this command
 func (o *CRDCountOptions) Run() error {
+	if o.Output != "" {
+		err := opts.ValidateOutputFormat(o.Output)
+		if err != nil {
+			return err
+		}
+	}
 	results, err := o.getCustomResourceCounts()
 	if err != nil {
 		return errors.Wrap(err, "cannot get custom resource counts")
 	}
+	if o.Output != "" {
+		counts := []CRDCountOutput{}
+		for _, r := range results {
+			counts = append(counts, CRDCountOutput{Name: r.name, Version: r.version, Count: r.count, Namespace: r.namespace})
+		}
+		return opts.RenderOutput(o.Out, counts, o.Output)
+	}
 
 	table := o.CreateTable()
 	table.AddRow("NAME", "VERSION", "COUNT", "NAMESPACE")
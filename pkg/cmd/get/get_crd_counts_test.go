@@ -3,6 +3,7 @@
 package get
 
 import (
+	"encoding/json"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -20,6 +21,7 @@ import (
 	kube_mocks "k8s.io/client-go/kubernetes/fake"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/testhelpers"
 )
 
 const (
@@ -70,6 +72,19 @@ func TestRun(t *testing.T) {
 	assert.Equal(t, 1, namespace1ScopedLine.count)
 	assert.Equal(t, 1, namespace2ScopedLine.count)
 
+	out := &testhelpers.FakeOut{}
+	o.Out = out
+	o.Output = "json"
+	err = o.Run()
+	assert.NoError(t, err)
+	counts := []CRDCountOutput{}
+	err = json.Unmarshal([]byte(out.GetOutput()), &counts)
+	assert.NoError(t, err)
+	assert.Equal(t, []CRDCountOutput{
+		{Name: "shiraz.wine.io", Version: "v1", Count: 1, Namespace: "cluster scoped"},
+		{Name: "rioja.wine.io", Version: "v1", Count: 1, Namespace: "cellar"},
+		{Name: "rioja.wine.io", Version: "v1", Count: 1, Namespace: "cellarx"},
+	}, counts)
 }
 
 func getNamespace(name string) *v1.Namespace {
@@ -74,6 +74,7 @@ func (o *GetCVEOptions) addGetCVEFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.ImageID, "image-id", "", "", "Image ID in CVE engine if already known")
 	cmd.Flags().StringVarP(&o.Version, "version", "", "", "Version or tag e.g. 0.0.1")
 	cmd.Flags().StringVarP(&o.Env, "environment", "e", "", "The Environment to find running applications")
+	o.AddGetFlags(cmd)
 }
 
 // Run implements this command
@@ -127,6 +128,13 @@ func (o *GetCVEOptions) Run() error {
 		query.TargetNamespace = targetNamespace
 	}
 
+	if o.Output != "" {
+		vulnerabilities, err := p.GetImageVulnerabilities(jxClient, client, query)
+		if err != nil {
+			return fmt.Errorf("error getting vulnerabilities for image %s: %v", query.ImageID, err)
+		}
+		return o.renderResult(vulnerabilities, o.Output)
+	}
 	err = p.GetImageVulnerabilityTable(jxClient, client, &table, query)
 	if err != nil {
 		return fmt.Errorf("error getting vulnerability table for image %s: %v", query.ImageID, err)
@@ -13,6 +13,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // GetDevPodOptions the command line options
@@ -60,6 +61,7 @@ func NewCmdGetDevPod(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&options.AllUsernames, "all-usernames", "", false, "Gets devpods for all usernames")
+	options.AddGetFlags(cmd)
 
 	options.AddCommonDevPodFlags(cmd)
 
@@ -92,6 +94,15 @@ func (o *GetDevPodOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "getting the DevPod names")
 	}
+	if o.Output != "" {
+		list := &corev1.PodList{}
+		for _, k := range names {
+			if pod := m[k]; pod != nil {
+				list.Items = append(list.Items, *pod)
+			}
+		}
+		return o.renderResult(list, o.Output)
+	}
 
 	table := o.CreateTable()
 	table.AddRow("NAME", "POD TEMPLATE", "AGE", "STATUS")
@@ -2,6 +2,7 @@ package get
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
@@ -89,6 +90,9 @@ func (o *GetEnvOptions) Run() error {
 			}
 			return util.InvalidArg(e, envNames)
 		}
+		if o.Output != "" {
+			return o.renderResult(env, o.Output)
+		}
 
 		// lets output one environment
 		spec := &env.Spec
@@ -100,7 +104,20 @@ func (o *GetEnvOptions) Run() error {
 		log.Blank()
 
 		ens := env.Spec.Namespace
-		if ens != "" {
+		if env.Spec.RemoteCluster {
+			remote := env.Status.Remote
+			if remote == nil {
+				log.Logger().Infof("No status has been reported yet by the environment controller in the remote cluster")
+				return nil
+			}
+			log.Logger().Infof("Remote cluster %s is %s at revision %s", util.ColorInfo(remote.Cluster), util.ColorInfo(string(remote.SyncStatus)), util.ColorInfo(remote.Revision))
+			table = o.CreateTable()
+			table.AddRow("APP", "VERSION", "DESIRED", "READY", "HEALTHY", "URL")
+			for _, app := range remote.Applications {
+				table.AddRow(app.Name, app.Version, formatInt32(app.Replicas), formatInt32(app.ReadyReplicas), strconv.FormatBool(app.Healthy), app.URL)
+			}
+			table.Render()
+		} else if ens != "" {
 			deps, err := kubeClient.AppsV1().Deployments(ens).List(metav1.ListOptions{})
 			if err != nil {
 				return fmt.Errorf("Could not find deployments in namespace %s: %s", ens, err)
@@ -122,11 +139,6 @@ func (o *GetEnvOptions) Run() error {
 		if err != nil {
 			return err
 		}
-		if len(envs.Items) == 0 {
-			log.Logger().Infof("No environments found.\nTo create an environment use: jx create env")
-			return nil
-		}
-
 		environments := o.filterEnvironments(envs.Items)
 		kube.SortEnvironments(environments)
 
@@ -134,6 +146,10 @@ func (o *GetEnvOptions) Run() error {
 			envs.Items = environments
 			return o.renderResult(envs, o.Output)
 		}
+		if len(envs.Items) == 0 {
+			log.Logger().Infof("No environments found.\nTo create an environment use: jx create env")
+			return nil
+		}
 		table := o.CreateTable()
 		if o.PreviewOnly {
 			table.AddRow("PULL REQUEST", "NAMESPACE", "APPLICATION")
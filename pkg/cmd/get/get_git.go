@@ -47,6 +47,7 @@ func NewCmdGetGit(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 
+	options.AddGetFlags(cmd)
 	return cmd
 }
 
@@ -57,6 +58,15 @@ func (o *GetGitOptions) Run() error {
 		return err
 	}
 	config := authConfigSvc.Config()
+	if o.Output != "" {
+		servers := authServersOutput(config.Servers, "")
+		for i := range servers {
+			if servers[i].Kind == "" {
+				servers[i].Kind = "github"
+			}
+		}
+		return o.renderResult(servers, o.Output)
+	}
 
 	table := o.CreateTable()
 	table.AddRow("Name", "Kind", "URL")
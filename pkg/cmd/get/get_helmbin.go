@@ -14,6 +14,13 @@ type GetHelmBinOptions struct {
 	GetOptions
 }
 
+// HelmBinOutput the helm binary of the team rendered by --output
+type HelmBinOutput struct {
+	Binary       string `json:"binary"`
+	NoTiller     bool   `json:"noTiller"`
+	HelmTemplate bool   `json:"helmTemplate"`
+}
+
 var (
 	getHelmBinLong = templates.LongDesc(`
 		Display the Helm binary name used in pipelines.
@@ -54,10 +61,13 @@ func NewCmdGetHelmBin(commonOpts *opts.CommonOptions) *cobra.Command {
 
 // Run implements this command
 func (o *GetHelmBinOptions) Run() error {
-	helm, _, _, err := o.TeamHelmBin()
+	helm, noTiller, helmTemplate, err := o.TeamHelmBin()
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		return o.renderResult(&HelmBinOutput{Binary: helm, NoTiller: noTiller, HelmTemplate: helmTemplate}, o.Output)
+	}
 	log.Logger().Infof("Your team uses the helm binary: %s", util.ColorInfo(helm))
 	log.Logger().Infof("To change this value use: %s", util.ColorInfo("jx edit helmbin helm3"))
 	return nil
@@ -27,6 +27,18 @@ type GetIssueOptions struct {
 	Id  string
 }
 
+// IssueStatusOutput the issue and the applications it has been deployed to rendered by --output
+type IssueStatusOutput struct {
+	IssueOutput
+	Deployments []IssueDeploymentOutput `json:"deployments"`
+}
+
+// IssueDeploymentOutput an application and environment an issue has been deployed to
+type IssueDeploymentOutput struct {
+	Application string `json:"application"`
+	Environment string `json:"environment"`
+}
+
 var (
 	GetIssueLong = templates.LongDesc(`
 		Display the status of an issue for a project.
@@ -97,6 +109,10 @@ func (o *GetIssueOptions) Run() error {
 	}
 
 	found := false
+	result := IssueStatusOutput{
+		IssueOutput: newIssueOutput(issue),
+		Deployments: []IssueDeploymentOutput{},
+	}
 	for _, env := range envList.Items {
 		envNs, err := kube.GetEnvironmentNamespace(client, ns, env.Name)
 		if err != nil {
@@ -118,10 +134,14 @@ func (o *GetIssueOptions) Run() error {
 		for _, app := range apps {
 			if o.match(issue.URL, app) {
 				table.AddRow(issue.URL, *issue.State, app, env.Name)
+				result.Deployments = append(result.Deployments, IssueDeploymentOutput{Application: app, Environment: env.Name})
 				found = true
 			}
 		}
 	}
+	if o.Output != "" {
+		return o.renderResult(result, o.Output)
+	}
 	if !found {
 		table.AddRow(issue.URL, *issue.State, "", "")
 	}
@@ -1,7 +1,10 @@
 package get
 
 import (
+	"time"
+
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
 
 	"github.com/spf13/cobra"
 
@@ -16,6 +19,49 @@ type GetIssuesOptions struct {
 	Filter string
 }
 
+// IssueOutput the issue rendered by --output
+type IssueOutput struct {
+	URL       string     `json:"url"`
+	Key       string     `json:"key,omitempty"`
+	Owner     string     `json:"owner,omitempty"`
+	Repo      string     `json:"repo,omitempty"`
+	Number    *int       `json:"number,omitempty"`
+	Title     string     `json:"title"`
+	State     string     `json:"state,omitempty"`
+	Labels    []string   `json:"labels"`
+	Assignees []string   `json:"assignees"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+}
+
+// newIssueOutput returns the issue to render
+func newIssueOutput(issue *gits.GitIssue) IssueOutput {
+	answer := IssueOutput{
+		URL:       issue.URL,
+		Key:       issue.Key,
+		Owner:     issue.Owner,
+		Repo:      issue.Repo,
+		Number:    issue.Number,
+		Title:     issue.Title,
+		Labels:    []string{},
+		Assignees: []string{},
+		CreatedAt: issue.CreatedAt,
+		UpdatedAt: issue.UpdatedAt,
+		ClosedAt:  issue.ClosedAt,
+	}
+	if issue.State != nil {
+		answer.State = *issue.State
+	}
+	for _, l := range issue.Labels {
+		answer.Labels = append(answer.Labels, l.Name)
+	}
+	for _, a := range issue.Assignees {
+		answer.Assignees = append(answer.Assignees, a.Login)
+	}
+	return answer
+}
+
 var (
 	GetIssuesLong = templates.LongDesc(`
 		Display one or more issues for a project.
@@ -66,6 +112,13 @@ func (o *GetIssuesOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		results := []IssueOutput{}
+		for _, i := range issues {
+			results = append(results, newIssueOutput(i))
+		}
+		return o.renderResult(results, o.Output)
+	}
 
 	table := o.CreateTable()
 	table.AddRow("ISSUE", "TITLE")
@@ -19,6 +19,11 @@ type GetLangOptions struct {
 	Pending bool
 }
 
+// LangOutput the language pack of the current directory rendered by --output
+type LangOutput struct {
+	Pack string `json:"pack"`
+}
+
 var (
 	getPackLong = templates.LongDesc(`
 		Display the pack of the current directory
@@ -72,7 +77,10 @@ func (o *GetLangOptions) Run() error {
 	//	DisableAddFiles:    true,
 	//	UseNextGenPipeline: false,
 	//}
-	_, err = o.StepOptions.DiscoverBuildPack(dir, projectConfig, "")
+	pack, err := o.StepOptions.DiscoverBuildPack(dir, projectConfig, "")
 	//_, err = o.InvokeDraftPack(args)
-	return err
+	if err != nil || o.Output == "" {
+		return err
+	}
+	return o.renderResult(&LangOutput{Pack: pack}, o.Output)
 }
@@ -38,6 +38,14 @@ type GetLimitsOptions struct {
 	GetOptions
 }
 
+// UserLimitsOutput the rate limits of a git user rendered by --output
+type UserLimitsOutput struct {
+	Name      string        `json:"name"`
+	URL       string        `json:"url"`
+	Username  string        `json:"username"`
+	Resources RateResources `json:"resources"`
+}
+
 var (
 	get_limits_long = templates.LongDesc(`
 		Display the github limits for users
@@ -72,6 +80,7 @@ func NewCmdGetLimits(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 
+	options.AddGetFlags(cmd)
 	return cmd
 }
 
@@ -85,6 +94,7 @@ func (o *GetLimitsOptions) Run() error {
 
 	table := o.CreateTable()
 	table.AddRow("Name", "URL", "Username", "Limit", "Remaining", "Reset")
+	results := []UserLimitsOutput{}
 
 	for _, s := range config.Servers {
 		kind := s.Kind
@@ -98,6 +108,7 @@ func (o *GetLimitsOptions) Run() error {
 				if err != nil {
 					return err
 				}
+				results = append(results, UserLimitsOutput{Name: s.Name, URL: s.URL, Username: u.Username, Resources: r.Resources})
 
 				resetLabel := ""
 				if 0 != r.Resources.Core.Reset {
@@ -111,6 +122,9 @@ func (o *GetLimitsOptions) Run() error {
 		}
 
 	}
+	if o.Output != "" {
+		return o.renderResult(results, o.Output)
+	}
 	table.Render()
 
 	return nil
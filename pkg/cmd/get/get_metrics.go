@@ -0,0 +1,44 @@
+package get
+
+import (
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/spf13/cobra"
+)
+
+// GetMetricsOptions the command line options
+type GetMetricsOptions struct {
+	GetOptions
+}
+
+var (
+	getMetricsExample = templates.Examples(`
+		# Display the delivery metrics of each app and environment
+		jx get metrics delivery
+	`)
+)
+
+// NewCmdGetMetrics creates the command object for 'jx get metrics'
+func NewCmdGetMetrics(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetMetricsOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "metrics",
+		Short:   "Display metrics about the pipelines and deployments",
+		Aliases: []string{"metric"},
+		Example: getMetricsExample,
+		Run: func(c *cobra.Command, args []string) {
+			options.Cmd = c
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(NewCmdGetMetricsDelivery(commonOpts))
+	return cmd
+}
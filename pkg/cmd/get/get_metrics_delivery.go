@@ -0,0 +1,127 @@
+package get
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// GetMetricsDeliveryOptions the command line options
+type GetMetricsDeliveryOptions struct {
+	GetOptions
+
+	Team        string
+	App         string
+	Environment string
+	Since       time.Duration
+}
+
+var (
+	getMetricsDeliveryLong = templates.LongDesc(`
+		Displays the four DORA delivery metrics of each app and environment computed from the PipelineActivity and
+		Release resources of a team:
+
+		* deployment frequency - the number of successful deployments per day
+		* lead time for changes - the mean time from a change being built to it being deployed
+		* change failure rate - the percentage of deployments which failed
+		* mean time to restore - the mean time from a failed deployment to the next successful one
+
+		The same metrics are exposed to Prometheus by 'jx controller metrics'.
+`)
+
+	getMetricsDeliveryExample = templates.Examples(`
+		# Display the delivery metrics of the current team over the last 30 days
+		jx get metrics delivery
+
+		# Display the delivery metrics of an app over the last week
+		jx get metrics delivery --app myapp --since 168h
+
+		# Display the delivery metrics of another team as YAML
+		jx get metrics delivery --team cheese -o yaml
+	`)
+)
+
+// NewCmdGetMetricsDelivery creates the command object for 'jx get metrics delivery'
+func NewCmdGetMetricsDelivery(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetMetricsDeliveryOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "delivery",
+		Short:   "Displays the DORA delivery metrics of each app and environment",
+		Aliases: []string{"dora"},
+		Long:    getMetricsDeliveryLong,
+		Example: getMetricsDeliveryExample,
+		Run: func(c *cobra.Command, args []string) {
+			options.Cmd = c
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.AddGetFlags(cmd)
+
+	cmd.Flags().StringVarP(&options.Team, "team", "t", "", "The team to display the metrics of. Defaults to the current team")
+	cmd.Flags().StringVarP(&options.App, "app", "a", "", "The app to display the metrics of")
+	cmd.Flags().StringVarP(&options.Environment, "env", "e", "", "The environment to display the metrics of")
+	cmd.Flags().DurationVarP(&options.Since, "since", "s", 30*24*time.Hour, "The window of deployments the metrics are computed over")
+	return cmd
+}
+
+// Run implements this command
+func (o *GetMetricsDeliveryOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	if o.Team != "" {
+		ns = o.Team
+	}
+	filter := &metrics.DeliveryFilter{
+		App:         o.App,
+		Environment: o.Environment,
+		Since:       time.Now().Add(-o.Since),
+	}
+	results, err := metrics.LoadDeliveryMetrics(jxClient, ns, filter)
+	if err != nil {
+		return err
+	}
+	if o.Output != "" {
+		return o.renderResult(results, o.Output)
+	}
+	if len(results) == 0 {
+		log.Logger().Infof("No deployments found in team %s since %s", util.ColorInfo(ns), util.ColorInfo(filter.Since.Format(time.RFC3339)))
+		return nil
+	}
+	table := o.CreateTable()
+	table.AddRow("APP", "ENVIRONMENT", "DEPLOYMENTS", "FAILED", "DEPLOYS/DAY", "LEAD TIME", "FAILURE RATE", "MTTR")
+	for _, m := range results {
+		table.AddRow(m.App, m.Environment,
+			fmt.Sprintf("%d", m.Deployments),
+			fmt.Sprintf("%d", m.FailedDeployments),
+			fmt.Sprintf("%.2f", m.DeploymentFrequency),
+			formatMetricDuration(m.LeadTime),
+			fmt.Sprintf("%.0f%%", m.ChangeFailureRate*100),
+			formatMetricDuration(m.MeanTimeToRestore))
+	}
+	table.Render()
+	return nil
+}
+
+func formatMetricDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Minute).String()
+}
@@ -0,0 +1,1104 @@
+// +build unit
+
+package get_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/bank-vaults/operator/pkg/apis/vault/v1alpha1"
+	vaultoperatorclient "github.com/banzaicloud/bank-vaults/operator/pkg/client/clientset/versioned"
+	fake_vaultoperatorclient "github.com/banzaicloud/bank-vaults/operator/pkg/client/clientset/versioned/fake"
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/builds"
+	clientsfake "github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
+	createoptions "github.com/jenkins-x/jx/v2/pkg/cmd/create/options"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/get"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/testhelpers"
+	"github.com/jenkins-x/jx/v2/pkg/extensions"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	helm_test "github.com/jenkins-x/jx/v2/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/v2/pkg/jenkinsfile/gitresolver"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	resources_test "github.com/jenkins-x/jx/v2/pkg/kube/resources/mocks"
+	"github.com/jenkins-x/jx/v2/pkg/kube/services"
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
+	"github.com/jenkins-x/jx/v2/pkg/vault"
+	fake_vault "github.com/jenkins-x/jx/v2/pkg/vault/fake"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/petergtz/pegomock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// newOutputTestOptions returns the common options with fake clients populated with the given resources and the fake
+// standard output the commands render to
+func newOutputTestOptions(k8sObjects []runtime.Object, jxObjects []runtime.Object) (*opts.CommonOptions, *testhelpers.FakeOut) {
+	out := &testhelpers.FakeOut{}
+	commonOpts := &opts.CommonOptions{Out: out}
+	commonOpts.SetDevNamespace("jx")
+	testhelpers.ConfigureTestOptionsWithResources(commonOpts, k8sObjects, jxObjects,
+		&gits.GitFake{CurrentBranch: "master"},
+		&gits.FakeProvider{},
+		helm_test.NewMockHelmer(),
+		resources_test.NewMockInstaller(),
+	)
+	testhelpers.SetFakeFactoryFromKubeClients(commonOpts)
+	return commonOpts, out
+}
+
+// outputTestFactory a fake factory which returns the given addon server, vault client and vault operator client
+type outputTestFactory struct {
+	*clientsfake.FakeFactory
+
+	addonServer         *auth.AuthServer
+	vaultClient         vault.Client
+	vaultOperatorClient vaultoperatorclient.Interface
+}
+
+func (f *outputTestFactory) CreateAddonAuthConfigService(namespace string, serviceKind string) (auth.ConfigService, error) {
+	configService := auth.NewMemoryAuthConfigService()
+	configService.SetConfig(&auth.AuthConfig{Servers: []*auth.AuthServer{f.addonServer}})
+	return configService, nil
+}
+
+func (f *outputTestFactory) CreateSystemVaultClient(namespace string) (vault.Client, error) {
+	return f.vaultClient, nil
+}
+
+func (f *outputTestFactory) CreateVaultClient(name string, namespace string) (vault.Client, error) {
+	return f.vaultClient, nil
+}
+
+func (f *outputTestFactory) CreateVaultOperatorClient() (vaultoperatorclient.Interface, error) {
+	return f.vaultOperatorClient, nil
+}
+
+// setOutputTestFactory replaces the factory of the options with one which returns the given addon server and vault
+// clients
+func setOutputTestFactory(commonOpts *opts.CommonOptions, f *outputTestFactory) {
+	apiClient, _ := commonOpts.ApiExtensionsClient()
+	jxClient, _, _ := commonOpts.JXClient()
+	kubeClient, _ := commonOpts.KubeClient()
+	f.FakeFactory = clientsfake.NewFakeFactoryFromClients(apiClient, jxClient, kubeClient, nil, nil)
+	f.FakeFactory.SetDelegateFactory(commonOpts.GetFactory())
+	commonOpts.SetFactory(f)
+}
+
+// useTestJxHome points JX_HOME at a temporary directory for the commands which read the local configuration and
+// returns the directory and a function which restores JX_HOME
+func useTestJxHome(t *testing.T) (string, func()) {
+	originalDir, tempDir, err := testhelpers.CreateTestJxHomeDir()
+	require.NoError(t, err)
+	return tempDir, func() {
+		err := testhelpers.CleanupTestJxHomeDir(originalDir, tempDir)
+		assert.NoError(t, err)
+	}
+}
+
+// newDevEnvironment returns the dev environment of the team in the jx namespace
+func newDevEnvironment() *v1.Environment {
+	devEnv := kube.NewPermanentEnvironment("dev")
+	devEnv.Spec.Namespace = "jx"
+	devEnv.Spec.Kind = v1.EnvironmentKindTypeDevelopment
+	return devEnv
+}
+
+// parseJSON parses the JSON output of a command into its generic maps and slices
+func parseJSON(t *testing.T, out *testhelpers.FakeOut) interface{} {
+	var answer interface{}
+	err := json.Unmarshal([]byte(out.GetOutput()), &answer)
+	require.NoError(t, err, "parsing the output %s", out.GetOutput())
+	return answer
+}
+
+func parseJSONList(t *testing.T, out *testhelpers.FakeOut) []interface{} {
+	list, ok := parseJSON(t, out).([]interface{})
+	require.True(t, ok, "the output is not a JSON array: %s", out.GetOutput())
+	return list
+}
+
+func parseJSONItems(t *testing.T, out *testhelpers.FakeOut) []interface{} {
+	object, ok := parseJSON(t, out).(map[string]interface{})
+	require.True(t, ok, "the output is not a JSON object: %s", out.GetOutput())
+	items, ok := object["items"].([]interface{})
+	require.True(t, ok, "the output has no items: %s", out.GetOutput())
+	return items
+}
+
+func field(t *testing.T, value interface{}, path ...string) interface{} {
+	for _, name := range path {
+		object, ok := value.(map[string]interface{})
+		require.True(t, ok, "expected an object with the field %s but got %v", name, value)
+		value = object[name]
+	}
+	return value
+}
+
+func TestGetActivityOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+	jxClient, ns, err := commonOpts.JXClient()
+	require.NoError(t, err)
+	_, err = testhelpers.CreateTestPipelineActivityWithTime(jxClient, ns, "jx-testing", "jx-testing", "master", "1", "workflow", metav1.Date(2019, time.October, 10, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	_, err = testhelpers.CreateTestPipelineActivityWithTime(jxClient, ns, "jx-testing", "other", "master", "2", "workflow", metav1.Date(2019, time.October, 11, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	options := &get.GetActivityOptions{
+		CommonOptions: commonOpts,
+		Filter:        "jx-testing/jx-testing",
+		Output:        "json",
+	}
+	err = options.Run()
+	require.NoError(t, err)
+	items := parseJSONItems(t, out)
+	require.Len(t, items, 1)
+	assert.Equal(t, "jx-testing/jx-testing/master", field(t, items[0], "spec", "pipeline"))
+	assert.Equal(t, "1", field(t, items[0], "spec", "build"))
+	assert.NotEmpty(t, field(t, items[0], "metadata", "name"))
+
+	out = &testhelpers.FakeOut{}
+	commonOpts.Out = out
+	options.Filter = ""
+	options.Output = "jsonpath={.items[*].spec.pipeline}"
+	err = options.Run()
+	require.NoError(t, err)
+	assert.Contains(t, out.GetOutput(), "jx-testing/other/master")
+
+	options.Output = "table"
+	assert.Error(t, options.Run())
+}
+
+func TestGetEnvOutput(t *testing.T) {
+	staging := kube.NewPermanentEnvironment("staging")
+	staging.Spec.Namespace = "jx-staging"
+	commonOpts, out := newOutputTestOptions(nil, []runtime.Object{staging})
+
+	options := &get.GetEnvOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	items := parseJSONItems(t, out)
+	names := []interface{}{}
+	for _, item := range items {
+		names = append(names, field(t, item, "metadata", "name"))
+	}
+	assert.Contains(t, names, "staging")
+
+	out = &testhelpers.FakeOut{}
+	commonOpts.Out = out
+	options.Args = []string{"staging"}
+	err = options.Run()
+	require.NoError(t, err)
+	env := parseJSON(t, out)
+	assert.Equal(t, "staging", field(t, env, "metadata", "name"))
+	assert.Equal(t, "jx-staging", field(t, env, "spec", "namespace"))
+}
+
+func TestGetBuildPodsOutput(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jx-testing-myapp-master-1-build-pod",
+			Namespace: "jx",
+			Labels:    map[string]string{builds.LabelBuildName: "jx-testing-myapp-master-1"},
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{
+					Name: "build-step-build",
+					Env: []corev1.EnvVar{
+						{Name: "REPO_OWNER", Value: "jx-testing"},
+						{Name: "REPO_NAME", Value: "myapp"},
+						{Name: "BRANCH_NAME", Value: "master"},
+						{Name: "JX_BUILD_NUMBER", Value: "1"},
+					},
+				},
+			},
+			Containers: []corev1.Container{{Name: "nop"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	commonOpts, out := newOutputTestOptions([]runtime.Object{pod}, nil)
+
+	options := &get.GetBuildPodsOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, pod.Name, field(t, list[0], "podName"))
+	assert.Equal(t, "jx-testing", field(t, list[0], "organisation"))
+	assert.Equal(t, "myapp", field(t, list[0], "repository"))
+	assert.Equal(t, "master", field(t, list[0], "branch"))
+	assert.Equal(t, "1", field(t, list[0], "build"))
+	assert.Equal(t, "Running", field(t, list[0], "status"))
+	assert.Nil(t, field(t, list[0], "Pod"))
+}
+
+func TestGetReleaseOutput(t *testing.T) {
+	release := &v1.Release{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-1.0.1", Namespace: "jx"},
+		Spec:       v1.ReleaseSpec{Name: "myapp", Version: "1.0.1"},
+	}
+	commonOpts, out := newOutputTestOptions(nil, []runtime.Object{release})
+
+	options := &get.GetReleaseOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "yaml"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	assert.Contains(t, out.GetOutput(), "items:\n")
+	assert.Contains(t, out.GetOutput(), "version: 1.0.1\n")
+
+	out = &testhelpers.FakeOut{}
+	commonOpts.Out = out
+	options.Output = "go-template={{range .items}}{{.spec.name}}@{{.spec.version}}{{end}}"
+	err = options.Run()
+	require.NoError(t, err)
+	assert.Equal(t, "myapp@1.0.1", out.GetOutput())
+}
+
+func TestGetStorageOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetStorageOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, len(kube.Classifications))
+	classifiers := []interface{}{}
+	for _, location := range list {
+		classifiers = append(classifiers, field(t, location, "classifier"))
+	}
+	for _, c := range kube.Classifications {
+		assert.Contains(t, classifiers, c)
+	}
+}
+
+func TestGetBranchPatternOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetBranchPatternOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	patterns := parseJSON(t, out)
+	assert.NotEmpty(t, field(t, patterns, "defaultBranchPattern"))
+}
+
+func TestGetTeamRoleOutput(t *testing.T) {
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "viewer",
+			Namespace:   "jx",
+			Labels:      map[string]string{kube.LabelKind: kube.ValueKindEnvironmentRole},
+			Annotations: map[string]string{kube.AnnotationTitle: "Viewer"},
+		},
+	}
+	commonOpts, out := newOutputTestOptions([]runtime.Object{role}, nil)
+
+	options := &get.GetTeamRoleOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	items := parseJSONItems(t, out)
+	require.Len(t, items, 1)
+	assert.Equal(t, "viewer", field(t, items[0], "metadata", "name"))
+	assert.Equal(t, "Viewer", field(t, items[0], "metadata", "annotations", kube.AnnotationTitle))
+}
+
+func TestGetURLOutput(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "myapp",
+			Namespace:   "jx",
+			Annotations: map[string]string{services.ExposeURLAnnotation: "https://myapp.jx.example.com/path"},
+		},
+	}
+	commonOpts, out := newOutputTestOptions([]runtime.Object{svc}, nil)
+
+	options := &get.GetURLOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "myapp", field(t, list[0], "name"))
+	assert.Equal(t, "https://myapp.jx.example.com/path", field(t, list[0], "url"))
+
+	out = &testhelpers.FakeOut{}
+	commonOpts.Out = out
+	options.OnlyViewHost = true
+	options.Output = "jsonpath={[0].url}"
+	err = options.Run()
+	require.NoError(t, err)
+	assert.Equal(t, "myapp.jx.example.com", out.GetOutput())
+}
+
+func TestGetAddonOutput(t *testing.T) {
+	_, cleanup := useTestJxHome(t)
+	defer cleanup()
+	pegomock.RegisterMockTestingT(t)
+	commonOpts, out := newOutputTestOptions(nil, nil)
+	pegomock.When(commonOpts.Helm().ListReleases(pegomock.EqString("jx"))).ThenReturn(
+		map[string]helm.ReleaseSummary{
+			"anchore": {ReleaseName: "anchore", Status: "DEPLOYED", ChartVersion: "1.0.0"},
+		}, []string{"anchore"}, nil)
+
+	options := &get.GetAddonOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "anchore", field(t, list[0], "name"))
+	assert.Equal(t, kube.AddonCharts["anchore"], field(t, list[0], "chart"))
+	assert.Equal(t, false, field(t, list[0], "enabled"))
+	assert.Equal(t, "DEPLOYED", field(t, list[0], "status"))
+	assert.Equal(t, "1.0.0", field(t, list[0], "version"))
+}
+
+func TestGetApplicationsOutput(t *testing.T) {
+	sr := &v1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "jx-testing-myapp", Namespace: "jx"},
+		Spec:       v1.SourceRepositorySpec{Org: "jx-testing", Repo: "myapp"},
+	}
+	staging := kube.NewPermanentEnvironment("staging")
+	staging.Spec.RemoteCluster = true
+	staging.Status.Remote = &v1.RemoteEnvironmentStatus{
+		Applications: []v1.RemoteApplicationStatus{
+			{Name: "myapp", Version: "1.0.1", Replicas: 1, ReadyReplicas: 1, URL: "https://myapp.jx-staging.example.com"},
+		},
+	}
+	commonOpts, out := newOutputTestOptions(nil, []runtime.Object{sr, staging})
+
+	options := &get.GetApplicationsOptions{
+		CommonOptions: commonOpts,
+		Output:        "json",
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "myapp", field(t, list[0], "name"))
+	deployments, ok := field(t, list[0], "deployments").([]interface{})
+	require.True(t, ok, "deployments should be a list")
+	require.Len(t, deployments, 1)
+	assert.Equal(t, "staging", field(t, deployments[0], "environment"))
+	assert.Equal(t, "jx-staging", field(t, deployments[0], "namespace"))
+	assert.Equal(t, "1.0.1", field(t, deployments[0], "version"))
+	assert.Equal(t, "1/1", field(t, deployments[0], "pods"))
+	assert.Equal(t, "https://myapp.jx-staging.example.com", field(t, deployments[0], "url"))
+}
+
+func TestGetApplicationsOutputNamespacePrefix(t *testing.T) {
+	sr := &v1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "jx-testing-myapp", Namespace: "jx"},
+		Spec:       v1.SourceRepositorySpec{Org: "jx-testing", Repo: "myapp"},
+	}
+	staging := kube.NewPermanentEnvironment("staging")
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jx-staging-myapp",
+			Namespace: "jx-staging",
+			Labels:    map[string]string{"version": "1.0.2"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "jx-staging-myapp"},
+			},
+		},
+	}
+	commonOpts, out := newOutputTestOptions([]runtime.Object{deployment}, []runtime.Object{sr, staging})
+
+	options := &get.GetApplicationsOptions{
+		CommonOptions: commonOpts,
+		Output:        "json",
+		HidePod:       true,
+		HideUrl:       true,
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	deployments, ok := field(t, list[0], "deployments").([]interface{})
+	require.True(t, ok, "deployments should be a list")
+	require.Len(t, deployments, 1)
+	assert.Equal(t, "staging", field(t, deployments[0], "environment"))
+	assert.Equal(t, "jx-staging", field(t, deployments[0], "namespace"))
+	assert.Equal(t, "myapp", field(t, deployments[0], "name"), "the namespace prefix should be removed from the deployment name")
+	assert.Equal(t, "1.0.2", field(t, deployments[0], "version"))
+}
+
+func TestGetBuildCostsOutput(t *testing.T) {
+	started := metav1.NewTime(time.Now().Add(-time.Hour))
+	completed := metav1.NewTime(time.Now().Add(-30 * time.Minute))
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "jx-testing-myapp-master-1", Namespace: "jx"},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:           "jx-testing/myapp/master",
+			Build:              "1",
+			GitOwner:           "jx-testing",
+			GitRepository:      "myapp",
+			GitBranch:          "master",
+			StartedTimestamp:   &started,
+			CompletedTimestamp: &completed,
+		},
+	}
+	commonOpts, out := newOutputTestOptions(nil, []runtime.Object{activity})
+
+	options := &get.GetBuildCostsOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		Since:      24 * time.Hour,
+		GroupBy:    []string{metrics.GroupByRepository},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "jx-testing/myapp", field(t, list[0], "repository"))
+	assert.Equal(t, float64(1), field(t, list[0], "builds"))
+	assert.NotNil(t, field(t, list[0], "cost"))
+
+	out = &testhelpers.FakeOut{}
+	commonOpts.Out = out
+	options.PerBuild = true
+	err = options.Run()
+	require.NoError(t, err)
+	list = parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "1", field(t, list[0], "build"))
+	assert.Equal(t, "master", field(t, list[0], "branch"))
+	assert.Equal(t, true, field(t, list[0], "estimated"))
+}
+
+func TestGetBuildPackOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetBuildPackOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	buildPack := parseJSON(t, out)
+	assert.NotEmpty(t, field(t, buildPack, "gitUrl"))
+
+	out = &testhelpers.FakeOut{}
+	commonOpts.Out = out
+	options.All = true
+	err = options.Run()
+	require.NoError(t, err)
+	items := parseJSONItems(t, out)
+	require.NotEmpty(t, items)
+	assert.NotEmpty(t, field(t, items[0], "spec", "label"))
+}
+
+func TestGetChatOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetChatOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		Kind:       "slack",
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "slack", field(t, list[0], "kind"))
+	assert.Equal(t, "https://fake-server.org", field(t, list[0], "url"))
+	assert.Equal(t, "fake-username", field(t, list[0], "currentUser"))
+	assert.NotContains(t, out.GetOutput(), "fake-token")
+}
+
+func TestGetTrackerOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetTrackerOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "https://fake-server.org", field(t, list[0], "url"))
+	assert.NotContains(t, out.GetOutput(), "fake-token")
+}
+
+func TestGetGitOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetGitOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, gits.KindGitFake, field(t, list[0], "kind"))
+	assert.Equal(t, "https://fake-server.org", field(t, list[0], "url"))
+	assert.NotContains(t, out.GetOutput(), "fake-token")
+}
+
+func TestGetTokenAddonOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetTokenAddonOptions{
+		GetTokenOptions: get.GetTokenOptions{
+			GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "https://fake-server.org", field(t, list[0], "url"))
+	assert.Equal(t, "fake-username", field(t, list[0], "username"))
+	assert.Equal(t, true, field(t, list[0], "hasToken"))
+	assert.NotContains(t, out.GetOutput(), "fake-token")
+}
+
+func TestGetLimitsOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetLimitsOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	assert.Empty(t, parseJSONList(t, out))
+}
+
+func TestGetConfigOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-get-config-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "jenkins-x.yml"), []byte("buildPack: maven\n"), 0600)
+	require.NoError(t, err)
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetConfigOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		Dir:        dir,
+	}
+	err = options.Run()
+	require.NoError(t, err)
+	assert.Equal(t, "maven", field(t, parseJSON(t, out), "buildPack"))
+}
+
+func TestGetCVEOutput(t *testing.T) {
+	imageID := "1234567890"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/images/by_id/" + imageID + "/vuln/os":
+			_, _ = w.Write([]byte(`{"imageDigest":"sha256:abc","vulnerabilities":[{"fix":"1.1","package":"openssl","severity":"High","url":"https://cve.example.com/CVE-2019-0001","vuln":"CVE-2019-0001"}]}`))
+		case "/images/sha256:abc":
+			_, _ = w.Write([]byte(`[{"image_detail":[{"fulltag":"docker.io/myapp:1.0.1"}]}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        kube.AddonServices["anchore"],
+			Namespace:   "jx",
+			Annotations: map[string]string{services.ExposeURLAnnotation: ts.URL},
+		},
+	}
+	commonOpts, out := newOutputTestOptions([]runtime.Object{svc}, nil)
+	setOutputTestFactory(commonOpts, &outputTestFactory{
+		addonServer: &auth.AuthServer{
+			URL:         ts.URL,
+			Kind:        kube.ValueKindCVE,
+			Users:       []*auth.UserAuth{{Username: "admin", Password: "secret"}},
+			CurrentUser: "admin",
+		},
+	})
+
+	options := &get.GetCVEOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		ImageID:    imageID,
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "docker.io/myapp:1.0.1", field(t, list[0], "image"))
+	assert.Equal(t, "High", field(t, list[0], "severity"))
+	assert.Equal(t, "CVE-2019-0001", field(t, list[0], "vulnerability"))
+	assert.Equal(t, "https://cve.example.com/CVE-2019-0001", field(t, list[0], "url"))
+	assert.Equal(t, "openssl", field(t, list[0], "package"))
+	assert.Equal(t, "1.1", field(t, list[0], "fix"))
+}
+
+func TestGetDevPodOutput(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jstrachan-maven",
+			Namespace: "jx",
+			Labels: map[string]string{
+				kube.LabelDevPodName:     "jstrachan-maven",
+				kube.LabelDevPodUsername: "jstrachan",
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	commonOpts, out := newOutputTestOptions([]runtime.Object{pod}, nil)
+
+	options := &get.GetDevPodOptions{
+		GetOptions:   get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		AllUsernames: true,
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	items := parseJSONItems(t, out)
+	require.Len(t, items, 1)
+	assert.Equal(t, "jstrachan-maven", field(t, items[0], "metadata", "name"))
+	assert.Equal(t, "jstrachan", field(t, items[0], "metadata", "labels", kube.LabelDevPodUsername))
+}
+
+func TestGetHelmBinOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetHelmBinOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	helmBin := parseJSON(t, out)
+	assert.NotEmpty(t, field(t, helmBin, "binary"))
+	assert.NotNil(t, field(t, helmBin, "noTiller"))
+	assert.NotNil(t, field(t, helmBin, "helmTemplate"))
+}
+
+// newIssuesOutputTestOptions returns the options for a git repository with a single issue
+func newIssuesOutputTestOptions(t *testing.T) (*opts.CommonOptions, *testhelpers.FakeOut, string) {
+	dir, err := ioutil.TempDir("", "test-get-issues-output")
+	require.NoError(t, err)
+	commonOpts, out := newOutputTestOptions(nil, nil)
+	commonOpts.SetGit(&gits.GitFake{
+		GitRemotes: []gits.GitRemote{{Name: "origin", URL: "https://fake.git/jx-testing/myapp.git"}},
+	})
+	number := 1
+	state := "open"
+	commonOpts.SetFakeGitProvider(&gits.FakeProvider{
+		Repositories: map[string][]*gits.FakeRepository{
+			"jx-testing": {
+				{
+					Owner:   "jx-testing",
+					GitRepo: &gits.GitRepository{Name: "myapp"},
+					Issues: map[int]*gits.FakeIssue{
+						number: {
+							Issue: &gits.GitIssue{
+								URL:    "https://fake.git/jx-testing/myapp/issues/1",
+								Owner:  "jx-testing",
+								Repo:   "myapp",
+								Number: &number,
+								Title:  "the app does not start",
+								State:  &state,
+								Labels: []gits.GitLabel{{Name: "bug"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	return commonOpts, out, dir
+}
+
+func TestGetIssuesOutput(t *testing.T) {
+	commonOpts, out, dir := newIssuesOutputTestOptions(t)
+	defer os.RemoveAll(dir)
+
+	options := &get.GetIssuesOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		Dir:        dir,
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "https://fake.git/jx-testing/myapp/issues/1", field(t, list[0], "url"))
+	assert.Equal(t, float64(1), field(t, list[0], "number"))
+	assert.Equal(t, "the app does not start", field(t, list[0], "title"))
+	assert.Equal(t, "open", field(t, list[0], "state"))
+	assert.Equal(t, []interface{}{"bug"}, field(t, list[0], "labels"))
+	assert.Equal(t, []interface{}{}, field(t, list[0], "assignees"))
+}
+
+func TestGetIssueOutput(t *testing.T) {
+	commonOpts, out, dir := newIssuesOutputTestOptions(t)
+	defer os.RemoveAll(dir)
+
+	options := &get.GetIssueOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		Dir:        dir,
+		Id:         "1",
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	issue := parseJSON(t, out)
+	assert.Equal(t, "https://fake.git/jx-testing/myapp/issues/1", field(t, issue, "url"))
+	assert.Equal(t, "the app does not start", field(t, issue, "title"))
+	assert.Equal(t, []interface{}{}, field(t, issue, "deployments"))
+}
+
+func TestGetLangOutput(t *testing.T) {
+	_, cleanup := useTestJxHome(t)
+	defer cleanup()
+	commonOpts, out := newOutputTestOptions(nil, nil)
+	settings, err := commonOpts.TeamSettings()
+	require.NoError(t, err)
+	packsDir, err := gitresolver.InitBuildPack(commonOpts.Git(), settings.BuildPackURL, settings.BuildPackRef)
+	require.NoError(t, err)
+	err = os.MkdirAll(filepath.Join(packsDir, "go"), 0700)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "test-get-lang-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "jenkins-x.yml"), []byte("buildPack: go\n"), 0600)
+	require.NoError(t, err)
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	err = os.Chdir(dir)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Chdir(cwd)
+		assert.NoError(t, err)
+	}()
+
+	options := &get.GetLangOptions{
+		GetOptions:  get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		StepOptions: step.StepOptions{CommonOptions: commonOpts},
+	}
+	err = options.Run()
+	require.NoError(t, err)
+	assert.Equal(t, "go", field(t, parseJSON(t, out), "pack"))
+}
+
+func TestGetMetricsDeliveryOutput(t *testing.T) {
+	started := metav1.NewTime(time.Now().Add(-time.Hour))
+	completed := metav1.NewTime(time.Now().Add(-30 * time.Minute))
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "jx-testing-myapp-master-1", Namespace: "jx"},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:         "jx-testing/myapp/master",
+			Build:            "1",
+			GitOwner:         "jx-testing",
+			GitRepository:    "myapp",
+			GitBranch:        "master",
+			Version:          "1.0.1",
+			LastCommitSHA:    "abc123",
+			StartedTimestamp: &started,
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{
+							Status:             v1.ActivityStatusTypeSucceeded,
+							StartedTimestamp:   &started,
+							CompletedTimestamp: &completed,
+						},
+						Environment: "staging",
+					},
+				},
+			},
+		},
+	}
+	commonOpts, out := newOutputTestOptions(nil, []runtime.Object{activity})
+
+	options := &get.GetMetricsDeliveryOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		Since:      24 * time.Hour,
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "myapp", field(t, list[0], "app"))
+	assert.Equal(t, "staging", field(t, list[0], "environment"))
+	assert.Equal(t, float64(1), field(t, list[0], "deployments"))
+	assert.Equal(t, float64(0), field(t, list[0], "failedDeployments"))
+}
+
+func TestGetPipelineOutput(t *testing.T) {
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetPipelineOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	mockProwConfig(options, t)
+	err := options.Run()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"test/repo/master"}, parseJSONList(t, out))
+}
+
+func TestGetPluginsOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-get-plugins-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "jx-hello"), []byte("#!/bin/sh\necho hello\n"), 0700)
+	require.NoError(t, err)
+	path := os.Getenv("PATH")
+	err = os.Setenv("PATH", dir)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Setenv("PATH", path)
+		assert.NoError(t, err)
+	}()
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetPluginsOptions{
+		CommonOptions: commonOpts,
+		Verifier: &extensions.CommandOverrideVerifier{
+			Root:        &cobra.Command{Use: "jx"},
+			SeenPlugins: map[string]string{},
+		},
+		Output: "json",
+	}
+	err = options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "hello", field(t, list[0], "subCommand"))
+	assert.NotEmpty(t, field(t, list[0], "group"))
+}
+
+func TestGetPostPreviewJobOutput(t *testing.T) {
+	devEnv := newDevEnvironment()
+	devEnv.Spec.TeamSettings.PostPreviewJobs = []batchv1.Job{
+		{ObjectMeta: metav1.ObjectMeta{Name: "owasp"}},
+	}
+	commonOpts, out := newOutputTestOptions(nil, []runtime.Object{devEnv})
+
+	options := &get.GetPostPreviewJobOptions{
+		CreateOptions: createoptions.CreateOptions{CommonOptions: commonOpts},
+		Output:        "json",
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	items := parseJSONItems(t, out)
+	require.Len(t, items, 1)
+	assert.Equal(t, "owasp", field(t, items[0], "metadata", "name"))
+}
+
+func TestGetPreviewOutput(t *testing.T) {
+	preview := kube.NewPreviewEnvironment("jx-testing-myapp-pr-1")
+	commonOpts, out := newOutputTestOptions(nil, []runtime.Object{preview})
+
+	options := &get.GetPreviewOptions{
+		GetEnvOptions: get.GetEnvOptions{
+			GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	items := parseJSONItems(t, out)
+	require.Len(t, items, 1)
+	assert.Equal(t, "jx-testing-myapp-pr-1", field(t, items[0], "metadata", "name"))
+	assert.Equal(t, string(v1.EnvironmentKindTypePreview), field(t, items[0], "spec", "kind"))
+}
+
+func TestGetQuickstartLocationsOutput(t *testing.T) {
+	devEnv := newDevEnvironment()
+	devEnv.Spec.TeamSettings.QuickstartLocations = []v1.QuickStartLocation{
+		{GitURL: gits.GitHubURL, GitKind: gits.KindGitHub, Owner: "jenkins-x-quickstarts", Includes: []string{"*"}},
+	}
+	commonOpts, out := newOutputTestOptions(nil, []runtime.Object{devEnv})
+
+	options := &get.GetQuickstartLocationOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, gits.GitHubURL, field(t, list[0], "gitUrl"))
+	assert.Equal(t, gits.KindGitHub, field(t, list[0], "gitKind"))
+	assert.Equal(t, "jenkins-x-quickstarts", field(t, list[0], "owner"))
+}
+
+func TestGetQuickstartsOutput(t *testing.T) {
+	_, cleanup := useTestJxHome(t)
+	defer cleanup()
+	versionsDir, err := ioutil.TempDir("", "test-get-quickstarts-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(versionsDir)
+	quickstartsYaml := `defaultOwner: jenkins-x-quickstarts
+quickstarts:
+- name: node-http
+  language: JavaScript
+  downloadZipURL: https://codeload.github.com/jenkins-x-quickstarts/node-http/zip/master
+`
+	err = ioutil.WriteFile(filepath.Join(versionsDir, "quickstarts.yml"), []byte(quickstartsYaml), 0600)
+	require.NoError(t, err)
+	commonOpts, out := newOutputTestOptions(nil, nil)
+	commonOpts.SetVersionResolver(&versionstream.VersionResolver{VersionsDir: versionsDir})
+
+	options := &get.GetQuickstartsOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		IgnoreTeam: true,
+	}
+	err = options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "node-http", field(t, list[0], "name"))
+	assert.Equal(t, "JavaScript", field(t, list[0], "language"))
+}
+
+func TestGetSecretOutput(t *testing.T) {
+	vaultClient := fake_vault.NewFakeVaultClient()
+	vaultClient.Data[""] = map[string]interface{}{"0": "docker", "1": "jenkins"}
+	commonOpts, out := newOutputTestOptions(nil, nil)
+	setOutputTestFactory(commonOpts, &outputTestFactory{vaultClient: vaultClient})
+
+	options := &get.GetSecretOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []interface{}{"docker", "jenkins"}, parseJSONList(t, out))
+}
+
+func TestGetStreamOutput(t *testing.T) {
+	jxHome, cleanup := useTestJxHome(t)
+	defer cleanup()
+	chartsDir := filepath.Join(jxHome, "jenkins-x-versions", "charts", "jenkins-x")
+	err := os.MkdirAll(chartsDir, 0700)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(chartsDir, "tekton.yml"), []byte("version: 0.0.1\n"), 0600)
+	require.NoError(t, err)
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetStreamOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+		Kind:       "charts",
+	}
+	options.Args = []string{"jenkins-x/tekton"}
+	err = options.Run()
+	require.NoError(t, err)
+	stream := parseJSON(t, out)
+	assert.Equal(t, "charts", field(t, stream, "kind"))
+	assert.Equal(t, "jenkins-x/tekton", field(t, stream, "name"))
+	assert.Equal(t, "0.0.1", field(t, stream, "version"))
+}
+
+func TestGetTeamOutput(t *testing.T) {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "jx",
+			Labels: map[string]string{kube.LabelEnvironment: kube.LabelValueDevEnvironment},
+		},
+	}
+	commonOpts, out := newOutputTestOptions([]runtime.Object{ns}, nil)
+
+	options := &get.GetTeamOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	items := parseJSONItems(t, out)
+	require.Len(t, items, 1)
+	assert.Equal(t, "jx", field(t, items[0], "metadata", "name"))
+}
+
+func TestGetUserOutput(t *testing.T) {
+	user := &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "jstrachan", Namespace: "jx"},
+		Spec:       v1.UserDetails{Login: "jstrachan", Name: "James Strachan"},
+	}
+	commonOpts, out := newOutputTestOptions(nil, []runtime.Object{user})
+
+	options := &get.GetUserOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	items := parseJSONItems(t, out)
+	require.Len(t, items, 1)
+	assert.Equal(t, "jstrachan", field(t, items[0], "spec", "login"))
+	assert.Equal(t, "James Strachan", field(t, items[0], "spec", "name"))
+}
+
+func TestGetVaultOutput(t *testing.T) {
+	v := &v1alpha1.Vault{
+		ObjectMeta: metav1.ObjectMeta{Name: "jx-vault", Namespace: "jx"},
+		Spec: v1alpha1.VaultSpec{
+			ExternalConfig: map[string]interface{}{
+				"auth": []interface{}{
+					map[string]interface{}{
+						"roles": []interface{}{
+							map[string]interface{}{"name": "vault-auth"},
+						},
+					},
+				},
+			},
+		},
+	}
+	commonOpts, out := newOutputTestOptions(nil, nil)
+	setOutputTestFactory(commonOpts, &outputTestFactory{
+		vaultOperatorClient: fake_vaultoperatorclient.NewSimpleClientset(v),
+	})
+
+	options := &get.GetVaultOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err := options.Run()
+	require.NoError(t, err)
+	list := parseJSONList(t, out)
+	require.Len(t, list, 1)
+	assert.Equal(t, "jx-vault", field(t, list[0], "name"))
+	assert.Equal(t, "jx", field(t, list[0], "namespace"))
+	assert.NotEmpty(t, field(t, list[0], "url"))
+	assert.Equal(t, "vault-auth", field(t, list[0], "authServiceAccountName"))
+}
+
+func TestGetVaultConfigOutput(t *testing.T) {
+	jxHome, cleanup := useTestJxHome(t)
+	defer cleanup()
+	binDir := filepath.Join(jxHome, "bin")
+	err := os.MkdirAll(binDir, 0700)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(binDir, "vault"), []byte{}, 0700)
+	require.NoError(t, err)
+	commonOpts, out := newOutputTestOptions(nil, nil)
+
+	options := &get.GetVaultConfigOptions{
+		GetOptions: get.GetOptions{CommonOptions: commonOpts, Output: "json"},
+	}
+	err = options.Run()
+	require.NoError(t, err)
+	vaultConfig := parseJSON(t, out)
+	assert.Equal(t, "https://fake.vault", field(t, vaultConfig, "address"))
+	assert.Equal(t, "fakevault", field(t, vaultConfig, "token"))
+}
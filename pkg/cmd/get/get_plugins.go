@@ -53,6 +53,17 @@ var (
 type GetPluginsOptions struct {
 	*opts.CommonOptions
 	Verifier extensions.PathVerifier
+	Output   string
+}
+
+// PluginOutput the plugin command rendered by the --output flag
+type PluginOutput struct {
+	Group       string `json:"group"`
+	SubCommand  string `json:"subCommand"`
+	Description string `json:"description,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Version     string `json:"version,omitempty"`
+	URL         string `json:"url,omitempty"`
 }
 
 // NewCmdGetPlugins provides a way to list all plugin executables visible to jx
@@ -76,10 +87,17 @@ func NewCmdGetPlugins(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 
+	opts.AddOutputFlag(cmd, &options.Output)
 	return cmd
 }
 
 func (o *GetPluginsOptions) Complete() error {
+	if o.Output != "" {
+		err := opts.ValidateOutputFormat(o.Output)
+		if err != nil {
+			return err
+		}
+	}
 	o.Verifier = &extensions.CommandOverrideVerifier{
 		Root:        o.Cmd.Root(),
 		SeenPlugins: make(map[string]string, 0),
@@ -100,6 +118,9 @@ func (o *GetPluginsOptions) printExtensionPlugins() error {
 	if !managedPluginsEnabled {
 		log.Logger().Warnf("Managed Plugins not available")
 	}
+	if o.Output != "" {
+		return o.renderPlugins(pcgs)
+	}
 	maxLength := 0
 	for _, pcg := range pcgs {
 		for _, pc := range pcg.Commands {
@@ -146,3 +167,22 @@ func (o *GetPluginsOptions) printExtensionPlugins() error {
 
 	return nil
 }
+
+// renderPlugins renders the plugin commands in the output format
+func (o *GetPluginsOptions) renderPlugins(pcgs templates.PluginCommandGroups) error {
+	plugins := []PluginOutput{}
+	for _, pcg := range pcgs {
+		for _, pc := range pcg.Commands {
+			url, _ := extensions.FindPluginUrl(pc.PluginSpec)
+			plugins = append(plugins, PluginOutput{
+				Group:       pcg.Message,
+				SubCommand:  pc.SubCommand,
+				Description: pc.Description,
+				Name:        pc.Name,
+				Version:     pc.Version,
+				URL:         url,
+			})
+		}
+	}
+	return opts.RenderOutput(o.Out, plugins, o.Output)
+}
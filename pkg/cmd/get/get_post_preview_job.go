@@ -12,6 +12,7 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	batchv1 "k8s.io/api/batch/v1"
 )
 
 var (
@@ -23,12 +24,16 @@ var (
 		# List the jobs triggered after a Preview is created 
 		jx get post preview job 
 
+		# Output the jobs triggered after a Preview is created as YAML
+		jx get post preview job -o yaml
 	`)
 )
 
 // GetPostPreviewJobOptions the options for the create spring command
 type GetPostPreviewJobOptions struct {
 	options.CreateOptions
+
+	Output string
 }
 
 // NewCmdGetPostPreviewJob creates a command object for the "create" command
@@ -51,15 +56,25 @@ func NewCmdGetPostPreviewJob(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
+	opts.AddOutputFlag(cmd, &options.Output)
 	return cmd
 }
 
 // Run implements the command
 func (o *GetPostPreviewJobOptions) Run() error {
+	if o.Output != "" {
+		err := opts.ValidateOutputFormat(o.Output)
+		if err != nil {
+			return err
+		}
+	}
 	settings, err := o.TeamSettings()
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		return opts.RenderOutput(o.Out, &batchv1.JobList{Items: settings.PostPreviewJobs}, o.Output)
+	}
 	table := o.CreateTable()
 	table.AddRow("NAME", "IMAGE", "BACKOFF_LIMIT", "COMMAND")
 
@@ -32,6 +32,9 @@ var (
 		# View the current preview environment URL
 		# inside a CI pipeline
 		jx get preview --current
+
+		# List the URLs of the applications of all preview environments
+		jx get previews -o jsonpath='{range .items[*]}{.spec.previewGitInfo.applicationURL}{"\n"}{end}'
 	`)
 )
 
@@ -91,6 +94,9 @@ func (o *GetPreviewOptions) CurrentPreviewUrl() error {
 	}
 	for _, env := range envList.Items {
 		if env.Spec.Kind == v1.EnvironmentKindTypePreview && env.Name == name {
+			if o.Output != "" {
+				return o.renderResult(&env, o.Output)
+			}
 			// lets log directly to stdout for easy capture of the URL from shell scripts
 			fmt.Println(env.Spec.PreviewGitSpec.ApplicationURL)
 			return nil
@@ -32,6 +32,8 @@ var (
 		# List all the quickstart locations via an alias
 		jx get qsloc
 
+		# Output the quickstart locations as JSON
+		jx get qsloc -o json
 	`)
 )
 
@@ -71,6 +73,9 @@ func (o *GetQuickstartLocationOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		return o.renderResult(locations, o.Output)
+	}
 
 	table := o.CreateTable()
 	table.AddRow("GIT SERVER", "KIND", "OWNER", "INCLUDES", "EXCLUDES")
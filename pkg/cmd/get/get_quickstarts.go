@@ -12,7 +12,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 )
 
-//GetQuickstartsOptions -  the command line options
+// GetQuickstartsOptions -  the command line options
 type GetQuickstartsOptions struct {
 	GetOptions
 	GitHubOrganisations []string
@@ -30,10 +30,13 @@ var (
 	getQuickstartsExample = templates.Examples(`
 		# List all the available quickstarts
 		jx get quickstarts
+
+		# Output the names of the Go quickstarts
+		jx get quickstarts -l go -o jsonpath='{range .[*]}{.name}{"\n"}{end}'
 	`)
 )
 
-//NewCmdGetQuickstarts creates the command
+// NewCmdGetQuickstarts creates the command
 func NewCmdGetQuickstarts(commonOpts *opts.CommonOptions) *cobra.Command {
 	options := &GetQuickstartsOptions{
 		GetOptions: GetOptions{
@@ -64,12 +67,19 @@ func NewCmdGetQuickstarts(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.Filter.AllowML, "machine-learning", "", false, "Allow machine-learning quickstarts in results")
 	cmd.Flags().BoolVarP(&options.ShortFormat, "short", "s", false, "return minimal details")
 	cmd.Flags().BoolVarP(&options.IgnoreTeam, "ignore-team", "", false, "ignores the quickstarts added to the Team Settings")
+	opts.AddOutputFlag(cmd, &options.Output)
 
 	return cmd
 }
 
 // Run implements this command
 func (o *GetQuickstartsOptions) Run() error {
+	if o.Output != "" {
+		err := opts.ValidateOutputFormat(o.Output)
+		if err != nil {
+			return err
+		}
+	}
 	model, err := o.LoadQuickStartsModel(o.GitHubOrganisations, o.IgnoreTeam)
 	if err != nil {
 		return fmt.Errorf("failed to load quickstarts: %s", err)
@@ -77,6 +87,12 @@ func (o *GetQuickstartsOptions) Run() error {
 
 	//output list of available quickstarts and exit
 	filteredQuickstarts := model.Filter(&o.Filter)
+	if o.Output != "" {
+		if filteredQuickstarts == nil {
+			filteredQuickstarts = []*quickstarts.Quickstart{}
+		}
+		return o.renderResult(filteredQuickstarts, o.Output)
+	}
 	table := o.CreateTable()
 	if o.ShortFormat {
 		table.AddRow("NAME")
@@ -3,6 +3,7 @@ package get
 import (
 	"fmt"
 
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
@@ -32,6 +33,9 @@ var (
 
 		# Filter the releases 
 		jx get release -f myapp
+
+		# Output the versions of the releases of myapp
+		jx get release -f myapp -o jsonpath='{.items[*].spec.version}'
 	`)
 )
 
@@ -76,6 +80,9 @@ func (o *GetReleaseOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		return o.renderResult(&v1.ReleaseList{Items: releases}, o.Output)
+	}
 	if len(releases) == 0 {
 		suffix := ""
 		if o.Filter != "" {
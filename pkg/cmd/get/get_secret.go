@@ -32,6 +32,9 @@ var (
 	getSecretExample = templates.Examples(`
 		# List all secrets
 		jx get secrets
+
+		# Display the pipelines, environments and pods which use a secret
+		jx get secret usages mycluster/secrets-adminUser
 	`)
 )
 
@@ -46,6 +49,7 @@ func NewCmdGetSecret(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "secrets",
 		Short:   "Display one or more Secrets",
+		Aliases: []string{"secret"},
 		Long:    getSecretLong,
 		Example: getSecretExample,
 		Run: func(c *cobra.Command, args []string) {
@@ -57,6 +61,7 @@ func NewCmdGetSecret(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 
 	options.AddGetFlags(cmd)
+	cmd.AddCommand(NewCmdGetSecretUsages(commonOpts))
 
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "Namespace from where to list the secrets")
 	cmd.Flags().StringVarP(&options.Name, "name", "m", "", "The name of the Vault to use")
@@ -79,6 +84,12 @@ func (o *GetSecretOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "listing all secrets in vault")
 	}
+	if o.Output != "" {
+		if secrets == nil {
+			secrets = []string{}
+		}
+		return o.renderResult(secrets, o.Output)
+	}
 
 	table := o.CreateTable()
 	table.AddRow("KEY")
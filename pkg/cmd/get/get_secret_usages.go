@@ -0,0 +1,406 @@
+package get
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SecretUsageSourcePipeline a reference from a jenkins-x.yml pipeline file
+	SecretUsageSourcePipeline = "pipeline"
+	// SecretUsageSourceValues a reference from a values file such as in an environment repository
+	SecretUsageSourceValues = "values"
+	// SecretUsageSourcePod a reference from the spec of a running pod
+	SecretUsageSourcePod = "pod"
+)
+
+var (
+	getSecretUsagesLong = templates.LongDesc(`
+		Displays every consumer of a Vault or Kubernetes secret so that it can be safely rotated or deleted.
+
+		The following are scanned for references:
+
+		* the jenkins-x.yml files and values files in the given directory
+		* the jenkins-x.yml file of every SourceRepository
+		* the values files of every Environment git repository
+		* the pod specs in the cluster
+
+		Secret URIs such as 'vault:mycluster/secrets:hmacToken' or 'local:secrets-adminUser:password' match when their
+		path is the given name or ends with it. Kubernetes secrets match when they are referenced by name.
+`)
+
+	getSecretUsagesExample = templates.Examples(`
+		# Display the usages of a Vault secret
+		jx get secret usages mycluster/secrets-adminUser
+
+		# Display the usages of a Kubernetes secret in the current directory and the cluster only
+		jx get secret usages jenkins-x-chartmuseum --skip-repositories --skip-environments
+	`)
+
+	secretURIRegex = regexp.MustCompile(`(vault|local|sops|gcpsm|awssm|azurekv):([-_.\w\/]+):([-_.\w]+)`)
+
+	secretNameRefKeys = []string{"secretKeyRef", "secretRef"}
+)
+
+// SecretUsage a reference to a secret
+type SecretUsage struct {
+	Source    string `json:"source"`
+	Name      string `json:"name"`
+	Location  string `json:"location"`
+	Reference string `json:"reference"`
+}
+
+// GetSecretUsagesOptions the command line options
+type GetSecretUsagesOptions struct {
+	GetOptions
+
+	Dir              string
+	Namespace        string
+	AllNamespaces    bool
+	SkipRepositories bool
+	SkipEnvironments bool
+	SkipPods         bool
+
+	gitProviders map[string]gits.GitProvider
+}
+
+// NewCmdGetSecretUsages creates the command object
+func NewCmdGetSecretUsages(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetSecretUsagesOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "usages [name]",
+		Short:   "Displays the pipelines, charts, environments and pods which reference a secret",
+		Aliases: []string{"usage"},
+		Long:    getSecretUsagesLong,
+		Example: getSecretUsagesExample,
+		Run: func(c *cobra.Command, args []string) {
+			options.Cmd = c
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.AddGetFlags(cmd)
+
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "The directory to scan for jenkins-x.yml and values files")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace to scan for pods. Defaults to the current namespace")
+	cmd.Flags().BoolVarP(&options.AllNamespaces, "all-namespaces", "", false, "Scan the pods in all namespaces")
+	cmd.Flags().BoolVarP(&options.SkipRepositories, "skip-repositories", "", false, "Do not scan the jenkins-x.yml files of the SourceRepository resources")
+	cmd.Flags().BoolVarP(&options.SkipEnvironments, "skip-environments", "", false, "Do not scan the values files of the Environment git repositories")
+	cmd.Flags().BoolVarP(&options.SkipPods, "skip-pods", "", false, "Do not scan the pods in the cluster")
+	return cmd
+}
+
+// Run implements this command
+func (o *GetSecretUsagesOptions) Run() error {
+	if len(o.Args) == 0 {
+		return util.MissingArgument("name")
+	}
+	name := o.Args[0]
+	results, err := o.FindUsages(name)
+	if err != nil {
+		return err
+	}
+	if o.Output != "" {
+		return o.renderResult(results, o.Output)
+	}
+	if len(results) == 0 {
+		log.Logger().Infof("No usages found for secret %s", util.ColorInfo(name))
+		return nil
+	}
+	table := o.CreateTable()
+	table.AddRow("SOURCE", "NAME", "LOCATION", "REFERENCE")
+	for _, r := range results {
+		table.AddRow(r.Source, r.Name, r.Location, r.Reference)
+	}
+	table.Render()
+	return nil
+}
+
+// FindUsages finds all the references to the secret with the given name
+func (o *GetSecretUsagesOptions) FindUsages(name string) ([]*SecretUsage, error) {
+	results := []*SecretUsage{}
+	if o.Dir != "" {
+		found, err := FindSecretUsagesInDir(o.Dir, name)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+	if !o.SkipRepositories || !o.SkipEnvironments {
+		found, err := o.findUsagesInGitRepositories(name)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+	if !o.SkipPods {
+		found, err := o.findUsagesInPods(name)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+	return results, nil
+}
+
+func (o *GetSecretUsagesOptions) findUsagesInGitRepositories(name string) ([]*SecretUsage, error) {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return nil, err
+	}
+	results := []*SecretUsage{}
+	if !o.SkipRepositories {
+		srList, err := jxClient.JenkinsV1().SourceRepositories(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing SourceRepositories in namespace %s", ns)
+		}
+		for _, sr := range srList.Items {
+			gitURL, err := kube.GetRepositoryGitURL(&sr)
+			if err != nil {
+				log.Logger().Warnf("Skipping SourceRepository %s: %s", sr.Name, err)
+				continue
+			}
+			found := o.findUsagesInGitFile(gitURL, "", config.ProjectConfigFileName, SecretUsageSourcePipeline, name)
+			results = append(results, found...)
+		}
+	}
+	if !o.SkipEnvironments {
+		envMap, names, err := kube.GetEnvironments(jxClient, ns)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing Environments in namespace %s", ns)
+		}
+		for _, envName := range names {
+			env := envMap[envName]
+			if env == nil || env.Spec.Source.URL == "" {
+				continue
+			}
+			for _, path := range []string{helm.DefaultEnvironmentChartDir + "/" + helm.ValuesFileName, helm.ValuesFileName} {
+				found := o.findUsagesInGitFile(env.Spec.Source.URL, env.Spec.Source.Ref, path, SecretUsageSourceValues, name)
+				results = append(results, found...)
+			}
+		}
+	}
+	return results, nil
+}
+
+// findUsagesInGitFile finds the references in a file of a git repository. Any failures to access the repository are
+// logged as warnings so that a single inaccessible repository does not prevent the audit
+func (o *GetSecretUsagesOptions) findUsagesInGitFile(gitURL string, ref string, path string, source string, name string) []*SecretUsage {
+	gitInfo, err := gits.ParseGitURL(gitURL)
+	if err != nil {
+		log.Logger().Warnf("Failed to parse git URL %s: %s", gitURL, err)
+		return nil
+	}
+	if o.gitProviders == nil {
+		o.gitProviders = map[string]gits.GitProvider{}
+	}
+	provider := o.gitProviders[gitInfo.HostURL()]
+	if provider == nil {
+		provider, _, err = o.CreateGitProviderForURLWithoutKind(gitURL)
+		if err != nil {
+			log.Logger().Warnf("Failed to create git provider for %s: %s", gitURL, err)
+			return nil
+		}
+		o.gitProviders[gitInfo.HostURL()] = provider
+	}
+	content, err := provider.GetContent(gitInfo.Organisation, gitInfo.Name, path, ref)
+	if err != nil || content == nil {
+		log.Logger().Debugf("No file %s in %s: %v", path, gitURL, err)
+		return nil
+	}
+	text := content.Content
+	if content.Encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			log.Logger().Warnf("Failed to decode %s in %s: %s", path, gitURL, err)
+			return nil
+		}
+		text = string(data)
+	}
+	return FindSecretUsagesInYAML(source, gitInfo.Organisation+"/"+gitInfo.Name, path, []byte(text), name)
+}
+
+func (o *GetSecretUsagesOptions) findUsagesInPods(name string) ([]*SecretUsage, error) {
+	kubeClient, currentNs, err := o.KubeClientAndNamespace()
+	if err != nil {
+		return nil, err
+	}
+	ns := o.Namespace
+	if ns == "" {
+		ns = currentNs
+	}
+	if o.AllNamespaces {
+		ns = ""
+	}
+	podList, err := kubeClient.CoreV1().Pods(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing pods in namespace %s", ns)
+	}
+	results := []*SecretUsage{}
+	for _, pod := range podList.Items {
+		if kube.PodSpecUsesSecret(&pod.Spec, name) {
+			results = append(results, &SecretUsage{
+				Source:    SecretUsageSourcePod,
+				Name:      pod.Namespace + "/" + pod.Name,
+				Location:  "spec",
+				Reference: name,
+			})
+		}
+	}
+	return results, nil
+}
+
+// FindSecretUsagesInDir finds the references to the secret in the jenkins-x.yml and values files of the given directory
+func FindSecretUsagesInDir(dir string, name string) ([]*SecretUsage, error) {
+	results := []*SecretUsage{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext != ".yml" && ext != ".yaml" {
+			return nil
+		}
+		source := SecretUsageSourceValues
+		if strings.HasPrefix(info.Name(), "jenkins-x") {
+			source = SecretUsageSourcePipeline
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "reading %s", path)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		results = append(results, FindSecretUsagesInYAML(source, dir, rel, data, name)...)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "scanning %s", dir)
+	}
+	return results, nil
+}
+
+// FindSecretUsagesInYAML finds the references to the secret in the given YAML content. Any documents which are not
+// valid YAML, such as chart templates, are scanned line by line for secret URIs
+func FindSecretUsagesInYAML(source string, name string, fileName string, data []byte, secretName string) []*SecretUsage {
+	results := []*SecretUsage{}
+	for i, doc := range strings.Split(string(data), "\n---") {
+		var value interface{}
+		err := yaml.Unmarshal([]byte(doc), &value)
+		if err != nil {
+			for j, line := range strings.Split(doc, "\n") {
+				for _, uri := range matchingSecretURIs(line, secretName) {
+					results = append(results, &SecretUsage{
+						Source:    source,
+						Name:      name,
+						Location:  fmt.Sprintf("%s:%d", fileName, j+1),
+						Reference: uri,
+					})
+				}
+			}
+			continue
+		}
+		location := fileName
+		if i > 0 {
+			location = fmt.Sprintf("%s[%d]", fileName, i)
+		}
+		walkSecretUsages(value, "", func(path string, reference string) {
+			results = append(results, &SecretUsage{
+				Source:    source,
+				Name:      name,
+				Location:  location + ":" + path,
+				Reference: reference,
+			})
+		}, secretName)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Location < results[j].Location
+	})
+	return results
+}
+
+func walkSecretUsages(value interface{}, path string, fn func(path string, reference string), secretName string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range secretNameRefKeys {
+			if ref, ok := v[key].(map[string]interface{}); ok && ref["name"] == secretName {
+				fn(joinYAMLPath(path, key), secretName)
+			}
+		}
+		if v["secretName"] == secretName {
+			fn(joinYAMLPath(path, "secretName"), secretName)
+		}
+		keys := []string{}
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkSecretUsages(v[k], joinYAMLPath(path, k), fn, secretName)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkSecretUsages(item, fmt.Sprintf("%s[%d]", path, i), fn, secretName)
+		}
+	case string:
+		for _, uri := range matchingSecretURIs(v, secretName) {
+			fn(path, uri)
+		}
+	}
+}
+
+// matchingSecretURIs returns the secret URIs in the text which reference the secret. The secret name matches the
+// path of the URI or its last segments, or the path and key if it contains a ':'
+func matchingSecretURIs(text string, secretName string) []string {
+	answer := []string{}
+	for _, m := range secretURIRegex.FindAllStringSubmatch(text, -1) {
+		path := m[2]
+		key := m[3]
+		if path == secretName || strings.HasSuffix(path, "/"+secretName) || path+":"+key == secretName {
+			answer = append(answer, m[0])
+		}
+	}
+	return answer
+}
+
+func joinYAMLPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
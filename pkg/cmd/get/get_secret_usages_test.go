@@ -0,0 +1,125 @@
+// +build unit
+
+package get
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	clientsfake "github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const pipelineWithSecrets = `buildPack: maven
+pipelineConfig:
+  env:
+  - name: HMAC
+    value: vault:mycluster/secrets:hmacToken
+  - name: CHARTMUSEUM_PASSWORD
+    valueFrom:
+      secretKeyRef:
+        name: jenkins-x-chartmuseum
+        key: BASIC_AUTH_PASS
+`
+
+const templateWithSecrets = `apiVersion: v1
+kind: Secret
+data:
+  password: {{ "vault:mycluster/secrets-adminUser:password" | b64enc }}
+  other: {{ "vault:mycluster/secrets-adminUserX:password" | b64enc }}
+`
+
+func TestFindSecretUsagesInYAML(t *testing.T) {
+	results := FindSecretUsagesInYAML(SecretUsageSourcePipeline, "myrepo", "jenkins-x.yml", []byte(pipelineWithSecrets), "secrets")
+	require.Len(t, results, 1)
+	assert.Equal(t, "jenkins-x.yml:pipelineConfig.env[0].value", results[0].Location)
+	assert.Equal(t, "vault:mycluster/secrets:hmacToken", results[0].Reference)
+
+	results = FindSecretUsagesInYAML(SecretUsageSourcePipeline, "myrepo", "jenkins-x.yml", []byte(pipelineWithSecrets), "jenkins-x-chartmuseum")
+	require.Len(t, results, 1)
+	assert.Equal(t, "jenkins-x.yml:pipelineConfig.env[1].valueFrom.secretKeyRef", results[0].Location)
+
+	results = FindSecretUsagesInYAML(SecretUsageSourceValues, "myrepo", "templates/secret.yaml", []byte(templateWithSecrets), "mycluster/secrets-adminUser")
+	require.Len(t, results, 1, "should fall back to scanning the lines of invalid YAML")
+	assert.Equal(t, "templates/secret.yaml:4", results[0].Location)
+
+	results = FindSecretUsagesInYAML(SecretUsageSourcePipeline, "myrepo", "jenkins-x.yml", []byte(pipelineWithSecrets), "mycluster/secrets:other")
+	assert.Empty(t, results)
+}
+
+func TestGetSecretUsages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-secret-usages-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "jenkins-x.yml"), []byte(pipelineWithSecrets), 0600)
+	require.NoError(t, err)
+	err = os.MkdirAll(filepath.Join(dir, "env"), 0700)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, "env", "values.yaml"), []byte("chartmuseum:\n  env:\n    open:\n      BASIC_AUTH_PASS: vault:mycluster/secrets-chartmuseum:password\n"), 0600)
+	require.NoError(t, err)
+
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "chartmuseum-abc", Namespace: "jx"},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					{Name: "auth", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "jenkins-x-chartmuseum"}}},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "jx"},
+		},
+	)
+	commonOpts := opts.NewCommonOptionsWithFactory(clientsfake.NewFakeFactory())
+	commonOpts.Out = os.Stdout
+	commonOpts.SetCurrentNamespace("jx")
+	commonOpts.SetKubeClient(kubeClient)
+
+	options := &GetSecretUsagesOptions{
+		GetOptions:       GetOptions{CommonOptions: &commonOpts},
+		Dir:              dir,
+		SkipRepositories: true,
+		SkipEnvironments: true,
+	}
+	results, err := options.FindUsages("jenkins-x-chartmuseum")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, SecretUsageSourcePipeline, results[0].Source)
+	assert.Equal(t, SecretUsageSourcePod, results[1].Source)
+	assert.Equal(t, "jx/chartmuseum-abc", results[1].Name)
+
+	results, err = options.FindUsages("secrets-chartmuseum")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, SecretUsageSourceValues, results[0].Source)
+	assert.Equal(t, filepath.Join("env", "values.yaml")+":chartmuseum.env.open.BASIC_AUTH_PASS", results[0].Location)
+
+	options.Args = []string{"jenkins-x-chartmuseum"}
+	err = options.Run()
+	require.NoError(t, err)
+
+	out := &testhelpers.FakeOut{}
+	commonOpts.Out = out
+	options.Output = "json"
+	err = options.Run()
+	require.NoError(t, err)
+	usages := []map[string]string{}
+	err = json.Unmarshal([]byte(out.GetOutput()), &usages)
+	require.NoError(t, err)
+	require.Len(t, usages, 2)
+	assert.Equal(t, SecretUsageSourcePipeline, usages[0]["source"])
+	assert.Equal(t, "jenkins-x.yml:pipelineConfig.env[1].valueFrom.secretKeyRef", usages[0]["location"])
+	assert.Equal(t, SecretUsageSourcePod, usages[1]["source"])
+	assert.Equal(t, "jx/chartmuseum-abc", usages[1]["name"])
+	assert.Contains(t, usages[1], "reference")
+}
@@ -74,6 +74,15 @@ func (o *GetStorageOptions) Run() error {
 		names = append(names, k)
 	}
 	sort.Strings(names)
+	if o.Output != "" {
+		locations := []v1.StorageLocation{}
+		for _, n := range names {
+			location := m[n]
+			location.Classifier = n
+			locations = append(locations, location)
+		}
+		return o.renderResult(locations, o.Output)
+	}
 	table := o.CreateTable()
 	table.AddRow("CLASSIFICATION", "LOCATION")
 	for _, n := range names {
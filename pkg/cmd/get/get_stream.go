@@ -26,6 +26,14 @@ type GetStreamOptions struct {
 	VersionsGitRef     string
 }
 
+// StreamVersionOutput the resolved version rendered by the --output flag
+type StreamVersionOutput struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Image   string `json:"image,omitempty"`
+}
+
 var (
 	getStreamLong = templates.LongDesc(`
 		Displays the version of a chart, package or docker image from the Version Stream
@@ -40,6 +48,9 @@ var (
 
 		# List the version of a chart
 		jx get stream -k charts jenkins-x/tekton
+
+		# Output the version of a chart for use in a script
+		jx get stream -k charts jenkins-x/tekton -o jsonpath='{.version}'
 	`)
 )
 
@@ -67,11 +78,18 @@ func NewCmdGetStream(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Kind, "kind", "k", "docker", "The kind of version. Possible values: "+strings.Join(versionstream.KindStrings, ", "))
 	cmd.Flags().StringVarP(&options.VersionsRepository, "repo", "r", "", "Jenkins X versions Git repo")
 	cmd.Flags().StringVarP(&options.VersionsGitRef, "versions-ref", "", "", "Jenkins X versions Git repository reference (tag, branch, sha etc)")
+	opts.AddOutputFlag(cmd, &options.Output)
 	return cmd
 }
 
 // Run implements this command
 func (o *GetStreamOptions) Run() error {
+	if o.Output != "" {
+		err := opts.ValidateOutputFormat(o.Output)
+		if err != nil {
+			return err
+		}
+	}
 	resolver, err := o.CreateVersionResolver(o.VersionsRepository, o.VersionsGitRef)
 	if err != nil {
 		return errors.Wrap(err, "failed to create the VersionResolver")
@@ -88,6 +106,9 @@ func (o *GetStreamOptions) Run() error {
 		if err != nil {
 			return errors.Wrapf(err, "failed to resolve docker image %s", name)
 		}
+		if o.Output != "" {
+			return o.renderResult(&StreamVersionOutput{Kind: o.Kind, Name: name, Image: result}, o.Output)
+		}
 		log.Logger().Infof("resolved image %s to %s", util.ColorInfo(name), util.ColorInfo(result))
 		return nil
 	}
@@ -96,6 +117,9 @@ func (o *GetStreamOptions) Run() error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to resolve %s version of %s", o.Kind, name)
 	}
+	if o.Output != "" {
+		return o.renderResult(&StreamVersionOutput{Kind: o.Kind, Name: name, Version: n}, o.Output)
+	}
 
 	log.Logger().Infof("resolved %s %s to version: %s", util.ColorInfo(name), util.ColorInfo(o.Kind), util.ColorInfo(n))
 	return nil
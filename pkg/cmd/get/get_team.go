@@ -3,6 +3,7 @@ package get
 import (
 	"strings"
 
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
@@ -10,6 +11,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // GetTeamOptions containers the CLI options
@@ -30,6 +32,9 @@ var (
 
 		# List the pending Teams which are not yet provisioned and available for use
 		jx get team -p
+
+		# Output the names of the teams
+		jx get team -o jsonpath='{.items[*].metadata.name}'
 	`)
 )
 
@@ -72,6 +77,13 @@ func (o *GetTeamOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		list := &corev1.NamespaceList{Items: []corev1.Namespace{}}
+		for _, team := range teams {
+			list.Items = append(list.Items, *team)
+		}
+		return o.renderResult(list, o.Output)
+	}
 	if len(teams) == 0 {
 		log.Logger().Info(`
 You do not belong to any teams.
@@ -105,6 +117,13 @@ func (o *GetTeamOptions) getPendingTeams() error {
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		list := &v1.TeamList{Items: []v1.Team{}}
+		for _, name := range names {
+			list.Items = append(list.Items, *teams[name])
+		}
+		return o.renderResult(list, o.Output)
+	}
 
 	if len(names) == 0 {
 		log.Logger().Info(`
@@ -7,6 +7,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
 )
 
 // GetTeamRoleOptions containers the CLI options
@@ -23,6 +24,8 @@ var (
 		# List the team roles for the current team
 		jx get teamrole
 
+		# Output the team roles of the current team as YAML
+		jx get teamrole -o yaml
 	`)
 )
 
@@ -60,6 +63,15 @@ func (o *GetTeamRoleOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		list := &rbacv1.RoleList{Items: []rbacv1.Role{}}
+		for _, name := range names {
+			if teamRole := teamRoles[name]; teamRole != nil {
+				list.Items = append(list.Items, *teamRole)
+			}
+		}
+		return o.renderResult(list, o.Output)
+	}
 	if len(teamRoles) == 0 {
 		log.Logger().Info(`
 There are no Team roles defined so far!
@@ -0,0 +1,44 @@
+package get
+
+import (
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/spf13/cobra"
+)
+
+// GetTestsOptions the command line options
+type GetTestsOptions struct {
+	GetOptions
+}
+
+var (
+	getTestsExample = templates.Examples(`
+		# Display the flaky tests of a repository
+		jx get tests flaky --repo myorg/myapp
+	`)
+)
+
+// NewCmdGetTests creates the command object for 'jx get tests'
+func NewCmdGetTests(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetTestsOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "tests",
+		Short:   "Display information about the tests run by the pipelines",
+		Aliases: []string{"test"},
+		Example: getTestsExample,
+		Run: func(c *cobra.Command, args []string) {
+			options.Cmd = c
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(NewCmdGetTestsFlaky(commonOpts))
+	return cmd
+}
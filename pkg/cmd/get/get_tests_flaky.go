@@ -0,0 +1,159 @@
+package get
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cloud/buckets"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/testresults"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// GetTestsFlakyOptions the command line options
+type GetTestsFlakyOptions struct {
+	GetOptions
+
+	Repository  string
+	Branch      string
+	Builds      int
+	MinFlipRate float64
+
+	// ReadURLFn reads the stored test results, defaulting to reading them from the bucket or git URL
+	ReadURLFn testresults.ReadURLFn
+}
+
+var (
+	getTestsFlakyLong = templates.LongDesc(`
+		Displays the flaky tests of a repository computed from the test results stored by 'jx step report tests' or
+		'jx step report junit' for its last builds, which both store them by default unless --store-results=false is
+		used.
+
+		The flip rate of a test is how often its outcome changed between passed and failed in consecutive builds of
+		the same branch. A test is flaky if it flipped at least twice and its flip rate is at least --min-flip-rate.
+`)
+
+	getTestsFlakyExample = templates.Examples(`
+		# Display the flaky tests of the master branch of the current repository
+		jx get tests flaky
+
+		# Display the flaky tests of a repository over its last 50 builds of all branches
+		jx get tests flaky --repo myorg/myapp --branch "" --builds 50
+
+		# Display the flaky tests as JSON
+		jx get tests flaky --repo myorg/myapp -o json
+	`)
+)
+
+// NewCmdGetTestsFlaky creates the command object for 'jx get tests flaky'
+func NewCmdGetTestsFlaky(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetTestsFlakyOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "flaky",
+		Short:   "Displays the flaky tests of a repository",
+		Long:    getTestsFlakyLong,
+		Example: getTestsFlakyExample,
+		Run: func(c *cobra.Command, args []string) {
+			options.Cmd = c
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.AddGetFlags(cmd)
+
+	cmd.Flags().StringVarP(&options.Repository, "repo", "r", "", "The repository as owner/name. Defaults to the repository of the current directory")
+	cmd.Flags().StringVarP(&options.Branch, "branch", "b", "master", "The branch whose builds are compared. Compares the builds of all branches if blank")
+	cmd.Flags().IntVarP(&options.Builds, "builds", "n", testresults.DefaultHistoryBuilds, "The number of most recent builds to compare")
+	cmd.Flags().Float64VarP(&options.MinFlipRate, "min-flip-rate", "", testresults.DefaultMinFlipRate, "The minimum flip rate of a flaky test between 0 and 1")
+	return cmd
+}
+
+// Run implements this command
+func (o *GetTestsFlakyOptions) Run() error {
+	if o.Builds < 2 {
+		return util.InvalidOptionf("builds", o.Builds, "at least 2 builds are needed to compare the test results")
+	}
+	if o.MinFlipRate < 0 || o.MinFlipRate > 1 {
+		return util.InvalidOptionf("min-flip-rate", o.MinFlipRate, "the flip rate must be between 0 and 1")
+	}
+	owner, repository, err := o.ownerAndRepository()
+	if err != nil {
+		return err
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	if o.ReadURLFn == nil {
+		authSvc, err := o.GitAuthConfigService()
+		if err != nil {
+			return err
+		}
+		o.ReadURLFn = func(u string) ([]byte, error) {
+			return buckets.ReadURL(u, 30*time.Second, step.CreateBucketHTTPFn(authSvc))
+		}
+	}
+	filter := &testresults.HistoryFilter{
+		Owner:      owner,
+		Repository: repository,
+		Branch:     o.Branch,
+		Builds:     o.Builds,
+	}
+	history, err := testresults.LoadHistory(jxClient, ns, filter, o.ReadURLFn)
+	if err != nil {
+		return err
+	}
+	flaky := testresults.FindFlakyTests(history, o.MinFlipRate)
+	if o.Output != "" {
+		return o.renderResult(flaky, o.Output)
+	}
+	if len(history) == 0 {
+		log.Logger().Infof("No test results found for %s, they are stored by 'jx step report tests' or 'jx step report junit'", util.ColorInfo(owner+"/"+repository))
+		return nil
+	}
+	if len(flaky) == 0 {
+		log.Logger().Infof("No flaky tests found in the last %d builds of %s", len(history), util.ColorInfo(owner+"/"+repository))
+		return nil
+	}
+	table := o.CreateTable()
+	table.AddRow("TEST", "FLIP RATE", "FLIPS", "FAILURES", "LAST FAILED BUILD")
+	for _, f := range flaky {
+		table.AddRow(f.Key,
+			fmt.Sprintf("%.0f%%", f.FlipRate*100),
+			fmt.Sprintf("%d", f.Flips),
+			fmt.Sprintf("%d/%d", f.Failures, f.Runs),
+			f.LastFailedBuild)
+	}
+	table.Render()
+	return nil
+}
+
+// ownerAndRepository returns the owner and name of the repository from the --repo option or the current directory
+func (o *GetTestsFlakyOptions) ownerAndRepository() (string, string, error) {
+	if o.Repository != "" {
+		parts := strings.Split(o.Repository, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", "", util.InvalidOptionf("repo", o.Repository, "the repository must be of the form owner/name")
+		}
+		return parts[0], parts[1], nil
+	}
+	gitInfo, err := o.FindGitInfo("")
+	if err != nil {
+		return "", "", errors.Wrap(err, "finding the repository of the current directory, use the --repo option")
+	}
+	return gitInfo.Organisation, gitInfo.Name, nil
+}
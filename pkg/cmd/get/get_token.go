@@ -15,6 +15,16 @@ type GetTokenOptions struct {
 	Name string
 }
 
+// TokenOutput a user of a service rendered by the --output flag which says whether the user has a token without
+// exposing it
+type TokenOutput struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Username string `json:"username,omitempty"`
+	HasToken bool   `json:"hasToken"`
+}
+
 // NewCmdGetToken creates the command
 func NewCmdGetToken(commonOpts *opts.CommonOptions) *cobra.Command {
 	options := &GetTokenOptions{
@@ -41,6 +51,7 @@ func NewCmdGetToken(commonOpts *opts.CommonOptions) *cobra.Command {
 func (o *GetTokenOptions) addFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.Kind, "kind", "k", "", "Filters the services by the kind")
 	cmd.Flags().StringVarP(&o.Name, "name", "n", "", "Filters the services by the name")
+	o.AddGetFlags(cmd)
 }
 
 // Run implements this command
@@ -54,6 +65,21 @@ func (o *GetTokenOptions) displayUsersWithTokens(authConfigSvc auth.ConfigServic
 	filterKind := o.Kind
 	filterName := o.Name
 
+	if o.Output != "" {
+		tokens := []TokenOutput{}
+		for _, s := range config.Servers {
+			if (filterKind == "" || filterKind == s.Kind) && (filterName == "" || filterName == s.Name) {
+				if len(s.Users) == 0 {
+					tokens = append(tokens, TokenOutput{Kind: s.Kind, Name: s.Name, URL: s.URL})
+				}
+				for _, u := range s.Users {
+					tokens = append(tokens, TokenOutput{Kind: s.Kind, Name: s.Name, URL: s.URL, Username: u.Username, HasToken: u.ApiToken != ""})
+				}
+			}
+		}
+		return o.renderResult(tokens, o.Output)
+	}
+
 	table := o.CreateTable()
 	table.AddRow("KIND", "NAME", "URL", "USERNAME", "TOKEN?")
 
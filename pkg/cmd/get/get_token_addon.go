@@ -24,6 +24,9 @@ var (
 	getTokenAddonExample = templates.Examples(`
 		# List all users with tokens for all addons
 		jx get token addon
+
+		# Output the users of the addons and whether they have a token as JSON
+		jx get token addon -o json
 	`)
 )
 
@@ -61,7 +64,7 @@ func (o *GetTokenAddonOptions) Run() error {
 		return err
 	}
 	config := authConfigSvc.Config()
-	if len(config.Servers) == 0 {
+	if len(config.Servers) == 0 && o.Output == "" {
 		log.Logger().Warnf("No addon servers registered. To register a new token for an addon server use: %s", util.ColorInfo("jx create token addon"))
 		return nil
 	}
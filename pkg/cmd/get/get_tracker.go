@@ -31,6 +31,9 @@ var (
 	getTrackerExample = templates.Examples(`
 		# List all registered issue tracker server URLs
 		jx get tracker
+
+		# Output the URLs of the Jira issue trackers
+		jx get tracker -k jira -o jsonpath='{[*].url}'
 	`)
 )
 
@@ -56,6 +59,7 @@ func NewCmdGetTracker(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&options.Kind, "kind", "k", "", "Filters the issue trackers by the kinds: "+strings.Join(issues.IssueTrackerKinds, ", "))
+	options.AddGetFlags(cmd)
 	return cmd
 }
 
@@ -66,6 +70,9 @@ func (o *GetTrackerOptions) Run() error {
 		return err
 	}
 	config := authConfigSvc.Config()
+	if o.Output != "" {
+		return o.renderResult(authServersOutput(config.Servers, o.Kind), o.Output)
+	}
 	if len(config.Servers) == 0 {
 		log.Logger().Infof("No issue trackers registered. To register a new issue tracker use: %s", util.ColorInfo("jx create tracker server"))
 		return nil
@@ -29,6 +29,9 @@ var (
 	get_url_example = templates.Examples(`
 		# List all URLs in this namespace
 		jx get url
+
+		# Output the URL of a service in the staging environment
+		jx get url -e staging -o go-template='{{range .}}{{if eq .name "myapp"}}{{.url}}{{end}}{{end}}'
 	`)
 )
 
@@ -54,6 +57,7 @@ func NewCmdGetURL(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	options.AddGetUrlFlags(cmd)
+	options.AddGetFlags(cmd)
 	return cmd
 }
 
@@ -81,6 +85,14 @@ func (o *GetURLOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		if o.OnlyViewHost {
+			for i := range urls {
+				urls[i].URL = util.URLToHostName(urls[i].URL)
+			}
+		}
+		return o.renderResult(urls, o.Output)
+	}
 	table := o.CreateTable()
 	header := "URL"
 	if o.OnlyViewHost {
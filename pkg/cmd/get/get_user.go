@@ -3,6 +3,7 @@ package get
 import (
 	"strings"
 
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
@@ -28,6 +29,9 @@ var (
 	getUserExample = templates.Examples(`
 		# List the users
 		jx get user
+
+		# Output the emails of the users
+		jx get user -o jsonpath='{.items[*].spec.email}'
 	`)
 )
 
@@ -71,6 +75,15 @@ func (o *GetUserOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.Output != "" {
+		list := &v1.UserList{Items: []v1.User{}}
+		for _, name := range names {
+			if user := users[name]; user != nil {
+				list.Items = append(list.Items, *user)
+			}
+		}
+		return o.renderResult(list, o.Output)
+	}
 
 	if len(names) == 0 {
 		log.Logger().Info(`
@@ -27,6 +27,9 @@ var (
 	getVaultExample = templates.Examples(`
 		# List all vaults 
 		jx get vaults
+
+		# Output the URL of each vault
+		jx get vaults -o jsonpath='{[*].url}'
 	`)
 )
 
@@ -83,7 +86,13 @@ func (o *GetVaultOptions) Run() error {
 	vaults, err := vault.GetVaults(client, vaultOperatorClient, o.Namespace, useIngressURL)
 	if err != nil {
 		log.Logger().Infof("No vault found.")
-		return nil
+		vaults = []*vault.Vault{}
+		if o.Output == "" {
+			return nil
+		}
+	}
+	if o.Output != "" {
+		return o.renderResult(vaults, o.Output)
 	}
 
 	table := o.CreateTable()
@@ -21,6 +21,12 @@ type GetVaultConfigOptions struct {
 	terminal  string
 }
 
+// VaultConfigOutput the vault configuration rendered by the --output flag
+type VaultConfigOutput struct {
+	Address string `json:"address"`
+	Token   string `json:"token"`
+}
+
 func (o *GetVaultConfigOptions) VaultName() string {
 	return o.Name
 }
@@ -37,6 +43,9 @@ var (
 	getVaultConfigExample = templates.Examples(`
 		# Gets vault config
 		jx get vault-config
+
+		# Gets the address of the vault
+		jx get vault-config -o jsonpath='{.address}'
 	`)
 )
 
@@ -83,6 +92,12 @@ func (o *GetVaultConfigOptions) Run() error {
 	}
 
 	url, token, err := vaultClient.Config()
+	if err != nil {
+		return err
+	}
+	if o.Output != "" {
+		return o.renderResult(&VaultConfigOutput{Address: url.String(), Token: token}, o.Output)
+	}
 	// Echo the client config out to the command line to be piped into bash
 	if o.terminal == "" {
 		if runtime.GOOS == "windows" {
@@ -96,6 +111,5 @@ func (o *GetVaultConfigOptions) Run() error {
 	} else {
 		_, _ = fmt.Fprintf(o.Out, "export VAULT_ADDR=%s\nexport VAULT_TOKEN=%s\n", url.String(), token)
 	}
-
-	return err
+	return nil
 }
@@ -0,0 +1,199 @@
+package login
+
+import (
+	"context"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/browser"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// LoginOptions the options for the "jx login" command
+type LoginOptions struct {
+	*opts.CommonOptions
+
+	OIDCIssuer string
+	ClientID   string
+	Scopes     []string
+	Servers    []string
+	UIServers  []string
+	Kind       string
+	Device     bool
+	NoBrowser  bool
+	Timeout    time.Duration
+
+	// OpenURL opens the login URL in a browser, defaults to the system browser
+	OpenURL func(string) error
+}
+
+var (
+	loginLong = templates.LongDesc(`
+		Logs in to an OIDC single sign on provider so that the jx CLI can use your own short lived tokens rather than
+		shared personal access tokens.
+
+		By default a browser is opened to login using the authorization code flow with PKCE. Use --device to login via
+		the device code flow when no browser is available, such as over SSH.
+
+		The refresh token is stored in your local jx config directory and the access tokens are refreshed transparently
+		when they expire for each of the git servers specified via --server and the Jenkins X UI URLs specified via
+		--ui-server. The Jenkins X UI is then opened by 'jx ui' via a local proxy which authenticates with your session.
+`)
+
+	loginExample = templates.Examples(`
+		# Login using the OIDC issuer for your git server
+		jx login --oidc https://sso.example.com/auth/realms/dev --server https://git.example.com
+
+		# Login to your git server and the Jenkins X UI
+		jx login --oidc https://sso.example.com/auth/realms/dev --server https://git.example.com --ui-server https://jenkins-x.example.com
+
+		# Login from a terminal without a browser
+		jx login --oidc https://sso.example.com/auth/realms/dev --server https://git.example.com --device
+	`)
+)
+
+// NewCmdLogin creates the "jx login" command
+func NewCmdLogin(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &LoginOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "login",
+		Short:   "Logs in to an OIDC single sign on provider to get tokens for git servers and the Jenkins X UI",
+		Long:    loginLong,
+		Example: loginExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.OIDCIssuer, "oidc", "", "", "The issuer URL of the OIDC provider to login to")
+	cmd.Flags().StringVarP(&options.ClientID, "client-id", "", auth.OIDCDefaultClientID, "The OIDC client ID registered for the jx CLI")
+	cmd.Flags().StringArrayVarP(&options.Scopes, "scope", "", auth.OIDCDefaultScopes, "The OIDC scopes to request")
+	cmd.Flags().StringArrayVarP(&options.Servers, "server", "s", nil, "The URLs of the git servers which accept the OIDC tokens. Defaults to the issuer URL unless --ui-server is specified")
+	cmd.Flags().StringArrayVarP(&options.UIServers, "ui-server", "", nil, "The URLs of the Jenkins X UI which accept the OIDC tokens")
+	cmd.Flags().StringVarP(&options.Kind, "kind", "k", "", "The kind of the servers such as 'gitea' or 'gitlab'")
+	cmd.Flags().BoolVarP(&options.Device, "device", "", false, "Login using the device code flow rather than opening a browser")
+	cmd.Flags().BoolVarP(&options.NoBrowser, "no-browser", "", false, "Print the login URL rather than opening a browser")
+	cmd.Flags().DurationVarP(&options.Timeout, "timeout", "t", 5*time.Minute, "The maximum time to wait for the login to complete")
+	return cmd
+}
+
+// Run implements this command
+func (o *LoginOptions) Run() error {
+	if o.OIDCIssuer == "" {
+		return util.MissingOption("oidc")
+	}
+	if o.ClientID == "" {
+		return util.MissingOption("client-id")
+	}
+	servers := o.Servers
+	if len(servers) == 0 && len(o.UIServers) == 0 {
+		servers = []string{o.OIDCIssuer}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout)
+	defer cancel()
+
+	provider, err := auth.DiscoverOIDCProvider(ctx, o.OIDCIssuer)
+	if err != nil {
+		return err
+	}
+	var token *oauth2.Token
+	if o.Device || provider.AuthorizationEndpoint == "" {
+		token, err = o.deviceLogin(ctx, provider)
+	} else {
+		token, err = provider.LoopbackLogin(ctx, o.ClientID, o.Scopes, o.openLoginURL)
+	}
+	if err != nil {
+		return err
+	}
+	if token.RefreshToken == "" {
+		log.Logger().Warnf("the OIDC issuer did not return a refresh token so you will need to login again when the token expires. Check the client allows the offline_access scope")
+	}
+	claims, err := provider.ParseOIDCClaims(ctx, o.ClientID, token)
+	if err != nil {
+		return errors.Wrap(err, "finding the user name from the OIDC token")
+	}
+	username := claims.Username()
+
+	authService, err := auth.NewOIDCAuthConfigService(auth.OIDCAuthConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "creating the OIDC auth config service")
+	}
+	config, err := authService.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "loading the OIDC sessions")
+	}
+	for _, server := range servers {
+		err = o.saveSession(authService, config, server, o.Kind, username, provider.Issuer, token)
+		if err != nil {
+			return err
+		}
+	}
+	for _, server := range o.UIServers {
+		err = o.saveSession(authService, config, server, auth.OIDCUIServerKind, username, provider.Issuer, token)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *LoginOptions) saveSession(authService auth.ConfigService, config *auth.AuthConfig, server string, kind string, username string, issuer string, token *oauth2.Token) error {
+	config.GetOrCreateServerName(server, "", kind)
+	user := &auth.UserAuth{
+		Username: username,
+	}
+	user.SetOIDCToken(issuer, o.ClientID, token)
+	err := authService.SaveUserAuth(server, user)
+	if err != nil {
+		return errors.Wrapf(err, "saving the OIDC session for %s", server)
+	}
+	log.Logger().Infof("Logged in as %s to %s", util.ColorInfo(username), util.ColorInfo(server))
+	return nil
+}
+
+func (o *LoginOptions) deviceLogin(ctx context.Context, provider *auth.OIDCProvider) (*oauth2.Token, error) {
+	device, err := provider.StartDeviceLogin(ctx, o.ClientID, o.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	log.Logger().Infof("To login open %s and enter the code %s", util.ColorInfo(device.VerificationURI), util.ColorInfo(device.UserCode))
+	if device.VerificationURIComplete != "" && !o.NoBrowser && !o.BatchMode {
+		err = o.openURL(device.VerificationURIComplete)
+		if err != nil {
+			log.Logger().Debugf("failed to open the browser: %s", err)
+		}
+	}
+	return provider.PollDeviceLogin(ctx, o.ClientID, device)
+}
+
+func (o *LoginOptions) openLoginURL(u string) error {
+	if o.NoBrowser || o.BatchMode {
+		log.Logger().Infof("To login open the following URL in a browser on this machine:\n\n%s\n", util.ColorInfo(u))
+		return nil
+	}
+	log.Logger().Info("Opening the browser to login...")
+	err := o.openURL(u)
+	if err != nil {
+		log.Logger().Infof("Failed to open the browser so please open the following URL:\n\n%s\n", util.ColorInfo(u))
+	}
+	return nil
+}
+
+func (o *LoginOptions) openURL(u string) error {
+	if o.OpenURL != nil {
+		return o.OpenURL(u)
+	}
+	return browser.OpenURL(u)
+}
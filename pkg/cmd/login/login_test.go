@@ -0,0 +1,138 @@
+// +build unit
+
+package login_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	clientsfake "github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/login"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestLoginOIDCDevice(t *testing.T) {
+	jxHome, err := ioutil.TempDir("", "test-jx-login-")
+	require.NoError(t, err)
+	defer os.RemoveAll(jxHome)
+	originalJxHome := os.Getenv("JX_HOME")
+	defer os.Setenv("JX_HOME", originalJxHome)
+	os.Setenv("JX_HOME", jxHome)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	polls := 0
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]string{
+			"issuer":                        server.URL,
+			"token_endpoint":                server.URL + "/token",
+			"device_authorization_endpoint": server.URL + "/device",
+			"jwks_uri":                      server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, &jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test", Algorithm: string(jose.RS256), Use: "sig"}},
+		})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "jx", r.PostForm.Get("client_id"))
+		writeJSON(t, w, map[string]interface{}{
+			"device_code":      "dev-123",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": server.URL + "/activate",
+			"expires_in":       60,
+			"interval":         1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "dev-123", r.PostForm.Get("device_code"))
+		polls++
+		if polls == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(t, w, map[string]string{"error": "authorization_pending"})
+			return
+		}
+		claims, err := json.Marshal(map[string]interface{}{
+			"iss":   server.URL,
+			"aud":   auth.OIDCDefaultClientID,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"sub":   "123",
+			"email": "james@example.com",
+		})
+		require.NoError(t, err)
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: "test"}}, nil)
+		require.NoError(t, err)
+		signed, err := signer.Sign(claims)
+		require.NoError(t, err)
+		idToken, err := signed.CompactSerialize()
+		require.NoError(t, err)
+		writeJSON(t, w, map[string]interface{}{
+			"access_token":  "access-1",
+			"refresh_token": "refresh-1",
+			"expires_in":    3600,
+			"id_token":      idToken,
+		})
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	commonOpts := opts.NewCommonOptionsWithFactory(clientsfake.NewFakeFactory())
+	commonOpts.Out = os.Stdout
+	commonOpts.BatchMode = true
+	options := &login.LoginOptions{
+		CommonOptions: &commonOpts,
+		OIDCIssuer:    server.URL,
+		ClientID:      auth.OIDCDefaultClientID,
+		Scopes:        auth.OIDCDefaultScopes,
+		Servers:       []string{"https://git.example.com"},
+		UIServers:     []string{"https://jx.example.com"},
+		Device:        true,
+		Timeout:       time.Minute,
+	}
+	err = options.Run()
+	require.NoError(t, err, "failed to login")
+	assert.Equal(t, 2, polls)
+
+	svc, err := auth.NewOIDCAuthConfigService(auth.OIDCAuthConfigFile)
+	require.NoError(t, err)
+	config, err := svc.LoadConfig()
+	require.NoError(t, err)
+	for _, u := range append(options.Servers, options.UIServers...) {
+		user := config.GetServer(u).CurrentAuth()
+		require.NotNil(t, user, "no user for %s", u)
+		assert.Equal(t, "james@example.com", user.Username)
+		assert.Equal(t, "access-1", user.ApiToken)
+		assert.Equal(t, "refresh-1", user.RefreshToken)
+		assert.Equal(t, server.URL, user.OIDCIssuer)
+	}
+	assert.Equal(t, auth.OIDCUIServerKind, config.GetServer("https://jx.example.com").Kind)
+}
+
+func TestLoginRequiresOIDC(t *testing.T) {
+	commonOpts := opts.NewCommonOptionsWithFactory(clientsfake.NewFakeFactory())
+	options := &login.LoginOptions{
+		CommonOptions: &commonOpts,
+		ClientID:      auth.OIDCDefaultClientID,
+	}
+	assert.Error(t, options.Run())
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, value interface{}) {
+	require.NoError(t, json.NewEncoder(w).Encode(value))
+}
@@ -1,6 +1,7 @@
 package opts
 
 import (
+	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/expose"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/pkg/errors"
@@ -12,11 +13,33 @@ func (o *CommonOptions) Expose(devNamespace, targetNamespace, password string) e
 	if err != nil {
 		return errors.Wrap(err, "creating cert-manager client")
 	}
+	tlsConfig, err := o.IngressTLSConfig()
+	if err != nil {
+		return err
+	}
 	versionsDir, _, err := o.CloneJXVersionsRepo("", "")
 	if err != nil {
 		return errors.Wrapf(err, "failed to clone the Jenkins X versions repository")
 	}
-	return expose.Expose(o.kubeClient, certClient, devNamespace, targetNamespace, password, o.Helm(), DefaultInstallTimeout, versionsDir)
+	return expose.Expose(o.kubeClient, certClient, devNamespace, targetNamespace, password, tlsConfig, o.Helm(),
+		DefaultInstallTimeout, versionsDir)
+}
+
+// IngressTLSConfig returns the ingress TLS configuration of the requirements in the team settings or nil if the
+// cluster was not installed with boot
+func (o *CommonOptions) IngressTLSConfig() (*config.TLSConfig, error) {
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the team settings")
+	}
+	requirements, err := config.GetRequirementsConfigFromTeamSettings(teamSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the requirements from the team settings")
+	}
+	if requirements == nil {
+		return nil, nil
+	}
+	return &requirements.Ingress.TLS, nil
 }
 
 // RunExposecontroller runs exponse controller in the given target dir with the given ingress configuration
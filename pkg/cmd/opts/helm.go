@@ -14,6 +14,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
 
 	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/kubevault"
 	"github.com/jenkins-x/jx/v2/pkg/secreturl/localvault"
 	"github.com/pborman/uuid"
 
@@ -427,6 +428,17 @@ func (o *CommonOptions) GetSecretURLClient(location secrets.SecretsLocationKind)
 			return o.secretURLClient, errors.Wrapf(err, "getting the file system secrets directory")
 		}
 		o.secretURLClient = localvault.NewFileSystemClient(dir)
+	case secrets.KubeLocationKind:
+		kubeClient, ns, err := o.KubeClientAndDevNamespace()
+		if err != nil {
+			return o.secretURLClient, errors.Wrapf(err, "creating the kube client")
+		}
+		o.secretURLClient = kubevault.NewClient(kubeClient, ns)
+	case secrets.AWSSecretsManagerLocationKind, secrets.GCPSecretManagerLocationKind, secrets.AzureKeyVaultLocationKind:
+		o.secretURLClient, err = o.createSecretManagerURLClient(location)
+		if err != nil {
+			return o.secretURLClient, errors.Wrapf(err, "creating %s secret URL client", location)
+		}
 	case secrets.AutoLocationKind:
 		location := o.detectSecretsLocation()
 		o.secretURLClient, err = o.GetSecretURLClient(location)
@@ -439,6 +451,9 @@ func (o *CommonOptions) GetSecretURLClient(location secrets.SecretsLocationKind)
 
 // detectSecretsLocation detects dynamically the secrets location by trying to create a vault client
 func (o *CommonOptions) detectSecretsLocation() secrets.SecretsLocationKind {
+	if location := o.GetSecretsLocation(); location == secrets.KubeLocationKind || secrets.IsCloudSecretManager(location) {
+		return location
+	}
 	_, err := o.SystemVaultClient(o.devNamespace)
 	if err == nil {
 		return secrets.VaultLocationKind
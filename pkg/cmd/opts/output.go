@@ -0,0 +1,163 @@
+package opts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	// OptionOutput the name of the flag for the output format of a command
+	OptionOutput = "output"
+
+	// OutputFormatJSON renders the result as JSON
+	OutputFormatJSON = "json"
+	// OutputFormatYAML renders the result as YAML
+	OutputFormatYAML = "yaml"
+	// OutputFormatGoTemplate renders the result with the Go template following the '='
+	OutputFormatGoTemplate = "go-template"
+	// OutputFormatGoTemplateFile renders the result with the Go template in the file following the '='
+	OutputFormatGoTemplateFile = "go-template-file"
+	// OutputFormatJSONPath renders the result with the JSONPath expression following the '='
+	OutputFormatJSONPath = "jsonpath"
+	// OutputFormatJSONPathFile renders the result with the JSONPath expression in the file following the '='
+	OutputFormatJSONPathFile = "jsonpath-file"
+)
+
+// OutputFormats the output formats which can be passed to --output
+var OutputFormats = []string{
+	OutputFormatJSON,
+	OutputFormatYAML,
+	OutputFormatGoTemplate + "=...",
+	OutputFormatGoTemplateFile + "=...",
+	OutputFormatJSONPath + "=...",
+	OutputFormatJSONPathFile + "=...",
+}
+
+// AddOutputFlag adds the --output flag used to render the result of a command in a machine readable format rather
+// than as a table. The -o shorthand is only added if the command does not already use it for another flag
+func AddOutputFlag(cmd *cobra.Command, output *string) {
+	shorthand := "o"
+	if cmd.Flags().ShorthandLookup(shorthand) != nil {
+		shorthand = ""
+	}
+	cmd.Flags().StringVarP(output, OptionOutput, shorthand, "",
+		fmt.Sprintf("The output format. One of: %s", strings.Join(OutputFormats, ", ")))
+}
+
+// ValidateOutputFormat returns an error if the output format is not supported
+func ValidateOutputFormat(format string) error {
+	_, _, err := parseOutputFormat(format)
+	return err
+}
+
+// RenderOutput renders the value in the given output format. The Go template and JSONPath formats are evaluated
+// against the JSON representation of the value so that they use the same field names as the JSON and YAML formats
+func RenderOutput(out io.Writer, value interface{}, format string) error {
+	kind, arg, err := parseOutputFormat(format)
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case OutputFormatJSON:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrap(err, "marshalling the output to JSON")
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case OutputFormatYAML:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return errors.Wrap(err, "marshalling the output to YAML")
+		}
+		_, err = out.Write(data)
+		return err
+	}
+
+	data, err := toJSONData(value)
+	if err != nil {
+		return err
+	}
+	if kind == OutputFormatGoTemplate {
+		tmpl, err := template.New("output").Parse(arg)
+		if err != nil {
+			return errors.Wrapf(err, "parsing the Go template %s", arg)
+		}
+		return tmpl.Execute(out, data)
+	}
+	jp := jsonpath.New("output").AllowMissingKeys(true)
+	err = jp.Parse(relaxedJSONPath(arg))
+	if err != nil {
+		return errors.Wrapf(err, "parsing the JSONPath expression %s", arg)
+	}
+	return jp.Execute(out, data)
+}
+
+// parseOutputFormat returns the kind of output format and its template or JSONPath expression, reading it from a file
+// for the file formats
+func parseOutputFormat(format string) (string, string, error) {
+	kind := format
+	arg := ""
+	if i := strings.Index(format, "="); i > 0 {
+		kind = format[:i]
+		arg = format[i+1:]
+	}
+	switch kind {
+	case OutputFormatJSON, OutputFormatYAML:
+		if arg == "" {
+			return kind, "", nil
+		}
+	case OutputFormatGoTemplate, OutputFormatJSONPath:
+		if arg != "" {
+			return kind, arg, nil
+		}
+	case OutputFormatGoTemplateFile, OutputFormatJSONPathFile:
+		if arg != "" {
+			data, err := ioutil.ReadFile(arg)
+			if err != nil {
+				return "", "", errors.Wrapf(err, "reading the output template %s", arg)
+			}
+			return strings.TrimSuffix(kind, "-file"), string(data), nil
+		}
+	}
+	return "", "", util.InvalidOptionf(OptionOutput, format, "expected one of: %s", strings.Join(OutputFormats, ", "))
+}
+
+// toJSONData converts the value to the generic maps and slices of its JSON representation
+func toJSONData(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling the output to JSON")
+	}
+	var answer interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&answer)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling the output from JSON")
+	}
+	return answer, nil
+}
+
+// relaxedJSONPath wraps a JSONPath expression in braces if it has none so that both '{.items[*].name}' and
+// '.items[*].name' can be used
+func relaxedJSONPath(expression string) string {
+	if strings.Contains(expression, "{") {
+		return expression
+	}
+	if !strings.HasPrefix(expression, ".") && !strings.HasPrefix(expression, "[") {
+		expression = "." + expression
+	}
+	return "{" + expression + "}"
+}
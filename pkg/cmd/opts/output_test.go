@@ -0,0 +1,105 @@
+// +build unit
+
+package opts_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type outputItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type outputList struct {
+	Items []outputItem `json:"items"`
+}
+
+var testOutput = &outputList{
+	Items: []outputItem{
+		{Name: "cheese", Count: 1},
+		{Name: "wine", Count: 2},
+	},
+}
+
+func renderOutput(t *testing.T, format string) string {
+	var out bytes.Buffer
+	err := opts.RenderOutput(&out, testOutput, format)
+	require.NoError(t, err, "rendering %s", format)
+	return out.String()
+}
+
+func TestRenderOutput(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `{"items":[{"name":"cheese","count":1},{"name":"wine","count":2}]}`+"\n", renderOutput(t, "json"))
+	assert.Equal(t, "items:\n- count: 1\n  name: cheese\n- count: 2\n  name: wine\n", renderOutput(t, "yaml"))
+	assert.Equal(t, "cheese=1 wine=2 ", renderOutput(t, `go-template={{range .items}}{{.name}}={{.count}} {{end}}`))
+	assert.Equal(t, "cheese wine", renderOutput(t, "jsonpath={.items[*].name}"))
+	assert.Equal(t, "cheese wine", renderOutput(t, "jsonpath=.items[*].name"))
+	assert.Equal(t, "", renderOutput(t, "jsonpath={.items[*].colour}"))
+}
+
+func TestRenderOutputFiles(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-render-output-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	templateFile := filepath.Join(dir, "output.tmpl")
+	err = ioutil.WriteFile(templateFile, []byte(`{{range .items}}{{.name}}{{"\n"}}{{end}}`), 0600)
+	require.NoError(t, err)
+	jsonPathFile := filepath.Join(dir, "output.jsonpath")
+	err = ioutil.WriteFile(jsonPathFile, []byte(`{.items[1].count}`), 0600)
+	require.NoError(t, err)
+
+	assert.Equal(t, "cheese\nwine\n", renderOutput(t, "go-template-file="+templateFile))
+	assert.Equal(t, "2", renderOutput(t, "jsonpath-file="+jsonPathFile))
+
+	err = opts.ValidateOutputFormat("jsonpath-file=" + filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{"json", "yaml", "go-template={{.}}", "jsonpath={.items}"} {
+		assert.NoError(t, opts.ValidateOutputFormat(format), "format %s", format)
+	}
+	for _, format := range []string{"", "table", "json=foo", "go-template", "jsonpath=", "csv"} {
+		assert.Error(t, opts.ValidateOutputFormat(format), "format %s", format)
+	}
+
+	var out bytes.Buffer
+	assert.Error(t, opts.RenderOutput(&out, testOutput, "go-template={{.items"))
+	assert.Error(t, opts.RenderOutput(&out, testOutput, "jsonpath={.items[}"))
+	assert.Empty(t, out.String())
+}
+
+func TestAddOutputFlag(t *testing.T) {
+	t.Parallel()
+
+	var output string
+	cmd := &cobra.Command{}
+	opts.AddOutputFlag(cmd, &output)
+	require.NoError(t, cmd.Flags().Parse([]string{"-o", "yaml"}))
+	assert.Equal(t, "yaml", output)
+
+	var owner string
+	cmd = &cobra.Command{}
+	cmd.Flags().StringVarP(&owner, "owner", "o", "", "")
+	opts.AddOutputFlag(cmd, &output)
+	require.NoError(t, cmd.Flags().Parse([]string{"-o", "jstrachan", "--output", "json"}))
+	assert.Equal(t, "jstrachan", owner)
+	assert.Equal(t, "json", output)
+}
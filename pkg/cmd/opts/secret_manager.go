@@ -0,0 +1,80 @@
+package opts
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/io/secrets"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/awssm"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/azurekv"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/gcpsm"
+	"github.com/pkg/errors"
+)
+
+// CreateSecretManagerClient creates a secret URL client for the cloud secret manager of the given location using the
+// secret manager configuration of the requirements
+func CreateSecretManagerClient(location secrets.SecretsLocationKind, requirements *config.RequirementsConfig) (secreturl.Client, error) {
+	if requirements == nil {
+		requirements = config.NewRequirementsConfig()
+	}
+	sm := requirements.SecretManager
+	prefix := sm.Prefix
+	switch location {
+	case secrets.AWSSecretsManagerLocationKind:
+		region := sm.Region
+		if region == "" {
+			region = requirements.Cluster.Region
+		}
+		return awssm.NewClient(region, prefix)
+	case secrets.GCPSecretManagerLocationKind:
+		project := sm.Project
+		if project == "" {
+			project = requirements.Cluster.ProjectID
+		}
+		return gcpsm.NewClient(project, prefix)
+	case secrets.AzureKeyVaultLocationKind:
+		return azurekv.NewClient(sm.KeyVault, prefix)
+	default:
+		return nil, fmt.Errorf("secrets location %q is not a cloud secret manager", location)
+	}
+}
+
+// createSecretManagerURLClient creates the secret URL client for the cloud secret manager using the requirements of the
+// current boot configuration or the team settings
+func (o *CommonOptions) createSecretManagerURLClient(location secrets.SecretsLocationKind) (secreturl.Client, error) {
+	requirements, err := o.loadSecretManagerRequirements()
+	if err != nil {
+		return nil, errors.Wrap(err, "loading the requirements for the secret manager configuration")
+	}
+	return CreateSecretManagerClient(location, requirements)
+}
+
+// loadSecretManagerRequirements loads the requirements from the current directory or falls back to the
+// requirements in the team settings
+func (o *CommonOptions) loadSecretManagerRequirements() (*config.RequirementsConfig, error) {
+	requirements, _, err := config.LoadRequirementsConfig("")
+	if err == nil {
+		return requirements, nil
+	}
+	log.Logger().Debugf("failed to load the requirements from the current directory: %s", err.Error())
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the team settings")
+	}
+	return config.GetRequirementsConfigFromTeamSettings(teamSettings)
+}
+
+// GetSecretURLClientForRequirements returns the secret URL client for the secret storage of the given requirements
+func (o *CommonOptions) GetSecretURLClientForRequirements(requirements *config.RequirementsConfig) (secreturl.Client, error) {
+	location := secrets.ToSecretsLocation(string(requirements.SecretStorage))
+	if o.secretURLClient == nil && secrets.IsCloudSecretManager(location) {
+		client, err := CreateSecretManagerClient(location, requirements)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating %s secret URL client", location)
+		}
+		o.secretURLClient = client
+	}
+	return o.GetSecretURLClient(location)
+}
@@ -24,8 +24,8 @@ import (
 )
 
 type BranchPatterns struct {
-	DefaultBranchPattern string
-	ForkBranchPattern    string
+	DefaultBranchPattern string `json:"defaultBranchPattern"`
+	ForkBranchPattern    string `json:"forkBranchPattern,omitempty"`
 }
 
 const (
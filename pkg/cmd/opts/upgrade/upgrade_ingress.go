@@ -523,6 +523,10 @@ func (o *UpgradeIngressOptions) createIngressRules() error {
 	if err != nil {
 		return fmt.Errorf("cannot find a dev team namespace to get existing exposecontroller config from. %v", err)
 	}
+	tlsConfig, err := o.IngressTLSConfig()
+	if err != nil {
+		return err
+	}
 	for _, n := range o.TargetNamespaces {
 		o.CleanExposecontrollerReources(n)
 
@@ -543,7 +547,7 @@ func (o *UpgradeIngressOptions) createIngressRules() error {
 			}
 		}
 
-		err := pki.CreateCertManagerResources(certmngClient, n, o.IngressConfig)
+		err := pki.CreateCertManagerResources(certmngClient, n, o.IngressConfig, tlsConfig)
 		if err != nil {
 			return err
 		}
@@ -37,7 +37,8 @@ import (
 )
 
 const (
-	optionPullRequestPollTime = "pull-request-poll-time"
+	optionPullRequestPollTime  = "pull-request-poll-time"
+	optionCanaryRestartTimeout = "canary-restart-timeout"
 
 	GitStatusSuccess = "success"
 )
@@ -66,19 +67,27 @@ type PromoteOptions struct {
 	NoWaitAfterMerge        bool
 	IgnoreLocalFiles        bool
 	NoWaitForUpdatePipeline bool
+	NoWaitForCanary         bool
 	Timeout                 string
 	PullRequestPollTime     string
+	CanaryRestartTimeout    string
 	Filter                  string
 	Alias                   string
+	Train                   bool
+	TrainMatrix             string
+
+	// TrainApplications the applications and versions promoted together as a release train
+	TrainApplications []v1.PromoteApplication
 
 	// calculated fields
-	TimeoutDuration         *time.Duration
-	PullRequestPollDuration *time.Duration
-	Activities              typev1.PipelineActivityInterface
-	GitInfo                 *gits.GitRepository
-	releaseResource         *v1.Release
-	ReleaseInfo             *ReleaseInfo
-	prow                    bool
+	TimeoutDuration              *time.Duration
+	PullRequestPollDuration      *time.Duration
+	CanaryRestartTimeoutDuration *time.Duration
+	Activities                   typev1.PipelineActivityInterface
+	GitInfo                      *gits.GitRepository
+	releaseResource              *v1.Release
+	ReleaseInfo                  *ReleaseInfo
+	prow                         bool
 
 	// Used for testing
 	CloneDir string
@@ -114,6 +123,12 @@ var (
 		# To promote a postgres chart using an alias
 		jx promote -f postgres --alias mydb
 
+		# Promote a release train of applications to production in a single Pull Request
+		jx promote --train myapp@1.2.3 mylib@2.0.1 --env production
+
+		# Promote the versions in a dependency matrix snapshot as a release train
+		jx promote --train --train-matrix dependency-matrix/matrix.yaml --env staging
+
 		# To create or update a Preview Environment please see the 'jx preview' command if you are inside a git clone of a repo
 		jx preview
 	`)
@@ -140,6 +155,8 @@ func NewCmdPromote(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The Namespace to promote to")
 	cmd.Flags().StringVarP(&options.Environment, opts.OptionEnvironment, "e", "", "The Environment to promote to")
 	cmd.Flags().BoolVarP(&options.AllAutomatic, "all-auto", "", false, "Promote to all automatic environments in order")
+	cmd.Flags().BoolVarP(&options.Train, "train", "", false, "Promotes the applications specified as 'app@version' arguments together as a release train in a single Pull Request")
+	cmd.Flags().StringVarP(&options.TrainMatrix, optionTrainMatrix, "", "", "The dependency matrix file or project directory containing the application versions to promote as a release train")
 
 	options.AddPromoteOptions(cmd)
 	return cmd
@@ -162,6 +179,8 @@ func (o *PromoteOptions) AddPromoteOptions(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.NoMergePullRequest, "no-merge", "", false, "Disables automatic merge of promote Pull Requests")
 	cmd.Flags().BoolVarP(&o.NoPoll, "no-poll", "", false, "Disables polling for Pull Request or Pipeline status")
 	cmd.Flags().BoolVarP(&o.NoWaitAfterMerge, "no-wait", "", false, "Disables waiting for completing promotion after the Pull request is merged")
+	cmd.Flags().BoolVarP(&o.NoWaitForCanary, "no-wait-canary", "", false, "Disables waiting for the Flagger Canary analysis of the application to complete")
+	cmd.Flags().StringVarP(&o.CanaryRestartTimeout, optionCanaryRestartTimeout, "", "3m", "The timeout to wait for Flagger to start analysing the promoted version once it is deployed. If the Canary has already succeeded, e.g. as the version was already deployed, the promotion then completes, otherwise it fails")
 	cmd.Flags().BoolVarP(&o.IgnoreLocalFiles, "ignore-local-file", "", false, "Ignores the local file system when deducing the Git repository")
 }
 
@@ -250,7 +269,15 @@ func (o *PromoteOptions) EnsureApplicationNameIsDefined(sf searchForChartFn, df
 
 // Run implements this command
 func (o *PromoteOptions) Run() error {
-	err := o.EnsureApplicationNameIsDefined(o.SearchForChart, o.DiscoverAppName)
+	if o.TrainMatrix != "" {
+		o.Train = true
+	}
+	var err error
+	if o.Train {
+		err = o.LoadTrainApplications()
+	} else {
+		err = o.EnsureApplicationNameIsDefined(o.SearchForChart, o.DiscoverAppName)
+	}
 	if err != nil {
 		return err
 	}
@@ -309,6 +336,13 @@ func (o *PromoteOptions) Run() error {
 		}
 		o.TimeoutDuration = &duration
 	}
+	if o.CanaryRestartTimeout != "" {
+		duration, err := time.ParseDuration(o.CanaryRestartTimeout)
+		if err != nil {
+			return fmt.Errorf("Invalid duration format %s for option --%s: %s", o.CanaryRestartTimeout, optionCanaryRestartTimeout, err)
+		}
+		o.CanaryRestartTimeoutDuration = &duration
+	}
 
 	targetNS, env, err := o.GetTargetNamespace(o.Namespace, o.Environment)
 	if err != nil {
@@ -317,6 +351,10 @@ func (o *PromoteOptions) Run() error {
 
 	o.Activities = jxClient.JenkinsV1().PipelineActivities(ns)
 
+	if o.Train {
+		return o.PromoteTrain(targetNS, env)
+	}
+
 	releaseName := o.ReleaseName
 	if releaseName == "" {
 		releaseName = targetNS + "-" + o.Application
@@ -542,6 +580,9 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 }
 
 func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo *ReleaseInfo) error {
+	if len(o.TrainApplications) > 0 {
+		return o.promoteTrainViaPullRequest(env, releaseInfo)
+	}
 	version := o.Version
 	versionName := version
 	if versionName == "" {
@@ -685,6 +726,7 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 	logNoMergeStatuses := false
 	urlStatusMap := map[string]string{}
 	urlStatusTargetURLMap := map[string]string{}
+	startTime := time.Now()
 
 	jxClient, _, err := o.JXClient()
 	if err != nil {
@@ -743,6 +785,10 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 
 						if o.NoWaitForUpdatePipeline {
 							log.Logger().Info("Pull Request merged but we are not waiting for the update pipeline to complete!")
+							err = o.waitForCanary(ns, promoteKey, startTime, end)
+							if err != nil {
+								return err
+							}
 							err = o.CommentOnIssues(ns, env, promoteKey)
 							if err == nil {
 								err = promoteKey.OnPromoteUpdate(kubeClient, jxClient, o.Namespace, kube.CompletePromotionUpdate)
@@ -810,6 +856,10 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 								}
 								if succeeded {
 									log.Logger().Info("Merge status checks all passed so the promotion worked!")
+									err = o.waitForCanary(ns, promoteKey, startTime, end)
+									if err != nil {
+										return err
+									}
 									err = o.CommentOnIssues(ns, env, promoteKey)
 									if err == nil {
 										err = promoteKey.OnPromoteUpdate(kubeClient, jxClient, o.Namespace, kube.CompletePromotionUpdate)
@@ -955,7 +1005,7 @@ func (o *PromoteOptions) CreatePromoteKey(env *v1.Environment) *kube.PromoteStep
 		if o.releaseResource == nil && releaseName != "" {
 			jxClient, _, err := o.JXClient()
 			if err == nil && jxClient != nil {
-				release, err := jxClient.JenkinsV1().Releases(env.Spec.Namespace).Get(releaseName, metav1.GetOptions{})
+				release, err := kube.GetEnvironmentRelease(jxClient, env, releaseName)
 				if err == nil && release != nil {
 					o.releaseResource = release
 				}
@@ -1007,6 +1057,10 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 	envName := environment.Spec.Label
 	app := o.Application
 	version := o.Version
+	if len(o.TrainApplications) > 0 {
+		log.Logger().Debugf("Not commenting on issues for release train %s", TrainSummary(o.TrainApplications))
+		return nil
+	}
 	if ens == "" {
 		log.Logger().Warnf("Environment %s has no namespace", envName)
 		return nil
@@ -1089,7 +1143,7 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 		log.Logger().Debugf("Application is available at: %s", util.ColorInfo(url))
 	}
 
-	release, err := jxClient.JenkinsV1().Releases(ens).Get(releaseName, metav1.GetOptions{})
+	release, err := kube.GetEnvironmentRelease(jxClient, environment, releaseName)
 	if err == nil && release != nil {
 		o.releaseResource = release
 		issues := release.Spec.Issues
@@ -0,0 +1,163 @@
+package promote
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/flagger"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultCanaryPollDuration = 10 * time.Second
+
+	// defaultCanaryRestartTimeout how long to wait for Flagger to start analysing the promoted version once it is deployed
+	defaultCanaryRestartTimeout = 3 * time.Minute
+)
+
+// waitForCanary waits for the Flagger Canary analysis of the promoted application to complete, recording its progress
+// in the PipelineActivity. If there is no Canary for the application there is nothing to wait for.
+// If Flagger rolls back the new version the promotion fails. The phase of the Canary is only used once Flagger has
+// detected the promoted version. If Flagger does not restart the analysis within the restart timeout of the target
+// deployment being updated, e.g. as the version was already deployed, a Succeeded Canary completes the promotion
+func (o *PromoteOptions) waitForCanary(ns string, promoteKey *kube.PromoteStepActivityKey, since time.Time, end time.Time) error {
+	if o.NoWaitForCanary || o.Application == "" {
+		return nil
+	}
+	dynamicClient, _, err := o.GetFactory().CreateDynamicClient()
+	if err != nil {
+		log.Logger().Warnf("Failed to create the dynamic client so cannot check for a Flagger Canary: %s", err)
+		return nil
+	}
+	canary, err := flagger.FindCanary(dynamicClient, ns, o.Application, o.ReleaseName, ns+"-"+o.Application)
+	if err != nil {
+		log.Logger().Warnf("Failed to find the Flagger Canary for %s in namespace %s: %s", o.Application, ns, err)
+		return nil
+	}
+	if canary == nil {
+		log.Logger().Debugf("No Flagger Canary found for %s in namespace %s", o.Application, ns)
+		return nil
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return errors.Wrap(err, "Getting jx client")
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return errors.Wrap(err, "Getting kube client")
+	}
+	pollDuration := defaultCanaryPollDuration
+	if o.PullRequestPollDuration != nil {
+		pollDuration = *o.PullRequestPollDuration
+	}
+
+	info := util.ColorInfo
+	log.Logger().Infof("Waiting for the Flagger Canary %s analysis in namespace %s", info(canary.Name), info(ns))
+	started := false
+	lastProgress := ""
+	lastAppliedSpec := canary.LastAppliedSpec
+	restartTimeout := defaultCanaryRestartTimeout
+	if o.CanaryRestartTimeoutDuration != nil {
+		restartTimeout = *o.CanaryRestartTimeoutDuration
+	}
+	var restartDeadline time.Time
+	for {
+		// the restart deadline only starts once the target deployment has been updated by the environment pipeline
+		if !started && o.isCanaryTargetPromoted(kubeClient, canary) {
+			if canary.IsAnalysedSince(since, lastAppliedSpec) {
+				started = true
+			} else if restartDeadline.IsZero() {
+				restartDeadline = time.Now().Add(restartTimeout)
+			} else if time.Now().After(restartDeadline) {
+				if !canary.IsSucceeded() {
+					return o.failCanaryPromotion(kubeClient, jxClient, promoteKey, fmt.Errorf("Timed out waiting for Flagger to analyse version %s with the Canary %s in namespace %s, its last phase %s is for a previous version", o.Version, canary.Name, ns, canary.Phase))
+				}
+				log.Logger().Infof("Canary %s was not restarted as version %s has already been analysed", info(canary.Name), info(o.Version))
+				started = true
+			}
+		}
+		if started {
+			progress := canary.Progress()
+			if progress != lastProgress {
+				lastProgress = progress
+				log.Logger().Infof("Canary %s: %s", info(canary.Name), info(progress))
+			}
+			status := canary
+			updateCanary := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+				status.UpdatePromoteCanaryStep(p)
+				if status.IsFailed() {
+					return kube.FailedPromotionCanary(a, s, ps, p)
+				}
+				if status.IsSucceeded() || status.IsInitialized() {
+					return kube.CompletePromotionCanary(a, s, ps, p)
+				}
+				return kube.StartPromotionCanary(a, s, ps, p)
+			}
+			err = promoteKey.OnPromoteCanary(jxClient, o.Namespace, updateCanary)
+			if err != nil {
+				log.Logger().Warnf("Failed to update PipelineActivity: %s", err)
+			}
+
+			if canary.IsFailed() {
+				message := canary.Message
+				if message == "" {
+					message = canary.Progress()
+				}
+				return o.failCanaryPromotion(kubeClient, jxClient, promoteKey, fmt.Errorf("Flagger rolled back the Canary %s in namespace %s: %s", canary.Name, ns, message))
+			}
+			if canary.IsSucceeded() {
+				log.Logger().Infof("Canary %s analysis succeeded", info(canary.Name))
+				return nil
+			}
+			if canary.IsInitialized() {
+				log.Logger().Infof("Canary %s was initialized without an analysis", info(canary.Name))
+				return nil
+			}
+		}
+		if time.Now().After(end) {
+			return o.failCanaryPromotion(kubeClient, jxClient, promoteKey, fmt.Errorf("Timed out waiting for the Canary %s analysis in namespace %s", canary.Name, ns))
+		}
+		time.Sleep(pollDuration)
+
+		latest, err := flagger.GetCanary(dynamicClient, ns, canary.Name)
+		if err != nil {
+			log.Logger().Warnf("Failed to get the Canary %s: %s", canary.Name, err)
+		} else if latest == nil {
+			log.Logger().Warnf("The Canary %s in namespace %s has been removed", canary.Name, ns)
+			return nil
+		} else {
+			canary = latest
+		}
+	}
+}
+
+// failCanaryPromotion marks the promote update step of the PipelineActivity as failed and returns the given error
+func (o *PromoteOptions) failCanaryPromotion(kubeClient kubernetes.Interface, jxClient versioned.Interface, promoteKey *kube.PromoteStepActivityKey, err error) error {
+	updateErr := promoteKey.OnPromoteUpdate(kubeClient, jxClient, o.Namespace, kube.FailedPromotionUpdate)
+	if updateErr != nil {
+		log.Logger().Warnf("Failed to update PipelineActivity: %s", updateErr)
+	}
+	return err
+}
+
+// isCanaryTargetPromoted returns true if the deployment targeted by the Canary has the promoted version or its version
+// cannot be determined
+func (o *PromoteOptions) isCanaryTargetPromoted(kubeClient kubernetes.Interface, canary *flagger.CanaryStatus) bool {
+	if o.Version == "" || canary.TargetName == "" {
+		return true
+	}
+	deployment, err := kubeClient.AppsV1().Deployments(canary.Namespace).Get(canary.TargetName, metav1.GetOptions{})
+	if err != nil {
+		log.Logger().Debugf("Failed to get the deployment %s targeted by the Canary %s: %s", canary.TargetName, canary.Name, err)
+		return true
+	}
+	version := kube.GetVersion(&deployment.ObjectMeta)
+	return version == "" || version == o.Version
+}
@@ -0,0 +1,122 @@
+// +build unit
+
+package promote
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+const canaryTestNamespace = "jx-production"
+
+func canaryTestOptions(phase string, deployedVersion string) (*PromoteOptions, versioned.Interface) {
+	canary := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "flagger.app/v1beta1",
+			"kind":       "Canary",
+			"metadata": map[string]interface{}{
+				"name":      "myapp",
+				"namespace": canaryTestNamespace,
+			},
+			"spec": map[string]interface{}{
+				"targetRef": map[string]interface{}{
+					"name": "jx-myapp",
+				},
+			},
+			"status": map[string]interface{}{
+				"phase":              phase,
+				"lastAppliedSpec":    "5d8f9c7b6",
+				"lastTransitionTime": "2020-03-01T10:00:00Z",
+			},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jx-myapp",
+			Namespace: canaryTestNamespace,
+			Labels:    map[string]string{"version": deployedVersion},
+		},
+	}
+	jxClient := jxfake.NewSimpleClientset()
+	factory := fake.NewFakeFactoryFromClients(nil, jxClient, kubefake.NewSimpleClientset(deployment), nil,
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), canary))
+	commonOpts := opts.NewCommonOptionsWithFactory(factory)
+	pollDuration := time.Millisecond
+	restartTimeout := 50 * time.Millisecond
+	o := &PromoteOptions{
+		CommonOptions:                &commonOpts,
+		Namespace:                    "jx",
+		Application:                  "myapp",
+		Version:                      "1.0.0",
+		PullRequestPollDuration:      &pollDuration,
+		CanaryRestartTimeoutDuration: &restartTimeout,
+	}
+	return o, jxClient
+}
+
+func canaryTestPromoteKey() *kube.PromoteStepActivityKey {
+	return &kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     "myorg-myapp-master-1",
+			Pipeline: "myorg/myapp/master",
+			Build:    "1",
+		},
+		Environment: "production",
+	}
+}
+
+func TestWaitForCanaryAlreadyAnalysedVersion(t *testing.T) {
+	t.Parallel()
+
+	o, _ := canaryTestOptions("Succeeded", "1.0.0")
+	now := time.Now()
+	err := o.waitForCanary(canaryTestNamespace, canaryTestPromoteKey(), now, now.Add(time.Minute))
+	assert.NoError(t, err, "re-promoting an already analysed version should succeed")
+}
+
+func TestWaitForCanaryNotRestarted(t *testing.T) {
+	t.Parallel()
+
+	o, jxClient := canaryTestOptions("Failed", "1.0.0")
+	promoteKey := canaryTestPromoteKey()
+	now := time.Now()
+	err := o.waitForCanary(canaryTestNamespace, promoteKey, now, now.Add(time.Minute))
+	require.Error(t, err, "the failed analysis of a previous version should not complete the promotion")
+
+	activity, err := jxClient.JenkinsV1().PipelineActivities("jx").Get(promoteKey.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	var update *v1.PromoteUpdateStep
+	for _, step := range activity.Spec.Steps {
+		if step.Promote != nil && step.Promote.Update != nil {
+			update = step.Promote.Update
+		}
+	}
+	require.NotNil(t, update, "the promote update step should be recorded")
+	assert.Equal(t, v1.ActivityStatusTypeFailed, update.Status)
+}
+
+func TestWaitForCanaryRestartDeadlineStartsOnDeployment(t *testing.T) {
+	t.Parallel()
+
+	o, _ := canaryTestOptions("Succeeded", "0.9.0")
+	now := time.Now()
+	err := o.waitForCanary(canaryTestNamespace, canaryTestPromoteKey(), now, now.Add(200*time.Millisecond))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Timed out waiting for the Canary myapp analysis",
+		"the previous version should not complete the promotion before the new version is deployed")
+}
@@ -0,0 +1,328 @@
+package promote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/builds"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/dependencymatrix"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+const (
+	optionTrainMatrix = "train-matrix"
+
+	// ReleaseTrainBranch is the branch name used in the pipeline name of release train promotions
+	ReleaseTrainBranch = "release-train"
+)
+
+// ParseTrainApplications parses the applications of a release train from values of the form 'app@version'.
+// Each value may contain a comma separated list of applications. If no version is specified the latest version
+// of the application is promoted
+func ParseTrainApplications(values []string) ([]v1.PromoteApplication, error) {
+	answer := []v1.PromoteApplication{}
+	names := map[string]bool{}
+	for _, value := range values {
+		for _, text := range strings.Split(value, ",") {
+			text = strings.TrimSpace(text)
+			if text == "" {
+				continue
+			}
+			app := v1.PromoteApplication{
+				Name: text,
+			}
+			idx := strings.LastIndex(text, "@")
+			if idx >= 0 {
+				app.Name = strings.TrimSpace(text[0:idx])
+				app.Version = strings.TrimSpace(text[idx+1:])
+			}
+			if app.Name == "" {
+				return answer, fmt.Errorf("missing application name in release train value '%s'", text)
+			}
+			if names[app.Name] {
+				return answer, fmt.Errorf("application %s is included more than once in the release train", app.Name)
+			}
+			names[app.Name] = true
+			answer = append(answer, app)
+		}
+	}
+	return answer, nil
+}
+
+// TrainApplicationsFromMatrix returns the applications of a release train from a dependency matrix snapshot
+func TrainApplicationsFromMatrix(matrix *dependencymatrix.DependencyMatrix) []v1.PromoteApplication {
+	answer := []v1.PromoteApplication{}
+	if matrix == nil {
+		return answer
+	}
+	names := map[string]bool{}
+	for _, d := range matrix.Dependencies {
+		if d == nil {
+			continue
+		}
+		name := d.Component
+		if name == "" {
+			name = d.Repo
+		}
+		if name == "" || names[name] {
+			continue
+		}
+		names[name] = true
+		answer = append(answer, v1.PromoteApplication{
+			Name:    name,
+			Version: d.Version,
+		})
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Name < answer[j].Name
+	})
+	return answer
+}
+
+// LoadTrainApplications loads the release train applications from the arguments and the optional dependency matrix
+func (o *PromoteOptions) LoadTrainApplications() error {
+	apps := []v1.PromoteApplication{}
+	if o.TrainMatrix != "" {
+		matrix, err := loadDependencyMatrix(o.TrainMatrix)
+		if err != nil {
+			return err
+		}
+		apps = TrainApplicationsFromMatrix(matrix)
+	}
+	argApps, err := ParseTrainApplications(o.Args)
+	if err != nil {
+		return err
+	}
+	// applications in the arguments override the versions in the dependency matrix
+	for _, a := range argApps {
+		found := false
+		for i := range apps {
+			if apps[i].Name == a.Name {
+				apps[i].Version = a.Version
+				found = true
+			}
+		}
+		if !found {
+			apps = append(apps, a)
+		}
+	}
+	if len(apps) == 0 {
+		return fmt.Errorf("no applications specified for the release train. Please specify arguments of the form 'app@version' or the --%s option", optionTrainMatrix)
+	}
+	o.TrainApplications = apps
+	return nil
+}
+
+// loadDependencyMatrix loads a dependency matrix snapshot from a YAML file or a project directory
+func loadDependencyMatrix(path string) (*dependencymatrix.DependencyMatrix, error) {
+	isDir, err := util.DirExists(path)
+	if err != nil {
+		return nil, err
+	}
+	if isDir {
+		return dependencymatrix.LoadDependencyMatrix(path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading dependency matrix %s", path)
+	}
+	matrix := &dependencymatrix.DependencyMatrix{}
+	err = yaml.Unmarshal(data, matrix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshaling dependency matrix %s", path)
+	}
+	return matrix, nil
+}
+
+// PromoteTrain promotes all the applications of the release train to the environment in a single Pull Request
+// which is tracked by a single PipelineActivity
+func (o *PromoteOptions) PromoteTrain(targetNS string, env *v1.Environment) error {
+	if env == nil {
+		return util.MissingOption(opts.OptionEnvironment)
+	}
+	gitURL := env.Spec.Source.URL
+	if gitURL == "" || !env.Spec.Kind.IsPermanent() {
+		return fmt.Errorf("cannot promote a release train to environment %s as it does not use GitOps", env.Name)
+	}
+	if o.AllAutomatic {
+		return fmt.Errorf("the --all-auto option cannot be used with --train")
+	}
+	err := o.defaultTrainPipeline(env)
+	if err != nil {
+		return err
+	}
+	info := util.ColorInfo
+	log.Logger().Infof("Promoting release train %s to namespace %s", info(TrainSummary(o.TrainApplications)), info(targetNS))
+
+	releaseInfo := &ReleaseInfo{
+		ReleaseName: ReleaseTrainBranch,
+		FullAppName: strings.Join(trainApplicationNames(o.TrainApplications), ","),
+	}
+	err = o.PromoteViaPullRequest(env, releaseInfo)
+	if err != nil {
+		return err
+	}
+
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	promoteKey := o.CreatePromoteKey(env)
+	startPromotePR := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
+		err := kube.StartPromotionPullRequest(a, s, ps, p)
+		if err != nil {
+			return err
+		}
+		pr := releaseInfo.PullRequestInfo
+		if pr != nil && pr.PullRequest != nil && p.PullRequestURL == "" {
+			p.PullRequestURL = pr.PullRequest.URL
+		}
+		ps.Applications = o.TrainApplications
+		return nil
+	}
+	err = promoteKey.OnPromotePullRequest(kubeClient, jxClient, o.Namespace, startPromotePR)
+	if err != nil {
+		log.Logger().Warnf("Failed to update PipelineActivity: %s", err)
+	}
+	// lets sleep a little before we try poll for the PR status
+	time.Sleep(waitAfterPullRequestCreated)
+
+	o.ReleaseInfo = releaseInfo
+	if !o.NoPoll {
+		return o.WaitForPromotion(targetNS, env, releaseInfo)
+	}
+	return nil
+}
+
+// defaultTrainPipeline defaults the pipeline and build used to track the release train so that a single
+// PipelineActivity records the promotion of all the applications
+func (o *PromoteOptions) defaultTrainPipeline(env *v1.Environment) error {
+	// the release train is not associated with the source code in the current directory
+	o.IgnoreLocalFiles = true
+	if o.Pipeline == "" {
+		gitInfo, err := gits.ParseGitURL(env.Spec.Source.URL)
+		if err != nil {
+			return errors.Wrapf(err, "parsing git URL %s", env.Spec.Source.URL)
+		}
+		o.Pipeline = kube.NewPipelineID(gitInfo.Organisation, gitInfo.Name, ReleaseTrainBranch).ID
+	}
+	if o.Build == "" {
+		o.Build = builds.GetBuildNumber()
+	}
+	if o.Build == "" {
+		activities, err := o.Activities.List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrap(err, "listing PipelineActivities")
+		}
+		pipelines := []*v1.PipelineActivity{}
+		for i := range activities.Items {
+			pipelines = append(pipelines, &activities.Items[i])
+		}
+		o.Build, _, err = kube.GenerateBuildNumber(o.Activities, pipelines, kube.NewPipelineIDFromString(o.Pipeline))
+		if err != nil {
+			return errors.Wrapf(err, "generating build number for pipeline %s", o.Pipeline)
+		}
+	}
+	return nil
+}
+
+// TrainModifyChartFn returns the function which sets the versions of the applications of the release train in the
+// environment chart, resolving the latest versions, and describes the Pull Request with the resolved versions
+func (o *PromoteOptions) TrainModifyChartFn(env *v1.Environment) environments.ModifyChartFn {
+	return func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+		templates map[string]string, dir string, details *gits.PullRequestDetails) error {
+		for i := range o.TrainApplications {
+			app := &o.TrainApplications[i]
+			if app.Version == "" {
+				version, err := o.findLatestVersion(app.Name)
+				if err != nil {
+					return err
+				}
+				app.Version = version
+			}
+			requirements.SetAppVersion(app.Name, app.Version, o.HelmRepositoryURL, "")
+		}
+		// the latest versions are only known now so describe the Pull Request with the resolved versions
+		details.Title, details.Message = trainPullRequestText(env, o.TrainApplications)
+		return nil
+	}
+}
+
+// promoteTrainViaPullRequest creates or updates the Pull Request which updates all the applications of the release train
+func (o *PromoteOptions) promoteTrainViaPullRequest(env *v1.Environment, releaseInfo *ReleaseInfo) error {
+	details := gits.PullRequestDetails{
+		BranchName: "promote-train-" + o.Build,
+	}
+	details.Title, details.Message = trainPullRequestText(env, o.TrainApplications)
+	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(env.Spec.Source.URL)
+	if err != nil {
+		return errors.Wrapf(err, "creating git provider for %s", env.Spec.Source.URL)
+	}
+
+	options := environments.EnvironmentPullRequestOptions{
+		Gitter:        o.Git(),
+		ModifyChartFn: o.TrainModifyChartFn(env),
+		GitProvider:   gitProvider,
+	}
+	filter := &gits.PullRequestFilter{}
+	if releaseInfo.PullRequestInfo != nil && releaseInfo.PullRequestInfo.PullRequest != nil {
+		filter.Number = releaseInfo.PullRequestInfo.PullRequest.Number
+	}
+	info, err := options.Create(env, o.CloneDir, &details, filter, "", true)
+	releaseInfo.PullRequestInfo = info
+	return err
+}
+
+// trainPullRequestText returns the title and message of the Pull Request which promotes the release train
+func trainPullRequestText(env *v1.Environment, apps []v1.PromoteApplication) (string, string) {
+	lines := []string{}
+	for _, app := range apps {
+		version := app.Version
+		if version == "" {
+			version = "latest"
+		}
+		lines = append(lines, fmt.Sprintf("* %s to version %s", app.Name, version))
+	}
+	title := "chore: release train " + TrainSummary(apps)
+	message := fmt.Sprintf("chore: Promote release train to %s\n\n%s", env.Name, strings.Join(lines, "\n"))
+	return title, message
+}
+
+// TrainSummary returns a short description of the applications in a release train
+func TrainSummary(apps []v1.PromoteApplication) string {
+	texts := []string{}
+	for _, app := range apps {
+		if app.Version == "" {
+			texts = append(texts, app.Name)
+		} else {
+			texts = append(texts, app.Name+"@"+app.Version)
+		}
+	}
+	return strings.Join(texts, ", ")
+}
+
+func trainApplicationNames(apps []v1.PromoteApplication) []string {
+	answer := []string{}
+	for _, app := range apps {
+		answer = append(answer, app.Name)
+	}
+	return answer
+}
@@ -0,0 +1,82 @@
+// +build unit
+
+package promote_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	helm_test "github.com/jenkins-x/jx/v2/pkg/helm/mocks"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseTrainApplications(t *testing.T) {
+	t.Parallel()
+
+	apps, err := promote.ParseTrainApplications([]string{"myapp@1.2.3", "mylib@2.0.1, other"})
+	require.NoError(t, err)
+	assert.Equal(t, []v1.PromoteApplication{
+		{Name: "myapp", Version: "1.2.3"},
+		{Name: "mylib", Version: "2.0.1"},
+		{Name: "other"},
+	}, apps)
+	assert.Equal(t, "myapp@1.2.3, mylib@2.0.1, other", promote.TrainSummary(apps))
+
+	_, err = promote.ParseTrainApplications([]string{"myapp@1.2.3", "myapp@1.2.4"})
+	assert.Error(t, err, "duplicate applications should fail")
+
+	_, err = promote.ParseTrainApplications([]string{"@1.2.3"})
+	assert.Error(t, err, "missing application name should fail")
+}
+
+func TestLoadTrainApplicationsFromMatrix(t *testing.T) {
+	t.Parallel()
+
+	promoteOptions := &promote.PromoteOptions{
+		CommonOptions: &opts.CommonOptions{},
+		TrainMatrix:   filepath.Join("test_data", "train", "matrix.yaml"),
+	}
+	promoteOptions.Args = []string{"roadrunner@1.2.4", "extra@0.1.0"}
+
+	err := promoteOptions.LoadTrainApplications()
+	require.NoError(t, err)
+	assert.Equal(t, []v1.PromoteApplication{
+		{Name: "cheese", Version: "0.0.2"},
+		{Name: "roadrunner", Version: "1.2.4"},
+		{Name: "extra", Version: "0.1.0"},
+	}, promoteOptions.TrainApplications)
+}
+
+func TestTrainModifyChartFnDescribesResolvedVersions(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+	helmer := helm_test.NewMockHelmer()
+	pegomock.When(helmer.SearchCharts(pegomock.EqString("mylib"), pegomock.EqBool(true))).ThenReturn(
+		[]helm.ChartSummary{{Name: "jenkins-x/mylib", ChartVersion: "2.0.1"}}, nil)
+
+	commonOpts := &opts.CommonOptions{}
+	commonOpts.SetHelm(helmer)
+	promoteOptions := &promote.PromoteOptions{
+		CommonOptions:     commonOpts,
+		TrainApplications: []v1.PromoteApplication{{Name: "myapp", Version: "1.2.3"}, {Name: "mylib"}},
+	}
+	env := &v1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "staging"}}
+
+	requirements := &helm.Requirements{}
+	details := &gits.PullRequestDetails{Title: "chore: release train myapp@1.2.3, mylib"}
+	err := promoteOptions.TrainModifyChartFn(env)(requirements, nil, nil, nil, "", details)
+	require.NoError(t, err)
+
+	assert.Equal(t, "chore: release train myapp@1.2.3, mylib@2.0.1", details.Title)
+	assert.Contains(t, details.Message, "* mylib to version 2.0.1")
+	assert.NotContains(t, details.Message, "latest")
+	require.Len(t, requirements.Dependencies, 2)
+	assert.Equal(t, "2.0.1", requirements.Dependencies[1].Version)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/report"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/restore"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/scheduler"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step/secrets"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/syntax"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/update"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/verify"
@@ -71,6 +72,7 @@ func NewCmdStep(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(post.NewCmdStepPost(commonOpts))
 	cmd.AddCommand(step.NewCmdStepRelease(commonOpts))
 	cmd.AddCommand(step.NewCmdStepReplicate(commonOpts))
+	cmd.AddCommand(secrets.NewCmdStepSecrets(commonOpts))
 	cmd.AddCommand(step.NewCmdStepSplitMonorepo(commonOpts))
 	cmd.AddCommand(syntax.NewCmdStepSyntax(commonOpts))
 	cmd.AddCommand(step.NewCmdStepTag(commonOpts))
@@ -365,8 +365,12 @@ func (o *StepCreateTaskOptions) Run() error {
 		if o.DisableConcurrent {
 			o.waitForPreviousPipeline(tektonClient, ns, 10*time.Minute)
 		}
+		err := tekton.PrepareDynamicSecrets(tektonCRDs)
+		if err != nil {
+			return errors.Wrapf(err, "failed to prepare dynamic secrets")
+		}
 		log.Logger().Infof("Applying changes ")
-		err := tekton.ApplyPipeline(jxClient, kubeClient, tektonClient, tektonCRDs, ns, activityKey)
+		err = tekton.ApplyPipeline(jxClient, kubeClient, tektonClient, tektonCRDs, ns, activityKey)
 		if err != nil {
 			return errors.Wrapf(err, "failed to apply Tekton CRDs")
 		}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/jenkins-x/jx/v2/pkg/kube/cluster"
 	v1 "k8s.io/api/core/v1"
@@ -110,7 +111,7 @@ func NewCmdStepCreateValues(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Name, "name", "", "values", "the kind of the file to create (and, by default, the schema name)")
 	cmd.Flags().StringVarP(&options.BasePath, "secret-base-path", "", "", fmt.Sprintf("the secret path used to store secrets in vault / file system. Typically a unique name per cluster+team. If none is specified we will default it to the cluster name from the %s file in the current or a parent directory.", config.RequirementsConfigFileName))
 	cmd.Flags().StringVarP(&options.ValuesFile, "out", "", "", "the path to the file to create, overrides --dir and --name")
-	cmd.Flags().StringVarP(&options.SecretsScheme, optionSecretsScheme, "", "", fmt.Sprintf("the scheme to store/reference any secrets in, valid options are vault and local. If none are specified we will default it from the %s file in the current or a parent directory.", config.RequirementsConfigFileName))
+	cmd.Flags().StringVarP(&options.SecretsScheme, optionSecretsScheme, "", "", fmt.Sprintf("the scheme to store/reference any secrets in, valid options are %s. If none are specified we will default it from the %s file in the current or a parent directory.", strings.Join(secrets.SecretsLocationValues, ", "), config.RequirementsConfigFileName))
 	return cmd
 }
 
@@ -155,11 +156,8 @@ func (o *StepCreateValuesOptions) Run() error {
 		}
 
 	}
-	if !(o.SecretsScheme == "vault" || o.SecretsScheme == "local") {
-		err = util.InvalidArgf(optionSecretsScheme, "Use one of vault or local")
-		if err != nil {
-			return err
-		}
+	if util.StringArrayIndex(secrets.SecretsLocationValues, o.SecretsScheme) < 0 {
+		return util.InvalidArgf(optionSecretsScheme, "Use one of %s", strings.Join(secrets.SecretsLocationValues, ", "))
 	}
 	if o.Schema == "" {
 		o.Schema = filepath.Join(o.Dir, fmt.Sprintf("%s.schema.json", o.Name))
@@ -174,7 +172,9 @@ func (o *StepCreateValuesOptions) Run() error {
 		o.ValuesFile = filepath.Join(o.Dir, fmt.Sprintf("%s.yaml", o.Name))
 	}
 
-	secretURLClient, err := o.GetSecretURLClient(secrets.ToSecretsLocation(o.SecretsScheme))
+	secretsRequirements := *requirements
+	secretsRequirements.SecretStorage = config.SecretStorageType(o.SecretsScheme)
+	secretURLClient, err := o.GetSecretURLClientForRequirements(&secretsRequirements)
 	if err != nil {
 		return err
 	}
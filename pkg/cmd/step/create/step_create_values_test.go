@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,6 +40,9 @@ import (
 	vault_test "github.com/jenkins-x/jx/v2/pkg/vault/mocks"
 
 	"github.com/jenkins-x/jx/v2/pkg/io/secrets"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/fakevault"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/kubevault"
 	"github.com/petergtz/pegomock"
 )
 
@@ -282,3 +286,107 @@ func TestCreateValuesFileWithLocalSecrets(t *testing.T) {
 		assert.Equal(r, "hmacToken: abc\n", string(secret.Data["prow.yaml"]))
 	})
 }
+
+func TestCreateValuesFileWithSecretSchemes(t *testing.T) {
+	tests.SkipForWindows(t, "go-expect does not work on windows")
+
+	for _, scheme := range []string{"kube", "awssm", "gcpsm", "azurekv"} {
+		t.Run(scheme, func(t *testing.T) {
+			sourceData := filepath.Join("test_data", "step_create_values", "install")
+			testData, err := ioutil.TempDir("", "test-jx-step-create-values-")
+			assert.NoError(t, err)
+			defer os.RemoveAll(testData)
+			err = util.CopyDir(sourceData, testData, true)
+			assert.NoError(t, err)
+
+			pegomock.RegisterMockTestingT(t)
+			mockFactory := clients_test.NewMockFactory()
+			commonOpts := opts.NewCommonOptionsWithFactory(mockFactory)
+			devEnv := kube.NewPermanentEnvironmentWithGit("dev", "https://fake.git/myorg/environment-myorg-dev.git")
+			pegomock.When(mockFactory.SecretsLocation()).ThenReturn(pegomock.ReturnValue(secrets.ToSecretsLocation(scheme)))
+			testhelpers.ConfigureTestOptionsWithResources(&commonOpts,
+				[]runtime.Object{},
+				[]runtime.Object{
+					devEnv,
+				},
+				gits.NewGitLocal(),
+				nil,
+				helm_test.NewMockHelmer(),
+				resources_test.NewMockInstaller(),
+			)
+			testhelpers.MockFactoryWithKubeClients(mockFactory, &commonOpts)
+			kubeClient, ns, err := mockFactory.CreateKubeClient()
+			assert.NoError(t, err)
+
+			// the cloud secret managers cannot be reached from the tests so use a fake secret store
+			var secretClient secreturl.Client = kubevault.NewClient(kubeClient, ns)
+			if scheme != "kube" {
+				secretClient = fakevault.NewFakeClient()
+				commonOpts.SetSecretURLClient(secretClient)
+			}
+
+			console := tests.NewTerminal(t, &timeout)
+			defer console.Cleanup()
+			commonOpts.In = console.In
+			commonOpts.Out = console.Out
+			commonOpts.Err = console.Err
+			commonOpts.BatchMode = false
+
+			outFile, err := ioutil.TempFile("", "")
+			assert.NoError(t, err)
+			defer os.Remove(outFile.Name())
+
+			o := StepCreateValuesOptions{
+				StepCreateOptions: step.StepCreateOptions{
+					StepOptions: step.StepOptions{
+						CommonOptions: &commonOpts,
+					},
+				},
+				Dir:           testData,
+				Name:          "values",
+				SecretsScheme: scheme,
+				ValuesFile:    outFile.Name(),
+			}
+
+			donec := make(chan struct{})
+			go func() {
+				defer close(donec)
+				console.ExpectString("Jenkins X Admin Username")
+				console.SendLine("admin")
+				console.ExpectString("Jenkins X Admin Password")
+				console.SendLine("abc")
+				console.ExpectString("HMAC token")
+				console.SendLine("abc")
+				console.ExpectString("Pipeline bot Git username")
+				console.SendLine("james")
+				console.ExpectString("Pipeline bot Git token")
+				console.SendLine("123456789")
+				console.ExpectString("Do you want to configure a Docker Registry?")
+				console.SendLine("y")
+				console.ExpectString("Docker Registry URL")
+				console.SendLine("")
+				console.ExpectString("Docker Registry username")
+				console.SendLine("james")
+				console.ExpectString("Docker Registry password")
+				console.SendLine("abc")
+				console.ExpectString("Do you want to configure a GPG Key?")
+				console.SendLine("n")
+				console.ExpectEOF()
+			}()
+			err = o.Run()
+			assert.NoError(t, err)
+			console.Close()
+			<-donec
+
+			golden, err := ioutil.ReadFile(filepath.Join(testData, "values.yaml.golden"))
+			assert.NoError(t, err)
+			actual, err := ioutil.ReadFile(outFile.Name())
+			assert.NoError(t, err)
+			assert.Equal(t, strings.Replace(string(golden), "vault:", scheme+":", -1), string(actual))
+
+			secret, err := secretClient.Read("foo/adminUser")
+			assert.NoError(t, err)
+			assert.Equal(t, "abc", secret["password"])
+		})
+	}
+}
@@ -25,7 +25,11 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/platform"
+	"github.com/jenkins-x/jx/v2/pkg/sealedsecrets"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
 	"github.com/jenkins-x/jx/v2/pkg/secreturl/fakevault"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/sopsvault"
+	"github.com/jenkins-x/jx/v2/pkg/sops"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/jenkins-x/jx/v2/pkg/vault"
 )
@@ -44,6 +48,12 @@ type StepHelmApplyOptions struct {
 	NoVault            bool
 	NoMasking          bool
 	ProviderValuesDir  string
+
+	// AllowPlaintextSecrets applies Secrets with literal values when the secrets are stored in Kubernetes
+	AllowPlaintextSecrets bool
+
+	// Encrypter decrypts any SOPS encrypted values files and secrets, it defaults to the sops binary
+	Encrypter sops.Encrypter
 }
 
 var (
@@ -52,8 +62,15 @@ var (
 
 		This step is usually used to apply any GitOps promotion changes into a Staging or Production cluster.
 
+		Any values files encrypted with SOPS are decrypted on the fly and 'sops:name:key' URIs are resolved from the SOPS
+		encrypted secrets in the 'secrets' folder of the chart.
+
+		When the secrets are stored in Kubernetes any Secret manifests with literal values are skipped as they should be
+		sealed with 'jx step secrets seal' before being committed to the environment git repository.
+
         Environment Variables:
-		- JX_NO_DELETE_TMP_DIR="true" - prevents the removal of the temporary directory.
+		- JX_NO_DELETE_TMP_DIR="true" - prevents the removal of the temporary directory. Any values files decrypted
+		  with SOPS are still removed so that no plain text secrets are left behind.
 `)
 
 	StepHelmApplyExample = templates.Examples(`
@@ -98,6 +115,7 @@ func NewCmdStepHelmApply(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.NoVault, "no-vault", "", false, "Disables loading secrets from Vault. e.g. if bootstrapping core services like Ingress before we have a Vault")
 	cmd.Flags().BoolVarP(&options.NoMasking, "no-masking", "", false, "The effective 'values.yaml' file is output to the console with parameters masked. Enabling this flag will show the unmasked secrets in the console output")
 	cmd.Flags().StringVarP(&options.ProviderValuesDir, "provider-values-dir", "", "", "The optional directory of kubernetes provider specific override values.tmpl.yaml files a kubernetes provider specific folder")
+	cmd.Flags().BoolVarP(&options.AllowPlaintextSecrets, "allow-plaintext-secrets", "", false, "Applies Secret manifests with literal values rather than skipping them when the secrets are stored in Kubernetes")
 
 	return cmd
 }
@@ -227,6 +245,28 @@ func (o *StepHelmApplyOptions) Run() error {
 		}
 	}
 
+	// the chart has been copied to a temporary directory so lets decrypt any SOPS encrypted values files in place
+	decryptedFiles, err := sops.DecryptFilesInPlace(o.encrypter(), valueFiles)
+	defer func() {
+		err := sops.RemoveFiles(decryptedFiles)
+		if err != nil {
+			log.Logger().Warnf("failed to remove the decrypted SOPS values files: %s", err.Error())
+		}
+	}()
+	if err != nil {
+		return errors.Wrap(err, "decrypting the SOPS encrypted values files")
+	}
+	for _, f := range decryptedFiles {
+		log.Logger().Debugf("decrypted SOPS values file %s", f)
+	}
+
+	if o.GetSecretsLocation() == secrets.KubeLocationKind && !o.AllowPlaintextSecrets {
+		err = o.skipPlaintextSecrets(dir)
+		if err != nil {
+			return err
+		}
+	}
+
 	vaultSecretLocation := o.GetSecretsLocation() == secrets.VaultLocationKind
 	if vaultSecretLocation && o.NoVault {
 		// lets install a fake secret URL client to avoid spurious vault errors
@@ -260,10 +300,14 @@ func (o *StepHelmApplyOptions) Run() error {
 		return errors.Wrap(err, "loading the requirements")
 	}
 
-	secretURLClient, err := o.GetSecretURLClient(secrets.ToSecretsLocation(string(requirements.SecretStorage)))
+	secretURLClient, err := o.GetSecretURLClientForRequirements(requirements)
 	if err != nil {
 		return errors.Wrap(err, "failed to create a Secret RL client")
 	}
+	secretURLClient, err = o.addSopsSecretURLClient(dir, secretURLClient)
+	if err != nil {
+		return err
+	}
 
 	DefaultEnvironments(requirements, devGitInfo)
 
@@ -399,6 +443,41 @@ func (o *StepHelmApplyOptions) Run() error {
 	return nil
 }
 
+// encrypter returns the Encrypter used to decrypt the SOPS encrypted values files and secrets
+func (o *StepHelmApplyOptions) encrypter() sops.Encrypter {
+	if o.Encrypter == nil {
+		o.Encrypter = sops.NewCLI(sops.Keys{})
+	}
+	return o.Encrypter
+}
+
+// skipPlaintextSecrets removes any Secrets with literal values from the copy of the chart so they are not applied
+func (o *StepHelmApplyOptions) skipPlaintextSecrets(dir string) error {
+	skipped, err := sealedsecrets.RemovePlaintextSecrets(dir)
+	if err != nil {
+		return errors.Wrapf(err, "removing the plain text Secrets from %s", dir)
+	}
+	for _, s := range skipped {
+		log.Logger().Warnf("skipping plain text Secret %s. Please seal it with 'jx step secrets seal'", util.ColorWarning(s.Name))
+	}
+	return nil
+}
+
+// addSopsSecretURLClient wraps the secret URL client so that sops: URIs are resolved from the SOPS encrypted
+// secrets in the chart directory if there are any
+func (o *StepHelmApplyOptions) addSopsSecretURLClient(dir string, secretURLClient secreturl.Client) (secreturl.Client, error) {
+	secretsDir := filepath.Join(dir, sops.SecretsDirName)
+	exists, err := util.DirExists(secretsDir)
+	if err != nil {
+		return secretURLClient, errors.Wrapf(err, "checking if directory %s exists", secretsDir)
+	}
+	if !exists {
+		return secretURLClient, nil
+	}
+	log.Logger().Debugf("resolving sops: URIs from the SOPS encrypted secrets in %s", secretsDir)
+	return sopsvault.NewClient(secretsDir, o.encrypter(), secretURLClient), nil
+}
+
 // getRequirements tries to load the requirements either from the team settings or local requirements file
 func (o *StepHelmApplyOptions) getRequirements() (*config.RequirementsConfig, string, error) {
 	// Try to load first the requirements from current directory
@@ -9,7 +9,6 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/helm"
-	"github.com/jenkins-x/jx/v2/pkg/io/secrets"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/pkg/errors"
 
@@ -96,7 +95,7 @@ func (o *StepHelmBuildOptions) Run() error {
 			return err
 		}
 
-		secretURLClient, err := o.GetSecretURLClient(secrets.ToSecretsLocation(string(requirements.SecretStorage)))
+		secretURLClient, err := o.GetSecretURLClientForRequirements(requirements)
 		if err != nil {
 			return errors.Wrap(err, "creating a Secret URL client")
 		}
@@ -13,13 +13,20 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/reportingtools"
+	"github.com/jenkins-x/jx/v2/pkg/testresults"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 var (
-	stepReportJUnitLong    = templates.LongDesc(`This step is used to generate an HTML report from *.junit.xml files created from running BDD tests.`)
+	stepReportJUnitLong = templates.LongDesc(`
+		This step is used to generate an HTML report from *.junit.xml files created from running BDD tests.
+
+		When running in a pipeline the result of each test is also stored in the team's reports storage, as by
+		'jx step report tests', so that 'jx get tests flaky' can find the flaky tests. Use --store-results=false to
+		disable it.
+`)
 	stepReportJUnitExample = templates.Examples(`
 	# Collect every *.junit.xml file from --in-dir, merge them, and store them in --out-dir with a file name --output-name and provide an HTML report title
 	jx step report --in-dir /randomdir --out-dir /outdir --merge --output-name resulting_report.html --suite-name This_is_the_report_title
@@ -29,6 +36,12 @@ var (
 
 	# Select a single *.junit.xml file and create a report form it
 	jx step report --in-dir /randomdir --out-dir /outdir --target-report test.junit.xml --output-name resulting_report.html
+
+	# Create the report without storing the result of each test for 'jx get tests flaky'
+	jx step report --out-dir /outdir --merge --output-name resulting_report.html --store-results=false
+
+	# Create the report of a Pull Request and comment on it with the failed tests which are known to be flaky
+	jx step report --out-dir /outdir --merge --output-name resulting_report.html --comment-flaky
 `)
 )
 
@@ -41,6 +54,9 @@ type StepReportJUnitOptions struct {
 	TargetReport     string
 	SuiteName        string
 	OutputReportName string
+	StoreResults     bool
+	CommentFlaky     bool
+	FlakyBuilds      int
 	DeleteReportFn   func(reportName string) error
 }
 
@@ -71,6 +87,8 @@ type TestCase struct {
 	Classname string   `xml:"classname,attr"`
 	Time      string   `xml:"time,attr"`
 	Failure   *Failure `xml:"failure,omitempty"`
+	Error     *Failure `xml:"error,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
 	SystemOut string   `xml:"system-out"`
 }
 
@@ -80,6 +98,12 @@ type Failure struct {
 	Type string `xml:"type,attr"`
 }
 
+// Skipped is the representation of a test case which was skipped in a *.junit.xml xml file
+type Skipped struct {
+	Text    string `xml:",chardata"`
+	Message string `xml:"message,attr,omitempty"`
+}
+
 // NewCmdStepReportJUnit Creates a new Command object
 func NewCmdStepReportJUnit(commonOpts *opts.CommonOptions) *cobra.Command {
 	options := &StepReportJUnitOptions{
@@ -110,6 +134,9 @@ func NewCmdStepReportJUnit(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.TargetReport, "target-report", "t", "", "The name of a single report file to parse")
 	cmd.Flags().StringVarP(&options.SuiteName, "suite-name", "s", "", "The name of the tests suite to be shown in the HTML report")
 	cmd.Flags().BoolVarP(&options.MergeReports, "merge", "m", false, "Whether or not to merge the report files in the \"in-folder\" to parse them and show it as a single test run")
+	cmd.Flags().BoolVarP(&options.StoreResults, "store-results", "", true, "Whether or not to store the result of each test in the team's reports storage when running in a pipeline so 'jx get tests flaky' can find the flaky tests")
+	cmd.Flags().BoolVarP(&options.CommentFlaky, "comment-flaky", "", false, "Whether or not to comment on the Pull Request with the failed tests which are known to be flaky")
+	cmd.Flags().IntVarP(&options.FlakyBuilds, "flaky-builds", "", testresults.DefaultHistoryBuilds, "The number of builds of the base branch used to find the flaky tests when commenting on a Pull Request")
 
 	return cmd
 }
@@ -168,6 +195,17 @@ func (o *StepReportJUnitOptions) Run() error {
 	if err != nil {
 		return logErrorAndExitGracefully("error creating the HTML report", err)
 	}
+
+	if o.StoreResults || o.CommentFlaky {
+		suites, err := parseJUnitReportFile(targetFileName)
+		if err != nil {
+			return logErrorAndExitGracefully("there was a problem parsing the test results", err)
+		}
+		err = o.processTestResults(testResultsOf(suites))
+		if err != nil {
+			return logErrorAndExitGracefully("there was a problem processing the test results", err)
+		}
+	}
 	return nil
 }
 
@@ -210,27 +248,11 @@ func (o *StepReportJUnitOptions) mergeJUnitReportFiles(jUnitReportFiles []string
 
 	aggregatedTestSuites := TestSuites{}
 	for _, v := range jUnitReportFiles {
-		bytes, err := ioutil.ReadFile(v)
+		testSuites, err := parseJUnitReportFile(v)
 		if err != nil {
 			return err
 		}
-
-		// trying to parse <testsuites></testsuites>
-		var testSuites TestSuites
-		err = xml.Unmarshal(bytes, &testSuites)
-		if err != nil {
-			// If no <testsuites></testsuites>, trying to parse <testsuite></testsuite>
-			var testSuite TestSuite
-			err = xml.Unmarshal(bytes, &testSuite)
-			if err != nil {
-				return err
-			}
-			aggregatedTestSuites.TestSuites = append(aggregatedTestSuites.TestSuites, testSuite)
-		} else {
-			for _, testSuite := range testSuites.TestSuites {
-				aggregatedTestSuites.TestSuites = append(aggregatedTestSuites.TestSuites, testSuite)
-			}
-		}
+		aggregatedTestSuites.TestSuites = append(aggregatedTestSuites.TestSuites, testSuites...)
 	}
 
 	suitesBytes, err := xml.Marshal(aggregatedTestSuites)
@@ -246,6 +268,28 @@ func (o *StepReportJUnitOptions) mergeJUnitReportFiles(jUnitReportFiles []string
 	return nil
 }
 
+// parseJUnitReportFile parses the test suites of a *.junit.xml file whose root is either <testsuites> or <testsuite>
+func parseJUnitReportFile(fileName string) ([]TestSuite, error) {
+	bytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	// trying to parse <testsuites></testsuites>
+	var testSuites TestSuites
+	err = xml.Unmarshal(bytes, &testSuites)
+	if err != nil {
+		// If no <testsuites></testsuites>, trying to parse <testsuite></testsuite>
+		var testSuite TestSuite
+		err = xml.Unmarshal(bytes, &testSuite)
+		if err != nil {
+			return nil, err
+		}
+		return []TestSuite{testSuite}, nil
+	}
+	return testSuites.TestSuites, nil
+}
+
 func logErrorAndExitGracefully(message string, err error) error {
 	log.Logger().Errorf("%s: %+v", message, err.Error())
 	return nil
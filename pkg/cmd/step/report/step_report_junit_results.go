@@ -0,0 +1,298 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/builds"
+	"github.com/jenkins-x/jx/v2/pkg/cloud/buckets"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step"
+	"github.com/jenkins-x/jx/v2/pkg/collector"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/testresults"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// testResultsOf returns the result of each test case of the suites
+func testResultsOf(suites []TestSuite) []testresults.TestResult {
+	answer := []testresults.TestResult{}
+	for _, suite := range suites {
+		for _, tc := range suite.TestCase {
+			outcome := testresults.OutcomePassed
+			switch {
+			case tc.Error != nil:
+				outcome = testresults.OutcomeError
+			case tc.Failure != nil:
+				outcome = testresults.OutcomeFailed
+			case tc.Skipped != nil:
+				outcome = testresults.OutcomeSkipped
+			}
+			answer = append(answer, testresults.TestResult{
+				Suite:     suite.Name,
+				Classname: tc.Classname,
+				Name:      tc.Name,
+				Duration:  parseJUnitTime(tc.Time),
+				Outcome:   outcome,
+			})
+		}
+	}
+	return answer
+}
+
+// parseJUnitTime parses the duration in seconds of a test case, returning zero if it is missing or invalid
+func parseJUnitTime(text string) float64 {
+	value, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(text), ",", "", -1), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// processTestResults stores the test results of the build and comments on its Pull Request with the known flaky
+// failures. The results are only processed when running in a pipeline
+func (o *StepReportJUnitOptions) processTestResults(tests []testresults.TestResult) error {
+	results := pipelineTestResults(tests)
+	if !isPipelineBuild(results) {
+		log.Logger().Infof("not running in a pipeline so the test results are not stored")
+		return nil
+	}
+	if o.StoreResults {
+		err := storeTestResults(o.CommonOptions, results)
+		if err != nil {
+			return err
+		}
+	}
+	if o.CommentFlaky {
+		return o.commentFlakyFailures(results)
+	}
+	return nil
+}
+
+// commentFlakyFailures comments on the Pull Request of the build with the failed tests which are flaky on the base
+// branch of the Pull Request
+func (o *StepReportJUnitOptions) commentFlakyFailures(results *testresults.BuildTestResults) error {
+	number, err := pullRequestNumber()
+	if err != nil || number == 0 || len(results.Failures()) == 0 {
+		return err
+	}
+	history, err := loadBaseBranchHistory(o.CommonOptions, results, "", o.FlakyBuilds, nil)
+	if err != nil {
+		return err
+	}
+	flaky := testresults.KnownFlakyFailures(results, testresults.FindFlakyTests(history, testresults.DefaultMinFlipRate))
+	if len(flaky) == 0 {
+		log.Logger().Infof("none of the %d failed tests are known to be flaky", len(results.Failures()))
+		return nil
+	}
+	log.Logger().Infof("commenting on Pull Request %s with %d known flaky failures", util.ColorInfo(number), len(flaky))
+	return addPullRequestComment(o.CommonOptions, results, number, testresults.FlakyFailuresComment(flaky, len(history)))
+}
+
+// pipelineTestResults returns the results of the tests of the build of the pipeline the step runs in
+func pipelineTestResults(tests []testresults.TestResult) *testresults.BuildTestResults {
+	return &testresults.BuildTestResults{
+		Version:    testresults.ResultsVersion,
+		Owner:      os.Getenv("REPO_OWNER"),
+		Repository: os.Getenv("REPO_NAME"),
+		Branch:     os.Getenv(util.EnvVarBranchName),
+		Build:      builds.GetBuildNumber(),
+		Timestamp:  time.Now().UTC(),
+		Tests:      tests,
+	}
+}
+
+// isPipelineBuild returns true if the results are of a build of a pipeline
+func isPipelineBuild(results *testresults.BuildTestResults) bool {
+	return results.Owner != "" && results.Repository != "" && results.Build != ""
+}
+
+// pullRequestNumber returns the number of the Pull Request the pipeline builds or zero if it is not building one
+func pullRequestNumber() (int, error) {
+	prNumber := os.Getenv("PULL_NUMBER")
+	if prNumber == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parsing the Pull Request number %s", prNumber)
+	}
+	return number, nil
+}
+
+// storeTestResults stores the test results in the team's reports storage and attaches their URL to the
+// PipelineActivity of the build
+func storeTestResults(o *opts.CommonOptions, results *testresults.BuildTestResults) error {
+	settings, err := o.TeamSettings()
+	if err != nil {
+		return err
+	}
+	location := settings.StorageLocationOrDefault(kube.ClassificationReports)
+	if location.IsEmpty() {
+		log.Logger().Warnf("no storage is configured for %s so the test results are not stored, see 'jx edit storage'", kube.ClassificationReports)
+		return nil
+	}
+	var gitKind string
+	if location.GitURL != "" {
+		gitInfo, err := gits.ParseGitURL(location.GitURL)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse git URL for storage URL %s", location.GitURL)
+		}
+		gitKind, err = o.GitServerKind(gitInfo)
+		if err != nil {
+			return errors.Wrapf(err, "could not determine git kind for storage URL %s", location.GitURL)
+		}
+	}
+	coll, err := collector.NewCollector(location, o.Git(), gitKind)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the collector for storage settings %s", location.Description())
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return errors.Wrap(err, "marshalling the test results")
+	}
+	storagePath := testresults.ResultsPath(results.Owner, results.Repository, results.Branch, results.Build)
+	u, err := coll.CollectData(data, storagePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store the test results at %s", storagePath)
+	}
+	log.Logger().Infof("stored the results of %d tests at %s", len(results.Tests), util.ColorInfo(u))
+
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return errors.Wrap(err, "cannot create the JX client")
+	}
+	key := &kube.PipelineActivityKey{
+		Name:     naming.ToValidName(fmt.Sprintf("%s-%s-%s-%s", results.Owner, results.Repository, results.Branch, results.Build)),
+		Pipeline: fmt.Sprintf("%s/%s/%s", results.Owner, results.Repository, results.Branch),
+		Build:    results.Build,
+		GitInfo: &gits.GitRepository{
+			Organisation: results.Owner,
+			Name:         results.Repository,
+		},
+	}
+	a, _, err := key.GetOrCreate(jxClient, ns)
+	if err != nil {
+		return err
+	}
+	a.Spec.Attachments = setAttachment(a.Spec.Attachments, jenkinsv1.Attachment{
+		Name: testresults.AttachmentName,
+		URLs: []string{u},
+	})
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).PatchUpdate(a)
+	return err
+}
+
+// setAttachment replaces the attachment with the same name so that the step can be run again in the same build,
+// otherwise the attachment is appended
+func setAttachment(attachments []jenkinsv1.Attachment, attachment jenkinsv1.Attachment) []jenkinsv1.Attachment {
+	for i := range attachments {
+		if attachments[i].Name == attachment.Name {
+			attachments[i] = attachment
+			return attachments
+		}
+	}
+	return append(attachments, attachment)
+}
+
+// loadBaseBranchHistory loads the stored test results of the latest builds of the base branch of the Pull Request,
+// defaulting to the PULL_BASE_REF environment variable or master. The results are read from the bucket or git URLs
+// unless a function to read them is given
+func loadBaseBranchHistory(o *opts.CommonOptions, results *testresults.BuildTestResults, baseBranch string, count int, readURL testresults.ReadURLFn) ([]*testresults.BuildTestResults, error) {
+	if baseBranch == "" {
+		baseBranch = os.Getenv("PULL_BASE_REF")
+	}
+	if baseBranch == "" {
+		baseBranch = "master"
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create the JX client")
+	}
+	if readURL == nil {
+		authConfigSvc, err := o.GitAuthConfigService()
+		if err != nil {
+			return nil, err
+		}
+		readURL = func(u string) ([]byte, error) {
+			return buckets.ReadURL(u, 30*time.Second, step.CreateBucketHTTPFn(authConfigSvc))
+		}
+	}
+	filter := &testresults.HistoryFilter{
+		Owner:      results.Owner,
+		Repository: results.Repository,
+		Branch:     baseBranch,
+		Builds:     count,
+	}
+	return testresults.LoadHistory(jxClient, ns, filter, readURL)
+}
+
+// gitProviderForBuild returns the git provider of the repository being built
+func gitProviderForBuild(o *opts.CommonOptions) (gits.GitProvider, error) {
+	gitURL := os.Getenv("SOURCE_URL")
+	if gitURL == "" {
+		gitInfo, err := o.FindGitInfo("")
+		if err != nil {
+			return nil, err
+		}
+		gitURL = gitInfo.URL
+	}
+	return o.GitProviderForURL(gitURL, "git provider")
+}
+
+// addPullRequestComment comments on the Pull Request of the build
+func addPullRequestComment(o *opts.CommonOptions, results *testresults.BuildTestResults, number int, comment string) error {
+	provider, err := gitProviderForBuild(o)
+	if err != nil {
+		return err
+	}
+	return provider.AddPRComment(buildPullRequest(results, number), comment)
+}
+
+// updatePullRequestComment updates the comment on the Pull Request of the build containing the marker so that each
+// build does not add another comment
+func updatePullRequestComment(o *opts.CommonOptions, results *testresults.BuildTestResults, number int, marker string, comment string) error {
+	provider, err := gitProviderForBuild(o)
+	if err != nil {
+		return err
+	}
+	return upsertPullRequestComment(provider, buildPullRequest(results, number), marker, comment)
+}
+
+// upsertPullRequestComment edits the comment of the Pull Request containing the marker, or adds the comment if there
+// is none or the git provider cannot edit comments. The marker is appended to the comment as a hidden HTML comment
+func upsertPullRequestComment(provider gits.GitProvider, pr *gits.GitPullRequest, marker string, comment string) error {
+	marker = fmt.Sprintf("<!-- %s -->", marker)
+	comment = comment + "\n" + marker
+	editor, ok := provider.(gits.PullRequestCommentEditor)
+	if ok {
+		comments, err := editor.ListPRComments(pr)
+		if err != nil {
+			return errors.Wrap(err, "listing the comments of the Pull Request")
+		}
+		for _, c := range comments {
+			if strings.Contains(c.Body, marker) {
+				return editor.EditPRComment(pr, c.ID, comment)
+			}
+		}
+	}
+	return provider.AddPRComment(pr, comment)
+}
+
+// buildPullRequest returns the Pull Request with the given number of the repository of the build
+func buildPullRequest(results *testresults.BuildTestResults, number int) *gits.GitPullRequest {
+	return &gits.GitPullRequest{
+		Repo:   results.Repository,
+		Owner:  results.Owner,
+		Number: &number,
+	}
+}
@@ -13,9 +13,11 @@ import (
 
 	"github.com/acarl005/stripansi"
 	"github.com/google/uuid"
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	log2 "github.com/jenkins-x/jx/v2/pkg/log"
 	reportingtools_test "github.com/jenkins-x/jx/v2/pkg/reportingtools/mocks"
+	"github.com/jenkins-x/jx/v2/pkg/testresults"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/petergtz/pegomock"
 	"github.com/stretchr/testify/assert"
@@ -178,7 +180,43 @@ func TestErrorNoMatchingFilesFound(t *testing.T) {
 	assert.Equal(t, "ERROR: there was a problem obtaining the matching report files: no report files to parse in test_data/junit/empty_dir, skipping\n", stripansi.Strip(output))
 }
 
+func TestTestResultsFromReport(t *testing.T) {
+	suites, err := parseJUnitReportFile(filepath.Join("test_data", "junit", "results_report", "results.junit.xml"))
+	assert.NoError(t, err)
+
+	results := testResultsOf(suites)
+	assert.Equal(t, []testresults.TestResult{
+		{Suite: "Jenkins X E2E tests: ui_smoke", Classname: "ui_smoke", Name: "can list projects", Duration: 1.25, Outcome: testresults.OutcomePassed},
+		{Suite: "Jenkins X E2E tests: ui_smoke", Classname: "ui_smoke", Name: "can list builds", Duration: 2, Outcome: testresults.OutcomeFailed},
+		{Suite: "Jenkins X E2E tests: ui_smoke", Classname: "ui_smoke", Name: "can show logs", Duration: 9.25, Outcome: testresults.OutcomeError},
+		{Suite: "Jenkins X E2E tests: ui_smoke", Classname: "ui_smoke", Name: "can delete projects", Duration: 0, Outcome: testresults.OutcomeSkipped},
+	}, results)
+}
+
 func AnyCommonOptions() *opts.CommonOptions {
 	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((**opts.CommonOptions)(nil)).Elem()))
 	return nil
 }
+
+func TestSetAttachment(t *testing.T) {
+	attachments := []jenkinsv1.Attachment{
+		{Name: "coverage", URLs: []string{"https://storage/coverage.html"}},
+		{Name: testresults.AttachmentName, URLs: []string{"https://storage/1/tests.json"}},
+	}
+	attachments = setAttachment(attachments, jenkinsv1.Attachment{Name: testresults.AttachmentName, URLs: []string{"https://storage/2/tests.json"}})
+	assert.Equal(t, []jenkinsv1.Attachment{
+		{Name: "coverage", URLs: []string{"https://storage/coverage.html"}},
+		{Name: testresults.AttachmentName, URLs: []string{"https://storage/2/tests.json"}},
+	}, attachments, "the existing attachment should be replaced")
+
+	attachments = setAttachment(nil, jenkinsv1.Attachment{Name: testresults.AttachmentName, URLs: []string{"https://storage/1/tests.json"}})
+	assert.Len(t, attachments, 1)
+}
+
+func TestStoreResultsDefaultsMatchReportTests(t *testing.T) {
+	t.Parallel()
+	junitFlag := NewCmdStepReportJUnit(&opts.CommonOptions{}).Flags().Lookup("store-results")
+	testsFlag := NewCmdStepReportTests(&opts.CommonOptions{}).Flags().Lookup("store-results")
+	assert.Equal(t, "true", junitFlag.DefValue, "the results should be stored by default so 'jx get tests flaky' can find the flaky tests")
+	assert.Equal(t, testsFlag.DefValue, junitFlag.DefValue)
+}
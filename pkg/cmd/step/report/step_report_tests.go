@@ -0,0 +1,246 @@
+package report
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/testresults"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// summaryCommentMarker identifies the comment with the summary of the tests so that it is updated by later builds
+const summaryCommentMarker = "jx step report tests"
+
+var (
+	stepReportTestsLong = templates.LongDesc(`
+		This step summarises the JUnit test reports and the Cobertura, Go cover profile or LCOV coverage reports of
+		a build.
+
+		When building a Pull Request the summary of the failed tests and the change in coverage compared to the
+		latest build of the base branch is added as a comment on the Pull Request. Later builds update the comment
+		when the git provider supports editing comments. The coverage is reported as a commit status which fails if
+		the coverage is below the --coverage-threshold.
+
+		The results are stored in the team's reports storage so that Pull Requests can be compared with their base
+		branch and 'jx get tests flaky' can find the flaky tests. Like 'jx step report junit' this is enabled by
+		default and can be disabled with --store-results=false.
+`)
+
+	stepReportTestsExample = templates.Examples(`
+		# Report the results of the *.junit.xml files in $REPORTS_DIR
+		jx step report tests
+
+		# Report the results and coverage of a Go build and fail the coverage status below 70%
+		jx step report tests --junit reports/*.xml --coverage coverage.out --coverage-threshold 70
+
+		# Report the coverage of a JavaScript build without commenting on the Pull Request
+		jx step report tests --junit junit.xml --coverage coverage/lcov.info --comment=false
+`)
+)
+
+// StepReportTestsOptions contains the command line flags and other helper objects
+type StepReportTestsOptions struct {
+	StepReportOptions
+	JUnitPatterns     []string
+	CoveragePatterns  []string
+	CoverageThreshold float64
+	BaseBranch        string
+	StatusContext     string
+	Comment           bool
+	Status            bool
+	StoreResults      bool
+
+	// ReadURLFn reads the stored test results of the base branch, defaulting to reading them from the bucket or git URL
+	ReadURLFn testresults.ReadURLFn
+}
+
+// NewCmdStepReportTests Creates a new Command object
+func NewCmdStepReportTests(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepReportTestsOptions{
+		StepReportOptions: StepReportOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "tests",
+		Short:   "Reports the test results and coverage of a build on its Pull Request and commit",
+		Long:    stepReportTestsLong,
+		Example: stepReportTestsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&options.JUnitPatterns, "junit", "j", nil, "The patterns of the JUnit report files. Defaults to the *.junit.xml files in $REPORTS_DIR")
+	cmd.Flags().StringArrayVarP(&options.CoveragePatterns, "coverage", "c", nil, "The patterns of the Cobertura XML, Go cover profile or LCOV coverage report files")
+	cmd.Flags().Float64VarP(&options.CoverageThreshold, "coverage-threshold", "", 0, "The coverage percentage below which the coverage commit status fails")
+	cmd.Flags().StringVarP(&options.BaseBranch, "base-branch", "", "", "The branch the Pull Request is compared with. Defaults to $PULL_BASE_REF or master")
+	cmd.Flags().StringVarP(&options.StatusContext, "status-context", "", "coverage", "The context of the coverage commit status")
+	cmd.Flags().BoolVarP(&options.Comment, "comment", "", true, "Whether or not to comment on the Pull Request with the summary")
+	cmd.Flags().BoolVarP(&options.Status, "status", "", true, "Whether or not to report the coverage as a commit status")
+	cmd.Flags().BoolVarP(&options.StoreResults, "store-results", "", true, "Whether or not to store the results in the team's reports storage so 'jx get tests flaky' can find the flaky tests")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepReportTestsOptions) Run() error {
+	if len(o.JUnitPatterns) == 0 {
+		o.JUnitPatterns = []string{filepath.Join(os.Getenv("REPORTS_DIR"), "*.junit.xml")}
+	}
+	junitFiles, err := globFiles(o.JUnitPatterns)
+	if err != nil {
+		return err
+	}
+	coverageFiles, err := globFiles(o.CoveragePatterns)
+	if err != nil {
+		return err
+	}
+	if len(junitFiles) == 0 && len(coverageFiles) == 0 {
+		return fmt.Errorf("no JUnit or coverage reports found matching %s", strings.Join(append(o.JUnitPatterns, o.CoveragePatterns...), ", "))
+	}
+
+	tests := []testresults.TestResult{}
+	for _, f := range junitFiles {
+		suites, err := parseJUnitReportFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "parsing the JUnit report %s", f)
+		}
+		tests = append(tests, testResultsOf(suites)...)
+	}
+	results := pipelineTestResults(tests)
+	for _, f := range coverageFiles {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		coverage, err := testresults.ParseCoverage(data)
+		if err != nil {
+			return errors.Wrapf(err, "parsing the coverage report %s", f)
+		}
+		if results.Coverage == nil {
+			results.Coverage = &testresults.Coverage{}
+		}
+		results.Coverage.Add(coverage)
+	}
+
+	if !isPipelineBuild(results) {
+		log.Logger().Info(testresults.NewSummary(results, nil).Markdown())
+		log.Logger().Infof("not running in a pipeline so the results are not stored or reported")
+		return nil
+	}
+	if o.StoreResults {
+		err = storeTestResults(o.CommonOptions, results)
+		if err != nil {
+			return err
+		}
+	}
+
+	number, err := pullRequestNumber()
+	if err != nil {
+		return err
+	}
+	var base *testresults.BuildTestResults
+	if number != 0 {
+		history, err := loadBaseBranchHistory(o.CommonOptions, results, o.BaseBranch, 1, o.ReadURLFn)
+		if err != nil {
+			log.Logger().Warnf("failed to load the test results of the base branch: %s", err)
+		} else if len(history) > 0 {
+			base = history[len(history)-1]
+		}
+	}
+	summary := testresults.NewSummary(results, base)
+	log.Logger().Info(summary.Markdown())
+
+	if o.Comment && number != 0 {
+		err = updatePullRequestComment(o.CommonOptions, results, number, summaryCommentMarker, summary.Markdown())
+		if err != nil {
+			return errors.Wrapf(err, "commenting on Pull Request %d", number)
+		}
+		log.Logger().Infof("commented on Pull Request %s", util.ColorInfo(number))
+	}
+	if o.Status && results.Coverage != nil {
+		return o.reportCoverageStatus(summary)
+	}
+	return nil
+}
+
+// reportCoverageStatus sets the coverage commit status of the commit being built
+func (o *StepReportTestsOptions) reportCoverageStatus(summary *testresults.Summary) error {
+	sha := os.Getenv("PULL_PULL_SHA")
+	if sha == "" {
+		sha = os.Getenv("PULL_BASE_SHA")
+	}
+	if sha == "" {
+		log.Logger().Warnf("no commit SHA found in $PULL_PULL_SHA or $PULL_BASE_SHA so the coverage status is not reported")
+		return nil
+	}
+	provider, err := gitProviderForBuild(o.CommonOptions)
+	if err != nil {
+		return err
+	}
+	status := CoverageStatus(summary, o.CoverageThreshold, o.StatusContext)
+	_, err = provider.UpdateCommitStatus(summary.Results.Owner, summary.Results.Repository, sha, status)
+	if err != nil {
+		return errors.Wrapf(err, "reporting the coverage status of commit %s", sha)
+	}
+	log.Logger().Infof("reported the %s status %s: %s", status.Context, util.ColorInfo(status.State), status.Description)
+	return nil
+}
+
+// CoverageStatus returns the commit status of the coverage which fails if the coverage is below the threshold
+// percentage
+func CoverageStatus(summary *testresults.Summary, threshold float64, context string) *gits.GitRepoStatus {
+	percent := summary.Results.Coverage.Percent()
+	description := fmt.Sprintf("%.1f%% coverage", percent)
+	if delta, ok := summary.CoverageDelta(); ok {
+		description = fmt.Sprintf("%.1f%% coverage (%+.1f%%)", percent, delta)
+	}
+	state := "success"
+	if threshold > 0 && percent < threshold {
+		state = "failure"
+		description += fmt.Sprintf(" is below the %.1f%% threshold", threshold)
+	}
+	return &gits.GitRepoStatus{
+		State:       state,
+		Context:     context,
+		Description: description,
+	}
+}
+
+// globFiles returns the sorted files matching any of the patterns
+func globFiles(patterns []string) ([]string, error) {
+	found := map[string]bool{}
+	answer := []string{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid file pattern %s", pattern)
+		}
+		for _, m := range matches {
+			if !found[m] {
+				found[m] = true
+				answer = append(answer, m)
+			}
+		}
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
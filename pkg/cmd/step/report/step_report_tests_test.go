@@ -0,0 +1,179 @@
+// +build unit
+
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/testresults"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setEnv sets the environment variables of a pipeline returning a function to restore them
+func setEnv(t *testing.T, env map[string]string) func() {
+	previous := map[string]*string{}
+	for k, v := range env {
+		if old, ok := os.LookupEnv(k); ok {
+			previous[k] = &old
+		} else {
+			previous[k] = nil
+		}
+		require.NoError(t, os.Setenv(k, v))
+	}
+	return func() {
+		for k, v := range previous {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+func TestStepReportTestsCommentsOnPullRequest(t *testing.T) {
+	defer setEnv(t, map[string]string{
+		"REPO_OWNER":      "jstrachan",
+		"REPO_NAME":       "myapp",
+		"BRANCH_NAME":     "PR-7",
+		"JX_BUILD_NUMBER": "2",
+		"PULL_NUMBER":     "7",
+		"PULL_BASE_REF":   "master",
+		"PULL_PULL_SHA":   "abc123",
+		"SOURCE_URL":      "https://fake.git/jstrachan/myapp.git",
+	})()
+
+	base := &testresults.BuildTestResults{
+		Version:    testresults.ResultsVersion,
+		Owner:      "jstrachan",
+		Repository: "myapp",
+		Branch:     "master",
+		Build:      "41",
+		Tests: []testresults.TestResult{
+			{Suite: "Jenkins X E2E tests: ui_smoke", Classname: "ui_smoke", Name: "can list builds", Outcome: testresults.OutcomeFailed},
+		},
+		Coverage: &testresults.Coverage{Covered: 1, Total: 2},
+	}
+	baseData, err := json.Marshal(base)
+	require.NoError(t, err)
+	started := metav1.NewTime(time.Now())
+	jxClient := fake.NewSimpleClientset(&v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "jstrachan-myapp-master-41", Namespace: "jx"},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:         "jstrachan/myapp/master",
+			Build:            "41",
+			GitOwner:         "jstrachan",
+			GitRepository:    "myapp",
+			GitBranch:        "master",
+			StartedTimestamp: &started,
+			Attachments: []v1.Attachment{
+				{Name: testresults.AttachmentName, URLs: []string{"gs://reports/master/41/test-results.json"}},
+			},
+		},
+	})
+
+	fakeRepo, err := gits.NewFakeRepository("jstrachan", "myapp", nil, nil)
+	require.NoError(t, err)
+	number := 7
+	fakeRepo.PullRequests[number] = &gits.FakePullRequest{
+		PullRequest: &gits.GitPullRequest{Owner: "jstrachan", Repo: "myapp", Number: &number},
+	}
+	commonOpts := &opts.CommonOptions{}
+	commonOpts.SetDevNamespace("jx")
+	commonOpts.SetJxClient(jxClient)
+	commonOpts.SetFakeGitProvider(gits.NewFakeProvider(fakeRepo))
+
+	o := &StepReportTestsOptions{
+		JUnitPatterns:    []string{filepath.Join("test_data", "tests", "*.junit.xml")},
+		CoveragePatterns: []string{filepath.Join("test_data", "tests", "coverage.out")},
+		Comment:          true,
+		ReadURLFn: func(u string) ([]byte, error) {
+			assert.Equal(t, "gs://reports/master/41/test-results.json", u)
+			return baseData, nil
+		},
+	}
+	o.CommonOptions = commonOpts
+	err = o.Run()
+	require.NoError(t, err)
+
+	comment := fakeRepo.PullRequests[number].Comment
+	assert.Contains(t, comment, "| This build | 1 | 2 | 1 | 70.0% |")
+	assert.Contains(t, comment, "| master #41 | 0 | 1 | 0 | 50.0% |")
+	assert.Contains(t, comment, "Coverage increased by 20.0% compared to master.")
+	assert.Contains(t, comment, "| `Jenkins X E2E tests: ui_smoke / ui_smoke / can list builds` | also fails on master |")
+	assert.Contains(t, comment, "| `Jenkins X E2E tests: ui_smoke / ui_smoke / can show logs` | **new failure** |")
+}
+
+func TestCoverageStatus(t *testing.T) {
+	results := &testresults.BuildTestResults{Coverage: &testresults.Coverage{Covered: 65, Total: 100}}
+	base := &testresults.BuildTestResults{Coverage: &testresults.Coverage{Covered: 70, Total: 100}}
+
+	status := CoverageStatus(testresults.NewSummary(results, base), 60, "coverage")
+	assert.Equal(t, "success", status.State)
+	assert.Equal(t, "coverage", status.Context)
+	assert.Equal(t, "65.0% coverage (-5.0%)", status.Description)
+
+	status = CoverageStatus(testresults.NewSummary(results, nil), 80, "coverage")
+	assert.Equal(t, "failure", status.State)
+	assert.Equal(t, "65.0% coverage is below the 80.0% threshold", status.Description)
+}
+
+func TestStepReportTestsWithoutReports(t *testing.T) {
+	o := &StepReportTestsOptions{
+		JUnitPatterns: []string{filepath.Join("test_data", "tests", "missing", "*.xml")},
+	}
+	err := o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no JUnit or coverage reports found")
+}
+
+// fakeCommentEditor is a git provider which can list and edit the comments of a Pull Request
+type fakeCommentEditor struct {
+	*gits.FakeProvider
+	comments []*gits.GitPullRequestComment
+}
+
+func (f *fakeCommentEditor) AddPRComment(pr *gits.GitPullRequest, comment string) error {
+	f.comments = append(f.comments, &gits.GitPullRequestComment{ID: int64(len(f.comments) + 1), Body: comment})
+	return nil
+}
+
+func (f *fakeCommentEditor) ListPRComments(pr *gits.GitPullRequest) ([]*gits.GitPullRequestComment, error) {
+	return f.comments, nil
+}
+
+func (f *fakeCommentEditor) EditPRComment(pr *gits.GitPullRequest, id int64, comment string) error {
+	for _, c := range f.comments {
+		if c.ID == id {
+			c.Body = comment
+			return nil
+		}
+	}
+	return errors.Errorf("comment %d not found", id)
+}
+
+func TestUpsertPullRequestComment(t *testing.T) {
+	t.Parallel()
+
+	number := 7
+	pr := &gits.GitPullRequest{Owner: "jstrachan", Repo: "myapp", Number: &number}
+	provider := &fakeCommentEditor{}
+	require.NoError(t, provider.AddPRComment(pr, "/lgtm"))
+
+	require.NoError(t, upsertPullRequestComment(provider, pr, summaryCommentMarker, "2 failed tests"))
+	require.NoError(t, upsertPullRequestComment(provider, pr, summaryCommentMarker, "all tests passed"))
+	require.Len(t, provider.comments, 2, "the summary comment should be updated rather than added again")
+	assert.Equal(t, "/lgtm", provider.comments[0].Body)
+	assert.Equal(t, "all tests passed\n<!-- jx step report tests -->", provider.comments[1].Body)
+}
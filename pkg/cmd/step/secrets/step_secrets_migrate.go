@@ -0,0 +1,396 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/io/secrets"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/kubevault"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/localvault"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/vault"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	statusMigrated     = "Migrated"
+	statusWouldMigrate = "Would migrate"
+)
+
+var (
+	// MigrateLocations the secret locations which secrets can be migrated between
+	MigrateLocations = []string{string(secrets.FileSystemLocationKind), string(secrets.VaultLocationKind), string(secrets.KubeLocationKind)}
+
+	stepSecretsMigrateLong = templates.LongDesc(`
+		Migrates all the secrets from one secret storage backend to another.
+
+		Every secret is copied from the source backend to the target backend and read back to verify it was stored
+		correctly. The secret URIs in the values files of the boot configuration are then rewritten to use the target
+		backend, the secretStorage of the requirements is updated and the secrets location of the cluster is switched.
+
+		The secrets are not removed from the source backend so that the migration can be reverted.
+`)
+
+	stepSecretsMigrateExample = templates.Examples(`
+		# report which secrets would be migrated from the local file system to vault
+		jx step secrets migrate --from local --to vault --dry-run
+
+		# migrate the secrets from the local file system to vault
+		jx step secrets migrate --from local --to vault
+
+		# migrate the secrets from vault to Kubernetes Secrets
+		jx step secrets migrate --from vault --to kube
+`)
+)
+
+// lister is implemented by the secret URL clients which can list all the secrets they store
+type lister interface {
+	List() ([]string, error)
+}
+
+// MigrateResult the result of migrating a secret
+type MigrateResult struct {
+	Path    string
+	Keys    []string
+	Status  string
+	Message string
+}
+
+// StepSecretsMigrateOptions contains the command line flags
+type StepSecretsMigrateOptions struct {
+	step.StepOptions
+
+	From         string
+	To           string
+	Dir          string
+	LocalDir     string
+	DryRun       bool
+	SkipURIs     bool
+	SkipLocation bool
+
+	// FromClient and ToClient are the clients of the backends, they are created from From and To if not specified
+	FromClient secreturl.Client
+	ToClient   secreturl.Client
+
+	results      []*MigrateResult
+	changedFiles []string
+}
+
+// NewCmdStepSecretsMigrate creates the `jx step secrets migrate` command
+func NewCmdStepSecretsMigrate(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSecretsMigrateOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "migrate",
+		Short:   "Migrates the secrets between the local file system, vault and Kubernetes Secrets",
+		Long:    stepSecretsMigrateLong,
+		Example: stepSecretsMigrateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.From, "from", "f", "", fmt.Sprintf("The secret storage to migrate from. Possible values: %s", strings.Join(MigrateLocations, ", ")))
+	cmd.Flags().StringVarP(&options.To, "to", "t", "", fmt.Sprintf("The secret storage to migrate to. Possible values: %s", strings.Join(MigrateLocations, ", ")))
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "The directory of the boot configuration whose secret URIs are rewritten")
+	cmd.Flags().StringVarP(&options.LocalDir, "local-dir", "", "", "The directory of the local file system secrets. Defaults to ~/.jx/localSecrets")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Reports what would be migrated without changing anything")
+	cmd.Flags().BoolVarP(&options.SkipURIs, "skip-uris", "", false, "Does not rewrite the secret URIs in the boot configuration")
+	cmd.Flags().BoolVarP(&options.SkipLocation, "skip-location", "", false, "Does not switch the secrets location of the cluster")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSecretsMigrateOptions) Run() error {
+	for name, value := range map[string]string{"from": o.From, "to": o.To} {
+		if value == "" {
+			return util.MissingOption(name)
+		}
+		if util.StringArrayIndex(MigrateLocations, value) < 0 {
+			return util.InvalidOption(name, value, MigrateLocations)
+		}
+	}
+	if o.From == o.To {
+		return fmt.Errorf("cannot migrate the secrets from %s to itself", o.From)
+	}
+	var err error
+	if o.FromClient == nil {
+		o.FromClient, err = o.createClient(o.From)
+		if err != nil {
+			return err
+		}
+	}
+	if o.ToClient == nil {
+		o.ToClient, err = o.createClient(o.To)
+		if err != nil {
+			return err
+		}
+	}
+
+	paths, err := ListSecretPaths(o.FromClient)
+	if err != nil {
+		return errors.Wrapf(err, "listing the secrets in %s", o.From)
+	}
+	o.results = nil
+	for _, path := range paths {
+		o.results = append(o.results, o.migrateSecret(path))
+	}
+	o.printResults()
+	for _, r := range o.results {
+		if r.Status == statusFailed {
+			return fmt.Errorf("failed to migrate the secret %s: %s", r.Path, r.Message)
+		}
+	}
+
+	if !o.SkipURIs {
+		o.changedFiles, err = RewriteSecretURIs(o.Dir, o.From, o.To, o.DryRun)
+		if err != nil {
+			return err
+		}
+		for _, f := range o.changedFiles {
+			if o.DryRun {
+				log.Logger().Infof("would rewrite the secret URIs in %s", util.ColorInfo(f))
+			} else {
+				log.Logger().Infof("rewrote the secret URIs in %s", util.ColorInfo(f))
+			}
+		}
+		err = o.updateRequirements()
+		if err != nil {
+			return err
+		}
+	}
+	if o.DryRun || o.SkipLocation {
+		return nil
+	}
+	err = o.SetSecretsLocation(secrets.ToSecretsLocation(o.To), true)
+	if err != nil {
+		return errors.Wrapf(err, "switching the secrets location to %s", o.To)
+	}
+	log.Logger().Infof("migrated %d secrets from %s to %s", len(o.results), util.ColorInfo(o.From), util.ColorInfo(o.To))
+	return nil
+}
+
+// Results returns the results of the last run
+func (o *StepSecretsMigrateOptions) Results() []*MigrateResult {
+	return o.results
+}
+
+// ChangedFiles returns the files whose secret URIs were rewritten in the last run
+func (o *StepSecretsMigrateOptions) ChangedFiles() []string {
+	return o.changedFiles
+}
+
+func (o *StepSecretsMigrateOptions) createClient(location string) (secreturl.Client, error) {
+	switch secrets.ToSecretsLocation(location) {
+	case secrets.FileSystemLocationKind:
+		dir := o.LocalDir
+		if dir == "" {
+			var err error
+			dir, err = util.LocalFileSystemSecretsDir()
+			if err != nil {
+				return nil, errors.Wrap(err, "getting the file system secrets directory")
+			}
+		}
+		return localvault.NewFileSystemClient(dir), nil
+	case secrets.VaultLocationKind:
+		_, ns, err := o.KubeClientAndDevNamespace()
+		if err != nil {
+			return nil, errors.Wrap(err, "creating the kube client")
+		}
+		client, err := o.SystemVaultClient(ns)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating the system vault client")
+		}
+		return client, nil
+	case secrets.KubeLocationKind:
+		kubeClient, ns, err := o.KubeClientAndDevNamespace()
+		if err != nil {
+			return nil, errors.Wrap(err, "creating the kube client")
+		}
+		return kubevault.NewClient(kubeClient, ns), nil
+	default:
+		return nil, util.InvalidOption("from", location, MigrateLocations)
+	}
+}
+
+// migrateSecret copies the secret to the target backend and verifies it by reading it back
+func (o *StepSecretsMigrateOptions) migrateSecret(path string) *MigrateResult {
+	result := &MigrateResult{Path: path}
+	fail := func(err error) *MigrateResult {
+		result.Status = statusFailed
+		result.Message = err.Error()
+		return result
+	}
+	data, err := o.FromClient.Read(path)
+	if err != nil {
+		return fail(err)
+	}
+	for k := range data {
+		result.Keys = append(result.Keys, k)
+	}
+	sort.Strings(result.Keys)
+	if o.DryRun {
+		result.Status = statusWouldMigrate
+		return result
+	}
+	_, err = o.ToClient.Write(path, data)
+	if err != nil {
+		return fail(err)
+	}
+	copied, err := o.ToClient.Read(path)
+	if err != nil {
+		return fail(errors.Wrap(err, "reading back the migrated secret"))
+	}
+	equal, err := secretDataEqual(data, copied)
+	if err != nil {
+		return fail(err)
+	}
+	if !equal {
+		return fail(fmt.Errorf("the migrated secret does not match the original"))
+	}
+	result.Status = statusMigrated
+	return result
+}
+
+func (o *StepSecretsMigrateOptions) printResults() {
+	table := o.CreateTable()
+	table.AddRow("SECRET", "KEYS", "STATUS", "MESSAGE")
+	for _, r := range o.results {
+		table.AddRow(r.Path, strings.Join(r.Keys, ", "), r.Status, r.Message)
+	}
+	table.Render()
+}
+
+// updateRequirements changes the secret storage of the requirements in the boot configuration
+func (o *StepSecretsMigrateOptions) updateRequirements() error {
+	fileName := filepath.Join(o.Dir, config.RequirementsConfigFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil || !exists {
+		return err
+	}
+	storage := config.SecretStorageType(o.To)
+	if util.StringArrayIndex(config.SecretStorageTypeValues, string(storage)) < 0 {
+		log.Logger().Warnf("The requirements do not support the secret storage %s so %s is not updated", o.To, fileName)
+		return nil
+	}
+	requirements, err := config.LoadRequirementsConfigFile(fileName)
+	if err != nil {
+		return err
+	}
+	if requirements.SecretStorage == storage {
+		return nil
+	}
+	if o.DryRun {
+		log.Logger().Infof("would change the secretStorage in %s to %s", util.ColorInfo(fileName), util.ColorInfo(o.To))
+		return nil
+	}
+	requirements.SecretStorage = storage
+	err = requirements.SaveConfig(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "saving %s", fileName)
+	}
+	log.Logger().Infof("changed the secretStorage in %s to %s", util.ColorInfo(fileName), util.ColorInfo(o.To))
+	return nil
+}
+
+// ListSecretPaths returns the paths of all the secrets stored in the given backend
+func ListSecretPaths(client secreturl.Client) ([]string, error) {
+	switch c := client.(type) {
+	case vault.Client:
+		return vault.ListRecursive(c, "")
+	case lister:
+		return c.List()
+	default:
+		return nil, fmt.Errorf("listing the secrets of %T is not supported", client)
+	}
+}
+
+// RewriteSecretURIs rewrites the secret URIs using the from scheme in the YAML files of the given directory to use the
+// to scheme. It returns the names of the changed files
+func RewriteSecretURIs(dir string, from string, to string, dryRun bool) ([]string, error) {
+	r := regexp.MustCompile(`(^|[\s"'=:])` + regexp.QuoteMeta(from) + `:([-_.\w\/]+:[-_.\w]+)`)
+	answer := []string{}
+	exists, err := util.DirExists(dir)
+	if err != nil || !exists {
+		return answer, err
+	}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext != ".yml" && ext != ".yaml" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "reading %s", path)
+		}
+		text := r.ReplaceAllString(string(data), "${1}"+to+":${2}")
+		if text == string(data) {
+			return nil
+		}
+		answer = append(answer, path)
+		if dryRun {
+			return nil
+		}
+		err = ioutil.WriteFile(path, []byte(text), info.Mode())
+		if err != nil {
+			return errors.Wrapf(err, "writing %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "rewriting the secret URIs in %s", dir)
+	}
+	return answer, nil
+}
+
+// secretDataEqual compares the secret data after normalising it via JSON as the backends may return
+// different types for the same values
+func secretDataEqual(a map[string]interface{}, b map[string]interface{}) (bool, error) {
+	normalise := func(m map[string]interface{}) (interface{}, error) {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshaling the secret")
+		}
+		var answer interface{}
+		err = json.Unmarshal(data, &answer)
+		return answer, err
+	}
+	na, err := normalise(a)
+	if err != nil {
+		return false, err
+	}
+	nb, err := normalise(b)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(na, nb), nil
+}
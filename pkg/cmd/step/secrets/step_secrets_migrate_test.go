@@ -0,0 +1,133 @@
+// +build unit
+
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	clientsfake "github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/kubevault"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/localvault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const parametersYAML = `adminUser:
+  username: admin
+  password: local:mycluster/secrets-adminUser:password
+pipelineUser:
+  token: "local:mycluster/secrets-pipelineUser:token"
+docs: see http://local:8080/foo
+`
+
+func createMigrateOptions(t *testing.T, dryRun bool) (*StepSecretsMigrateOptions, *kubevault.Client, string) {
+	dir, err := ioutil.TempDir("", "test-secrets-migrate-")
+	require.NoError(t, err)
+
+	localClient := localvault.NewFileSystemClient(filepath.Join(dir, "localSecrets"))
+	_, err = localClient.Write("mycluster/secrets-adminUser", map[string]interface{}{"username": "admin", "password": "s3cr3t"})
+	require.NoError(t, err)
+	_, err = localClient.Write("mycluster/secrets-pipelineUser", map[string]interface{}{"token": "abc", "nested": map[string]interface{}{"port": 8080}})
+	require.NoError(t, err)
+
+	bootDir := filepath.Join(dir, "boot")
+	err = os.MkdirAll(filepath.Join(bootDir, "env"), 0700)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(bootDir, "env", "parameters.yaml"), []byte(parametersYAML), 0600)
+	require.NoError(t, err)
+	requirements := config.NewRequirementsConfig()
+	requirements.SecretStorage = config.SecretStorageTypeLocal
+	err = requirements.SaveConfig(filepath.Join(bootDir, config.RequirementsConfigFileName))
+	require.NoError(t, err)
+
+	kubeClient := kubevault.NewClient(fake.NewSimpleClientset(), testNamespace)
+
+	commonOpts := opts.NewCommonOptionsWithFactory(clientsfake.NewFakeFactory())
+	commonOpts.Out = os.Stdout
+	commonOpts.BatchMode = true
+
+	options := &StepSecretsMigrateOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+		},
+		From:       "local",
+		To:         "kube",
+		Dir:        bootDir,
+		DryRun:     dryRun,
+		FromClient: localClient,
+		ToClient:   kubeClient,
+	}
+	return options, kubeClient, dir
+}
+
+func TestStepSecretsMigrate(t *testing.T) {
+	options, kubeClient, dir := createMigrateOptions(t, false)
+	defer os.RemoveAll(dir)
+
+	err := options.Run()
+	require.NoError(t, err, "failed to migrate the secrets")
+
+	results := options.Results()
+	require.Len(t, results, 2)
+	for _, r := range results {
+		assert.Equal(t, statusMigrated, r.Status, "secret %s", r.Path)
+	}
+
+	paths, err := kubeClient.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mycluster/secrets-adminUser", "mycluster/secrets-pipelineUser"}, paths)
+
+	data, err := kubeClient.Read("mycluster/secrets-pipelineUser")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", data["token"])
+	assert.Equal(t, map[string]interface{}{"port": float64(8080)}, data["nested"])
+
+	text, err := ioutil.ReadFile(filepath.Join(options.Dir, "env", "parameters.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(text), "password: kube:mycluster/secrets-adminUser:password")
+	assert.Contains(t, string(text), `token: "kube:mycluster/secrets-pipelineUser:token"`)
+	assert.Contains(t, string(text), "http://local:8080/foo", "should not rewrite URLs")
+
+	requirements, err := config.LoadRequirementsConfigFile(filepath.Join(options.Dir, config.RequirementsConfigFileName))
+	require.NoError(t, err)
+	assert.Equal(t, config.SecretStorageTypeKube, requirements.SecretStorage)
+}
+
+func TestStepSecretsMigrateDryRun(t *testing.T) {
+	options, kubeClient, dir := createMigrateOptions(t, true)
+	defer os.RemoveAll(dir)
+
+	err := options.Run()
+	require.NoError(t, err, "failed to report the secrets to migrate")
+
+	results := options.Results()
+	require.Len(t, results, 2)
+	assert.Equal(t, statusWouldMigrate, results[0].Status)
+	assert.Equal(t, []string{"password", "username"}, results[0].Keys)
+	assert.Len(t, options.ChangedFiles(), 1)
+
+	paths, err := kubeClient.List()
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+
+	text, err := ioutil.ReadFile(filepath.Join(options.Dir, "env", "parameters.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, parametersYAML, string(text))
+}
+
+func TestStepSecretsMigrateInvalidLocation(t *testing.T) {
+	options, _, dir := createMigrateOptions(t, true)
+	defer os.RemoveAll(dir)
+
+	options.To = "local"
+	assert.Error(t, options.Run())
+	options.To = "bogus"
+	assert.Error(t, options.Run())
+}
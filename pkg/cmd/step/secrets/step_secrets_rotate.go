@@ -0,0 +1,534 @@
+package secrets
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/update"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/services"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sethvargo/go-password/password"
+	"github.com/spf13/cobra"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// RotateKindHMAC the HMAC token used to sign the webhooks
+	RotateKindHMAC = "hmac"
+	// RotateKindChartMuseum the admin password of ChartMuseum
+	RotateKindChartMuseum = "chartmuseum"
+	// RotateKindNexus the admin password of Nexus
+	RotateKindNexus = "nexus"
+
+	statusRotated     = "Rotated"
+	statusWouldRotate = "Would rotate"
+	statusSkipped     = "Skipped"
+	statusFailed      = "Failed"
+
+	nexusServiceName        = "nexus"
+	mavenSettingsSecretName = "jenkins-maven-settings"
+	mavenSettingsSecretKey  = "settings.xml"
+	passwordSymbols         = "~!#%^_+-=?,."
+)
+
+var (
+	// RotateKinds the kinds of credentials which can be rotated
+	RotateKinds = []string{RotateKindHMAC, RotateKindChartMuseum, RotateKindNexus}
+
+	stepSecretsRotateLong = templates.LongDesc(`
+		Generates new values for the credentials used by Jenkins X and updates everything which consumes them.
+
+		The new values are written to the secret store (Vault, the local file system or a cloud secret manager) and the
+		Kubernetes Secrets. The webhooks of all the SourceRepositories are updated when the HMAC token changes and any
+		Deployments which use the changed Secrets are restarted.
+
+		The API token of the pipeline git user is not rotated as the git providers do not allow a token to create new
+		tokens. Create a new token for the pipeline user on the git provider, update it with 'jx create git token' and
+		the secret store, then revoke the old token.
+`)
+
+	stepSecretsRotateExample = templates.Examples(`
+		# report which credentials would be rotated
+		jx step secrets rotate --dry-run
+
+		# rotate the HMAC token and update all the webhooks
+		jx step secrets rotate --kind hmac
+
+		# rotate all the credentials
+		jx step secrets rotate
+`)
+)
+
+// secretKeyRef a key in a Kubernetes Secret
+type secretKeyRef struct {
+	Name string
+	Key  string
+}
+
+// credential describes where a rotatable credential is stored
+type credential struct {
+	Kind        string
+	SecretPath  string
+	SecretKey   string
+	KubeSecrets []secretKeyRef
+}
+
+// RotateResult the result of rotating a kind of credential
+type RotateResult struct {
+	Kind        string
+	Status      string
+	Secrets     []string
+	Deployments []string
+	Message     string
+}
+
+// StepSecretsRotateOptions contains the command line flags
+type StepSecretsRotateOptions struct {
+	step.StepOptions
+
+	Kinds           []string
+	Dir             string
+	BasePath        string
+	DryRun          bool
+	SkipSecretStore bool
+	NoRestart       bool
+
+	// GeneratePassword generates new passwords, it can be replaced in tests
+	GeneratePassword func() (string, error)
+	// ChangeNexusPassword changes the admin password of Nexus, it can be replaced in tests
+	ChangeNexusPassword func(kubeClient kubernetes.Interface, ns string, oldPassword string, newPassword string) error
+	// UpdateWebhooks updates the HMAC token of the webhooks of all the SourceRepositories, it can be replaced in tests
+	UpdateWebhooks func(hmacToken string, warnOnFail bool) error
+
+	secretClient secreturl.Client
+	results      []*RotateResult
+}
+
+// NewCmdStepSecretsRotate creates the `jx step secrets rotate` command
+func NewCmdStepSecretsRotate(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSecretsRotateOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "rotate",
+		Short:   "Generates new credentials and updates the webhooks, Secrets and Deployments which use them",
+		Long:    stepSecretsRotateLong,
+		Example: stepSecretsRotateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringArrayVarP(&options.Kinds, "kind", "k", RotateKinds, fmt.Sprintf("The kinds of credentials to rotate. Possible values: %s", strings.Join(RotateKinds, ", ")))
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "The directory used to find the requirements file which configures the secret store")
+	cmd.Flags().StringVarP(&options.BasePath, "secret-base-path", "", "", "The path of the secrets in the secret store. Defaults to the cluster name from the requirements")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Reports what would be rotated without changing anything")
+	cmd.Flags().BoolVarP(&options.SkipSecretStore, "skip-secret-store", "", false, "Only updates the Kubernetes Secrets and does not write the new values to the secret store")
+	cmd.Flags().BoolVarP(&options.NoRestart, "no-restart", "", false, "Does not restart the Deployments which use the rotated Secrets")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSecretsRotateOptions) Run() error {
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return errors.Wrap(err, "creating the kube client")
+	}
+	for _, kind := range o.Kinds {
+		if util.StringArrayIndex(RotateKinds, kind) < 0 {
+			return util.InvalidOption("kind", kind, RotateKinds)
+		}
+	}
+	if o.GeneratePassword == nil {
+		o.GeneratePassword = generatePassword
+	}
+	if o.ChangeNexusPassword == nil {
+		o.ChangeNexusPassword = changeNexusPassword
+	}
+	if o.UpdateWebhooks == nil {
+		o.UpdateWebhooks = o.updateWebhooks
+	}
+	if !o.SkipSecretStore {
+		err = o.initSecretStore()
+		if err != nil {
+			return err
+		}
+	}
+
+	o.results = nil
+	for _, kind := range o.Kinds {
+		o.results = append(o.results, o.rotatePassword(kubeClient, ns, credentialFor(kind)))
+	}
+	o.printResults()
+
+	for _, r := range o.results {
+		if r.Status == statusFailed {
+			return fmt.Errorf("failed to rotate the %s credentials: %s", r.Kind, r.Message)
+		}
+	}
+	return nil
+}
+
+// Results returns the results of the last run
+func (o *StepSecretsRotateOptions) Results() []*RotateResult {
+	return o.results
+}
+
+// initSecretStore creates the client for the secret store configured in the requirements
+func (o *StepSecretsRotateOptions) initSecretStore() error {
+	requirements, _, err := config.LoadRequirementsConfig(o.Dir)
+	if err != nil {
+		teamSettings, err2 := o.TeamSettings()
+		if err2 != nil {
+			log.Logger().Warnf("Could not find the requirements so the secret store will not be updated: %s", err.Error())
+			return nil
+		}
+		requirements, err = config.GetRequirementsConfigFromTeamSettings(teamSettings)
+		if err != nil || requirements == nil {
+			log.Logger().Warnf("Could not find the requirements so the secret store will not be updated")
+			return nil
+		}
+	}
+	if o.BasePath == "" {
+		o.BasePath = requirements.Cluster.ClusterName
+	}
+	o.secretClient, err = o.GetSecretURLClientForRequirements(requirements)
+	if err != nil {
+		return errors.Wrap(err, "creating the secret store client")
+	}
+	return nil
+}
+
+func credentialFor(kind string) *credential {
+	switch kind {
+	case RotateKindHMAC:
+		return &credential{
+			Kind:       kind,
+			SecretPath: "secrets",
+			SecretKey:  "hmacToken",
+			KubeSecrets: []secretKeyRef{
+				{Name: "hmac-token", Key: "hmac"},
+				{Name: "lighthouse-hmac-token", Key: "hmac"},
+			},
+		}
+	case RotateKindChartMuseum:
+		return &credential{
+			Kind:       kind,
+			SecretPath: "secrets-chartmuseum",
+			SecretKey:  "password",
+			KubeSecrets: []secretKeyRef{
+				{Name: kube.SecretJenkinsChartMuseum, Key: "BASIC_AUTH_PASS"},
+			},
+		}
+	case RotateKindNexus:
+		return &credential{
+			Kind:       kind,
+			SecretPath: "secrets-nexus",
+			SecretKey:  "password",
+			KubeSecrets: []secretKeyRef{
+				{Name: nexusServiceName, Key: "password"},
+			},
+		}
+	}
+	return nil
+}
+
+// rotatePassword generates a new value for a credential which is owned by Jenkins X
+func (o *StepSecretsRotateOptions) rotatePassword(kubeClient kubernetes.Interface, ns string, cred *credential) *RotateResult {
+	result := &RotateResult{Kind: cred.Kind}
+	refs, oldValue, err := existingSecretKeys(kubeClient, ns, cred.KubeSecrets)
+	if err != nil {
+		return failed(result, err)
+	}
+	if len(refs) == 0 {
+		result.Status = statusSkipped
+		result.Message = "no Secrets found"
+		return result
+	}
+	for _, ref := range refs {
+		result.Secrets = append(result.Secrets, ref.Name)
+	}
+
+	if o.DryRun {
+		return o.dryRun(kubeClient, ns, result)
+	}
+
+	var newValue string
+	if cred.Kind == RotateKindHMAC {
+		newValue, err = generateHMACToken()
+	} else {
+		newValue, err = o.GeneratePassword()
+	}
+	if err != nil {
+		return failed(result, errors.Wrap(err, "generating the new value"))
+	}
+
+	// persist the new value before changing Nexus so that it is never lost, restoring the old value if Nexus fails
+	err = o.persistValue(kubeClient, ns, cred, refs, newValue)
+	if err != nil {
+		return failed(result, o.rollback(kubeClient, ns, cred, refs, oldValue, err))
+	}
+	if cred.Kind == RotateKindNexus {
+		err = o.ChangeNexusPassword(kubeClient, ns, oldValue, newValue)
+		if err != nil {
+			return failed(result, o.rollback(kubeClient, ns, cred, refs, oldValue, err))
+		}
+		changed, err := updateMavenSettings(kubeClient, ns, oldValue, newValue)
+		if err != nil {
+			return failed(result, err)
+		}
+		if changed {
+			result.Secrets = append(result.Secrets, mavenSettingsSecretName)
+		}
+	}
+
+	// the webhooks must all sign with the new token, otherwise the old token is restored everywhere
+	if cred.Kind == RotateKindHMAC {
+		err = o.UpdateWebhooks(newValue, false)
+		if err != nil {
+			err = errors.Wrap(err, "updating the webhooks")
+			restoreErr := o.UpdateWebhooks(oldValue, true)
+			if restoreErr != nil {
+				err = errors.Errorf("%s and failed to restore the old token of the webhooks: %s", err.Error(), restoreErr.Error())
+			}
+			return failed(result, o.rollback(kubeClient, ns, cred, refs, oldValue, err))
+		}
+	}
+	return o.restartDeployments(kubeClient, ns, result)
+}
+
+// updateWebhooks updates the HMAC token of the webhooks of all the SourceRepositories
+func (o *StepSecretsRotateOptions) updateWebhooks(hmacToken string, warnOnFail bool) error {
+	webhooks := &update.UpdateWebhooksOptions{
+		CommonOptions:  o.CommonOptions,
+		HMAC:           hmacToken,
+		ExactHookMatch: true,
+		WarnOnFail:     warnOnFail,
+	}
+	return webhooks.Run()
+}
+
+func (o *StepSecretsRotateOptions) persistValue(kubeClient kubernetes.Interface, ns string, cred *credential, refs []secretKeyRef, value string) error {
+	err := o.writeSecretStore(cred.SecretPath, cred.SecretKey, value)
+	if err != nil {
+		return err
+	}
+	return updateSecretKeys(kubeClient, ns, refs, value)
+}
+
+// rollback restores the old value of the credential after the rotation failed and returns the cause of the failure
+func (o *StepSecretsRotateOptions) rollback(kubeClient kubernetes.Interface, ns string, cred *credential, refs []secretKeyRef, oldValue string, cause error) error {
+	err := o.persistValue(kubeClient, ns, cred, refs, oldValue)
+	if err != nil {
+		return errors.Errorf("%s and failed to restore the old value: %s", cause.Error(), err.Error())
+	}
+	return cause
+}
+
+func (o *StepSecretsRotateOptions) dryRun(kubeClient kubernetes.Interface, ns string, result *RotateResult) *RotateResult {
+	deployments, err := deploymentsUsingSecrets(kubeClient, ns, result.Secrets)
+	if err != nil {
+		return failed(result, err)
+	}
+	result.Status = statusWouldRotate
+	result.Deployments = deployments
+	return result
+}
+
+// writeSecretStore writes the new value into the secret store preserving the other keys at the same path
+func (o *StepSecretsRotateOptions) writeSecretStore(path string, key string, value string) error {
+	if o.secretClient == nil {
+		return nil
+	}
+	if o.BasePath != "" {
+		path = o.BasePath + "/" + path
+	}
+	data := map[string]interface{}{}
+	existing, err := o.secretClient.Read(path)
+	if err == nil {
+		for k, v := range existing {
+			data[k] = v
+		}
+	}
+	data[key] = value
+	_, err = o.secretClient.Write(path, data)
+	if err != nil {
+		return errors.Wrapf(err, "writing %s to the secret store", path)
+	}
+	return nil
+}
+
+func (o *StepSecretsRotateOptions) restartDeployments(kubeClient kubernetes.Interface, ns string, result *RotateResult) *RotateResult {
+	result.Status = statusRotated
+	if o.NoRestart {
+		return result
+	}
+	deployments, err := deploymentsUsingSecrets(kubeClient, ns, result.Secrets)
+	if err != nil {
+		return failed(result, err)
+	}
+	for _, name := range deployments {
+		err = kube.RestartDeployment(kubeClient, ns, name)
+		if err != nil {
+			return failed(result, errors.Wrapf(err, "restarting Deployment %s", name))
+		}
+	}
+	result.Deployments = deployments
+	return result
+}
+
+// changeNexusPassword changes the admin password of Nexus via its REST API
+func changeNexusPassword(kubeClient kubernetes.Interface, ns string, oldPassword string, newPassword string) error {
+	nexusURL, err := services.FindServiceURL(kubeClient, ns, nexusServiceName)
+	if err != nil {
+		return errors.Wrap(err, "finding the Nexus URL")
+	}
+	u := util.UrlJoin(nexusURL, "service/rest/beta/security/users/admin/change-password")
+	req, err := http.NewRequest(http.MethodPut, u, strings.NewReader(newPassword))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("admin", oldPassword)
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := util.GetClientWithTimeout(time.Minute).Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "changing the Nexus admin password via %s", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("changing the Nexus admin password via %s returned status %d", u, resp.StatusCode)
+	}
+	return nil
+}
+
+// updateMavenSettings replaces the Nexus password in the maven settings Secret used by the pipelines
+func updateMavenSettings(kubeClient kubernetes.Interface, ns string, oldPassword string, newPassword string) (bool, error) {
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(mavenSettingsSecretName, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "getting Secret %s", mavenSettingsSecretName)
+	}
+	settings := string(secret.Data[mavenSettingsSecretKey])
+	oldText := "<password>" + oldPassword + "</password>"
+	if oldPassword == "" || !strings.Contains(settings, oldText) {
+		return false, nil
+	}
+	secret.Data[mavenSettingsSecretKey] = []byte(strings.Replace(settings, oldText, "<password>"+newPassword+"</password>", -1))
+	_, err = kubeClient.CoreV1().Secrets(ns).Update(secret)
+	if err != nil {
+		return false, errors.Wrapf(err, "updating Secret %s", mavenSettingsSecretName)
+	}
+	return true, nil
+}
+
+// existingSecretKeys returns the references to the Secrets which exist along with the current value of the first one
+func existingSecretKeys(kubeClient kubernetes.Interface, ns string, refs []secretKeyRef) ([]secretKeyRef, string, error) {
+	answer := []secretKeyRef{}
+	value := ""
+	for _, ref := range refs {
+		secret, err := kubeClient.CoreV1().Secrets(ns).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				continue
+			}
+			return nil, "", errors.Wrapf(err, "getting Secret %s", ref.Name)
+		}
+		if len(answer) == 0 {
+			value = string(secret.Data[ref.Key])
+		}
+		answer = append(answer, ref)
+	}
+	return answer, value, nil
+}
+
+func updateSecretKeys(kubeClient kubernetes.Interface, ns string, refs []secretKeyRef, value string) error {
+	for _, ref := range refs {
+		secret, err := kubeClient.CoreV1().Secrets(ns).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "getting Secret %s", ref.Name)
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[ref.Key] = []byte(value)
+		_, err = kubeClient.CoreV1().Secrets(ns).Update(secret)
+		if err != nil {
+			return errors.Wrapf(err, "updating Secret %s", ref.Name)
+		}
+	}
+	return nil
+}
+
+// deploymentsUsingSecrets returns the sorted names of the Deployments which use any of the given Secrets
+func deploymentsUsingSecrets(kubeClient kubernetes.Interface, ns string, secretNames []string) ([]string, error) {
+	names := map[string]bool{}
+	for _, secretName := range secretNames {
+		deployments, err := kube.FindDeploymentsUsingSecret(kubeClient, ns, secretName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "finding the Deployments which use Secret %s", secretName)
+		}
+		for _, d := range deployments {
+			names[d.Name] = true
+		}
+	}
+	answer := []string{}
+	for name := range names {
+		answer = append(answer, name)
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
+
+func (o *StepSecretsRotateOptions) printResults() {
+	table := o.CreateTable()
+	table.AddRow("KIND", "STATUS", "SECRETS", "RESTARTED DEPLOYMENTS", "MESSAGE")
+	for _, r := range o.results {
+		table.AddRow(r.Kind, r.Status, strings.Join(r.Secrets, ", "), strings.Join(r.Deployments, ", "), r.Message)
+	}
+	table.Render()
+}
+
+func failed(result *RotateResult, err error) *RotateResult {
+	result.Status = statusFailed
+	result.Message = err.Error()
+	return result
+}
+
+func generatePassword() (string, error) {
+	generator, err := password.NewGenerator(&password.GeneratorInput{
+		Symbols: passwordSymbols,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "creating the password generator")
+	}
+	return generator.Generate(20, 4, 2, false, true)
+}
+
+// generateHMACToken generates a new webhook HMAC token from a cryptographically secure source
+func generateHMACToken() (string, error) {
+	generator, err := password.NewGenerator(&password.GeneratorInput{})
+	if err != nil {
+		return "", errors.Wrap(err, "creating the token generator")
+	}
+	return generator.Generate(41, 10, 0, false, true)
+}
@@ -0,0 +1,228 @@
+// +build unit
+
+package secrets
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	clientsfake "github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/fakevault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testNamespace = "jx"
+
+func createRotateOptions(t *testing.T, dryRun bool) (*StepSecretsRotateOptions, *fake.Clientset, string) {
+	dir, err := ioutil.TempDir("", "test-secrets-rotate-")
+	require.NoError(t, err)
+
+	requirements := config.NewRequirementsConfig()
+	requirements.Cluster.ClusterName = "mycluster"
+	requirements.SecretStorage = config.SecretStorageTypeLocal
+	err = requirements.SaveConfig(filepath.Join(dir, config.RequirementsConfigFileName))
+	require.NoError(t, err)
+
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: kube.SecretJenkinsChartMuseum, Namespace: testNamespace},
+			Data: map[string][]byte{
+				"BASIC_AUTH_USER": []byte("admin"),
+				"BASIC_AUTH_PASS": []byte("old-password"),
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "jenkins-x-chartmuseum", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name: "chartmuseum",
+								EnvFrom: []corev1.EnvFromSource{
+									{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: kube.SecretJenkinsChartMuseum}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: testNamespace},
+		},
+	)
+
+	commonOpts := opts.NewCommonOptionsWithFactory(clientsfake.NewFakeFactory())
+	commonOpts.Out = os.Stdout
+	commonOpts.SetDevNamespace(testNamespace)
+	commonOpts.SetKubeClient(kubeClient)
+	commonOpts.SetSecretURLClient(fakevault.NewFakeClient())
+	commonOpts.BatchMode = true
+
+	options := &StepSecretsRotateOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+		},
+		Kinds:  []string{RotateKindChartMuseum, RotateKindNexus},
+		Dir:    dir,
+		DryRun: dryRun,
+		GeneratePassword: func() (string, error) {
+			return "new-password", nil
+		},
+	}
+	return options, kubeClient, dir
+}
+
+func TestStepSecretsRotate(t *testing.T) {
+	options, kubeClient, dir := createRotateOptions(t, false)
+	defer os.RemoveAll(dir)
+
+	err := options.Run()
+	require.NoError(t, err, "failed to rotate the secrets")
+
+	results := options.Results()
+	require.Len(t, results, 2)
+	assert.Equal(t, statusRotated, results[0].Status)
+	assert.Equal(t, []string{"jenkins-x-chartmuseum"}, results[0].Deployments)
+	assert.Equal(t, statusSkipped, results[1].Status, "nexus is not installed")
+
+	secret, err := kubeClient.CoreV1().Secrets(testNamespace).Get(kube.SecretJenkinsChartMuseum, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "new-password", string(secret.Data["BASIC_AUTH_PASS"]))
+	assert.Equal(t, "admin", string(secret.Data["BASIC_AUTH_USER"]))
+
+	deployment, err := kubeClient.AppsV1().Deployments(testNamespace).Get("jenkins-x-chartmuseum", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, deployment.Spec.Template.Annotations[kube.AnnotationRestartedAt])
+
+	deployment, err = kubeClient.AppsV1().Deployments(testNamespace).Get("unrelated", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, deployment.Spec.Template.Annotations[kube.AnnotationRestartedAt])
+
+	data, err := options.secretClient.Read("mycluster/secrets-chartmuseum")
+	require.NoError(t, err)
+	assert.Equal(t, "new-password", data["password"])
+}
+
+func TestStepSecretsRotateDryRun(t *testing.T) {
+	options, kubeClient, dir := createRotateOptions(t, true)
+	defer os.RemoveAll(dir)
+
+	err := options.Run()
+	require.NoError(t, err, "failed to report the secrets to rotate")
+
+	results := options.Results()
+	require.Len(t, results, 2)
+	assert.Equal(t, statusWouldRotate, results[0].Status)
+	assert.Equal(t, []string{kube.SecretJenkinsChartMuseum}, results[0].Secrets)
+	assert.Equal(t, []string{"jenkins-x-chartmuseum"}, results[0].Deployments)
+
+	secret, err := kubeClient.CoreV1().Secrets(testNamespace).Get(kube.SecretJenkinsChartMuseum, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "old-password", string(secret.Data["BASIC_AUTH_PASS"]))
+
+	deployment, err := kubeClient.AppsV1().Deployments(testNamespace).Get("jenkins-x-chartmuseum", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, deployment.Spec.Template.Annotations[kube.AnnotationRestartedAt])
+}
+
+func TestStepSecretsRotateNexusRestoresOldPasswordOnFailure(t *testing.T) {
+	options, kubeClient, dir := createRotateOptions(t, false)
+	defer os.RemoveAll(dir)
+
+	_, err := kubeClient.CoreV1().Secrets(testNamespace).Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: nexusServiceName, Namespace: testNamespace},
+		Data: map[string][]byte{
+			"password": []byte("old-nexus-password"),
+		},
+	})
+	require.NoError(t, err)
+
+	storedPassword := ""
+	options.Kinds = []string{RotateKindNexus}
+	options.ChangeNexusPassword = func(kubeClient kubernetes.Interface, ns string, oldPassword string, newPassword string) error {
+		secret, err := kubeClient.CoreV1().Secrets(ns).Get(nexusServiceName, metav1.GetOptions{})
+		require.NoError(t, err)
+		storedPassword = string(secret.Data["password"])
+		return errors.New("nexus is down")
+	}
+	err = options.Run()
+	require.Error(t, err)
+	assert.Equal(t, "new-password", storedPassword, "the new password should be stored before Nexus is changed")
+
+	results := options.Results()
+	require.Len(t, results, 1)
+	assert.Equal(t, statusFailed, results[0].Status)
+	assert.Equal(t, "nexus is down", results[0].Message)
+
+	secret, err := kubeClient.CoreV1().Secrets(testNamespace).Get(nexusServiceName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "old-nexus-password", string(secret.Data["password"]))
+	data, err := options.secretClient.Read("mycluster/secrets-nexus")
+	require.NoError(t, err)
+	assert.Equal(t, "old-nexus-password", data["password"])
+}
+
+func TestStepSecretsRotateHMACRestoresOldTokenOnWebhookFailure(t *testing.T) {
+	options, kubeClient, dir := createRotateOptions(t, false)
+	defer os.RemoveAll(dir)
+
+	_, err := kubeClient.CoreV1().Secrets(testNamespace).Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "lighthouse-hmac-token", Namespace: testNamespace},
+		Data: map[string][]byte{
+			"hmac": []byte("old-hmac"),
+		},
+	})
+	require.NoError(t, err)
+
+	webhookTokens := []string{}
+	options.Kinds = []string{RotateKindHMAC}
+	options.UpdateWebhooks = func(hmacToken string, warnOnFail bool) error {
+		webhookTokens = append(webhookTokens, hmacToken)
+		if hmacToken != "old-hmac" {
+			assert.False(t, warnOnFail, "the new token should not ignore webhook failures")
+			return errors.New("webhook update failed")
+		}
+		return nil
+	}
+	err = options.Run()
+	require.Error(t, err)
+
+	require.Len(t, webhookTokens, 2)
+	assert.Equal(t, "old-hmac", webhookTokens[1], "the webhooks should be restored to the old token")
+	results := options.Results()
+	require.Len(t, results, 1)
+	assert.Equal(t, statusFailed, results[0].Status)
+
+	secret, err := kubeClient.CoreV1().Secrets(testNamespace).Get("lighthouse-hmac-token", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "old-hmac", string(secret.Data["hmac"]))
+	data, err := options.secretClient.Read("mycluster/secrets")
+	require.NoError(t, err)
+	assert.Equal(t, "old-hmac", data["hmacToken"])
+}
+
+func TestGenerateHMACToken(t *testing.T) {
+	first, err := generateHMACToken()
+	require.NoError(t, err)
+	second, err := generateHMACToken()
+	require.NoError(t, err)
+
+	assert.Len(t, first, 41)
+	assert.Regexp(t, "^[a-zA-Z0-9]+$", first)
+	assert.NotEqual(t, first, second)
+}
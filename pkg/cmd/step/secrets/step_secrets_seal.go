@@ -0,0 +1,161 @@
+package secrets
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/sealedsecrets"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepSecretsSealLong = templates.LongDesc(`
+		Seals the plain text Kubernetes Secret manifests in an environment git repository using Bitnami sealed-secrets.
+
+		Each Secret whose values are literals, rather than helm template expressions or secret URIs, is replaced by
+		a SealedSecret encrypted with the public key of the sealed-secrets controller in the cluster. Only the controller
+		can decrypt the SealedSecret so it is safe to commit it to git.
+`)
+
+	stepSecretsSealExample = templates.Examples(`
+		# seal the plain text secrets in the current environment repository
+		jx step secrets seal
+
+		# report which secrets would be sealed
+		jx step secrets seal --dir env --dry-run
+
+		# seal the secrets using a certificate fetched with 'kubeseal --fetch-cert'
+		jx step secrets seal --cert sealed-secrets.pem
+	`)
+)
+
+// StepSecretsSealOptions contains the command line flags
+type StepSecretsSealOptions struct {
+	step.StepOptions
+
+	Dir                 string
+	Namespace           string
+	CertFile            string
+	ControllerName      string
+	ControllerNamespace string
+	Scope               string
+	DryRun              bool
+
+	sealed []*sealedsecrets.PlaintextSecret
+}
+
+// NewCmdStepSecretsSeal creates the `jx step secrets seal` command
+func NewCmdStepSecretsSeal(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSecretsSealOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "seal",
+		Short:   "Seals the plain text Secrets in an environment git repository using sealed-secrets",
+		Long:    stepSecretsSealLong,
+		Example: stepSecretsSealExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "The directory of the environment git repository")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace of the Secrets which do not specify one. Defaults to $DEPLOY_NAMESPACE or the current namespace")
+	cmd.Flags().StringVarP(&options.CertFile, "cert", "", "", "The PEM encoded certificate of the sealed-secrets controller. If not specified it is fetched from the sealed-secrets controller")
+	cmd.Flags().StringVarP(&options.ControllerName, "controller-name", "", sealedsecrets.DefaultControllerName, "The name of the Service of the sealed-secrets controller")
+	cmd.Flags().StringVarP(&options.ControllerNamespace, "controller-namespace", "", sealedsecrets.DefaultControllerNamespace, "The namespace of the sealed-secrets controller")
+	cmd.Flags().StringVarP(&options.Scope, "scope", "", string(sealedsecrets.ScopeStrict), fmt.Sprintf("The scope the secrets are sealed for. Possible values: %s", strings.Join(sealedsecrets.ScopeValues, ", ")))
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Reports the Secrets which would be sealed without changing anything")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSecretsSealOptions) Run() error {
+	scope := sealedsecrets.Scope(o.Scope)
+	if util.StringArrayIndex(sealedsecrets.ScopeValues, o.Scope) < 0 {
+		return util.InvalidOption("scope", o.Scope, sealedsecrets.ScopeValues)
+	}
+	if o.DryRun {
+		secrets, err := sealedsecrets.FindPlaintextSecrets(o.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "finding the plain text Secrets in %s", o.Dir)
+		}
+		for _, s := range secrets {
+			log.Logger().Infof("would seal Secret %s", util.ColorInfo(s.String()))
+		}
+		o.sealed = secrets
+		return nil
+	}
+
+	cert, err := o.loadCertificate()
+	if err != nil {
+		return err
+	}
+	pubKey, err := sealedsecrets.ParseCertificate(cert)
+	if err != nil {
+		return err
+	}
+
+	o.sealed, err = sealedsecrets.ReplacePlaintextSecrets(o.Dir, func(s *sealedsecrets.PlaintextSecret, doc []byte) ([]byte, error) {
+		secret, err := sealedsecrets.ParseSecret(doc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing Secret %s in %s", s.Name, s.File)
+		}
+		if secret.Namespace == "" && scope != sealedsecrets.ScopeClusterWide {
+			secret.Namespace, err = o.GetDeployNamespace(o.Namespace)
+			if err != nil {
+				return nil, err
+			}
+		}
+		sealed, err := sealedsecrets.SealSecret(secret, pubKey, scope)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sealing Secret %s in %s", s.Name, s.File)
+		}
+		data, err := yaml.Marshal(sealed)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshaling the SealedSecret %s", s.Name)
+		}
+		log.Logger().Infof("sealed Secret %s", util.ColorInfo(s.String()))
+		return data, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "sealing the plain text Secrets in %s", o.Dir)
+	}
+	if len(o.sealed) == 0 {
+		log.Logger().Infof("no plain text Secrets found in %s", util.ColorInfo(o.Dir))
+	}
+	return nil
+}
+
+// Sealed returns the plain text Secrets sealed, or which would be sealed in dry run mode, in the last run
+func (o *StepSecretsSealOptions) Sealed() []*sealedsecrets.PlaintextSecret {
+	return o.sealed
+}
+
+func (o *StepSecretsSealOptions) loadCertificate() ([]byte, error) {
+	if o.CertFile != "" {
+		data, err := ioutil.ReadFile(o.CertFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the sealed-secrets certificate %s", o.CertFile)
+		}
+		return data, nil
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return sealedsecrets.FetchCertificate(kubeClient, o.ControllerNamespace, o.ControllerName)
+}
@@ -0,0 +1,118 @@
+// +build unit
+
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	clientsfake "github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/sealedsecrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const plaintextSecretYAML = `apiVersion: v1
+kind: Secret
+metadata:
+  name: cheese
+type: Opaque
+stringData:
+  password: s3cr3t
+`
+
+func TestStepSecretsSeal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-secrets-seal-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "env", "templates", "cheese-secret.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(plaintextSecretYAML), 0644))
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sealed-secret"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.AddProxyReactor("services", func(action k8stesting.Action) (bool, restclient.ResponseWrapper, error) {
+		return true, &certResponse{data: certPEM}, nil
+	})
+
+	commonOpts := opts.NewCommonOptionsWithFactory(clientsfake.NewFakeFactory())
+	commonOpts.Out = os.Stdout
+	commonOpts.SetKubeClient(kubeClient)
+	options := &StepSecretsSealOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+		},
+		Dir:                 dir,
+		Namespace:           "jx-staging",
+		ControllerName:      sealedsecrets.DefaultControllerName,
+		ControllerNamespace: sealedsecrets.DefaultControllerNamespace,
+		Scope:               string(sealedsecrets.ScopeStrict),
+		DryRun:              true,
+	}
+	require.NoError(t, options.Run())
+	assert.Len(t, options.Sealed(), 1)
+	data, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, plaintextSecretYAML, string(data), "dry run should not modify the file")
+
+	options.DryRun = false
+	require.NoError(t, options.Run())
+	assert.Len(t, options.Sealed(), 1)
+
+	data, err = ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	sealed := &sealedsecrets.SealedSecret{}
+	require.NoError(t, yaml.Unmarshal(data, sealed))
+	assert.Equal(t, sealedsecrets.Kind, sealed.Kind)
+	assert.Equal(t, "cheese", sealed.Name)
+	assert.Equal(t, "jx-staging", sealed.Namespace)
+	assert.NotEmpty(t, sealed.Spec.EncryptedData["password"])
+	assert.NotContains(t, string(data), "s3cr3t")
+
+	remaining, err := sealedsecrets.FindPlaintextSecrets(dir)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	options.Scope = "global"
+	assert.Error(t, options.Run())
+}
+
+// certResponse serves the certificate of the sealed-secrets controller through the fake API server proxy
+type certResponse struct {
+	data []byte
+}
+
+func (r *certResponse) DoRaw() ([]byte, error) {
+	return r.data, nil
+}
+
+func (r *certResponse) Stream() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(r.data)), nil
+}
@@ -1,6 +1,10 @@
 package verify
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
@@ -9,6 +13,19 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// tlsSecretName returns the name of the Secret containing the TLS certificate for the domain
+func tlsSecretName(domain string, tlsConfig *config.TLSConfig) string {
+	secretName := tlsConfig.SecretName
+	if secretName == "" {
+		if tlsConfig.Production {
+			secretName = fmt.Sprintf("tls-%s-p", domain)
+		} else {
+			secretName = fmt.Sprintf("tls-%s-s", domain)
+		}
+	}
+	return strings.ReplaceAll(secretName, ".", "-")
+}
+
 func (o *StepVerifyOptions) validateKaniko(ns string) error {
 	kubeClient, _, err := o.KubeClientAndDevNamespace()
 	if err != nil {
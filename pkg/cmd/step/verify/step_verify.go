@@ -32,6 +32,7 @@ func NewCmdStepVerify(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepVerifyBehavior(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyDependencies(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyDNS(commonOpts))
+	cmd.AddCommand(NewCmdStepVerifyEnvironmentDrift(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyEnvironments(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyGit(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyIngress(commonOpts))
@@ -40,6 +41,7 @@ func NewCmdStepVerify(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepVerifyPod(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyPreInstall(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyRequirements(commonOpts))
+	cmd.AddCommand(NewCmdStepVerifySecrets(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyURL(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyValues(commonOpts))
 
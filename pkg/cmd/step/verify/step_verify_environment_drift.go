@@ -0,0 +1,285 @@
+package verify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/restmapper"
+)
+
+const (
+	optionEnvironmentDriftEnv = "env"
+	helmfileFileName          = "helmfile.yaml"
+)
+
+var (
+	stepVerifyEnvironmentDriftLong = templates.LongDesc(`
+		Verifies that the resources running in an environment match the resources defined in the environment git repository.
+
+		The environment chart (or helmfile) is rendered and compared with the live resources in the cluster. Any resources which
+		have been added, removed or modified in the cluster (e.g. via 'kubectl edit') are reported.
+`)
+
+	stepVerifyEnvironmentDriftExample = templates.Examples(`
+		# verify the staging environment has not drifted from its git repository
+		jx step verify environment-drift --env staging
+
+		# create an issue on the environment git repository if drift is detected
+		jx step verify environment-drift --env production --issue
+
+		# verify a local clone of the environment git repository and fail if there is drift
+		jx step verify environment-drift --env staging --dir . --fail
+`)
+)
+
+// StepVerifyEnvironmentDriftOptions contains the command line flags
+type StepVerifyEnvironmentDriftOptions struct {
+	StepVerifyOptions
+
+	Environment string
+	Dir         string
+	ReleaseName string
+	Namespace   string
+	Selector    string
+	ValueFiles  []string
+	CreateIssue bool
+	PullRequest int
+	Fail        bool
+}
+
+// NewCmdStepVerifyEnvironmentDrift creates the `jx step verify environment-drift` command
+func NewCmdStepVerifyEnvironmentDrift(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepVerifyEnvironmentDriftOptions{
+		StepVerifyOptions: StepVerifyOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "environment-drift",
+		Aliases: []string{"env-drift", "drift"},
+		Short:   "Verifies that the resources in an environment match its git repository",
+		Long:    stepVerifyEnvironmentDriftLong,
+		Example: stepVerifyEnvironmentDriftExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Environment, optionEnvironmentDriftEnv, "e", "", "The name of the environment to verify")
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory containing the environment git repository. If not specified the environment git repository is cloned")
+	cmd.Flags().StringVarP(&options.ReleaseName, "name", "n", "", "The helm release name of the environment chart. Defaults to 'jx' for the development environment or the environment namespace")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "", "", "The namespace to compare. Defaults to the namespace of the environment")
+	cmd.Flags().StringVarP(&options.Selector, "selector", "s", "", "The label selector used to find resources in the cluster which are not in git. Defaults to the chart release label")
+	cmd.Flags().StringArrayVarP(&options.ValueFiles, "values", "f", []string{}, "Additional values files used to render the environment chart")
+	cmd.Flags().BoolVarP(&options.CreateIssue, "issue", "", false, "Creates an issue on the environment git repository if drift is detected")
+	cmd.Flags().IntVarP(&options.PullRequest, "pr", "", 0, "Adds a comment to the given pull request number of the environment git repository if drift is detected")
+	cmd.Flags().BoolVarP(&options.Fail, "fail", "", false, "Returns an error if drift is detected")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepVerifyEnvironmentDriftOptions) Run() error {
+	if o.Environment == "" {
+		return util.MissingOption(optionEnvironmentDriftEnv)
+	}
+	jxClient, devNs, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	env, err := kube.GetEnvironment(jxClient, devNs, o.Environment)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find environment %s in namespace %s", o.Environment, devNs)
+	}
+	ns := o.Namespace
+	if ns == "" {
+		ns = env.Spec.Namespace
+	}
+	if ns == "" {
+		ns = devNs
+	}
+	releaseName := o.ReleaseName
+	if releaseName == "" {
+		releaseName = ns
+		if env.Spec.Kind == v1.EnvironmentKindTypeDevelopment {
+			releaseName = "jx"
+		}
+	}
+	selector := o.Selector
+	if selector == "" {
+		selector = helm.LabelReleaseName + "=" + releaseName
+	}
+
+	gitURL := env.Spec.Source.URL
+	dir := o.Dir
+	if dir == "" {
+		if gitURL == "" {
+			return fmt.Errorf("environment %s has no git repository", o.Environment)
+		}
+		dir, err = ioutil.TempDir("", "jx-env-drift-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create a temporary directory")
+		}
+		defer os.RemoveAll(dir)
+		err = o.Git().Clone(gitURL, dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to clone environment git repository %s", gitURL)
+		}
+	}
+
+	outDir, err := ioutil.TempDir("", "jx-env-drift-output-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(outDir)
+
+	err = o.renderEnvironment(dir, releaseName, ns, outDir)
+	if err != nil {
+		return err
+	}
+	desired, err := environments.LoadResources(outDir, ns)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the rendered resources of environment %s", o.Environment)
+	}
+
+	dynamicClient, _, err := o.GetFactory().CreateDynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the dynamic client")
+	}
+	groupResources, err := restmapper.GetAPIGroupResources(kubeClient.Discovery())
+	if err != nil {
+		return errors.Wrap(err, "failed to discover the API resources")
+	}
+	loader := &environments.LiveResourceLoader{
+		DynamicClient: dynamicClient,
+		Mapper:        restmapper.NewDiscoveryRESTMapper(groupResources),
+		Selector:      selector,
+	}
+	live, err := loader.Load(ns, desired)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the live resources in namespace %s", ns)
+	}
+
+	drifts := environments.CompareResources(desired, live)
+	if len(drifts) == 0 {
+		log.Logger().Infof("environment %s has not drifted from its git repository", util.ColorInfo(o.Environment))
+		return nil
+	}
+
+	table := o.CreateTable()
+	table.AddRow("CHANGE", "KIND", "NAMESPACE", "NAME", "FIELDS")
+	for _, d := range drifts {
+		table.AddRow(string(d.Type), d.Kind, d.Namespace, d.Name, strings.Join(d.Differences, ", "))
+	}
+	table.Render()
+
+	if o.CreateIssue || o.PullRequest > 0 {
+		err = o.reportDrift(gitURL, drifts)
+		if err != nil {
+			return err
+		}
+	}
+	if o.Fail {
+		return fmt.Errorf("environment %s has drifted from its git repository: %d resources differ", o.Environment, len(drifts))
+	}
+	return nil
+}
+
+// renderEnvironment renders the environment resources into the output directory via helmfile or helm template
+func (o *StepVerifyEnvironmentDriftOptions) renderEnvironment(dir string, releaseName string, ns string, outDir string) error {
+	helmfile := filepath.Join(dir, helmfileFileName)
+	exists, err := util.FileExists(helmfile)
+	if err != nil {
+		return err
+	}
+	if exists {
+		cmd := util.Command{
+			Name: "helmfile",
+			Args: []string{"--file", helmfile, "template", "--output-dir", outDir},
+			Dir:  dir,
+		}
+		_, err = cmd.RunWithoutRetry()
+		if err != nil {
+			return errors.Wrapf(err, "failed to run helmfile template on %s", helmfile)
+		}
+		return nil
+	}
+
+	chartDir := filepath.Join(dir, "env")
+	exists, err = util.DirExists(chartDir)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		chartDir = dir
+	}
+	_, err = o.HelmInitDependencyBuild(chartDir, o.DefaultReleaseCharts(), o.ValueFiles)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build the chart dependencies in %s", chartDir)
+	}
+	err = o.Helm().Template(chartDir, releaseName, ns, outDir, false, nil, nil, o.ValueFiles)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render the chart in %s", chartDir)
+	}
+	return nil
+}
+
+// reportDrift reports the drift as an issue or pull request comment on the environment git repository
+func (o *StepVerifyEnvironmentDriftOptions) reportDrift(gitURL string, drifts []environments.ResourceDrift) error {
+	if gitURL == "" {
+		return fmt.Errorf("cannot report the drift of environment %s as it has no git repository", o.Environment)
+	}
+	gitInfo, err := gits.ParseGitURL(gitURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse git URL %s", gitURL)
+	}
+	provider, err := o.GitProviderForURL(gitURL, "environment repository")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the git provider for %s", gitURL)
+	}
+	body := environments.DriftMarkdown(o.Environment, drifts)
+	if o.PullRequest > 0 {
+		err = provider.CreateIssueComment(gitInfo.Organisation, gitInfo.Name, o.PullRequest, body)
+		if err != nil {
+			return errors.Wrapf(err, "failed to comment on pull request %d of %s", o.PullRequest, gitURL)
+		}
+		log.Logger().Infof("added drift report to pull request %s", util.ColorInfo(provider.IssueURL(gitInfo.Organisation, gitInfo.Name, o.PullRequest, true)))
+	}
+	if o.CreateIssue {
+		issue, err := provider.CreateIssue(gitInfo.Organisation, gitInfo.Name, &gits.GitIssue{
+			Title: fmt.Sprintf("Environment %s has drifted from git", o.Environment),
+			Body:  body,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to create issue on %s", gitURL)
+		}
+		if issue != nil {
+			log.Logger().Infof("created issue %s", util.ColorInfo(issue.URL))
+		}
+	}
+	return nil
+}
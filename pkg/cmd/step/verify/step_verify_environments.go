@@ -19,7 +19,9 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/io/secrets"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/pki"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
@@ -88,6 +90,14 @@ func (o *StepVerifyEnvironmentsOptions) Run() error {
 		return errors.Wrapf(err, "failed to load Environments in namespace %s", ns)
 	}
 
+	if exists && o.GetSecretsLocation() == secrets.KubeLocationKind {
+		// the secrets are stored in plain Kubernetes Secrets so lets make sure none are committed to git unsealed
+		err = verifyNoPlaintextSecrets(filepath.Dir(requirementsFileName))
+		if err != nil {
+			return err
+		}
+	}
+
 	if exists {
 		// lets store the requirements in the team settings now so that when we create the git auth provider
 		// we will be able to detect if we are using GitHub App secrets or not
@@ -532,17 +542,10 @@ func (o *StepVerifyEnvironmentsOptions) createEnvironmentHelmValues(requirements
 		},
 	}
 
-	// Only set the secret name if TLS is enabled else exposecontroller thinks the ingress needs TLS
+	// Only set the secret name and issuer if TLS is enabled else exposecontroller thinks the ingress needs TLS
 	if envCfg.Ingress.TLS.Enabled {
-		secretName := envCfg.Ingress.TLS.SecretName
-		if secretName == "" {
-			if envCfg.Ingress.TLS.Production {
-				secretName = fmt.Sprintf("tls-%s-p", domain)
-			} else {
-				secretName = fmt.Sprintf("tls-%s-s", domain)
-			}
-		}
-		helmValues.ExposeController.Config.TLSSecretName = strings.ReplaceAll(secretName, ".", "-")
+		helmValues.ExposeController.Config.TLSSecretName = tlsSecretName(domain, &envCfg.Ingress.TLS)
+		helmValues.ExposeController.Config.Issuer = pki.IssuerName(&envCfg.Ingress.TLS)
 	}
 
 	return helmValues, nil
@@ -312,3 +312,57 @@ func pipelineEnvValueForKey(envVars []corev1.EnvVar, key string) string {
 	}
 	return ""
 }
+
+func Test_createEnvironmentHelmValuesIssuer(t *testing.T) {
+	commonOpts := opts.NewCommonOptionsWithFactory(fake.NewFakeFactory())
+	options := &commonOpts
+	testhelpers.ConfigureTestOptions(options, options.Git(), options.Helm())
+
+	testOptions := &StepVerifyEnvironmentsOptions{
+		StepVerifyOptions: StepVerifyOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: options,
+			},
+		},
+	}
+
+	environment := &v1.Environment{
+		ObjectMeta: v12.ObjectMeta{
+			Name: "staging",
+		},
+	}
+	requirements := config.NewRequirementsConfig()
+	requirements.Ingress.Domain = "example.com"
+	requirements.Environments = []config.EnvironmentConfig{
+		{
+			Key: "staging",
+			Ingress: config.IngressConfig{
+				TLS: config.TLSConfig{
+					Enabled: true,
+					Issuer: &config.TLSIssuerConfig{
+						Kind:       config.TLSIssuerKindCA,
+						SecretName: "my-ca",
+					},
+				},
+			},
+		},
+	}
+
+	valuesConfig, err := testOptions.createEnvironmentHelmValues(requirements, environment)
+	assert.NoError(t, err)
+	assert.Equal(t, "jx-ca", valuesConfig.ExposeController.Config.Issuer, "the exposecontroller values should use the CA issuer")
+	assert.Equal(t, "tls-example-com-s", valuesConfig.ExposeController.Config.TLSSecretName)
+	values, err := valuesConfig.String()
+	assert.NoError(t, err)
+	assert.Contains(t, values, "issuer: jx-ca", "the generated environment values should contain the issuer")
+
+	requirements.Environments[0].Ingress.TLS = config.TLSConfig{Enabled: true, Production: true}
+	valuesConfig, err = testOptions.createEnvironmentHelmValues(requirements, environment)
+	assert.NoError(t, err)
+	assert.Equal(t, "letsencrypt-prod", valuesConfig.ExposeController.Config.Issuer)
+
+	requirements.Environments[0].Ingress.TLS = config.TLSConfig{}
+	valuesConfig, err = testOptions.createEnvironmentHelmValues(requirements, environment)
+	assert.NoError(t, err)
+	assert.Empty(t, valuesConfig.ExposeController.Config.Issuer, "no issuer should be set without TLS")
+}
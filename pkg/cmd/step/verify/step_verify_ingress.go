@@ -12,6 +12,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cloud/gke/externaldns"
 	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/pki"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 
 	"github.com/jenkins-x/jx/v2/pkg/cloud"
@@ -189,15 +190,63 @@ func (o *StepVerifyIngressOptions) Run() error {
 		if requirements.Ingress.IsAutoDNSDomain() {
 			return fmt.Errorf("TLS is not supported with automated domains like %s, you will need to use a real domain you own", requirements.Ingress.Domain)
 		}
-		_, err = mail.ParseAddress(requirements.Ingress.TLS.Email)
+		if requirements.Ingress.TLS.RequiresEmail() {
+			_, err = mail.ParseAddress(requirements.Ingress.TLS.Email)
+			if err != nil {
+				return errors.Wrap(err, "You must provide a valid email address to enable TLS so you can receive notifications from LetsEncrypt about your certificates")
+			}
+		}
+		err = o.verifyTLSIssuer(&requirements.Ingress.TLS, ns)
 		if err != nil {
-			return errors.Wrap(err, "You must provide a valid email address to enable TLS so you can receive notifications from LetsEncrypt about your certificates")
+			return errors.Wrap(err, "verifying the TLS issuer")
 		}
 	}
 
 	return requirements.SaveConfig(requirementsFileName)
 }
 
+// verifyTLSIssuer validates the configuration of custom TLS issuers such as a CA, Vault PKI or an internal ACME server
+// along with their Secrets and server, then creates or updates the cert-manager issuer if cert-manager is installed
+func (o *StepVerifyIngressOptions) verifyTLSIssuer(tlsConfig *config.TLSConfig, ns string) error {
+	kind := tlsConfig.IssuerKind()
+	if kind == config.TLSIssuerKindLetsEncrypt {
+		return nil
+	}
+	err := tlsConfig.Issuer.Validate()
+	if err != nil {
+		return err
+	}
+	kubeClient, devNs, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return errors.Wrap(err, "creating kubernetes client")
+	}
+	if ns == "" {
+		ns = devNs
+	}
+	name := pki.IssuerName(tlsConfig)
+	log.Logger().Infof("validating the %s TLS issuer %s in namespace %s", util.ColorInfo(kind), util.ColorInfo(name), util.ColorInfo(ns))
+
+	err = pki.ValidateIssuerSecrets(kubeClient, ns, tlsConfig)
+	if err != nil {
+		return err
+	}
+	err = pki.CheckIssuerServer(tlsConfig)
+	if err != nil {
+		return err
+	}
+
+	certClient, err := o.CertManagerClient()
+	if err != nil {
+		return errors.Wrap(err, "creating the cert-manager client")
+	}
+	_, err = pki.CreateOrUpdateIssuer(certClient, ns, tlsConfig)
+	if err != nil {
+		// cert-manager may not be installed yet in which case the issuer is created later by the boot charts
+		log.Logger().Warnf("unable to create the cert-manager issuer %s in namespace %s: %s", name, ns, err)
+	}
+	return nil
+}
+
 func (o *StepVerifyIngressOptions) discoverIngressDomain(requirements *config.RequirementsConfig, requirementsFileName string) error {
 	client, err := o.KubeClient()
 	var domain string
@@ -1,8 +1,10 @@
 package verify
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 
 	"github.com/jenkins-x/jx/v2/pkg/cloud"
@@ -10,10 +12,15 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/pki"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 // StepVerifyInstallOptions contains the command line flags
@@ -23,6 +30,8 @@ type StepVerifyInstallOptions struct {
 	Dir             string
 	Namespace       string
 	PodWaitDuration time.Duration
+	// CertificateWaitDuration how long to wait for the TLS certificate to be issued before skipping its verification
+	CertificateWaitDuration time.Duration
 }
 
 // NewCmdStepVerifyInstall creates the `jx step verify pod` command
@@ -50,6 +59,7 @@ func NewCmdStepVerifyInstall(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "the directory to look for the install requirements file")
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "", "", "the namespace that Jenkins X will be booted into. If not specified it defaults to $DEPLOY_NAMESPACE")
 	cmd.Flags().DurationVarP(&options.PodWaitDuration, "pod-wait-time", "w", time.Second, "The default wait time to wait for the pods to be ready")
+	cmd.Flags().DurationVarP(&options.CertificateWaitDuration, "certificate-wait-time", "", 5*time.Minute, "The wait time for the TLS certificate to be issued before skipping its verification")
 	return cmd
 }
 
@@ -108,6 +118,63 @@ func (o *StepVerifyInstallOptions) Run() error {
 			}
 		}
 	}
+	if requirements.Ingress.TLS.Enabled {
+		err = o.VerifyTLSCertificate(kubeClient, ns, requirements)
+		if err != nil {
+			return err
+		}
+	}
 	log.Logger().Infof("Installation is currently looking: %s\n", util.ColorInfo("GOOD"))
 	return nil
 }
+
+// VerifyTLSCertificate verifies the certificate chain issued for the ingress domain is valid and trusted by the roots
+// of the configured issuer. If the certificate has not been issued before the certificate wait time expires a warning
+// is logged as cert-manager may still be issuing it
+func (o *StepVerifyInstallOptions) VerifyTLSCertificate(kubeClient kubernetes.Interface, ns string, requirements *config.RequirementsConfig) error {
+	tlsConfig := &requirements.Ingress.TLS
+	domain := requirements.Ingress.Domain
+	if tlsConfig.IssuerKind() == config.TLSIssuerKindLetsEncrypt && !tlsConfig.Production {
+		log.Logger().Infof("skipping the TLS certificate chain verification as the LetsEncrypt staging certificates are not trusted")
+		return nil
+	}
+	secretName := tlsSecretName(domain, tlsConfig)
+	log.Logger().Infof("verifying the TLS certificate in Secret %s\n", util.ColorInfo(secretName))
+
+	var secret *corev1.Secret
+	issuing := false
+	err := util.Retry(o.CertificateWaitDuration, func() error {
+		s, err := kubeClient.CoreV1().Secrets(ns).Get(secretName, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				issuing = true
+				return err
+			}
+			issuing = false
+			return backoff.Permanent(errors.Wrapf(err, "getting the TLS certificate Secret %s in namespace %s", secretName, ns))
+		}
+		if len(s.Data[corev1.TLSCertKey]) == 0 {
+			issuing = true
+			return fmt.Errorf("the Secret has no %s", corev1.TLSCertKey)
+		}
+		secret = s
+		return nil
+	})
+	if err != nil {
+		if !issuing {
+			return err
+		}
+		log.Logger().Warnf("skipping the verification of the TLS certificate Secret %s in namespace %s as it has not been issued by %s yet: %s",
+			secretName, ns, pki.IssuerName(tlsConfig), err.Error())
+		return nil
+	}
+	roots, err := pki.IssuerRootCAs(kubeClient, ns, tlsConfig, secret)
+	if err != nil {
+		return errors.Wrap(err, "loading the root CAs of the TLS issuer")
+	}
+	err = pki.VerifyCertificateChain(secret.Data[corev1.TLSCertKey], roots, domain, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "verifying the TLS certificate in Secret %s in namespace %s", secretName, ns)
+	}
+	return nil
+}
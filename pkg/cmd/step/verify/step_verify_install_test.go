@@ -0,0 +1,47 @@
+// +build unit
+
+package verify_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step/verify"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestVerifyTLSCertificate(t *testing.T) {
+	t.Parallel()
+
+	requirements := config.NewRequirementsConfig()
+	requirements.Ingress.Domain = "example.com"
+	requirements.Ingress.TLS.Enabled = true
+	requirements.Ingress.TLS.Production = true
+
+	o := &verify.StepVerifyInstallOptions{
+		StepVerifyOptions: verify.StepVerifyOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &opts.CommonOptions{},
+			},
+		},
+		CertificateWaitDuration: 10 * time.Millisecond,
+	}
+
+	kubeClient := fake.NewSimpleClientset()
+	err := o.VerifyTLSCertificate(kubeClient, "jx", requirements)
+	assert.NoError(t, err, "a certificate which has not been issued yet should only be warned about")
+
+	kubeClient.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "tls-example-com-p", nil)
+	})
+	err = o.VerifyTLSCertificate(kubeClient, "jx", requirements)
+	assert.Error(t, err, "errors other than the certificate not being issued should fail")
+}
@@ -474,8 +474,9 @@ func (o *StepVerifyPreInstallOptions) VerifyInstallConfig(kubeClient kubernetes.
 	_, err := kube.DefaultModifyConfigMap(kubeClient, ns, kube.ConfigMapNameJXInstallConfig,
 		func(configMap *corev1.ConfigMap) error {
 			secretsLocation := string(secrets.FileSystemLocationKind)
-			if requirements.SecretStorage == config.SecretStorageTypeVault {
-				secretsLocation = string(secrets.VaultLocationKind)
+			location := secrets.ToSecretsLocation(string(requirements.SecretStorage))
+			if location == secrets.VaultLocationKind || secrets.IsCloudSecretManager(location) {
+				secretsLocation = string(location)
 			}
 			modifyMapIfNotBlank(configMap.Data, kube.KubeProvider, requirements.Cluster.Provider)
 			modifyMapIfNotBlank(configMap.Data, kube.ProjectID, requirements.Cluster.ProjectID)
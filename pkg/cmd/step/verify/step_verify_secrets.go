@@ -0,0 +1,89 @@
+package verify
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/sealedsecrets"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifySecretsLong = templates.LongDesc(`
+		Verifies that an environment git repository does not contain any unsealed Kubernetes Secrets.
+
+		Secrets whose values are helm template expressions or secret URIs are allowed. Secrets with literal values
+		should be sealed with 'jx step secrets seal' before being committed.
+`)
+
+	verifySecretsExample = templates.Examples(`
+		# verify the current environment repository has no unsealed secrets
+		jx step verify secrets
+
+		# verify the environment repository in the env directory
+		jx step verify secrets --dir env
+	`)
+)
+
+// StepVerifySecretsOptions contains the command line flags
+type StepVerifySecretsOptions struct {
+	step.StepOptions
+
+	Dir string
+}
+
+// NewCmdStepVerifySecrets creates the `jx step verify secrets` command
+func NewCmdStepVerifySecrets(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepVerifySecretsOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "secrets",
+		Aliases: []string{"secret"},
+		Short:   "Verifies that an environment git repository does not contain any unsealed Secrets",
+		Long:    verifySecretsLong,
+		Example: verifySecretsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "The directory of the environment git repository")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepVerifySecretsOptions) Run() error {
+	err := verifyNoPlaintextSecrets(o.Dir)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("no unsealed Secrets found in %s\n", util.ColorInfo(o.Dir))
+	return nil
+}
+
+// verifyNoPlaintextSecrets returns an error if the directory contains any Secrets with literal values
+func verifyNoPlaintextSecrets(dir string) error {
+	secrets, err := sealedsecrets.FindPlaintextSecrets(dir)
+	if err != nil {
+		return errors.Wrapf(err, "finding the plain text Secrets in %s", dir)
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	for _, s := range secrets {
+		log.Logger().Errorf("found unsealed Secret %s", util.ColorError(s.String()))
+	}
+	return fmt.Errorf("found %d unsealed Secrets in %s. Please seal them with 'jx step secrets seal --dir %s'", len(secrets), dir, dir)
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 	"github.com/jenkins-x/jx/v2/pkg/config"
-	"github.com/jenkins-x/jx/v2/pkg/io/secrets"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/secreturl"
 	"github.com/jenkins-x/jx/v2/pkg/surveyutils"
@@ -176,7 +175,7 @@ func (o *StepVerifyValuesOptions) verifySchema(schema []byte, values []byte) err
 }
 
 func (o *StepVerifyValuesOptions) resolveSecrets(requirements *config.RequirementsConfig, values []byte) ([]byte, error) {
-	client, err := o.secretClient(requirements.SecretStorage)
+	client, err := o.secretClient(requirements)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating secret client")
 	}
@@ -187,13 +186,11 @@ func (o *StepVerifyValuesOptions) resolveSecrets(requirements *config.Requiremen
 	return []byte(result), nil
 }
 
-func (o *StepVerifyValuesOptions) secretClient(secretStorage config.SecretStorageType) (secreturl.Client, error) {
+func (o *StepVerifyValuesOptions) secretClient(requirements *config.RequirementsConfig) (secreturl.Client, error) {
 	if o.SecretClient != nil {
 		return o.SecretClient, nil
 	}
-
-	location := secrets.ToSecretsLocation(string(secretStorage))
-	return o.GetSecretURLClient(location)
+	return o.GetSecretURLClientForRequirements(requirements)
 }
 
 func convertYamlToJson(yml []byte) ([]byte, error) {
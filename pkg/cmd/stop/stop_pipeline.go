@@ -170,30 +170,20 @@ func (o *StopPipelineOptions) cancelPipelineRun() error {
 			if labels == nil {
 				continue
 			}
-			owner := labels[tekton.LabelOwner]
-			repo := labels[tekton.LabelRepo]
-			branch := labels[tekton.LabelBranch]
-			context := labels[tekton.LabelContext]
-			buildNumber := labels[tekton.LabelBuild]
-
-			if owner == "" {
+			if labels[tekton.LabelOwner] == "" {
 				log.Logger().Warnf("missing label %s on PipelineRun %s has labels %#v", tekton.LabelOwner, pr.Name, labels)
 				continue
 			}
-			if repo == "" {
+			if labels[tekton.LabelRepo] == "" {
 				log.Logger().Warnf("missing label %s on PipelineRun %s has labels %#v", tekton.LabelRepo, pr.Name, labels)
 				continue
 			}
-			if branch == "" {
+			if labels[tekton.LabelBranch] == "" {
 				log.Logger().Warnf("missing label %s on PipelineRun %s has labels %#v", tekton.LabelBranch, pr.Name, labels)
 				continue
 			}
 
-			name := fmt.Sprintf("%s/%s/%s #%s", owner, repo, branch, buildNumber)
-
-			if context != "" {
-				name = fmt.Sprintf("%s-%s", name, context)
-			}
+			name := PipelineRunName(&pr)
 			allNames = append(allNames, name)
 			m[name] = &pr
 		}
@@ -238,3 +228,13 @@ func (o *StopPipelineOptions) cancelPipelineRun() error {
 	}
 	return nil
 }
+
+// PipelineRunName returns the name of the PipelineRun to pass as an argument to stop it
+func PipelineRunName(pr *pipelineapi.PipelineRun) string {
+	labels := pr.Labels
+	name := fmt.Sprintf("%s/%s/%s #%s", labels[tekton.LabelOwner], labels[tekton.LabelRepo], labels[tekton.LabelBranch], labels[tekton.LabelBuild])
+	if context := labels[tekton.LabelContext]; context != "" {
+		name = fmt.Sprintf("%s-%s", name, context)
+	}
+	return name
+}
@@ -10,7 +10,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jenkins-x/jx/v2/pkg/auth"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/kube/services"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -30,6 +32,13 @@ type UIOptions struct {
 	OnlyViewURL  bool
 	HideURLLabel bool
 	LocalPort    string
+
+	TUI             bool
+	ShowCompleted   bool
+	RefreshInterval time.Duration
+
+	// OIDCSessionsFile the file containing the OIDC sessions of `jx login --oidc`, defaults to auth.OIDCAuthConfigFile
+	OIDCSessionsFile string
 }
 
 const (
@@ -42,13 +51,24 @@ var (
 		Opens the CloudBees JX UI in a browser.
 
 		Which helps you visualise your CI/CD pipelines.
+
+		If the UI is exposed via an Ingress and you have logged in to it via 'jx login --oidc <issuer> --ui-server <ui-url>'
+		the UI is opened via a local proxy which authenticates with your OIDC session, refreshing the access token when
+		it expires.
+
+		Use --tui to show a full screen dashboard of the running and queued pipelines in the terminal instead. Select a
+		pipeline to see its stages and the live log of the selected stage or step, press 's' to stop the pipeline or 'r'
+		to restart it.
 `)
 	core_example = templates.Examples(`
 		# Open the JX UI dashboard in a browser
 		jx ui
 
 		# Print the Jenkins X console URL but do not open a browser
-		jx ui -u`)
+		jx ui -u
+
+		# Show the running and queued pipelines in a terminal dashboard
+		jx ui --tui`)
 )
 
 // NewCmdUI creates the "jx ui" command
@@ -72,12 +92,18 @@ func NewCmdUI(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.OnlyViewURL, "url", "u", false, "Only displays the label and the URL and does not open the browser")
 	cmd.Flags().BoolVarP(&options.HideURLLabel, "hide-label", "l", false, "Hides the URL label from display")
 	cmd.Flags().StringVarP(&options.LocalPort, "local-port", "p", "", "The local port to forward the data to")
+	cmd.Flags().BoolVarP(&options.TUI, "tui", "", false, "Shows a dashboard of the pipelines in the terminal instead of opening the UI")
+	cmd.Flags().BoolVarP(&options.ShowCompleted, "completed", "", false, "Includes the completed pipelines in the terminal dashboard")
+	cmd.Flags().DurationVarP(&options.RefreshInterval, "refresh", "", time.Second, "The interval the terminal dashboard is refreshed at")
 
 	return cmd
 }
 
 // Run implements this command
 func (o *UIOptions) Run() error {
+	if o.TUI {
+		return o.RunTUI()
+	}
 	kubeClient, ns, err := o.KubeClientAndDevNamespace()
 	if err != nil {
 		return err
@@ -124,12 +150,27 @@ func (o *UIOptions) Run() error {
 		os.Exit(1)
 
 	} else {
+		uiURL := services.IngressURL(&ingressList.Items[0])
+		proxy, err := newOIDCUIProxy(uiURL, o.oidcSessionsFile())
+		if err != nil {
+			return err
+		}
+		if proxy != nil {
+			return o.runOIDCProxy(proxy)
+		}
 		log.Logger().Warn("Only single-user mode is available for the UI at this time")
 	}
 
 	return nil
 }
 
+func (o *UIOptions) oidcSessionsFile() string {
+	if o.OIDCSessionsFile != "" {
+		return o.OIDCSessionsFile
+	}
+	return auth.OIDCAuthConfigFile
+}
+
 func (o UIOptions) executePortForwardRoutine(serviceName string) {
 	outWriter := ioutil.Discard
 	if o.Verbose {
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// oidcUIProxy proxies the requests to the Jenkins X UI adding the OIDC access token of the user who logged in via
+// `jx login --oidc`, transparently refreshing it when it expires
+type oidcUIProxy struct {
+	uiURL       string
+	authService auth.ConfigService
+	proxy       *httputil.ReverseProxy
+
+	lock sync.Mutex
+	user *auth.UserAuth
+}
+
+// newOIDCUIProxy creates a proxy for the given UI URL using the OIDC sessions stored in the given file. Returns nil if
+// the user has not logged in to the UI via `jx login --oidc`
+func newOIDCUIProxy(uiURL string, fileName string) (*oidcUIProxy, error) {
+	if uiURL == "" {
+		return nil, nil
+	}
+	target, err := url.Parse(uiURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing the UI URL %s", uiURL)
+	}
+	authService, err := auth.NewOIDCAuthConfigService(fileName)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the OIDC auth config service")
+	}
+	p := &oidcUIProxy{
+		uiURL:       uiURL,
+		authService: authService,
+	}
+	_, err = p.bearerToken()
+	if err != nil {
+		log.Logger().Debugf("not using an OIDC session for the UI: %s", err)
+		return nil, nil
+	}
+	p.proxy = httputil.NewSingleHostReverseProxy(target)
+	director := p.proxy.Director
+	p.proxy.Director = func(req *http.Request) {
+		director(req)
+		// lets use the host of the UI so that the request is routed by the ingress controller
+		req.Host = target.Host
+	}
+	return p, nil
+}
+
+// bearerToken returns the OIDC access token for the UI, reloading the OIDC sessions to refresh it when it expires
+func (p *oidcUIProxy) bearerToken() (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.user != nil && !p.user.OIDCTokenExpired(time.Now()) {
+		return p.user.BearerToken, nil
+	}
+	config, err := p.authService.LoadConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "loading the OIDC sessions")
+	}
+	server := config.GetServer(p.uiURL)
+	if server == nil || server.CurrentAuth() == nil {
+		return "", fmt.Errorf("no OIDC session found for %s so please run 'jx login --oidc <issuer> --ui-server %s'", p.uiURL, p.uiURL)
+	}
+	p.user = server.CurrentAuth()
+	return p.user.BearerToken, nil
+}
+
+// ServeHTTP forwards the request to the UI with the OIDC access token of the user
+func (p *oidcUIProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, err := p.bearerToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+	p.proxy.ServeHTTP(w, r)
+}
+
+// runOIDCProxy opens the UI in the browser via a local proxy which authenticates the requests with the OIDC session
+// of the user until the command is interrupted
+func (o *UIOptions) runOIDCProxy(proxy *oidcUIProxy) error {
+	err := o.decideLocalForwardPort()
+	if err != nil {
+		return errors.Wrap(err, "there was an error obtaining the local port to forward to")
+	}
+	listener, err := net.Listen("tcp", "localhost:"+o.LocalPort)
+	if err != nil {
+		return errors.Wrapf(err, "listening on local port %s", o.LocalPort)
+	}
+	server := &http.Server{Handler: proxy}
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Logger().Errorf("failed to proxy the UI: %s", err)
+		}
+	}()
+	defer server.Close()
+
+	localURL := fmt.Sprintf("http://localhost:%s", o.LocalPort)
+	log.Logger().Infof("Proxying %s with your OIDC session", util.ColorInfo(proxy.uiURL))
+	err = o.openURL(localURL, "Jenkins X UI")
+	if err != nil {
+		return errors.Wrapf(err, "there was a problem opening the UI in the browser from address %s", util.ColorInfo(localURL))
+	}
+
+	// This channel will block until a SIGINT signal is received (Ctrl-c)
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	s := <-c
+	log.Logger().Debugf("Received signal %s", s.String())
+	log.Logger().Info("\nStopping the UI proxy")
+	return nil
+}
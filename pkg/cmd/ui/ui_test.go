@@ -3,12 +3,19 @@
 package ui
 
 import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/Netflix/go-expect"
 	"github.com/acarl005/stripansi"
 	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/auth"
 	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
@@ -16,6 +23,8 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/tests"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -49,6 +58,44 @@ func TestShowErrorMessageIfIngressFound(t *testing.T) {
 		"we should show a warning message informing that the UI is not ready to be launched in SSO / TLS mode")
 }
 
+func TestOIDCUIProxy(t *testing.T) {
+	t.Parallel()
+	uiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer uiServer.Close()
+
+	dir, err := ioutil.TempDir("", "test-ui-oidc-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, auth.OIDCAuthConfigFile)
+
+	proxy, err := newOIDCUIProxy(uiServer.URL, fileName)
+	require.NoError(t, err)
+	assert.Nil(t, proxy, "the proxy should not be used without an OIDC session for the UI")
+
+	svc, err := auth.NewOIDCAuthConfigService(fileName)
+	require.NoError(t, err)
+	config, err := svc.LoadConfig()
+	require.NoError(t, err)
+	config.GetOrCreateServerName(uiServer.URL, "", auth.OIDCUIServerKind)
+	user := &auth.UserAuth{Username: "jstrachan"}
+	user.SetOIDCToken("https://sso.example.com", auth.OIDCDefaultClientID, &oauth2.Token{
+		AccessToken:  "access-1",
+		RefreshToken: "refresh-1",
+		Expiry:       time.Now().Add(time.Hour),
+	})
+	require.NoError(t, svc.SaveUserAuth(uiServer.URL, user))
+
+	proxy, err = newOIDCUIProxy(uiServer.URL, fileName)
+	require.NoError(t, err)
+	require.NotNil(t, proxy)
+	recorder := httptest.NewRecorder()
+	proxy.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost:9000/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "Bearer access-1", recorder.Body.String(), "the OIDC access token should be added to the UI requests")
+}
+
 func TestGetLocalURL(t *testing.T) {
 	jxClient, kubeClient, co, ns := getFakeClientsAndNs(t)
 
@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/get"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/start"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/stop"
+	"github.com/jenkins-x/jx/v2/pkg/dashboard"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/logs"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/pkg/errors"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultServiceAccount the service account used to run the meta pipeline when restarting a pipeline
+const defaultServiceAccount = "tekton-bot"
+
+// tuiBackend implements dashboard.Backend using the pipeline cache and the jx stop and start pipeline commands
+type tuiBackend struct {
+	*UIOptions
+
+	cache        *kube.PipelineNamespaceCache
+	jxClient     versioned.Interface
+	tektonClient tektonclient.Interface
+	kubeClient   kubernetes.Interface
+	ns           string
+	logMasker    *kube.LogMasker
+	out          io.Writer
+}
+
+// RunTUI shows the terminal dashboard of the pipelines
+func (o *UIOptions) RunTUI() error {
+	if o.BatchMode {
+		return errors.New("the terminal dashboard cannot be used in batch mode")
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return errors.Wrap(err, "could not create the jx client")
+	}
+	tektonClient, _, err := o.TektonClient()
+	if err != nil {
+		return errors.Wrap(err, "could not create the tekton client")
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return errors.Wrap(err, "could not create the kube client")
+	}
+	logMasker, err := kube.NewLogMasker(kubeClient, ns)
+	if err != nil {
+		log.Logger().Debugf("failed to load all of the log masker secrets and patterns in namespace %s: %s", ns, err)
+	}
+
+	cache := kube.NewPipelineCache(jxClient, ns)
+	defer cache.Stop()
+
+	backend := &tuiBackend{
+		UIOptions:    o,
+		cache:        cache,
+		jxClient:     jxClient,
+		tektonClient: tektonClient,
+		kubeClient:   kubeClient,
+		ns:           ns,
+		logMasker:    logMasker,
+	}
+	d := &dashboard.Dashboard{
+		Backend:       backend,
+		Namespace:     ns,
+		ShowCompleted: o.ShowCompleted,
+	}
+
+	term, err := dashboard.OpenTerminal(os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	// show the log messages and the output of the stop and restart commands in the dashboard instead of drawing over it
+	backend.out = d.MessageWriter()
+	log.SetOutput(backend.out)
+	defer log.SetOutput(os.Stderr)
+	err = d.Run(term, o.RefreshInterval)
+	closeErr := term.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// Pipelines returns the pipelines from the cache
+func (b *tuiBackend) Pipelines() []*v1.PipelineActivity {
+	return b.cache.Pipelines()
+}
+
+// Structure returns the PipelineStructure of the PipelineRun of the pipeline
+func (b *tuiBackend) Structure(activity *v1.PipelineActivity) (*v1.PipelineStructure, error) {
+	pr, err := b.pipelineRun(activity)
+	if err != nil || pr == nil {
+		return nil, err
+	}
+	structure, err := tekton.StructureForPipelineRun(b.jxClient, b.ns, pr)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return structure, nil
+}
+
+// StreamLogs streams the logs of the running pipeline, until the stop channel is closed, or the archived logs of a
+// completed pipeline
+func (b *tuiBackend) StreamLogs(activity *v1.PipelineActivity, writer logs.LogWriter, stop <-chan struct{}) error {
+	logger := &logs.TektonLogger{
+		JXClient:     b.jxClient,
+		TektonClient: b.tektonClient,
+		KubeClient:   b.kubeClient,
+		Namespace:    b.ns,
+		LogWriter:    writer,
+		LogMasker:    b.logMasker,
+		Stop:         stop,
+	}
+	if activity.Spec.BuildLogsURL != "" && activity.Spec.Status.IsTerminated() {
+		authSvc, err := b.GitAuthConfigService()
+		if err != nil {
+			return err
+		}
+		return logger.StreamPipelinePersistentLogs(activity.Spec.BuildLogsURL, b.jxClient, b.ns, authSvc)
+	}
+	return logger.GetRunningBuildLogs(activity, fmt.Sprintf("%s #%s", activity.Spec.Pipeline, activity.Spec.Build), false)
+}
+
+// Stop stops the pipeline using jx stop pipeline
+func (b *tuiBackend) Stop(activity *v1.PipelineActivity) error {
+	options := &stop.StopPipelineOptions{
+		GetOptions: get.GetOptions{
+			CommonOptions: b.commonOptions(),
+		},
+	}
+	pr, err := b.pipelineRun(activity)
+	if err != nil {
+		return err
+	}
+	if pr != nil {
+		options.Args = []string{stop.PipelineRunName(pr)}
+	} else {
+		// pipelines without a PipelineRun are Jenkins jobs which are stopped by their build number
+		_, err = fmt.Sscanf(activity.Spec.Build, "%d", &options.Build)
+		if err != nil {
+			return errors.Wrapf(err, "invalid build number %s of pipeline %s", activity.Spec.Build, activity.Spec.Pipeline)
+		}
+		options.Args = []string{activity.Spec.Pipeline}
+	}
+	return options.Run()
+}
+
+// Restart starts a new run of the pipeline using jx start pipeline
+func (b *tuiBackend) Restart(activity *v1.PipelineActivity) error {
+	commonOpts := b.commonOptions()
+	if commonOpts.ServiceAccount == "" {
+		commonOpts.ServiceAccount = defaultServiceAccount
+	}
+	options := &start.StartPipelineOptions{
+		CommonOptions: commonOpts,
+	}
+	pr, err := b.pipelineRun(activity)
+	if err != nil {
+		return err
+	}
+	if pr != nil {
+		options.Context = pr.Labels[tekton.LabelContext]
+	}
+	options.Args = []string{activity.Spec.Pipeline}
+	return options.Run()
+}
+
+// commonOptions returns a copy of the common options which writes its errors to the dashboard. The actions run in the
+// background so each one uses its own copy
+func (b *tuiBackend) commonOptions() *opts.CommonOptions {
+	commonOpts := *b.CommonOptions
+	if b.out != nil {
+		commonOpts.Err = b.out
+	}
+	return &commonOpts
+}
+
+// pipelineRun returns the PipelineRun of the build pipeline of the activity, or its meta pipeline if the build
+// pipeline has not been created yet, or nil if the activity has no PipelineRun
+func (b *tuiBackend) pipelineRun(activity *v1.PipelineActivity) (*pipelineapi.PipelineRun, error) {
+	selector := []string{
+		fmt.Sprintf("%s=%s", tekton.LabelOwner, activity.Spec.GitOwner),
+		fmt.Sprintf("%s=%s", tekton.LabelRepo, activity.Spec.GitRepository),
+		fmt.Sprintf("%s=%s", tekton.LabelBranch, activity.Spec.GitBranch),
+		fmt.Sprintf("%s=%s", tekton.LabelBuild, activity.Spec.Build),
+	}
+	prList, err := b.tektonClient.TektonV1alpha1().PipelineRuns(b.ns).List(metav1.ListOptions{
+		LabelSelector: strings.Join(selector, ","),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the PipelineRuns of %s in namespace %s", activity.Name, b.ns)
+	}
+	var answer *pipelineapi.PipelineRun
+	for i := range prList.Items {
+		pr := &prList.Items[i]
+		if pr.Labels[tekton.LabelType] == tekton.MetaPipeline.String() {
+			if answer == nil {
+				answer = pr
+			}
+			continue
+		}
+		return pr, nil
+	}
+	return answer, nil
+}
@@ -56,6 +56,7 @@ func NewCmdUpgrade(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdUpgradePlatform(commonOpts))
 	cmd.AddCommand(NewCmdUpgradeApps(commonOpts))
 	cmd.AddCommand(NewCmdUpgradeCRDs(commonOpts))
+	cmd.AddCommand(NewCmdUpgradeEnv(commonOpts))
 	cmd.AddCommand(NewCmdUpgradeBoot(commonOpts))
 
 	return cmd
@@ -0,0 +1,230 @@
+package upgrade
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeEnvLong = templates.LongDesc(`
+		Upgrades Environments to the latest version of the blueprint they were created from in the version stream.
+
+		The charts and values of the blueprint are applied via a Pull Request on the Environment git repository. Once the
+		Pull Request is merged the role bindings and resource quota of the blueprint are updated in the cluster.
+`)
+
+	upgradeEnvExample = templates.Examples(`
+		# Upgrades the 'perf' Environment to the latest version of its blueprint
+		jx upgrade env perf
+
+		# Upgrades all the Environments which were created from a blueprint
+		jx upgrade env --all
+
+		# Applies the 'perf-test' blueprint to an existing Environment
+		jx upgrade env staging --blueprint perf-test
+	`)
+)
+
+// UpgradeEnvOptions the options for the upgrade env command
+type UpgradeEnvOptions struct {
+	UpgradeOptions
+
+	Blueprint           string
+	All                 bool
+	Force               bool
+	AutoMerge           bool
+	Timeout             string
+	PullRequestPollTime string
+}
+
+// NewCmdUpgradeEnv defines the command
+func NewCmdUpgradeEnv(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &UpgradeEnvOptions{
+		UpgradeOptions: UpgradeOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "environment [names]",
+		Short:   "Upgrades Environments to the latest version of their blueprint",
+		Aliases: []string{"env", "envs", "environments"},
+		Long:    upgradeEnvLong,
+		Example: upgradeEnvExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Blueprint, "blueprint", "", "", "The name of the blueprint to apply. Defaults to the blueprint the Environment was created from")
+	cmd.Flags().BoolVarP(&options.All, "all", "", false, "Upgrades all the Environments which were created from a blueprint")
+	cmd.Flags().BoolVarP(&options.Force, "force", "", false, "Re-applies the blueprint even if the Environment is already on the latest version")
+	cmd.Flags().BoolVarP(&options.AutoMerge, "auto-merge", "", false, "Automatically merge the Pull Request if it passes the pipeline checks")
+	cmd.Flags().StringVarP(&options.Timeout, opts.OptionTimeout, "", "1h", "The timeout to wait for the Pull Request to be merged before the resources of the blueprint are applied")
+	cmd.Flags().StringVarP(&options.PullRequestPollTime, "pull-request-poll-time", "", "20s", "Poll time when waiting for the Pull Request to be merged")
+	return cmd
+}
+
+// Run implements the command
+func (o *UpgradeEnvOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+
+	envs := []*v1.Environment{}
+	if len(o.Args) > 0 {
+		for _, name := range o.Args {
+			env, err := kube.GetEnvironment(jxClient, ns, name)
+			if err != nil {
+				return errors.Wrapf(err, "getting Environment %s", name)
+			}
+			envs = append(envs, env)
+		}
+	} else if o.All {
+		permanentEnvs, err := kube.GetPermanentEnvironments(jxClient, ns)
+		if err != nil {
+			return errors.Wrap(err, "getting the permanent Environments")
+		}
+		for _, env := range permanentEnvs {
+			if environments.BlueprintName(env) != "" {
+				envs = append(envs, env)
+			}
+		}
+		if len(envs) == 0 {
+			log.Logger().Infof("No Environments were created from a blueprint")
+			return nil
+		}
+	} else {
+		return fmt.Errorf("please specify the names of the Environments to upgrade or use the --all option")
+	}
+
+	timeout, err := time.ParseDuration(o.Timeout)
+	if err != nil {
+		return errors.Wrapf(err, "invalid duration format %s for option --%s", o.Timeout, opts.OptionTimeout)
+	}
+	pollTime, err := time.ParseDuration(o.PullRequestPollTime)
+	if err != nil {
+		return errors.Wrapf(err, "invalid duration format %s for option --pull-request-poll-time", o.PullRequestPollTime)
+	}
+
+	resolver, err := o.GetVersionResolver()
+	if err != nil {
+		return errors.Wrap(err, "creating the version resolver")
+	}
+	for _, env := range envs {
+		name := o.Blueprint
+		if name == "" {
+			name = environments.BlueprintName(env)
+		}
+		if name == "" {
+			return fmt.Errorf("Environment %s was not created from a blueprint. Please specify the blueprint to apply via the --blueprint option", env.Name)
+		}
+		blueprint, err := versionstream.LoadEnvironmentBlueprint(resolver.VersionsDir, name)
+		if err != nil {
+			return errors.Wrapf(err, "loading blueprint %s", name)
+		}
+		if !o.Force && name == environments.BlueprintName(env) && blueprint.Version == environments.BlueprintVersion(env) {
+			log.Logger().Infof("Environment %s is already on version %s of blueprint %s", util.ColorInfo(env.Name), util.ColorInfo(blueprint.Version), util.ColorInfo(name))
+			continue
+		}
+		gitProvider, pr, err := o.upgradeEnvironment(env, blueprint)
+		if err != nil {
+			return errors.Wrapf(err, "upgrading Environment %s", env.Name)
+		}
+		if pr != nil {
+			// the resources must not be ahead of the charts and values of the blueprint so wait for them to be merged
+			err = waitForPullRequestMerge(gitProvider, pr, timeout, pollTime)
+			if err != nil {
+				return errors.Wrapf(err, "the resources of blueprint %s were not applied to Environment %s", name, env.Name)
+			}
+		}
+		err = environments.ApplyBlueprintResources(kubeClient, jxClient, ns, env, blueprint)
+		if err != nil {
+			return errors.Wrapf(err, "applying the resources of blueprint %s to Environment %s", name, env.Name)
+		}
+		err = o.ModifyEnvironment(env.Name, func(env *v1.Environment) error {
+			environments.SetBlueprintAnnotations(env, blueprint)
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "updating Environment %s", env.Name)
+		}
+	}
+	return nil
+}
+
+// upgradeEnvironment creates a Pull Request on the environment git repository to apply the charts and values of the
+// blueprint returning the git provider and the Pull Request, if one was created
+func (o *UpgradeEnvOptions) upgradeEnvironment(env *v1.Environment, blueprint *versionstream.EnvironmentBlueprint) (gits.GitProvider, *gits.GitPullRequest, error) {
+	gitURL := env.Spec.Source.URL
+	if gitURL == "" {
+		log.Logger().Warnf("Environment %s does not use GitOps so the charts and values of blueprint %s are not applied", env.Name, blueprint.Name)
+		return nil, nil, nil
+	}
+	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(gitURL)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "creating git provider for %s", gitURL)
+	}
+	options := environments.EnvironmentPullRequestOptions{
+		Gitter:        o.Git(),
+		GitProvider:   gitProvider,
+		ModifyChartFn: environments.BlueprintModifyChartFn(blueprint),
+	}
+	details := gits.PullRequestDetails{
+		BranchName: "blueprint-" + blueprint.Name + "-" + blueprint.Version,
+		Title:      fmt.Sprintf("chore: upgrade to blueprint %s version %s", blueprint.Name, blueprint.Version),
+		Message:    fmt.Sprintf("chore: upgrade Environment %s to version %s of blueprint %s", env.Name, blueprint.Version, blueprint.Name),
+	}
+	info, err := options.Create(env, "", &details, nil, "", o.AutoMerge)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "creating Pull Request on %s", gitURL)
+	}
+	if info == nil || info.PullRequest == nil {
+		return gitProvider, nil, nil
+	}
+	log.Logger().Infof("Created Pull Request %s to upgrade Environment %s to blueprint %s version %s", util.ColorInfo(info.PullRequest.URL),
+		util.ColorInfo(env.Name), util.ColorInfo(blueprint.Name), util.ColorInfo(blueprint.Version))
+	return gitProvider, info.PullRequest, nil
+}
+
+// waitForPullRequestMerge polls the Pull Request until it is merged returning an error if it is closed without being
+// merged or the timeout expires
+func waitForPullRequestMerge(gitProvider gits.GitProvider, pr *gits.GitPullRequest, timeout time.Duration, pollTime time.Duration) error {
+	end := time.Now().Add(timeout)
+	log.Logger().Infof("Waiting for Pull Request %s to be merged", util.ColorInfo(pr.URL))
+	for {
+		err := gitProvider.UpdatePullRequestStatus(pr)
+		if err != nil {
+			log.Logger().Warnf("Failed to query the Pull Request status for %s: %s", pr.URL, err)
+		} else if pr.Merged != nil && *pr.Merged {
+			log.Logger().Infof("Pull Request %s is merged", util.ColorInfo(pr.URL))
+			return nil
+		} else if pr.IsClosed() {
+			return fmt.Errorf("Pull Request %s was closed without being merged", pr.URL)
+		}
+		if time.Now().After(end) {
+			return fmt.Errorf("timed out after %s waiting for Pull Request %s to be merged", timeout.String(), pr.URL)
+		}
+		time.Sleep(pollTime)
+	}
+}
@@ -0,0 +1,41 @@
+// +build unit
+
+package upgrade
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForPullRequestMerge(t *testing.T) {
+	t.Parallel()
+
+	repo, err := gits.NewFakeRepository("jstrachan", "environment-perf", nil, nil)
+	require.NoError(t, err)
+	gitProvider := gits.NewFakeProvider(repo)
+	number := 1
+	pr := &gits.GitPullRequest{
+		URL:    "https://fake.git/jstrachan/environment-perf/pull/1",
+		Owner:  "jstrachan",
+		Repo:   "environment-perf",
+		Number: &number,
+	}
+	repo.PullRequests[number] = &gits.FakePullRequest{PullRequest: pr}
+
+	err = waitForPullRequestMerge(gitProvider, pr, 0, time.Millisecond)
+	assert.Error(t, err, "the open Pull Request should time out")
+
+	closed := time.Now()
+	pr.ClosedAt = &closed
+	err = waitForPullRequestMerge(gitProvider, pr, time.Minute, time.Millisecond)
+	assert.Error(t, err, "the Pull Request was closed without being merged")
+
+	merged := true
+	pr.Merged = &merged
+	err = waitForPullRequestMerge(gitProvider, pr, time.Minute, time.Millisecond)
+	assert.NoError(t, err)
+}
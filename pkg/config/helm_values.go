@@ -19,6 +19,7 @@ type ExposeControllerConfig struct {
 	URLTemplate   string `json:"urltemplate,omitempty"`
 	IngressClass  string `json:"ingressClass,omitempty"`
 	TLSSecretName string `json:"tlsSecretName,omitempty"`
+	Issuer        string `json:"issuer,omitempty"`
 }
 type ExposeController struct {
 	Config      ExposeControllerConfig `json:"config,omitempty"`
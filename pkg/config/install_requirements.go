@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -130,10 +131,18 @@ const (
 	// SecretStorageTypeLocal specifies that we use the local file system in
 	// `~/.jx/localSecrets` to store secrets
 	SecretStorageTypeLocal SecretStorageType = "local"
+	// SecretStorageTypeKube specifies that we use Kubernetes Secrets to store secrets
+	SecretStorageTypeKube SecretStorageType = "kube"
+	// SecretStorageTypeAWSSecretsManager specifies that we use AWS Secrets Manager to store secrets
+	SecretStorageTypeAWSSecretsManager SecretStorageType = "awssm"
+	// SecretStorageTypeGCPSecretManager specifies that we use GCP Secret Manager to store secrets
+	SecretStorageTypeGCPSecretManager SecretStorageType = "gcpsm"
+	// SecretStorageTypeAzureKeyVault specifies that we use Azure Key Vault to store secrets
+	SecretStorageTypeAzureKeyVault SecretStorageType = "azurekv"
 )
 
 // SecretStorageTypeValues the string values for the secret storage
-var SecretStorageTypeValues = []string{"local", "vault"}
+var SecretStorageTypeValues = []string{"local", "vault", "kube", "awssm", "gcpsm", "azurekv"}
 
 // WebhookType is the type of a webhook strategy
 type WebhookType string
@@ -254,6 +263,53 @@ type TLSConfig struct {
 	Production bool `json:"production"`
 	// SecretName the name of the secret which contains the TLS certificate
 	SecretName string `json:"secretName,omitempty"`
+	// Issuer the cert-manager issuer used to issue the certificates. Defaults to the LetsEncrypt staging or
+	// production issuer depending on the Production flag
+	Issuer *TLSIssuerConfig `json:"issuer,omitempty"`
+}
+
+// TLSIssuerKind the kind of cert-manager issuer used to issue the TLS certificates
+type TLSIssuerKind string
+
+const (
+	// TLSIssuerKindLetsEncrypt uses the LetsEncrypt staging or production ACME servers
+	TLSIssuerKindLetsEncrypt TLSIssuerKind = "letsencrypt"
+	// TLSIssuerKindCA signs the certificates using a CA certificate and key stored in a Secret
+	TLSIssuerKindCA TLSIssuerKind = "ca"
+	// TLSIssuerKindVault signs the certificates using a Vault PKI secrets engine
+	TLSIssuerKindVault TLSIssuerKind = "vault"
+	// TLSIssuerKindACME uses a custom ACME server such as an internal step-ca
+	TLSIssuerKindACME TLSIssuerKind = "acme"
+)
+
+// TLSIssuerKindValues the string values for the TLS issuer kinds
+var TLSIssuerKindValues = []string{"letsencrypt", "ca", "vault", "acme"}
+
+// TLSIssuerConfig contains the configuration of the cert-manager issuer used to issue the TLS certificates
+type TLSIssuerConfig struct {
+	// Kind the kind of issuer. Defaults to "letsencrypt"
+	Kind TLSIssuerKind `json:"kind,omitempty"`
+	// Name the name of the cert-manager issuer. Defaults to a name based on the kind
+	Name string `json:"name,omitempty"`
+	// Server the ACME directory URL for the "acme" kind or the Vault server URL for the "vault" kind
+	Server string `json:"server,omitempty"`
+	// SkipTLSVerify if the TLS certificate of the ACME server should not be verified
+	SkipTLSVerify bool `json:"skipTLSVerify,omitempty"`
+	// SecretName the name of the Secret containing the CA certificate and key for the "ca" kind
+	SecretName string `json:"secretName,omitempty"`
+	// Path the Vault PKI signing path such as "pki_int/sign/example-dot-com" for the "vault" kind
+	Path string `json:"path,omitempty"`
+	// TokenSecretName the name of the Secret containing the Vault token in the "token" key
+	TokenSecretName string `json:"tokenSecretName,omitempty"`
+	// AppRoleID the Vault AppRole ID used to authenticate if not using a token
+	AppRoleID string `json:"appRoleId,omitempty"`
+	// AppRoleSecretName the name of the Secret containing the Vault AppRole secret ID in the "secretId" key
+	AppRoleSecretName string `json:"appRoleSecretName,omitempty"`
+	// AppRolePath the path where the Vault AppRole auth method is mounted. Defaults to "approle"
+	AppRolePath string `json:"appRolePath,omitempty"`
+	// CABundle the PEM encoded root CA certificates used to verify the issued certificates and the ACME or Vault
+	// server if they are not publicly trusted
+	CABundle string `json:"caBundle,omitempty"`
 }
 
 // JxInstallProfile contains the jx profile info
@@ -365,6 +421,18 @@ type VaultConfig struct {
 	AWSConfig           *VaultAWSConfig `json:"aws,omitempty"`
 }
 
+// SecretManagerConfig contains the configuration of the cloud secret manager used to store secrets
+type SecretManagerConfig struct {
+	// Prefix the optional prefix of the secret names so that several clusters can share a secret manager
+	Prefix string `json:"prefix,omitempty"`
+	// Region the AWS region of AWS Secrets Manager. Defaults to the cluster region
+	Region string `json:"region,omitempty"`
+	// Project the GCP project of GCP Secret Manager. Defaults to the cluster project
+	Project string `json:"project,omitempty"`
+	// KeyVault the name or URL of the Azure Key Vault
+	KeyVault string `json:"keyVault,omitempty"`
+}
+
 // VaultAWSConfig contains all the Vault configuration needed by Vault to be deployed in AWS
 type VaultAWSConfig struct {
 	VaultAWSUnsealConfig
@@ -491,6 +559,8 @@ type RequirementsConfig struct {
 	Ingress IngressConfig `json:"ingress"`
 	// Repository specifies what kind of artifact repository you wish to use for storing artifacts (jars, tarballs, npm modules etc)
 	Repository RepositoryType `json:"repository,omitempty"`
+	// SecretManager the configuration of the cloud secret manager if using a cloud secret manager for secrets
+	SecretManager SecretManagerConfig `json:"secretManager,omitempty"`
 	// SecretStorage how should we store secrets for the cluster
 	SecretStorage SecretStorageType `json:"secretStorage,omitempty"`
 	// Storage contains storage requirements
@@ -806,6 +876,57 @@ func (c *RequirementsConfig) handleDeprecation() {
 	}
 }
 
+// IssuerKind returns the kind of issuer used to issue the TLS certificates
+func (c *TLSConfig) IssuerKind() TLSIssuerKind {
+	if c.Issuer == nil || c.Issuer.Kind == "" {
+		return TLSIssuerKindLetsEncrypt
+	}
+	return c.Issuer.Kind
+}
+
+// RequiresEmail returns true if the issuer registers an ACME account which requires an email address
+func (c *TLSConfig) RequiresEmail() bool {
+	kind := c.IssuerKind()
+	return kind == TLSIssuerKindLetsEncrypt || kind == TLSIssuerKindACME
+}
+
+// Validate validates the issuer configuration has the fields required by its kind
+func (c *TLSIssuerConfig) Validate() error {
+	switch c.Kind {
+	case "", TLSIssuerKindLetsEncrypt:
+		if c.Server != "" {
+			return fmt.Errorf("the TLS issuer server can only be specified for the kinds %s or %s", TLSIssuerKindACME, TLSIssuerKindVault)
+		}
+	case TLSIssuerKindCA:
+		if c.SecretName == "" {
+			return fmt.Errorf("the TLS issuer kind %s requires the secretName of the CA key pair", c.Kind)
+		}
+	case TLSIssuerKindACME:
+		if c.Server == "" {
+			return fmt.Errorf("the TLS issuer kind %s requires the ACME directory server URL", c.Kind)
+		}
+		if _, err := url.ParseRequestURI(c.Server); err != nil {
+			return errors.Wrapf(err, "invalid ACME server URL %s", c.Server)
+		}
+	case TLSIssuerKindVault:
+		if c.Server == "" || c.Path == "" {
+			return fmt.Errorf("the TLS issuer kind %s requires the Vault server URL and the PKI signing path", c.Kind)
+		}
+		if _, err := url.ParseRequestURI(c.Server); err != nil {
+			return errors.Wrapf(err, "invalid Vault server URL %s", c.Server)
+		}
+		if (c.TokenSecretName == "") == (c.AppRoleID == "") {
+			return fmt.Errorf("the TLS issuer kind %s requires either a tokenSecretName or an appRoleId", c.Kind)
+		}
+		if c.AppRoleID != "" && c.AppRoleSecretName == "" {
+			return fmt.Errorf("the TLS issuer kind %s requires the appRoleSecretName when using an appRoleId", c.Kind)
+		}
+	default:
+		return util.InvalidOption("kind", string(c.Kind), TLSIssuerKindValues)
+	}
+	return nil
+}
+
 // IsAutoDNSDomain returns true if the domain is configured to use an auto DNS sub domain like
 // '.nip.io' or '.xip.io'
 func (i *IngressConfig) IsAutoDNSDomain() bool {
@@ -419,3 +419,33 @@ func TestLoadRequirementsConfig_load_invalid_yaml(t *testing.T) {
 	requirementsConfigPath := path.Join(absolute, config.RequirementsConfigFileName)
 	assert.EqualError(t, err, fmt.Sprintf("validation failures in YAML file %s:\nenvironments.0: Additional property namespace is not allowed", requirementsConfigPath))
 }
+
+func TestTLSIssuerConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		issuer config.TLSIssuerConfig
+		valid  bool
+	}{
+		{"letsencrypt", config.TLSIssuerConfig{}, true},
+		{"letsencrypt with server", config.TLSIssuerConfig{Server: "https://acme.example.com"}, false},
+		{"ca", config.TLSIssuerConfig{Kind: config.TLSIssuerKindCA, SecretName: "ca-key-pair"}, true},
+		{"ca without secret", config.TLSIssuerConfig{Kind: config.TLSIssuerKindCA}, false},
+		{"acme", config.TLSIssuerConfig{Kind: config.TLSIssuerKindACME, Server: "https://ca.internal/acme/directory"}, true},
+		{"acme invalid server", config.TLSIssuerConfig{Kind: config.TLSIssuerKindACME, Server: "ca.internal"}, false},
+		{"vault token", config.TLSIssuerConfig{Kind: config.TLSIssuerKindVault, Server: "https://vault.internal", Path: "pki/sign/jx", TokenSecretName: "vault-token"}, true},
+		{"vault approle", config.TLSIssuerConfig{Kind: config.TLSIssuerKindVault, Server: "https://vault.internal", Path: "pki/sign/jx", AppRoleID: "jx", AppRoleSecretName: "vault-approle"}, true},
+		{"vault without auth", config.TLSIssuerConfig{Kind: config.TLSIssuerKindVault, Server: "https://vault.internal", Path: "pki/sign/jx"}, false},
+		{"vault approle without secret", config.TLSIssuerConfig{Kind: config.TLSIssuerKindVault, Server: "https://vault.internal", Path: "pki/sign/jx", AppRoleID: "jx"}, false},
+		{"unknown kind", config.TLSIssuerConfig{Kind: "selfsigned"}, false},
+	}
+	for _, tc := range testCases {
+		err := tc.issuer.Validate()
+		if tc.valid {
+			assert.NoError(t, err, tc.name)
+		} else {
+			assert.Error(t, err, tc.name)
+		}
+	}
+}
@@ -307,7 +307,7 @@ func (in *EnabledConfig) DeepCopy() *EnabledConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvironmentConfig) DeepCopyInto(out *EnvironmentConfig) {
 	*out = *in
-	out.Ingress = in.Ingress
+	in.Ingress.DeepCopyInto(&out.Ingress)
 	return
 }
 
@@ -501,7 +501,7 @@ func (in *Image) DeepCopy() *Image {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressConfig) DeepCopyInto(out *IngressConfig) {
 	*out = *in
-	out.TLS = in.TLS
+	in.TLS.DeepCopyInto(&out.TLS)
 	return
 }
 
@@ -901,7 +901,7 @@ func (in *RequirementsConfig) DeepCopyInto(out *RequirementsConfig) {
 		*out = new(GithubAppConfig)
 		**out = **in
 	}
-	out.Ingress = in.Ingress
+	in.Ingress.DeepCopyInto(&out.Ingress)
 	out.Storage = in.Storage
 	in.Vault.DeepCopyInto(&out.Vault)
 	out.Velero = in.Velero
@@ -979,6 +979,11 @@ func (in *StorageEntryConfig) DeepCopy() *StorageEntryConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSConfig) DeepCopyInto(out *TLSConfig) {
 	*out = *in
+	if in.Issuer != nil {
+		in, out := &in.Issuer, &out.Issuer
+		*out = new(TLSIssuerConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -992,6 +997,22 @@ func (in *TLSConfig) DeepCopy() *TLSConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSIssuerConfig) DeepCopyInto(out *TLSIssuerConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSIssuerConfig.
+func (in *TLSIssuerConfig) DeepCopy() *TLSIssuerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSIssuerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultAWSConfig) DeepCopyInto(out *VaultAWSConfig) {
 	*out = *in
@@ -72,10 +72,32 @@ func NewAnchoreProvider(server *auth.AuthServer, user *auth.UserAuth) (CVEProvid
 }
 
 func (a AnchoreProvider) GetImageVulnerabilityTable(jxClient versioned.Interface, client kubernetes.Interface, table *table.Table, query CVEQuery) error {
+	vulnerabilities, err := a.GetImageVulnerabilities(jxClient, client, query)
+	if err != nil {
+		return err
+	}
+	for _, v := range vulnerabilities {
+		var sev string
+		switch v.Severity {
+		case "High":
+			sev = util.ColorError(v.Severity)
+		case "Medium":
+			sev = util.ColorWarning(v.Severity)
+		case "Low":
+			sev = util.ColorStatus(v.Severity)
+		}
+		table.AddRow(v.Image, sev, v.Vulnerability, v.URL, v.Package, v.Fix)
+	}
+	return nil
+}
+
+// GetImageVulnerabilities returns the vulnerabilities of the images matching the query
+func (a AnchoreProvider) GetImageVulnerabilities(jxClient versioned.Interface, client kubernetes.Interface, query CVEQuery) ([]ImageVulnerability, error) {
 
 	var err error
 	var vList VulnerabilityList
 	var imageIDs []string
+	answer := []ImageVulnerability{}
 
 	if query.ImageID != "" {
 		var vList VulnerabilityList
@@ -83,10 +105,10 @@ func (a AnchoreProvider) GetImageVulnerabilityTable(jxClient versioned.Interface
 
 		err = a.AnchoreGet(subPath, &vList)
 		if err != nil {
-			return fmt.Errorf("error getting vulnerabilities for image %s: %v", query.ImageID, err)
+			return nil, fmt.Errorf("error getting vulnerabilities for image %s: %v", query.ImageID, err)
 		}
 
-		return a.addVulnerabilitiesTableRows(table, &vList)
+		return a.addVulnerabilities(answer, &vList)
 	}
 
 	if query.Environment != "" {
@@ -95,7 +117,7 @@ func (a AnchoreProvider) GetImageVulnerabilityTable(jxClient versioned.Interface
 		// list pods in the namespace
 		podList, err := client.CoreV1().Pods(query.TargetNamespace).List(meta_v1.ListOptions{})
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// if they have the annotation add the value to a list
 		for _, p := range podList.Items {
@@ -103,10 +125,10 @@ func (a AnchoreProvider) GetImageVulnerabilityTable(jxClient versioned.Interface
 				imageIDs = append(imageIDs, p.Annotations[AnnotationCVEImageId])
 			}
 		}
-		// loop over the list and get the CVEs for each, adding the rows
-		err = a.getCVEsFromImageList(table, &vList, imageIDs)
+		// loop over the list and get the CVEs for each
+		answer, err = a.getCVEsFromImageList(answer, &vList, imageIDs)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -120,7 +142,7 @@ func (a AnchoreProvider) GetImageVulnerabilityTable(jxClient versioned.Interface
 
 			err = a.AnchoreGet(subPath, &images)
 			if err != nil {
-				return fmt.Errorf("error getting images %v", err)
+				return nil, fmt.Errorf("error getting images %v", err)
 			}
 
 			for _, image := range images {
@@ -135,19 +157,19 @@ func (a AnchoreProvider) GetImageVulnerabilityTable(jxClient versioned.Interface
 				}
 			}
 			if len(imageIDs) > 0 {
-				err = a.getCVEsFromImageList(table, &vList, imageIDs)
+				answer, err = a.getCVEsFromImageList(answer, &vList, imageIDs)
 				if err != nil {
-					return err
+					return nil, err
 				}
 			} else {
-				return fmt.Errorf("no matching images found for ImageName %s and Vesion %s", query.ImageName, query.Vesion)
+				return nil, fmt.Errorf("no matching images found for ImageName %s and Vesion %s", query.ImageName, query.Vesion)
 			}
 		}
 	} else {
-		return fmt.Errorf("choose an image name, an optinal version or anchore image id to find vulnerabilities")
+		return nil, fmt.Errorf("choose an image name, an optinal version or anchore image id to find vulnerabilities")
 	}
 
-	return nil
+	return answer, nil
 
 }
 
@@ -175,45 +197,43 @@ func (a AnchoreProvider) AnchoreGet(subPath string, rs result) error {
 	return nil
 }
 
-func (a AnchoreProvider) addVulnerabilitiesTableRows(table *table.Table, vList *VulnerabilityList) error {
+func (a AnchoreProvider) addVulnerabilities(answer []ImageVulnerability, vList *VulnerabilityList) ([]ImageVulnerability, error) {
 
 	var image []Image
 	subPath := fmt.Sprintf(getVulnerabilitiesByImageDigest, vList.ImageDigest)
 
 	err := a.AnchoreGet(subPath, &image)
 	if err != nil {
-		return fmt.Errorf("error getting image for image digest %s: %v", vList.ImageDigest, err)
+		return nil, fmt.Errorf("error getting image for image digest %s: %v", vList.ImageDigest, err)
 	}
 	// TODO sort vList on severity and version?
 
 	for _, v := range vList.Vulnerabilities {
-		var sev string
-		switch v.Severity {
-		case "High":
-			sev = util.ColorError(v.Severity)
-		case "Medium":
-			sev = util.ColorWarning(v.Severity)
-		case "Low":
-			sev = util.ColorStatus(v.Severity)
-		}
-		table.AddRow(image[0].ImageDetails[0].Fulltag, sev, v.Vuln, v.URL, v.Package, v.Fix)
+		answer = append(answer, ImageVulnerability{
+			Image:         image[0].ImageDetails[0].Fulltag,
+			Severity:      v.Severity,
+			Vulnerability: v.Vuln,
+			URL:           v.URL,
+			Package:       v.Package,
+			Fix:           v.Fix,
+		})
 	}
-	return nil
+	return answer, nil
 }
 
-func (a AnchoreProvider) getCVEsFromImageList(table *table.Table, vList *VulnerabilityList, ids []string) error {
+func (a AnchoreProvider) getCVEsFromImageList(answer []ImageVulnerability, vList *VulnerabilityList, ids []string) ([]ImageVulnerability, error) {
 	for _, imageID := range ids {
 		subPath := fmt.Sprintf(getVulnerabilitiesByImageID, imageID, vulnerabilityType)
 
 		err := a.AnchoreGet(subPath, &vList)
 		if err != nil {
-			return fmt.Errorf("error getting vulnerabilities for image %s: %v", imageID, err)
+			return nil, fmt.Errorf("error getting vulnerabilities for image %s: %v", imageID, err)
 		}
 
-		err = a.addVulnerabilitiesTableRows(table, vList)
+		answer, err = a.addVulnerabilities(answer, vList)
 		if err != nil {
-			return fmt.Errorf("error building vulnerabilities table for image digest %s: %v", vList.ImageDigest, err)
+			return nil, fmt.Errorf("error building vulnerabilities for image digest %s: %v", vList.ImageDigest, err)
 		}
 	}
-	return nil
+	return answer, nil
 }
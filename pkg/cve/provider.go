@@ -17,6 +17,19 @@ type CVEQuery struct {
 	Environment     string
 	TargetNamespace string
 }
+
+// ImageVulnerability a vulnerability found in an image
+type ImageVulnerability struct {
+	Image         string `json:"image"`
+	Severity      string `json:"severity"`
+	Vulnerability string `json:"vulnerability"`
+	URL           string `json:"url"`
+	Package       string `json:"package"`
+	Fix           string `json:"fix"`
+}
+
 type CVEProvider interface {
 	GetImageVulnerabilityTable(jxClient versioned.Interface, client kubernetes.Interface, table *table.Table, query CVEQuery) error
+	// GetImageVulnerabilities returns the vulnerabilities of the images matching the query
+	GetImageVulnerabilities(jxClient versioned.Interface, client kubernetes.Interface, query CVEQuery) ([]ImageVulnerability, error)
 }
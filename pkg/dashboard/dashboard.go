@@ -0,0 +1,638 @@
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/acarl005/stripansi"
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/logs"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	reverseVideo = "\x1b[7m"
+	resetStyle   = "\x1b[0m"
+
+	footerHelp = "j/k move  tab switch pane  enter select  s stop  r restart  a toggle completed  pgup/pgdn scroll logs  G follow  q quit"
+
+	// messagesLimit the number of log messages kept for the messages pane
+	messagesLimit = 100
+	// messageRows the maximum number of rows of the messages pane
+	messageRows = 3
+	// minimumLogRows the minimum number of rows of the stages and logs when the messages pane is shown
+	minimumLogRows = 3
+)
+
+// Backend provides the pipelines shown in the dashboard and performs the actions on them
+type Backend interface {
+	// Pipelines returns the current pipelines
+	Pipelines() []*v1.PipelineActivity
+
+	// Structure returns the PipelineStructure of the pipeline or nil if it has not been created yet
+	Structure(activity *v1.PipelineActivity) (*v1.PipelineStructure, error)
+
+	// StreamLogs writes the log of the pipeline to the writer blocking until the pipeline has completed or the stop
+	// channel is closed
+	StreamLogs(activity *v1.PipelineActivity, writer logs.LogWriter, stop <-chan struct{}) error
+
+	// Stop stops the running pipeline
+	Stop(activity *v1.PipelineActivity) error
+
+	// Restart triggers a new run of the pipeline
+	Restart(activity *v1.PipelineActivity) error
+}
+
+type pane int
+
+const (
+	pipelinesPane pane = iota
+	stagesPane
+)
+
+// action a pending action which needs confirming
+type action struct {
+	prompt   string
+	progress string
+	run      func() (string, error)
+}
+
+// actionResult the result of a confirmed action which has been run in the background
+type actionResult struct {
+	message string
+	err     error
+}
+
+// Dashboard is the state of the terminal dashboard of the pipelines
+type Dashboard struct {
+	Backend       Backend
+	Namespace     string
+	ShowCompleted bool
+	LogLimit      int
+
+	pipelines    []*v1.PipelineActivity
+	selected     string
+	pipelineTop  int
+	structure    *v1.PipelineStructure
+	structureFor string
+	tree         []TreeNode
+	treeIndex    int
+	treeTop      int
+	logs         *LogBuffer
+	logsFor      string
+	logsStop     chan struct{}
+	logScroll    int
+	logHeight    int
+	focus        pane
+	confirm      *action
+	message      string
+	running      bool
+	results      chan actionResult
+	messages     *LogBuffer
+}
+
+// Refresh reloads the pipelines and the stage tree and log of the selected pipeline
+func (d *Dashboard) Refresh() {
+	d.pipelines = ActivePipelines(d.Backend.Pipelines(), d.ShowCompleted)
+	activity := d.selectedPipeline()
+	if activity == nil {
+		if len(d.pipelines) == 0 {
+			d.selected = ""
+			d.tree = nil
+			return
+		}
+		activity = d.pipelines[0]
+		d.selected = activity.Name
+	}
+
+	if d.structureFor != activity.Name {
+		d.structure = nil
+		d.structureFor = activity.Name
+		d.treeIndex = 0
+		d.treeTop = 0
+	}
+	if d.structure == nil {
+		structure, err := d.Backend.Structure(activity)
+		if err != nil {
+			d.message = fmt.Sprintf("failed to get the structure of %s: %s", activity.Name, err)
+		}
+		d.structure = structure
+	}
+	d.tree = StageTree(d.structure, activity)
+	if d.treeIndex >= len(d.tree) {
+		d.treeIndex = len(d.tree) - 1
+	}
+
+	restart := d.logsFor != activity.Name
+	if !restart && d.logs != nil {
+		// retry the logs of a pipeline which had not started running when they were last requested
+		done, err := d.logs.Status()
+		restart = done && err != nil && StateOf(activity) != StateCompleted && len(d.logs.Lines(nil)) == 0
+	}
+	if restart {
+		d.streamLogs(activity)
+	}
+}
+
+func (d *Dashboard) streamLogs(activity *v1.PipelineActivity) {
+	d.stopLogs()
+	buffer := NewLogBuffer(d.LogLimit)
+	stop := make(chan struct{})
+	d.logs = buffer
+	d.logsFor = activity.Name
+	d.logsStop = stop
+	d.logScroll = 0
+	go func() {
+		buffer.Finish(d.Backend.StreamLogs(activity, buffer, stop))
+	}()
+}
+
+// stopLogs stops streaming the log of the previously selected pipeline
+func (d *Dashboard) stopLogs() {
+	if d.logsStop != nil {
+		close(d.logsStop)
+		d.logsStop = nil
+	}
+}
+
+// MessageWriter returns the writer of the messages pane so that the log messages can be shown without drawing over
+// the dashboard
+func (d *Dashboard) MessageWriter() io.Writer {
+	if d.messages == nil {
+		d.messages = NewLogBuffer(messagesLimit)
+	}
+	return d.messages
+}
+
+func (d *Dashboard) selectedPipeline() *v1.PipelineActivity {
+	for _, activity := range d.pipelines {
+		if activity.Name == d.selected {
+			return activity
+		}
+	}
+	return nil
+}
+
+func (d *Dashboard) selectedIndex() int {
+	for i, activity := range d.pipelines {
+		if activity.Name == d.selected {
+			return i
+		}
+	}
+	return 0
+}
+
+func (d *Dashboard) selectedNode() *TreeNode {
+	if d.treeIndex < 0 || d.treeIndex >= len(d.tree) {
+		return nil
+	}
+	return &d.tree[d.treeIndex]
+}
+
+// HandleKey updates the dashboard for the pressed key returning true if the dashboard should quit
+func (d *Dashboard) HandleKey(key Key) bool {
+	if d.confirm != nil {
+		confirm := d.confirm
+		d.confirm = nil
+		if key.Code == KeyRune && (key.Rune == 'y' || key.Rune == 'Y') {
+			d.startAction(confirm)
+		} else {
+			d.message = ""
+		}
+		return false
+	}
+
+	d.message = ""
+	switch key.Code {
+	case KeyCtrlC:
+		return true
+	case KeyUp:
+		d.move(-1)
+	case KeyDown:
+		d.move(1)
+	case KeyTab, KeyBackTab, KeyLeft, KeyRight:
+		d.switchPane()
+	case KeyEnter:
+		if d.focus == pipelinesPane {
+			d.focus = stagesPane
+		}
+		d.logScroll = 0
+	case KeyEscape:
+		d.focus = pipelinesPane
+	case KeyPageUp:
+		d.scrollLogs(d.logPage())
+	case KeyPageDown:
+		d.scrollLogs(-d.logPage())
+	case KeyHome:
+		if d.logs != nil {
+			d.scrollLogs(len(d.logs.Lines(d.selectedNode())))
+		}
+	case KeyEnd:
+		d.logScroll = 0
+	case KeyRune:
+		switch key.Rune {
+		case 'q':
+			return true
+		case 'k':
+			d.move(-1)
+		case 'j':
+			d.move(1)
+		case 'G':
+			d.logScroll = 0
+		case 'a':
+			d.ShowCompleted = !d.ShowCompleted
+			d.Refresh()
+		case 's':
+			d.confirmStop()
+		case 'r':
+			d.confirmRestart()
+		}
+	}
+	return false
+}
+
+// startAction runs the confirmed action in the background so that the dashboard keeps responding while it runs
+func (d *Dashboard) startAction(confirm *action) {
+	if d.running {
+		d.message = "please wait for the previous action to complete"
+		return
+	}
+	if d.results == nil {
+		d.results = make(chan actionResult, 1)
+	}
+	d.running = true
+	d.message = confirm.progress
+	results := d.results
+	go func() {
+		message, err := confirm.run()
+		results <- actionResult{message: message, err: err}
+	}()
+}
+
+func (d *Dashboard) completeAction(result actionResult) {
+	d.running = false
+	if result.err != nil {
+		d.message = result.err.Error()
+	} else {
+		d.message = result.message
+	}
+	d.Refresh()
+}
+
+// WaitForAction waits for the confirmed action running in the background, if any, to complete and shows its result
+func (d *Dashboard) WaitForAction() {
+	if d.running {
+		d.completeAction(<-d.results)
+	}
+}
+
+func (d *Dashboard) switchPane() {
+	if d.focus == pipelinesPane {
+		d.focus = stagesPane
+	} else {
+		d.focus = pipelinesPane
+	}
+}
+
+func (d *Dashboard) move(delta int) {
+	if d.focus == stagesPane {
+		index := d.treeIndex + delta
+		if index >= 0 && index < len(d.tree) {
+			d.treeIndex = index
+			d.logScroll = 0
+		}
+		return
+	}
+	index := d.selectedIndex() + delta
+	if index >= 0 && index < len(d.pipelines) {
+		d.selected = d.pipelines[index].Name
+		d.Refresh()
+	}
+}
+
+func (d *Dashboard) logPage() int {
+	if d.logHeight > 1 {
+		return d.logHeight - 1
+	}
+	return 1
+}
+
+func (d *Dashboard) scrollLogs(delta int) {
+	d.logScroll += delta
+	if d.logScroll < 0 {
+		d.logScroll = 0
+	}
+}
+
+func (d *Dashboard) confirmStop() {
+	activity := d.selectedPipeline()
+	if activity == nil {
+		return
+	}
+	name := pipelineName(activity)
+	if StateOf(activity) == StateCompleted {
+		d.message = fmt.Sprintf("pipeline %s has already completed", name)
+		return
+	}
+	d.confirm = &action{
+		prompt:   fmt.Sprintf("Stop pipeline %s? (y/n)", name),
+		progress: fmt.Sprintf("stopping pipeline %s...", name),
+		run: func() (string, error) {
+			err := d.Backend.Stop(activity)
+			return fmt.Sprintf("stopped pipeline %s", name), err
+		},
+	}
+}
+
+func (d *Dashboard) confirmRestart() {
+	activity := d.selectedPipeline()
+	if activity == nil {
+		return
+	}
+	name := pipelineName(activity)
+	d.confirm = &action{
+		prompt:   fmt.Sprintf("Restart pipeline %s? (y/n)", activity.Spec.Pipeline),
+		progress: fmt.Sprintf("restarting pipeline %s...", activity.Spec.Pipeline),
+		run: func() (string, error) {
+			err := d.Backend.Restart(activity)
+			return fmt.Sprintf("restarted pipeline %s", activity.Spec.Pipeline), err
+		},
+	}
+	if StateOf(activity) != StateCompleted {
+		d.confirm.prompt = fmt.Sprintf("Pipeline %s is still running, start another run of %s? (y/n)", name, activity.Spec.Pipeline)
+	}
+}
+
+func pipelineName(activity *v1.PipelineActivity) string {
+	return fmt.Sprintf("%s #%s", activity.Spec.Pipeline, activity.Spec.Build)
+}
+
+// Run draws the dashboard on the terminal refreshing it at the given interval until the user quits. Any action which
+// is still running when the user quits is waited for so that it is not interrupted
+func (d *Dashboard) Run(term *Terminal, interval time.Duration) error {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	keys := make(chan Key)
+	go term.ReadKeys(keys)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer d.stopLogs()
+	defer d.WaitForAction()
+	if d.results == nil {
+		d.results = make(chan actionResult, 1)
+	}
+
+	d.Refresh()
+	for {
+		err := term.Draw(d.Render(term.Size()))
+		if err != nil {
+			return err
+		}
+		select {
+		case key, ok := <-keys:
+			if !ok || d.HandleKey(key) {
+				return nil
+			}
+		case result := <-d.results:
+			d.completeAction(result)
+		case <-ticker.C:
+			d.Refresh()
+		}
+	}
+}
+
+// Render returns the lines of the dashboard for a terminal of the given size
+func (d *Dashboard) Render(width int, height int) []string {
+	if width < 20 {
+		width = 20
+	}
+	if height < 8 {
+		height = 8
+	}
+	lines := []string{d.renderHeader(width)}
+
+	// the pipelines use up to a third of the screen below the header
+	pipelineRows := len(d.pipelines)
+	if pipelineRows < 1 {
+		pipelineRows = 1
+	}
+	if max := (height - 3) / 3; pipelineRows > max {
+		pipelineRows = max
+	}
+	lines = append(lines, d.renderPipelines(width, pipelineRows)...)
+	lines = append(lines, strings.Repeat("─", width))
+
+	messages := d.visibleMessages()
+	lowerRows := height - len(lines) - 1
+	// the messages pane only uses the rows left after the stages and logs have at least minimumLogRows
+	if room := lowerRows - minimumLogRows - 1; len(messages) > room {
+		if room <= 0 {
+			messages = nil
+		} else {
+			messages = messages[len(messages)-room:]
+		}
+	}
+	if len(messages) > 0 {
+		lowerRows -= len(messages) + 1
+	}
+	lines = append(lines, d.renderStagesAndLogs(width, lowerRows)...)
+	if len(messages) > 0 {
+		lines = append(lines, strings.Repeat("─", width))
+		for _, message := range messages {
+			lines = append(lines, fit(stripansi.Strip(message), width))
+		}
+	}
+	lines = append(lines, d.renderFooter(width))
+	return lines
+}
+
+// visibleMessages returns the most recent log messages written to the messages pane
+func (d *Dashboard) visibleMessages() []string {
+	if d.messages == nil {
+		return nil
+	}
+	lines := d.messages.Lines(nil)
+	if len(lines) > messageRows {
+		lines = lines[len(lines)-messageRows:]
+	}
+	return lines
+}
+
+func (d *Dashboard) renderHeader(width int) string {
+	running := 0
+	queued := 0
+	for _, activity := range d.pipelines {
+		switch StateOf(activity) {
+		case StateRunning:
+			running++
+		case StateQueued:
+			queued++
+		}
+	}
+	text := fmt.Sprintf(" Jenkins X pipelines  namespace: %s  running: %d  queued: %d", d.Namespace, running, queued)
+	if d.ShowCompleted {
+		text += "  (showing completed)"
+	}
+	return reverseVideo + fit(text, width) + resetStyle
+}
+
+func (d *Dashboard) renderPipelines(width int, rows int) []string {
+	header := fmt.Sprintf("  %-50s %-8s %-10s %-10s %-10s", "PIPELINE", "BUILD", "STATE", "STATUS", "DURATION")
+	lines := []string{util.ColorBold(fit(header, width))}
+	if len(d.pipelines) == 0 {
+		return append(lines, fit("  no pipelines are running or queued", width))
+	}
+
+	index := d.selectedIndex()
+	d.pipelineTop = scrollTop(d.pipelineTop, index, rows)
+	now := &metav1.Time{Time: time.Now()}
+	for i := d.pipelineTop; i < len(d.pipelines) && i < d.pipelineTop+rows; i++ {
+		activity := d.pipelines[i]
+		spec := &activity.Spec
+		end := spec.CompletedTimestamp
+		if end == nil {
+			end = now
+		}
+		row := fmt.Sprintf("  %-50s %-8s %-10s %-10s %-10s", spec.Pipeline, spec.Build, StateOf(activity), spec.Status, util.DurationString(spec.StartedTimestamp, end))
+		line := fit(row, width)
+		if i == index {
+			line = highlight(line, d.focus == pipelinesPane)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (d *Dashboard) renderStagesAndLogs(width int, rows int) []string {
+	treeWidth := width / 3
+	if treeWidth > 40 {
+		treeWidth = 40
+	}
+	logWidth := width - treeWidth - 3
+	d.logHeight = rows
+
+	d.treeTop = scrollTop(d.treeTop, d.treeIndex, rows)
+	treeLines := []string{}
+	for i := d.treeTop; i < len(d.tree) && i < d.treeTop+rows; i++ {
+		node := &d.tree[i]
+		line := fit(strings.Repeat("  ", node.Depth)+statusIcon(node.Status)+" "+node.Label, treeWidth)
+		if i == d.treeIndex {
+			line = highlight(line, d.focus == stagesPane)
+		}
+		treeLines = append(treeLines, line)
+	}
+
+	logLines := d.visibleLogLines(rows)
+	lines := []string{}
+	for i := 0; i < rows; i++ {
+		left := strings.Repeat(" ", treeWidth)
+		if i < len(treeLines) {
+			left = treeLines[i]
+		}
+		right := ""
+		if i < len(logLines) {
+			right = fit(logLines[i], logWidth)
+		}
+		lines = append(lines, left+" │ "+right)
+	}
+	return lines
+}
+
+func (d *Dashboard) visibleLogLines(rows int) []string {
+	if d.logs == nil {
+		return nil
+	}
+	lines := d.logs.Lines(d.selectedNode())
+	if len(lines) == 0 {
+		done, err := d.logs.Status()
+		switch {
+		case err != nil:
+			return []string{"no logs available: " + err.Error()}
+		case done:
+			return []string{"no logs available"}
+		default:
+			return []string{"waiting for logs..."}
+		}
+	}
+	end := len(lines) - d.logScroll
+	if end < rows {
+		end = rows
+		if end > len(lines) {
+			end = len(lines)
+		}
+		d.logScroll = len(lines) - end
+	}
+	start := end - rows
+	if start < 0 {
+		start = 0
+	}
+	answer := []string{}
+	for _, line := range lines[start:end] {
+		answer = append(answer, stripansi.Strip(strings.Replace(line, "\t", "    ", -1)))
+	}
+	return answer
+}
+
+func (d *Dashboard) renderFooter(width int) string {
+	text := footerHelp
+	if d.confirm != nil {
+		text = d.confirm.prompt
+	} else if d.message != "" {
+		text = d.message
+	} else if d.logScroll > 0 {
+		text = fmt.Sprintf("scrolled back %d lines, press G to follow the log  ", d.logScroll) + text
+	}
+	return reverseVideo + fit(" "+text, width) + resetStyle
+}
+
+// scrollTop returns the first visible row of a list so that the selected row is visible
+func scrollTop(top int, selected int, rows int) int {
+	if selected < top {
+		return selected
+	}
+	if selected >= top+rows {
+		return selected - rows + 1
+	}
+	return top
+}
+
+func highlight(line string, focused bool) string {
+	if focused {
+		return reverseVideo + stripansi.Strip(line) + resetStyle
+	}
+	return util.ColorBold(stripansi.Strip(line))
+}
+
+func statusIcon(status v1.ActivityStatusType) string {
+	switch status {
+	case v1.ActivityStatusTypeSucceeded:
+		return util.ColorInfo("✓")
+	case v1.ActivityStatusTypeFailed, v1.ActivityStatusTypeError:
+		return util.ColorError("✗")
+	case v1.ActivityStatusTypeAborted:
+		return util.ColorWarning("■")
+	case v1.ActivityStatusTypeRunning:
+		return util.ColorStatus("▶")
+	case v1.ActivityStatusTypeWaitingForApproval:
+		return util.ColorWarning("?")
+	case v1.ActivityStatusTypeNotExecuted:
+		return "-"
+	}
+	return "·"
+}
+
+// fit pads or truncates the text to the width of the screen ignoring any colors, which are removed if the text is
+// truncated
+func fit(text string, width int) string {
+	plain := stripansi.Strip(text)
+	length := utf8.RuneCountInString(plain)
+	if length <= width {
+		return text + strings.Repeat(" ", width-length)
+	}
+	return string([]rune(plain)[:width])
+}
@@ -0,0 +1,300 @@
+// +build unit
+
+package dashboard_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/dashboard"
+	"github.com/jenkins-x/jx/v2/pkg/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeBackend struct {
+	lock       sync.Mutex
+	activities []*v1.PipelineActivity
+	structure  *v1.PipelineStructure
+	logLines   []logs.LogLine
+	stopped    []string
+	restarted  []string
+	streams    map[string]<-chan struct{}
+	release    chan struct{}
+}
+
+func (f *fakeBackend) Pipelines() []*v1.PipelineActivity {
+	return f.activities
+}
+
+func (f *fakeBackend) Structure(activity *v1.PipelineActivity) (*v1.PipelineStructure, error) {
+	return f.structure, nil
+}
+
+func (f *fakeBackend) StreamLogs(activity *v1.PipelineActivity, writer logs.LogWriter, stop <-chan struct{}) error {
+	f.lock.Lock()
+	if f.streams == nil {
+		f.streams = map[string]<-chan struct{}{}
+	}
+	f.streams[activity.Name] = stop
+	f.lock.Unlock()
+
+	lch := make(chan logs.LogLine)
+	ech := make(chan error)
+	done := make(chan error)
+	go func() {
+		done <- writer.StreamLog(lch, ech)
+	}()
+	for _, line := range f.logLines {
+		l := line
+		l.Line = activity.Spec.Build + ": " + l.Line
+		err := writer.WriteLog(l, lch)
+		if err != nil {
+			return err
+		}
+	}
+	close(lch)
+	return <-done
+}
+
+// streamStopped returns true if the log stream of the activity has been stopped
+func (f *fakeBackend) streamStopped(name string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	select {
+	case <-f.streams[name]:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *fakeBackend) Stop(activity *v1.PipelineActivity) error {
+	if f.release != nil {
+		<-f.release
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.stopped = append(f.stopped, activity.Name)
+	return nil
+}
+
+func (f *fakeBackend) Restart(activity *v1.PipelineActivity) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.restarted = append(f.restarted, activity.Name)
+	return nil
+}
+
+func newTestPipelines() []*v1.PipelineActivity {
+	started := metav1.NewTime(time.Now().Add(-time.Minute))
+	completed := newTestActivity("jx-testing-myapp-master-1", v1.ActivityStatusTypeSucceeded)
+	completed.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	running := newTestActivity("jx-testing-myapp-master-2", v1.ActivityStatusTypeRunning,
+		newTestStage("build", v1.ActivityStatusTypeRunning,
+			v1.CoreActivityStep{Name: "Git Clone", Status: v1.ActivityStatusTypeSucceeded},
+			v1.CoreActivityStep{Name: "Build Make", Status: v1.ActivityStatusTypeRunning}))
+	running.Spec.Build = "2"
+	running.Spec.StartedTimestamp = &started
+	queued := newTestActivity("jx-testing-myapp-pr-3-1", v1.ActivityStatusTypePending)
+	queued.Spec.Pipeline = "jx-testing/myapp/PR-3"
+	return []*v1.PipelineActivity{completed, queued, running}
+}
+
+func activityNames(activities []*v1.PipelineActivity) []string {
+	names := []string{}
+	for _, a := range activities {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+func TestActivePipelines(t *testing.T) {
+	t.Parallel()
+
+	pipelines := newTestPipelines()
+	assert.Equal(t, dashboard.StateCompleted, dashboard.StateOf(pipelines[0]))
+	assert.Equal(t, dashboard.StateQueued, dashboard.StateOf(pipelines[1]))
+	assert.Equal(t, dashboard.StateRunning, dashboard.StateOf(pipelines[2]))
+
+	assert.Equal(t, []string{"jx-testing-myapp-master-2", "jx-testing-myapp-pr-3-1"}, activityNames(dashboard.ActivePipelines(pipelines, false)))
+	assert.Equal(t, []string{"jx-testing-myapp-master-2", "jx-testing-myapp-pr-3-1", "jx-testing-myapp-master-1"}, activityNames(dashboard.ActivePipelines(pipelines, true)))
+
+	started := metav1.Now()
+	pending := newTestActivity("jx-testing-myapp-master-4", v1.ActivityStatusTypePending, newTestStage("build", v1.ActivityStatusTypePending))
+	pending.Spec.Steps[0].Stage.StartedTimestamp = &started
+	assert.Equal(t, dashboard.StateRunning, dashboard.StateOf(pending))
+}
+
+func TestLogBuffer(t *testing.T) {
+	t.Parallel()
+
+	buffer := dashboard.NewLogBuffer(3)
+	buffer.Append(logs.LogLine{Line: "\nShowing logs for build stage build"})
+	assert.Equal(t, []string{"Showing logs for build stage build"}, buffer.Lines(&dashboard.TreeNode{Stages: []string{"build"}}), "the lines of logs without stages are shown for every node")
+
+	buffer.Append(logs.LogLine{Line: "cloning\r", Stage: "build", Step: "step-git-clone"})
+	buffer.Append(logs.LogLine{Line: "compiling\nlinking", Stage: "build", Step: "step-build-make"})
+	assert.Equal(t, []string{"cloning", "compiling", "linking"}, buffer.Lines(nil))
+
+	node := &dashboard.TreeNode{Stages: []string{"build"}, Step: "Build Make"}
+	assert.Equal(t, []string{"compiling", "linking"}, buffer.Lines(node))
+
+	done, err := buffer.Status()
+	assert.False(t, done)
+	assert.NoError(t, err)
+	buffer.Finish(nil)
+	done, _ = buffer.Status()
+	assert.True(t, done)
+}
+
+func waitForLogs(t *testing.T, d *dashboard.Dashboard, text string) []string {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = render(d)
+		if strings.Contains(strings.Join(lines, "\n"), text) {
+			return lines
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Fail(t, "the log was not shown", "expected %s in\n%s", text, strings.Join(lines, "\n"))
+	return nil
+}
+
+func render(d *dashboard.Dashboard) []string {
+	lines := d.Render(100, 20)
+	for i := range lines {
+		lines[i] = stripansi.Strip(lines[i])
+	}
+	return lines
+}
+
+func TestDashboard(t *testing.T) {
+	t.Parallel()
+
+	backend := &fakeBackend{
+		activities: newTestPipelines(),
+		logLines: []logs.LogLine{
+			{Line: "cloning", Stage: "build", Step: "step-git-clone"},
+			{Line: "compiling", Stage: "build", Step: "step-build-make"},
+		},
+		release: make(chan struct{}),
+	}
+	d := &dashboard.Dashboard{
+		Backend:   backend,
+		Namespace: "jx",
+	}
+	d.Refresh()
+
+	lines := waitForLogs(t, d, "2: compiling")
+	assert.False(t, backend.streamStopped("jx-testing-myapp-master-2"))
+	require.Len(t, lines, 20)
+	for _, line := range lines {
+		assert.True(t, len([]rune(line)) <= 100, "line %q is wider than the screen", line)
+	}
+	assert.Contains(t, lines[0], "namespace: jx")
+	assert.Contains(t, lines[0], "running: 1")
+	assert.Contains(t, lines[0], "queued: 1")
+	all := strings.Join(lines, "\n")
+	assert.Contains(t, all, "jx-testing/myapp/master")
+	assert.Contains(t, all, "jx-testing/myapp/PR-3")
+	assert.NotContains(t, all, "Succeeded", "the completed pipelines are hidden")
+	assert.Contains(t, all, "Build Make")
+	assert.Contains(t, all, "2: cloning")
+
+	// select the Build Make step to only show its log
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyEnter})
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyDown})
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'j'})
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'j'})
+	all = strings.Join(render(d), "\n")
+	assert.Contains(t, all, "2: compiling")
+	assert.NotContains(t, all, "2: cloning")
+
+	// stop the running pipeline
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyTab})
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 's'})
+	lines = render(d)
+	assert.Contains(t, lines[len(lines)-1], "Stop pipeline jx-testing/myapp/master #2? (y/n)")
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'y'})
+	lines = render(d)
+	assert.Contains(t, lines[len(lines)-1], "stopping pipeline jx-testing/myapp/master #2...", "the dashboard should not wait for the pipeline to stop")
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 's'})
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'y'})
+	lines = render(d)
+	assert.Contains(t, lines[len(lines)-1], "please wait for the previous action to complete")
+	close(backend.release)
+	d.WaitForAction()
+	assert.Equal(t, []string{"jx-testing-myapp-master-2"}, backend.stopped)
+	lines = render(d)
+	assert.Contains(t, lines[len(lines)-1], "stopped pipeline jx-testing/myapp/master #2")
+
+	// cancel restarting the queued pipeline and then restart it
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyDown})
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'r'})
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'n'})
+	assert.Empty(t, backend.restarted)
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'r'})
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'y'})
+	d.WaitForAction()
+	assert.Equal(t, []string{"jx-testing-myapp-pr-3-1"}, backend.restarted)
+	assert.True(t, backend.streamStopped("jx-testing-myapp-master-2"), "the log of the previously selected pipeline should be stopped")
+
+	// show the completed pipelines
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'a'})
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyDown})
+	all = strings.Join(render(d), "\n")
+	assert.Contains(t, all, "Succeeded")
+	assert.Contains(t, all, "(showing completed)")
+	waitForLogs(t, d, "1: compiling")
+
+	d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 's'})
+	lines = render(d)
+	assert.Contains(t, lines[len(lines)-1], "has already completed")
+
+	assert.False(t, d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'x'}))
+	assert.True(t, d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 'q'}))
+	assert.True(t, d.HandleKey(dashboard.Key{Code: dashboard.KeyCtrlC}))
+}
+
+func TestDashboardWithoutPipelines(t *testing.T) {
+	t.Parallel()
+
+	d := &dashboard.Dashboard{
+		Backend:   &fakeBackend{},
+		Namespace: "jx",
+	}
+	d.Refresh()
+	lines := render(d)
+	require.Len(t, lines, 20)
+	assert.Contains(t, strings.Join(lines, "\n"), "no pipelines are running or queued")
+	assert.False(t, d.HandleKey(dashboard.Key{Code: dashboard.KeyRune, Rune: 's'}))
+	assert.False(t, d.HandleKey(dashboard.Key{Code: dashboard.KeyDown}))
+}
+
+func TestDashboardMessages(t *testing.T) {
+	t.Parallel()
+
+	d := &dashboard.Dashboard{
+		Backend:   &fakeBackend{activities: newTestPipelines()},
+		Namespace: "jx",
+	}
+	d.Refresh()
+	writer := d.MessageWriter()
+	for _, message := range []string{"first", "second", "third", "fourth"} {
+		_, err := writer.Write([]byte(message + "\n"))
+		require.NoError(t, err)
+	}
+	lines := render(d)
+	require.Len(t, lines, 20)
+	all := strings.Join(lines, "\n")
+	assert.NotContains(t, all, "first", "only the most recent messages are shown")
+	assert.Contains(t, lines[16], "second")
+	assert.Contains(t, lines[17], "third")
+	assert.Contains(t, lines[18], "fourth")
+}
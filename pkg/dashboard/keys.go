@@ -0,0 +1,129 @@
+package dashboard
+
+import (
+	"unicode/utf8"
+)
+
+// KeyCode the code of a special key which does not type a character
+type KeyCode int
+
+const (
+	// KeyRune a key which types the character in the Rune of the Key
+	KeyRune KeyCode = iota
+	// KeyUp the up arrow key
+	KeyUp
+	// KeyDown the down arrow key
+	KeyDown
+	// KeyLeft the left arrow key
+	KeyLeft
+	// KeyRight the right arrow key
+	KeyRight
+	// KeyPageUp the page up key
+	KeyPageUp
+	// KeyPageDown the page down key
+	KeyPageDown
+	// KeyHome the home key
+	KeyHome
+	// KeyEnd the end key
+	KeyEnd
+	// KeyEnter the enter key
+	KeyEnter
+	// KeyTab the tab key
+	KeyTab
+	// KeyBackTab the tab key pressed with shift
+	KeyBackTab
+	// KeyEscape the escape key
+	KeyEscape
+	// KeyCtrlC control and C which quits the dashboard
+	KeyCtrlC
+)
+
+// Key a key pressed in the terminal
+type Key struct {
+	Code KeyCode
+	Rune rune
+}
+
+// escapeSequences the escape sequences sent by terminals for the special keys, without the leading escape
+var escapeSequences = map[string]KeyCode{
+	"[A":  KeyUp,
+	"[B":  KeyDown,
+	"[C":  KeyRight,
+	"[D":  KeyLeft,
+	"OA":  KeyUp,
+	"OB":  KeyDown,
+	"OC":  KeyRight,
+	"OD":  KeyLeft,
+	"[5~": KeyPageUp,
+	"[6~": KeyPageDown,
+	"[H":  KeyHome,
+	"[F":  KeyEnd,
+	"OH":  KeyHome,
+	"OF":  KeyEnd,
+	"[1~": KeyHome,
+	"[4~": KeyEnd,
+	"[7~": KeyHome,
+	"[8~": KeyEnd,
+	"[Z":  KeyBackTab,
+}
+
+// ParseKeys parses the bytes read from a terminal in raw mode into the keys which were pressed. Unknown escape
+// sequences are ignored
+func ParseKeys(data []byte) []Key {
+	keys := []Key{}
+	for len(data) > 0 {
+		b := data[0]
+		switch {
+		case b == 0x1b:
+			key, n := parseEscapeSequence(data[1:])
+			if n >= 0 {
+				if key.Code != KeyRune || key.Rune != 0 {
+					keys = append(keys, key)
+				}
+				data = data[1+n:]
+				continue
+			}
+			keys = append(keys, Key{Code: KeyEscape})
+			data = data[1:]
+			continue
+		case b == '\r' || b == '\n':
+			keys = append(keys, Key{Code: KeyEnter})
+		case b == '\t':
+			keys = append(keys, Key{Code: KeyTab})
+		case b == 0x03:
+			keys = append(keys, Key{Code: KeyCtrlC})
+		case b < 0x20 || b == 0x7f:
+			// ignore the other control characters
+		default:
+			r, size := utf8.DecodeRune(data)
+			keys = append(keys, Key{Code: KeyRune, Rune: r})
+			data = data[size:]
+			continue
+		}
+		data = data[1:]
+	}
+	return keys
+}
+
+// parseEscapeSequence parses the escape sequence following an escape returning the key and the number of bytes it
+// used or -1 if the escape is not followed by a sequence so it is the escape key
+func parseEscapeSequence(data []byte) (Key, int) {
+	if len(data) < 2 || (data[0] != '[' && data[0] != 'O') {
+		return Key{}, -1
+	}
+	for i := 1; i < len(data); i++ {
+		b := data[i]
+		// the final byte of a control sequence is in the range @ to ~
+		if b >= 0x40 && b <= 0x7e {
+			code, ok := escapeSequences[string(data[:i+1])]
+			if !ok {
+				return Key{}, i + 1
+			}
+			return Key{Code: code}, i + 1
+		}
+		if data[0] == 'O' {
+			break
+		}
+	}
+	return Key{}, -1
+}
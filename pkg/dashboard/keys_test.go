@@ -0,0 +1,37 @@
+// +build unit
+
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/dashboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input    string
+		expected []dashboard.Key
+	}{
+		{"j", []dashboard.Key{{Code: dashboard.KeyRune, Rune: 'j'}}},
+		{"qG", []dashboard.Key{{Code: dashboard.KeyRune, Rune: 'q'}, {Code: dashboard.KeyRune, Rune: 'G'}}},
+		{"\x1b[A\x1b[B", []dashboard.Key{{Code: dashboard.KeyUp}, {Code: dashboard.KeyDown}}},
+		{"\x1bOC\x1bOD", []dashboard.Key{{Code: dashboard.KeyRight}, {Code: dashboard.KeyLeft}}},
+		{"\x1b[5~\x1b[6~", []dashboard.Key{{Code: dashboard.KeyPageUp}, {Code: dashboard.KeyPageDown}}},
+		{"\x1b[H\x1b[4~", []dashboard.Key{{Code: dashboard.KeyHome}, {Code: dashboard.KeyEnd}}},
+		{"\t\x1b[Z", []dashboard.Key{{Code: dashboard.KeyTab}, {Code: dashboard.KeyBackTab}}},
+		{"\r", []dashboard.Key{{Code: dashboard.KeyEnter}}},
+		{"\x03", []dashboard.Key{{Code: dashboard.KeyCtrlC}}},
+		{"\x1b", []dashboard.Key{{Code: dashboard.KeyEscape}}},
+		{"\x1bq", []dashboard.Key{{Code: dashboard.KeyEscape}, {Code: dashboard.KeyRune, Rune: 'q'}}},
+		{"\x1b[15~s", []dashboard.Key{{Code: dashboard.KeyRune, Rune: 's'}}},
+		{"é", []dashboard.Key{{Code: dashboard.KeyRune, Rune: 'é'}}},
+		{"\x7f\x01", []dashboard.Key{}},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, dashboard.ParseKeys([]byte(tc.input)), "parsing %q", tc.input)
+	}
+}
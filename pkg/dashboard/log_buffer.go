@@ -0,0 +1,121 @@
+package dashboard
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/jx/v2/pkg/logs"
+)
+
+// DefaultLogLimit the default number of log lines kept for the selected pipeline
+const DefaultLogLimit = 5000
+
+// LogBuffer is an implementation of logs.LogWriter which keeps the most recent lines of the log of a pipeline so that
+// they can be shown for the stage or step selected in the dashboard
+type LogBuffer struct {
+	lock  sync.Mutex
+	lines []logs.LogLine
+	limit int
+	done  bool
+	err   error
+}
+
+// NewLogBuffer creates a buffer which keeps up to limit log lines
+func NewLogBuffer(limit int) *LogBuffer {
+	if limit <= 0 {
+		limit = DefaultLogLimit
+	}
+	return &LogBuffer{limit: limit}
+}
+
+// WriteLog implementation of LogWriter.WriteLog for LogBuffer
+func (b *LogBuffer) WriteLog(line logs.LogLine, lch chan<- logs.LogLine) error {
+	lch <- line
+	return nil
+}
+
+// StreamLog implementation of LogWriter.StreamLog for LogBuffer which appends the lines to the buffer
+func (b *LogBuffer) StreamLog(lch <-chan logs.LogLine, ech <-chan error) error {
+	for {
+		select {
+		case l, ok := <-lch:
+			if !ok {
+				return nil
+			}
+			b.Append(l)
+		case err := <-ech:
+			return err
+		}
+	}
+}
+
+// BytesLimit defines the limit of bytes to be used to fetch the logs from the kube API
+// defaulted to 0 for this implementation
+func (b *LogBuffer) BytesLimit() int {
+	return 0
+}
+
+// Write implements io.Writer appending each line of the text to the buffer
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.Append(logs.LogLine{Line: strings.TrimSuffix(string(p), "\n")})
+	return len(p), nil
+}
+
+// Append adds the log line to the buffer discarding the oldest lines when the buffer is full
+func (b *LogBuffer) Append(line logs.LogLine) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, text := range strings.Split(strings.TrimPrefix(line.Line, "\n"), "\n") {
+		l := line
+		l.Line = strings.TrimSuffix(text, "\r")
+		b.lines = append(b.lines, l)
+	}
+	if len(b.lines) > b.limit {
+		b.lines = append([]logs.LogLine{}, b.lines[len(b.lines)-b.limit:]...)
+	}
+}
+
+// Finish marks the log as complete with the error which stopped the logs being streamed, if any
+func (b *LogBuffer) Finish(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.done = true
+	b.err = err
+}
+
+// Status returns true if the log is complete and the error which stopped it being streamed, if any
+func (b *LogBuffer) Status() (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.done, b.err
+}
+
+// Lines returns the log lines written by the stages or step of the given node or all the lines if the node is nil.
+// All the lines are returned for logs without stages such as the archived logs of completed pipelines
+func (b *LogBuffer) Lines(node *TreeNode) []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if node != nil && !b.hasStages() {
+		node = nil
+	}
+	answer := []string{}
+	for _, l := range b.lines {
+		if node == nil || node.Matches(l.Stage, l.Step) {
+			answer = append(answer, l.Line)
+		}
+	}
+	return answer
+}
+
+func (b *LogBuffer) hasStages() bool {
+	for _, l := range b.lines {
+		if l.Stage != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"sort"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+)
+
+// PipelineState the state a pipeline is shown in on the dashboard
+type PipelineState string
+
+const (
+	// StateRunning the pipeline has started running its stages
+	StateRunning PipelineState = "Running"
+	// StateQueued the pipeline has been triggered but none of its stages have started yet
+	StateQueued PipelineState = "Queued"
+	// StateCompleted the pipeline has stopped executing
+	StateCompleted PipelineState = "Completed"
+)
+
+// stateOrder the order the pipelines are listed in by their state
+var stateOrder = map[PipelineState]int{
+	StateRunning:   0,
+	StateQueued:    1,
+	StateCompleted: 2,
+}
+
+// StateOf returns the state of the given pipeline
+func StateOf(activity *v1.PipelineActivity) PipelineState {
+	spec := &activity.Spec
+	if spec.Status.IsTerminated() {
+		return StateCompleted
+	}
+	if spec.Status == v1.ActivityStatusTypeRunning || spec.Status == v1.ActivityStatusTypeWaitingForApproval {
+		return StateRunning
+	}
+	for _, step := range spec.Steps {
+		if step.Stage != nil && step.Stage.StartedTimestamp != nil {
+			return StateRunning
+		}
+	}
+	return StateQueued
+}
+
+// ActivePipelines returns the running pipelines followed by the queued pipelines, newest first, and the completed
+// pipelines if includeCompleted is true
+func ActivePipelines(activities []*v1.PipelineActivity, includeCompleted bool) []*v1.PipelineActivity {
+	answer := []*v1.PipelineActivity{}
+	for _, activity := range activities {
+		if activity == nil {
+			continue
+		}
+		if !includeCompleted && StateOf(activity) == StateCompleted {
+			continue
+		}
+		answer = append(answer, activity)
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		a := answer[i]
+		b := answer[j]
+		sa := stateOrder[StateOf(a)]
+		sb := stateOrder[StateOf(b)]
+		if sa != sb {
+			return sa < sb
+		}
+		ta := a.CreationTimestamp
+		tb := b.CreationTimestamp
+		if !ta.Equal(&tb) {
+			return tb.Before(&ta)
+		}
+		return a.Name < b.Name
+	})
+	return answer
+}
@@ -0,0 +1,99 @@
+package dashboard
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	enterAlternateScreen = "\x1b[?1049h\x1b[?25l"
+	leaveAlternateScreen = "\x1b[?25h\x1b[?1049l"
+	cursorHome           = "\x1b[H"
+	clearToEndOfLine     = "\x1b[K"
+	clearToEndOfScreen   = "\x1b[J"
+
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Terminal is the full screen terminal the dashboard is drawn on
+type Terminal struct {
+	in    *os.File
+	out   *os.File
+	state *terminal.State
+}
+
+// OpenTerminal switches the terminal into raw mode and to its alternate screen so the dashboard can be drawn without
+// losing the contents of the terminal, which are restored by Close
+func OpenTerminal(in *os.File, out *os.File) (*Terminal, error) {
+	if !terminal.IsTerminal(int(in.Fd())) || !terminal.IsTerminal(int(out.Fd())) {
+		return nil, errors.New("the dashboard requires an interactive terminal")
+	}
+	state, err := terminal.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to switch the terminal to raw mode")
+	}
+	t := &Terminal{
+		in:    in,
+		out:   out,
+		state: state,
+	}
+	_, err = out.WriteString(enterAlternateScreen)
+	if err != nil {
+		t.Close()
+		return nil, errors.Wrap(err, "failed to switch to the alternate screen")
+	}
+	return t, nil
+}
+
+// Close restores the contents and the mode of the terminal
+func (t *Terminal) Close() error {
+	_, err := t.out.WriteString(leaveAlternateScreen)
+	if restoreErr := terminal.Restore(int(t.in.Fd()), t.state); restoreErr != nil {
+		return errors.Wrap(restoreErr, "failed to restore the terminal")
+	}
+	return err
+}
+
+// Size returns the width and height of the terminal
+func (t *Terminal) Size() (int, int) {
+	width, height, err := terminal.GetSize(int(t.out.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return defaultWidth, defaultHeight
+	}
+	return width, height
+}
+
+// Draw replaces the screen with the given lines
+func (t *Terminal) Draw(lines []string) error {
+	var buffer strings.Builder
+	buffer.WriteString(cursorHome)
+	for i, line := range lines {
+		if i > 0 {
+			buffer.WriteString("\r\n")
+		}
+		buffer.WriteString(line)
+		buffer.WriteString(clearToEndOfLine)
+	}
+	buffer.WriteString(clearToEndOfScreen)
+	_, err := t.out.WriteString(buffer.String())
+	return err
+}
+
+// ReadKeys reads the keys pressed in the terminal sending them to the channel until the input is closed
+func (t *Terminal) ReadKeys(keys chan<- Key) {
+	data := make([]byte, 256)
+	for {
+		n, err := t.in.Read(data)
+		for _, key := range ParseKeys(data[:n]) {
+			keys <- key
+		}
+		if err != nil {
+			close(keys)
+			return
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+)
+
+// stageNameReplacer converts the names of the stages in a PipelineStructure into their names in a PipelineActivity
+var stageNameReplacer = strings.NewReplacer("-", " ")
+
+// TreeNode a line in the stage tree of a pipeline
+type TreeNode struct {
+	Label  string
+	Depth  int
+	Status v1.ActivityStatusType
+
+	// Stages the names of the stages in the PipelineStructure whose logs are shown when the node is selected, all the
+	// logs of the pipeline are shown when there are no stages
+	Stages []string
+
+	// Step the title of the step whose logs are shown when the node is selected
+	Step string
+}
+
+// StageTree returns the stage tree of the pipeline with the nested stages of its PipelineStructure and the status of
+// the stages and their steps from the PipelineActivity. The stages of the activity are listed without nesting if
+// there is no structure
+func StageTree(structure *v1.PipelineStructure, activity *v1.PipelineActivity) []TreeNode {
+	spec := &activity.Spec
+	nodes := []TreeNode{
+		{
+			Label:  fmt.Sprintf("%s #%s", spec.Pipeline, spec.Build),
+			Status: spec.Status,
+		},
+	}
+
+	stages := map[string]*v1.StageActivityStep{}
+	for i := range spec.Steps {
+		stage := spec.Steps[i].Stage
+		if stage != nil {
+			stages[stage.Name] = stage
+		}
+	}
+
+	visited := map[string]bool{}
+	var structureNodes []TreeNode
+	if structure != nil {
+		for _, psc := range structure.GetAllStagesAndChildren() {
+			structureNodes = appendStageNodes(structureNodes, psc, nil, 1, stages, visited)
+		}
+	}
+
+	// the stages which are not in the structure, such as the meta pipeline, run before the stages of the structure
+	for i := range spec.Steps {
+		stage := spec.Steps[i].Stage
+		if stage == nil || visited[stage.Name] {
+			continue
+		}
+		names := strings.Split(stage.Name, " / ")
+		stageName := strings.Replace(names[len(names)-1], " ", "-", -1)
+		nodes = append(nodes, TreeNode{
+			Label:  stage.Name,
+			Depth:  1,
+			Status: stage.Status,
+			Stages: []string{stageName},
+		})
+		nodes = appendStepNodes(nodes, stage, stageName, 2)
+	}
+	nodes = append(nodes, structureNodes...)
+
+	for i := range spec.Steps {
+		step := &spec.Steps[i]
+		if step.Promote != nil {
+			nodes = append(nodes, TreeNode{
+				Label:  "Promote: " + step.Promote.Environment,
+				Depth:  1,
+				Status: step.Promote.Status,
+			})
+		}
+		if step.Preview != nil {
+			nodes = append(nodes, TreeNode{
+				Label:  "Preview",
+				Depth:  1,
+				Status: step.Preview.Status,
+			})
+		}
+	}
+	return nodes
+}
+
+func appendStageNodes(nodes []TreeNode, psc *v1.PipelineStageAndChildren, parents []string, depth int, stages map[string]*v1.StageActivityStep, visited map[string]bool) []TreeNode {
+	name := psc.Stage.Name
+	activityName := stageNameReplacer.Replace(strings.Join(append(append([]string{}, parents...), name), " / "))
+	node := TreeNode{
+		Label:  stageNameReplacer.Replace(name),
+		Depth:  depth,
+		Stages: leafStageNames(psc),
+	}
+	stage := stages[activityName]
+	if stage != nil {
+		visited[activityName] = true
+		node.Status = stage.Status
+	}
+	nodes = append(nodes, node)
+
+	children := append(append([]v1.PipelineStageAndChildren{}, psc.Stages...), psc.Parallel...)
+	if len(children) == 0 {
+		if stage != nil {
+			nodes = appendStepNodes(nodes, stage, name, depth+1)
+		}
+		return nodes
+	}
+	childParents := append(append([]string{}, parents...), name)
+	for i := range children {
+		nodes = appendStageNodes(nodes, &children[i], childParents, depth+1, stages, visited)
+	}
+	return nodes
+}
+
+func appendStepNodes(nodes []TreeNode, stage *v1.StageActivityStep, stageName string, depth int) []TreeNode {
+	for _, step := range stage.Steps {
+		nodes = append(nodes, TreeNode{
+			Label:  step.Name,
+			Depth:  depth,
+			Status: step.Status,
+			Stages: []string{stageName},
+			Step:   step.Name,
+		})
+	}
+	return nodes
+}
+
+// leafStageNames returns the names of the stages with steps nested in the given stage
+func leafStageNames(psc *v1.PipelineStageAndChildren) []string {
+	if len(psc.Stages) == 0 && len(psc.Parallel) == 0 {
+		return []string{psc.Stage.Name}
+	}
+	answer := []string{}
+	for i := range psc.Stages {
+		answer = append(answer, leafStageNames(&psc.Stages[i])...)
+	}
+	for i := range psc.Parallel {
+		answer = append(answer, leafStageNames(&psc.Parallel[i])...)
+	}
+	return answer
+}
+
+// stepTitle returns the title of the step in a PipelineActivity for the name of its container the same way as the
+// build controller
+func stepTitle(containerName string) string {
+	name := strings.Replace(strings.TrimPrefix(containerName, "step-"), "-", " ", -1)
+	return strings.Title(name)
+}
+
+// Matches returns true if the log line was written by a stage or step of the node
+func (n *TreeNode) Matches(stage string, step string) bool {
+	if len(n.Stages) == 0 {
+		return true
+	}
+	found := false
+	for _, s := range n.Stages {
+		if s == stage {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+	return n.Step == "" || n.Step == stepTitle(step)
+}
@@ -0,0 +1,130 @@
+// +build unit
+
+package dashboard_test
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/dashboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// newTestStructure returns the structure of a pipeline with a build stage and a parent stage of two parallel stages
+func newTestStructure() *v1.PipelineStructure {
+	return &v1.PipelineStructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "jx-testing-myapp-master-1"},
+		Stages: []v1.PipelineStructureStage{
+			{Name: "build", TaskRef: stringPtr("build-task"), Depth: 0},
+			{Name: "tests", Parallel: []string{"unit-tests", "e2e-tests"}, Depth: 0},
+			{Name: "unit-tests", TaskRef: stringPtr("unit-task"), Depth: 1, Parent: stringPtr("tests")},
+			{Name: "e2e-tests", TaskRef: stringPtr("e2e-task"), Depth: 1, Parent: stringPtr("tests")},
+		},
+	}
+}
+
+func newTestStage(name string, status v1.ActivityStatusType, steps ...v1.CoreActivityStep) v1.PipelineActivityStep {
+	return v1.PipelineActivityStep{
+		Kind: v1.ActivityStepKindTypeStage,
+		Stage: &v1.StageActivityStep{
+			CoreActivityStep: v1.CoreActivityStep{Name: name, Status: status},
+			Steps:            steps,
+		},
+	}
+}
+
+func newTestActivity(name string, status v1.ActivityStatusType, steps ...v1.PipelineActivityStep) *v1.PipelineActivity {
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "jx"},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:      "jx-testing/myapp/master",
+			Build:         "1",
+			Status:        status,
+			GitOwner:      "jx-testing",
+			GitRepository: "myapp",
+			GitBranch:     "master",
+			Steps:         steps,
+		},
+	}
+}
+
+func treeLabels(nodes []dashboard.TreeNode) []string {
+	labels := []string{}
+	for _, n := range nodes {
+		labels = append(labels, n.Label)
+	}
+	return labels
+}
+
+func TestStageTree(t *testing.T) {
+	t.Parallel()
+
+	activity := newTestActivity("jx-testing-myapp-master-1", v1.ActivityStatusTypeRunning,
+		newTestStage("meta pipeline", v1.ActivityStatusTypeSucceeded, v1.CoreActivityStep{Name: "Create Tekton Crds", Status: v1.ActivityStatusTypeSucceeded}),
+		newTestStage("build", v1.ActivityStatusTypeSucceeded,
+			v1.CoreActivityStep{Name: "Git Clone", Status: v1.ActivityStatusTypeSucceeded},
+			v1.CoreActivityStep{Name: "Build Make", Status: v1.ActivityStatusTypeSucceeded}),
+		newTestStage("tests / unit tests", v1.ActivityStatusTypeRunning, v1.CoreActivityStep{Name: "Test", Status: v1.ActivityStatusTypeRunning}),
+		newTestStage("tests / e2e tests", v1.ActivityStatusTypePending),
+	)
+
+	nodes := dashboard.StageTree(newTestStructure(), activity)
+	assert.Equal(t, []string{"jx-testing/myapp/master #1", "meta pipeline", "Create Tekton Crds", "build", "Git Clone", "Build Make", "tests", "unit tests", "Test", "e2e tests"}, treeLabels(nodes))
+
+	depths := []int{}
+	for _, n := range nodes {
+		depths = append(depths, n.Depth)
+	}
+	assert.Equal(t, []int{0, 1, 2, 1, 2, 2, 1, 2, 3, 2}, depths)
+
+	root := nodes[0]
+	assert.Equal(t, v1.ActivityStatusTypeRunning, root.Status)
+	assert.Empty(t, root.Stages)
+	assert.True(t, root.Matches("", ""))
+
+	meta := nodes[1]
+	assert.Equal(t, []string{"meta-pipeline"}, meta.Stages)
+
+	tests := nodes[6]
+	assert.Equal(t, []string{"unit-tests", "e2e-tests"}, tests.Stages)
+	assert.True(t, tests.Matches("e2e-tests", "step-test"))
+	assert.False(t, tests.Matches("build", "step-build-make"))
+
+	unitTests := nodes[7]
+	assert.Equal(t, v1.ActivityStatusTypeRunning, unitTests.Status)
+	assert.Equal(t, v1.ActivityStatusTypePending, nodes[9].Status)
+
+	buildMake := nodes[5]
+	assert.Equal(t, "Build Make", buildMake.Step)
+	assert.True(t, buildMake.Matches("build", "step-build-make"))
+	assert.False(t, buildMake.Matches("build", "step-git-clone"))
+	assert.False(t, buildMake.Matches("unit-tests", "step-build-make"))
+}
+
+func TestStageTreeWithoutStructure(t *testing.T) {
+	t.Parallel()
+
+	promote := v1.PipelineActivityStep{
+		Kind: v1.ActivityStepKindTypePromote,
+		Promote: &v1.PromoteActivityStep{
+			CoreActivityStep: v1.CoreActivityStep{Name: "promote: staging", Status: v1.ActivityStatusTypeRunning},
+			Environment:      "staging",
+		},
+	}
+	activity := newTestActivity("jx-testing-myapp-master-1", v1.ActivityStatusTypeRunning,
+		newTestStage("from build pack", v1.ActivityStatusTypeSucceeded, v1.CoreActivityStep{Name: "Build Make", Status: v1.ActivityStatusTypeSucceeded}),
+		promote,
+	)
+
+	nodes := dashboard.StageTree(nil, activity)
+	require.Len(t, nodes, 4)
+	assert.Equal(t, []string{"jx-testing/myapp/master #1", "from build pack", "Build Make", "Promote: staging"}, treeLabels(nodes))
+	assert.True(t, nodes[2].Matches("from-build-pack", "step-build-make"))
+	assert.Equal(t, v1.ActivityStatusTypeRunning, nodes[3].Status)
+}
@@ -0,0 +1,234 @@
+package environments
+
+import (
+	"fmt"
+	"path/filepath"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// BlueprintResourceQuotaName the name of the ResourceQuota created in the namespace of an environment from its blueprint
+const BlueprintResourceQuotaName = "jx-blueprint"
+
+// DefaultBlueprintOrder the order of an environment created from a blueprint which does not specify an order
+const DefaultBlueprintOrder int32 = 100
+
+// ApplyBlueprintDefaults defaults any missing configuration of the environment from the blueprint
+func ApplyBlueprintDefaults(env *v1.Environment, blueprint *versionstream.EnvironmentBlueprint) {
+	if env.Spec.Label == "" {
+		env.Spec.Label = blueprint.Label
+	}
+	if env.Spec.PromotionStrategy == "" {
+		env.Spec.PromotionStrategy = v1.PromotionStrategyType(blueprint.PromotionStrategy)
+	}
+	if env.Spec.Order == 0 {
+		env.Spec.Order = blueprint.Order
+	}
+	if env.Spec.Order == 0 {
+		env.Spec.Order = DefaultBlueprintOrder
+	}
+}
+
+// SetBlueprintAnnotations records the blueprint and its version on the environment so that it can be upgraded later
+func SetBlueprintAnnotations(env *v1.Environment, blueprint *versionstream.EnvironmentBlueprint) {
+	if env.Annotations == nil {
+		env.Annotations = map[string]string{}
+	}
+	env.Annotations[kube.AnnotationBlueprint] = blueprint.Name
+	env.Annotations[kube.AnnotationBlueprintVersion] = blueprint.Version
+}
+
+// BlueprintName returns the name of the blueprint the environment was created from or blank if it was not
+// created from a blueprint
+func BlueprintName(env *v1.Environment) string {
+	if env.Annotations == nil {
+		return ""
+	}
+	return env.Annotations[kube.AnnotationBlueprint]
+}
+
+// BlueprintVersion returns the version of the blueprint which was last applied to the environment
+func BlueprintVersion(env *v1.Environment) string {
+	if env.Annotations == nil {
+		return ""
+	}
+	return env.Annotations[kube.AnnotationBlueprintVersion]
+}
+
+// ApplyBlueprintToChart adds the charts of the blueprint to the requirements of the environment chart and merges
+// the blueprint values into the values of the environment chart
+func ApplyBlueprintToChart(blueprint *versionstream.EnvironmentBlueprint, requirements *helm.Requirements, values map[string]interface{}) {
+	for _, c := range blueprint.Charts {
+		requirements.SetAppVersion(c.ChartName(), c.Version, c.Repository, c.Alias)
+	}
+	if len(blueprint.Values) > 0 {
+		util.CombineMapTrees(values, blueprint.Values)
+	}
+}
+
+// BlueprintModifyChartFn returns the function to modify the environment chart in a Pull Request to apply the blueprint
+func BlueprintModifyChartFn(blueprint *versionstream.EnvironmentBlueprint) ModifyChartFn {
+	return func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+		templates map[string]string, dir string, details *gits.PullRequestDetails) error {
+		ApplyBlueprintToChart(blueprint, requirements, values)
+		return nil
+	}
+}
+
+// ApplyBlueprintToDir applies the blueprint to the environment chart in the 'env' folder of the git clone.
+// Saving the values file drops any comments in it so the values file is only saved if the blueprint has values
+func ApplyBlueprintToDir(dir string, blueprint *versionstream.EnvironmentBlueprint) error {
+	chartDir := filepath.Join(dir, helm.DefaultEnvironmentChartDir)
+	requirementsFile := filepath.Join(chartDir, helm.RequirementsFileName)
+	requirements, err := helm.LoadRequirementsFile(requirementsFile)
+	if err != nil {
+		return errors.Wrapf(err, "loading %s", requirementsFile)
+	}
+	valuesFile := filepath.Join(chartDir, helm.ValuesFileName)
+	values, err := helm.LoadValuesFile(valuesFile)
+	if err != nil {
+		return errors.Wrapf(err, "loading %s", valuesFile)
+	}
+	ApplyBlueprintToChart(blueprint, requirements, values)
+
+	err = helm.SaveFile(requirementsFile, requirements)
+	if err != nil {
+		return errors.Wrapf(err, "saving %s", requirementsFile)
+	}
+	if len(blueprint.Values) == 0 {
+		return nil
+	}
+	err = helm.SaveFile(valuesFile, values)
+	if err != nil {
+		return errors.Wrapf(err, "saving %s", valuesFile)
+	}
+	return nil
+}
+
+// BlueprintRoleBindings returns the EnvironmentRoleBindings of the blueprint for the environment
+func BlueprintRoleBindings(env *v1.Environment, blueprint *versionstream.EnvironmentBlueprint, ns string) []*v1.EnvironmentRoleBinding {
+	answer := []*v1.EnvironmentRoleBinding{}
+	for _, rb := range blueprint.RoleBindings {
+		answer = append(answer, &v1.EnvironmentRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      naming.ToValidName(env.Name + "-" + rb.Name),
+				Namespace: ns,
+				Labels: map[string]string{
+					kube.LabelEnvironment: env.Name,
+				},
+				Annotations: map[string]string{
+					kube.AnnotationBlueprint:        blueprint.Name,
+					kube.AnnotationBlueprintVersion: blueprint.Version,
+				},
+			},
+			Spec: v1.EnvironmentRoleBindingSpec{
+				RoleRef:  rb.RoleRef,
+				Subjects: rb.Subjects,
+				Environments: []v1.EnvironmentFilter{
+					{
+						Includes: []string{env.Name},
+					},
+				},
+			},
+		})
+	}
+	return answer
+}
+
+// BlueprintResourceQuota returns the ResourceQuota of the blueprint for the environment namespace or nil if the
+// blueprint has no quota
+func BlueprintResourceQuota(env *v1.Environment, blueprint *versionstream.EnvironmentBlueprint) *corev1.ResourceQuota {
+	if blueprint.ResourceQuota == nil {
+		return nil
+	}
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BlueprintResourceQuotaName,
+			Namespace: env.Spec.Namespace,
+			Labels: map[string]string{
+				kube.LabelEnvironment: env.Name,
+			},
+			Annotations: map[string]string{
+				kube.AnnotationBlueprint:        blueprint.Name,
+				kube.AnnotationBlueprintVersion: blueprint.Version,
+			},
+		},
+		Spec: *blueprint.ResourceQuota.DeepCopy(),
+	}
+}
+
+// ApplyBlueprintResources creates or updates the EnvironmentRoleBindings of the blueprint in the development namespace
+// and the ResourceQuota of the blueprint in the environment namespace
+func ApplyBlueprintResources(kubeClient kubernetes.Interface, jxClient versioned.Interface, devNs string, env *v1.Environment, blueprint *versionstream.EnvironmentBlueprint) error {
+	envRoleInterface := jxClient.JenkinsV1().EnvironmentRoleBindings(devNs)
+	for _, rb := range BlueprintRoleBindings(env, blueprint, devNs) {
+		existing, err := envRoleInterface.Get(rb.Name, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "getting EnvironmentRoleBinding %s", rb.Name)
+			}
+			_, err = envRoleInterface.Create(rb)
+			if err != nil {
+				return errors.Wrapf(err, "creating EnvironmentRoleBinding %s", rb.Name)
+			}
+			log.Logger().Infof("Created EnvironmentRoleBinding %s", util.ColorInfo(rb.Name))
+			continue
+		}
+		existing.Labels = util.MergeMaps(existing.Labels, rb.Labels)
+		existing.Annotations = util.MergeMaps(existing.Annotations, rb.Annotations)
+		existing.Spec = rb.Spec
+		_, err = envRoleInterface.Update(existing)
+		if err != nil {
+			return errors.Wrapf(err, "updating EnvironmentRoleBinding %s", rb.Name)
+		}
+		log.Logger().Infof("Updated EnvironmentRoleBinding %s", util.ColorInfo(rb.Name))
+	}
+
+	quota := BlueprintResourceQuota(env, blueprint)
+	if quota == nil {
+		return nil
+	}
+	if env.Spec.Namespace == "" {
+		return fmt.Errorf("no namespace is defined for Environment %s", env.Name)
+	}
+	if env.Spec.RemoteCluster {
+		log.Logger().Warnf("Environment %s is in a remote cluster so not applying the ResourceQuota of blueprint %s", env.Name, blueprint.Name)
+		return nil
+	}
+	quotaInterface := kubeClient.CoreV1().ResourceQuotas(env.Spec.Namespace)
+	existing, err := quotaInterface.Get(quota.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "getting ResourceQuota %s in namespace %s", quota.Name, env.Spec.Namespace)
+		}
+		_, err = quotaInterface.Create(quota)
+		if err != nil {
+			return errors.Wrapf(err, "creating ResourceQuota %s in namespace %s", quota.Name, env.Spec.Namespace)
+		}
+		log.Logger().Infof("Created ResourceQuota %s in namespace %s", util.ColorInfo(quota.Name), util.ColorInfo(env.Spec.Namespace))
+		return nil
+	}
+	existing.Labels = util.MergeMaps(existing.Labels, quota.Labels)
+	existing.Annotations = util.MergeMaps(existing.Annotations, quota.Annotations)
+	existing.Spec = quota.Spec
+	_, err = quotaInterface.Update(existing)
+	if err != nil {
+		return errors.Wrapf(err, "updating ResourceQuota %s in namespace %s", quota.Name, env.Spec.Namespace)
+	}
+	log.Logger().Infof("Updated ResourceQuota %s in namespace %s", util.ColorInfo(quota.Name), util.ColorInfo(env.Spec.Namespace))
+	return nil
+}
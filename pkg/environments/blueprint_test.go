@@ -0,0 +1,181 @@
+// +build unit
+
+package environments_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func testBlueprint(version string, cpu string) *versionstream.EnvironmentBlueprint {
+	return &versionstream.EnvironmentBlueprint{
+		Name:              "perf-test",
+		Version:           version,
+		Label:             "Performance Test",
+		Order:             150,
+		PromotionStrategy: "Manual",
+		Charts: []versionstream.BlueprintChart{
+			{
+				Name:       "stable/postgresql",
+				Repository: "https://kubernetes-charts.storage.googleapis.com",
+				Version:    "8.6.4",
+				Alias:      "db",
+			},
+		},
+		Values: map[string]interface{}{
+			"db": map[string]interface{}{
+				"persistence": map[string]interface{}{
+					"size": "20Gi",
+				},
+			},
+		},
+		RoleBindings: []versionstream.BlueprintRoleBinding{
+			{
+				Name: "testers",
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "ClusterRole",
+					Name:     "edit",
+				},
+				Subjects: []rbacv1.Subject{
+					{
+						APIGroup: "rbac.authorization.k8s.io",
+						Kind:     "Group",
+						Name:     "perf-testers",
+					},
+				},
+			},
+		},
+		ResourceQuota: &corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse(cpu),
+			},
+		},
+	}
+}
+
+func TestApplyBlueprintToChart(t *testing.T) {
+	t.Parallel()
+
+	blueprint := testBlueprint("1.0.0", "8")
+	requirements := &helm.Requirements{
+		Dependencies: []*helm.Dependency{
+			{
+				Name:       "postgresql",
+				Alias:      "db",
+				Version:    "8.0.0",
+				Repository: "https://kubernetes-charts.storage.googleapis.com",
+			},
+		},
+	}
+	values := map[string]interface{}{
+		"db": map[string]interface{}{
+			"persistence": map[string]interface{}{
+				"size":    "8Gi",
+				"enabled": true,
+			},
+		},
+	}
+
+	environments.ApplyBlueprintToChart(blueprint, requirements, values)
+
+	require.Len(t, requirements.Dependencies, 1)
+	assert.Equal(t, "8.6.4", requirements.Dependencies[0].Version)
+	persistence := values["db"].(map[string]interface{})["persistence"].(map[string]interface{})
+	assert.Equal(t, "20Gi", persistence["size"])
+	assert.Equal(t, true, persistence["enabled"], "existing values should be preserved")
+
+	env := &v1.Environment{}
+	env.Spec.Label = "Perf"
+	environments.ApplyBlueprintDefaults(env, blueprint)
+	assert.Equal(t, "Perf", env.Spec.Label)
+	assert.Equal(t, v1.PromotionStrategyTypeManual, env.Spec.PromotionStrategy)
+	assert.Equal(t, int32(150), env.Spec.Order)
+
+	environments.SetBlueprintAnnotations(env, blueprint)
+	assert.Equal(t, "perf-test", environments.BlueprintName(env))
+	assert.Equal(t, "1.0.0", environments.BlueprintVersion(env))
+
+	env = &v1.Environment{}
+	blueprint.Order = 0
+	environments.ApplyBlueprintDefaults(env, blueprint)
+	assert.Equal(t, environments.DefaultBlueprintOrder, env.Spec.Order, "the default order should be used if the blueprint has no order")
+}
+
+func TestApplyBlueprintToDir(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-blueprint-dir-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	chartDir := filepath.Join(dir, helm.DefaultEnvironmentChartDir)
+	require.NoError(t, os.MkdirAll(chartDir, 0755))
+	valuesFile := filepath.Join(chartDir, helm.ValuesFileName)
+	valuesYaml := "# the size of the database\ndb:\n  persistence:\n    size: 8Gi\n"
+	require.NoError(t, ioutil.WriteFile(valuesFile, []byte(valuesYaml), 0600))
+	require.NoError(t, helm.SaveFile(filepath.Join(chartDir, helm.RequirementsFileName), &helm.Requirements{}))
+
+	blueprint := testBlueprint("1.0.0", "8")
+	blueprint.Values = nil
+	err = environments.ApplyBlueprintToDir(dir, blueprint)
+	require.NoError(t, err)
+
+	requirements, err := helm.LoadRequirementsFile(filepath.Join(chartDir, helm.RequirementsFileName))
+	require.NoError(t, err)
+	require.Len(t, requirements.Dependencies, 1)
+	assert.Equal(t, "db", requirements.Dependencies[0].Alias)
+	data, err := ioutil.ReadFile(valuesFile)
+	require.NoError(t, err)
+	assert.Equal(t, valuesYaml, string(data), "the values file should not be rewritten if the blueprint has no values")
+
+	err = environments.ApplyBlueprintToDir(dir, testBlueprint("1.0.0", "8"))
+	require.NoError(t, err)
+	values, err := helm.LoadValuesFile(valuesFile)
+	require.NoError(t, err)
+	assert.Equal(t, "20Gi", values["db"].(map[string]interface{})["persistence"].(map[string]interface{})["size"])
+}
+
+func TestApplyBlueprintResources(t *testing.T) {
+	t.Parallel()
+
+	env := kube.NewPermanentEnvironment("perf")
+	env.Spec.Namespace = "jx-perf"
+	kubeClient := kubefake.NewSimpleClientset()
+	jxClient := fake.NewSimpleClientset()
+
+	err := environments.ApplyBlueprintResources(kubeClient, jxClient, devNs, env, testBlueprint("1.0.0", "8"))
+	require.NoError(t, err)
+
+	// applying a newer version of the blueprint updates the resources
+	err = environments.ApplyBlueprintResources(kubeClient, jxClient, devNs, env, testBlueprint("1.1.0", "16"))
+	require.NoError(t, err)
+
+	rb, err := jxClient.JenkinsV1().EnvironmentRoleBindings(devNs).Get("perf-testers", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "edit", rb.Spec.RoleRef.Name)
+	assert.Equal(t, []string{"perf"}, rb.Spec.Environments[0].Includes)
+	assert.Equal(t, "1.1.0", rb.Annotations[kube.AnnotationBlueprintVersion])
+
+	quota, err := kubeClient.CoreV1().ResourceQuotas("jx-perf").Get(environments.BlueprintResourceQuotaName, metav1.GetOptions{})
+	require.NoError(t, err)
+	cpu := quota.Spec.Hard[corev1.ResourceRequestsCPU]
+	assert.Equal(t, "16", cpu.String())
+	assert.Equal(t, "perf", quota.Labels[kube.LabelEnvironment])
+}
@@ -0,0 +1,379 @@
+package environments
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+)
+
+// DriftType is the kind of difference between the environment git repository and the cluster
+type DriftType string
+
+const (
+	// DriftTypeAdded the resource exists in the cluster but not in the environment git repository
+	DriftTypeAdded DriftType = "Added"
+	// DriftTypeRemoved the resource is in the environment git repository but missing from the cluster
+	DriftTypeRemoved DriftType = "Removed"
+	// DriftTypeModified the resource in the cluster differs from the environment git repository
+	DriftTypeModified DriftType = "Modified"
+)
+
+// ignoredTopLevelFields are not compared as they are populated by the cluster
+var ignoredTopLevelFields = map[string]bool{
+	"metadata": true,
+	"status":   true,
+}
+
+// ResourceDrift describes a resource which differs between the environment git repository and the cluster
+type ResourceDrift struct {
+	APIVersion  string
+	Kind        string
+	Namespace   string
+	Name        string
+	Type        DriftType
+	Differences []string
+}
+
+// String returns a textual description of the drift
+func (d *ResourceDrift) String() string {
+	return fmt.Sprintf("%s %s", d.Type, d.Key())
+}
+
+// Key returns the unique key of the drifted resource
+func (d *ResourceDrift) Key() string {
+	return resourceKey(d.APIVersion, d.Kind, d.Namespace, d.Name)
+}
+
+func resourceKey(apiVersion string, kind string, ns string, name string) string {
+	key := apiVersion + "/" + kind + "/"
+	if ns != "" {
+		key += ns + "/"
+	}
+	return key + name
+}
+
+// ResourceKey returns the unique key of the resource
+func ResourceKey(u *unstructured.Unstructured) string {
+	return resourceKey(u.GetAPIVersion(), u.GetKind(), u.GetNamespace(), u.GetName())
+}
+
+// LoadResources loads all the kubernetes resources in the YAML files in the given directory tree
+// such as the output of 'helm template'
+func LoadResources(dir string, defaultNamespace string) ([]*unstructured.Unstructured, error) {
+	answer := []*unstructured.Unstructured{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load file %s", path)
+		}
+		resources, err := ParseResources(data, defaultNamespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse resources in file %s", path)
+		}
+		answer = append(answer, resources...)
+		return nil
+	})
+	return answer, err
+}
+
+// ParseResources parses the kubernetes resources in the given multi document YAML
+func ParseResources(data []byte, defaultNamespace string) ([]*unstructured.Unstructured, error) {
+	answer := []*unstructured.Unstructured{}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return answer, errors.Wrap(err, "failed to read YAML document")
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		jsonData, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return answer, errors.Wrap(err, "failed to convert YAML to JSON")
+		}
+		if string(jsonData) == "null" {
+			continue
+		}
+		u := &unstructured.Unstructured{}
+		err = u.UnmarshalJSON(jsonData)
+		if err != nil {
+			return answer, errors.Wrap(err, "failed to unmarshal resource")
+		}
+		if u.IsList() {
+			list, err := u.ToList()
+			if err != nil {
+				return answer, err
+			}
+			for i := range list.Items {
+				answer = appendResource(answer, &list.Items[i], defaultNamespace)
+			}
+			continue
+		}
+		answer = appendResource(answer, u, defaultNamespace)
+	}
+	return answer, nil
+}
+
+// appendResource appends the resource if it has a kind and name, defaulting its namespace
+func appendResource(answer []*unstructured.Unstructured, u *unstructured.Unstructured, defaultNamespace string) []*unstructured.Unstructured {
+	if u.GetKind() == "" || u.GetName() == "" {
+		return answer
+	}
+	if u.GetNamespace() == "" {
+		u.SetNamespace(defaultNamespace)
+	}
+	return append(answer, u)
+}
+
+// CompareResources compares the desired resources from the environment git repository with the live resources
+// in the cluster. Only fields specified in the desired resources are compared so that defaulted fields in the
+// live resources are not reported as drift.
+func CompareResources(desired []*unstructured.Unstructured, live []*unstructured.Unstructured) []ResourceDrift {
+	liveMap := map[string]*unstructured.Unstructured{}
+	for _, u := range live {
+		liveMap[ResourceKey(u)] = u
+	}
+	desiredKeys := map[string]bool{}
+	answer := []ResourceDrift{}
+	for _, d := range desired {
+		key := ResourceKey(d)
+		desiredKeys[key] = true
+		l := liveMap[key]
+		if l == nil {
+			answer = append(answer, newResourceDrift(d, DriftTypeRemoved, nil))
+			continue
+		}
+		differences := ResourceDifferences(d, l)
+		if len(differences) > 0 {
+			answer = append(answer, newResourceDrift(d, DriftTypeModified, differences))
+		}
+	}
+	for _, l := range live {
+		if !desiredKeys[ResourceKey(l)] {
+			answer = append(answer, newResourceDrift(l, DriftTypeAdded, nil))
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Key() < answer[j].Key()
+	})
+	return answer
+}
+
+func newResourceDrift(u *unstructured.Unstructured, driftType DriftType, differences []string) ResourceDrift {
+	return ResourceDrift{
+		APIVersion:  u.GetAPIVersion(),
+		Kind:        u.GetKind(),
+		Namespace:   u.GetNamespace(),
+		Name:        u.GetName(),
+		Type:        driftType,
+		Differences: differences,
+	}
+}
+
+// ResourceDifferences returns the paths of the fields in the desired resource which differ in the live resource
+func ResourceDifferences(desired *unstructured.Unstructured, live *unstructured.Unstructured) []string {
+	answer := []string{}
+	if desired.GetKind() == "Secret" {
+		// secret values are usually populated from vault so lets only compare their existence
+		return answer
+	}
+	answer = append(answer, diffValues("metadata.labels", toInterfaceMap(desired.GetLabels()), toInterfaceMap(live.GetLabels()))...)
+	answer = append(answer, diffValues("metadata.annotations", toInterfaceMap(desired.GetAnnotations()), toInterfaceMap(live.GetAnnotations()))...)
+
+	keys := []string{}
+	for k := range desired.Object {
+		if !ignoredTopLevelFields[k] && k != "apiVersion" && k != "kind" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		answer = append(answer, diffValues(k, desired.Object[k], live.Object[k])...)
+	}
+	return answer
+}
+
+// diffValues returns the paths where the live value does not contain the desired value
+func diffValues(path string, desired interface{}, live interface{}) []string {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			if len(d) == 0 && live == nil {
+				return nil
+			}
+			return []string{path}
+		}
+		keys := []string{}
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		answer := []string{}
+		for _, k := range keys {
+			answer = append(answer, diffValues(path+"."+k, d[k], l[k])...)
+		}
+		return answer
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			if len(d) == 0 && live == nil {
+				return nil
+			}
+			return []string{path}
+		}
+		if len(d) != len(l) {
+			return []string{path}
+		}
+		answer := []string{}
+		for i := range d {
+			answer = append(answer, diffValues(fmt.Sprintf("%s[%d]", path, i), d[i], l[i])...)
+		}
+		return answer
+	case nil:
+		return nil
+	default:
+		if reflect.DeepEqual(desired, live) || fmt.Sprintf("%v", desired) == fmt.Sprintf("%v", live) {
+			return nil
+		}
+		return []string{path}
+	}
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	answer := map[string]interface{}{}
+	for k, v := range m {
+		answer[k] = v
+	}
+	return answer
+}
+
+// LiveResourceLoader loads the live resources from the cluster which correspond to the resources in an environment
+type LiveResourceLoader struct {
+	DynamicClient dynamic.Interface
+	Mapper        meta.RESTMapper
+	// Selector is an optional label selector used to find resources in the cluster which are not in the environment git repository
+	Selector string
+}
+
+// Load returns the live resources for the desired resources along with any other resources of the same kinds
+// in the namespace which match the selector
+func (l *LiveResourceLoader) Load(ns string, desired []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	answer := []*unstructured.Unstructured{}
+	found := map[string]bool{}
+	kinds := map[schema.GroupVersionKind]bool{}
+	for _, d := range desired {
+		gvk := d.GroupVersionKind()
+		kinds[gvk] = true
+		resource, namespaced, err := l.resourceFor(gvk)
+		if err != nil {
+			log.Logger().Warnf("ignoring resource %s as %s", ResourceKey(d), err.Error())
+			continue
+		}
+		var u *unstructured.Unstructured
+		if namespaced {
+			u, err = l.DynamicClient.Resource(resource).Namespace(d.GetNamespace()).Get(d.GetName(), metav1.GetOptions{})
+		} else {
+			u, err = l.DynamicClient.Resource(resource).Get(d.GetName(), metav1.GetOptions{})
+		}
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get the live resource %s", ResourceKey(d))
+		}
+		if u == nil {
+			continue
+		}
+		if !namespaced {
+			u.SetNamespace(d.GetNamespace())
+		}
+		// lets make sure the resource is keyed by the desired API version
+		u.SetAPIVersion(d.GetAPIVersion())
+		found[ResourceKey(u)] = true
+		answer = append(answer, u)
+	}
+	if l.Selector == "" {
+		return answer, nil
+	}
+	for gvk := range kinds {
+		resource, namespaced, err := l.resourceFor(gvk)
+		if err != nil || !namespaced {
+			continue
+		}
+		list, err := l.DynamicClient.Resource(resource).Namespace(ns).List(metav1.ListOptions{
+			LabelSelector: l.Selector,
+		})
+		if err != nil {
+			log.Logger().Warnf("failed to list %s in namespace %s: %s", resource.String(), ns, err.Error())
+			continue
+		}
+		for i := range list.Items {
+			u := &list.Items[i]
+			u.SetAPIVersion(gvk.GroupVersion().String())
+			u.SetKind(gvk.Kind)
+			key := ResourceKey(u)
+			if !found[key] {
+				found[key] = true
+				answer = append(answer, u)
+			}
+		}
+	}
+	return answer, nil
+}
+
+func (l *LiveResourceLoader) resourceFor(gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error) {
+	mapping, err := l.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, errors.Wrapf(err, "no resource mapping for %s", gvk.String())
+	}
+	return mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// DriftMarkdown returns a markdown report of the drift of the given environment
+func DriftMarkdown(envName string, drifts []ResourceDrift) string {
+	var buffer strings.Builder
+	buffer.WriteString(fmt.Sprintf("The environment **%s** has drifted from its git repository:\n\n", envName))
+	buffer.WriteString("| Change | Kind | Name | Fields |\n")
+	buffer.WriteString("| --- | --- | --- | --- |\n")
+	for _, d := range drifts {
+		name := d.Name
+		if d.Namespace != "" {
+			name = d.Namespace + "/" + d.Name
+		}
+		buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", d.Type, d.Kind, name, strings.Join(d.Differences, ", ")))
+	}
+	return buffer.String()
+}
@@ -0,0 +1,224 @@
+// +build unit
+
+package environments_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const desiredYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+  labels:
+    jenkins.io/chart-release: jx-staging
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: myapp
+        image: myapp:1.0.1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  labels:
+    jenkins.io/chart-release: jx-staging
+data:
+  foo: bar
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+data:
+  password: c2VjcmV0
+`
+
+const liveYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+  namespace: jx-staging
+  resourceVersion: "123"
+  labels:
+    jenkins.io/chart-release: jx-staging
+spec:
+  replicas: 3
+  progressDeadlineSeconds: 600
+  template:
+    spec:
+      containers:
+      - name: myapp
+        image: myapp:1.0.1
+        imagePullPolicy: IfNotPresent
+status:
+  readyReplicas: 3
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: jx-staging
+data:
+  password: Y2hhbmdlZA==
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: manual
+  namespace: jx-staging
+  labels:
+    jenkins.io/chart-release: jx-staging
+data:
+  foo: bar
+`
+
+func TestCompareResources(t *testing.T) {
+	t.Parallel()
+
+	desired, err := environments.ParseResources([]byte(desiredYAML), "jx-staging")
+	require.NoError(t, err)
+	require.Len(t, desired, 3)
+	live, err := environments.ParseResources([]byte(liveYAML), "")
+	require.NoError(t, err)
+
+	drifts := environments.CompareResources(desired, live)
+	require.Len(t, drifts, 3)
+
+	assert.Equal(t, environments.DriftTypeModified, drifts[0].Type)
+	assert.Equal(t, "myapp", drifts[0].Name)
+	assert.Equal(t, []string{"spec.replicas"}, drifts[0].Differences)
+
+	assert.Equal(t, environments.DriftTypeRemoved, drifts[1].Type)
+	assert.Equal(t, "config", drifts[1].Name)
+
+	assert.Equal(t, environments.DriftTypeAdded, drifts[2].Type)
+	assert.Equal(t, "manual", drifts[2].Name)
+
+	markdown := environments.DriftMarkdown("staging", drifts)
+	assert.Contains(t, markdown, "| Modified | Deployment | jx-staging/myapp | spec.replicas |")
+}
+
+func newTestLoader(objects ...runtime.Object) (*environments.LiveResourceLoader, *dynamicfake.FakeDynamicClient) {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DeploymentList"}, &unstructured.UnstructuredList{})
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMapList"}, &unstructured.UnstructuredList{})
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Version: "v1", Kind: "SecretList"}, &unstructured.UnstructuredList{})
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme, objects...)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+
+	loader := &environments.LiveResourceLoader{
+		DynamicClient: dynamicClient,
+		Mapper:        mapper,
+		Selector:      "jenkins.io/chart-release=jx-staging",
+	}
+	return loader, dynamicClient
+}
+
+func TestLiveResourceLoader(t *testing.T) {
+	t.Parallel()
+
+	desired, err := environments.ParseResources([]byte(desiredYAML), "jx-staging")
+	require.NoError(t, err)
+	live, err := environments.ParseResources([]byte(liveYAML), "")
+	require.NoError(t, err)
+
+	objects := []runtime.Object{}
+	for _, u := range live {
+		objects = append(objects, u)
+	}
+	loader, _ := newTestLoader(objects...)
+	loaded, err := loader.Load("jx-staging", desired)
+	require.NoError(t, err)
+
+	drifts := environments.CompareResources(desired, loaded)
+	names := []string{}
+	for _, d := range drifts {
+		names = append(names, d.String())
+	}
+	assert.Equal(t, []string{
+		"Modified apps/v1/Deployment/jx-staging/myapp",
+		"Removed v1/ConfigMap/jx-staging/config",
+		"Added v1/ConfigMap/jx-staging/manual",
+	}, names)
+}
+
+func TestLiveResourceLoaderFailsOnAPIErrors(t *testing.T) {
+	t.Parallel()
+
+	desired, err := environments.ParseResources([]byte(desiredYAML), "jx-staging")
+	require.NoError(t, err)
+
+	loader, dynamicClient := newTestLoader()
+	dynamicClient.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "myapp", nil)
+	})
+	_, err = loader.Load("jx-staging", desired)
+	require.Error(t, err, "an API error must not be reported as a removed resource")
+
+	loader, _ = newTestLoader()
+	loaded, err := loader.Load("jx-staging", desired)
+	require.NoError(t, err)
+	assert.Empty(t, loaded)
+}
+
+func TestParseResources(t *testing.T) {
+	t.Parallel()
+
+	data := `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+data:
+  script: |
+    echo "a --- b"
+    ---not-a-separator
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: second
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: third
+    namespace: jx-production
+---
+---
+`
+	resources, err := environments.ParseResources([]byte(data), "jx-staging")
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+	assert.Equal(t, "first", resources[0].GetName())
+	assert.Equal(t, "jx-staging", resources[0].GetNamespace())
+	script, _, _ := unstructured.NestedString(resources[0].Object, "data", "script")
+	assert.Equal(t, "echo \"a --- b\"\n---not-a-separator\n", script)
+	assert.Equal(t, "second", resources[1].GetName())
+	assert.Equal(t, "jx-staging", resources[1].GetNamespace(), "the items of a List should have the default namespace")
+	assert.Equal(t, "Secret", resources[2].GetKind())
+	assert.Equal(t, "jx-production", resources[2].GetNamespace())
+}
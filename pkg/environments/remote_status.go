@@ -0,0 +1,285 @@
+package environments
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/flagger"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/services"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RemoteStatusCollector collects the status of an environment running inside a remote cluster
+// so that it can be reported back to the development cluster
+type RemoteStatusCollector struct {
+	KubeClient kubernetes.Interface
+	JXClient   versioned.Interface
+	// Namespace the namespace in the remote cluster the environment is deployed into
+	Namespace string
+	// GitOwner and GitRepository identify the environment git repository
+	GitOwner      string
+	GitRepository string
+	// Branch the branch of the environment git repository which is applied
+	Branch string
+	// Cluster the name of the remote cluster
+	Cluster string
+}
+
+// Collect returns the current status of the environment in the remote cluster
+func (c *RemoteStatusCollector) Collect() (*v1.RemoteEnvironmentStatus, error) {
+	now := metav1.Now()
+	status := &v1.RemoteEnvironmentStatus{
+		Cluster:             c.Cluster,
+		LastReportTimestamp: &now,
+	}
+	activity, err := c.latestPipelineActivity()
+	if err != nil {
+		return nil, err
+	}
+	if activity != nil {
+		status.SyncStatus = ToRemoteSyncStatus(activity.Spec.Status)
+		status.Revision = activity.Spec.LastCommitSHA
+		status.LastSyncTimestamp = activity.Spec.CompletedTimestamp
+		status.Message = activity.Name
+	}
+
+	deployments, err := kube.GetDeployments(c.KubeClient, c.Namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list Deployments in namespace %s", c.Namespace)
+	}
+	for _, d := range deployments {
+		if flagger.IsCanaryAuxiliaryDeployment(d) {
+			continue
+		}
+		status.Applications = append(status.Applications, c.applicationStatus(d))
+	}
+	sort.Slice(status.Applications, func(i, j int) bool {
+		return status.Applications[i].Name < status.Applications[j].Name
+	})
+	return status, nil
+}
+
+// latestPipelineActivity returns the most recent PipelineActivity for the environment git repository
+func (c *RemoteStatusCollector) latestPipelineActivity() (*v1.PipelineActivity, error) {
+	list, err := c.JXClient.JenkinsV1().PipelineActivities(c.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", c.Namespace)
+	}
+	var answer *v1.PipelineActivity
+	for i := range list.Items {
+		a := &list.Items[i]
+		if !strings.EqualFold(a.Spec.GitOwner, c.GitOwner) || !strings.EqualFold(a.Spec.GitRepository, c.GitRepository) {
+			continue
+		}
+		if c.Branch != "" && a.Spec.GitBranch != "" && a.Spec.GitBranch != c.Branch {
+			continue
+		}
+		if answer == nil || activityStartTime(a).After(activityStartTime(answer)) {
+			answer = a
+		}
+	}
+	return answer, nil
+}
+
+func activityStartTime(a *v1.PipelineActivity) time.Time {
+	if a.Spec.StartedTimestamp != nil {
+		return a.Spec.StartedTimestamp.Time
+	}
+	return a.CreationTimestamp.Time
+}
+
+func (c *RemoteStatusCollector) applicationStatus(d appsv1.Deployment) v1.RemoteApplicationStatus {
+	var replicas int32 = 1
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	name := d.Name
+	labels, err := metav1.LabelSelectorAsMap(d.Spec.Selector)
+	if err == nil && labels["app"] != "" {
+		name = labels["app"]
+	}
+	name = kube.GetAppName(name, c.Namespace)
+	url, err := services.FindServiceURL(c.KubeClient, c.Namespace, name)
+	if err != nil {
+		log.Logger().Debugf("could not find the service URL for %s in namespace %s: %s", name, c.Namespace, err.Error())
+	}
+	return v1.RemoteApplicationStatus{
+		Name:          name,
+		Deployment:    d.Name,
+		Version:       kube.GetVersion(&d.ObjectMeta),
+		Replicas:      replicas,
+		ReadyReplicas: d.Status.ReadyReplicas,
+		Healthy:       IsDeploymentHealthy(&d),
+		URL:           url,
+	}
+}
+
+// IsDeploymentHealthy returns true if all the desired replicas of the deployment are ready
+// and the deployment has not failed to progress
+func IsDeploymentHealthy(d *appsv1.Deployment) bool {
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return false
+		}
+	}
+	var replicas int32 = 1
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.ReadyReplicas >= replicas
+}
+
+// ToRemoteSyncStatus converts the status of an environment pipeline to the remote sync status
+func ToRemoteSyncStatus(status v1.ActivityStatusType) v1.RemoteSyncStatusType {
+	switch status {
+	case v1.ActivityStatusTypeSucceeded:
+		return v1.RemoteSyncStatusSynced
+	case v1.ActivityStatusTypeFailed, v1.ActivityStatusTypeError, v1.ActivityStatusTypeAborted:
+		return v1.RemoteSyncStatusFailed
+	case v1.ActivityStatusTypeNone:
+		return v1.RemoteSyncStatusUnknown
+	default:
+		return v1.RemoteSyncStatusSyncing
+	}
+}
+
+// UpdateRemoteEnvironmentStatus stores the remote status on the Environment resource in the development cluster
+func UpdateRemoteEnvironmentStatus(jxClient versioned.Interface, devNs string, envName string, status *v1.RemoteEnvironmentStatus) (*v1.Environment, error) {
+	envInterface := jxClient.JenkinsV1().Environments(devNs)
+	env, err := envInterface.Get(envName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find Environment %s in namespace %s", envName, devNs)
+	}
+	env.Status.Remote = status
+	answer, err := envInterface.PatchUpdate(env)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update the status of Environment %s in namespace %s", envName, devNs)
+	}
+	return answer, nil
+}
+
+// UpdateRemoteReleases copies the Release resources from the remote cluster into the development cluster
+// setting their status based on the health of the applications reported in the remote status.
+// Releases are stored in the development namespace of the Environment, as its namespace only exists in the remote
+// cluster, with the name prefixed and labelled with the name of the Environment so that they can be found by promotion.
+// The copies of Releases which no longer exist in the remote cluster are removed.
+func UpdateRemoteReleases(remoteJXClient versioned.Interface, remoteNs string, devJXClient versioned.Interface, env *v1.Environment, status *v1.RemoteEnvironmentStatus) error {
+	list, err := remoteJXClient.JenkinsV1().Releases(remoteNs).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list Releases in namespace %s", remoteNs)
+	}
+	ns := env.Namespace
+	releaseInterface := devJXClient.JenkinsV1().Releases(ns)
+	names := map[string]bool{}
+	for i := range list.Items {
+		release := &list.Items[i]
+		releaseStatus := RemoteReleaseStatus(release, status)
+		name := kube.RemoteReleaseName(env.Name, release.Name)
+		names[name] = true
+
+		existing, err := releaseInterface.Get(name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get Release %s in namespace %s", name, ns)
+		}
+		if err != nil {
+			labels := map[string]string{}
+			for k, v := range release.Labels {
+				labels[k] = v
+			}
+			labels[kube.LabelEnvironment] = env.Name
+			remoteRelease := &v1.Release{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        name,
+					Labels:      labels,
+					Annotations: release.Annotations,
+				},
+				Spec:   release.Spec,
+				Status: v1.ReleaseStatus{Status: releaseStatus},
+			}
+			_, err = releaseInterface.Create(remoteRelease)
+			if err != nil {
+				log.Logger().Warnf("failed to create Release %s in namespace %s: %s", name, ns, err.Error())
+			}
+			continue
+		}
+		if existing.Status.Status == releaseStatus && reflect.DeepEqual(existing.Spec, release.Spec) {
+			continue
+		}
+		existing.Spec = release.Spec
+		existing.Status.Status = releaseStatus
+		_, err = releaseInterface.PatchUpdate(existing)
+		if err != nil {
+			log.Logger().Warnf("failed to update Release %s in namespace %s: %s", name, ns, err.Error())
+		}
+	}
+
+	copies, err := releaseInterface.List(metav1.ListOptions{
+		LabelSelector: kube.LabelEnvironment + "=" + env.Name,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the Releases of environment %s in namespace %s", env.Name, ns)
+	}
+	prefix := kube.RemoteReleaseName(env.Name, "")
+	for _, release := range copies.Items {
+		if names[release.Name] || !strings.HasPrefix(release.Name, prefix) {
+			continue
+		}
+		err = releaseInterface.Delete(release.Name, &metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Logger().Warnf("failed to delete Release %s in namespace %s: %s", release.Name, ns, err.Error())
+		}
+	}
+	return nil
+}
+
+// RemoteReleaseStatus returns the status of the release based on the applications in the remote status
+func RemoteReleaseStatus(release *v1.Release, status *v1.RemoteEnvironmentStatus) v1.ReleaseStatusType {
+	if status == nil {
+		return v1.ReleaseStatusTypePending
+	}
+	for _, app := range status.Applications {
+		if app.Name != release.Spec.Name || app.Version != release.Spec.Version {
+			continue
+		}
+		if app.Healthy {
+			return v1.ReleaseStatusTypeDeployed
+		}
+		if status.SyncStatus == v1.RemoteSyncStatusFailed {
+			return v1.ReleaseStatusTypeFailed
+		}
+		return v1.ReleaseStatusTypePending
+	}
+	if status.SyncStatus == v1.RemoteSyncStatusFailed {
+		return v1.ReleaseStatusTypeFailed
+	}
+	return v1.ReleaseStatusTypePending
+}
+
+// RemoteStatusSummary returns a short description of the remote status
+func RemoteStatusSummary(status *v1.RemoteEnvironmentStatus) string {
+	if status == nil {
+		return ""
+	}
+	healthy := 0
+	for _, app := range status.Applications {
+		if app.Healthy {
+			healthy++
+		}
+	}
+	syncStatus := string(status.SyncStatus)
+	if syncStatus == "" {
+		syncStatus = "Unknown"
+	}
+	return fmt.Sprintf("%s %d/%d healthy", syncStatus, healthy, len(status.Applications))
+}
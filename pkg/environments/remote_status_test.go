@@ -0,0 +1,246 @@
+// +build unit
+
+package environments_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const (
+	remoteNs = "jx-production"
+	devNs    = "jx"
+)
+
+func TestCollectRemoteStatus(t *testing.T) {
+	t.Parallel()
+
+	kubeClient := kubefake.NewSimpleClientset(
+		deployment("jx-myapp", "myapp", "1.0.1", 2, 2),
+		deployment("jx-other", "other", "0.0.3", 1, 0),
+	)
+	jxClient := fake.NewSimpleClientset(
+		activity("myorg-environment-production-master-1", v1.ActivityStatusTypeSucceeded, "abc", time.Hour),
+		activity("myorg-environment-production-master-2", v1.ActivityStatusTypeRunning, "def", time.Minute),
+	)
+
+	collector := &environments.RemoteStatusCollector{
+		KubeClient:    kubeClient,
+		JXClient:      jxClient,
+		Namespace:     remoteNs,
+		GitOwner:      "myorg",
+		GitRepository: "environment-production",
+		Branch:        "master",
+		Cluster:       "prod-cluster",
+	}
+	status, err := collector.Collect()
+	require.NoError(t, err)
+
+	assert.Equal(t, v1.RemoteSyncStatusSyncing, status.SyncStatus)
+	assert.Equal(t, "def", status.Revision)
+	assert.Equal(t, "prod-cluster", status.Cluster)
+	require.Len(t, status.Applications, 2)
+
+	app := status.Applications[0]
+	assert.Equal(t, "myapp", app.Name)
+	assert.Equal(t, "1.0.1", app.Version)
+	assert.Equal(t, int32(2), app.ReadyReplicas)
+	assert.True(t, app.Healthy)
+
+	app = status.Applications[1]
+	assert.Equal(t, "other", app.Name)
+	assert.False(t, app.Healthy)
+
+	assert.Equal(t, "Syncing 1/2 healthy", environments.RemoteStatusSummary(status))
+}
+
+func TestUpdateRemoteStatusAndReleases(t *testing.T) {
+	t.Parallel()
+
+	devJXClient := fake.NewSimpleClientset(&v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "production",
+			Namespace: devNs,
+		},
+		Spec: v1.EnvironmentSpec{
+			Namespace:     remoteNs,
+			RemoteCluster: true,
+		},
+	})
+	remoteJXClient := fake.NewSimpleClientset(
+		release("myapp-1.0.1", "myapp", "1.0.1"),
+		release("other-0.0.3", "other", "0.0.3"),
+	)
+	status := &v1.RemoteEnvironmentStatus{
+		SyncStatus: v1.RemoteSyncStatusSynced,
+		Revision:   "abc",
+		Applications: []v1.RemoteApplicationStatus{
+			{Name: "myapp", Version: "1.0.1", Replicas: 1, ReadyReplicas: 1, Healthy: true},
+			{Name: "other", Version: "0.0.3", Replicas: 1},
+		},
+	}
+
+	env, err := environments.UpdateRemoteEnvironmentStatus(devJXClient, devNs, "production", status)
+	require.NoError(t, err)
+	require.NotNil(t, env.Status.Remote)
+	assert.Equal(t, "abc", env.Status.Remote.Revision)
+
+	err = environments.UpdateRemoteReleases(remoteJXClient, remoteNs, devJXClient, env, status)
+	require.NoError(t, err)
+
+	r, err := devJXClient.JenkinsV1().Releases(devNs).Get("production-myapp-1.0.1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ReleaseStatusTypeDeployed, r.Status.Status)
+	assert.Equal(t, "production", r.Labels[kube.LabelEnvironment])
+
+	_, err = devJXClient.JenkinsV1().Releases(devNs).Get("other-0.0.3", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "the remote Release should be prefixed with the environment name")
+
+	r, err = kube.GetEnvironmentRelease(devJXClient, env, "other-0.0.3")
+	require.NoError(t, err)
+	assert.Equal(t, v1.ReleaseStatusTypePending, r.Status.Status)
+
+	list, err := devJXClient.JenkinsV1().Releases(remoteNs).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Items, "no Release should be created in the remote namespace of the development cluster")
+}
+
+func TestUpdateRemoteReleasesRefreshesCopies(t *testing.T) {
+	t.Parallel()
+
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "production", Namespace: devNs},
+		Spec:       v1.EnvironmentSpec{Namespace: remoteNs, RemoteCluster: true},
+	}
+	stale := release("production-old-0.0.1", "old", "0.0.1")
+	stale.Namespace = devNs
+	stale.Labels = map[string]string{kube.LabelEnvironment: "production"}
+	local := release("production-local-0.0.1", "local", "0.0.1")
+	local.Namespace = devNs
+	devJXClient := fake.NewSimpleClientset(env, stale, local)
+	remoteRelease := release("myapp-1.0.1", "myapp", "1.0.1")
+	remoteJXClient := fake.NewSimpleClientset(remoteRelease)
+
+	err := environments.UpdateRemoteReleases(remoteJXClient, remoteNs, devJXClient, env, nil)
+	require.NoError(t, err)
+
+	remoteRelease.Spec.GitHTTPURL = "https://github.com/myorg/myapp"
+	_, err = remoteJXClient.JenkinsV1().Releases(remoteNs).Update(remoteRelease)
+	require.NoError(t, err)
+	err = environments.UpdateRemoteReleases(remoteJXClient, remoteNs, devJXClient, env, nil)
+	require.NoError(t, err)
+
+	r, err := devJXClient.JenkinsV1().Releases(devNs).Get("production-myapp-1.0.1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/myorg/myapp", r.Spec.GitHTTPURL, "the spec of the copy should be updated")
+
+	_, err = devJXClient.JenkinsV1().Releases(devNs).Get("production-old-0.0.1", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "the copy of a Release removed from the remote cluster should be deleted")
+	_, err = devJXClient.JenkinsV1().Releases(devNs).Get("production-local-0.0.1", metav1.GetOptions{})
+	assert.NoError(t, err, "Releases which are not labelled with the environment should be kept")
+}
+
+func TestUpdateRemoteReleasesFailsOnGetError(t *testing.T) {
+	t.Parallel()
+
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "production", Namespace: devNs},
+		Spec:       v1.EnvironmentSpec{Namespace: remoteNs, RemoteCluster: true},
+	}
+	devJXClient := fake.NewSimpleClientset(env)
+	devJXClient.PrependReactor("get", "releases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "releases"}, "myapp-1.0.1", nil)
+	})
+	remoteJXClient := fake.NewSimpleClientset(release("myapp-1.0.1", "myapp", "1.0.1"))
+
+	err := environments.UpdateRemoteReleases(remoteJXClient, remoteNs, devJXClient, env, nil)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsForbidden(errors.Cause(err)))
+
+	list, err := devJXClient.JenkinsV1().Releases(devNs).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Items, "no Release should be created when the existing Release cannot be read")
+}
+
+func TestRemoteReleaseStatus(t *testing.T) {
+	t.Parallel()
+
+	r := release("myapp-1.0.1", "myapp", "1.0.1")
+	failed := &v1.RemoteEnvironmentStatus{
+		SyncStatus: v1.RemoteSyncStatusFailed,
+		Applications: []v1.RemoteApplicationStatus{
+			{Name: "myapp", Version: "1.0.0", Healthy: true},
+		},
+	}
+	assert.Equal(t, v1.ReleaseStatusTypeFailed, environments.RemoteReleaseStatus(r, failed))
+	assert.Equal(t, v1.ReleaseStatusTypePending, environments.RemoteReleaseStatus(r, nil))
+}
+
+func deployment(name string, app string, version string, replicas int32, ready int32) runtime.Object {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: remoteNs,
+			Labels: map[string]string{
+				"version": version,
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": app,
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{
+			ReadyReplicas: ready,
+		},
+	}
+}
+
+func activity(name string, status v1.ActivityStatusType, sha string, age time.Duration) runtime.Object {
+	started := metav1.NewTime(time.Now().Add(-age))
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: remoteNs,
+		},
+		Spec: v1.PipelineActivitySpec{
+			GitOwner:         "myorg",
+			GitRepository:    "environment-production",
+			GitBranch:        "master",
+			Status:           status,
+			LastCommitSHA:    sha,
+			StartedTimestamp: &started,
+		},
+	}
+}
+
+func release(name string, app string, version string) *v1.Release {
+	return &v1.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: remoteNs,
+		},
+		Spec: v1.ReleaseSpec{
+			Name:    app,
+			Version: version,
+		},
+	}
+}
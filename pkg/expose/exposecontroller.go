@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/helm"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
@@ -27,9 +28,10 @@ const (
 	exposecontrollerChart   = "jenkins-x/exposecontroller"
 )
 
-// Expose gets an existing config from the devNamespace and runs exposecontroller in the targetNamespace
+// Expose gets an existing config from the devNamespace and runs exposecontroller in the targetNamespace. The TLS
+// configuration of the requirements is used to create custom cert-manager issuers in the targetNamespace
 func Expose(kubeClient kubernetes.Interface, certclient certclient.Interface, devNamespace, targetNamespace, password string,
-	helmer helm.Helmer, installTimeout string, versionsDir string) error {
+	tlsConfig *config.TLSConfig, helmer helm.Helmer, installTimeout string, versionsDir string) error {
 	// todo switch to using exposecontroller as a jx plugin
 	_, err := kubeClient.CoreV1().Secrets(targetNamespace).Get(kube.SecretBasicAuth, metav1.GetOptions{})
 	if err != nil {
@@ -69,7 +71,7 @@ func Expose(kubeClient kubernetes.Interface, certclient certclient.Interface, de
 		if err != nil {
 			return err
 		}
-		err = pki.CreateCertManagerResources(certclient, targetNamespace, ic)
+		err = pki.CreateCertManagerResources(certclient, targetNamespace, ic, tlsConfig)
 		if err != nil {
 			return errors.Wrapf(err, "creating the cert-manager resources in namespace %q", targetNamespace)
 		}
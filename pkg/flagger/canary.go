@@ -0,0 +1,241 @@
+package flagger
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// CanaryPhase is the phase of a Flagger Canary analysis
+type CanaryPhase string
+
+const (
+	// CanaryPhaseInitializing the canary is being initialized
+	CanaryPhaseInitializing CanaryPhase = "Initializing"
+	// CanaryPhaseInitialized the canary has been initialized and the primary deployment is ready
+	CanaryPhaseInitialized CanaryPhase = "Initialized"
+	// CanaryPhaseWaiting the canary analysis is waiting for confirmation to start
+	CanaryPhaseWaiting CanaryPhase = "Waiting"
+	// CanaryPhaseProgressing the canary analysis is in progress
+	CanaryPhaseProgressing CanaryPhase = "Progressing"
+	// CanaryPhasePromoting the canary is being promoted to the primary deployment
+	CanaryPhasePromoting CanaryPhase = "Promoting"
+	// CanaryPhaseFinalising the canary promotion is being finalised
+	CanaryPhaseFinalising CanaryPhase = "Finalising"
+	// CanaryPhaseSucceeded the canary analysis succeeded and the new version was promoted
+	CanaryPhaseSucceeded CanaryPhase = "Succeeded"
+	// CanaryPhaseFailed the canary analysis failed and Flagger rolled back the new version
+	CanaryPhaseFailed CanaryPhase = "Failed"
+)
+
+// CanaryResources are the resources of the Canary custom resource in the versions supported by Flagger
+var CanaryResources = []schema.GroupVersionResource{
+	{Group: "flagger.app", Version: "v1beta1", Resource: "canaries"},
+	{Group: "flagger.app", Version: "v1alpha3", Resource: "canaries"},
+}
+
+// CanaryStatus is the status of the analysis of a Flagger Canary
+type CanaryStatus struct {
+	Name               string
+	Namespace          string
+	TargetName         string
+	Phase              CanaryPhase
+	CanaryWeight       int64
+	MaxWeight          int64
+	StepWeight         int64
+	Iterations         int64
+	FailedChecks       int64
+	Message            string
+	LastAppliedSpec    string
+	LastTransitionTime time.Time
+}
+
+// IsFailed returns true if the canary analysis failed and the new version has been rolled back
+func (s *CanaryStatus) IsFailed() bool {
+	return s.Phase == CanaryPhaseFailed
+}
+
+// IsSucceeded returns true if the new version has been promoted to the primary deployment
+func (s *CanaryStatus) IsSucceeded() bool {
+	return s.Phase == CanaryPhaseSucceeded
+}
+
+// IsInitialized returns true if Flagger deployed the primary deployment without an analysis, which happens on the
+// first deployment of the application
+func (s *CanaryStatus) IsInitialized() bool {
+	return s.Phase == CanaryPhaseInitialized
+}
+
+// IsComplete returns true if the canary is in a final phase
+func (s *CanaryStatus) IsComplete() bool {
+	return s.IsFailed() || s.IsSucceeded() || s.IsInitialized()
+}
+
+// IsAnalysedSince returns true if Flagger detected a new spec of the target deployment compared to the given last
+// applied spec or changed the phase of the canary since the given time
+func (s *CanaryStatus) IsAnalysedSince(since time.Time, lastAppliedSpec string) bool {
+	return s.LastAppliedSpec != lastAppliedSpec || s.LastTransitionTime.After(since)
+}
+
+// Progress returns a short description of the progress of the canary analysis
+func (s *CanaryStatus) Progress() string {
+	switch s.Phase {
+	case CanaryPhaseProgressing:
+		if s.MaxWeight > 0 {
+			return fmt.Sprintf("%s %d/%d%%", s.Phase, s.CanaryWeight, s.MaxWeight)
+		}
+		if s.Iterations > 0 {
+			return fmt.Sprintf("%s iteration %d", s.Phase, s.Iterations)
+		}
+		return fmt.Sprintf("%s %d%%", s.Phase, s.CanaryWeight)
+	case CanaryPhaseFailed:
+		if s.FailedChecks > 0 {
+			return fmt.Sprintf("%s %d checks", s.Phase, s.FailedChecks)
+		}
+	}
+	return string(s.Phase)
+}
+
+// UpdatePromoteCanaryStep updates the Canary step of a promotion from the status of the Canary
+func (s *CanaryStatus) UpdatePromoteCanaryStep(p *v1.PromoteCanaryStep) {
+	p.Canary = s.Name
+	p.Phase = string(s.Phase)
+	p.Weight = int32(s.CanaryWeight)
+	p.MaxWeight = int32(s.MaxWeight)
+	p.Iterations = int32(s.Iterations)
+	p.FailedChecks = int32(s.FailedChecks)
+	if s.Message != "" {
+		p.Description = s.Message
+	}
+}
+
+// ToCanaryStatus converts the Canary resource into its status
+func ToCanaryStatus(u *unstructured.Unstructured) *CanaryStatus {
+	status := &CanaryStatus{
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+	}
+	status.TargetName, _, _ = unstructured.NestedString(u.Object, "spec", "targetRef", "name")
+	phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+	status.Phase = CanaryPhase(phase)
+	status.CanaryWeight = nestedInt(u, "status", "canaryWeight")
+	status.Iterations = nestedInt(u, "status", "iterations")
+	status.FailedChecks = nestedInt(u, "status", "failedChecks")
+	status.LastAppliedSpec, _, _ = unstructured.NestedString(u.Object, "status", "lastAppliedSpec")
+
+	// v1beta1 uses spec.analysis whereas v1alpha3 uses spec.canaryAnalysis
+	for _, analysis := range []string{"analysis", "canaryAnalysis"} {
+		if status.MaxWeight == 0 {
+			status.MaxWeight = nestedInt(u, "spec", analysis, "maxWeight")
+		}
+		if status.StepWeight == 0 {
+			status.StepWeight = nestedInt(u, "spec", analysis, "stepWeight")
+		}
+	}
+
+	text, _, _ := unstructured.NestedString(u.Object, "status", "lastTransitionTime")
+	if text != "" {
+		t, err := time.Parse(time.RFC3339, text)
+		if err == nil {
+			status.LastTransitionTime = t
+		}
+	}
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if message, ok := m["message"].(string); ok && message != "" {
+			status.Message = message
+		}
+	}
+	return status
+}
+
+func nestedInt(u *unstructured.Unstructured, fields ...string) int64 {
+	value, found, _ := unstructured.NestedFieldNoCopy(u.Object, fields...)
+	if !found {
+		return 0
+	}
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// GetCanary returns the status of the Canary with the given name or nil if it does not exist
+func GetCanary(dynamicClient dynamic.Interface, ns string, name string) (*CanaryStatus, error) {
+	for _, resource := range CanaryResources {
+		u, err := dynamicClient.Resource(resource).Namespace(ns).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get Canary %s in namespace %s", name, ns)
+		}
+		return ToCanaryStatus(u), nil
+	}
+	return nil, nil
+}
+
+// GetCanaries returns the status of the Canaries in the namespace indexed by the name of their target deployment.
+// If Flagger is not installed an empty map is returned
+func GetCanaries(dynamicClient dynamic.Interface, ns string) (map[string]*CanaryStatus, error) {
+	answer := map[string]*CanaryStatus{}
+	for _, resource := range CanaryResources {
+		list, err := dynamicClient.Resource(resource).Namespace(ns).List(metav1.ListOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return answer, errors.Wrapf(err, "failed to list Canaries in namespace %s", ns)
+		}
+		for i := range list.Items {
+			status := ToCanaryStatus(&list.Items[i])
+			name := status.TargetName
+			if name == "" {
+				name = status.Name
+			}
+			answer[name] = status
+		}
+		if len(answer) > 0 {
+			return answer, nil
+		}
+	}
+	return answer, nil
+}
+
+// FindCanary returns the status of the first Canary in the namespace which has one of the given names
+// or targets a deployment with one of the given names
+func FindCanary(dynamicClient dynamic.Interface, ns string, names ...string) (*CanaryStatus, error) {
+	canaries, err := GetCanaries(dynamicClient, ns)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if canary := canaries[name]; canary != nil {
+			return canary, nil
+		}
+		for _, canary := range canaries {
+			if canary.Name == name {
+				return canary, nil
+			}
+		}
+	}
+	return nil, nil
+}
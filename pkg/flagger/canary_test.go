@@ -0,0 +1,133 @@
+// +build unit
+
+package flagger_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/flagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func canary(name string, target string, phase string, weight int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "flagger.app/v1beta1",
+			"kind":       "Canary",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "jx-production",
+			},
+			"spec": map[string]interface{}{
+				"targetRef": map[string]interface{}{
+					"name": target,
+				},
+				"analysis": map[string]interface{}{
+					"maxWeight":  int64(50),
+					"stepWeight": int64(10),
+				},
+			},
+			"status": map[string]interface{}{
+				"phase":              phase,
+				"canaryWeight":       weight,
+				"failedChecks":       int64(2),
+				"lastAppliedSpec":    "5d8f9c7b6",
+				"lastTransitionTime": "2020-03-01T10:00:00Z",
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":    "Promoted",
+						"message": "Canary analysis failed, Deployment scaled to zero.",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestToCanaryStatus(t *testing.T) {
+	t.Parallel()
+
+	status := flagger.ToCanaryStatus(canary("myapp", "jx-myapp", "Progressing", 20))
+	assert.Equal(t, "jx-myapp", status.TargetName)
+	assert.Equal(t, flagger.CanaryPhaseProgressing, status.Phase)
+	assert.Equal(t, int64(50), status.MaxWeight)
+	assert.Equal(t, int64(10), status.StepWeight)
+	assert.Equal(t, "Progressing 20/50%", status.Progress())
+	assert.False(t, status.IsComplete())
+	assert.Equal(t, 2020, status.LastTransitionTime.Year())
+	assert.Equal(t, "5d8f9c7b6", status.LastAppliedSpec)
+
+	status = flagger.ToCanaryStatus(canary("myapp", "jx-myapp", "Failed", 0))
+	assert.True(t, status.IsFailed())
+	assert.Equal(t, "Failed 2 checks", status.Progress())
+
+	step := &v1.PromoteCanaryStep{}
+	status.UpdatePromoteCanaryStep(step)
+	assert.Equal(t, "myapp", step.Canary)
+	assert.Equal(t, "Failed", step.Phase)
+	assert.Equal(t, int32(2), step.FailedChecks)
+	assert.Equal(t, "Canary analysis failed, Deployment scaled to zero.", step.Description)
+}
+
+func TestCanaryPhases(t *testing.T) {
+	t.Parallel()
+
+	lastTransition := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	before := lastTransition.Add(-time.Minute)
+	after := lastTransition.Add(time.Minute)
+
+	testCases := []struct {
+		phase       string
+		succeeded   bool
+		initialized bool
+		complete    bool
+	}{
+		{"Initializing", false, false, false},
+		{"Initialized", false, true, true},
+		{"Progressing", false, false, false},
+		{"Promoting", false, false, false},
+		{"Succeeded", true, false, true},
+		{"Failed", false, false, true},
+	}
+	for _, tc := range testCases {
+		status := flagger.ToCanaryStatus(canary("myapp", "jx-myapp", tc.phase, 0))
+		assert.Equal(t, tc.succeeded, status.IsSucceeded(), "succeeded for phase %s", tc.phase)
+		assert.Equal(t, tc.initialized, status.IsInitialized(), "initialized for phase %s", tc.phase)
+		assert.Equal(t, tc.complete, status.IsComplete(), "complete for phase %s", tc.phase)
+		assert.True(t, status.IsAnalysedSince(before, "5d8f9c7b6"), "analysed before the last transition for phase %s", tc.phase)
+		assert.False(t, status.IsAnalysedSince(after, "5d8f9c7b6"), "analysed after the last transition for phase %s", tc.phase)
+		assert.True(t, status.IsAnalysedSince(after, "7f6c5d4e3"), "analysed a new spec after the last transition for phase %s", tc.phase)
+	}
+}
+
+func TestFindCanary(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	for _, resource := range flagger.CanaryResources {
+		scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: resource.Group, Version: resource.Version, Kind: "CanaryList"}, &unstructured.UnstructuredList{})
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme, canary("myapp", "jx-myapp", "Succeeded", 0))
+
+	status, err := flagger.FindCanary(dynamicClient, "jx-production", "jx-myapp")
+	require.NoError(t, err)
+	require.NotNil(t, status)
+	assert.Equal(t, "myapp", status.Name)
+	assert.True(t, status.IsSucceeded())
+
+	status, err = flagger.FindCanary(dynamicClient, "jx-production", "other")
+	require.NoError(t, err)
+	assert.Nil(t, status)
+
+	status, err = flagger.GetCanary(dynamicClient, "jx-production", "myapp")
+	require.NoError(t, err)
+	require.NotNil(t, status)
+	assert.Equal(t, "Succeeded", status.Progress())
+}
@@ -917,6 +917,45 @@ func (p *GitHubProvider) AddPRComment(pr *GitPullRequest, comment string) error
 	return nil
 }
 
+// ListPRComments returns the comments of the Pull Request
+func (p *GitHubProvider) ListPRComments(pr *GitPullRequest) ([]*GitPullRequestComment, error) {
+	if pr.Number == nil {
+		return nil, fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	answer := []*GitPullRequestComment{}
+	options := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			Page:    1,
+			PerPage: pageSize,
+		},
+	}
+	for {
+		comments, _, err := p.Client.Issues.ListComments(p.Context, pr.Owner, pr.Repo, *pr.Number, options)
+		if err != nil {
+			return answer, err
+		}
+		for _, c := range comments {
+			answer = append(answer, &GitPullRequestComment{
+				ID:   c.GetID(),
+				Body: c.GetBody(),
+			})
+		}
+		if len(comments) < pageSize {
+			break
+		}
+		options.Page++
+	}
+	return answer, nil
+}
+
+// EditPRComment replaces the body of the comment of the Pull Request
+func (p *GitHubProvider) EditPRComment(pr *GitPullRequest, id int64, comment string) error {
+	_, _, err := p.Client.Issues.EditComment(p.Context, pr.Owner, pr.Repo, id, &github.IssueComment{
+		Body: &comment,
+	})
+	return err
+}
+
 func (p *GitHubProvider) CreateIssueComment(owner string, repo string, number int, comment string) error {
 	issueComment := &github.IssueComment{
 		Body: &comment,
@@ -23,6 +23,13 @@ type OrganisationChecker interface {
 	IsUserInOrganisation(user string, organisation string) (bool, error)
 }
 
+// PullRequestCommentEditor is implemented by git providers whose API allows the comments of a Pull Request to be
+// listed and edited
+type PullRequestCommentEditor interface {
+	ListPRComments(pr *GitPullRequest) ([]*GitPullRequestComment, error)
+	EditPRComment(pr *GitPullRequest, id int64, comment string) error
+}
+
 // GitProvider is the interface for abstracting use of different git provider APIs
 //go:generate pegomock generate github.com/jenkins-x/jx/v2/pkg/gits GitProvider -o mocks/git_provider.go
 type GitProvider interface {
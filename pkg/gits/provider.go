@@ -41,6 +41,12 @@ type GitRepository struct {
 	Archived         bool
 }
 
+// GitPullRequestComment a comment on a Pull Request
+type GitPullRequestComment struct {
+	ID   int64
+	Body string
+}
+
 type GitPullRequest struct {
 	URL                string
 	Author             *GitUser
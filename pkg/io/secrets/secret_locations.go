@@ -24,8 +24,17 @@ const (
 	KubeLocationKind SecretsLocationKind = "kube"
 	// AutoLocationKind indicates that secrets location needs to be dynamically determine
 	AutoLocationKind SecretsLocationKind = "auto"
+	// AWSSecretsManagerLocationKind indicates that secrets location is AWS Secrets Manager
+	AWSSecretsManagerLocationKind SecretsLocationKind = "awssm"
+	// GCPSecretManagerLocationKind indicates that secrets location is GCP Secret Manager
+	GCPSecretManagerLocationKind SecretsLocationKind = "gcpsm"
+	// AzureKeyVaultLocationKind indicates that secrets location is Azure Key Vault
+	AzureKeyVaultLocationKind SecretsLocationKind = "azurekv"
 )
 
+// SecretsLocationValues the secrets locations which can be used to store secrets
+var SecretsLocationValues = []string{"local", "vault", "kube", "awssm", "gcpsm", "azurekv"}
+
 // SecretLocation interfaces to identify where is the secrets location
 type SecretLocation interface {
 	// Location returns the location where the secrets are stored
@@ -58,8 +67,11 @@ func (s *secretLocation) Location() SecretsLocationKind {
 		return s.location
 	}
 	value, ok := configMap[SecretsLocationKey]
-	if ok && value == string(VaultLocationKind) {
-		return VaultLocationKind
+	if ok {
+		location := ToSecretsLocation(value)
+		if location == VaultLocationKind || location == KubeLocationKind || IsCloudSecretManager(location) {
+			return location
+		}
 	}
 	return s.location
 }
@@ -98,7 +110,23 @@ func ToSecretsLocation(location string) SecretsLocationKind {
 		return VaultLocationKind
 	case "kube":
 		return KubeLocationKind
+	case "awssm":
+		return AWSSecretsManagerLocationKind
+	case "gcpsm":
+		return GCPSecretManagerLocationKind
+	case "azurekv":
+		return AzureKeyVaultLocationKind
 	default:
 		return AutoLocationKind
 	}
 }
+
+// IsCloudSecretManager returns true if the secrets location is a cloud secret manager
+func IsCloudSecretManager(location SecretsLocationKind) bool {
+	switch location {
+	case AWSSecretsManagerLocationKind, GCPSecretManagerLocationKind, AzureKeyVaultLocationKind:
+		return true
+	default:
+		return false
+	}
+}
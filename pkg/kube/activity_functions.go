@@ -180,3 +180,63 @@ func FailedPromotionUpdate(a *v1.PipelineActivity, s *v1.PipelineActivityStep, p
 	p.Status = v1.ActivityStatusTypeFailed
 	return nil
 }
+
+// StartPromotionCanary marks the Canary analysis of a promotion as running
+func StartPromotionCanary(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+	err := StartPromote(ps)
+	if err != nil {
+		return err
+	}
+	if p.StartedTimestamp == nil {
+		p.StartedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	if p.Status != v1.ActivityStatusTypeRunning {
+		p.Status = v1.ActivityStatusTypeRunning
+	}
+	return nil
+}
+
+// CompletePromotionCanary marks the Canary analysis of a promotion as succeeded
+func CompletePromotionCanary(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+	if p.StartedTimestamp == nil {
+		p.StartedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	if p.CompletedTimestamp == nil {
+		p.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	p.Status = v1.ActivityStatusTypeSucceeded
+	return nil
+}
+
+// FailedPromotionCanary marks the Canary analysis of a promotion, the promotion and the activity as failed
+// as the new version has been rolled back
+func FailedPromotionCanary(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+	err := FailedPromote(ps)
+	if err != nil {
+		return err
+	}
+	if p.StartedTimestamp == nil {
+		p.StartedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	if p.CompletedTimestamp == nil {
+		p.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	p.Status = v1.ActivityStatusTypeFailed
+	a.Spec.Status = v1.ActivityStatusTypeFailed
+	if a.Spec.CompletedTimestamp == nil {
+		a.Spec.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	return nil
+}
@@ -136,6 +136,12 @@ const (
 	// ConfigMapNameJXInstallConfig is the ConfigMap containing the jx installation's CA and server url. Used by jx login
 	ConfigMapNameJXInstallConfig = "jx-install-config"
 
+	// ConfigMapNameLogMasker is the ConfigMap containing the additional regular expressions masked in the build logs
+	ConfigMapNameLogMasker = "jenkins-x-log-masker"
+
+	// LogMaskerPatternsKey is the key in the log masker ConfigMap containing the regular expressions, one per line
+	LogMaskerPatternsKey = "patterns"
+
 	// LocalHelmRepoName is the default name of the local chart repository where CI/CD releases go to
 	LocalHelmRepoName = "releases"
 
@@ -293,6 +299,15 @@ const (
 	// AnnotationReleaseName is the name of the annotation that stores the release name in the preview environment
 	AnnotationReleaseName = "jenkins.io/chart-release"
 
+	// AnnotationBlueprint is the name of the version stream blueprint an environment was created from
+	AnnotationBlueprint = "jenkins.io/blueprint"
+
+	// AnnotationBlueprintVersion is the version of the blueprint last applied to an environment
+	AnnotationBlueprintVersion = "jenkins.io/blueprint-version"
+
+	// AnnotationRestartedAt is the pod template annotation updated to trigger a rolling restart of a deployment
+	AnnotationRestartedAt = "jenkins.io/restartedAt"
+
 	// SecretDataUsername the username in a Secret/Credentials
 	SecretDataUsername = "username"
 
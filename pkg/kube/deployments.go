@@ -184,3 +184,69 @@ func GetDeploymentPods(client kubernetes.Interface, name, namespace string) ([]v
 
 	return pods.Items, err
 }
+
+// FindDeploymentsUsingSecret returns the deployments in the given namespace whose pods reference the given secret
+// via a volume, an environment variable or an image pull secret
+func FindDeploymentsUsingSecret(client kubernetes.Interface, namespace string, secretName string) ([]appsv1.Deployment, error) {
+	answer := []appsv1.Deployment{}
+	deps, err := client.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return answer, err
+	}
+	for _, d := range deps.Items {
+		if PodSpecUsesSecret(&d.Spec.Template.Spec, secretName) {
+			answer = append(answer, d)
+		}
+	}
+	return answer, nil
+}
+
+// PodSpecUsesSecret returns true if the given pod spec references the given secret
+func PodSpecUsesSecret(spec *v1.PodSpec, secretName string) bool {
+	for _, v := range spec.Volumes {
+		if v.Secret != nil && v.Secret.SecretName == secretName {
+			return true
+		}
+		if v.Projected != nil {
+			for _, s := range v.Projected.Sources {
+				if s.Secret != nil && s.Secret.Name == secretName {
+					return true
+				}
+			}
+		}
+	}
+	for _, s := range spec.ImagePullSecrets {
+		if s.Name == secretName {
+			return true
+		}
+	}
+	containers := append([]v1.Container{}, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for _, c := range containers {
+		for _, e := range c.EnvFrom {
+			if e.SecretRef != nil && e.SecretRef.Name == secretName {
+				return true
+			}
+		}
+		for _, e := range c.Env {
+			if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil && e.ValueFrom.SecretKeyRef.Name == secretName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RestartDeployment triggers a rolling restart of the given deployment by updating an annotation on its pod template
+func RestartDeployment(client kubernetes.Interface, namespace string, name string) error {
+	d, err := client.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if d.Spec.Template.Annotations == nil {
+		d.Spec.Template.Annotations = map[string]string{}
+	}
+	d.Spec.Template.Annotations[AnnotationRestartedAt] = time.Now().UTC().Format(time.RFC3339)
+	_, err = client.AppsV1().Deployments(namespace).Update(d)
+	return err
+}
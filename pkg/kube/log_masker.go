@@ -1,21 +1,60 @@
 package kube
 
 import (
+	"encoding/base64"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+// minEncodedWordLength the minimum length of a secret value before its encoded variants are masked too, to avoid
+// masking common short strings
+const minEncodedWordLength = 4
+
+var (
+	// DefaultLogMaskPatterns the regular expressions of well known credential formats which are always masked
+	DefaultLogMaskPatterns = []string{
+		// AWS access key IDs
+		`\b(AKIA|ASIA)[0-9A-Z]{16}\b`,
+		// GitHub tokens
+		`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+		// Slack tokens
+		`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`,
+	}
+
+	logMaskSecretURIRegex = regexp.MustCompile(`\b(vault|local|sops|gcpsm|awssm|azurekv|kube):([-_.\w\/]+):([-_.\w]+)`)
+)
+
 // LogMasker replaces words in a log from a set of secrets
 type LogMasker struct {
 	ReplaceWords map[string]string
+
+	// Patterns the regular expressions whose matches are masked
+	Patterns []*regexp.Regexp
+
+	// lock guards the sortedWords cache as the same masker is used to mask the logs of concurrent steps
+	lock        sync.Mutex
+	sortedWords []string
 }
 
-// NewLogMasker creates a new LogMasker loading secrets from the given namespace
+// NewLogMasker creates a new LogMasker loading secrets and any configured patterns from the given namespace
 func NewLogMasker(kubeClient kubernetes.Interface, ns string) (*LogMasker, error) {
 	masker := &LogMasker{}
+	err := masker.AddPatterns(DefaultLogMaskPatterns...)
+	if err != nil {
+		return masker, err
+	}
 	resourceList, err := kubeClient.CoreV1().Secrets(ns).List(metav1.ListOptions{})
 	if err != nil {
 		return masker, err
@@ -23,7 +62,8 @@ func NewLogMasker(kubeClient kubernetes.Interface, ns string) (*LogMasker, error
 	for _, secret := range resourceList.Items {
 		masker.LoadSecret(&secret)
 	}
-	return masker, nil
+	err = masker.LoadPatterns(kubeClient, ns)
+	return masker, err
 }
 
 // NewLogMaskerFromMap creates a new LogMasker with all the string values in a tree of map
@@ -49,27 +89,109 @@ func (m *LogMasker) LoadSecrets(kubeClient kubernetes.Interface, ns string) erro
 
 // LoadSecret loads the secret data into the log masker
 func (m *LogMasker) LoadSecret(secret *corev1.Secret) {
-	if m.ReplaceWords == nil {
-		m.ReplaceWords = map[string]string{}
-	}
-
 	if secret.Data != nil {
 		for _, v := range secret.Data {
 			if v != nil && len(v) > 0 {
-				// key := string(k)
-				value := string(v)
-
-				m.ReplaceWords[value] = m.replaceValue(value)
+				m.AddWord(string(v))
 			}
 		}
 	}
 }
 
+// LoadPatterns loads the regular expressions from the log masker ConfigMap in the given namespace if it exists
+func (m *LogMasker) LoadPatterns(kubeClient kubernetes.Interface, ns string) error {
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get(ConfigMapNameLogMasker, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "getting ConfigMap %s in namespace %s", ConfigMapNameLogMasker, ns)
+	}
+	patterns := []string{}
+	for _, line := range strings.Split(cm.Data[LogMaskerPatternsKey], "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return m.AddPatterns(patterns...)
+}
+
+// LoadSecretURIs loads the values of any secret URIs such as `vault:path:key` in the given texts using the secret
+// URL client. Each referenced secret is read once and all of its values are masked
+func (m *LogMasker) LoadSecretURIs(client secreturl.Client, texts ...string) error {
+	paths := map[string]bool{}
+	for _, text := range texts {
+		for _, match := range logMaskSecretURIRegex.FindAllStringSubmatch(text, -1) {
+			paths[match[2]] = true
+		}
+	}
+	var errs []error
+	for path := range paths {
+		data, err := client.Read(path)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "reading secret %s", path))
+			continue
+		}
+		m.replaceMapValues(data)
+	}
+	return util.CombineErrors(errs...)
+}
+
+// ContainsSecretURI returns true if the text contains a secret URI such as `vault:path:key`
+func ContainsSecretURI(text string) bool {
+	return logMaskSecretURIRegex.MatchString(text)
+}
+
+// AddPatterns adds the regular expressions whose matches are masked. Any invalid patterns are skipped so that the
+// valid ones are still masked and an error combining all of the invalid patterns is returned
+func (m *LogMasker) AddPatterns(patterns ...string) error {
+	var errs []error
+	for _, p := range patterns {
+		r, err := regexp.Compile(p)
+		if err != nil {
+			log.Logger().Warnf("ignoring the invalid log mask pattern %q: %s", p, err)
+			errs = append(errs, errors.Wrapf(err, "invalid log mask pattern %q", p))
+			continue
+		}
+		m.Patterns = append(m.Patterns, r)
+	}
+	return util.CombineErrors(errs...)
+}
+
+// AddWord adds a value to be masked along with its base64 and URL encoded variants
+func (m *LogMasker) AddWord(value string) {
+	if value == "" {
+		return
+	}
+	if m.ReplaceWords == nil {
+		m.ReplaceWords = map[string]string{}
+	}
+	m.ReplaceWords[value] = m.replaceValue(value)
+	if len(value) < minEncodedWordLength {
+		return
+	}
+	for _, encoded := range []string{
+		base64.StdEncoding.EncodeToString([]byte(value)),
+		base64.RawStdEncoding.EncodeToString([]byte(value)),
+		base64.URLEncoding.EncodeToString([]byte(value)),
+		url.QueryEscape(value),
+	} {
+		if encoded != value {
+			m.ReplaceWords[encoded] = m.replaceValue(encoded)
+		}
+	}
+}
+
 // MaskLog returns the text with all of the secrets masked out
 func (m *LogMasker) MaskLog(text string) string {
 	answer := text
-	for k, v := range m.ReplaceWords {
-		answer = strings.Replace(answer, k, v, -1)
+	// lets replace the longest words first so that encoded values are masked before any values they contain
+	for _, k := range m.words() {
+		answer = strings.Replace(answer, k, m.ReplaceWords[k], -1)
+	}
+	for _, r := range m.Patterns {
+		answer = r.ReplaceAllStringFunc(answer, m.replaceValue)
 	}
 	return answer
 }
@@ -80,6 +202,27 @@ func (m *LogMasker) MaskLogData(logData []byte) []byte {
 	return []byte(text)
 }
 
+// words returns the words to replace sorted by length, longest first
+func (m *LogMasker) words() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(m.sortedWords) != len(m.ReplaceWords) {
+		words := make([]string, 0, len(m.ReplaceWords))
+		for k := range m.ReplaceWords {
+			words = append(words, k)
+		}
+		sort.Slice(words, func(i, j int) bool {
+			if len(words[i]) != len(words[j]) {
+				return len(words[i]) > len(words[j])
+			}
+			return words[i] < words[j]
+		})
+		m.sortedWords = words
+	}
+	return m.sortedWords
+}
+
 // replaceMapValues adds all the string values in the given map to the replacer words
 func (m *LogMasker) replaceMapValues(values map[string]interface{}) {
 	for _, value := range values {
@@ -90,7 +233,7 @@ func (m *LogMasker) replaceMapValues(values map[string]interface{}) {
 		}
 		text, ok := value.(string)
 		if ok {
-			m.ReplaceWords[text] = m.replaceValue(text)
+			m.AddWord(text)
 		}
 	}
 }
@@ -1,6 +1,9 @@
 package pki
 
 import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
@@ -35,32 +38,34 @@ const (
 	certManagerIssuerStagingServer = "https://acme-staging-v02.api.letsencrypt.org/directory"
 )
 
-// CleanCertManagerResources removed the cert-manager resources from the given namespaces
+// CleanCertManagerResources removes the cert-manager issuer of the ingress configuration and its certificate from the
+// given namespace, defaulting to the LetsEncrypt staging issuer
 func CleanCertManagerResources(certclient certclient.Interface, ns string, ic kube.IngressConfig) error {
-	if ic.Issuer == CertManagerIssuerProd {
-		_, err := certclient.Certmanager().Issuers(ns).Get(CertManagerIssuerProd, metav1.GetOptions{})
-		if err == nil {
-			err := certclient.Certmanager().Issuers(ns).Delete(CertManagerIssuerProd, &metav1.DeleteOptions{})
-			if err != nil {
-				return errors.Wrapf(err, "deleting cert-manager issuer %q", CertManagerIssuerProd)
-			}
-		}
-		_ = certclient.Certmanager().Certificates(ns).Delete(CertManagerIssuerProd, &metav1.DeleteOptions{})
-	} else {
-		_, err := certclient.Certmanager().Issuers(ns).Get(CertManagerIssuerStaging, metav1.GetOptions{})
-		if err == nil {
-			err := certclient.Certmanager().Issuers(ns).Delete(CertManagerIssuerStaging, &metav1.DeleteOptions{})
-			if err != nil {
-				return errors.Wrapf(err, "deleting cert-manager issuer %q", CertManagerIssuerStaging)
-			}
+	issuer := ic.Issuer
+	if issuer == "" {
+		issuer = CertManagerIssuerStaging
+	}
+	_, err := certclient.Certmanager().Issuers(ns).Get(issuer, metav1.GetOptions{})
+	if err == nil {
+		err := certclient.Certmanager().Issuers(ns).Delete(issuer, &metav1.DeleteOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "deleting cert-manager issuer %q", issuer)
 		}
-		_ = certclient.Certmanager().Certificates(ns).Delete(CertManagerIssuerStaging, &metav1.DeleteOptions{})
 	}
+	_ = certclient.Certmanager().Certificates(ns).Delete(issuer, &metav1.DeleteOptions{})
 	return nil
 }
 
-// CreateIssuer creates a cert-manager issuer according with the ingress configuration
-func CreateIssuer(certclient certclient.Interface, ns string, ic kube.IngressConfig) error {
+// CreateIssuer creates a cert-manager issuer according with the ingress configuration. Custom issuers such as a CA,
+// Vault PKI or an internal ACME server are created or updated from the TLS configuration of the requirements
+func CreateIssuer(certclient certclient.Interface, ns string, ic kube.IngressConfig, tlsConfig *config.TLSConfig) error {
+	if isCustomIssuer(ic.Issuer) {
+		if tlsConfig == nil || IssuerName(tlsConfig) != ic.Issuer {
+			return fmt.Errorf("no TLS configuration found in the requirements for the cert-manager issuer %q", ic.Issuer)
+		}
+		_, err := CreateOrUpdateIssuer(certclient, ns, tlsConfig)
+		return err
+	}
 	if ic.Issuer == CertManagerIssuerProd {
 		_, err := certclient.Certmanager().Issuers(ns).Get(CertManagerIssuerProd, metav1.GetOptions{})
 		if err != nil {
@@ -84,6 +89,11 @@ func CreateIssuer(certclient certclient.Interface, ns string, ic kube.IngressCon
 	return nil
 }
 
+// isCustomIssuer checks if the issuer name is not one of the LetsEncrypt issuers
+func isCustomIssuer(name string) bool {
+	return name != "" && name != CertManagerIssuerProd && name != CertManagerIssuerStaging
+}
+
 func issuer(name string, server string, email string) *certmng.Issuer {
 	return &certmng.Issuer{
 		ObjectMeta: metav1.ObjectMeta{
@@ -110,12 +120,22 @@ func issuer(name string, server string, email string) *certmng.Issuer {
 	}
 }
 
-// CreateCertManagerResources creates the cert-manager resources such as issuer in the target namespace
-func CreateCertManagerResources(certclient certclient.Interface, targetNamespace string, ic kube.IngressConfig) error {
+// CreateCertManagerResources creates the cert-manager resources such as issuer in the target namespace. The TLS
+// configuration of the requirements is only needed for custom issuers and may be nil otherwise
+func CreateCertManagerResources(certclient certclient.Interface, targetNamespace string, ic kube.IngressConfig, tlsConfig *config.TLSConfig) error {
 	if !ic.TLS {
 		return nil
 	}
 
+	// custom issuers are updated in place so that the certificates they already signed are kept
+	if isCustomIssuer(ic.Issuer) {
+		err := CreateIssuer(certclient, targetNamespace, ic, tlsConfig)
+		if err != nil {
+			return errors.Wrapf(err, "creating the cert-manager issuer %s/%s", targetNamespace, ic.Issuer)
+		}
+		return nil
+	}
+
 	// do not recreate the issuer if it is already there and correctly configured
 	if alreadyConfigured(certclient, targetNamespace, ic) {
 		return nil
@@ -126,7 +146,7 @@ func CreateCertManagerResources(certclient certclient.Interface, targetNamespace
 		return errors.Wrapf(err, "cleaning the cert-manager resources from namespace %q", targetNamespace)
 	}
 
-	err = CreateIssuer(certclient, targetNamespace, ic)
+	err = CreateIssuer(certclient, targetNamespace, ic, tlsConfig)
 	if err != nil {
 		return errors.Wrapf(err, "creating the cert-manager issuer %s/%s", targetNamespace, ic.Issuer)
 	}
@@ -141,8 +161,8 @@ func alreadyConfigured(certClient certclient.Interface, targetNamespace string,
 		log.Logger().Infof("Certificate issuer %s does not exist. Creating...", util.ColorInfo(ingressConfig.Issuer))
 		return false
 	}
-	// ingress and issuer email must match
-	if issuer.Spec.ACME.Email != ingressConfig.Email {
+	// ingress and issuer email must match for ACME issuers
+	if issuer.Spec.ACME != nil && issuer.Spec.ACME.Email != ingressConfig.Email {
 		issuer.Spec.ACME.Email = ingressConfig.Email
 		_, err := certClient.CertmanagerV1alpha1().Issuers(targetNamespace).Update(issuer)
 		if err != nil {
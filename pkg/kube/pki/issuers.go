@@ -0,0 +1,330 @@
+package pki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	certmng "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	certclient "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CertManagerIssuerCA default name of the issuer signing certificates with a CA key pair
+	CertManagerIssuerCA = "jx-ca"
+	// CertManagerIssuerVault default name of the issuer signing certificates with Vault PKI
+	CertManagerIssuerVault = "jx-vault"
+	// CertManagerIssuerACME default name of the issuer using a custom ACME server
+	CertManagerIssuerACME = "jx-acme"
+
+	// CACertKey the key of the CA certificate in the TLS secrets created by cert-manager
+	CACertKey = "ca.crt"
+
+	vaultTokenSecretKey     = "token"
+	vaultAppRoleSecretKey   = "secretId"
+	defaultVaultAppRolePath = "approle"
+	issuerServerTimeout     = 10 * time.Second
+)
+
+// IssuerName returns the name of the cert-manager issuer for the TLS configuration
+func IssuerName(tlsConfig *config.TLSConfig) string {
+	if tlsConfig.Issuer != nil && tlsConfig.Issuer.Name != "" {
+		return tlsConfig.Issuer.Name
+	}
+	switch tlsConfig.IssuerKind() {
+	case config.TLSIssuerKindCA:
+		return CertManagerIssuerCA
+	case config.TLSIssuerKindVault:
+		return CertManagerIssuerVault
+	case config.TLSIssuerKindACME:
+		return CertManagerIssuerACME
+	}
+	if tlsConfig.Production {
+		return CertManagerIssuerProd
+	}
+	return CertManagerIssuerStaging
+}
+
+// NewIssuer creates the cert-manager issuer for the TLS configuration
+func NewIssuer(tlsConfig *config.TLSConfig) (*certmng.Issuer, error) {
+	name := IssuerName(tlsConfig)
+	kind := tlsConfig.IssuerKind()
+	if kind == config.TLSIssuerKindLetsEncrypt {
+		server := certManagerIssuerStagingServer
+		if tlsConfig.Production {
+			server = certManagerIssuerProdServer
+		}
+		return issuer(name, server, tlsConfig.Email), nil
+	}
+	ic := tlsConfig.Issuer
+	err := ic.Validate()
+	if err != nil {
+		return nil, err
+	}
+	answer := &certmng.Issuer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: certmng.IssuerStatus{
+			Conditions: []certmng.IssuerCondition{},
+		},
+	}
+	switch kind {
+	case config.TLSIssuerKindCA:
+		answer.Spec.CA = &certmng.CAIssuer{
+			SecretName: ic.SecretName,
+		}
+	case config.TLSIssuerKindACME:
+		answer = issuer(name, ic.Server, tlsConfig.Email)
+		answer.Spec.ACME.SkipTLSVerify = ic.SkipTLSVerify
+	case config.TLSIssuerKindVault:
+		vaultIssuer := &certmng.VaultIssuer{
+			Server: ic.Server,
+			Path:   ic.Path,
+		}
+		if ic.TokenSecretName != "" {
+			vaultIssuer.Auth.TokenSecretRef = secretKeySelector(ic.TokenSecretName, vaultTokenSecretKey)
+		} else {
+			path := ic.AppRolePath
+			if path == "" {
+				path = defaultVaultAppRolePath
+			}
+			vaultIssuer.Auth.AppRole = certmng.VaultAppRole{
+				Path:      path,
+				RoleId:    ic.AppRoleID,
+				SecretRef: secretKeySelector(ic.AppRoleSecretName, vaultAppRoleSecretKey),
+			}
+		}
+		answer.Spec.Vault = vaultIssuer
+	}
+	return answer, nil
+}
+
+// CreateOrUpdateIssuer creates or updates the cert-manager issuer for the TLS configuration in the given namespace
+func CreateOrUpdateIssuer(client certclient.Interface, ns string, tlsConfig *config.TLSConfig) (*certmng.Issuer, error) {
+	issuer, err := NewIssuer(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	issuers := client.CertmanagerV1alpha1().Issuers(ns)
+	existing, err := issuers.Get(issuer.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "getting cert-manager issuer %s/%s", ns, issuer.Name)
+		}
+		answer, err := issuers.Create(issuer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating cert-manager issuer %s/%s", ns, issuer.Name)
+		}
+		return answer, nil
+	}
+	existing.Spec = issuer.Spec
+	answer, err := issuers.Update(existing)
+	if err != nil {
+		return nil, errors.Wrapf(err, "updating cert-manager issuer %s/%s", ns, issuer.Name)
+	}
+	return answer, nil
+}
+
+// ValidateIssuerSecrets checks that the Secrets referenced by the issuer exist in the namespace with the required keys
+func ValidateIssuerSecrets(kubeClient kubernetes.Interface, ns string, tlsConfig *config.TLSConfig) error {
+	ic := tlsConfig.Issuer
+	switch tlsConfig.IssuerKind() {
+	case config.TLSIssuerKindCA:
+		secret, err := getSecretWithKeys(kubeClient, ns, ic.SecretName, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+		if err != nil {
+			return err
+		}
+		certs, err := parseCertificates(secret.Data[corev1.TLSCertKey])
+		if err != nil {
+			return errors.Wrapf(err, "parsing the CA certificate in Secret %s/%s", ns, ic.SecretName)
+		}
+		if !certs[0].IsCA {
+			return fmt.Errorf("the certificate in Secret %s/%s is not a CA certificate", ns, ic.SecretName)
+		}
+	case config.TLSIssuerKindVault:
+		if ic.TokenSecretName != "" {
+			_, err := getSecretWithKeys(kubeClient, ns, ic.TokenSecretName, vaultTokenSecretKey)
+			return err
+		}
+		_, err := getSecretWithKeys(kubeClient, ns, ic.AppRoleSecretName, vaultAppRoleSecretKey)
+		return err
+	}
+	return nil
+}
+
+// CheckIssuerServer checks that the ACME directory or Vault server of the issuer can be reached from here, trusting
+// the CA bundle of the issuer if one is configured
+func CheckIssuerServer(tlsConfig *config.TLSConfig) error {
+	kind := tlsConfig.IssuerKind()
+	if kind != config.TLSIssuerKindACME && kind != config.TLSIssuerKindVault {
+		return nil
+	}
+	ic := tlsConfig.Issuer
+	client, err := issuerHTTPClient(ic)
+	if err != nil {
+		return err
+	}
+	u := ic.Server
+	if kind == config.TLSIssuerKindVault {
+		u = strings.TrimSuffix(u, "/") + "/v1/sys/health"
+	}
+	resp, err := client.Get(u)
+	if err != nil {
+		return errors.Wrapf(err, "connecting to the %s issuer server %s", kind, ic.Server)
+	}
+	defer resp.Body.Close()
+	if kind == config.TLSIssuerKindVault {
+		// the Vault health endpoint uses the status code to report standby or sealed servers
+		return nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "reading the ACME directory %s", u)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "newNonce") {
+		return fmt.Errorf("the ACME server %s did not return an ACME directory, status %d", u, resp.StatusCode)
+	}
+	return nil
+}
+
+// VerifyCertificateChain verifies that the PEM encoded certificate chain is currently valid, chains to the given PEM
+// encoded root CAs (or the system roots if none are given) and the leaf certificate is for the given domain
+func VerifyCertificateChain(chainPEM []byte, rootsPEM []byte, domain string, now time.Time) error {
+	certs, err := parseCertificates(chainPEM)
+	if err != nil {
+		return errors.Wrap(err, "parsing the certificate chain")
+	}
+	leaf := certs[0]
+	opts := x509.VerifyOptions{
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   now,
+	}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if len(rootsPEM) > 0 {
+		opts.Roots = x509.NewCertPool()
+		if !opts.Roots.AppendCertsFromPEM(rootsPEM) {
+			return fmt.Errorf("no valid PEM certificates in the CA bundle")
+		}
+	}
+	_, err = leaf.Verify(opts)
+	if err != nil {
+		return errors.Wrapf(err, "verifying the certificate chain of %s", leaf.Subject.CommonName)
+	}
+	if domain != "" && !certificateMatchesDomain(leaf, domain) {
+		return fmt.Errorf("the certificate for %s with DNS names %s is not for the domain %s",
+			leaf.Subject.CommonName, strings.Join(leaf.DNSNames, ", "), domain)
+	}
+	return nil
+}
+
+// IssuerRootCAs returns the PEM encoded root CAs which should be trusted to verify the certificates issued for the
+// TLS configuration, using the CA bundle of the issuer, the CA certificate stored by cert-manager in the TLS secret
+// or the CA key pair of a CA issuer. An empty result means the system roots should be used
+func IssuerRootCAs(kubeClient kubernetes.Interface, ns string, tlsConfig *config.TLSConfig, tlsSecret *corev1.Secret) ([]byte, error) {
+	if tlsConfig.Issuer != nil && tlsConfig.Issuer.CABundle != "" {
+		return []byte(tlsConfig.Issuer.CABundle), nil
+	}
+	if tlsConfig.IssuerKind() == config.TLSIssuerKindLetsEncrypt {
+		return nil, nil
+	}
+	if ca := tlsSecret.Data[CACertKey]; len(ca) > 0 {
+		return ca, nil
+	}
+	if tlsConfig.IssuerKind() == config.TLSIssuerKindCA {
+		secret, err := getSecretWithKeys(kubeClient, ns, tlsConfig.Issuer.SecretName, corev1.TLSCertKey)
+		if err != nil {
+			return nil, err
+		}
+		return secret.Data[corev1.TLSCertKey], nil
+	}
+	return nil, nil
+}
+
+func certificateMatchesDomain(cert *x509.Certificate, domain string) bool {
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	for _, name := range names {
+		name = strings.TrimPrefix(name, "*.")
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var answer []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, cert)
+	}
+	if len(answer) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificates found")
+	}
+	return answer, nil
+}
+
+func getSecretWithKeys(kubeClient kubernetes.Interface, ns string, name string, keys ...string) (*corev1.Secret, error) {
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the issuer Secret %s/%s", ns, name)
+	}
+	for _, k := range keys {
+		if len(secret.Data[k]) == 0 {
+			return nil, fmt.Errorf("the issuer Secret %s/%s has no %s key", ns, name, k)
+		}
+	}
+	return secret, nil
+}
+
+func issuerHTTPClient(ic *config.TLSIssuerConfig) (*http.Client, error) {
+	tlsClientConfig := &tls.Config{
+		InsecureSkipVerify: ic.SkipTLSVerify, // #nosec G402 only when the issuer is configured to skip verification
+	}
+	if ic.CABundle != "" {
+		tlsClientConfig.RootCAs = x509.NewCertPool()
+		if !tlsClientConfig.RootCAs.AppendCertsFromPEM([]byte(ic.CABundle)) {
+			return nil, fmt.Errorf("no valid PEM certificates in the issuer CA bundle")
+		}
+	}
+	return &http.Client{
+		Timeout: issuerServerTimeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsClientConfig,
+		},
+	}, nil
+}
+
+func secretKeySelector(name string, key string) certmng.SecretKeySelector {
+	return certmng.SecretKeySelector{
+		LocalObjectReference: certmng.LocalObjectReference{
+			Name: name,
+		},
+		Key: key,
+	}
+}
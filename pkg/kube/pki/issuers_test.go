@@ -0,0 +1,265 @@
+// +build unit
+
+package pki_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/pki"
+	"github.com/jetstack/cert-manager/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signerCert := template
+	var signerKey interface{} = key
+	if parent != nil {
+		signerCert = parent.cert
+		signerKey = parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func newTestCA(t *testing.T, name string, serial int64) *testCert {
+	return newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+}
+
+func newTestLeaf(t *testing.T, ca *testCert, dnsName string) *testCert {
+	return newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(100),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+}
+
+func TestIssuerName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, pki.CertManagerIssuerStaging, pki.IssuerName(&config.TLSConfig{}))
+	assert.Equal(t, pki.CertManagerIssuerProd, pki.IssuerName(&config.TLSConfig{Production: true}))
+	assert.Equal(t, pki.CertManagerIssuerCA, pki.IssuerName(&config.TLSConfig{Issuer: &config.TLSIssuerConfig{Kind: config.TLSIssuerKindCA}}))
+	assert.Equal(t, "step-ca", pki.IssuerName(&config.TLSConfig{Issuer: &config.TLSIssuerConfig{Kind: config.TLSIssuerKindACME, Name: "step-ca"}}))
+}
+
+func TestCreateOrUpdateIssuer(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset()
+	const ns = "jx"
+
+	tlsConfig := &config.TLSConfig{
+		Email: "admin@example.com",
+		Issuer: &config.TLSIssuerConfig{
+			Kind:   config.TLSIssuerKindACME,
+			Server: "https://ca.internal:9000/acme/acme/directory",
+		},
+	}
+	issuer, err := pki.CreateOrUpdateIssuer(client, ns, tlsConfig)
+	require.NoError(t, err)
+	require.NotNil(t, issuer.Spec.ACME)
+	assert.Equal(t, pki.CertManagerIssuerACME, issuer.Name)
+	assert.Equal(t, "https://ca.internal:9000/acme/acme/directory", issuer.Spec.ACME.Server)
+	assert.Equal(t, "admin@example.com", issuer.Spec.ACME.Email)
+
+	tlsConfig.Issuer = &config.TLSIssuerConfig{
+		Kind:              config.TLSIssuerKindVault,
+		Name:              pki.CertManagerIssuerACME,
+		Server:            "https://vault.internal:8200",
+		Path:              "pki_int/sign/example-dot-com",
+		AppRoleID:         "my-role",
+		AppRoleSecretName: "cert-manager-vault-approle",
+	}
+	_, err = pki.CreateOrUpdateIssuer(client, ns, tlsConfig)
+	require.NoError(t, err)
+
+	issuer, err = client.CertmanagerV1alpha1().Issuers(ns).Get(pki.CertManagerIssuerACME, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, issuer.Spec.ACME, "the issuer should be updated to the new kind")
+	require.NotNil(t, issuer.Spec.Vault)
+	assert.Equal(t, "pki_int/sign/example-dot-com", issuer.Spec.Vault.Path)
+	assert.Equal(t, "approle", issuer.Spec.Vault.Auth.AppRole.Path)
+	assert.Equal(t, "my-role", issuer.Spec.Vault.Auth.AppRole.RoleId)
+	assert.Equal(t, "cert-manager-vault-approle", issuer.Spec.Vault.Auth.AppRole.SecretRef.Name)
+	assert.Equal(t, "secretId", issuer.Spec.Vault.Auth.AppRole.SecretRef.Key)
+
+	tlsConfig.Issuer = &config.TLSIssuerConfig{Kind: config.TLSIssuerKindVault}
+	_, err = pki.CreateOrUpdateIssuer(client, ns, tlsConfig)
+	assert.Error(t, err, "an invalid issuer should not be created")
+}
+
+func TestCreateIssuerCustomIssuers(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset()
+	const ns = "jx"
+	caConfig := &config.TLSConfig{Issuer: &config.TLSIssuerConfig{Kind: config.TLSIssuerKindCA, SecretName: "ca-key-pair"}}
+
+	err := pki.CreateIssuer(client, ns, kube.IngressConfig{Issuer: pki.CertManagerIssuerCA, TLS: true}, nil)
+	assert.Error(t, err, "a custom issuer should not be created without its TLS configuration")
+
+	err = pki.CreateIssuer(client, ns, kube.IngressConfig{Issuer: pki.CertManagerIssuerCA, TLS: true}, caConfig)
+	require.NoError(t, err)
+	issuer, err := client.CertmanagerV1alpha1().Issuers(ns).Get(pki.CertManagerIssuerCA, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, issuer.Spec.CA)
+	assert.Equal(t, "ca-key-pair", issuer.Spec.CA.SecretName)
+
+	err = pki.CreateIssuer(client, ns, kube.IngressConfig{Issuer: pki.CertManagerIssuerProd, Email: "admin@example.com"}, nil)
+	require.NoError(t, err)
+	_, err = client.CertmanagerV1alpha1().Issuers(ns).Get(pki.CertManagerIssuerProd, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	err = pki.CleanCertManagerResources(client, ns, kube.IngressConfig{Issuer: pki.CertManagerIssuerProd})
+	require.NoError(t, err)
+	_, err = client.CertmanagerV1alpha1().Issuers(ns).Get(pki.CertManagerIssuerProd, metav1.GetOptions{})
+	assert.Error(t, err, "the issuer should be removed")
+}
+
+func TestCreateCertManagerResourcesNonACMEIssuers(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset()
+	const ns = "jx-preview"
+	caConfig := &config.TLSConfig{Issuer: &config.TLSIssuerConfig{Kind: config.TLSIssuerKindCA, SecretName: "ca-key-pair"}}
+	ic := kube.IngressConfig{TLS: true, Issuer: pki.CertManagerIssuerCA, Email: "admin@example.com"}
+
+	err := pki.CreateCertManagerResources(client, ns, ic, caConfig)
+	require.NoError(t, err)
+	err = pki.CreateCertManagerResources(client, ns, ic, caConfig)
+	require.NoError(t, err, "an existing CA issuer should be updated")
+	issuer, err := client.CertmanagerV1alpha1().Issuers(ns).Get(pki.CertManagerIssuerCA, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, issuer.Spec.ACME)
+	require.NotNil(t, issuer.Spec.CA)
+
+	// a LetsEncrypt issuer name reused for a CA issuer has no ACME email to sync
+	stagingConfig := &config.TLSConfig{Issuer: &config.TLSIssuerConfig{Kind: config.TLSIssuerKindCA, Name: pki.CertManagerIssuerStaging, SecretName: "ca-key-pair"}}
+	_, err = pki.CreateOrUpdateIssuer(client, ns, stagingConfig)
+	require.NoError(t, err)
+	err = pki.CreateCertManagerResources(client, ns, kube.IngressConfig{TLS: true, Issuer: pki.CertManagerIssuerStaging, Email: "admin@example.com"}, nil)
+	require.NoError(t, err)
+	issuer, err = client.CertmanagerV1alpha1().Issuers(ns).Get(pki.CertManagerIssuerStaging, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotNil(t, issuer.Spec.CA, "the existing issuer should be kept")
+}
+
+func TestValidateIssuerSecrets(t *testing.T) {
+	t.Parallel()
+
+	const ns = "jx"
+	ca := newTestCA(t, "Internal CA", 1)
+	leaf := newTestLeaf(t, ca, "example.com")
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-key-pair", Namespace: ns},
+			Data:       map[string][]byte{corev1.TLSCertKey: ca.pem, corev1.TLSPrivateKeyKey: []byte("key")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-a-ca", Namespace: ns},
+			Data:       map[string][]byte{corev1.TLSCertKey: leaf.pem, corev1.TLSPrivateKeyKey: []byte("key")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "vault-token", Namespace: ns},
+			Data:       map[string][]byte{"token": []byte("s.123")},
+		},
+	)
+
+	tlsConfig := func(issuer config.TLSIssuerConfig) *config.TLSConfig {
+		return &config.TLSConfig{Issuer: &issuer}
+	}
+	assert.NoError(t, pki.ValidateIssuerSecrets(kubeClient, ns, tlsConfig(config.TLSIssuerConfig{Kind: config.TLSIssuerKindCA, SecretName: "ca-key-pair"})))
+	assert.Error(t, pki.ValidateIssuerSecrets(kubeClient, ns, tlsConfig(config.TLSIssuerConfig{Kind: config.TLSIssuerKindCA, SecretName: "not-a-ca"})))
+	assert.Error(t, pki.ValidateIssuerSecrets(kubeClient, ns, tlsConfig(config.TLSIssuerConfig{Kind: config.TLSIssuerKindCA, SecretName: "missing"})))
+	assert.NoError(t, pki.ValidateIssuerSecrets(kubeClient, ns, tlsConfig(config.TLSIssuerConfig{Kind: config.TLSIssuerKindVault, TokenSecretName: "vault-token"})))
+	assert.Error(t, pki.ValidateIssuerSecrets(kubeClient, ns, tlsConfig(config.TLSIssuerConfig{Kind: config.TLSIssuerKindVault, AppRoleSecretName: "vault-token"})))
+}
+
+func TestCheckIssuerServer(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"newNonce":"https://%s/acme/new-nonce","newAccount":"https://%s/acme/new-account"}`, r.Host, r.Host)
+	}))
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	tlsConfig := &config.TLSConfig{
+		Issuer: &config.TLSIssuerConfig{
+			Kind:   config.TLSIssuerKindACME,
+			Server: server.URL + "/directory",
+		},
+	}
+	assert.Error(t, pki.CheckIssuerServer(tlsConfig), "the test server should not be trusted without the CA bundle")
+
+	tlsConfig.Issuer.CABundle = string(serverCA)
+	assert.NoError(t, pki.CheckIssuerServer(tlsConfig))
+}
+
+func TestVerifyCertificateChain(t *testing.T) {
+	t.Parallel()
+
+	root := newTestCA(t, "Root CA", 1)
+	intermediate := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Intermediate CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, root)
+	leaf := newTestLeaf(t, intermediate, "*.jx.example.com")
+	chain := append(append([]byte{}, leaf.pem...), intermediate.pem...)
+
+	now := time.Now()
+	assert.NoError(t, pki.VerifyCertificateChain(chain, root.pem, "example.com", now))
+	assert.Error(t, pki.VerifyCertificateChain(leaf.pem, root.pem, "example.com", now), "the intermediate is required")
+	assert.Error(t, pki.VerifyCertificateChain(chain, newTestCA(t, "Other CA", 3).pem, "example.com", now), "the chain should not be trusted by another root")
+	assert.Error(t, pki.VerifyCertificateChain(chain, root.pem, "other.com", now), "the certificate is not for the domain")
+	assert.Error(t, pki.VerifyCertificateChain(chain, root.pem, "example.com", now.Add(2*time.Hour)), "the certificate has expired")
+}
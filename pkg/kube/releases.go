@@ -31,6 +31,21 @@ func GetOrCreateRelease(jxClient versioned.Interface, ns string, release *v1.Rel
 	return answer, nil
 }
 
+// RemoteReleaseName returns the name of the copy in the development namespace of a Release from the remote cluster
+// of the given environment
+func RemoteReleaseName(envName string, releaseName string) string {
+	return envName + "-" + releaseName
+}
+
+// GetEnvironmentRelease returns the Release with the given name deployed to the environment. The Releases of a remote
+// environment are copied into the development namespace labelled with the environment
+func GetEnvironmentRelease(jxClient versioned.Interface, env *v1.Environment, releaseName string) (*v1.Release, error) {
+	if env.Spec.RemoteCluster {
+		return jxClient.JenkinsV1().Releases(env.Namespace).Get(RemoteReleaseName(env.Name, releaseName), metav1.GetOptions{})
+	}
+	return jxClient.JenkinsV1().Releases(env.Spec.Namespace).Get(releaseName, metav1.GetOptions{})
+}
+
 type ReleaseOrder []v1.Release
 
 func (a ReleaseOrder) Len() int      { return len(a) }
@@ -49,10 +49,10 @@ const (
 
 // Vault stores some details of a Vault resource
 type Vault struct {
-	Name                   string
-	Namespace              string
-	URL                    string
-	AuthServiceAccountName string
+	Name                   string `json:"name"`
+	Namespace              string `json:"namespace"`
+	URL                    string `json:"url"`
+	AuthServiceAccountName string `json:"authServiceAccountName"`
 }
 
 // GCPConfig keeps the configuration for Google Cloud
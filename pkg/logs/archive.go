@@ -0,0 +1,471 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/cloud/gke"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LogIndexVersion the version of the format of the log index
+	LogIndexVersion = 1
+
+	logSuffix   = ".log"
+	indexSuffix = ".index.json"
+
+	// stepLogsDir the directory in the temporary directory of an archive holding the step logs as they are written
+	stepLogsDir = ".steps"
+
+	// maxLineLength the longest line which can be read from an archived log
+	maxLineLength = 10 * 1024 * 1024
+)
+
+// stepHeaderRegex matches the line written before the logs of each step of a build
+var stepHeaderRegex = regexp.MustCompile(`Showing logs for build .* stage (.*) and container (.*)$`)
+
+// LogIndex the index of the archived log of a build, holding the location and time range of the log of each step
+type LogIndex struct {
+	Version int             `json:"version"`
+	Steps   []LogIndexEntry `json:"steps"`
+}
+
+// LogIndexEntry the location and time range of the log of a step of a build
+type LogIndexEntry struct {
+	Stage string `json:"stage"`
+	Step  string `json:"step"`
+	// Path is the path of the step log relative to the build log without its .log suffix
+	Path string `json:"path"`
+	// Offset and Length are the range of bytes of the step in the combined build log
+	Offset         int64      `json:"offset"`
+	Length         int64      `json:"length"`
+	Lines          int        `json:"lines"`
+	FirstTimestamp *time.Time `json:"firstTimestamp,omitempty"`
+	LastTimestamp  *time.Time `json:"lastTimestamp,omitempty"`
+}
+
+// ArchivedLine a line of the archived log of a step
+type ArchivedLine struct {
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Line      string     `json:"line"`
+}
+
+// LogArchive builds the combined log of a build along with the structured log of each step and the index of the steps.
+// The step logs are written to temporary files as the lines are added so Close must be called to remove them
+type LogArchive struct {
+	data  []byte
+	index LogIndex
+	dir   string
+	steps map[string]*stepLogFile
+}
+
+// stepLogFile the temporary file of the structured log of a step
+type stepLogFile struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// NewLogArchive creates an empty archive
+func NewLogArchive() *LogArchive {
+	return &LogArchive{
+		index: LogIndex{Version: LogIndexVersion},
+		steps: map[string]*stepLogFile{},
+	}
+}
+
+// Add appends the line to the combined log and, if it was output by a step, to the log file of the step
+func (a *LogArchive) Add(line LogLine) error {
+	offset := int64(len(a.data))
+	a.data = append(a.data, line.Line...)
+	a.data = append(a.data, '\n')
+	if line.Step == "" {
+		return nil
+	}
+
+	var entry *LogIndexEntry
+	if n := len(a.index.Steps); n > 0 && a.index.Steps[n-1].Stage == line.Stage && a.index.Steps[n-1].Step == line.Step {
+		entry = &a.index.Steps[n-1]
+	} else {
+		a.index.Steps = append(a.index.Steps, LogIndexEntry{
+			Stage:  line.Stage,
+			Step:   line.Step,
+			Path:   StepLogPath(line.Stage, line.Step),
+			Offset: offset,
+		})
+		entry = &a.index.Steps[len(a.index.Steps)-1]
+	}
+	entry.Length = int64(len(a.data)) - entry.Offset
+	entry.Lines++
+
+	archived := ArchivedLine{Line: line.Line}
+	if !line.Timestamp.IsZero() {
+		t := line.Timestamp.UTC()
+		archived.Timestamp = &t
+		if entry.FirstTimestamp == nil {
+			entry.FirstTimestamp = &t
+		}
+		entry.LastTimestamp = &t
+	}
+	data, err := json.Marshal(&archived)
+	if err != nil {
+		return errors.Wrapf(err, "marshalling a log line of step %s", line.Step)
+	}
+	stepLog, err := a.stepLogFile(entry.Path)
+	if err != nil {
+		return err
+	}
+	_, err = stepLog.writer.Write(append(data, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "writing the log of step %s", line.Step)
+	}
+	return nil
+}
+
+// stepLogFile returns the temporary file of the log of the step with the given path, creating it on the first line
+func (a *LogArchive) stepLogFile(path string) (*stepLogFile, error) {
+	if stepLog := a.steps[path]; stepLog != nil {
+		return stepLog, nil
+	}
+	dir, err := a.tempDir()
+	if err != nil {
+		return nil, err
+	}
+	fileName := filepath.Join(dir, stepLogsDir, filepath.FromSlash(path))
+	err = os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the directory of %s", fileName)
+	}
+	file, err := os.Create(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %s", fileName)
+	}
+	stepLog := &stepLogFile{file: file, writer: bufio.NewWriter(file)}
+	a.steps[path] = stepLog
+	return stepLog, nil
+}
+
+func (a *LogArchive) tempDir() (string, error) {
+	if a.dir == "" {
+		dir, err := ioutil.TempDir("", "jx-build-logs-")
+		if err != nil {
+			return "", errors.Wrap(err, "creating a temporary directory for the step logs")
+		}
+		a.dir = dir
+	}
+	return a.dir, nil
+}
+
+// closeStepLogFiles flushes and closes the temporary files of the step logs
+func (a *LogArchive) closeStepLogFiles() error {
+	var errs []error
+	for path, stepLog := range a.steps {
+		err := stepLog.writer.Flush()
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "writing the log of step %s", path))
+		}
+		err = stepLog.file.Close()
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "closing the log of step %s", path))
+		}
+	}
+	a.steps = map[string]*stepLogFile{}
+	return util.CombineErrors(errs...)
+}
+
+// Data returns the combined log of the build
+func (a *LogArchive) Data() []byte {
+	return a.data
+}
+
+// Index returns the index of the steps
+func (a *LogArchive) Index() *LogIndex {
+	return &a.index
+}
+
+// WriteFiles writes the index and moves the step logs to store alongside the combined log of the build with the given
+// file name into a temporary directory, returning the directory. e.g. the index of 'logs/myapp/master/1.log' is
+// 'logs/myapp/master/1.index.json' and its step logs are in the 'logs/myapp/master/1' directory. It is called once
+// all the lines have been added
+func (a *LogArchive) WriteFiles(logFileName string) (string, error) {
+	err := a.closeStepLogFiles()
+	if err != nil {
+		return "", err
+	}
+	index, err := json.Marshal(&a.index)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling the log index")
+	}
+	dir, err := a.tempDir()
+	if err != nil {
+		return "", err
+	}
+	indexFile := filepath.Join(dir, filepath.FromSlash(IndexURL(logFileName)))
+	err = os.MkdirAll(filepath.Dir(indexFile), util.DefaultWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "creating the directory of %s", indexFile)
+	}
+	err = ioutil.WriteFile(indexFile, index, util.DefaultFileWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "writing %s", indexFile)
+	}
+	stepLogs := filepath.Join(dir, stepLogsDir)
+	exists, err := util.DirExists(stepLogs)
+	if err != nil || !exists {
+		return dir, err
+	}
+	stepDir := filepath.Join(dir, filepath.FromSlash(strings.TrimSuffix(logFileName, logSuffix)))
+	err = os.Rename(stepLogs, stepDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "moving the step logs to %s", stepDir)
+	}
+	return dir, nil
+}
+
+// Close closes and removes the temporary files of the step logs
+func (a *LogArchive) Close() error {
+	err := a.closeStepLogFiles()
+	if a.dir != "" {
+		err = util.CombineErrors(err, os.RemoveAll(a.dir))
+		a.dir = ""
+	}
+	return err
+}
+
+// StepLogPath returns the path of the structured log of a step relative to the directory of the build
+func StepLogPath(stage string, step string) string {
+	return naming.ToValidName(stage) + "/" + naming.ToValidName(step) + logSuffix
+}
+
+// IndexURL returns the URL of the log index of the build log at the given URL
+func IndexURL(logsURL string) string {
+	return strings.TrimSuffix(logsURL, logSuffix) + indexSuffix
+}
+
+// StepLogURL returns the URL of the structured log of the indexed step of the build log at the given URL
+func StepLogURL(logsURL string, entry *LogIndexEntry) string {
+	return strings.TrimSuffix(logsURL, logSuffix) + "/" + entry.Path
+}
+
+// ArchivedLogFilter filters the lines of archived build logs
+type ArchivedLogFilter struct {
+	// Stage and Step match the stage and step names ignoring case. The 'step-' prefix of the step containers is optional
+	Stage string
+	Step  string
+	Grep  *regexp.Regexp
+	// Since only includes the lines logged after the given time
+	Since time.Time
+}
+
+// MatchesStep returns true if the filter includes the given stage and step
+func (f *ArchivedLogFilter) MatchesStep(stage string, step string) bool {
+	if f.Stage != "" && !strings.EqualFold(f.Stage, stage) && naming.ToValidName(f.Stage) != naming.ToValidName(stage) {
+		return false
+	}
+	if f.Step != "" {
+		s := strings.TrimPrefix(strings.ToLower(step), "step-")
+		if !strings.EqualFold(f.Step, step) && strings.TrimPrefix(naming.ToValidName(f.Step), "step-") != s {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesLine returns true if the filter includes the given line
+func (f *ArchivedLogFilter) MatchesLine(line string, timestamp *time.Time) bool {
+	if !f.Since.IsZero() && timestamp != nil && timestamp.Before(f.Since) {
+		return false
+	}
+	return f.Grep == nil || f.Grep.MatchString(stripansi.Strip(line))
+}
+
+// StreamArchivedLogs writes the lines of the archived build log at the given URL which match the filter. If the build
+// has a log index only the logs of the matching steps are downloaded, otherwise the whole log is filtered
+func (t *TektonLogger) StreamArchivedLogs(logsURL string, filter *ArchivedLogFilter, jxClient versioned.Interface, ns string, authSvc auth.ConfigService) error {
+	t.initializeLoggingRoutine()
+	defer t.wg.Wait()
+	defer t.closeLoggingChannels()
+
+	index, err := t.loadLogIndex(logsURL, jxClient, ns, authSvc)
+	if err != nil {
+		log.Logger().Debugf("failed to load the log index of %s so filtering the whole log: %s", logsURL, err)
+		return t.filterArchivedLog(logsURL, filter, jxClient, ns, authSvc)
+	}
+	for i := range index.Steps {
+		entry := &index.Steps[i]
+		if !filter.MatchesStep(entry.Stage, entry.Step) {
+			continue
+		}
+		if !filter.Since.IsZero() && entry.LastTimestamp != nil && entry.LastTimestamp.Before(filter.Since) {
+			continue
+		}
+		stepURL := StepLogURL(logsURL, entry)
+		scanner, err := t.downloadArchivedFile(stepURL, jxClient, ns, authSvc)
+		if err != nil {
+			return errors.Wrapf(err, "downloading the log of stage %s step %s", entry.Stage, entry.Step)
+		}
+		header := false
+		for scanner.Scan() {
+			line := ArchivedLine{}
+			err = json.Unmarshal(scanner.Bytes(), &line)
+			if err != nil {
+				return errors.Wrapf(err, "parsing the log of stage %s step %s", entry.Stage, entry.Step)
+			}
+			if !filter.MatchesLine(line.Line, line.Timestamp) {
+				continue
+			}
+			if !header {
+				header = true
+				err = t.LogWriter.WriteLog(LogLine{Line: stepHeader(entry.Stage, entry.Step)}, t.logsChannel)
+				if err != nil {
+					return err
+				}
+			}
+			err = t.writeArchivedLine(line.Line)
+			if err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return errors.Wrapf(err, "reading the log of stage %s step %s", entry.Stage, entry.Step)
+		}
+	}
+	return nil
+}
+
+// filterArchivedLog filters the combined build log of builds archived before log indexes were introduced. The stage
+// and step of each line are taken from the header written before each step
+func (t *TektonLogger) filterArchivedLog(logsURL string, filter *ArchivedLogFilter, jxClient versioned.Interface, ns string, authSvc auth.ConfigService) error {
+	if !filter.Since.IsZero() {
+		log.Logger().Warnf("the build log at %s has no index so the logs cannot be filtered by time", logsURL)
+	}
+	scanner, err := t.downloadArchivedFile(logsURL, jxClient, ns, authSvc)
+	if err != nil {
+		return err
+	}
+	stage := ""
+	step := ""
+	header := false
+	for scanner.Scan() {
+		text := scanner.Text()
+		if m := stepHeaderRegex.FindStringSubmatch(stripansi.Strip(text)); m != nil {
+			stage, step = m[1], m[2]
+			header = false
+			continue
+		}
+		if !filter.MatchesStep(stage, step) || !filter.MatchesLine(text, nil) {
+			continue
+		}
+		if !header && step != "" {
+			header = true
+			err = t.LogWriter.WriteLog(LogLine{Line: stepHeader(stage, step)}, t.logsChannel)
+			if err != nil {
+				return err
+			}
+		}
+		err = t.writeArchivedLine(text)
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (t *TektonLogger) writeArchivedLine(text string) error {
+	if t.LogMasker != nil {
+		text = t.LogMasker.MaskLog(text)
+	}
+	return t.LogWriter.WriteLog(LogLine{Line: text}, t.logsChannel)
+}
+
+func (t *TektonLogger) loadLogIndex(logsURL string, jxClient versioned.Interface, ns string, authSvc auth.ConfigService) (*LogIndex, error) {
+	scanner, err := t.downloadArchivedFile(IndexURL(logsURL), jxClient, ns, authSvc)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for scanner.Scan() {
+		data = append(data, scanner.Bytes()...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	index := &LogIndex{}
+	err = json.Unmarshal(data, index)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing the log index %s", IndexURL(logsURL))
+	}
+	return index, nil
+}
+
+// downloadArchivedFile returns a scanner of the lines of an archived file in a bucket or served over HTTP
+func (t *TektonLogger) downloadArchivedFile(fileURL string, jxClient versioned.Interface, ns string, authSvc auth.ConfigService) (*bufio.Scanner, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse URL %s", fileURL)
+	}
+	var scanner *bufio.Scanner
+	switch u.Scheme {
+	case "gs":
+		scanner, err = performProviderDownload(fileURL, jxClient, ns)
+		if err != nil {
+			// TODO: This is only here as long as we keep supporting non boot clusters, as GKE are the only ones with LTS supported outside of boot
+			var err2 error
+			scanner, err2 = gke.StreamTransferFileFromBucket(fileURL)
+			if err2 != nil {
+				return nil, util.CombineErrors(err, err2)
+			}
+		}
+	case "s3":
+		scanner, err = performProviderDownload(fileURL, jxClient, ns)
+		if err != nil {
+			return nil, errors.Wrapf(err, "downloading %s", fileURL)
+		}
+	case "http", "https":
+		data, err := downloadLogFile(fileURL, authSvc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "downloading %s", fileURL)
+		}
+		scanner = bufio.NewScanner(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("the URL scheme is not supported: %s", u.Scheme)
+	}
+	scanner.Buffer(nil, maxLineLength)
+	return scanner, nil
+}
+
+// stepHeader returns the line written before the archived logs of each step
+func stepHeader(stage string, step string) string {
+	return fmt.Sprintf("\nShowing logs for stage %s and container %s", stage, step)
+}
+
+// splitTimestamp splits the RFC3339 timestamp which prefixes the lines of pod logs requested with timestamps
+func splitTimestamp(line string) (time.Time, string) {
+	idx := strings.Index(line, " ")
+	if idx < 0 {
+		idx = len(line)
+	}
+	t, err := time.Parse(time.RFC3339Nano, line[:idx])
+	if err != nil {
+		return time.Time{}, line
+	}
+	if idx < len(line) {
+		idx++
+	}
+	return t, line[idx:]
+}
@@ -0,0 +1,194 @@
+// +build unit
+
+package logs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var archiveStart = time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func archivedLine(stage string, step string, seconds int, text string) LogLine {
+	return LogLine{
+		Line:      text,
+		Stage:     stage,
+		Step:      step,
+		Timestamp: archiveStart.Add(time.Duration(seconds) * time.Second),
+	}
+}
+
+// newTestArchive creates the archive of a build with a build stage of two steps and a promote stage of one step
+func newTestArchive(t *testing.T) *LogArchive {
+	archive := NewLogArchive()
+	lines := []LogLine{
+		{Line: "\nShowing logs for build jx/myapp/master #1 stage from build pack and container step-git-clone"},
+		archivedLine("from build pack", "step-git-clone", 0, "Cloning into myapp"),
+		{Line: "\nShowing logs for build jx/myapp/master #1 stage from build pack and container step-build-mvn"},
+		archivedLine("from build pack", "step-build-mvn", 10, "[INFO] Compiling 12 source files"),
+		archivedLine("from build pack", "step-build-mvn", 70, "[ERROR] Tests run: 3, Failures: 1"),
+		archivedLine("from build pack", "step-build-mvn", 80, "[INFO] BUILD FAILURE"),
+		{Line: "\nShowing logs for build jx/myapp/master #1 stage promote and container step-changelog"},
+		archivedLine("promote", "step-changelog", 120, "generated the changelog"),
+	}
+	for _, l := range lines {
+		require.NoError(t, archive.Add(l))
+	}
+	return archive
+}
+
+// archiveFiles returns the contents of the files written by the archive for the given log file keyed by their paths
+func archiveFiles(t *testing.T, archive *LogArchive, logFileName string) map[string][]byte {
+	dir, err := archive.WriteFiles(logFileName)
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)], err = ioutil.ReadFile(path)
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
+
+// serveArchive serves the files of the archive, recording the paths which were requested
+func serveArchive(t *testing.T, files map[string][]byte) (*httptest.Server, *[]string) {
+	var lock sync.Mutex
+	requested := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requested = append(requested, r.URL.Path)
+		lock.Unlock()
+		data, ok := files[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, err := w.Write(data)
+		assert.NoError(t, err)
+	}))
+	return server, &requested
+}
+
+func TestLogArchive(t *testing.T) {
+	t.Parallel()
+
+	archive := newTestArchive(t)
+	defer archive.Close()
+	data := string(archive.Data())
+	assert.Equal(t, 11, strings.Count(data, "\n"), "the combined log should have every line including the step headers")
+
+	index := archive.Index()
+	assert.Equal(t, LogIndexVersion, index.Version)
+	require.Len(t, index.Steps, 3)
+	mvn := index.Steps[1]
+	assert.Equal(t, "from build pack", mvn.Stage)
+	assert.Equal(t, "step-build-mvn", mvn.Step)
+	assert.Equal(t, "from-build-pack/step-build-mvn.log", mvn.Path)
+	assert.Equal(t, 3, mvn.Lines)
+	assert.Equal(t, archiveStart.Add(10*time.Second), *mvn.FirstTimestamp)
+	assert.Equal(t, archiveStart.Add(80*time.Second), *mvn.LastTimestamp)
+	assert.Equal(t, "[INFO] Compiling 12 source files\n[ERROR] Tests run: 3, Failures: 1\n[INFO] BUILD FAILURE\n",
+		data[mvn.Offset:mvn.Offset+mvn.Length], "the offsets should locate the step in the combined log")
+
+	dir := archive.dir
+	require.NotEmpty(t, dir, "the step logs should be written to a temporary directory as they are added")
+	files := archiveFiles(t, archive, "jenkins-x/logs/jx/myapp/master/1.log")
+	require.Len(t, files, 4)
+	loaded := LogIndex{}
+	require.NoError(t, json.Unmarshal(files["jenkins-x/logs/jx/myapp/master/1.index.json"], &loaded))
+	assert.Equal(t, *index, loaded)
+	stepLog := string(files["jenkins-x/logs/jx/myapp/master/1/promote/step-changelog.log"])
+	assert.Equal(t, `{"timestamp":"2020-03-01T09:02:00Z","line":"generated the changelog"}`+"\n", stepLog)
+
+	require.NoError(t, archive.Close())
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "the temporary step logs should be removed when the archive is closed")
+}
+
+func TestSplitTimestamp(t *testing.T) {
+	t.Parallel()
+
+	ts, line := splitTimestamp("2020-03-01T09:00:01.123456789Z [INFO] hello world")
+	assert.Equal(t, archiveStart.Add(1123456789*time.Nanosecond), ts)
+	assert.Equal(t, "[INFO] hello world", line)
+
+	ts, line = splitTimestamp("2020-03-01T09:00:01Z")
+	assert.Equal(t, archiveStart.Add(time.Second), ts)
+	assert.Equal(t, "", line)
+
+	ts, line = splitTimestamp("no timestamp here")
+	assert.True(t, ts.IsZero())
+	assert.Equal(t, "no timestamp here", line)
+}
+
+func TestStreamArchivedLogsWithIndex(t *testing.T) {
+	t.Parallel()
+
+	archive := newTestArchive(t)
+	defer archive.Close()
+	server, requested := serveArchive(t, archiveFiles(t, archive, "logs/jx/myapp/master/1.log"))
+	defer server.Close()
+
+	writer := &TestWriter{}
+	tl := TektonLogger{LogWriter: writer}
+	filter := &ArchivedLogFilter{
+		Stage: "From Build Pack",
+		Step:  "build-mvn",
+		Grep:  regexp.MustCompile("ERROR|FAILURE"),
+		Since: archiveStart.Add(75 * time.Second),
+	}
+	err := tl.StreamArchivedLogs(server.URL+"/logs/jx/myapp/master/1.log", filter, nil, "jx", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"\nShowing logs for stage from build pack and container step-build-mvn",
+		"[INFO] BUILD FAILURE",
+	}, writer.StreamLinesLogged)
+	assert.Equal(t, []string{"/logs/jx/myapp/master/1.index.json", "/logs/jx/myapp/master/1/from-build-pack/step-build-mvn.log"}, *requested,
+		"only the index and the log of the matching step should be downloaded")
+}
+
+func TestStreamArchivedLogsWithoutIndex(t *testing.T) {
+	t.Parallel()
+
+	archive := newTestArchive(t)
+	defer archive.Close()
+	server, _ := serveArchive(t, map[string][]byte{
+		"logs/jx/myapp/master/1.log": archive.Data(),
+	})
+	defer server.Close()
+
+	writer := &TestWriter{}
+	tl := TektonLogger{LogWriter: writer}
+	filter := &ArchivedLogFilter{
+		Grep: regexp.MustCompile("(?i)changelog|error"),
+	}
+	err := tl.StreamArchivedLogs(server.URL+"/logs/jx/myapp/master/1.log", filter, nil, "jx", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"\nShowing logs for stage from build pack and container step-build-mvn",
+		"[ERROR] Tests run: 3, Failures: 1",
+		"\nShowing logs for stage promote and container step-changelog",
+		"generated the changelog",
+	}, writer.StreamLinesLogged)
+}
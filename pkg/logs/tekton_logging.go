@@ -51,8 +51,20 @@ type TektonLogger struct {
 	errorsChannel     chan error
 	wg                *sync.WaitGroup
 	FailIfPodFails    bool
+
+	// LogMasker masks any secrets in the streamed build logs and the logs read from long term storage
+	LogMasker *kube.LogMasker
+
+	// Timestamps requests the timestamp of each line from the pods so that it can be archived along with the line
+	Timestamps bool
+
+	// Stop stops streaming the logs of the running pipeline when it is closed
+	Stop <-chan struct{}
 }
 
+// errLogsStopped is returned when streaming the logs is stopped by closing the Stop channel
+var errLogsStopped = errors.New("streaming the logs was stopped")
+
 // LogWriter is an interface that can be implemented to define different ways to stream / write logs
 // it's the implementer's responsibility to route those logs through the corresponding medium
 type LogWriter interface {
@@ -69,6 +81,12 @@ type retrieverFunc func(pod *corev1.Pod, container *corev1.Container) (io.Reader
 type LogLine struct {
 	Line       string
 	ShouldMask bool
+
+	// Stage and Step are the pipeline stage and the step container which output the line, they are blank for the
+	// lines describing the progress of the build
+	Stage     string
+	Step      string
+	Timestamp time.Time
 }
 
 // GetTektonPipelinesWithActivePipelineActivity returns list of all PipelineActivities with corresponding Tekton PipelineRuns ordered by the PipelineRun creation timestamp and a map to obtain its reference once a name has been selected
@@ -227,6 +245,9 @@ func (t TektonLogger) GetRunningBuildLogs(pa *v1.PipelineActivity, buildName str
 
 	// Make sure we check again for the build pipeline if we just get the metapipeline initially, assuming the metapipeline succeeds
 	for !loggedAllRunsForActivity {
+		if t.stopped() {
+			return errLogsStopped
+		}
 		runsByType, err := getPipelineRunsForActivity(pa, t.TektonClient)
 		if err != nil {
 			return errors.Wrapf(err, "failed to get PipelineRun names for activity %s in namespace %s", pa.Name, pa.Namespace)
@@ -274,6 +295,9 @@ func (t TektonLogger) GetRunningBuildLogs(pa *v1.PipelineActivity, buildName str
 
 			// Repeat until we've seen pods for all stages
 			for stagesToCheckCount > len(stagesSeen) {
+				if t.stopped() {
+					return errLogsStopped
+				}
 				pods, err := builds.GetPipelineRunPods(t.KubeClient, pa.Namespace, runToLog.Name)
 				if err != nil {
 					return errors.Wrapf(err, "failed to get pods for pipeline run %s in namespace %s", runToLog.Name, pa.Namespace)
@@ -326,6 +350,9 @@ func (t *TektonLogger) getContainerLogsFromPod(pod *corev1.Pod, pa *v1.PipelineA
 	t.initializeLoggingRoutine()
 	for i, ic := range containers {
 		pod, err := t.waitForContainerToStart(pa.Namespace, pod, i, stageName)
+		if t.stopped() {
+			return t.finishStoppedLogs()
+		}
 		err = t.LogWriter.WriteLog(LogLine{
 			Line: fmt.Sprintf("\nShowing logs for build %v stage %s and container %s",
 				infoColor.Sprintf(buildName), infoColor.Sprintf(stageName), infoColor.Sprintf(ic.Name)),
@@ -333,8 +360,11 @@ func (t *TektonLogger) getContainerLogsFromPod(pod *corev1.Pod, pa *v1.PipelineA
 		if err != nil {
 			return errors.Wrapf(err, "there was a problem writing a single line into the logs writer")
 		}
-		err = t.fetchLogsToChannel(pa.Namespace, pod, &ic)
+		err = t.fetchLogsToChannel(pa.Namespace, pod, &ic, stageName)
 		if err != nil {
+			if t.stopped() {
+				return t.finishStoppedLogs()
+			}
 			return errors.Wrap(err, "couldn't fetch logs into the logs channel")
 		}
 		if hasStepFailed(pod, i, t.KubeClient, pa.Namespace) {
@@ -365,7 +395,7 @@ func (t *TektonLogger) syncStreamLog() {
 	defer t.wg.Done()
 }
 
-func (t *TektonLogger) fetchLogsToChannel(ns string, pod *corev1.Pod, container *corev1.Container) error {
+func (t *TektonLogger) fetchLogsToChannel(ns string, pod *corev1.Pod, container *corev1.Container, stageName string) error {
 
 	if t.LogsRetrieverFunc == nil {
 		t.LogsRetrieverFunc = t.retrieveLogsFromPod
@@ -377,14 +407,14 @@ func (t *TektonLogger) fetchLogsToChannel(ns string, pod *corev1.Pod, container
 		return err
 	}
 	defer cleanFN()
-	err = writeStreamLines(reader, t.logsChannel)
+	err = writeStreamLines(reader, t.logsChannel, t.LogMasker, stageName, container.Name, t.Timestamps)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func writeStreamLines(reader io.Reader, logCh chan<- LogLine) error {
+func writeStreamLines(reader io.Reader, logCh chan<- LogLine, logMasker *kube.LogMasker, stageName string, stepName string, timestamps bool) error {
 	buffReader := bufio.NewReader(reader)
 	if buffReader == nil {
 		return errors.New("there was a problem obtaining a buffered reader")
@@ -397,7 +427,14 @@ func writeStreamLines(reader io.Reader, logCh chan<- LogLine) error {
 			}
 			return errors.Wrap(err, "failed to read stream")
 		}
-		logCh <- LogLine{Line: string(line), ShouldMask: true}
+		logLine := LogLine{Line: string(line), ShouldMask: true, Stage: stageName, Step: stepName}
+		if timestamps {
+			logLine.Timestamp, logLine.Line = splitTimestamp(logLine.Line)
+		}
+		if logMasker != nil {
+			logLine.Line = logMasker.MaskLog(logLine.Line)
+		}
+		logCh <- logLine
 	}
 }
 
@@ -435,7 +472,11 @@ func (t TektonLogger) waitForContainerToStart(ns string, pod *corev1.Pod, idx in
 		log.Logger().Warn("There was a problem writing a single line into the writeFN")
 	}
 	for {
-		time.Sleep(time.Second)
+		select {
+		case <-t.Stop:
+			return pod, errLogsStopped
+		case <-time.After(time.Second):
+		}
 		p, err := t.KubeClient.CoreV1().Pods(ns).Get(pod.Name, metav1.GetOptions{})
 		if err != nil {
 			return p, errors.Wrapf(err, "failed to load pod %s", pod.Name)
@@ -488,6 +529,9 @@ func (t *TektonLogger) StreamPipelinePersistentLogs(logsURL string, jxClient ver
 			Line: "The build pods for this build have been garbage collected and we couldn't find the any stored log file",
 		})
 	}
+	if t.LogMasker != nil {
+		logBytes = t.LogMasker.MaskLogData(logBytes)
+	}
 	return t.writeBlockingLine(LogLine{
 		Line: string(logBytes),
 	})
@@ -496,6 +540,9 @@ func (t *TektonLogger) StreamPipelinePersistentLogs(logsURL string, jxClient ver
 func (t *TektonLogger) streamPipedLogs(scanner *bufio.Scanner, logsURL string) error {
 	for scanner.Scan() {
 		text := scanner.Text()
+		if t.LogMasker != nil {
+			text = t.LogMasker.MaskLog(text)
+		}
 		err := t.LogWriter.WriteLog(LogLine{
 			Line: text,
 		}, t.logsChannel)
@@ -545,8 +592,9 @@ func (t *TektonLogger) writeBlockingLine(line LogLine) error {
 // Uses the same signature as retrieverFunc so it can be used in TektonLogger
 func (t TektonLogger) retrieveLogsFromPod(pod *corev1.Pod, container *corev1.Container) (io.Reader, func(), error) {
 	options := &corev1.PodLogOptions{
-		Container: container.Name,
-		Follow:    true,
+		Container:  container.Name,
+		Follow:     true,
+		Timestamps: t.Timestamps,
 	}
 	bytesLimit := t.LogWriter.BytesLimit()
 	if bytesLimit > 0 {
@@ -559,11 +607,43 @@ func (t TektonLogger) retrieveLogsFromPod(pod *corev1.Pod, container *corev1.Con
 		return nil, nil, errors.Wrapf(err, "there was an error creating the logs stream for pod %s", pod.Name)
 	}
 	reader := bufio.NewReader(stream)
-	return reader, func() {
-		if stream != nil {
+	done := make(chan struct{})
+	var once sync.Once
+	closeStream := func() {
+		once.Do(func() {
+			close(done)
 			stream.Close()
-		}
-	}, nil
+		})
+	}
+	if t.Stop != nil {
+		// closing the stream unblocks reading the log when streaming is stopped
+		go func() {
+			select {
+			case <-t.Stop:
+				closeStream()
+			case <-done:
+			}
+		}()
+	}
+	return reader, closeStream, nil
+}
+
+// stopped returns true if the Stop channel has been closed
+func (t *TektonLogger) stopped() bool {
+	select {
+	case <-t.Stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// finishStoppedLogs closes the logging channels of a stopped stream waiting for the LogWriter to write the lines
+// already sent
+func (t *TektonLogger) finishStoppedLogs() error {
+	t.closeLoggingChannels()
+	t.wg.Wait()
+	return errLogsStopped
 }
 
 func downloadLogFile(logsURL string, authSvc auth.ConfigService) ([]byte, error) {
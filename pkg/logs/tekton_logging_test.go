@@ -257,6 +257,40 @@ func TestGetRunningBuildLogsWithMatchingBuildPods(t *testing.T) {
 	assert.Equal(t, containersNumber, len(tl.LogWriter.(*TestWriter).StreamLinesLogged))
 }
 
+func TestGetRunningBuildLogsStopped(t *testing.T) {
+	testCaseDir := path.Join("test_data")
+	_, _, _, _, ns := getFakeClientsAndNs(t)
+
+	podsList := tekton_helpers_test.AssertLoadPods(t, testCaseDir)
+	pipelineRuns := tekton_helpers_test.AssertLoadPipelineRuns(t, testCaseDir)
+	kubeClient := kubeMocks.NewSimpleClientset(podsList)
+	tektonClient := tektonMocks.NewSimpleClientset(pipelineRuns)
+	structures := tekton_helpers_test.AssertLoadPipelineStructures(t, testCaseDir)
+	jxClient := jxfake.NewSimpleClientset(structures)
+
+	stop := make(chan struct{})
+	close(stop)
+	tl := TektonLogger{
+		JXClient:     jxClient,
+		TektonClient: tektonClient,
+		KubeClient:   kubeClient,
+		Namespace:    ns,
+		LogWriter: &TestWriter{
+			StreamLinesLogged: make([]string, 0),
+			SingleLinesLogged: make([]string, 0),
+		},
+		LogsRetrieverFunc: LogsProvider,
+		Stop:              stop,
+	}
+
+	pa := assertAndCreatePA1(t, jxClient, ns)
+
+	err := tl.GetRunningBuildLogs(pa, "fakeowner/fakerepo/fakebranch/1", false)
+
+	assert.Equal(t, errLogsStopped, err)
+	assert.Empty(t, tl.LogWriter.(*TestWriter).StreamLinesLogged, "no logs should be streamed once stopped")
+}
+
 func TestGetRunningBuildLogsWithMatchingBuildPodsWithFailedContainerInTheMiddle(t *testing.T) {
 	// https://github.com/jenkins-x/jx/issues/5171
 	testCaseDir := path.Join("test_data", "pod_with_failure")
@@ -0,0 +1,247 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/builds"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// GroupByTeam groups the build costs by team
+	GroupByTeam = "team"
+	// GroupByRepository groups the build costs by repository
+	GroupByRepository = "repo"
+	// GroupByBranchKind groups the build costs by the kind of branch such as pull requests or releases
+	GroupByBranchKind = "branch-type"
+	// GroupByPipeline groups the build costs by pipeline
+	GroupByPipeline = "pipeline"
+
+	bytesPerGiB = 1024 * 1024 * 1024
+)
+
+// GroupByValues the dimensions the build costs can be grouped by
+var GroupByValues = []string{GroupByTeam, GroupByRepository, GroupByBranchKind, GroupByPipeline}
+
+// BuildCost the cost of the resources requested by the pods of a build
+type BuildCost struct {
+	Team       string        `json:"team"`
+	Pipeline   string        `json:"pipeline"`
+	Repository string        `json:"repository"`
+	Branch     string        `json:"branch"`
+	BranchKind string        `json:"branchKind"`
+	Build      string        `json:"build"`
+	Started    time.Time     `json:"started"`
+	Duration   time.Duration `json:"duration"`
+	// CPUCoreHours the CPU cores requested multiplied by the hours they were requested for
+	CPUCoreHours float64 `json:"cpuCoreHours"`
+	// MemoryGiBHours the GiB of memory requested multiplied by the hours they were requested for
+	MemoryGiBHours float64 `json:"memoryGiBHours"`
+	Cost           float64 `json:"cost"`
+	Currency       string  `json:"currency"`
+	// Estimated is true if the pods of the build were deleted so the default requests of the rate card were used
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// BuildCostGroup the total cost of the builds in a group. Only the fields which are grouped by are set
+type BuildCostGroup struct {
+	Team           string  `json:"team,omitempty"`
+	Repository     string  `json:"repository,omitempty"`
+	BranchKind     string  `json:"branchKind,omitempty"`
+	Pipeline       string  `json:"pipeline,omitempty"`
+	Builds         int     `json:"builds"`
+	Estimated      int     `json:"estimated"`
+	BuildHours     float64 `json:"buildHours"`
+	CPUCoreHours   float64 `json:"cpuCoreHours"`
+	MemoryGiBHours float64 `json:"memoryGiBHours"`
+	Cost           float64 `json:"cost"`
+	Currency       string  `json:"currency"`
+}
+
+// LoadBuildCosts computes the cost of the builds of a team started since the given time. The cost of a build is the
+// resources requested by its pods multiplied by how long the pods ran, priced with the rate card. Builds whose pods
+// have been deleted are estimated from their duration and the default requests of the rate card
+func LoadBuildCosts(jxClient versioned.Interface, kubeClient kubernetes.Interface, ns string, since time.Time, rateCard *RateCard) ([]*BuildCost, error) {
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the PipelineActivities in namespace %s", ns)
+	}
+	pods, err := builds.GetBuildPods(kubeClient, ns)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the build pods in namespace %s", ns)
+	}
+	podInfos := []*builds.BuildPodInfo{}
+	for _, pod := range pods {
+		podInfos = append(podInfos, builds.CreateBuildPodInfo(pod))
+	}
+	now := time.Now()
+	answer := []*BuildCost{}
+	for i := range activities.Items {
+		a := &activities.Items[i]
+		if a.Spec.StartedTimestamp == nil || a.Spec.StartedTimestamp.Time.Before(since) {
+			continue
+		}
+		var buildPods []*corev1.Pod
+		for _, info := range podInfos {
+			if info.MatchesPipeline(a) {
+				buildPods = append(buildPods, info.Pod)
+			}
+		}
+		answer = append(answer, ComputeBuildCost(ns, a, buildPods, rateCard, now))
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Started.Before(answer[j].Started)
+	})
+	return answer, nil
+}
+
+// ComputeBuildCost computes the cost of a build from its pods, or from the default requests of the rate card and the
+// duration of the activity if it has no pods
+func ComputeBuildCost(team string, a *v1.PipelineActivity, pods []*corev1.Pod, rateCard *RateCard, now time.Time) *BuildCost {
+	cost := &BuildCost{
+		Team:       team,
+		Pipeline:   a.Spec.Pipeline,
+		Repository: a.RepositoryOwner() + "/" + a.RepositoryName(),
+		Branch:     a.BranchName(),
+		BranchKind: BranchKind(a.BranchName()),
+		Build:      a.Spec.Build,
+		Started:    a.Spec.StartedTimestamp.Time,
+		Currency:   rateCard.Currency,
+	}
+	end := now
+	if a.Spec.CompletedTimestamp != nil {
+		end = a.Spec.CompletedTimestamp.Time
+	}
+	cost.Duration = end.Sub(cost.Started)
+
+	if len(pods) == 0 {
+		cost.Estimated = true
+		hours := cost.Duration.Hours()
+		cost.CPUCoreHours = rateCard.DefaultCPU * hours
+		cost.MemoryGiBHours = rateCard.DefaultMemoryGiB * hours
+	}
+	for _, pod := range pods {
+		cpu, memory := PodRequests(pod)
+		hours := PodDuration(pod, now).Hours()
+		cost.CPUCoreHours += cpu * hours
+		cost.MemoryGiBHours += memory * hours
+	}
+	cost.Cost = rateCard.Cost(cost.CPUCoreHours, cost.MemoryGiBHours)
+	return cost
+}
+
+// PodRequests returns the CPU cores and GiB of memory requested by a pod. As with the scheduler these are the larger of
+// the sum of the requests of the containers and the largest request of an init container
+func PodRequests(pod *corev1.Pod) (float64, float64) {
+	cpu := 0.0
+	memory := 0.0
+	for _, c := range pod.Spec.Containers {
+		cpu += float64(c.Resources.Requests.Cpu().MilliValue()) / 1000
+		memory += float64(c.Resources.Requests.Memory().Value()) / bytesPerGiB
+	}
+	for _, c := range pod.Spec.InitContainers {
+		initCPU := float64(c.Resources.Requests.Cpu().MilliValue()) / 1000
+		if initCPU > cpu {
+			cpu = initCPU
+		}
+		initMemory := float64(c.Resources.Requests.Memory().Value()) / bytesPerGiB
+		if initMemory > memory {
+			memory = initMemory
+		}
+	}
+	return cpu, memory
+}
+
+// PodDuration returns how long a pod ran from its start until its last container terminated, or until now if it
+// is still running
+func PodDuration(pod *corev1.Pod, now time.Time) time.Duration {
+	if pod.Status.StartTime == nil {
+		return 0
+	}
+	start := pod.Status.StartTime.Time
+	end := time.Time{}
+	running := false
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, s := range statuses {
+		if s.State.Terminated != nil {
+			if s.State.Terminated.FinishedAt.Time.After(end) {
+				end = s.State.Terminated.FinishedAt.Time
+			}
+		} else {
+			running = true
+		}
+	}
+	completed := pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+	if !completed && (running || end.IsZero()) {
+		end = now
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// ValidateGroupBy returns an error if any of the dimensions to group by is unknown
+func ValidateGroupBy(groupBy []string) error {
+	for _, g := range groupBy {
+		found := false
+		for _, v := range GroupByValues {
+			if g == v {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown group %s, expected one of %s", g, strings.Join(GroupByValues, ", "))
+		}
+	}
+	return nil
+}
+
+// GroupBuildCosts sums the costs of the builds grouped by the given dimensions and currency with the most expensive
+// group first
+func GroupBuildCosts(costs []*BuildCost, groupBy []string) []*BuildCostGroup {
+	groups := map[string]*BuildCostGroup{}
+	answer := []*BuildCostGroup{}
+	for _, c := range costs {
+		group := &BuildCostGroup{Currency: c.Currency}
+		for _, g := range groupBy {
+			switch g {
+			case GroupByTeam:
+				group.Team = c.Team
+			case GroupByRepository:
+				group.Repository = c.Repository
+			case GroupByBranchKind:
+				group.BranchKind = c.BranchKind
+			case GroupByPipeline:
+				group.Pipeline = c.Pipeline
+			}
+		}
+		key := strings.Join([]string{group.Team, group.Repository, group.BranchKind, group.Pipeline, group.Currency}, "\x00")
+		if existing, ok := groups[key]; ok {
+			group = existing
+		} else {
+			groups[key] = group
+			answer = append(answer, group)
+		}
+		group.Builds++
+		if c.Estimated {
+			group.Estimated++
+		}
+		group.BuildHours += c.Duration.Hours()
+		group.CPUCoreHours += c.CPUCoreHours
+		group.MemoryGiBHours += c.MemoryGiBHours
+		group.Cost += c.Cost
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return answer[i].Cost > answer[j].Cost
+	})
+	return answer
+}
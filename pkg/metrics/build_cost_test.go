@@ -0,0 +1,235 @@
+// +build unit
+
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func requests(cpu string, memory string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		},
+	}
+}
+
+func terminated(finished float64) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		State: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{FinishedAt: *at(finished)},
+		},
+	}
+}
+
+func newBuildActivity(name string, branch string, started float64, completed float64) *v1.PipelineActivity {
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:           "jstrachan/myapp/" + branch,
+			Build:              "1",
+			GitOwner:           "jstrachan",
+			GitRepository:      "myapp",
+			GitBranch:          branch,
+			StartedTimestamp:   at(started),
+			CompletedTimestamp: at(completed),
+		},
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	t.Parallel()
+
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "place-tools", Resources: requests("3", "512Mi")},
+			},
+			Containers: []corev1.Container{
+				{Name: "step-build", Resources: requests("1", "2Gi")},
+				{Name: "step-test", Resources: requests("500m", "1Gi")},
+			},
+		},
+	}
+	cpu, memory := metrics.PodRequests(pod)
+	assert.Equal(t, 3.0, cpu)
+	assert.Equal(t, 3.0, memory)
+}
+
+func TestPodDuration(t *testing.T) {
+	t.Parallel()
+
+	now := start.Add(10 * time.Hour)
+	pod := &corev1.Pod{
+		Status: corev1.PodStatus{
+			Phase:                 corev1.PodSucceeded,
+			StartTime:             at(1),
+			InitContainerStatuses: []corev1.ContainerStatus{terminated(1.25)},
+			ContainerStatuses:     []corev1.ContainerStatus{terminated(2.5), terminated(3)},
+		},
+	}
+	assert.Equal(t, 2*time.Hour, metrics.PodDuration(pod, now))
+
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.ContainerStatuses[1] = corev1.ContainerStatus{}
+	assert.Equal(t, 9*time.Hour, metrics.PodDuration(pod, now))
+
+	pod.Status.StartTime = nil
+	assert.Equal(t, time.Duration(0), metrics.PodDuration(pod, now))
+
+	// the pods come from an informer cache so the spare capacity of their statuses must not be written to
+	initStatuses := make([]corev1.ContainerStatus, 1, 3)
+	initStatuses[0] = terminated(1.25)
+	pod.Status.StartTime = at(1)
+	pod.Status.InitContainerStatuses = initStatuses
+	metrics.PodDuration(pod, now)
+	assert.Equal(t, corev1.ContainerStatus{}, initStatuses[:2][1], "the pod should not be modified")
+}
+
+func TestComputeBuildCost(t *testing.T) {
+	t.Parallel()
+
+	rateCard := &metrics.RateCard{Currency: "EUR", CPUCoreHour: 0.1, MemoryGiBHour: 0.01, DefaultCPU: 1, DefaultMemoryGiB: 2}
+	activity := newBuildActivity("jstrachan-myapp-pr-1-1", "PR-1", 0, 2)
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "step-build", Resources: requests("2", "4Gi")}},
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodSucceeded,
+			StartTime:         at(0),
+			ContainerStatuses: []corev1.ContainerStatus{terminated(1.5)},
+		},
+	}
+
+	cost := metrics.ComputeBuildCost("jx", activity, []*corev1.Pod{pod}, rateCard, start.Add(10*time.Hour))
+	assert.Equal(t, "jstrachan/myapp", cost.Repository)
+	assert.Equal(t, metrics.BranchKindPullRequest, cost.BranchKind)
+	assert.Equal(t, 2*time.Hour, cost.Duration)
+	assert.Equal(t, 3.0, cost.CPUCoreHours)
+	assert.Equal(t, 6.0, cost.MemoryGiBHours)
+	assert.InDelta(t, 0.36, cost.Cost, 0.0001)
+	assert.Equal(t, "EUR", cost.Currency)
+	assert.False(t, cost.Estimated)
+
+	// without pods the default requests of the rate card are used for the duration of the build
+	cost = metrics.ComputeBuildCost("jx", activity, nil, rateCard, start.Add(10*time.Hour))
+	assert.True(t, cost.Estimated)
+	assert.Equal(t, 2.0, cost.CPUCoreHours)
+	assert.Equal(t, 4.0, cost.MemoryGiBHours)
+	assert.InDelta(t, 0.24, cost.Cost, 0.0001)
+}
+
+func TestLoadBuildCostsEstimatesBuildsWithoutPods(t *testing.T) {
+	t.Parallel()
+
+	jxClient := fake.NewSimpleClientset(
+		newBuildActivity("jstrachan-myapp-master-1", "master", 2, 3),
+		newBuildActivity("jstrachan-myapp-pr-1-1", "PR-1", 1, 1.5),
+		newBuildActivity("jstrachan-myapp-master-0", "master", -48, -47),
+	)
+	costs, err := metrics.LoadBuildCosts(jxClient, kubefake.NewSimpleClientset(), testNamespace, start, metrics.DefaultRateCard())
+	require.NoError(t, err)
+	require.Len(t, costs, 2)
+	assert.Equal(t, "PR-1", costs[0].Branch)
+	assert.Equal(t, "master", costs[1].Branch)
+	assert.True(t, costs[1].Estimated)
+	assert.Equal(t, "USD", costs[1].Currency)
+}
+
+func TestGroupBuildCosts(t *testing.T) {
+	t.Parallel()
+
+	costs := []*metrics.BuildCost{
+		{Team: "jx", Repository: "jstrachan/myapp", BranchKind: metrics.BranchKindPullRequest, Duration: time.Hour, CPUCoreHours: 1, Cost: 1, Currency: "USD"},
+		{Team: "jx", Repository: "jstrachan/myapp", BranchKind: metrics.BranchKindRelease, Duration: time.Hour, CPUCoreHours: 2, Cost: 2, Currency: "USD", Estimated: true},
+		{Team: "jx", Repository: "jstrachan/other", BranchKind: metrics.BranchKindPullRequest, Duration: time.Hour, CPUCoreHours: 4, Cost: 4, Currency: "USD"},
+	}
+
+	groups := metrics.GroupBuildCosts(costs, []string{metrics.GroupByRepository})
+	require.Len(t, groups, 2)
+	assert.Equal(t, "jstrachan/other", groups[0].Repository)
+	assert.Equal(t, "jstrachan/myapp", groups[1].Repository)
+	assert.Equal(t, "", groups[1].Team)
+	assert.Equal(t, 2, groups[1].Builds)
+	assert.Equal(t, 1, groups[1].Estimated)
+	assert.Equal(t, 2.0, groups[1].BuildHours)
+	assert.Equal(t, 3.0, groups[1].Cost)
+
+	groups = metrics.GroupBuildCosts(costs, []string{metrics.GroupByTeam, metrics.GroupByBranchKind})
+	require.Len(t, groups, 2)
+	assert.Equal(t, metrics.BranchKindPullRequest, groups[0].BranchKind)
+	assert.Equal(t, 5.0, groups[0].Cost)
+
+	assert.NoError(t, metrics.ValidateGroupBy([]string{"team", "repo", "branch-type", "pipeline"}))
+	assert.Error(t, metrics.ValidateGroupBy([]string{"colour"}))
+}
+
+func TestParseRateCard(t *testing.T) {
+	t.Parallel()
+
+	rateCard, err := metrics.ParseRateCard([]byte("currency: GBP\ncpuCoreHour: 0.02\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "GBP", rateCard.Currency)
+	assert.Equal(t, 0.02, rateCard.CPUCoreHour)
+	assert.Equal(t, metrics.DefaultRateCard().MemoryGiBHour, rateCard.MemoryGiBHour)
+
+	_, err = metrics.ParseRateCard([]byte("memoryGiBHour: -1\n"))
+	assert.Error(t, err)
+}
+
+func TestLoadRateCard(t *testing.T) {
+	t.Parallel()
+
+	kubeClient := kubefake.NewSimpleClientset()
+	rateCard, err := metrics.LoadRateCard(kubeClient, testNamespace)
+	require.NoError(t, err)
+	assert.Equal(t, metrics.DefaultRateCard(), rateCard)
+
+	kubeClient = kubefake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: metrics.RateCardConfigMap, Namespace: testNamespace},
+		Data: map[string]string{
+			metrics.RateCardConfigMapKey: "currency: EUR\n",
+		},
+	})
+	rateCard, err = metrics.LoadRateCard(kubeClient, testNamespace)
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", rateCard.Currency)
+}
+
+func TestBuildCostCollector(t *testing.T) {
+	t.Parallel()
+
+	collector := metrics.NewBuildCostCollector()
+	collector.Update([]*metrics.BuildCostGroup{
+		{Team: "jx", Repository: "jstrachan/myapp", BranchKind: metrics.BranchKindRelease, Builds: 3, Cost: 1.5, Currency: "USD"},
+	})
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	expected := `
+# HELP jx_build_cost The cost of the resources requested by the builds in the window
+# TYPE jx_build_cost gauge
+jx_build_cost{branch_kind="release",currency="USD",repository="jstrachan/myapp",team="jx"} 1.5
+# HELP jx_build_cost_builds The number of builds in the window whose cost was computed
+# TYPE jx_build_cost_builds gauge
+jx_build_cost_builds{branch_kind="release",currency="USD",repository="jstrachan/myapp",team="jx"} 3
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "jx_build_cost", "jx_build_cost_builds")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Deployment a promotion of a version of an app to an environment
+type Deployment struct {
+	App         string    `json:"app"`
+	Version     string    `json:"version,omitempty"`
+	Environment string    `json:"environment"`
+	Failed      bool      `json:"failed,omitempty"`
+	Completed   time.Time `json:"completed"`
+	// ChangeTime the start time of the earliest pipeline which built a change included in the deployment
+	ChangeTime time.Time `json:"changeTime"`
+}
+
+// DeliveryMetrics the four DORA delivery metrics of an app in an environment
+type DeliveryMetrics struct {
+	Team              string `json:"team,omitempty"`
+	App               string `json:"app"`
+	Environment       string `json:"environment"`
+	Deployments       int    `json:"deployments"`
+	FailedDeployments int    `json:"failedDeployments"`
+	// DeploymentFrequency the number of successful deployments per day
+	DeploymentFrequency float64 `json:"deploymentFrequency"`
+	// LeadTime the mean time from the start of the earliest pipeline which built a change to it being deployed
+	LeadTime time.Duration `json:"leadTime"`
+	// ChangeFailureRate the ratio of deployments which failed
+	ChangeFailureRate float64 `json:"changeFailureRate"`
+	// MeanTimeToRestore the mean time from a failed deployment to the next successful deployment
+	MeanTimeToRestore time.Duration `json:"meanTimeToRestore"`
+	// Restores the number of failed deployments which have been restored
+	Restores int `json:"restores"`
+}
+
+// DeliveryFilter filters the deployments included in the delivery metrics
+type DeliveryFilter struct {
+	App         string
+	Environment string
+	Since       time.Time
+	Until       time.Time
+}
+
+// Matches returns true if the deployment matches the filter
+func (f *DeliveryFilter) Matches(d *Deployment) bool {
+	if f.App != "" && f.App != d.App {
+		return false
+	}
+	if f.Environment != "" && f.Environment != d.Environment {
+		return false
+	}
+	if !f.Since.IsZero() && d.Completed.Before(f.Since) {
+		return false
+	}
+	return f.Until.IsZero() || !d.Completed.After(f.Until)
+}
+
+// LoadDeliveryMetrics loads the PipelineActivity and Release resources in the given namespace and computes the
+// delivery metrics of the deployments which match the filter
+func LoadDeliveryMetrics(jxClient versioned.Interface, ns string, filter *DeliveryFilter) ([]*DeliveryMetrics, error) {
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the PipelineActivities in namespace %s", ns)
+	}
+	releases, err := jxClient.JenkinsV1().Releases(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the Releases in namespace %s", ns)
+	}
+	deployments := FindDeployments(activities.Items, releases.Items)
+	answer := ComputeDeliveryMetrics(deployments, filter)
+	for _, m := range answer {
+		m.Team = ns
+	}
+	return answer, nil
+}
+
+// FindDeployments finds the completed promotions of the activities. The change time of each deployment is the start
+// of the earliest pipeline which built one of the commits of the matching Release, otherwise the start of the release
+// pipeline itself
+func FindDeployments(activities []v1.PipelineActivity, releases []v1.Release) []*Deployment {
+	commitTimes := map[string]time.Time{}
+	for i := range activities {
+		spec := &activities[i].Spec
+		if spec.LastCommitSHA == "" || spec.StartedTimestamp == nil {
+			continue
+		}
+		t, ok := commitTimes[spec.LastCommitSHA]
+		if !ok || spec.StartedTimestamp.Time.Before(t) {
+			commitTimes[spec.LastCommitSHA] = spec.StartedTimestamp.Time
+		}
+	}
+	releaseMap := map[string]*v1.Release{}
+	for i := range releases {
+		r := &releases[i]
+		releaseMap[releaseKey(r.Spec.GitOwner, r.Spec.GitRepository, r.Spec.Version)] = r
+	}
+
+	answer := []*Deployment{}
+	for i := range activities {
+		activity := &activities[i]
+		spec := &activity.Spec
+		for _, step := range spec.Steps {
+			promote := step.Promote
+			if step.Kind != v1.ActivityStepKindTypePromote || promote == nil {
+				continue
+			}
+			failed := false
+			switch promote.Status {
+			case v1.ActivityStatusTypeSucceeded:
+			case v1.ActivityStatusTypeFailed, v1.ActivityStatusTypeError:
+				failed = true
+			default:
+				continue
+			}
+			completed := promote.CompletedTimestamp
+			if completed == nil {
+				completed = spec.CompletedTimestamp
+			}
+			if completed == nil {
+				continue
+			}
+			apps := promote.Applications
+			if len(apps) == 0 {
+				apps = []v1.PromoteApplication{{Name: spec.GitRepository, Version: spec.Version}}
+			}
+			for _, app := range apps {
+				d := &Deployment{
+					App:         app.Name,
+					Version:     app.Version,
+					Environment: promote.Environment,
+					Failed:      failed,
+					Completed:   completed.Time,
+				}
+				if spec.StartedTimestamp != nil {
+					d.ChangeTime = spec.StartedTimestamp.Time
+				}
+				release := releaseMap[releaseKey(spec.GitOwner, app.Name, app.Version)]
+				if release != nil {
+					for _, commit := range release.Spec.Commits {
+						t, ok := commitTimes[commit.SHA]
+						if ok && (d.ChangeTime.IsZero() || t.Before(d.ChangeTime)) {
+							d.ChangeTime = t
+						}
+					}
+				}
+				answer = append(answer, d)
+			}
+		}
+	}
+	return answer
+}
+
+// ComputeDeliveryMetrics computes the delivery metrics for each app and environment of the deployments which match
+// the filter, sorted by app and environment
+func ComputeDeliveryMetrics(deployments []*Deployment, filter *DeliveryFilter) []*DeliveryMetrics {
+	if filter == nil {
+		filter = &DeliveryFilter{}
+	}
+	groups := map[string][]*Deployment{}
+	for _, d := range deployments {
+		if filter.Matches(d) {
+			key := d.App + "/" + d.Environment
+			groups[key] = append(groups[key], d)
+		}
+	}
+
+	days := 0.0
+	if !filter.Since.IsZero() {
+		until := filter.Until
+		if until.IsZero() {
+			until = time.Now()
+		}
+		days = until.Sub(filter.Since).Hours() / 24
+	}
+
+	answer := []*DeliveryMetrics{}
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Completed.Before(group[j].Completed)
+		})
+		m := &DeliveryMetrics{
+			App:         group[0].App,
+			Environment: group[0].Environment,
+		}
+		var leadTime, restoreTime time.Duration
+		leadTimes := 0
+		var failedSince *time.Time
+		for _, d := range group {
+			if d.Failed {
+				m.FailedDeployments++
+				if failedSince == nil {
+					failedSince = &d.Completed
+				}
+				continue
+			}
+			m.Deployments++
+			if !d.ChangeTime.IsZero() && d.ChangeTime.Before(d.Completed) {
+				leadTime += d.Completed.Sub(d.ChangeTime)
+				leadTimes++
+			}
+			if failedSince != nil {
+				restoreTime += d.Completed.Sub(*failedSince)
+				m.Restores++
+				failedSince = nil
+			}
+		}
+		// without a window lets use the period between the first and last deployments
+		groupDays := days
+		if groupDays <= 0 && len(group) > 1 {
+			groupDays = group[len(group)-1].Completed.Sub(group[0].Completed).Hours() / 24
+		}
+		if groupDays > 0 {
+			m.DeploymentFrequency = float64(m.Deployments) / groupDays
+		}
+		if leadTimes > 0 {
+			m.LeadTime = leadTime / time.Duration(leadTimes)
+		}
+		if total := m.Deployments + m.FailedDeployments; total > 0 {
+			m.ChangeFailureRate = float64(m.FailedDeployments) / float64(total)
+		}
+		if m.Restores > 0 {
+			m.MeanTimeToRestore = restoreTime / time.Duration(m.Restores)
+		}
+		answer = append(answer, m)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		if answer[i].App != answer[j].App {
+			return answer[i].App < answer[j].App
+		}
+		return answer[i].Environment < answer[j].Environment
+	})
+	return answer
+}
+
+func releaseKey(owner string, repository string, version string) string {
+	return strings.ToLower(owner + "/" + repository + "/" + strings.TrimPrefix(version, "v"))
+}
@@ -0,0 +1,158 @@
+// +build unit
+
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testNamespace = "jx"
+
+var start = time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func at(hours float64) *metav1.Time {
+	t := metav1.NewTime(start.Add(time.Duration(hours * float64(time.Hour))))
+	return &t
+}
+
+// newActivity creates a release pipeline of the given version which promotes to staging with the given status
+func newActivity(name string, version string, sha string, started float64, promoted float64, status v1.ActivityStatusType) *v1.PipelineActivity {
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:         "jstrachan/myapp/master",
+			GitOwner:         "jstrachan",
+			GitRepository:    "myapp",
+			Version:          version,
+			LastCommitSHA:    sha,
+			StartedTimestamp: at(started),
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{
+							Status:             status,
+							CompletedTimestamp: at(promoted),
+						},
+						Environment: "staging",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDeliveryMetrics(t *testing.T) {
+	t.Parallel()
+
+	// the pull request build of commit abc started 10 hours before the release pipeline promoted it
+	prBuild := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "jstrachan-myapp-pr-1-1", Namespace: testNamespace},
+		Spec: v1.PipelineActivitySpec{
+			LastCommitSHA:    "abc",
+			StartedTimestamp: at(0),
+		},
+	}
+	release := &v1.Release{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-1.0.1", Namespace: testNamespace},
+		Spec: v1.ReleaseSpec{
+			GitOwner:      "jstrachan",
+			GitRepository: "myapp",
+			Version:       "v1.0.1",
+			Commits:       []v1.CommitSummary{{SHA: "abc"}, {SHA: "def"}},
+		},
+	}
+	jxClient := fake.NewSimpleClientset(
+		prBuild,
+		release,
+		newActivity("jstrachan-myapp-master-1", "1.0.1", "def", 8, 10, v1.ActivityStatusTypeSucceeded),
+		newActivity("jstrachan-myapp-master-2", "1.0.2", "ghi", 20, 22, v1.ActivityStatusTypeFailed),
+		newActivity("jstrachan-myapp-master-3", "1.0.3", "jkl", 24, 26, v1.ActivityStatusTypeSucceeded),
+		newActivity("jstrachan-myapp-master-4", "1.0.4", "mno", 30, 0, v1.ActivityStatusTypeRunning),
+	)
+
+	filter := &metrics.DeliveryFilter{
+		Since: start,
+		Until: start.Add(48 * time.Hour),
+	}
+	results, err := metrics.LoadDeliveryMetrics(jxClient, testNamespace, filter)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	m := results[0]
+	assert.Equal(t, testNamespace, m.Team)
+	assert.Equal(t, "myapp", m.App)
+	assert.Equal(t, "staging", m.Environment)
+	assert.Equal(t, 2, m.Deployments)
+	assert.Equal(t, 1, m.FailedDeployments)
+	assert.InDelta(t, 1.0, m.DeploymentFrequency, 0.001, "2 deployments in 2 days")
+	assert.Equal(t, 6*time.Hour, m.LeadTime, "the mean of 10 hours from the pull request build and 2 hours")
+	assert.InDelta(t, 1.0/3, m.ChangeFailureRate, 0.001)
+	assert.Equal(t, 4*time.Hour, m.MeanTimeToRestore)
+	assert.Equal(t, 1, m.Restores)
+
+	filter.App = "other"
+	results, err = metrics.LoadDeliveryMetrics(jxClient, testNamespace, filter)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestDeliveryMetricsReleaseTrain(t *testing.T) {
+	t.Parallel()
+
+	activity := newActivity("jstrachan-env-master-1", "", "", 0, 1, v1.ActivityStatusTypeSucceeded)
+	activity.Spec.Steps[0].Promote.Environment = "production"
+	activity.Spec.Steps[0].Promote.Applications = []v1.PromoteApplication{
+		{Name: "app1", Version: "1.0.0"},
+		{Name: "app2", Version: "2.0.0"},
+	}
+	deployments := metrics.FindDeployments([]v1.PipelineActivity{*activity}, nil)
+	require.Len(t, deployments, 2)
+
+	results := metrics.ComputeDeliveryMetrics(deployments, nil)
+	require.Len(t, results, 2)
+	assert.Equal(t, "app1", results[0].App)
+	assert.Equal(t, "app2", results[1].App)
+	assert.Equal(t, "production", results[1].Environment)
+	assert.Equal(t, time.Hour, results[1].LeadTime)
+}
+
+func TestDeliveryCollector(t *testing.T) {
+	t.Parallel()
+
+	collector := metrics.NewDeliveryCollector()
+	collector.Update([]*metrics.DeliveryMetrics{
+		{
+			Team:                "jx",
+			App:                 "myapp",
+			Environment:         "staging",
+			Deployments:         2,
+			DeploymentFrequency: 0.5,
+			LeadTime:            90 * time.Second,
+			ChangeFailureRate:   0.25,
+		},
+	})
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	expected := `
+# HELP jx_delivery_lead_time_seconds The mean time from the start of the earliest pipeline which built a change to it being deployed
+# TYPE jx_delivery_lead_time_seconds gauge
+jx_delivery_lead_time_seconds{app="myapp",environment="staging",team="jx"} 90
+# HELP jx_delivery_change_failure_rate The ratio of deployments which failed
+# TYPE jx_delivery_change_failure_rate gauge
+jx_delivery_change_failure_rate{app="myapp",environment="staging",team="jx"} 0.25
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "jx_delivery_lead_time_seconds", "jx_delivery_change_failure_rate")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,242 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// PipelineStatusSucceeded the status of a pipeline or stage which succeeded
+	PipelineStatusSucceeded = "succeeded"
+	// PipelineStatusFailed the status of a pipeline or stage which failed
+	PipelineStatusFailed = "failed"
+
+	// BranchKindPullRequest the branch kind of pull request pipelines
+	BranchKindPullRequest = "pullrequest"
+	// BranchKindBatch the branch kind of batch pipelines which build several pull requests together
+	BranchKindBatch = "batch"
+	// BranchKindRelease the branch kind of release pipelines on the main branches
+	BranchKindRelease = "release"
+	// BranchKindFeature the branch kind of pipelines on any other branch
+	BranchKindFeature = "feature"
+
+	inFlightQueued  = "queued"
+	inFlightRunning = "running"
+
+	// completedRunExpiry how long completed runs are remembered so that they are not observed twice
+	completedRunExpiry = 24 * time.Hour
+	// staleRunExpiry how long a run which has not been seen is still considered to be in flight
+	staleRunExpiry = 24 * time.Hour
+)
+
+// PipelineBuckets the histogram buckets in seconds of the pipeline and stage durations, from 10 seconds to 2 hours
+var PipelineBuckets = []float64{10, 30, 60, 120, 300, 600, 900, 1200, 1800, 2700, 3600, 5400, 7200}
+
+// QueueBuckets the histogram buckets in seconds of the time pipelines wait before running
+var QueueBuckets = []float64{1, 5, 10, 20, 30, 60, 120, 300, 600, 1200}
+
+// PipelineRunState the current state of a pipeline run
+type PipelineRunState struct {
+	Name       string
+	Repository string
+	BranchKind string
+	Type       string
+	Created    time.Time
+	// Running is the time the first pod of the run started running
+	Running *time.Time
+	// Started is the time the run started, if not specified the creation time is used
+	Started   *time.Time
+	Completed *time.Time
+	Status    string
+	Stages    []*StageState
+}
+
+// StageState the current state of a stage of a pipeline run
+type StageState struct {
+	Name      string
+	Started   *time.Time
+	Completed *time.Time
+	Status    string
+}
+
+type trackedRun struct {
+	repository     string
+	branchKind     string
+	queueObserved  bool
+	stagesObserved map[string]bool
+	lastSeen       time.Time
+}
+
+// PipelineCollector records the duration, queue time and results of pipeline runs and the runs in flight
+type PipelineCollector struct {
+	lock      sync.Mutex
+	runs      map[string]*trackedRun
+	completed map[string]time.Time
+	since     time.Time
+	now       func() time.Time
+
+	duration      *prometheus.HistogramVec
+	stageDuration *prometheus.HistogramVec
+	queueTime     *prometheus.HistogramVec
+	results       *prometheus.CounterVec
+	inFlight      *prometheus.GaugeVec
+}
+
+// NewPipelineCollector creates a new collector of the metrics of the pipeline runs which complete after the given time.
+// This is usually the time the collector is started so that the runs which completed before a restart are not
+// counted again
+func NewPipelineCollector(since time.Time) *PipelineCollector {
+	return &PipelineCollector{
+		runs:      map[string]*trackedRun{},
+		completed: map[string]time.Time{},
+		since:     since,
+		now:       time.Now,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "pipeline",
+			Name:      "duration_seconds",
+			Help:      "The duration of completed pipeline runs",
+			Buckets:   PipelineBuckets,
+		}, []string{"repository", "branch_kind", "type", "status"}),
+		stageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "pipeline",
+			Name:      "stage_duration_seconds",
+			Help:      "The duration of completed pipeline stages",
+			Buckets:   PipelineBuckets,
+		}, []string{"repository", "branch_kind", "stage", "status"}),
+		queueTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "pipeline",
+			Name:      "queue_seconds",
+			Help:      "The time from a pipeline run being created to its first pod running",
+			Buckets:   QueueBuckets,
+		}, []string{"repository", "branch_kind"}),
+		results: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "pipeline",
+			Name:      "runs_total",
+			Help:      "The number of completed pipeline runs",
+		}, []string{"repository", "branch_kind", "type", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "pipeline",
+			Name:      "runs_in_flight",
+			Help:      "The number of pipeline runs which are queued or running",
+		}, []string{"repository", "branch_kind", "state"}),
+	}
+}
+
+// BranchKind returns the kind of branch a pipeline is building
+func BranchKind(branch string) string {
+	lower := strings.ToLower(branch)
+	switch {
+	case strings.HasPrefix(lower, "pr-"):
+		return BranchKindPullRequest
+	case lower == "batch":
+		return BranchKindBatch
+	case lower == "master" || lower == "main":
+		return BranchKindRelease
+	default:
+		return BranchKindFeature
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *PipelineCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.duration.Describe(ch)
+	c.stageDuration.Describe(ch)
+	c.queueTime.Describe(ch)
+	c.results.Describe(ch)
+	c.inFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *PipelineCollector) Collect(ch chan<- prometheus.Metric) {
+	c.duration.Collect(ch)
+	c.stageDuration.Collect(ch)
+	c.queueTime.Collect(ch)
+	c.results.Collect(ch)
+	c.inFlight.Collect(ch)
+}
+
+// Observe records the metrics of the pipeline run. It can be called every time the run changes as the queue time,
+// stage durations and result of each run are only recorded once
+func (c *PipelineCollector) Observe(state *PipelineRunState) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	defer c.updateInFlight(now)
+	if _, ok := c.completed[state.Name]; ok {
+		return
+	}
+	if state.Completed != nil && state.Completed.Before(c.since) {
+		return
+	}
+	run := c.runs[state.Name]
+	if run == nil {
+		run = &trackedRun{
+			repository:     state.Repository,
+			branchKind:     state.BranchKind,
+			stagesObserved: map[string]bool{},
+		}
+		c.runs[state.Name] = run
+	}
+	run.lastSeen = now
+
+	if !run.queueObserved && state.Running != nil {
+		c.queueTime.WithLabelValues(state.Repository, state.BranchKind).Observe(seconds(state.Created, *state.Running))
+		run.queueObserved = true
+	}
+	for _, stage := range state.Stages {
+		if stage.Started == nil || stage.Completed == nil || run.stagesObserved[stage.Name] {
+			continue
+		}
+		c.stageDuration.WithLabelValues(state.Repository, state.BranchKind, stage.Name, stage.Status).Observe(seconds(*stage.Started, *stage.Completed))
+		run.stagesObserved[stage.Name] = true
+	}
+	if state.Completed == nil || state.Status == "" {
+		return
+	}
+	started := state.Created
+	if state.Started != nil {
+		started = *state.Started
+	}
+	c.duration.WithLabelValues(state.Repository, state.BranchKind, state.Type, state.Status).Observe(seconds(started, *state.Completed))
+	c.results.WithLabelValues(state.Repository, state.BranchKind, state.Type, state.Status).Inc()
+	delete(c.runs, state.Name)
+	c.completed[state.Name] = now
+}
+
+// updateInFlight prunes the expired runs and recalculates the runs in flight
+func (c *PipelineCollector) updateInFlight(now time.Time) {
+	for name, t := range c.completed {
+		if now.Sub(t) > completedRunExpiry {
+			delete(c.completed, name)
+		}
+	}
+	c.inFlight.Reset()
+	for name, run := range c.runs {
+		if now.Sub(run.lastSeen) > staleRunExpiry {
+			delete(c.runs, name)
+			continue
+		}
+		state := inFlightQueued
+		if run.queueObserved {
+			state = inFlightRunning
+		}
+		c.inFlight.WithLabelValues(run.repository, run.branchKind, state).Inc()
+	}
+}
+
+func seconds(from time.Time, to time.Time) float64 {
+	d := to.Sub(from).Seconds()
+	if d < 0 {
+		return 0
+	}
+	return d
+}
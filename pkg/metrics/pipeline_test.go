@@ -0,0 +1,154 @@
+// +build unit
+
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func timeAt(seconds int) *time.Time {
+	t := start.Add(time.Duration(seconds) * time.Second)
+	return &t
+}
+
+func TestBranchKind(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, metrics.BranchKindPullRequest, metrics.BranchKind("PR-123"))
+	assert.Equal(t, metrics.BranchKindBatch, metrics.BranchKind("batch"))
+	assert.Equal(t, metrics.BranchKindRelease, metrics.BranchKind("master"))
+	assert.Equal(t, metrics.BranchKindFeature, metrics.BranchKind("feature-cheese"))
+}
+
+func TestPipelineCollector(t *testing.T) {
+	t.Parallel()
+
+	collector := metrics.NewPipelineCollector(start)
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	state := &metrics.PipelineRunState{
+		Name:       "jstrachan-myapp-pr-1-1",
+		Repository: "jstrachan/myapp",
+		BranchKind: metrics.BranchKindPullRequest,
+		Type:       "build",
+		Created:    start,
+	}
+	queued := `
+# HELP jx_pipeline_runs_in_flight The number of pipeline runs which are queued or running
+# TYPE jx_pipeline_runs_in_flight gauge
+jx_pipeline_runs_in_flight{branch_kind="pullrequest",repository="jstrachan/myapp",state="queued"} 1
+`
+	collector.Observe(state)
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(queued), "jx_pipeline_runs_in_flight"))
+
+	// the first pod starts running after 30 seconds and the first stage completes
+	state.Running = timeAt(30)
+	state.Stages = []*metrics.StageState{
+		{Name: "from build pack", Started: timeAt(30), Completed: timeAt(150), Status: metrics.PipelineStatusSucceeded},
+		{Name: "promote", Started: timeAt(150)},
+	}
+	collector.Observe(state)
+	collector.Observe(state)
+
+	running := `
+# HELP jx_pipeline_queue_seconds The time from a pipeline run being created to its first pod running
+# TYPE jx_pipeline_queue_seconds histogram
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="1"} 0
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="5"} 0
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="10"} 0
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="20"} 0
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="30"} 1
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="60"} 1
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="120"} 1
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="300"} 1
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="600"} 1
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="1200"} 1
+jx_pipeline_queue_seconds_bucket{branch_kind="pullrequest",repository="jstrachan/myapp",le="+Inf"} 1
+jx_pipeline_queue_seconds_sum{branch_kind="pullrequest",repository="jstrachan/myapp"} 30
+jx_pipeline_queue_seconds_count{branch_kind="pullrequest",repository="jstrachan/myapp"} 1
+# HELP jx_pipeline_runs_in_flight The number of pipeline runs which are queued or running
+# TYPE jx_pipeline_runs_in_flight gauge
+jx_pipeline_runs_in_flight{branch_kind="pullrequest",repository="jstrachan/myapp",state="running"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(running), "jx_pipeline_queue_seconds", "jx_pipeline_runs_in_flight"))
+
+	state.Stages[1].Completed = timeAt(200)
+	state.Stages[1].Status = metrics.PipelineStatusFailed
+	state.Started = timeAt(10)
+	state.Completed = timeAt(210)
+	state.Status = metrics.PipelineStatusFailed
+	collector.Observe(state)
+	collector.Observe(state)
+
+	completed := `
+# HELP jx_pipeline_runs_total The number of completed pipeline runs
+# TYPE jx_pipeline_runs_total counter
+jx_pipeline_runs_total{branch_kind="pullrequest",repository="jstrachan/myapp",status="failed",type="build"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(completed), "jx_pipeline_runs_total", "jx_pipeline_runs_in_flight"))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	stageCounts := map[string]uint64{}
+	for _, family := range families {
+		switch family.GetName() {
+		case "jx_pipeline_duration_seconds":
+			require.Len(t, family.Metric, 1)
+			assert.Equal(t, 200.0, family.Metric[0].Histogram.GetSampleSum(), "the duration should be from the start time")
+		case "jx_pipeline_stage_duration_seconds":
+			for _, m := range family.Metric {
+				for _, l := range m.Label {
+					if l.GetName() == "stage" {
+						stageCounts[l.GetValue()] = m.Histogram.GetSampleCount()
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]uint64{"from build pack": 1, "promote": 1}, stageCounts, "each stage should be observed once")
+}
+
+func TestPipelineCollectorSkipsRunsCompletedBeforeStart(t *testing.T) {
+	t.Parallel()
+
+	collector := metrics.NewPipelineCollector(*timeAt(300))
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	collector.Observe(&metrics.PipelineRunState{
+		Name:       "jstrachan-myapp-master-1",
+		Repository: "jstrachan/myapp",
+		BranchKind: metrics.BranchKindRelease,
+		Type:       "build",
+		Created:    start,
+		Running:    timeAt(10),
+		Completed:  timeAt(200),
+		Status:     metrics.PipelineStatusSucceeded,
+	})
+	collector.Observe(&metrics.PipelineRunState{
+		Name:       "jstrachan-myapp-master-2",
+		Repository: "jstrachan/myapp",
+		BranchKind: metrics.BranchKindRelease,
+		Type:       "build",
+		Created:    *timeAt(100),
+		Running:    timeAt(110),
+		Completed:  timeAt(400),
+		Status:     metrics.PipelineStatusSucceeded,
+	})
+
+	expected := `
+# HELP jx_pipeline_runs_total The number of completed pipeline runs
+# TYPE jx_pipeline_runs_total counter
+jx_pipeline_runs_total{branch_kind="release",repository="jstrachan/myapp",status="succeeded",type="build"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "jx_pipeline_runs_total"), "only the run which completed after the collector started should be counted")
+}
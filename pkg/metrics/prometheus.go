@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "jx"
+
+var deliveryLabels = []string{"team", "app", "environment"}
+
+// DeliveryCollector exports the latest delivery metrics as Prometheus gauges
+type DeliveryCollector struct {
+	lock    sync.RWMutex
+	metrics []*DeliveryMetrics
+
+	deployments         *prometheus.Desc
+	failedDeployments   *prometheus.Desc
+	deploymentFrequency *prometheus.Desc
+	leadTime            *prometheus.Desc
+	changeFailureRate   *prometheus.Desc
+	meanTimeToRestore   *prometheus.Desc
+}
+
+// NewDeliveryCollector creates a new collector of the delivery metrics
+func NewDeliveryCollector() *DeliveryCollector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "delivery", name), help, deliveryLabels, nil)
+	}
+	return &DeliveryCollector{
+		deployments:         desc("deployments", "The number of successful deployments in the window"),
+		failedDeployments:   desc("failed_deployments", "The number of failed deployments in the window"),
+		deploymentFrequency: desc("deployment_frequency_per_day", "The number of successful deployments per day"),
+		leadTime:            desc("lead_time_seconds", "The mean time from the start of the earliest pipeline which built a change to it being deployed"),
+		changeFailureRate:   desc("change_failure_rate", "The ratio of deployments which failed"),
+		meanTimeToRestore:   desc("mean_time_to_restore_seconds", "The mean time from a failed deployment to the next successful deployment"),
+	}
+}
+
+// Update replaces the delivery metrics which are exported
+func (c *DeliveryCollector) Update(metrics []*DeliveryMetrics) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.metrics = metrics
+}
+
+// Describe implements prometheus.Collector
+func (c *DeliveryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.deployments
+	ch <- c.failedDeployments
+	ch <- c.deploymentFrequency
+	ch <- c.leadTime
+	ch <- c.changeFailureRate
+	ch <- c.meanTimeToRestore
+}
+
+// Collect implements prometheus.Collector
+func (c *DeliveryCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, m := range c.metrics {
+		labels := []string{m.Team, m.App, m.Environment}
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+		}
+		gauge(c.deployments, float64(m.Deployments))
+		gauge(c.failedDeployments, float64(m.FailedDeployments))
+		gauge(c.deploymentFrequency, m.DeploymentFrequency)
+		gauge(c.leadTime, m.LeadTime.Seconds())
+		gauge(c.changeFailureRate, m.ChangeFailureRate)
+		gauge(c.meanTimeToRestore, m.MeanTimeToRestore.Seconds())
+	}
+}
+
+var buildCostLabels = []string{"team", "repository", "branch_kind", "currency"}
+
+// BuildCostCollector exports the cost of the builds grouped by team, repository and kind of branch as Prometheus gauges
+type BuildCostCollector struct {
+	lock   sync.RWMutex
+	groups []*BuildCostGroup
+
+	builds         *prometheus.Desc
+	cost           *prometheus.Desc
+	cpuCoreHours   *prometheus.Desc
+	memoryGiBHours *prometheus.Desc
+}
+
+// NewBuildCostCollector creates a new collector of the build costs
+func NewBuildCostCollector() *BuildCostCollector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "build", name), help, buildCostLabels, nil)
+	}
+	return &BuildCostCollector{
+		builds:         desc("cost_builds", "The number of builds in the window whose cost was computed"),
+		cost:           desc("cost", "The cost of the resources requested by the builds in the window"),
+		cpuCoreHours:   desc("cpu_core_hours", "The CPU core hours requested by the builds in the window"),
+		memoryGiBHours: desc("memory_gib_hours", "The GiB hours of memory requested by the builds in the window"),
+	}
+}
+
+// Update replaces the build costs which are exported. The costs should be grouped by team, repository and kind of branch
+func (c *BuildCostCollector) Update(groups []*BuildCostGroup) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.groups = groups
+}
+
+// Describe implements prometheus.Collector
+func (c *BuildCostCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.builds
+	ch <- c.cost
+	ch <- c.cpuCoreHours
+	ch <- c.memoryGiBHours
+}
+
+// Collect implements prometheus.Collector
+func (c *BuildCostCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, g := range c.groups {
+		labels := []string{g.Team, g.Repository, g.BranchKind, g.Currency}
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+		}
+		gauge(c.builds, float64(g.Builds))
+		gauge(c.cost, g.Cost)
+		gauge(c.cpuCoreHours, g.CPUCoreHours)
+		gauge(c.memoryGiBHours, g.MemoryGiBHours)
+	}
+}
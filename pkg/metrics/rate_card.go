@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// RateCardConfigMap the name of the ConfigMap in the team namespace with the rate card used to price builds
+	RateCardConfigMap = "jx-build-rate-card"
+	// RateCardConfigMapKey the key of the rate card in the ConfigMap
+	RateCardConfigMapKey = "rate-card.yaml"
+)
+
+// RateCard the prices used to compute the cost of the resources requested by builds
+type RateCard struct {
+	// Currency the currency of the prices
+	Currency string `json:"currency"`
+	// CPUCoreHour the price of requesting one CPU core for an hour
+	CPUCoreHour float64 `json:"cpuCoreHour"`
+	// MemoryGiBHour the price of requesting one GiB of memory for an hour
+	MemoryGiBHour float64 `json:"memoryGiBHour"`
+	// DefaultCPU the CPU cores assumed to be requested by builds whose pods have been deleted
+	DefaultCPU float64 `json:"defaultCPU"`
+	// DefaultMemoryGiB the GiB of memory assumed to be requested by builds whose pods have been deleted
+	DefaultMemoryGiB float64 `json:"defaultMemoryGiB"`
+}
+
+// DefaultRateCard returns the rate card used when none is configured which is based on the on demand price of
+// general purpose cloud instances
+func DefaultRateCard() *RateCard {
+	return &RateCard{
+		Currency:         "USD",
+		CPUCoreHour:      0.031611,
+		MemoryGiBHour:    0.004237,
+		DefaultCPU:       1,
+		DefaultMemoryGiB: 2,
+	}
+}
+
+// Cost returns the price of the CPU core hours and GiB hours of memory
+func (r *RateCard) Cost(cpuCoreHours float64, memoryGiBHours float64) float64 {
+	return cpuCoreHours*r.CPUCoreHour + memoryGiBHours*r.MemoryGiBHour
+}
+
+// Validate returns an error if the rate card is invalid
+func (r *RateCard) Validate() error {
+	if r.Currency == "" {
+		return fmt.Errorf("the rate card has no currency")
+	}
+	for name, value := range map[string]float64{
+		"cpuCoreHour":      r.CPUCoreHour,
+		"memoryGiBHour":    r.MemoryGiBHour,
+		"defaultCPU":       r.DefaultCPU,
+		"defaultMemoryGiB": r.DefaultMemoryGiB,
+	} {
+		if value < 0 {
+			return fmt.Errorf("the %s of the rate card cannot be negative but was %v", name, value)
+		}
+	}
+	return nil
+}
+
+// ParseRateCard parses a YAML rate card. Any prices which are not specified are taken from the default rate card
+func ParseRateCard(data []byte) (*RateCard, error) {
+	rateCard := DefaultRateCard()
+	err := yaml.Unmarshal(data, rateCard)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the rate card")
+	}
+	err = rateCard.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return rateCard, nil
+}
+
+// LoadRateCard loads the rate card from the ConfigMap in the team namespace, returning the default rate card if there
+// is no ConfigMap
+func LoadRateCard(kubeClient kubernetes.Interface, ns string) (*RateCard, error) {
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get(RateCardConfigMap, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return DefaultRateCard(), nil
+		}
+		return nil, errors.Wrapf(err, "getting the ConfigMap %s in namespace %s", RateCardConfigMap, ns)
+	}
+	data := cm.Data[RateCardConfigMapKey]
+	if data == "" {
+		return DefaultRateCard(), nil
+	}
+	rateCard, err := ParseRateCard([]byte(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "in the ConfigMap %s", RateCardConfigMap)
+	}
+	return rateCard, nil
+}
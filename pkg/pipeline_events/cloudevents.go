@@ -0,0 +1,76 @@
+package pipline_events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// CloudEventsHTTPProvider implements PipelineEventsProvider by posting CloudEvents in the structured content mode to
+// an HTTP endpoint such as a Knative broker
+type CloudEventsHTTPProvider struct {
+	URL string
+	// Headers the additional HTTP headers to send, such as authorization
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// NewCloudEventsHTTPProvider creates a provider which posts the events to the given URL
+func NewCloudEventsHTTPProvider(url string) PipelineEventsProvider {
+	return &CloudEventsHTTPProvider{
+		URL:    url,
+		Client: util.GetClientWithTimeout(30 * time.Second),
+	}
+}
+
+// SendActivity sends the current status of the activity
+func (p *CloudEventsHTTPProvider) SendActivity(a *v1.PipelineActivity) error {
+	return p.send(NewActivityEvent(a))
+}
+
+// SendRelease sends the release
+func (p *CloudEventsHTTPProvider) SendRelease(r *v1.Release) error {
+	return p.send(NewReleaseEvent(r))
+}
+
+// SendPromotion sends the current status of the promotion of the activity to an environment
+func (p *CloudEventsHTTPProvider) SendPromotion(a *v1.PipelineActivity, promote *v1.PromoteActivityStep) error {
+	return p.send(NewPromotionEvent(a, promote))
+}
+
+func (p *CloudEventsHTTPProvider) send(event *CloudEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "marshalling the %s event %s", event.Type, event.ID)
+	}
+	req, err := http.NewRequest(http.MethodPost, p.URL, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrapf(err, "creating the request to %s", p.URL)
+	}
+	req.Header.Set("Content-Type", CloudEventsContentType)
+	for k, v := range p.Headers {
+		req.Header.Set(k, v)
+	}
+	client := p.Client
+	if client == nil {
+		client = util.GetClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "posting the %s event %s to %s", event.Type, event.ID, p.URL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("posting the %s event %s to %s returned status %d: %s", event.Type, event.ID, p.URL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
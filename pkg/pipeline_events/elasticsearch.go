@@ -100,6 +100,26 @@ func (e ElasticsearchProvider) SendRelease(r *v1.Release) error {
 	return nil
 }
 
+// SendPromotion indexes the promotion of the activity to an environment
+func (e ElasticsearchProvider) SendPromotion(a *v1.PipelineActivity, promote *v1.PromoteActivityStep) error {
+	event := NewPromotionEvent(a, promote)
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	var index *Index
+
+	err = e.post("promotions", event.ID, data, &index)
+	if err != nil {
+		return err
+	}
+
+	if index.Id == "" {
+		return fmt.Errorf("promotion %s not created, no elasticsearch id returned from POST\n", event.Subject)
+	}
+	return nil
+}
+
 func (e ElasticsearchProvider) SendIssue(i *ESIssue) error {
 	id := strings.Replace(i.URL, ":", "-", -1)
 	id = strings.Replace(id, "/", "-", -1)
@@ -0,0 +1,223 @@
+package pipline_events
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// CloudEventsSpecVersion the version of the CloudEvents specification the events conform to
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType the content type of an event in the structured content mode
+	CloudEventsContentType = "application/cloudevents+json"
+
+	// EventTypeActivity the type of the events sent when the status of a pipeline activity changes
+	EventTypeActivity = "io.jenkins-x.pipeline.activity.v1"
+	// EventTypeRelease the type of the events sent when a release is created
+	EventTypeRelease = "io.jenkins-x.release.v1"
+	// EventTypePromotion the type of the events sent when the status of a promotion to an environment changes
+	EventTypePromotion = "io.jenkins-x.promotion.v1"
+
+	// eventSource the prefix of the source of the events which is followed by the namespace and resource kind
+	eventSource = "/apis/jenkins.io/v1/namespaces/"
+)
+
+// CloudEvent a CloudEvent encoded in the structured content mode
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            *time.Time  `json:"time,omitempty"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+// ActivityEvent the data of an activity event. Its fields are a stable schema which only changes with the event type
+type ActivityEvent struct {
+	Name          string     `json:"name"`
+	Namespace     string     `json:"namespace"`
+	Pipeline      string     `json:"pipeline"`
+	Build         string     `json:"build"`
+	Status        string     `json:"status"`
+	GitOwner      string     `json:"gitOwner,omitempty"`
+	GitRepository string     `json:"gitRepository,omitempty"`
+	GitBranch     string     `json:"gitBranch,omitempty"`
+	GitURL        string     `json:"gitUrl,omitempty"`
+	Context       string     `json:"context,omitempty"`
+	CommitSHA     string     `json:"commitSha,omitempty"`
+	Author        string     `json:"author,omitempty"`
+	Version       string     `json:"version,omitempty"`
+	BuildLogsURL  string     `json:"buildLogsUrl,omitempty"`
+	Started       *time.Time `json:"started,omitempty"`
+	Completed     *time.Time `json:"completed,omitempty"`
+}
+
+// ReleaseEvent the data of a release event. Its fields are a stable schema which only changes with the event type
+type ReleaseEvent struct {
+	Name            string   `json:"name"`
+	Namespace       string   `json:"namespace"`
+	App             string   `json:"app"`
+	Version         string   `json:"version"`
+	GitOwner        string   `json:"gitOwner,omitempty"`
+	GitRepository   string   `json:"gitRepository,omitempty"`
+	GitURL          string   `json:"gitUrl,omitempty"`
+	ReleaseNotesURL string   `json:"releaseNotesUrl,omitempty"`
+	Commits         []string `json:"commits,omitempty"`
+	Issues          []string `json:"issues,omitempty"`
+	PullRequests    []string `json:"pullRequests,omitempty"`
+}
+
+// PromotionEvent the data of a promotion event. Its fields are a stable schema which only changes with the event type
+type PromotionEvent struct {
+	Activity       string     `json:"activity"`
+	Namespace      string     `json:"namespace"`
+	Pipeline       string     `json:"pipeline"`
+	Build          string     `json:"build"`
+	App            string     `json:"app"`
+	Version        string     `json:"version"`
+	Environment    string     `json:"environment"`
+	Status         string     `json:"status"`
+	PullRequestURL string     `json:"pullRequestUrl,omitempty"`
+	ApplicationURL string     `json:"applicationUrl,omitempty"`
+	Started        *time.Time `json:"started,omitempty"`
+	Completed      *time.Time `json:"completed,omitempty"`
+}
+
+// NewActivityEvent creates the event of the current status of the activity
+func NewActivityEvent(a *v1.PipelineActivity) *CloudEvent {
+	spec := &a.Spec
+	data := &ActivityEvent{
+		Name:          a.Name,
+		Namespace:     a.Namespace,
+		Pipeline:      spec.Pipeline,
+		Build:         spec.Build,
+		Status:        string(spec.Status),
+		GitOwner:      a.RepositoryOwner(),
+		GitRepository: a.RepositoryName(),
+		GitBranch:     a.BranchName(),
+		GitURL:        spec.GitURL,
+		Context:       spec.Context,
+		CommitSHA:     spec.LastCommitSHA,
+		Author:        spec.Author,
+		Version:       spec.Version,
+		BuildLogsURL:  spec.BuildLogsURL,
+		Started:       timeOf(spec.StartedTimestamp),
+		Completed:     timeOf(spec.CompletedTimestamp),
+	}
+	eventTime := data.Completed
+	if eventTime == nil {
+		eventTime = data.Started
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              fmt.Sprintf("%s-%s", eventUID(a.UID, a.Name), spec.Status),
+		Source:          eventSource + a.Namespace + "/pipelineactivities",
+		Type:            EventTypeActivity,
+		Subject:         a.Name,
+		Time:            eventTime,
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// NewReleaseEvent creates the event of the release
+func NewReleaseEvent(r *v1.Release) *CloudEvent {
+	spec := &r.Spec
+	data := &ReleaseEvent{
+		Name:            r.Name,
+		Namespace:       r.Namespace,
+		App:             spec.Name,
+		Version:         spec.Version,
+		GitOwner:        spec.GitOwner,
+		GitRepository:   spec.GitRepository,
+		GitURL:          spec.GitHTTPURL,
+		ReleaseNotesURL: spec.ReleaseNotesURL,
+	}
+	for _, c := range spec.Commits {
+		data.Commits = append(data.Commits, c.SHA)
+	}
+	for _, i := range spec.Issues {
+		data.Issues = append(data.Issues, i.URL)
+	}
+	for _, pr := range spec.PullRequests {
+		data.PullRequests = append(data.PullRequests, pr.URL)
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              eventUID(r.UID, r.Name),
+		Source:          eventSource + r.Namespace + "/releases",
+		Type:            EventTypeRelease,
+		Subject:         r.Name,
+		Time:            timeOf(&r.CreationTimestamp),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// NewPromotionEvent creates the event of the current status of the promotion of the activity
+func NewPromotionEvent(a *v1.PipelineActivity, promote *v1.PromoteActivityStep) *CloudEvent {
+	spec := &a.Spec
+	data := &PromotionEvent{
+		Activity:       a.Name,
+		Namespace:      a.Namespace,
+		Pipeline:       spec.Pipeline,
+		Build:          spec.Build,
+		App:            a.RepositoryName(),
+		Version:        spec.Version,
+		Environment:    promote.Environment,
+		Status:         string(promote.Status),
+		ApplicationURL: promote.ApplicationURL,
+		Started:        timeOf(promote.StartedTimestamp),
+		Completed:      timeOf(promote.CompletedTimestamp),
+	}
+	if promote.PullRequest != nil {
+		data.PullRequestURL = promote.PullRequest.PullRequestURL
+	}
+	eventTime := data.Completed
+	if eventTime == nil {
+		eventTime = data.Started
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              fmt.Sprintf("%s-%s-%s", eventUID(a.UID, a.Name), promote.Environment, promote.Status),
+		Source:          eventSource + a.Namespace + "/pipelineactivities",
+		Type:            EventTypePromotion,
+		Subject:         a.Name + "/" + promote.Environment,
+		Time:            eventTime,
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// PromoteSteps returns the promote steps of the activity
+func PromoteSteps(a *v1.PipelineActivity) []*v1.PromoteActivityStep {
+	var answer []*v1.PromoteActivityStep
+	for i := range a.Spec.Steps {
+		if promote := a.Spec.Steps[i].Promote; promote != nil && promote.Environment != "" {
+			answer = append(answer, promote)
+		}
+	}
+	return answer
+}
+
+// eventUID returns the UID of a resource, falling back to its name if it has not been created yet
+func eventUID(uid types.UID, name string) string {
+	if uid != "" {
+		return string(uid)
+	}
+	return name
+}
+
+func timeOf(t *metav1.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	answer := t.Time.UTC()
+	return &answer
+}
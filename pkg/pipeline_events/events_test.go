@@ -0,0 +1,309 @@
+// +build unit
+
+package pipline_events
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var eventStart = metav1.NewTime(time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC))
+
+func newTestActivity() *v1.PipelineActivity {
+	completed := metav1.NewTime(eventStart.Add(5 * time.Minute))
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jstrachan-myapp-master-3",
+			Namespace: "jx",
+			UID:       "1234",
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:           "jstrachan/myapp/master",
+			Build:              "3",
+			Status:             v1.ActivityStatusTypeSucceeded,
+			GitOwner:           "jstrachan",
+			GitRepository:      "myapp",
+			GitBranch:          "master",
+			Version:            "0.0.3",
+			LastCommitSHA:      "abc123",
+			StartedTimestamp:   &eventStart,
+			CompletedTimestamp: &completed,
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{
+							Status:           v1.ActivityStatusTypeRunning,
+							StartedTimestamp: &completed,
+						},
+						Environment: "staging",
+						PullRequest: &v1.PromotePullRequestStep{
+							PullRequestURL: "https://github.com/jstrachan/environment-staging/pull/7",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestNewActivityEvent(t *testing.T) {
+	t.Parallel()
+
+	event := NewActivityEvent(newTestActivity())
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	actual := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &actual))
+	assert.Equal(t, "1.0", actual["specversion"])
+	assert.Equal(t, "1234-Succeeded", actual["id"])
+	assert.Equal(t, "/apis/jenkins.io/v1/namespaces/jx/pipelineactivities", actual["source"])
+	assert.Equal(t, EventTypeActivity, actual["type"])
+	assert.Equal(t, "2020-03-01T09:05:00Z", actual["time"])
+	assert.Equal(t, map[string]interface{}{
+		"name":          "jstrachan-myapp-master-3",
+		"namespace":     "jx",
+		"pipeline":      "jstrachan/myapp/master",
+		"build":         "3",
+		"status":        "Succeeded",
+		"gitOwner":      "jstrachan",
+		"gitRepository": "myapp",
+		"gitBranch":     "master",
+		"commitSha":     "abc123",
+		"version":       "0.0.3",
+		"started":       "2020-03-01T09:00:00Z",
+		"completed":     "2020-03-01T09:05:00Z",
+	}, actual["data"])
+}
+
+func TestNewPromotionEvent(t *testing.T) {
+	t.Parallel()
+
+	a := newTestActivity()
+	promotes := PromoteSteps(a)
+	require.Len(t, promotes, 1)
+	event := NewPromotionEvent(a, promotes[0])
+	assert.Equal(t, "1234-staging-Running", event.ID)
+	assert.Equal(t, "jstrachan-myapp-master-3/staging", event.Subject)
+	data := event.Data.(*PromotionEvent)
+	assert.Equal(t, "myapp", data.App)
+	assert.Equal(t, "0.0.3", data.Version)
+	assert.Equal(t, "https://github.com/jstrachan/environment-staging/pull/7", data.PullRequestURL)
+}
+
+func TestCloudEventsHTTPProvider(t *testing.T) {
+	t.Parallel()
+
+	var contentType string
+	received := CloudEvent{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	provider := NewCloudEventsHTTPProvider(server.URL)
+	err := provider.SendRelease(&v1.Release{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-0.0.3", Namespace: "jx", UID: "5678"},
+		Spec:       v1.ReleaseSpec{Name: "myapp", Version: "0.0.3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, CloudEventsContentType, contentType)
+	assert.Equal(t, "5678", received.ID)
+	assert.Equal(t, EventTypeRelease, received.Type)
+	assert.Equal(t, "myapp-0.0.3", received.Subject)
+}
+
+func TestCloudEventsHTTPProviderError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broker not ready", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := NewCloudEventsHTTPProvider(server.URL).SendActivity(newTestActivity())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "returned status 503: broker not ready")
+}
+
+
+// startNATSServer starts an embedded NATS server listening on a random local port
+func startNATSServer(t *testing.T, options *server.Options) *server.Server {
+	options.Host = "127.0.0.1"
+	options.Port = -1
+	options.NoLog = true
+	options.NoSigs = true
+	s, err := server.NewServer(options)
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(10*time.Second), "the NATS server should start")
+	return s
+}
+
+// subscribeNATS subscribes to all the subjects below the prefix so that the published events can be checked
+func subscribeNATS(t *testing.T, url string, prefix string, options ...nats.Option) (*nats.Conn, *nats.Subscription) {
+	conn, err := nats.Connect(url, options...)
+	require.NoError(t, err)
+	sub, err := conn.SubscribeSync(prefix + ".>")
+	require.NoError(t, err)
+	require.NoError(t, conn.Flush())
+	return conn, sub
+}
+
+// nextNATSMessages returns the next messages of the subscription grouped by subject
+func nextNATSMessages(t *testing.T, sub *nats.Subscription, count int) map[string][]string {
+	messages := map[string][]string{}
+	for i := 0; i < count; i++ {
+		msg, err := sub.NextMsg(5 * time.Second)
+		require.NoError(t, err)
+		messages[msg.Subject] = append(messages[msg.Subject], string(msg.Data))
+	}
+	return messages
+}
+
+func TestNATSProvider(t *testing.T) {
+	t.Parallel()
+
+	s := startNATSServer(t, &server.Options{Username: "ci", Password: "secret"})
+	defer s.Shutdown()
+	url := "nats://ci:secret@" + s.Addr().String()
+	subscriber, sub := subscribeNATS(t, url, "ci.events")
+	defer subscriber.Close()
+
+	provider := NewNATSProvider(url, "ci.events")
+	defer provider.Close()
+
+	a := newTestActivity()
+	require.NoError(t, provider.SendActivity(a))
+	require.NoError(t, provider.SendPromotion(a, PromoteSteps(a)[0]))
+	require.NoError(t, provider.SendActivity(a))
+
+	messages := nextNATSMessages(t, sub, 3)
+	require.Len(t, messages["ci.events.activity"], 2)
+	require.Len(t, messages["ci.events.promotion"], 1)
+	assert.Equal(t, 2, s.NumClients(), "the connection of the provider should be reused")
+
+	event := CloudEvent{}
+	require.NoError(t, json.Unmarshal([]byte(messages["ci.events.promotion"][0]), &event))
+	assert.Equal(t, EventTypePromotion, event.Type)
+	assert.Equal(t, "1234-staging-Running", event.ID)
+}
+
+func TestNATSProviderError(t *testing.T) {
+	t.Parallel()
+
+	s := startNATSServer(t, &server.Options{
+		Users: []*server.User{
+			{
+				Username: "ci",
+				Password: "secret",
+				Permissions: &server.Permissions{
+					Publish: &server.SubjectPermission{Deny: []string{"jenkinsx.events.release"}},
+				},
+			},
+		},
+	})
+	defer s.Shutdown()
+	url := "nats://ci:secret@" + s.Addr().String()
+	subscriber, sub := subscribeNATS(t, url, DefaultNATSSubject)
+	defer subscriber.Close()
+
+	provider := NewNATSProvider(url, "")
+	defer provider.Close()
+
+	err := provider.SendRelease(&v1.Release{ObjectMeta: metav1.ObjectMeta{Name: "myapp-0.0.3"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Permissions Violation")
+
+	require.NoError(t, provider.SendActivity(newTestActivity()), "the provider should publish after an error")
+	messages := nextNATSMessages(t, sub, 1)
+	assert.Len(t, messages["jenkinsx.events.activity"], 1)
+}
+
+func TestNATSProviderTLS(t *testing.T) {
+	t.Parallel()
+
+	// use the self signed certificate of an HTTPS test server
+	https := httptest.NewTLSServer(http.NotFoundHandler())
+	defer https.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(https.Certificate())
+	s := startNATSServer(t, &server.Options{
+		Authorization: "mytoken",
+		TLS:           true,
+		TLSTimeout:    5,
+		TLSConfig:     https.TLS,
+	})
+	defer s.Shutdown()
+	url := "tls://mytoken@" + s.Addr().String()
+	subscriber, sub := subscribeNATS(t, url, DefaultNATSSubject, nats.Secure(&tls.Config{RootCAs: roots}))
+	defer subscriber.Close()
+
+	provider := NewNATSProvider(url, "")
+	provider.Timeout = 2 * time.Second
+	defer provider.Close()
+	err := provider.SendActivity(newTestActivity())
+	require.Error(t, err, "the certificate of the server should not be trusted")
+
+	provider.TLSConfig = &tls.Config{RootCAs: roots}
+	require.NoError(t, provider.SendActivity(newTestActivity()))
+
+	messages := nextNATSMessages(t, sub, 1)
+	assert.Len(t, messages["jenkinsx.events.activity"], 1)
+}
+
+func TestNATSProviderNKeyCluster(t *testing.T) {
+	t.Parallel()
+
+	user, err := nkeys.CreateUser()
+	require.NoError(t, err)
+	publicKey, err := user.PublicKey()
+	require.NoError(t, err)
+	seed, err := user.Seed()
+	require.NoError(t, err)
+	dir, err := ioutil.TempDir("", "nats-nkey")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	seedFile := filepath.Join(dir, "user.nk")
+	require.NoError(t, ioutil.WriteFile(seedFile, seed, 0600))
+
+	s := startNATSServer(t, &server.Options{Nkeys: []*server.NkeyUser{{Nkey: publicKey}}})
+	defer s.Shutdown()
+	url := "nats://" + s.Addr().String()
+	nkey, err := nats.NkeyOptionFromSeed(seedFile)
+	require.NoError(t, err)
+	subscriber, sub := subscribeNATS(t, url, DefaultNATSSubject, nkey)
+	defer subscriber.Close()
+
+	// the first URL of the cluster is not listening so the client has to use the second one
+	provider := NewNATSProvider("nats://127.0.0.1:1,"+url, "")
+	defer provider.Close()
+	err = provider.SendActivity(newTestActivity())
+	require.Error(t, err, "the server should require the NKey")
+	provider.NKeySeedFile = seedFile
+	require.NoError(t, provider.SendActivity(newTestActivity()))
+
+	messages := nextNATSMessages(t, sub, 1)
+	assert.Len(t, messages["jenkinsx.events.activity"], 1)
+}
@@ -5,4 +5,6 @@ import v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 type PipelineEventsProvider interface {
 	SendActivity(a *v1.PipelineActivity) error
 	SendRelease(a *v1.Release) error
+	// SendPromotion sends the current status of the promotion of the activity to an environment
+	SendPromotion(a *v1.PipelineActivity, promote *v1.PromoteActivityStep) error
 }
@@ -5,17 +5,17 @@ import (
 )
 
 type Quickstart struct {
-	ID             string
-	Owner          string
-	Name           string
-	Version        string
-	Language       string
-	Framework      string
-	Tags           []string
-	DownloadZipURL string
-	GitServer      string
-	GitKind        string
-	GitProvider    gits.GitProvider
+	ID             string           `json:"id"`
+	Owner          string           `json:"owner"`
+	Name           string           `json:"name"`
+	Version        string           `json:"version,omitempty"`
+	Language       string           `json:"language,omitempty"`
+	Framework      string           `json:"framework,omitempty"`
+	Tags           []string         `json:"tags,omitempty"`
+	DownloadZipURL string           `json:"downloadZipURL,omitempty"`
+	GitServer      string           `json:"gitServer,omitempty"`
+	GitKind        string           `json:"gitKind,omitempty"`
+	GitProvider    gits.GitProvider `json:"-"`
 }
 
 type QuickstartModel struct {
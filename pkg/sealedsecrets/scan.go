@@ -0,0 +1,219 @@
+package sealedsecrets
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/sops"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const templatePlaceholder = "__jx_template__"
+
+var (
+	documentSeparatorRegex = regexp.MustCompile(`(?m)^---.*$`)
+	templateLineRegex      = regexp.MustCompile(`(?m)^\s*\{\{.*\}\}\s*$`)
+	templateExprRegex      = regexp.MustCompile(`\{\{.*?\}\}`)
+)
+
+// PlaintextSecret a Secret with literal values found in a manifest file
+type PlaintextSecret struct {
+	// File the manifest file containing the Secret
+	File string
+	// Index the index of the YAML document of the Secret in the file
+	Index int
+	// Name the name of the Secret
+	Name string
+	// Namespace the namespace of the Secret if specified
+	Namespace string
+	// Keys the keys of the Secret which have literal values
+	Keys []string
+}
+
+// String returns a description of where the secret was found
+func (s *PlaintextSecret) String() string {
+	return fmt.Sprintf("%s in %s with keys %s", s.Name, s.File, strings.Join(s.Keys, ", "))
+}
+
+// FindPlaintextSecrets finds all the Secrets in the YAML files of the directory tree whose values are literals rather
+// than helm template expressions or secret URIs
+func FindPlaintextSecrets(dir string) ([]*PlaintextSecret, error) {
+	answer := []*PlaintextSecret{}
+	err := walkManifests(dir, func(fileName string, docs [][]byte) error {
+		for i, doc := range docs {
+			s := parsePlaintextSecret(doc)
+			if s != nil {
+				s.File = fileName
+				s.Index = i
+				answer = append(answer, s)
+			}
+		}
+		return nil
+	})
+	return answer, err
+}
+
+// RemovePlaintextSecrets removes the plain text Secrets from the YAML files of the directory tree returning the
+// secrets which were removed. Files which only contain plain text Secrets are deleted
+func RemovePlaintextSecrets(dir string) ([]*PlaintextSecret, error) {
+	return ReplacePlaintextSecrets(dir, func(s *PlaintextSecret, doc []byte) ([]byte, error) {
+		return nil, nil
+	})
+}
+
+// ReplacePlaintextSecrets replaces each plain text Secret in the YAML files of the directory tree with the result of
+// the given function. If the function returns no data the Secret is removed
+func ReplacePlaintextSecrets(dir string, fn func(s *PlaintextSecret, doc []byte) ([]byte, error)) ([]*PlaintextSecret, error) {
+	answer := []*PlaintextSecret{}
+	err := walkManifests(dir, func(fileName string, docs [][]byte) error {
+		modified := false
+		newDocs := [][]byte{}
+		for i, doc := range docs {
+			s := parsePlaintextSecret(doc)
+			if s == nil {
+				newDocs = append(newDocs, doc)
+				continue
+			}
+			s.File = fileName
+			s.Index = i
+			answer = append(answer, s)
+			modified = true
+			data, err := fn(s, doc)
+			if err != nil {
+				return err
+			}
+			if len(data) > 0 {
+				newDocs = append(newDocs, data)
+			}
+		}
+		if !modified {
+			return nil
+		}
+		if len(newDocs) == 0 {
+			err := os.Remove(fileName)
+			if err != nil {
+				return errors.Wrapf(err, "removing file %s", fileName)
+			}
+			return nil
+		}
+		err := ioutil.WriteFile(fileName, joinDocuments(newDocs), 0644)
+		if err != nil {
+			return errors.Wrapf(err, "saving file %s", fileName)
+		}
+		return nil
+	})
+	return answer, err
+}
+
+// ParseSecret parses the Secret in the given YAML document
+func ParseSecret(doc []byte) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := yaml.Unmarshal(doc, secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the Secret YAML")
+	}
+	return secret, nil
+}
+
+func walkManifests(dir string, fn func(fileName string, docs [][]byte) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(name)
+		if ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "reading file %s", path)
+		}
+		if sops.IsEncrypted(data) {
+			return nil
+		}
+		return fn(path, splitDocuments(data))
+	})
+}
+
+// parsePlaintextSecret returns the details of the secret if the document is a Secret which has literal values
+func parsePlaintextSecret(doc []byte) *PlaintextSecret {
+	// lets replace any helm template expressions so that templates can be parsed
+	text := templateLineRegex.ReplaceAllString(string(doc), "")
+	text = templateExprRegex.ReplaceAllString(text, templatePlaceholder)
+
+	m := map[string]interface{}{}
+	err := yaml.Unmarshal([]byte(text), &m)
+	if err != nil {
+		return nil
+	}
+	if m["kind"] != "Secret" || (m["apiVersion"] != "v1" && m["apiVersion"] != nil) {
+		return nil
+	}
+	s := &PlaintextSecret{}
+	if metadata, ok := m["metadata"].(map[string]interface{}); ok {
+		s.Name, _ = metadata["name"].(string)
+		s.Namespace, _ = metadata["namespace"].(string)
+	}
+	for _, key := range []string{"data", "stringData"} {
+		values, ok := m[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k, v := range values {
+			if isLiteralValue(v) {
+				s.Keys = append(s.Keys, k)
+			}
+		}
+	}
+	if len(s.Keys) == 0 {
+		return nil
+	}
+	return s
+}
+
+func isLiteralValue(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	text := fmt.Sprintf("%v", value)
+	return text != "" && !strings.Contains(text, templatePlaceholder) && !kube.ContainsSecretURI(text)
+}
+
+func splitDocuments(data []byte) [][]byte {
+	answer := [][]byte{}
+	for _, doc := range documentSeparatorRegex.Split(string(data), -1) {
+		if strings.TrimSpace(doc) != "" {
+			answer = append(answer, []byte(strings.TrimLeft(doc, "\n")))
+		}
+	}
+	return answer
+}
+
+func joinDocuments(docs [][]byte) []byte {
+	var buffer bytes.Buffer
+	for i, doc := range docs {
+		if i > 0 {
+			buffer.WriteString("---\n")
+		}
+		buffer.Write(doc)
+		if !bytes.HasSuffix(doc, []byte("\n")) {
+			buffer.WriteString("\n")
+		}
+	}
+	return buffer.Bytes()
+}
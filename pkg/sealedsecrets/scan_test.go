@@ -0,0 +1,110 @@
+// +build unit
+
+package sealedsecrets_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/sealedsecrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	plaintextSecret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: plain
+type: Opaque
+data:
+  password: czNjcjN0
+`
+	templatedSecret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: templated
+{{- if .Values.token }}
+stringData:
+  token: {{ .Values.token | quote }}
+  url: "vault:secret/data/jx/pipelineUser:url"
+{{- end }}
+`
+	sopsSecret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: sops
+stringData:
+  password: "sops:secret/jx/adminUser:password"
+`
+	configMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cheese
+data:
+  password: not-a-secret
+`
+)
+
+func writeManifests(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "test-sealed-secrets-")
+	require.NoError(t, err)
+	files := map[string]string{
+		"env/templates/plain-secret.yaml":     plaintextSecret,
+		"env/templates/templated-secret.yaml": templatedSecret,
+		"env/templates/sops-secret.yaml":      sopsSecret,
+		"env/templates/mixed.yaml":            configMap + "---\n" + plaintextSecret,
+		".git/ignored.yaml":                   plaintextSecret,
+		"README.md":                           plaintextSecret,
+	}
+	for name, text := range files {
+		fileName := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+		require.NoError(t, ioutil.WriteFile(fileName, []byte(text), 0644))
+	}
+	return dir
+}
+
+func TestFindPlaintextSecrets(t *testing.T) {
+	t.Parallel()
+
+	dir := writeManifests(t)
+	defer os.RemoveAll(dir)
+
+	secrets, err := sealedsecrets.FindPlaintextSecrets(dir)
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	files := []string{}
+	for _, s := range secrets {
+		assert.Equal(t, "plain", s.Name)
+		assert.Equal(t, []string{"password"}, s.Keys)
+		files = append(files, filepath.Base(s.File))
+	}
+	assert.ElementsMatch(t, []string{"plain-secret.yaml", "mixed.yaml"}, files)
+}
+
+func TestRemovePlaintextSecrets(t *testing.T) {
+	t.Parallel()
+
+	dir := writeManifests(t)
+	defer os.RemoveAll(dir)
+
+	removed, err := sealedsecrets.RemovePlaintextSecrets(dir)
+	require.NoError(t, err)
+	assert.Len(t, removed, 2)
+
+	_, err = os.Stat(filepath.Join(dir, "env/templates/plain-secret.yaml"))
+	assert.True(t, os.IsNotExist(err), "a file of only plain text secrets should be removed")
+	data, err := ioutil.ReadFile(filepath.Join(dir, "env/templates/mixed.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, configMap, string(data))
+	data, err = ioutil.ReadFile(filepath.Join(dir, "env/templates/templated-secret.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, templatedSecret, string(data), "templated secrets should not be modified")
+
+	secrets, err := sealedsecrets.FindPlaintextSecrets(dir)
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+}
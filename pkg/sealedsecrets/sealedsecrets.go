@@ -0,0 +1,219 @@
+package sealedsecrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultControllerNamespace the namespace the sealed-secrets controller is installed into by default
+	DefaultControllerNamespace = "kube-system"
+
+	// DefaultControllerName the name of the Service of the sealed-secrets controller
+	DefaultControllerName = "sealed-secrets-controller"
+
+	// CertificatePath the path the controller serves the certificate of its active sealing key on
+	CertificatePath = "/v1/cert.pem"
+
+	// AnnotationNamespaceWide the annotation which marks a sealed secret as usable with any name in its namespace
+	AnnotationNamespaceWide = "sealedsecrets.bitnami.com/namespace-wide"
+
+	// AnnotationClusterWide the annotation which marks a sealed secret as usable with any name in any namespace
+	AnnotationClusterWide = "sealedsecrets.bitnami.com/cluster-wide"
+
+	// APIVersion the API version of the SealedSecret resources
+	APIVersion = "bitnami.com/v1alpha1"
+
+	// Kind the kind of the SealedSecret resources
+	Kind = "SealedSecret"
+
+	sessionKeyBytes = 32
+)
+
+// Scope the scope a secret is sealed for which restricts where the controller will unseal it
+type Scope string
+
+const (
+	// ScopeStrict the secret can only be unsealed with the same name and namespace
+	ScopeStrict Scope = "strict"
+	// ScopeNamespaceWide the secret can be renamed within the same namespace
+	ScopeNamespaceWide Scope = "namespace-wide"
+	// ScopeClusterWide the secret can be unsealed in any namespace with any name
+	ScopeClusterWide Scope = "cluster-wide"
+)
+
+// ScopeValues the valid scope values
+var ScopeValues = []string{string(ScopeStrict), string(ScopeNamespaceWide), string(ScopeClusterWide)}
+
+// SealedSecret a Bitnami sealed secret resource
+type SealedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SealedSecretSpec `json:"spec"`
+}
+
+// SealedSecretSpec the spec of a sealed secret
+type SealedSecretSpec struct {
+	Template      SecretTemplateSpec `json:"template,omitempty"`
+	EncryptedData map[string]string  `json:"encryptedData"`
+}
+
+// SecretTemplateSpec the template of the Secret created by the controller when unsealing
+type SecretTemplateSpec struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Type corev1.SecretType `json:"type,omitempty"`
+}
+
+// ParseCertificate parses the PEM encoded certificate of the sealed-secrets controller returning its RSA public key
+func ParseCertificate(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the sealed-secrets certificate")
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the sealed-secrets certificate does not contain an RSA public key")
+	}
+	return key, nil
+}
+
+// FetchCertificate returns the PEM encoded certificate of the active sealing key served by the controller with the
+// given Service name and namespace. The certificate is fetched through the API server proxy, the same way kubeseal does,
+// so only the public key is ever read
+func FetchCertificate(kubeClient kubernetes.Interface, ns string, name string) ([]byte, error) {
+	data, err := kubeClient.CoreV1().Services(ns).ProxyGet("http", name, "", CertificatePath, nil).DoRaw()
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching the sealed-secrets certificate from the Service %s in namespace %s. Is the sealed-secrets controller installed?", name, ns)
+	}
+	if _, err := ParseCertificate(data); err != nil {
+		return nil, errors.Wrapf(err, "parsing the certificate served by the Service %s in namespace %s", name, ns)
+	}
+	return data, nil
+}
+
+// SealSecret encrypts the data of the given secret with the public key of the controller returning the sealed secret
+func SealSecret(secret *corev1.Secret, pubKey *rsa.PublicKey, scope Scope) (*SealedSecret, error) {
+	if secret.Name == "" {
+		return nil, fmt.Errorf("cannot seal a Secret without a name")
+	}
+	if scope != ScopeClusterWide && secret.Namespace == "" {
+		return nil, fmt.Errorf("cannot seal the Secret %s with the %s scope without a namespace", secret.Name, scope)
+	}
+	label, err := scopeLabel(secret.Namespace, secret.Name, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := &SealedSecret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: APIVersion,
+			Kind:       Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.Name,
+			Namespace:   secret.Namespace,
+			Labels:      secret.Labels,
+			Annotations: map[string]string{},
+		},
+		Spec: SealedSecretSpec{
+			Template: SecretTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        secret.Name,
+					Namespace:   secret.Namespace,
+					Labels:      secret.Labels,
+					Annotations: secret.Annotations,
+				},
+				Type: secret.Type,
+			},
+			EncryptedData: map[string]string{},
+		},
+	}
+	switch scope {
+	case ScopeNamespaceWide:
+		sealed.Annotations[AnnotationNamespaceWide] = "true"
+	case ScopeClusterWide:
+		sealed.Annotations[AnnotationClusterWide] = "true"
+	}
+	if len(sealed.Annotations) == 0 {
+		sealed.Annotations = nil
+	}
+
+	values := map[string][]byte{}
+	for k, v := range secret.Data {
+		values[k] = v
+	}
+	for k, v := range secret.StringData {
+		values[k] = []byte(v)
+	}
+	for k, v := range values {
+		ciphertext, err := HybridEncrypt(rand.Reader, pubKey, v, label)
+		if err != nil {
+			return nil, errors.Wrapf(err, "encrypting key %s of Secret %s", k, secret.Name)
+		}
+		sealed.Spec.EncryptedData[k] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+	return sealed, nil
+}
+
+// HybridEncrypt encrypts the plain text using the same scheme as the sealed-secrets controller: a random AES-256-GCM
+// session key encrypts the data and the session key is encrypted with RSA-OAEP using the scope label
+func HybridEncrypt(rnd io.Reader, pubKey *rsa.PublicKey, plaintext []byte, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, sessionKeyBytes)
+	if _, err := io.ReadFull(rnd, sessionKey); err != nil {
+		return nil, errors.Wrap(err, "generating the session key")
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rnd, pubKey, sessionKey, label)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting the session key")
+	}
+
+	ciphertext := make([]byte, 2, 2+len(rsaCiphertext)+len(plaintext)+aed.Overhead())
+	binary.BigEndian.PutUint16(ciphertext, uint16(len(rsaCiphertext)))
+	ciphertext = append(ciphertext, rsaCiphertext...)
+
+	// the session key is only ever used once so a zero nonce is safe
+	zeroNonce := make([]byte, aed.NonceSize())
+	return aed.Seal(ciphertext, zeroNonce, plaintext, nil), nil
+}
+
+func scopeLabel(ns string, name string, scope Scope) ([]byte, error) {
+	switch scope {
+	case "", ScopeStrict:
+		return []byte(ns + "/" + name), nil
+	case ScopeNamespaceWide:
+		return []byte(ns), nil
+	case ScopeClusterWide:
+		return []byte{}, nil
+	default:
+		return nil, util.InvalidOption("scope", string(scope), ScopeValues)
+	}
+}
@@ -0,0 +1,148 @@
+// +build unit
+
+package sealedsecrets_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/sealedsecrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestKeyPair generates a sealing key pair like the sealed-secrets controller returning the PEM encoded certificate
+func newTestKeyPair(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sealed-secret"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// hybridDecrypt decrypts the data in the same way as the sealed-secrets controller
+func hybridDecrypt(t *testing.T, key *rsa.PrivateKey, encoded string, label string) string {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	rsaLen := int(binary.BigEndian.Uint16(ciphertext))
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, ciphertext[2:2+rsaLen], []byte(label))
+	require.NoError(t, err, "decrypting the session key with label %q", label)
+	block, err := aes.NewCipher(sessionKey)
+	require.NoError(t, err)
+	aed, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	plaintext, err := aed.Open(nil, make([]byte, aed.NonceSize()), ciphertext[2+rsaLen:], nil)
+	require.NoError(t, err)
+	return string(plaintext)
+}
+
+func TestSealSecret(t *testing.T) {
+	t.Parallel()
+
+	key, cert := newTestKeyPair(t)
+	pubKey, err := sealedsecrets.ParseCertificate(cert)
+	require.NoError(t, err)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-secret",
+			Namespace: "jx-staging",
+			Labels:    map[string]string{"app": "cheese"},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+		StringData: map[string]string{"token": "abc123"},
+	}
+
+	sealed, err := sealedsecrets.SealSecret(secret, pubKey, sealedsecrets.ScopeStrict)
+	require.NoError(t, err)
+	assert.Equal(t, sealedsecrets.Kind, sealed.Kind)
+	assert.Equal(t, "my-secret", sealed.Spec.Template.Name)
+	assert.Equal(t, corev1.SecretTypeOpaque, sealed.Spec.Template.Type)
+	assert.Empty(t, sealed.Annotations)
+	assert.Equal(t, "s3cr3t", hybridDecrypt(t, key, sealed.Spec.EncryptedData["password"], "jx-staging/my-secret"))
+	assert.Equal(t, "abc123", hybridDecrypt(t, key, sealed.Spec.EncryptedData["token"], "jx-staging/my-secret"))
+
+	sealed, err = sealedsecrets.SealSecret(secret, pubKey, sealedsecrets.ScopeNamespaceWide)
+	require.NoError(t, err)
+	assert.Equal(t, "true", sealed.Annotations[sealedsecrets.AnnotationNamespaceWide])
+	assert.Equal(t, "s3cr3t", hybridDecrypt(t, key, sealed.Spec.EncryptedData["password"], "jx-staging"))
+
+	secret.Namespace = ""
+	_, err = sealedsecrets.SealSecret(secret, pubKey, sealedsecrets.ScopeStrict)
+	assert.Error(t, err, "a strict scoped secret requires a namespace")
+
+	sealed, err = sealedsecrets.SealSecret(secret, pubKey, sealedsecrets.ScopeClusterWide)
+	require.NoError(t, err)
+	assert.Equal(t, "true", sealed.Annotations[sealedsecrets.AnnotationClusterWide])
+	assert.Equal(t, "s3cr3t", hybridDecrypt(t, key, sealed.Spec.EncryptedData["password"], ""))
+}
+
+func TestFetchCertificate(t *testing.T) {
+	t.Parallel()
+
+	ns := sealedsecrets.DefaultControllerNamespace
+	name := sealedsecrets.DefaultControllerName
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.AddProxyReactor("services", func(action k8stesting.Action) (bool, restclient.ResponseWrapper, error) {
+		return true, &proxyResponse{err: errors.New("service unavailable")}, nil
+	})
+	_, err := sealedsecrets.FetchCertificate(kubeClient, ns, name)
+	assert.Error(t, err, "the certificate should not be fetched when the controller is not available")
+
+	_, certPEM := newTestKeyPair(t)
+	kubeClient = fake.NewSimpleClientset()
+	kubeClient.AddProxyReactor("services", func(action k8stesting.Action) (bool, restclient.ResponseWrapper, error) {
+		proxy := action.(k8stesting.ProxyGetAction)
+		assert.Equal(t, ns, proxy.GetNamespace())
+		assert.Equal(t, name, proxy.GetName())
+		assert.Equal(t, sealedsecrets.CertificatePath, proxy.GetPath())
+		return true, &proxyResponse{data: certPEM}, nil
+	})
+	cert, err := sealedsecrets.FetchCertificate(kubeClient, ns, name)
+	require.NoError(t, err)
+	assert.Equal(t, string(certPEM), string(cert))
+	for _, action := range kubeClient.Actions() {
+		assert.NotEqual(t, "secrets", action.GetResource().Resource, "the sealing key Secrets should never be read")
+	}
+}
+
+// proxyResponse a canned response of a request proxied by the API server
+type proxyResponse struct {
+	data []byte
+	err  error
+}
+
+func (r *proxyResponse) DoRaw() ([]byte, error) {
+	return r.data, r.err
+}
+
+func (r *proxyResponse) Stream() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(r.data)), r.err
+}
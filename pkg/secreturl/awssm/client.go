@@ -0,0 +1,121 @@
+package awssm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/pkg/errors"
+)
+
+// URIScheme the URI scheme used to reference secrets in AWS Secrets Manager such as `awssm:path/to/secret:key`
+const URIScheme = "awssm:"
+
+var awsURIRegex = regexp.MustCompile(`:[\s"]*awssm:[-_.\w\/:]*`)
+
+// Client a secret URL client which stores each secret as a JSON document in AWS Secrets Manager
+type Client struct {
+	API    secretsmanageriface.SecretsManagerAPI
+	Prefix string
+}
+
+// NewClient creates a new AWS Secrets Manager client for the given region. The optional prefix is added to
+// the name of all the secrets
+func NewClient(region string, prefix string) (secreturl.Client, error) {
+	config := &aws.Config{}
+	if region != "" {
+		config.Region = aws.String(region)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating AWS session")
+	}
+	return NewClientWithAPI(secretsmanager.New(sess), prefix), nil
+}
+
+// NewClientWithAPI creates a new client using the given AWS Secrets Manager API
+func NewClientWithAPI(api secretsmanageriface.SecretsManagerAPI, prefix string) secreturl.Client {
+	return &Client{
+		API:    api,
+		Prefix: prefix,
+	}
+}
+
+// Read reads a named secret from AWS Secrets Manager
+func (c *Client) Read(secretName string) (map[string]interface{}, error) {
+	id := c.secretID(secretName)
+	output, err := c.API.GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("secret %s does not exist in AWS Secrets Manager", id)
+		}
+		return nil, errors.Wrapf(err, "reading secret %s from AWS Secrets Manager", id)
+	}
+	return secreturl.DecodeSecret(id, aws.StringValue(output.SecretString))
+}
+
+// ReadObject reads a generic named object from AWS Secrets Manager.
+// The secret _must_ be serializable to JSON.
+func (c *Client) ReadObject(secretName string, secret interface{}) error {
+	return secreturl.ReadObject(c, secretName, secret)
+}
+
+// Write writes a named secret to AWS Secrets Manager creating the secret if it does not exist
+func (c *Client) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	id := c.secretID(secretName)
+	text, err := secreturl.EncodeSecret(data)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.API.PutSecretValue(&secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(id),
+		SecretString: aws.String(text),
+	})
+	if err != nil {
+		if !isNotFound(err) {
+			return nil, errors.Wrapf(err, "writing secret %s to AWS Secrets Manager", id)
+		}
+		_, err = c.API.CreateSecret(&secretsmanager.CreateSecretInput{
+			Name:         aws.String(id),
+			SecretString: aws.String(text),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating secret %s in AWS Secrets Manager", id)
+		}
+	}
+	return c.Read(secretName)
+}
+
+// WriteObject writes a generic named object to AWS Secrets Manager.
+// The secret _must_ be serializable to JSON.
+func (c *Client) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	return secreturl.WriteObject(c, secretName, secret)
+}
+
+// ReplaceURIs will replace any awssm: URIs in a string
+func (c *Client) ReplaceURIs(s string) (string, error) {
+	return secreturl.ReplaceURIs(s, c, awsURIRegex, URIScheme)
+}
+
+// secretID returns the name of the secret in AWS Secrets Manager which allows alphanumeric characters and /_+=.@-
+func (c *Client) secretID(secretName string) string {
+	return secreturl.ToSecretID(c.Prefix, secretName, '-', func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || strings.ContainsRune("/_+=.@-", r)
+	})
+}
+
+func isNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == secretsmanager.ErrCodeResourceNotFoundException
+}
@@ -0,0 +1,71 @@
+// +build unit
+
+package awssm_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/awssm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretsManager an in memory fake of the AWS Secrets Manager API
+type fakeSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets map[string]string
+}
+
+func (f *fakeSecretsManager) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := f.secrets[aws.StringValue(input.SecretId)]
+	if !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+	return &secretsmanager.GetSecretValueOutput{
+		Name:         input.SecretId,
+		SecretString: aws.String(value),
+	}, nil
+}
+
+func (f *fakeSecretsManager) PutSecretValue(input *secretsmanager.PutSecretValueInput) (*secretsmanager.PutSecretValueOutput, error) {
+	name := aws.StringValue(input.SecretId)
+	if _, ok := f.secrets[name]; !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+	f.secrets[name] = aws.StringValue(input.SecretString)
+	return &secretsmanager.PutSecretValueOutput{Name: input.SecretId}, nil
+}
+
+func (f *fakeSecretsManager) CreateSecret(input *secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error) {
+	f.secrets[aws.StringValue(input.Name)] = aws.StringValue(input.SecretString)
+	return &secretsmanager.CreateSecretOutput{Name: input.Name}, nil
+}
+
+func TestAWSSecretsManagerClient(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeSecretsManager{secrets: map[string]string{}}
+	client := awssm.NewClientWithAPI(fake, "mycluster")
+
+	_, err := client.Read("secret/admin")
+	require.Error(t, err)
+
+	_, err = client.Write("secret/admin", map[string]interface{}{"password": "s3cr3t"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"password":"s3cr3t"}`, fake.secrets["mycluster/secret/admin"])
+
+	_, err = client.Write("secret/admin", map[string]interface{}{"password": "n3w"})
+	require.NoError(t, err)
+
+	data, err := client.Read("secret/admin")
+	require.NoError(t, err)
+	assert.Equal(t, "n3w", data["password"])
+
+	result, err := client.ReplaceURIs(`password: awssm:secret/admin:password`)
+	require.NoError(t, err)
+	assert.Equal(t, `password: n3w`, result)
+}
@@ -0,0 +1,133 @@
+package azurekv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/2016-10-01/keyvault"
+	kvauth "github.com/Azure/azure-sdk-for-go/services/keyvault/auth"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/pkg/errors"
+)
+
+// URIScheme the URI scheme used to reference secrets in Azure Key Vault such as `azurekv:path/to/secret:key`
+const URIScheme = "azurekv:"
+
+var azureURIRegex = regexp.MustCompile(`:[\s"]*azurekv:[-_.\w\/:]*`)
+
+// KeyVaultAPI the subset of the Azure Key Vault API used to read and write secrets
+type KeyVaultAPI interface {
+	GetSecret(ctx context.Context, vaultBaseURL string, secretName string, secretVersion string) (keyvault.SecretBundle, error)
+	SetSecret(ctx context.Context, vaultBaseURL string, secretName string, parameters keyvault.SecretSetParameters) (keyvault.SecretBundle, error)
+}
+
+// Client a secret URL client which stores each secret as a JSON document in Azure Key Vault
+type Client struct {
+	API      KeyVaultAPI
+	VaultURL string
+	Prefix   string
+}
+
+// NewClient creates a new Azure Key Vault client for the given key vault name using the credentials from the
+// environment. The optional prefix is added to the name of all the secrets
+func NewClient(vaultName string, prefix string) (secreturl.Client, error) {
+	if vaultName == "" {
+		return nil, fmt.Errorf("no Azure Key Vault name specified")
+	}
+	authorizer, err := kvauth.NewAuthorizerFromEnvironment()
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the Azure Key Vault authorizer from the environment")
+	}
+	client := keyvault.New()
+	client.Authorizer = authorizer
+	return NewClientWithAPI(client, VaultURL(vaultName), prefix), nil
+}
+
+// NewClientWithAPI creates a new client using the given Azure Key Vault API and vault URL
+func NewClientWithAPI(api KeyVaultAPI, vaultURL string, prefix string) secreturl.Client {
+	return &Client{
+		API:      api,
+		VaultURL: vaultURL,
+		Prefix:   prefix,
+	}
+}
+
+// VaultURL returns the URL of the key vault with the given name. If the name is already a URL it is returned
+func VaultURL(vaultName string) string {
+	if strings.HasPrefix(vaultName, "https://") || strings.HasPrefix(vaultName, "http://") {
+		return vaultName
+	}
+	return fmt.Sprintf("https://%s.vault.azure.net", vaultName)
+}
+
+// Read reads the latest version of a named secret from Azure Key Vault
+func (c *Client) Read(secretName string) (map[string]interface{}, error) {
+	id := c.secretID(secretName)
+	bundle, err := c.API.GetSecret(context.Background(), c.VaultURL, id, "")
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("secret %s does not exist in Azure Key Vault %s", id, c.VaultURL)
+		}
+		return nil, errors.Wrapf(err, "reading secret %s from Azure Key Vault %s", id, c.VaultURL)
+	}
+	text := ""
+	if bundle.Value != nil {
+		text = *bundle.Value
+	}
+	return secreturl.DecodeSecret(id, text)
+}
+
+// ReadObject reads a generic named object from Azure Key Vault.
+// The secret _must_ be serializable to JSON.
+func (c *Client) ReadObject(secretName string, secret interface{}) error {
+	return secreturl.ReadObject(c, secretName, secret)
+}
+
+// Write writes a new version of a named secret to Azure Key Vault
+func (c *Client) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	id := c.secretID(secretName)
+	text, err := secreturl.EncodeSecret(data)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	_, err = c.API.SetSecret(context.Background(), c.VaultURL, id, keyvault.SecretSetParameters{
+		Value:       &text,
+		ContentType: &contentType,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "writing secret %s to Azure Key Vault %s", id, c.VaultURL)
+	}
+	return c.Read(secretName)
+}
+
+// WriteObject writes a generic named object to Azure Key Vault.
+// The secret _must_ be serializable to JSON.
+func (c *Client) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	return secreturl.WriteObject(c, secretName, secret)
+}
+
+// ReplaceURIs will replace any azurekv: URIs in a string
+func (c *Client) ReplaceURIs(s string) (string, error) {
+	return secreturl.ReplaceURIs(s, c, azureURIRegex, URIScheme)
+}
+
+// secretID returns the name of the secret in Azure Key Vault which only allows alphanumeric characters and -
+func (c *Client) secretID(secretName string) string {
+	return secreturl.ToSecretID(c.Prefix, secretName, '-', func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-'
+	})
+}
+
+func isNotFound(err error) bool {
+	if derr, ok := err.(autorest.DetailedError); ok {
+		if code, ok := derr.StatusCode.(int); ok {
+			return code == http.StatusNotFound
+		}
+	}
+	return false
+}
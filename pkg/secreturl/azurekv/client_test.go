@@ -0,0 +1,55 @@
+// +build unit
+
+package azurekv_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/2016-10-01/keyvault"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/azurekv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const vaultURL = "https://myvault.vault.azure.net"
+
+// fakeKeyVault an in memory fake of the Azure Key Vault API
+type fakeKeyVault struct {
+	secrets map[string]string
+}
+
+func (f *fakeKeyVault) GetSecret(ctx context.Context, vaultBaseURL string, secretName string, secretVersion string) (keyvault.SecretBundle, error) {
+	value, ok := f.secrets[vaultBaseURL+"/"+secretName]
+	if !ok {
+		return keyvault.SecretBundle{}, autorest.DetailedError{StatusCode: http.StatusNotFound}
+	}
+	return keyvault.SecretBundle{Value: &value}, nil
+}
+
+func (f *fakeKeyVault) SetSecret(ctx context.Context, vaultBaseURL string, secretName string, parameters keyvault.SecretSetParameters) (keyvault.SecretBundle, error) {
+	f.secrets[vaultBaseURL+"/"+secretName] = *parameters.Value
+	return keyvault.SecretBundle{Value: parameters.Value}, nil
+}
+
+func TestAzureKeyVaultClient(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, vaultURL, azurekv.VaultURL("myvault"))
+
+	fake := &fakeKeyVault{secrets: map[string]string{}}
+	client := azurekv.NewClientWithAPI(fake, vaultURL, "")
+
+	_, err := client.Read("secret/admin")
+	require.Error(t, err)
+
+	_, err = client.Write("secret/admin.user", map[string]interface{}{"password": "s3cr3t"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"password":"s3cr3t"}`, fake.secrets[vaultURL+"/secret-2fadmin-2euser"])
+
+	result, err := client.ReplaceURIs(`password: "azurekv:secret/admin.user:password"`)
+	require.NoError(t, err)
+	assert.Equal(t, `password: "s3cr3t"`, result)
+}
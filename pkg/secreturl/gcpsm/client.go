@@ -0,0 +1,191 @@
+package gcpsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// URIScheme the URI scheme used to reference secrets in GCP Secret Manager such as `gcpsm:path/to/secret:key`
+	URIScheme = "gcpsm:"
+
+	// DefaultEndpoint the default endpoint of the GCP Secret Manager REST API
+	DefaultEndpoint = "https://secretmanager.googleapis.com/v1"
+
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+var gcpURIRegex = regexp.MustCompile(`:[\s"]*gcpsm:[-_.\w\/:]*`)
+
+// Client a secret URL client which stores each secret as a JSON document in GCP Secret Manager
+type Client struct {
+	HTTPClient *http.Client
+	Endpoint   string
+	Project    string
+	Prefix     string
+}
+
+type secretPayload struct {
+	Data string `json:"data"`
+}
+
+type accessSecretVersionResponse struct {
+	Payload secretPayload `json:"payload"`
+}
+
+type addSecretVersionRequest struct {
+	Payload secretPayload `json:"payload"`
+}
+
+// NewClient creates a new GCP Secret Manager client for the given project using the application default credentials.
+// The optional prefix is added to the name of all the secrets
+func NewClient(project string, prefix string) (secreturl.Client, error) {
+	if project == "" {
+		return nil, fmt.Errorf("no GCP project specified for GCP Secret Manager")
+	}
+	httpClient, err := google.DefaultClient(context.Background(), cloudPlatformScope)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating GCP client from the application default credentials")
+	}
+	return NewClientWithHTTPClient(httpClient, DefaultEndpoint, project, prefix), nil
+}
+
+// NewClientWithHTTPClient creates a new client using the given HTTP client and REST API endpoint
+func NewClientWithHTTPClient(httpClient *http.Client, endpoint string, project string, prefix string) secreturl.Client {
+	return &Client{
+		HTTPClient: httpClient,
+		Endpoint:   endpoint,
+		Project:    project,
+		Prefix:     prefix,
+	}
+}
+
+// Read reads the latest version of a named secret from GCP Secret Manager
+func (c *Client) Read(secretName string) (map[string]interface{}, error) {
+	id := c.secretID(secretName)
+	resp := &accessSecretVersionResponse{}
+	status, err := c.call(http.MethodGet, c.secretURL(id)+"/versions/latest:access", nil, resp)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return nil, fmt.Errorf("secret %s does not exist in GCP Secret Manager project %s", id, c.Project)
+		}
+		return nil, errors.Wrapf(err, "reading secret %s from GCP Secret Manager", id)
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding the payload of secret %s", id)
+	}
+	return secreturl.DecodeSecret(id, string(data))
+}
+
+// ReadObject reads a generic named object from GCP Secret Manager.
+// The secret _must_ be serializable to JSON.
+func (c *Client) ReadObject(secretName string, secret interface{}) error {
+	return secreturl.ReadObject(c, secretName, secret)
+}
+
+// Write adds a new version of a named secret to GCP Secret Manager creating the secret if it does not exist
+func (c *Client) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	id := c.secretID(secretName)
+	text, err := secreturl.EncodeSecret(data)
+	if err != nil {
+		return nil, err
+	}
+	body := &addSecretVersionRequest{
+		Payload: secretPayload{
+			Data: base64.StdEncoding.EncodeToString([]byte(text)),
+		},
+	}
+	status, err := c.call(http.MethodPost, c.secretURL(id)+":addVersion", body, nil)
+	if err != nil {
+		if status != http.StatusNotFound {
+			return nil, errors.Wrapf(err, "writing secret %s to GCP Secret Manager", id)
+		}
+		create := map[string]interface{}{
+			"replication": map[string]interface{}{
+				"automatic": map[string]interface{}{},
+			},
+		}
+		createURL := fmt.Sprintf("%s/projects/%s/secrets?secretId=%s", c.Endpoint, url.PathEscape(c.Project), url.QueryEscape(id))
+		_, err = c.call(http.MethodPost, createURL, create, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating secret %s in GCP Secret Manager", id)
+		}
+		_, err = c.call(http.MethodPost, c.secretURL(id)+":addVersion", body, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "writing secret %s to GCP Secret Manager", id)
+		}
+	}
+	return c.Read(secretName)
+}
+
+// WriteObject writes a generic named object to GCP Secret Manager.
+// The secret _must_ be serializable to JSON.
+func (c *Client) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	return secreturl.WriteObject(c, secretName, secret)
+}
+
+// ReplaceURIs will replace any gcpsm: URIs in a string
+func (c *Client) ReplaceURIs(s string) (string, error) {
+	return secreturl.ReplaceURIs(s, c, gcpURIRegex, URIScheme)
+}
+
+func (c *Client) secretURL(id string) string {
+	return fmt.Sprintf("%s/projects/%s/secrets/%s", c.Endpoint, url.PathEscape(c.Project), url.PathEscape(id))
+}
+
+// secretID returns the name of the secret in GCP Secret Manager which allows alphanumeric characters, _ and -
+func (c *Client) secretID(secretName string) string {
+	return secreturl.ToSecretID(c.Prefix, secretName, '-', func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-'
+	})
+}
+
+// call invokes the REST API returning the HTTP status code
+func (c *Client) call(method string, u string, body interface{}, result interface{}) (int, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, errors.Wrap(err, "marshaling request body")
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return 0, errors.Wrapf(err, "creating request %s", u)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invoking %s %s", method, u)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, errors.Wrapf(err, "reading response of %s", u)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%s %s returned status %d: %s", method, u, resp.StatusCode, string(data))
+	}
+	if result != nil {
+		err = json.Unmarshal(data, result)
+		if err != nil {
+			return resp.StatusCode, errors.Wrapf(err, "unmarshaling response of %s", u)
+		}
+	}
+	return resp.StatusCode, nil
+}
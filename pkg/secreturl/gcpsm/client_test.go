@@ -0,0 +1,84 @@
+// +build unit
+
+package gcpsm_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/gcpsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretManager an in memory fake of the GCP Secret Manager REST API
+type fakeSecretManager struct {
+	lock    sync.Mutex
+	secrets map[string][]string
+}
+
+func (f *fakeSecretManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/projects/myproject/secrets")
+	switch {
+	case r.Method == http.MethodPost && path == "":
+		f.secrets[r.URL.Query().Get("secretId")] = []string{}
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodPost && strings.HasSuffix(path, ":addVersion"):
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "/"), ":addVersion")
+		versions, ok := f.secrets[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body := map[string]map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.secrets[name] = append(versions, body["payload"]["data"])
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/versions/latest:access"):
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/versions/latest:access")
+		versions := f.secrets[name]
+		if len(versions) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"payload": map[string]string{"data": versions[len(versions)-1]},
+		})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestGCPSecretManagerClient(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeSecretManager{secrets: map[string][]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := gcpsm.NewClientWithHTTPClient(server.Client(), server.URL, "myproject", "mycluster")
+
+	_, err := client.Read("secret/admin")
+	require.Error(t, err)
+
+	_, err = client.Write("secret/admin", map[string]interface{}{"password": "s3cr3t"})
+	require.NoError(t, err)
+	_, err = client.Write("secret/admin", map[string]interface{}{"password": "n3w"})
+	require.NoError(t, err)
+	assert.Len(t, fake.secrets["mycluster-2fsecret-2fadmin"], 2)
+
+	data, err := client.Read("secret/admin")
+	require.NoError(t, err)
+	assert.Equal(t, "n3w", data["password"])
+
+	result, err := client.ReplaceURIs(`password: gcpsm:secret/admin:password`)
+	require.NoError(t, err)
+	assert.Equal(t, `password: n3w`, result)
+}
@@ -1,6 +1,7 @@
 package secreturl
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -60,3 +61,74 @@ func trimBeforePrefix(s string, prefix string) (string, string) {
 func ToURI(path string, key string, scheme string) string {
 	return fmt.Sprintf("%s:%s:%s", scheme, path, key)
 }
+
+// ReadObject reads the named secret using the client and deserializes it into the given object.
+// The secret _must_ be serializable to JSON.
+func ReadObject(client Client, secretName string, secret interface{}) error {
+	m, err := client.Read(secretName)
+	if err != nil {
+		return errors.Wrapf(err, "reading the secret %q", secretName)
+	}
+	err = util.ToStructFromMapStringInterface(m, &secret)
+	if err != nil {
+		return errors.Wrapf(err, "deserializing the secret %q", secretName)
+	}
+	return nil
+}
+
+// WriteObject serializes the given object and writes it as the named secret using the client.
+// The secret _must_ be serializable to JSON.
+func WriteObject(client Client, secretName string, secret interface{}) (map[string]interface{}, error) {
+	m, err := util.ToMapStringInterfaceFromStruct(secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "serializing the secret %q", secretName)
+	}
+	return client.Write(secretName, m)
+}
+
+// EncodeSecret encodes the secret data as a JSON string for secret stores which store a single text value per secret
+func EncodeSecret(data map[string]interface{}) (string, error) {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return "", errors.Wrap(err, "marshaling secret to JSON")
+	}
+	return string(bytes), nil
+}
+
+// DecodeSecret decodes the JSON string value of a secret from secret stores which store a single text value per secret
+func DecodeSecret(secretName string, text string) (map[string]interface{}, error) {
+	answer := map[string]interface{}{}
+	if strings.TrimSpace(text) == "" {
+		return answer, nil
+	}
+	err := json.Unmarshal([]byte(text), &answer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshaling JSON of secret %q", secretName)
+	}
+	return answer, nil
+}
+
+// ToSecretID converts a secret path such as 'foo/bar.baz' into a secret identifier for secret stores which only support a
+// restricted set of characters in names. Any character which is not valid, and the escape character itself, is replaced
+// by the escape character followed by the hex code of each of its bytes so that different secret paths never map to the
+// same identifier
+func ToSecretID(prefix string, secretName string, escape rune, valid func(r rune) bool) string {
+	name := strings.Trim(secretName, "/")
+	if prefix != "" {
+		name = strings.Trim(prefix, "/") + "/" + name
+	}
+	var buffer strings.Builder
+	for _, r := range name {
+		if r != escape && valid(r) {
+			buffer.WriteRune(r)
+			continue
+		}
+		for _, b := range []byte(string(r)) {
+			fmt.Fprintf(&buffer, "%c%02x", escape, b)
+		}
+	}
+	return buffer.String()
+}
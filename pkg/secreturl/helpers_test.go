@@ -149,3 +149,24 @@ password: %s
 	assert.NoError(t, err, "should replace the URIs without error")
 	assert.EqualValues(t, fmt.Sprintf(testString, testValue), result, "should replace the URIs")
 }
+
+func TestToSecretID(t *testing.T) {
+	t.Parallel()
+
+	alphanumeric := func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-'
+	}
+	assert.Equal(t, "jx-2fcluster-2fadmin", secreturl.ToSecretID("jx", "/cluster/admin", '-', alphanumeric))
+	assert.Equal(t, "foo-2fbar-2ebaz", secreturl.ToSecretID("", "foo/bar.baz", '-', alphanumeric))
+	assert.Equal(t, "foo-2dbar", secreturl.ToSecretID("", "foo-bar", '-', alphanumeric), "the escape character should be escaped")
+	assert.Equal(t, "caf-c3-a9", secreturl.ToSecretID("", "café", '-', alphanumeric))
+
+	names := []string{"foo/bar", "foo-bar", "foo.bar", "foo_bar", "foo/bar-2f", "foo-2fbar"}
+	ids := map[string]string{}
+	for _, name := range names {
+		id := secreturl.ToSecretID("", name, '-', alphanumeric)
+		other, ok := ids[id]
+		assert.False(t, ok, "secrets %s and %s have the same identifier %s", name, other, id)
+		ids[id] = name
+	}
+}
@@ -0,0 +1,175 @@
+package kubevault
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// URIScheme the URI scheme used to reference secrets stored in Kubernetes Secrets such as `kube:path/to/secret:key`
+	URIScheme = "kube:"
+
+	// LabelSecretStore the label added to the Kubernetes Secrets created by the client so that they can be listed
+	LabelSecretStore = "jenkins.io/secret-store"
+
+	// AnnotationSecretPath the annotation containing the path of the secret
+	AnnotationSecretPath = "jenkins.io/secret-path"
+
+	// AnnotationJSONKeys the annotation listing the keys whose values are not strings and so are stored as JSON
+	AnnotationJSONKeys = "jenkins.io/json-keys"
+
+	secretStoreLabelValue = "jx"
+	secretNamePrefix      = "jx-secret"
+)
+
+var kubeURIRegex = regexp.MustCompile(`:[\s"]*kube:[-_.\w\/:]*`)
+
+// Client a secret URL client which stores each secret as a Kubernetes Secret in a namespace
+type Client struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+}
+
+// NewClient creates a new client storing the secrets in the given namespace
+func NewClient(kubeClient kubernetes.Interface, ns string) *Client {
+	return &Client{
+		KubeClient: kubeClient,
+		Namespace:  ns,
+	}
+}
+
+// Read reads the Kubernetes Secret for the given secret path
+func (c *Client) Read(secretName string) (map[string]interface{}, error) {
+	name := SecretResourceName(secretName)
+	secret, err := c.KubeClient.CoreV1().Secrets(c.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading Secret %s for %q in namespace %s", name, secretName, c.Namespace)
+	}
+	jsonKeys := map[string]bool{}
+	for _, k := range strings.Split(secret.Annotations[AnnotationJSONKeys], ",") {
+		jsonKeys[k] = true
+	}
+	answer := map[string]interface{}{}
+	for k, v := range secret.Data {
+		if jsonKeys[k] {
+			var value interface{}
+			err = json.Unmarshal(v, &value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unmarshaling key %s of secret %q", k, secretName)
+			}
+			answer[k] = value
+			continue
+		}
+		answer[k] = string(v)
+	}
+	return answer, nil
+}
+
+// ReadObject reads a generic named object from the Kubernetes Secret.
+// The secret _must_ be serializable to JSON.
+func (c *Client) ReadObject(secretName string, secret interface{}) error {
+	return secreturl.ReadObject(c, secretName, secret)
+}
+
+// Write creates or updates the Kubernetes Secret for the given secret path. Any values which are not strings are
+// stored as JSON
+func (c *Client) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	name := SecretResourceName(secretName)
+	secretData := map[string][]byte{}
+	jsonKeys := []string{}
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			secretData[k] = []byte(s)
+			continue
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshaling key %s of secret %q", k, secretName)
+		}
+		secretData[k] = value
+		jsonKeys = append(jsonKeys, k)
+	}
+	sort.Strings(jsonKeys)
+
+	secrets := c.KubeClient.CoreV1().Secrets(c.Namespace)
+	secret, err := secrets.Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "getting Secret %s in namespace %s", name, c.Namespace)
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: c.Namespace,
+			},
+		}
+	} else if path := secret.Annotations[AnnotationSecretPath]; path != "" && path != secretName {
+		return nil, fmt.Errorf("cannot store secret %q as the Secret %s already stores the secret %q", secretName, name, path)
+	}
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Labels[LabelSecretStore] = secretStoreLabelValue
+	secret.Annotations[AnnotationSecretPath] = secretName
+	secret.Annotations[AnnotationJSONKeys] = strings.Join(jsonKeys, ",")
+	secret.Data = secretData
+	if secret.ResourceVersion == "" {
+		_, err = secrets.Create(secret)
+	} else {
+		_, err = secrets.Update(secret)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "saving Secret %s for %q in namespace %s", name, secretName, c.Namespace)
+	}
+	return data, nil
+}
+
+// WriteObject writes a generic named object to the Kubernetes Secret.
+// The secret _must_ be serializable to JSON.
+func (c *Client) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	return secreturl.WriteObject(c, secretName, secret)
+}
+
+// ReplaceURIs will replace any kube: URIs in a string
+func (c *Client) ReplaceURIs(s string) (string, error) {
+	return secreturl.ReplaceURIs(s, c, kubeURIRegex, URIScheme)
+}
+
+// List returns the paths of all the secrets stored by the client
+func (c *Client) List() ([]string, error) {
+	list, err := c.KubeClient.CoreV1().Secrets(c.Namespace).List(metav1.ListOptions{
+		LabelSelector: LabelSecretStore + "=" + secretStoreLabelValue,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing Secrets in namespace %s", c.Namespace)
+	}
+	answer := []string{}
+	for _, s := range list.Items {
+		if path := s.Annotations[AnnotationSecretPath]; path != "" {
+			answer = append(answer, path)
+		}
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
+
+// SecretResourceName returns the name of the Kubernetes Secret used to store the given secret path
+func SecretResourceName(secretName string) string {
+	id := secreturl.ToSecretID("", secretName, '-', func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+	})
+	return secretNamePrefix + "-" + id
+}
@@ -108,6 +108,33 @@ func (c *FileSystemClient) ReplaceURIs(s string) (string, error) {
 	return secreturl.ReplaceURIs(s, c, localURIRegex, "local:")
 }
 
+// List returns the paths of all the secrets in the directory tree
+func (c *FileSystemClient) List() ([]string, error) {
+	answer := []string{}
+	exists, err := util.DirExists(c.Dir)
+	if err != nil || !exists {
+		return answer, err
+	}
+	err = filepath.Walk(c.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".yaml" {
+			return nil
+		}
+		rel, err := filepath.Rel(c.Dir, path)
+		if err != nil {
+			return err
+		}
+		answer = append(answer, filepath.ToSlash(strings.TrimSuffix(rel, ".yaml")))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing secrets in %s", c.Dir)
+	}
+	return answer, nil
+}
+
 func (c *FileSystemClient) fileName(secretName string) string {
 	return filepath.Join(c.Dir, secretName+".yaml")
 }
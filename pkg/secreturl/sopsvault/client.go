@@ -0,0 +1,84 @@
+package sopsvault
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/sops"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// URIScheme the URI scheme used to reference secrets encrypted with SOPS such as `sops:mysecret:password`
+const URIScheme = "sops:"
+
+var sopsURIRegex = regexp.MustCompile(`:[\s"]*sops:[-_.\w\/:]*`)
+
+// Client a secret URL client which stores each secret as a SOPS encrypted YAML file in a directory, typically
+// the secrets folder of an environment git repository. Any other URIs are replaced by the optional delegate client
+type Client struct {
+	Dir       string
+	Encrypter sops.Encrypter
+	Delegate  secreturl.Client
+}
+
+// NewClient creates a new SOPS client for the encrypted files in the given directory
+func NewClient(dir string, encrypter sops.Encrypter, delegate secreturl.Client) secreturl.Client {
+	return &Client{
+		Dir:       dir,
+		Encrypter: encrypter,
+		Delegate:  delegate,
+	}
+}
+
+// Read decrypts the named secret
+func (c *Client) Read(secretName string) (map[string]interface{}, error) {
+	fileName := c.fileName(secretName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return nil, fmt.Errorf("SOPS secret file does not exist: %s", fileName)
+	}
+	return sops.ReadEncryptedYAML(c.Encrypter, fileName)
+}
+
+// ReadObject reads a generic named object from the encrypted file.
+// The secret _must_ be serializable to JSON.
+func (c *Client) ReadObject(secretName string, secret interface{}) error {
+	return secreturl.ReadObject(c, secretName, secret)
+}
+
+// Write encrypts the data into the named secret file
+func (c *Client) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	err := sops.WriteEncryptedYAML(c.Encrypter, c.fileName(secretName), data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "writing SOPS secret %s", secretName)
+	}
+	return data, nil
+}
+
+// WriteObject writes a generic named object to the encrypted file.
+// The secret _must_ be serializable to JSON.
+func (c *Client) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	return secreturl.WriteObject(c, secretName, secret)
+}
+
+// ReplaceURIs will replace any sops: URIs in a string and then any URIs supported by the delegate client
+func (c *Client) ReplaceURIs(s string) (string, error) {
+	answer, err := secreturl.ReplaceURIs(s, c, sopsURIRegex, URIScheme)
+	if err != nil {
+		return answer, err
+	}
+	if c.Delegate != nil {
+		return c.Delegate.ReplaceURIs(answer)
+	}
+	return answer, nil
+}
+
+func (c *Client) fileName(secretName string) string {
+	return filepath.Join(c.Dir, secretName+".yaml")
+}
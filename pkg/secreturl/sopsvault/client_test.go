@@ -0,0 +1,46 @@
+// +build unit
+
+package sopsvault_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/fakevault"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/sopsvault"
+	"github.com/jenkins-x/jx/v2/pkg/sops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSopsClientReplaceURIs(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-sopsvault-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	delegate := fakevault.NewFakeClient()
+	_, err = delegate.Write("cluster/admin", map[string]interface{}{"password": "vault-password"})
+	require.NoError(t, err)
+
+	client := sopsvault.NewClient(dir, sops.NewFakeEncrypter(), delegate)
+	_, err = client.Write("mysecret", map[string]interface{}{"password": "sops-password"})
+	require.NoError(t, err)
+
+	data, err := client.Read("mysecret")
+	require.NoError(t, err)
+	assert.Equal(t, "sops-password", data["password"])
+
+	_, err = client.Read("missing")
+	assert.Error(t, err)
+
+	values := "db:\n  password: sops:mysecret:password\nadmin:\n  password: vault:cluster/admin:password\n"
+	actual, err := client.ReplaceURIs(values)
+	require.NoError(t, err)
+	assert.Equal(t, "db:\n  password: sops-password\nadmin:\n  password: vault-password\n", actual)
+
+	_, err = client.ReplaceURIs("password: sops:mysecret:missing\n")
+	assert.Error(t, err)
+}
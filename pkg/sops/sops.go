@@ -0,0 +1,226 @@
+package sops
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBinary the name of the sops binary
+	DefaultBinary = "sops"
+
+	// ConfigFileName the name of the sops configuration file which defines the creation rules of a repository
+	ConfigFileName = ".sops.yaml"
+
+	// SecretsDirName the name of the directory inside an environment chart which contains the encrypted secrets
+	SecretsDirName = "secrets"
+
+	metadataKey = "sops"
+	macKey      = "mac"
+)
+
+// Encrypter encrypts and decrypts files using SOPS
+type Encrypter interface {
+	// EncryptFile encrypts the given plain text file in place
+	EncryptFile(fileName string) error
+
+	// DecryptFile returns the decrypted content of the given encrypted file
+	DecryptFile(fileName string) ([]byte, error)
+}
+
+// Keys the keys used to encrypt new files. If no keys are specified the creation rules of the
+// .sops.yaml file in the directory of the file or one of its parents are used
+type Keys struct {
+	Age     []string
+	PGP     []string
+	KMS     []string
+	GCPKMS  []string
+	AzureKV []string
+}
+
+// IsEmpty returns true if no keys are specified
+func (k *Keys) IsEmpty() bool {
+	return len(k.Age) == 0 && len(k.PGP) == 0 && len(k.KMS) == 0 && len(k.GCPKMS) == 0 && len(k.AzureKV) == 0
+}
+
+// Args returns the command line arguments of the sops binary for the keys
+func (k *Keys) Args() []string {
+	args := []string{}
+	add := func(flag string, values []string) {
+		if len(values) > 0 {
+			args = append(args, flag, strings.Join(values, ","))
+		}
+	}
+	add("--age", k.Age)
+	add("--pgp", k.PGP)
+	add("--kms", k.KMS)
+	add("--gcp-kms", k.GCPKMS)
+	add("--azure-kv", k.AzureKV)
+	return args
+}
+
+// CLI an Encrypter which uses the sops binary
+type CLI struct {
+	Binary string
+	Keys   Keys
+	Env    map[string]string
+}
+
+// NewCLI creates a new Encrypter using the sops binary with the given keys
+func NewCLI(keys Keys) Encrypter {
+	return &CLI{
+		Binary: DefaultBinary,
+		Keys:   keys,
+	}
+}
+
+// EncryptFile encrypts the given plain text file in place
+func (c *CLI) EncryptFile(fileName string) error {
+	args := append([]string{"--encrypt", "--in-place"}, c.Keys.Args()...)
+	args = append(args, filepath.Base(fileName))
+	_, err := c.run(filepath.Dir(fileName), args...)
+	if err != nil {
+		return errors.Wrapf(err, "encrypting %s", fileName)
+	}
+	return nil
+}
+
+// DecryptFile returns the decrypted content of the given encrypted file
+func (c *CLI) DecryptFile(fileName string) ([]byte, error) {
+	data, err := c.run(filepath.Dir(fileName), "--decrypt", filepath.Base(fileName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "decrypting %s", fileName)
+	}
+	return data, nil
+}
+
+func (c *CLI) run(dir string, args ...string) ([]byte, error) {
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	binary := c.Binary
+	if binary == "" {
+		binary = DefaultBinary
+	}
+	cmd := util.Command{
+		Dir:  dir,
+		Name: binary,
+		Args: args,
+		Env:  c.Env,
+		Out:  out,
+		Err:  errOut,
+	}
+	_, err := cmd.RunWithoutRetry()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err.Error(), strings.TrimSpace(errOut.String()))
+	}
+	return out.Bytes(), nil
+}
+
+// IsEncrypted returns true if the given YAML or JSON content has been encrypted by SOPS
+func IsEncrypted(data []byte) bool {
+	m := map[string]interface{}{}
+	err := yaml.Unmarshal(data, &m)
+	if err != nil {
+		return false
+	}
+	metadata, ok := m[metadataKey].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = metadata[macKey]
+	return ok
+}
+
+// IsEncryptedFile returns true if the given file exists and has been encrypted by SOPS
+func IsEncryptedFile(fileName string) (bool, error) {
+	exists, err := util.FileExists(fileName)
+	if err != nil || !exists {
+		return false, err
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return false, errors.Wrapf(err, "reading %s", fileName)
+	}
+	return IsEncrypted(data), nil
+}
+
+// DecryptFilesInPlace decrypts any of the given files which are encrypted by SOPS replacing their content with the
+// plain text. It should only be used on temporary copies of the files. It returns the names of the decrypted files
+func DecryptFilesInPlace(encrypter Encrypter, fileNames []string) ([]string, error) {
+	answer := []string{}
+	for _, fileName := range fileNames {
+		encrypted, err := IsEncryptedFile(fileName)
+		if err != nil {
+			return answer, err
+		}
+		if !encrypted {
+			continue
+		}
+		data, err := encrypter.DecryptFile(fileName)
+		if err != nil {
+			return answer, err
+		}
+		err = ioutil.WriteFile(fileName, data, 0600)
+		if err != nil {
+			return answer, errors.Wrapf(err, "writing decrypted file %s", fileName)
+		}
+		answer = append(answer, fileName)
+	}
+	return answer, nil
+}
+
+// RemoveFiles removes the given decrypted files so that no plain text secrets are left on disk
+func RemoveFiles(fileNames []string) error {
+	for _, fileName := range fileNames {
+		err := os.Remove(fileName)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "removing decrypted file %s", fileName)
+		}
+	}
+	return nil
+}
+
+// WriteEncryptedYAML marshals the given data as YAML into the given file and encrypts it
+func WriteEncryptedYAML(encrypter Encrypter, fileName string, data map[string]interface{}) error {
+	text, err := yaml.Marshal(data)
+	if err != nil {
+		return errors.Wrapf(err, "marshaling %s", fileName)
+	}
+	err = os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "creating the directory for %s", fileName)
+	}
+	err = ioutil.WriteFile(fileName, text, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "writing %s", fileName)
+	}
+	err = encrypter.EncryptFile(fileName)
+	if err != nil {
+		// lets not leave the plain text behind
+		_ = os.Remove(fileName)
+		return err
+	}
+	return nil
+}
+
+// ReadEncryptedYAML decrypts the given file and unmarshals its YAML content
+func ReadEncryptedYAML(encrypter Encrypter, fileName string) (map[string]interface{}, error) {
+	text, err := encrypter.DecryptFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{}
+	err = yaml.Unmarshal(text, &data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshaling decrypted %s", fileName)
+	}
+	return data, nil
+}
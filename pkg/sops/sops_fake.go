@@ -0,0 +1,60 @@
+package sops
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// FakeEncrypter a local stand-in for SOPS which base64 encodes the content of a file and adds the SOPS metadata
+// so that it can be used in tests without the sops binary or any KMS
+type FakeEncrypter struct {
+}
+
+type fakeEncryptedFile struct {
+	Data string                 `json:"data"`
+	Sops map[string]interface{} `json:"sops"`
+}
+
+// NewFakeEncrypter creates a new fake Encrypter
+func NewFakeEncrypter() Encrypter {
+	return &FakeEncrypter{}
+}
+
+// EncryptFile encodes the given plain text file in place
+func (f *FakeEncrypter) EncryptFile(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", fileName)
+	}
+	encrypted := &fakeEncryptedFile{
+		Data: base64.StdEncoding.EncodeToString(data),
+		Sops: map[string]interface{}{
+			macKey: "fake",
+		},
+	}
+	data, err = yaml.Marshal(encrypted)
+	if err != nil {
+		return errors.Wrapf(err, "marshaling %s", fileName)
+	}
+	return ioutil.WriteFile(fileName, data, 0600)
+}
+
+// DecryptFile returns the decoded content of the given file
+func (f *FakeEncrypter) DecryptFile(fileName string) ([]byte, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", fileName)
+	}
+	encrypted := &fakeEncryptedFile{}
+	err = yaml.Unmarshal(data, encrypted)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshaling %s", fileName)
+	}
+	if encrypted.Sops == nil {
+		return nil, errors.Errorf("file %s is not encrypted", fileName)
+	}
+	return base64.StdEncoding.DecodeString(encrypted.Data)
+}
@@ -0,0 +1,83 @@
+// +build unit
+
+package sops_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/sops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEncrypted(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, sops.IsEncrypted([]byte("password: ENC[AES256_GCM,data:abc]\nsops:\n  mac: ENC[AES256_GCM,data:xyz]\n  version: 3.5.0\n")))
+	assert.True(t, sops.IsEncrypted([]byte(`{"password": "ENC[AES256_GCM,data:abc]", "sops": {"mac": "xyz"}}`)))
+	assert.False(t, sops.IsEncrypted([]byte("password: s3cr3t\n")))
+	assert.False(t, sops.IsEncrypted([]byte("sops: enabled\n")))
+	assert.False(t, sops.IsEncrypted([]byte("not: [valid")))
+}
+
+func TestKeysArgs(t *testing.T) {
+	t.Parallel()
+
+	keys := sops.Keys{}
+	assert.True(t, keys.IsEmpty())
+	assert.Empty(t, keys.Args())
+
+	keys = sops.Keys{
+		Age: []string{"age1abc", "age1def"},
+		KMS: []string{"arn:aws:kms:us-east-1:123:key/abc"},
+	}
+	assert.False(t, keys.IsEmpty())
+	assert.Equal(t, []string{"--age", "age1abc,age1def", "--kms", "arn:aws:kms:us-east-1:123:key/abc"}, keys.Args())
+}
+
+func TestEncryptedYAMLRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-sops-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	encrypter := sops.NewFakeEncrypter()
+	secretFile := filepath.Join(dir, "secrets", "mysecret.yaml")
+	err = sops.WriteEncryptedYAML(encrypter, secretFile, map[string]interface{}{
+		"password": "s3cr3t",
+	})
+	require.NoError(t, err)
+
+	encrypted, err := sops.IsEncryptedFile(secretFile)
+	require.NoError(t, err)
+	assert.True(t, encrypted, "the secret file should be encrypted")
+
+	data, err := sops.ReadEncryptedYAML(encrypter, secretFile)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", data["password"])
+
+	plainFile := filepath.Join(dir, "values.yaml")
+	err = ioutil.WriteFile(plainFile, []byte("replicas: 2\n"), 0600)
+	require.NoError(t, err)
+
+	decrypted, err := sops.DecryptFilesInPlace(encrypter, []string{plainFile, secretFile, filepath.Join(dir, "missing.yaml")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{secretFile}, decrypted)
+
+	text, err := ioutil.ReadFile(secretFile)
+	require.NoError(t, err)
+	assert.Equal(t, "password: s3cr3t\n", string(text))
+	text, err = ioutil.ReadFile(plainFile)
+	require.NoError(t, err)
+	assert.Equal(t, "replicas: 2\n", string(text))
+
+	err = sops.RemoveFiles(append(decrypted, filepath.Join(dir, "missing.yaml")))
+	require.NoError(t, err)
+	_, err = os.Stat(secretFile)
+	assert.True(t, os.IsNotExist(err), "the decrypted file should be removed")
+	assert.FileExists(t, plainFile)
+}
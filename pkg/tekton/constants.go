@@ -25,4 +25,16 @@ const (
 
 	// LabelType is the label added to Tekton CRDs for the type of pipeline.
 	LabelType = "jenkins.io/pipelineType"
+
+	// LabelDynamicSecretPipelineRun is the label added to the Secret containing the dynamic secrets of a PipelineRun.
+	LabelDynamicSecretPipelineRun = "jenkins.io/dynamic-secrets-pipelinerun"
+
+	// AnnotationDynamicSecretLeases is the annotation on the Secret containing the dynamic secrets which lists the Vault lease IDs.
+	AnnotationDynamicSecretLeases = "jenkins.io/vault-leases"
+
+	// DynamicSecretFinalizer is the finalizer on the Secret containing the dynamic secrets which is removed once its Vault leases are revoked.
+	DynamicSecretFinalizer = "jenkins.io/revoke-vault-leases"
+
+	// AnnotationDynamicSecretRefs is the annotation on the PipelineRun which maps the keys of its dynamic secrets Secret to the `vault-dynamic:` references to lease.
+	AnnotationDynamicSecretRefs = "jenkins.io/vault-dynamic-secrets"
 )
@@ -0,0 +1,366 @@
+package tekton
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/vault"
+	"github.com/pkg/errors"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DynamicSecretsVolumeName the name of the volume containing the dynamic secrets in file mode
+	DynamicSecretsVolumeName = "vault-dynamic"
+
+	// DynamicSecretsMountPath the directory where the dynamic secrets in file mode are mounted
+	DynamicSecretsMountPath = "/var/run/secrets/vault-dynamic"
+
+	dynamicSecretNameSuffix = "-vault-dynamic"
+)
+
+var invalidSecretKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]+`)
+
+// HasDynamicSecretRefs returns true if any of the steps of the tasks reference a dynamic secret
+func HasDynamicSecretRefs(crds *CRDWrapper) bool {
+	found := false
+	visitDynamicSecretEnvVars(crds, func(env *corev1.EnvVar, container *corev1.Container, volumes *[]corev1.Volume) error {
+		found = true
+		return nil
+	})
+	return found
+}
+
+// DynamicSecretName returns the name of the Secret containing the dynamic secrets of the given PipelineRun
+func DynamicSecretName(pipelineRunName string) string {
+	return pipelineRunName + dynamicSecretNameSuffix
+}
+
+// PrepareDynamicSecrets replaces the references to dynamic secrets via `vault-dynamic:` URIs in the environment
+// variables of the steps with references to the Secret of the PipelineRun and records the original references in an
+// annotation of the PipelineRun. The secrets are leased by the build controller once the pods of the PipelineRun are
+// scheduled, which the pods wait for as they cannot start without the Secret
+func PrepareDynamicSecrets(crds *CRDWrapper) error {
+	if !HasDynamicSecretRefs(crds) {
+		return nil
+	}
+	pr := crds.PipelineRun()
+	if pr == nil || pr.Name == "" {
+		return errors.New("cannot use dynamic secrets without a PipelineRun name")
+	}
+	secretName := DynamicSecretName(pr.Name)
+	refs := map[string]string{}
+	err := visitDynamicSecretEnvVars(crds, func(env *corev1.EnvVar, container *corev1.Container, volumes *[]corev1.Volume) error {
+		ref, err := vault.ParseDynamicSecretRef(env.Value)
+		if err != nil {
+			return errors.Wrapf(err, "parsing the value of environment variable %s", env.Name)
+		}
+		dataKey := strings.Trim(invalidSecretKeyChars.ReplaceAllString(ref.LeaseKey()+"-"+ref.Key, "-"), "-.")
+		refs[dataKey] = strings.TrimSpace(env.Value)
+
+		if ref.Mode == vault.DynamicSecretModeFile {
+			addDynamicSecretsVolume(volumes, container, secretName)
+			env.Value = filepath.Join(DynamicSecretsMountPath, dataKey)
+			return nil
+		}
+		env.Value = ""
+		env.ValueFrom = &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  dataKey,
+			},
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(refs)
+	if err != nil {
+		return errors.Wrapf(err, "marshalling the dynamic secret references of PipelineRun %s", pr.Name)
+	}
+	if pr.Annotations == nil {
+		pr.Annotations = map[string]string{}
+	}
+	pr.Annotations[AnnotationDynamicSecretRefs] = string(data)
+	return nil
+}
+
+// LeaseDynamicSecrets leases the dynamic secrets referenced by the PipelineRun unless they have already been leased
+// and stores them in a Secret owned by the PipelineRun. Each secret path is leased once so that related values such as
+// a username and password match. The Secret has a finalizer so that it is not garbage collected before its leases are
+// revoked by RevokeDynamicSecrets
+func LeaseDynamicSecrets(vaultClient vault.Client, kubeClient kubernetes.Interface, ns string, pr *v1alpha1.PipelineRun) error {
+	text := pr.Annotations[AnnotationDynamicSecretRefs]
+	if text == "" {
+		return nil
+	}
+	secretName := DynamicSecretName(pr.Name)
+	secrets := kubeClient.CoreV1().Secrets(ns)
+	_, err := secrets.Get(secretName, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "getting Secret %s", secretName)
+	}
+	refs := map[string]string{}
+	err = json.Unmarshal([]byte(text), &refs)
+	if err != nil {
+		return errors.Wrapf(err, "parsing the dynamic secret references of PipelineRun %s", pr.Name)
+	}
+
+	leases := map[string]*vault.DynamicSecret{}
+	data, err := leaseDynamicSecretData(vaultClient, pr.Name, refs, leases)
+	leaseIDs := []string{}
+	for _, secret := range leases {
+		if secret.LeaseID != "" {
+			leaseIDs = append(leaseIDs, secret.LeaseID)
+		}
+	}
+	sort.Strings(leaseIDs)
+	if err != nil {
+		warnRevokeLeases(vaultClient, leaseIDs)
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: secretName,
+			Labels: map[string]string{
+				LabelDynamicSecretPipelineRun: pr.Name,
+			},
+			Annotations: map[string]string{
+				AnnotationDynamicSecretLeases: strings.Join(leaseIDs, ","),
+			},
+			Finalizers: []string{DynamicSecretFinalizer},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: syntax.TektonAPIVersion,
+					Kind:       "PipelineRun",
+					Name:       pr.Name,
+					UID:        pr.UID,
+				},
+			},
+		},
+		Data: data,
+	}
+	_, err = secrets.Create(secret)
+	if err != nil {
+		warnRevokeLeases(vaultClient, leaseIDs)
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "creating Secret %s for the dynamic secrets of PipelineRun %s", secretName, pr.Name)
+	}
+	return nil
+}
+
+// leaseDynamicSecretData leases the dynamic secrets of the references indexed by their key in the Secret, adding the
+// leases to the given map so that they can be revoked on failure
+func leaseDynamicSecretData(vaultClient vault.Client, pipelineRunName string, refs map[string]string, leases map[string]*vault.DynamicSecret) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	for _, dataKey := range util.SortedMapKeys(refs) {
+		ref, err := vault.ParseDynamicSecretRef(refs[dataKey])
+		if err != nil {
+			return nil, err
+		}
+		leaseKey := ref.LeaseKey()
+		secret := leases[leaseKey]
+		if secret == nil {
+			secret, err = vaultClient.LeaseDynamic(ref.Path, ref.Params)
+			if err != nil {
+				return nil, err
+			}
+			leases[leaseKey] = secret
+			log.Logger().Infof("leased dynamic secret %s for %d seconds for PipelineRun %s", util.ColorInfo(ref.Path), secret.LeaseDuration, util.ColorInfo(pipelineRunName))
+		}
+		value, ok := secret.Data[ref.Key]
+		if !ok {
+			return nil, fmt.Errorf("dynamic secret %s has no key %s", ref.Path, ref.Key)
+		}
+		text, err := dynamicSecretValueToString(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting key %s of dynamic secret %s", ref.Key, ref.Path)
+		}
+		data[dataKey] = []byte(text)
+	}
+	return data, nil
+}
+
+// RevokeDynamicSecrets revokes the leases of the dynamic secrets of the given PipelineRun. All the leases are revoked
+// even if some of them fail, returning the combined errors and keeping the failed leases to be revoked later. Once all
+// the leases are revoked the finalizer of the Secret is removed so that it is garbage collected with the PipelineRun.
+// The Secret is kept until then so that the revoked values are still masked in the build logs
+func RevokeDynamicSecrets(vaultClient vault.Client, kubeClient kubernetes.Interface, ns string, pipelineRunName string) error {
+	secretName := DynamicSecretName(pipelineRunName)
+	secrets := kubeClient.CoreV1().Secrets(ns)
+	secret, err := secrets.Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "getting Secret %s", secretName)
+	}
+	leaseIDs := DynamicSecretLeases(secret)
+	if len(leaseIDs) == 0 && !hasDynamicSecretFinalizer(secret) {
+		return nil
+	}
+	failed := []string{}
+	errs := []error{}
+	for _, id := range leaseIDs {
+		err := vaultClient.RevokeLease(id)
+		if err != nil {
+			failed = append(failed, id)
+			errs = append(errs, err)
+		}
+	}
+	if len(failed) > 0 {
+		secret.Annotations[AnnotationDynamicSecretLeases] = strings.Join(failed, ",")
+	} else {
+		delete(secret.Annotations, AnnotationDynamicSecretLeases)
+		finalizers := []string{}
+		for _, f := range secret.Finalizers {
+			if f != DynamicSecretFinalizer {
+				finalizers = append(finalizers, f)
+			}
+		}
+		secret.Finalizers = finalizers
+	}
+	_, err = secrets.Update(secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		errs = append(errs, errors.Wrapf(err, "updating Secret %s", secretName))
+	}
+	revokeErr := util.CombineErrors(errs...)
+	if revokeErr != nil {
+		return revokeErr
+	}
+	log.Logger().Infof("revoked %d dynamic secret leases of PipelineRun %s", len(leaseIDs), util.ColorInfo(pipelineRunName))
+	return nil
+}
+
+// DynamicSecretLeases returns the IDs of the Vault leases of the Secret containing the dynamic secrets of a PipelineRun
+// which have not been revoked yet
+func DynamicSecretLeases(secret *corev1.Secret) []string {
+	leaseIDs := []string{}
+	for _, id := range strings.Split(secret.Annotations[AnnotationDynamicSecretLeases], ",") {
+		if id != "" {
+			leaseIDs = append(leaseIDs, id)
+		}
+	}
+	return leaseIDs
+}
+
+func hasDynamicSecretFinalizer(secret *corev1.Secret) bool {
+	for _, f := range secret.Finalizers {
+		if f == DynamicSecretFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// visitDynamicSecretEnvVars invokes the callback for each environment variable of the steps and step templates
+// which references a dynamic secret
+func visitDynamicSecretEnvVars(crds *CRDWrapper, fn func(env *corev1.EnvVar, container *corev1.Container, volumes *[]corev1.Volume) error) error {
+	visit := func(container *corev1.Container, volumes *[]corev1.Volume) error {
+		for i := range container.Env {
+			env := &container.Env[i]
+			if env.ValueFrom == nil && vault.IsDynamicSecretRef(env.Value) {
+				err := fn(env, container, volumes)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, task := range crds.Tasks() {
+		if task.Spec.StepTemplate != nil {
+			err := visit(task.Spec.StepTemplate, &task.Spec.Volumes)
+			if err != nil {
+				return err
+			}
+		}
+		for i := range task.Spec.Steps {
+			err := visit(&task.Spec.Steps[i].Container, &task.Spec.Volumes)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func addDynamicSecretsVolume(volumes *[]corev1.Volume, container *corev1.Container, secretName string) {
+	found := false
+	for _, v := range *volumes {
+		if v.Name == DynamicSecretsVolumeName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		*volumes = append(*volumes, corev1.Volume{
+			Name: DynamicSecretsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+				},
+			},
+		})
+	}
+	for _, m := range container.VolumeMounts {
+		if m.Name == DynamicSecretsVolumeName {
+			return
+		}
+	}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      DynamicSecretsVolumeName,
+		MountPath: DynamicSecretsMountPath,
+		ReadOnly:  true,
+	})
+}
+
+func dynamicSecretValueToString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+// revokeLeases revokes all the given leases returning the combined errors of any which failed
+func revokeLeases(vaultClient vault.Client, leaseIDs []string) error {
+	errs := []error{}
+	for _, id := range leaseIDs {
+		errs = append(errs, vaultClient.RevokeLease(id))
+	}
+	return util.CombineErrors(errs...)
+}
+
+// warnRevokeLeases revokes the leases of dynamic secrets which could not be used, only logging any failures
+func warnRevokeLeases(vaultClient vault.Client, leaseIDs []string) {
+	err := revokeLeases(vaultClient, leaseIDs)
+	if err != nil {
+		log.Logger().Warnf("failed to revoke the dynamic secret leases: %s", err)
+	}
+}
@@ -0,0 +1,214 @@
+// +build unit
+
+package tekton_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/jenkins-x/jx/v2/pkg/vault"
+	vaultfake "github.com/jenkins-x/jx/v2/pkg/vault/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseDynamicSecretRef(t *testing.T) {
+	ref, err := vault.ParseDynamicSecretRef("vault-dynamic:pki/issue/example?common_name=build.example.com&ttl=1h:certificate:file")
+	require.NoError(t, err)
+	assert.Equal(t, "pki/issue/example", ref.Path)
+	assert.Equal(t, "certificate", ref.Key)
+	assert.Equal(t, vault.DynamicSecretModeFile, ref.Mode)
+	assert.Equal(t, map[string]interface{}{"common_name": "build.example.com", "ttl": "1h"}, ref.Params)
+	assert.Equal(t, "pki/issue/example?common_name=build.example.com&ttl=1h", ref.LeaseKey())
+
+	ref, err = vault.ParseDynamicSecretRef("vault-dynamic:database/creds/readonly:username")
+	require.NoError(t, err)
+	assert.Equal(t, vault.DynamicSecretModeEnv, ref.Mode)
+	assert.Equal(t, "database/creds/readonly", ref.LeaseKey())
+
+	for _, text := range []string{"vault-dynamic:database/creds/readonly", "vault-dynamic::username", "vault-dynamic:a:b:bogus"} {
+		_, err = vault.ParseDynamicSecretRef(text)
+		assert.Error(t, err, "should fail to parse %s", text)
+	}
+}
+
+func TestPrepareLeaseAndRevokeDynamicSecrets(t *testing.T) {
+	vaultClient := vaultfake.NewFakeVaultClient()
+	vaultClient.DynamicData["database/creds/readonly"] = map[string]interface{}{
+		"username": "v-build-abc",
+		"password": "s3cr3t",
+	}
+	vaultClient.DynamicData["pki/issue/example"] = map[string]interface{}{
+		"certificate": "-----BEGIN CERTIFICATE-----",
+	}
+	kubeClient := fake.NewSimpleClientset()
+
+	task := &v1alpha1.Task{ObjectMeta: metav1.ObjectMeta{Name: "mytask"}}
+	task.Spec.Steps = []v1alpha1.Step{
+		{
+			Container: corev1.Container{
+				Name:  "build",
+				Image: "maven",
+				Env: []corev1.EnvVar{
+					{Name: "DB_USER", Value: "vault-dynamic:database/creds/readonly:username"},
+					{Name: "DB_PASSWORD", Value: "vault-dynamic:database/creds/readonly:password"},
+					{Name: "TLS_CERT", Value: "vault-dynamic:pki/issue/example?common_name=build.example.com&ttl=30m:certificate:file"},
+					{Name: "PLAIN", Value: "hello"},
+				},
+			},
+		},
+	}
+	crds := createDynamicSecretsCRDs(t, task)
+	require.True(t, tekton.HasDynamicSecretRefs(crds))
+
+	err := tekton.PrepareDynamicSecrets(crds)
+	require.NoError(t, err)
+	assert.False(t, tekton.HasDynamicSecretRefs(crds))
+	assert.Empty(t, vaultClient.Leases, "nothing should be leased before the pipeline pods are scheduled")
+	pr := crds.PipelineRun()
+	assert.NotEmpty(t, pr.Annotations[tekton.AnnotationDynamicSecretRefs])
+
+	secretName := tekton.DynamicSecretName("myrun")
+	env := task.Spec.Steps[0].Env
+	require.NotNil(t, env[0].ValueFrom)
+	assert.Equal(t, secretName, env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Contains(t, env[2].Value, tekton.DynamicSecretsMountPath+"/")
+	assert.Nil(t, env[2].ValueFrom)
+	assert.Equal(t, "hello", env[3].Value)
+	require.Len(t, task.Spec.Volumes, 1)
+	assert.Equal(t, secretName, task.Spec.Volumes[0].Secret.SecretName)
+	require.Len(t, task.Spec.Steps[0].VolumeMounts, 1)
+	assert.Equal(t, tekton.DynamicSecretsMountPath, task.Spec.Steps[0].VolumeMounts[0].MountPath)
+
+	pr.UID = "1234"
+	err = tekton.LeaseDynamicSecrets(vaultClient, kubeClient, ns, pr)
+	require.NoError(t, err)
+	assert.Len(t, vaultClient.Leases, 2, "the database credentials should share a lease")
+	assert.Equal(t, "30m", vaultClient.LeaseParams["pki/issue/example"]["ttl"])
+
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(secretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "myrun", secret.Labels[tekton.LabelDynamicSecretPipelineRun])
+	assert.Equal(t, []string{tekton.DynamicSecretFinalizer}, secret.Finalizers)
+	require.Len(t, secret.OwnerReferences, 1)
+	assert.Equal(t, "PipelineRun", secret.OwnerReferences[0].Kind)
+	assert.Equal(t, "myrun", secret.OwnerReferences[0].Name)
+	assert.Equal(t, pr.UID, secret.OwnerReferences[0].UID)
+	assert.Equal(t, "v-build-abc", string(secret.Data[env[0].ValueFrom.SecretKeyRef.Key]))
+	assert.Equal(t, "s3cr3t", string(secret.Data[env[1].ValueFrom.SecretKeyRef.Key]))
+	assert.Len(t, tekton.DynamicSecretLeases(secret), 2)
+
+	err = tekton.LeaseDynamicSecrets(vaultClient, kubeClient, ns, pr)
+	require.NoError(t, err)
+	assert.Len(t, vaultClient.Leases, 2, "the secrets should only be leased once")
+
+	err = tekton.RevokeDynamicSecrets(vaultClient, kubeClient, ns, "myrun")
+	require.NoError(t, err)
+	assert.Empty(t, vaultClient.Leases)
+	secret, err = kubeClient.CoreV1().Secrets(ns).Get(secretName, metav1.GetOptions{})
+	require.NoError(t, err, "the Secret should be kept to mask the values in the logs")
+	assert.Empty(t, tekton.DynamicSecretLeases(secret))
+	assert.Empty(t, secret.Finalizers)
+
+	err = tekton.RevokeDynamicSecrets(vaultClient, kubeClient, ns, "myrun")
+	assert.NoError(t, err, "revoking twice should be a no-op")
+}
+
+func TestRevokeDynamicSecretsRevokesAllLeases(t *testing.T) {
+	vaultClient := vaultfake.NewFakeVaultClient()
+	vaultClient.DynamicData["database/creds/readonly"] = map[string]interface{}{"username": "v-build-abc"}
+	vaultClient.DynamicData["aws/creds/deploy"] = map[string]interface{}{"access_key": "AKIA"}
+	kubeClient := fake.NewSimpleClientset()
+
+	task := &v1alpha1.Task{ObjectMeta: metav1.ObjectMeta{Name: "mytask"}}
+	task.Spec.Steps = []v1alpha1.Step{
+		{
+			Container: corev1.Container{
+				Name:  "deploy",
+				Image: "aws",
+				Env: []corev1.EnvVar{
+					{Name: "DB_USER", Value: "vault-dynamic:database/creds/readonly:username"},
+					{Name: "AWS_ACCESS_KEY_ID", Value: "vault-dynamic:aws/creds/deploy:access_key"},
+				},
+			},
+		},
+	}
+	pr := prepareDynamicSecrets(t, task)
+
+	err := tekton.LeaseDynamicSecrets(vaultClient, kubeClient, ns, pr)
+	require.NoError(t, err)
+	require.Len(t, vaultClient.Leases, 2)
+
+	secretName := tekton.DynamicSecretName("myrun")
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(secretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	secret.Annotations[tekton.AnnotationDynamicSecretLeases] = "unknown/1," + secret.Annotations[tekton.AnnotationDynamicSecretLeases]
+	_, err = kubeClient.CoreV1().Secrets(ns).Update(secret)
+	require.NoError(t, err)
+
+	err = tekton.RevokeDynamicSecrets(vaultClient, kubeClient, ns, "myrun")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown/1")
+	assert.Empty(t, vaultClient.Leases, "the other leases should still be revoked")
+	secret, err = kubeClient.CoreV1().Secrets(ns).Get(secretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unknown/1"}, tekton.DynamicSecretLeases(secret), "the failed lease should be revoked later")
+	assert.Equal(t, []string{tekton.DynamicSecretFinalizer}, secret.Finalizers, "the Secret should not be deleted before all its leases are revoked")
+}
+
+func TestLeaseDynamicSecretsMissingKeyRevokesLeases(t *testing.T) {
+	vaultClient := vaultfake.NewFakeVaultClient()
+	vaultClient.DynamicData["aws/creds/deploy"] = map[string]interface{}{"access_key": "AKIA"}
+	kubeClient := fake.NewSimpleClientset()
+
+	task := &v1alpha1.Task{ObjectMeta: metav1.ObjectMeta{Name: "mytask"}}
+	task.Spec.Steps = []v1alpha1.Step{
+		{
+			Container: corev1.Container{
+				Name:  "deploy",
+				Image: "aws",
+				Env: []corev1.EnvVar{
+					{Name: "AWS_ACCESS_KEY_ID", Value: "vault-dynamic:aws/creds/deploy:access_key"},
+					{Name: "AWS_SECRET_ACCESS_KEY", Value: "vault-dynamic:aws/creds/deploy:secret_key"},
+				},
+			},
+		},
+	}
+	pr := prepareDynamicSecrets(t, task)
+
+	err := tekton.LeaseDynamicSecrets(vaultClient, kubeClient, ns, pr)
+	require.Error(t, err)
+	assert.Empty(t, vaultClient.Leases, "the lease should be revoked on failure")
+	_, err = kubeClient.CoreV1().Secrets(ns).Get(tekton.DynamicSecretName("myrun"), metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func prepareDynamicSecrets(t *testing.T, task *v1alpha1.Task) *v1alpha1.PipelineRun {
+	crds := createDynamicSecretsCRDs(t, task)
+	err := tekton.PrepareDynamicSecrets(crds)
+	require.NoError(t, err)
+	return crds.PipelineRun()
+}
+
+func createDynamicSecretsCRDs(t *testing.T, task *v1alpha1.Task) *tekton.CRDWrapper {
+	pipeline := &v1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "mypipeline"},
+		Spec: v1alpha1.PipelineSpec{
+			Tasks: []v1alpha1.PipelineTask{{Name: task.Name, TaskRef: v1alpha1.TaskRef{Name: task.Name}}},
+		},
+	}
+	pr := &v1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "myrun"},
+		Spec: v1alpha1.PipelineRunSpec{
+			PipelineRef: v1alpha1.PipelineRef{Name: pipeline.Name},
+		},
+	}
+	crds, err := tekton.NewCRDWrapper(pipeline, []*v1alpha1.Task{task}, nil, nil, pr)
+	require.NoError(t, err)
+	return crds
+}
@@ -0,0 +1,155 @@
+package testresults
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Coverage the code coverage of a build as the number of covered lines or statements
+type Coverage struct {
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+}
+
+// Percent returns the percentage of the lines or statements which are covered
+func (c *Coverage) Percent() float64 {
+	if c == nil || c.Total == 0 {
+		return 0
+	}
+	return float64(c.Covered) * 100 / float64(c.Total)
+}
+
+// Add adds the coverage of another report
+func (c *Coverage) Add(other *Coverage) {
+	c.Covered += other.Covered
+	c.Total += other.Total
+}
+
+// ParseCoverage parses a Cobertura XML, Go cover profile or LCOV coverage report detecting its format from its
+// contents
+func ParseCoverage(data []byte) (*Coverage, error) {
+	text := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(text, "mode:"):
+		return ParseGoCoverProfile(data)
+	case strings.HasPrefix(text, "<"):
+		return ParseCobertura(data)
+	case strings.HasPrefix(text, "TN:") || strings.HasPrefix(text, "SF:"):
+		return ParseLCOV(data)
+	default:
+		return nil, fmt.Errorf("unknown coverage report format, expected Cobertura XML, a Go cover profile or LCOV")
+	}
+}
+
+// ParseGoCoverProfile parses the statements coverage of a profile written by 'go test -coverprofile'. Blocks which
+// are repeated, such as when the profiles of several packages are concatenated, are only counted once
+func ParseGoCoverProfile(data []byte) (*Coverage, error) {
+	blocks := map[string]bool{}
+	statements := map[string]int{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// the format is name.go:line.column,line.column numberOfStatements count
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid Go cover profile line: %s", line)
+		}
+		numStmts, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid number of statements in Go cover profile line: %s", line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid count in Go cover profile line: %s", line)
+		}
+		block := fields[0]
+		statements[block] = numStmts
+		blocks[block] = blocks[block] || count > 0
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	coverage := &Coverage{}
+	for block, numStmts := range statements {
+		coverage.Total += numStmts
+		if blocks[block] {
+			coverage.Covered += numStmts
+		}
+	}
+	return coverage, nil
+}
+
+type coberturaReport struct {
+	XMLName      xml.Name `xml:"coverage"`
+	LinesCovered *int     `xml:"lines-covered,attr"`
+	LinesValid   *int     `xml:"lines-valid,attr"`
+	Packages     []struct {
+		Classes []struct {
+			Lines []struct {
+				Number int `xml:"number,attr"`
+				Hits   int `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// ParseCobertura parses the line coverage of a Cobertura XML report using its totals, or its lines if the totals
+// are missing
+func ParseCobertura(data []byte) (*Coverage, error) {
+	report := coberturaReport{}
+	err := xml.Unmarshal(data, &report)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the Cobertura report")
+	}
+	if report.LinesCovered != nil && report.LinesValid != nil {
+		return &Coverage{Covered: *report.LinesCovered, Total: *report.LinesValid}, nil
+	}
+	coverage := &Coverage{}
+	for _, p := range report.Packages {
+		for _, c := range p.Classes {
+			for _, l := range c.Lines {
+				coverage.Total++
+				if l.Hits > 0 {
+					coverage.Covered++
+				}
+			}
+		}
+	}
+	return coverage, nil
+}
+
+// ParseLCOV parses the line coverage of an LCOV tracefile from the line totals of each source file
+func ParseLCOV(data []byte) (*Coverage, error) {
+	coverage := &Coverage{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var target *int
+		switch {
+		case strings.HasPrefix(line, "LF:"):
+			target = &coverage.Total
+		case strings.HasPrefix(line, "LH:"):
+			target = &coverage.Covered
+		default:
+			continue
+		}
+		value, err := strconv.Atoi(line[3:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid LCOV line: %s", line)
+		}
+		*target += value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return coverage, nil
+}
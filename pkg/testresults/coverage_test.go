@@ -0,0 +1,114 @@
+// +build unit
+
+package testresults_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/testresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCoverage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		report   string
+		expected testresults.Coverage
+	}{
+		{
+			name: "go cover profile with a block repeated by another package",
+			report: `mode: atomic
+github.com/jstrachan/myapp/main.go:10.13,12.2 2 1
+github.com/jstrachan/myapp/main.go:14.20,18.2 3 0
+github.com/jstrachan/myapp/main.go:14.20,18.2 3 4
+github.com/jstrachan/myapp/util.go:3.10,5.2 5 0
+`,
+			expected: testresults.Coverage{Covered: 5, Total: 10},
+		},
+		{
+			name: "cobertura with totals",
+			report: `<?xml version="1.0" ?>
+<coverage line-rate="0.75" lines-covered="75" lines-valid="100" version="5.0"></coverage>`,
+			expected: testresults.Coverage{Covered: 75, Total: 100},
+		},
+		{
+			name: "cobertura without totals",
+			report: `<coverage line-rate="0.5">
+  <packages><package name="app"><classes><class name="Main">
+    <lines><line number="1" hits="3"/><line number="2" hits="0"/><line number="3" hits="1"/><line number="4" hits="0"/></lines>
+  </class></classes></package></packages>
+</coverage>`,
+			expected: testresults.Coverage{Covered: 2, Total: 4},
+		},
+		{
+			name: "lcov",
+			report: `TN:
+SF:src/index.js
+DA:1,1
+LF:20
+LH:15
+end_of_record
+SF:src/util.js
+LF:10
+LH:2
+end_of_record
+`,
+			expected: testresults.Coverage{Covered: 17, Total: 30},
+		},
+	}
+	for _, tt := range tests {
+		coverage, err := testresults.ParseCoverage([]byte(tt.report))
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.expected, *coverage, tt.name)
+	}
+
+	_, err := testresults.ParseCoverage([]byte("not a coverage report"))
+	assert.Error(t, err)
+}
+
+func TestSummaryMarkdown(t *testing.T) {
+	t.Parallel()
+
+	passed := testresults.OutcomePassed
+	failed := testresults.OutcomeFailed
+	base := newBuild("master", 41, map[string]testresults.Outcome{
+		"TestStable": passed,
+		"TestFlaky":  failed,
+		"TestBroken": failed,
+	})
+	base.Coverage = &testresults.Coverage{Covered: 800, Total: 1000}
+	results := newBuild("PR-7", 2, map[string]testresults.Outcome{
+		"TestStable":  failed,
+		"TestFlaky":   passed,
+		"TestBroken":  failed,
+		"TestSkipped": testresults.OutcomeSkipped,
+	})
+	results.Coverage = &testresults.Coverage{Covered: 785, Total: 1000}
+
+	summary := testresults.NewSummary(results, base)
+	delta, ok := summary.CoverageDelta()
+	require.True(t, ok)
+	assert.InDelta(t, -1.5, delta, 0.0001)
+	assert.Equal(t, `### Test results
+
+| | Passed | Failed | Skipped | Coverage |
+| --- | --- | --- | --- | --- |
+| This build | 1 | 2 | 1 | 78.5% |
+| master #41 | 1 | 2 | 0 | 80.0% |
+
+Coverage decreased by 1.5% compared to master.
+
+1 tests failing on master now pass.
+
+| Failed test | |
+| --- | --- |
+| `+"`myapp / TestStable`"+` | **new failure** |
+| `+"`myapp / TestBroken`"+` | also fails on master |
+`, summary.Markdown())
+
+	noBase := testresults.NewSummary(newBuild("master", 42, map[string]testresults.Outcome{"TestStable": passed}), nil)
+	assert.Contains(t, noBase.Markdown(), "All tests passed.")
+}
@@ -0,0 +1,126 @@
+package testresults
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultHistoryBuilds the default number of builds the flakiness of the tests is computed over
+	DefaultHistoryBuilds = 20
+	// DefaultMinFlipRate the default flip rate above which a test is considered flaky
+	DefaultMinFlipRate = 0.1
+)
+
+// FlakyTest the flakiness of a test across builds
+type FlakyTest struct {
+	Key       string `json:"key"`
+	Suite     string `json:"suite"`
+	Classname string `json:"classname,omitempty"`
+	Name      string `json:"name"`
+	// Runs the number of builds the test ran in
+	Runs int `json:"runs"`
+	// Failures the number of builds the test failed in
+	Failures int `json:"failures"`
+	// Flips the number of times the outcome changed between passed and failed in consecutive builds of a branch
+	Flips int `json:"flips"`
+	// FlipRate the ratio of the flips to the number of consecutive runs
+	FlipRate float64 `json:"flipRate"`
+	// LastFailedBuild the last build the test failed in
+	LastFailedBuild string `json:"lastFailedBuild,omitempty"`
+}
+
+// FindFlakyTests computes the flip rate of each test from the results of the builds ordered from the oldest. Only
+// consecutive builds of the same branch are compared so a test broken on one branch and fixed on another is not a
+// flip. A test is flaky if it flipped at least twice, so that a single breakage and fix is not reported, and its flip
+// rate is at least the minimum flip rate. The flaky tests are returned with the highest flip rate first
+func FindFlakyTests(history []*BuildTestResults, minFlipRate float64) []*FlakyTest {
+	tests := map[string]*FlakyTest{}
+	transitions := map[string]int{}
+	lastFailed := map[string]bool{}
+	for _, build := range history {
+		for i := range build.Tests {
+			result := &build.Tests[i]
+			if result.Outcome == OutcomeSkipped {
+				continue
+			}
+			key := result.Key()
+			test := tests[key]
+			if test == nil {
+				test = &FlakyTest{
+					Key:       key,
+					Suite:     result.Suite,
+					Classname: result.Classname,
+					Name:      result.Name,
+				}
+				tests[key] = test
+			}
+			failed := result.Outcome.IsFailure()
+			test.Runs++
+			if failed {
+				test.Failures++
+				test.LastFailedBuild = build.Build
+			}
+			branchKey := build.Branch + "\x00" + key
+			if previous, ok := lastFailed[branchKey]; ok {
+				transitions[key]++
+				if previous != failed {
+					test.Flips++
+				}
+			}
+			lastFailed[branchKey] = failed
+		}
+	}
+
+	answer := []*FlakyTest{}
+	for key, test := range tests {
+		if transitions[key] > 0 {
+			test.FlipRate = float64(test.Flips) / float64(transitions[key])
+		}
+		if test.Flips >= 2 && test.FlipRate >= minFlipRate {
+			answer = append(answer, test)
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		t1 := answer[i]
+		t2 := answer[j]
+		if t1.FlipRate != t2.FlipRate {
+			return t1.FlipRate > t2.FlipRate
+		}
+		if t1.Failures != t2.Failures {
+			return t1.Failures > t2.Failures
+		}
+		return t1.Key < t2.Key
+	})
+	return answer
+}
+
+// KnownFlakyFailures returns the flaky tests which failed in the build
+func KnownFlakyFailures(build *BuildTestResults, flaky []*FlakyTest) []*FlakyTest {
+	flakyByKey := map[string]*FlakyTest{}
+	for _, f := range flaky {
+		flakyByKey[f.Key] = f
+	}
+	var answer []*FlakyTest
+	for _, t := range build.Failures() {
+		if f, ok := flakyByKey[t.Key()]; ok {
+			answer = append(answer, f)
+		}
+	}
+	return answer
+}
+
+// FlakyFailuresComment returns the markdown of a Pull Request comment listing the failed tests which are known to be
+// flaky
+func FlakyFailuresComment(failures []*FlakyTest, builds int) string {
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("The following failed tests are known to be flaky over the last %d builds:\n\n", builds))
+	buf.WriteString("| Test | Flip rate | Failures |\n")
+	buf.WriteString("| --- | --- | --- |\n")
+	for _, f := range failures {
+		buf.WriteString(fmt.Sprintf("| `%s` | %.0f%% | %d/%d |\n", f.Key, f.FlipRate*100, f.Failures, f.Runs))
+	}
+	buf.WriteString("\nThey may pass if the build is retried with `/test this`.\n")
+	return buf.String()
+}
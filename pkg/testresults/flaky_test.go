@@ -0,0 +1,180 @@
+// +build unit
+
+package testresults_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/testresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testNamespace = "jx"
+
+var start = time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// newBuild creates the results of a build where each outcome is given as test name to outcome
+func newBuild(branch string, build int, outcomes map[string]testresults.Outcome) *testresults.BuildTestResults {
+	results := &testresults.BuildTestResults{
+		Version:    testresults.ResultsVersion,
+		Owner:      "jstrachan",
+		Repository: "myapp",
+		Branch:     branch,
+		Build:      fmt.Sprintf("%d", build),
+		Timestamp:  start.Add(time.Duration(build) * time.Hour),
+	}
+	for _, name := range []string{"TestStable", "TestFlaky", "TestBroken", "TestSkipped"} {
+		if outcome, ok := outcomes[name]; ok {
+			results.Tests = append(results.Tests, testresults.TestResult{
+				Suite:   "myapp",
+				Name:    name,
+				Outcome: outcome,
+			})
+		}
+	}
+	return results
+}
+
+func masterHistory() []*testresults.BuildTestResults {
+	passed := testresults.OutcomePassed
+	failed := testresults.OutcomeFailed
+	flaky := []testresults.Outcome{passed, failed, passed, passed, testresults.OutcomeError, passed}
+	history := []*testresults.BuildTestResults{}
+	for i, outcome := range flaky {
+		broken := passed
+		if i >= 3 {
+			broken = failed
+		}
+		history = append(history, newBuild("master", i+1, map[string]testresults.Outcome{
+			"TestStable":  passed,
+			"TestFlaky":   outcome,
+			"TestBroken":  broken,
+			"TestSkipped": testresults.OutcomeSkipped,
+		}))
+	}
+	return history
+}
+
+func TestFindFlakyTests(t *testing.T) {
+	t.Parallel()
+
+	flaky := testresults.FindFlakyTests(masterHistory(), testresults.DefaultMinFlipRate)
+	require.Len(t, flaky, 1, "a test which broke once should not be flaky")
+	f := flaky[0]
+	assert.Equal(t, "myapp / TestFlaky", f.Key)
+	assert.Equal(t, 6, f.Runs)
+	assert.Equal(t, 2, f.Failures)
+	assert.Equal(t, 4, f.Flips)
+	assert.Equal(t, 0.8, f.FlipRate)
+	assert.Equal(t, "5", f.LastFailedBuild)
+
+	assert.Empty(t, testresults.FindFlakyTests(masterHistory(), 0.9), "the flip rate should be below the minimum")
+}
+
+func TestFindFlakyTestsComparesBuildsOfTheSameBranch(t *testing.T) {
+	t.Parallel()
+
+	passed := testresults.OutcomePassed
+	failed := testresults.OutcomeFailed
+	history := []*testresults.BuildTestResults{
+		newBuild("PR-1", 1, map[string]testresults.Outcome{"TestBroken": failed}),
+		newBuild("master", 2, map[string]testresults.Outcome{"TestBroken": passed}),
+		newBuild("PR-2", 3, map[string]testresults.Outcome{"TestBroken": failed}),
+		newBuild("master", 4, map[string]testresults.Outcome{"TestBroken": passed}),
+		newBuild("PR-1", 5, map[string]testresults.Outcome{"TestBroken": passed}),
+	}
+	assert.Empty(t, testresults.FindFlakyTests(history, 0), "failures on pull requests should not flip the master builds")
+}
+
+func TestKnownFlakyFailures(t *testing.T) {
+	t.Parallel()
+
+	flaky := testresults.FindFlakyTests(masterHistory(), testresults.DefaultMinFlipRate)
+	build := newBuild("PR-3", 1, map[string]testresults.Outcome{
+		"TestStable": testresults.OutcomeFailed,
+		"TestFlaky":  testresults.OutcomeFailed,
+	})
+	failures := testresults.KnownFlakyFailures(build, flaky)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "myapp / TestFlaky", failures[0].Key)
+
+	comment := testresults.FlakyFailuresComment(failures, 6)
+	assert.Contains(t, comment, "over the last 6 builds")
+	assert.Contains(t, comment, "| `myapp / TestFlaky` | 80% | 2/6 |")
+}
+
+func TestLoadHistory(t *testing.T) {
+	t.Parallel()
+
+	jxClient := fake.NewSimpleClientset()
+	files := map[string][]byte{}
+	for i, build := range masterHistory() {
+		u := fmt.Sprintf("gs://reports/%s", testresults.ResultsPath(build.Owner, build.Repository, build.Branch, build.Build))
+		data, err := json.Marshal(build)
+		require.NoError(t, err)
+		files[u] = data
+		started := metav1.NewTime(build.Timestamp)
+		_, err = jxClient.JenkinsV1().PipelineActivities(testNamespace).Create(&v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("jstrachan-myapp-master-%d", i+1), Namespace: testNamespace},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:         "jstrachan/myapp/master",
+				Build:            build.Build,
+				GitOwner:         "jstrachan",
+				GitRepository:    "myapp",
+				GitBranch:        "master",
+				StartedTimestamp: &started,
+				Attachments: []v1.Attachment{
+					{Name: testresults.AttachmentName, URLs: []string{u}},
+				},
+			},
+		})
+		require.NoError(t, err)
+	}
+	_, err := jxClient.JenkinsV1().PipelineActivities(testNamespace).Create(&v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "jstrachan-other-master-1", Namespace: testNamespace},
+		Spec: v1.PipelineActivitySpec{
+			GitOwner:      "jstrachan",
+			GitRepository: "other",
+			GitBranch:     "master",
+			Attachments: []v1.Attachment{
+				{Name: testresults.AttachmentName, URLs: []string{"gs://reports/other.json"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	filter := &testresults.HistoryFilter{
+		Owner:      "jstrachan",
+		Repository: "myapp",
+		Branch:     "master",
+		Builds:     4,
+	}
+	history, err := testresults.LoadHistory(jxClient, testNamespace, filter, func(u string) ([]byte, error) {
+		data, ok := files[u]
+		if !ok {
+			return nil, fmt.Errorf("not found %s", u)
+		}
+		return data, nil
+	})
+	require.NoError(t, err)
+	builds := []string{}
+	for _, h := range history {
+		builds = append(builds, h.Build)
+	}
+	assert.Equal(t, []string{"3", "4", "5", "6"}, builds, "the most recent builds should be loaded from the oldest")
+}
+
+func TestParseResultsRejectsNewerVersions(t *testing.T) {
+	t.Parallel()
+
+	_, err := testresults.ParseResults([]byte(`{"version": 2}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported test results version 2")
+}
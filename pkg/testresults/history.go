@@ -0,0 +1,76 @@
+package testresults
+
+import (
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HistoryFilter selects the builds to load the test results of
+type HistoryFilter struct {
+	Owner      string
+	Repository string
+	// Branch the branch of the builds, or all branches if blank
+	Branch string
+	// Builds the maximum number of the most recent builds to load
+	Builds int
+}
+
+// ReadURLFn reads the contents of a URL such as a bucket or HTTP URL
+type ReadURLFn func(url string) ([]byte, error)
+
+// LoadHistory loads the stored test results of the most recent builds matching the filter using the URLs attached
+// to their PipelineActivity resources. The results are returned from the oldest build. The builds whose results
+// cannot be read are skipped with a warning
+func LoadHistory(jxClient versioned.Interface, ns string, filter *HistoryFilter, readURL ReadURLFn) ([]*BuildTestResults, error) {
+	list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the PipelineActivities in namespace %s", ns)
+	}
+	activities := []v1.PipelineActivity{}
+	for _, a := range list.Items {
+		if a.RepositoryOwner() != filter.Owner || a.RepositoryName() != filter.Repository {
+			continue
+		}
+		if filter.Branch != "" && a.BranchName() != filter.Branch {
+			continue
+		}
+		if resultsURL(&a) != "" {
+			activities = append(activities, a)
+		}
+	}
+	kube.SortActivities(activities)
+	if filter.Builds > 0 && len(activities) > filter.Builds {
+		activities = activities[len(activities)-filter.Builds:]
+	}
+
+	answer := []*BuildTestResults{}
+	for i := range activities {
+		u := resultsURL(&activities[i])
+		data, err := readURL(u)
+		if err != nil {
+			log.Logger().Warnf("failed to read the test results of %s from %s: %s", activities[i].Name, u, err)
+			continue
+		}
+		results, err := ParseResults(data)
+		if err != nil {
+			log.Logger().Warnf("failed to parse the test results of %s from %s: %s", activities[i].Name, u, err)
+			continue
+		}
+		answer = append(answer, results)
+	}
+	return answer, nil
+}
+
+// resultsURL returns the URL of the test results attached to the activity
+func resultsURL(a *v1.PipelineActivity) string {
+	for _, attachment := range a.Spec.Attachments {
+		if attachment.Name == AttachmentName && len(attachment.URLs) > 0 {
+			return attachment.URLs[len(attachment.URLs)-1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package testresults
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ResultsVersion the version of the format of the stored test results
+	ResultsVersion = 1
+	// ResultsFileName the name of the file the test results of a build are stored in
+	ResultsFileName = "test-results.json"
+	// AttachmentName the name of the PipelineActivity attachment with the URL of the stored test results
+	AttachmentName = "test-results"
+)
+
+// Outcome the outcome of running a test
+type Outcome string
+
+const (
+	// OutcomePassed the test passed
+	OutcomePassed Outcome = "passed"
+	// OutcomeFailed an assertion of the test failed
+	OutcomeFailed Outcome = "failed"
+	// OutcomeError the test failed with an unexpected error
+	OutcomeError Outcome = "error"
+	// OutcomeSkipped the test did not run
+	OutcomeSkipped Outcome = "skipped"
+)
+
+// IsFailure returns true if the test failed or errored
+func (o Outcome) IsFailure() bool {
+	return o == OutcomeFailed || o == OutcomeError
+}
+
+// TestResult the result of a single test case
+type TestResult struct {
+	Suite     string `json:"suite"`
+	Classname string `json:"classname,omitempty"`
+	Name      string `json:"name"`
+	// Duration the duration of the test in seconds
+	Duration float64 `json:"duration"`
+	Outcome  Outcome `json:"outcome"`
+}
+
+// Key returns the key which identifies the same test across builds
+func (r *TestResult) Key() string {
+	parts := []string{}
+	for _, p := range []string{r.Suite, r.Classname, r.Name} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " / ")
+}
+
+// BuildTestResults the results of all the tests run by a build
+type BuildTestResults struct {
+	Version    int          `json:"version"`
+	Owner      string       `json:"owner"`
+	Repository string       `json:"repository"`
+	Branch     string       `json:"branch"`
+	Build      string       `json:"build"`
+	Timestamp  time.Time    `json:"timestamp"`
+	Tests      []TestResult `json:"tests"`
+	// Coverage the code coverage of the build, if it was reported
+	Coverage *Coverage `json:"coverage,omitempty"`
+}
+
+// Failures returns the tests which failed or errored
+func (b *BuildTestResults) Failures() []TestResult {
+	var answer []TestResult
+	for _, t := range b.Tests {
+		if t.Outcome.IsFailure() {
+			answer = append(answer, t)
+		}
+	}
+	return answer
+}
+
+// Count returns the number of tests with the given outcome
+func (b *BuildTestResults) Count(outcome Outcome) int {
+	count := 0
+	for _, t := range b.Tests {
+		if t.Outcome == outcome {
+			count++
+		}
+	}
+	return count
+}
+
+// ResultsPath returns the path in the reports storage the test results of a build are stored at
+func ResultsPath(owner string, repository string, branch string, build string) string {
+	return path.Join("jenkins-x", kube.ClassificationReports, owner, repository, branch, build, ResultsFileName)
+}
+
+// ParseResults parses the stored test results of a build
+func ParseResults(data []byte) (*BuildTestResults, error) {
+	results := &BuildTestResults{}
+	err := json.Unmarshal(data, results)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the test results")
+	}
+	if results.Version > ResultsVersion {
+		return nil, fmt.Errorf("unsupported test results version %d, upgrade jx to read it", results.Version)
+	}
+	return results, nil
+}
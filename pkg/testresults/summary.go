@@ -0,0 +1,119 @@
+package testresults
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxSummaryFailures the maximum number of failed tests listed in a summary
+const maxSummaryFailures = 20
+
+// Summary the summary of the test results of a build compared to the latest build of its base branch
+type Summary struct {
+	Results *BuildTestResults
+	// Base the results of the base branch, if any
+	Base *BuildTestResults
+	// NewFailures the tests which failed but did not fail in the base branch
+	NewFailures map[string]bool
+	// Fixed the number of tests which failed in the base branch but passed
+	Fixed int
+}
+
+// NewSummary compares the test results with the results of the base branch which may be nil
+func NewSummary(results *BuildTestResults, base *BuildTestResults) *Summary {
+	summary := &Summary{
+		Results:     results,
+		Base:        base,
+		NewFailures: map[string]bool{},
+	}
+	baseOutcomes := map[string]Outcome{}
+	if base != nil {
+		for i := range base.Tests {
+			baseOutcomes[base.Tests[i].Key()] = base.Tests[i].Outcome
+		}
+	}
+	for i := range results.Tests {
+		t := &results.Tests[i]
+		baseOutcome := baseOutcomes[t.Key()]
+		if t.Outcome.IsFailure() && !baseOutcome.IsFailure() {
+			summary.NewFailures[t.Key()] = true
+		}
+		if t.Outcome == OutcomePassed && baseOutcome.IsFailure() {
+			summary.Fixed++
+		}
+	}
+	return summary
+}
+
+// CoverageDelta returns the change in the coverage percentage compared to the base branch and whether both builds
+// have coverage
+func (s *Summary) CoverageDelta() (float64, bool) {
+	if s.Results.Coverage == nil || s.Base == nil || s.Base.Coverage == nil {
+		return 0, false
+	}
+	return s.Results.Coverage.Percent() - s.Base.Coverage.Percent(), true
+}
+
+// Markdown returns the summary as markdown suitable for a Pull Request comment
+func (s *Summary) Markdown() string {
+	var buf strings.Builder
+	buf.WriteString("### Test results\n\n")
+	buf.WriteString("| | Passed | Failed | Skipped | Coverage |\n")
+	buf.WriteString("| --- | --- | --- | --- | --- |\n")
+	writeSummaryRow(&buf, "This build", s.Results)
+	if s.Base != nil {
+		writeSummaryRow(&buf, fmt.Sprintf("%s #%s", s.Base.Branch, s.Base.Build), s.Base)
+	}
+	buf.WriteString("\n")
+
+	if delta, ok := s.CoverageDelta(); ok {
+		buf.WriteString(fmt.Sprintf("Coverage %s compared to %s.\n\n", describeDelta(delta), s.Base.Branch))
+	}
+	if s.Fixed > 0 {
+		buf.WriteString(fmt.Sprintf("%d tests failing on %s now pass.\n\n", s.Fixed, s.Base.Branch))
+	}
+
+	failures := s.Results.Failures()
+	if len(failures) == 0 {
+		buf.WriteString("All tests passed.\n")
+		return buf.String()
+	}
+	buf.WriteString("| Failed test | |\n")
+	buf.WriteString("| --- | --- |\n")
+	for i, t := range failures {
+		if i == maxSummaryFailures {
+			buf.WriteString(fmt.Sprintf("| and %d more | |\n", len(failures)-maxSummaryFailures))
+			break
+		}
+		note := ""
+		if s.Base != nil {
+			note = "also fails on " + s.Base.Branch
+			if s.NewFailures[t.Key()] {
+				note = "**new failure**"
+			}
+		}
+		buf.WriteString(fmt.Sprintf("| `%s` | %s |\n", t.Key(), note))
+	}
+	return buf.String()
+}
+
+func writeSummaryRow(buf *strings.Builder, name string, results *BuildTestResults) {
+	coverage := "-"
+	if results.Coverage != nil {
+		coverage = fmt.Sprintf("%.1f%%", results.Coverage.Percent())
+	}
+	failed := results.Count(OutcomeFailed) + results.Count(OutcomeError)
+	buf.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %s |\n", name, results.Count(OutcomePassed), failed, results.Count(OutcomeSkipped), coverage))
+}
+
+// describeDelta describes a change in the coverage percentage
+func describeDelta(delta float64) string {
+	switch {
+	case delta >= 0.05:
+		return fmt.Sprintf("increased by %.1f%%", delta)
+	case delta <= -0.05:
+		return fmt.Sprintf("decreased by %.1f%%", -delta)
+	default:
+		return "is unchanged"
+	}
+}
@@ -0,0 +1,279 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnvOTLPEndpoint the standard OpenTelemetry environment variable of the collector endpoint
+	EnvOTLPEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	// EnvOTLPTracesEndpoint the standard OpenTelemetry environment variable of the collector traces endpoint
+	EnvOTLPTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+
+	// tracesPath the path of the OTLP/HTTP traces endpoint of a collector
+	tracesPath = "/v1/traces"
+	// scopeName the name of the instrumentation scope of the exported spans
+	scopeName = "github.com/jenkins-x/jx/pkg/tracing"
+	// spanKindInternal the OTLP span kind of the pipeline spans
+	spanKindInternal = 1
+)
+
+// Exporter exports traces to a tracing backend
+type Exporter interface {
+	// Export exports the spans of the trace
+	Export(trace *Trace) error
+}
+
+// OTLPExporter exports traces to an OpenTelemetry collector using OTLP over HTTP with the JSON encoding
+type OTLPExporter struct {
+	// Endpoint the URL of the collector. If it does not end with /v1/traces the path is appended
+	Endpoint string
+	// TracesEndpoint the URL the traces are posted to as is, which takes precedence over Endpoint
+	TracesEndpoint string
+	// Headers the additional HTTP headers to send, such as authorization
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// NewOTLPExporter creates an exporter which sends the traces to the collector at the given endpoint
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	return &OTLPExporter{
+		Endpoint: endpoint,
+		Client:   util.GetClientWithTimeout(30 * time.Second),
+	}
+}
+
+// NewOTLPExporterFromEnv creates an exporter for the collector configured by the standard OpenTelemetry environment
+// variables. The traces endpoint is used as is while the traces path is appended to the collector endpoint. It returns
+// nil if neither variable is set
+func NewOTLPExporterFromEnv() *OTLPExporter {
+	if endpoint := os.Getenv(EnvOTLPTracesEndpoint); endpoint != "" {
+		exporter := NewOTLPExporter("")
+		exporter.TracesEndpoint = endpoint
+		return exporter
+	}
+	if endpoint := os.Getenv(EnvOTLPEndpoint); endpoint != "" {
+		return NewOTLPExporter(endpoint)
+	}
+	return nil
+}
+
+// URL returns the URL the traces are posted to
+func (e *OTLPExporter) URL() string {
+	if e.TracesEndpoint != "" {
+		return e.TracesEndpoint
+	}
+	u := strings.TrimSuffix(e.Endpoint, "/")
+	if !strings.Contains(u, "://") {
+		u = "http://" + u
+	}
+	if !strings.HasSuffix(u, tracesPath) {
+		u += tracesPath
+	}
+	return u
+}
+
+// Export posts the trace to the collector
+func (e *OTLPExporter) Export(trace *Trace) error {
+	data, err := json.Marshal(ToOTLP(trace))
+	if err != nil {
+		return errors.Wrap(err, "marshalling the trace to OTLP JSON")
+	}
+	req, err := http.NewRequest(http.MethodPost, e.URL(), bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrapf(err, "creating the request to %s", e.URL())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	client := e.Client
+	if client == nil {
+		client = util.GetClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "exporting the trace %s to %s", trace.ID, e.URL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("exporting the trace %s to %s returned status %d: %s", trace.ID, e.URL(), resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// FileExporter appends each trace as a line of OTLP JSON to a file, using the same format as the file exporter of
+// the OpenTelemetry collector. It is useful for testing and for loading the traces into a backend later
+type FileExporter struct {
+	Path string
+	lock sync.Mutex
+}
+
+// NewFileExporter creates an exporter which appends the traces to the given file
+func NewFileExporter(path string) *FileExporter {
+	return &FileExporter{Path: path}
+}
+
+// Export appends the trace to the file
+func (e *FileExporter) Export(trace *Trace) error {
+	data, err := json.Marshal(ToOTLP(trace))
+	if err != nil {
+		return errors.Wrap(err, "marshalling the trace to OTLP JSON")
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	err = os.MkdirAll(filepath.Dir(e.Path), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "creating the directory of %s", e.Path)
+	}
+	f, err := os.OpenFile(e.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "opening %s", e.Path)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "writing the trace %s to %s", trace.ID, e.Path)
+	}
+	return nil
+}
+
+// ExportTraceServiceRequest the OTLP JSON encoding of a request to export traces
+type ExportTraceServiceRequest struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+// ResourceSpans the OTLP JSON encoding of the spans of a resource
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+// Resource the OTLP JSON encoding of a resource
+type Resource struct {
+	Attributes []KeyValue `json:"attributes,omitempty"`
+}
+
+// ScopeSpans the OTLP JSON encoding of the spans of an instrumentation scope
+type ScopeSpans struct {
+	Scope Scope      `json:"scope"`
+	Spans []OTLPSpan `json:"spans"`
+}
+
+// Scope the OTLP JSON encoding of an instrumentation scope
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// OTLPSpan the OTLP JSON encoding of a span
+type OTLPSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	Links             []OTLPLink `json:"links,omitempty"`
+	Status            OTLPStatus `json:"status"`
+}
+
+// OTLPLink the OTLP JSON encoding of a link
+type OTLPLink struct {
+	TraceID    string     `json:"traceId"`
+	SpanID     string     `json:"spanId"`
+	Attributes []KeyValue `json:"attributes,omitempty"`
+}
+
+// OTLPStatus the OTLP JSON encoding of the status of a span
+type OTLPStatus struct {
+	Code StatusCode `json:"code,omitempty"`
+}
+
+// KeyValue the OTLP JSON encoding of an attribute
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue the OTLP JSON encoding of the value of an attribute
+type AnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// ToOTLP converts the trace into the OTLP JSON encoding
+func ToOTLP(trace *Trace) *ExportTraceServiceRequest {
+	spans := []OTLPSpan{}
+	for _, s := range trace.Spans {
+		span := OTLPSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(s.Start),
+			EndTimeUnixNano:   unixNano(s.End),
+			Attributes:        keyValues(s.Attributes),
+			Status:            OTLPStatus{Code: s.Status},
+		}
+		if !s.ParentSpanID.IsEmpty() {
+			span.ParentSpanID = s.ParentSpanID.String()
+		}
+		for _, l := range s.Links {
+			span.Links = append(span.Links, OTLPLink{
+				TraceID:    l.TraceID.String(),
+				SpanID:     l.SpanID.String(),
+				Attributes: keyValues(l.Attributes),
+			})
+		}
+		spans = append(spans, span)
+	}
+	return &ExportTraceServiceRequest{
+		ResourceSpans: []ResourceSpans{
+			{
+				Resource: Resource{Attributes: keyValues(trace.Resource)},
+				ScopeSpans: []ScopeSpans{
+					{
+						Scope: Scope{Name: scopeName},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+// keyValues converts the attributes into OTLP key values sorted by key
+func keyValues(attributes map[string]string) []KeyValue {
+	var answer []KeyValue
+	for k, v := range attributes {
+		answer = append(answer, KeyValue{Key: k, Value: AnyValue{StringValue: v}})
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Key < answer[j].Key
+	})
+	return answer
+}
+
+func unixNano(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
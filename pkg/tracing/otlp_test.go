@@ -0,0 +1,86 @@
+// +build unit
+
+package tracing_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPExporterURL(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "http://otel-collector:4318/v1/traces", tracing.NewOTLPExporter("otel-collector:4318").URL())
+	assert.Equal(t, "https://otel.example.com/v1/traces", tracing.NewOTLPExporter("https://otel.example.com/").URL())
+	assert.Equal(t, "https://otel.example.com/v1/traces", tracing.NewOTLPExporter("https://otel.example.com/v1/traces").URL())
+}
+
+func TestNewOTLPExporterFromEnv(t *testing.T) {
+	for _, name := range []string{tracing.EnvOTLPEndpoint, tracing.EnvOTLPTracesEndpoint} {
+		value, ok := os.LookupEnv(name)
+		if ok {
+			defer os.Setenv(name, value)
+		} else {
+			defer os.Unsetenv(name)
+		}
+		os.Unsetenv(name)
+	}
+
+	assert.Nil(t, tracing.NewOTLPExporterFromEnv(), "no exporter should be created without the environment variables")
+
+	os.Setenv(tracing.EnvOTLPEndpoint, "http://otel-collector:4318/")
+	exporter := tracing.NewOTLPExporterFromEnv()
+	require.NotNil(t, exporter)
+	assert.Equal(t, "http://otel-collector:4318/v1/traces", exporter.URL(), "the traces path should be appended to the collector endpoint")
+
+	os.Setenv(tracing.EnvOTLPTracesEndpoint, "https://otel.example.com/custom/traces")
+	exporter = tracing.NewOTLPExporterFromEnv()
+	require.NotNil(t, exporter)
+	assert.Equal(t, "https://otel.example.com/custom/traces", exporter.URL(), "the traces endpoint should be used as is")
+}
+
+func TestOTLPExporter(t *testing.T) {
+	t.Parallel()
+
+	var requests []tracing.ExportTraceServiceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		request := tracing.ExportTraceServiceRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := tracing.NewOTLPExporter(server.URL)
+	exporter.Headers = map[string]string{"Authorization": "Bearer secret"}
+	trace := tracing.PipelineRunTrace(runName, newActivity())
+	require.NoError(t, exporter.Export(trace))
+
+	require.Len(t, requests, 1)
+	spans := requests[0].ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, len(trace.Spans))
+	for _, span := range spans {
+		if span.Name == "pull request" {
+			require.Len(t, span.Links, 1)
+			assert.Equal(t, tracing.NewTraceID("https://github.com/jstrachan/environment-staging/pull/7").String(), span.Links[0].TraceID)
+		}
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no space left", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	err := tracing.NewOTLPExporter(failing.URL).Export(trace)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no space left")
+}
@@ -0,0 +1,334 @@
+package tracing
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AttributeServiceName the resource attribute of the name of the service producing the traces
+	AttributeServiceName = "service.name"
+	// AttributeServiceNamespace the resource attribute of the namespace of the service producing the traces
+	AttributeServiceNamespace = "service.namespace"
+
+	// AttributePipeline the name of the pipeline, such as owner/repository/branch
+	AttributePipeline = "jx.pipeline"
+	// AttributeBuild the build number of the pipeline
+	AttributeBuild = "jx.build"
+	// AttributeContext the context of the pipeline
+	AttributeContext = "jx.context"
+	// AttributePipelineRun the name of the Tekton PipelineRun
+	AttributePipelineRun = "jx.pipeline_run"
+	// AttributeActivity the name of the PipelineActivity
+	AttributeActivity = "jx.activity"
+	// AttributeBuildLogsURL the URL of the build logs
+	AttributeBuildLogsURL = "jx.build_logs_url"
+	// AttributeEnvironment the environment of a promote or preview span
+	AttributeEnvironment = "jx.environment"
+	// AttributeLinkType the type of a link, either trigger, promote or preview
+	AttributeLinkType = "jx.link_type"
+	// AttributeGitOwner the owner of the git repository
+	AttributeGitOwner = "vcs.owner"
+	// AttributeGitRepository the name of the git repository
+	AttributeGitRepository = "vcs.repository"
+	// AttributeGitBranch the branch being built
+	AttributeGitBranch = "vcs.branch"
+	// AttributeCommitSHA the SHA of the commit being built
+	AttributeCommitSHA = "vcs.commit.sha"
+	// AttributeCommitURL the URL of the commit being built
+	AttributeCommitURL = "vcs.commit.url"
+	// AttributePullRequestURL the URL of a pull request
+	AttributePullRequestURL = "vcs.pull_request.url"
+
+	// LinkTypeTrigger the link to the webhook which triggered the pipeline
+	LinkTypeTrigger = "trigger"
+	// LinkTypePromote the link to a promote pull request
+	LinkTypePromote = "promote"
+	// LinkTypePreview the link to the pull request of a preview environment
+	LinkTypePreview = "preview"
+
+	// stageSeparator separates the names of nested stages in a PipelineActivity
+	stageSeparator = " / "
+
+	// exportedRunExpiry how long exported runs are remembered so that they are not exported twice
+	exportedRunExpiry = 24 * time.Hour
+	// exportQueueSize the number of pipeline runs which can be waiting to be exported
+	exportQueueSize = 100
+)
+
+// TriggerKey returns the key of the webhook event which triggered a build of the given commit. It is used to derive
+// the trace and span IDs of the trigger link
+func TriggerKey(owner string, repository string, sha string) string {
+	return "webhook:" + owner + "/" + repository + "@" + sha
+}
+
+// PipelineRunTrace creates the trace of a pipeline run from its PipelineActivity. The pipeline run is the root span,
+// each stage and step are child spans and any promotions are spans linked to their pull requests
+func PipelineRunTrace(runName string, activity *v1.PipelineActivity) *Trace {
+	spec := &activity.Spec
+	traceID := NewTraceID(runName)
+	trace := &Trace{ID: traceID}
+
+	root := &Span{
+		TraceID: traceID,
+		SpanID:  NewSpanID(traceID, ""),
+		Name:    strings.TrimSpace(spec.Pipeline + " #" + spec.Build),
+		Status:  statusCode(spec.Status),
+		Attributes: attributes(map[string]string{
+			AttributePipeline:      spec.Pipeline,
+			AttributeBuild:         spec.Build,
+			AttributeContext:       spec.Context,
+			AttributePipelineRun:   runName,
+			AttributeActivity:      activity.Name,
+			AttributeBuildLogsURL:  spec.BuildLogsURL,
+			AttributeGitOwner:      spec.GitOwner,
+			AttributeGitRepository: spec.GitRepository,
+			AttributeGitBranch:     spec.GitBranch,
+			AttributeCommitSHA:     spec.LastCommitSHA,
+			AttributeCommitURL:     spec.LastCommitURL,
+		}),
+	}
+	if spec.LastCommitSHA != "" {
+		root.Links = append(root.Links, NewLink(TriggerKey(spec.GitOwner, spec.GitRepository, spec.LastCommitSHA), attributes(map[string]string{
+			AttributeLinkType:  LinkTypeTrigger,
+			AttributeCommitSHA: spec.LastCommitSHA,
+			AttributeCommitURL: spec.LastCommitURL,
+		})))
+	}
+	setTimes(root, spec.StartedTimestamp, spec.CompletedTimestamp, nil)
+	trace.Spans = append(trace.Spans, root)
+
+	stageSpans := map[string]*Span{}
+	for i := range spec.Steps {
+		step := &spec.Steps[i]
+		switch {
+		case step.Stage != nil:
+			stage := step.Stage
+			parent := root
+			if idx := strings.LastIndex(stage.Name, stageSeparator); idx > 0 {
+				if s := stageSpans[stage.Name[:idx]]; s != nil {
+					parent = s
+				}
+			}
+			span := childSpan(parent, "stage:"+stage.Name, stage.Name, &stage.CoreActivityStep)
+			if span == nil {
+				continue
+			}
+			stageSpans[stage.Name] = span
+			trace.Spans = append(trace.Spans, span)
+			for j := range stage.Steps {
+				s := &stage.Steps[j]
+				if stepSpan := childSpan(span, "step:"+stage.Name+"/"+s.Name, s.Name, s); stepSpan != nil {
+					trace.Spans = append(trace.Spans, stepSpan)
+				}
+			}
+
+		case step.Promote != nil:
+			promote := step.Promote
+			span := childSpan(root, "promote:"+promote.Environment, "promote "+promote.Environment, &promote.CoreActivityStep)
+			if span == nil {
+				continue
+			}
+			span.Attributes[AttributeEnvironment] = promote.Environment
+			trace.Spans = append(trace.Spans, span)
+			if pr := promote.PullRequest; pr != nil {
+				prSpan := childSpan(span, "promote:"+promote.Environment+":pullrequest", "pull request", &pr.CoreActivityStep)
+				if prSpan != nil {
+					if pr.PullRequestURL != "" {
+						prSpan.Attributes[AttributePullRequestURL] = pr.PullRequestURL
+						prSpan.Links = append(prSpan.Links, NewLink(pr.PullRequestURL, map[string]string{
+							AttributeLinkType:       LinkTypePromote,
+							AttributePullRequestURL: pr.PullRequestURL,
+						}))
+					}
+					trace.Spans = append(trace.Spans, prSpan)
+				}
+			}
+			if update := promote.Update; update != nil {
+				if updateSpan := childSpan(span, "promote:"+promote.Environment+":update", "update", &update.CoreActivityStep); updateSpan != nil {
+					trace.Spans = append(trace.Spans, updateSpan)
+				}
+			}
+
+		case step.Preview != nil:
+			preview := step.Preview
+			span := childSpan(root, "preview:"+preview.Environment, "preview "+preview.Environment, &preview.CoreActivityStep)
+			if span == nil {
+				continue
+			}
+			span.Attributes[AttributeEnvironment] = preview.Environment
+			if preview.PullRequestURL != "" {
+				span.Attributes[AttributePullRequestURL] = preview.PullRequestURL
+				span.Links = append(span.Links, NewLink(preview.PullRequestURL, map[string]string{
+					AttributeLinkType:       LinkTypePreview,
+					AttributePullRequestURL: preview.PullRequestURL,
+				}))
+			}
+			trace.Spans = append(trace.Spans, span)
+		}
+	}
+
+	// lets make sure the pipeline run span covers all of its stages in case the activity is missing timestamps
+	for _, span := range trace.Spans[1:] {
+		if root.Start.IsZero() || span.Start.Before(root.Start) {
+			root.Start = span.Start
+		}
+		if span.End.After(root.End) {
+			root.End = span.End
+		}
+	}
+	return trace
+}
+
+// childSpan creates the span of a step of a PipelineActivity or returns nil if the step never started
+func childSpan(parent *Span, key string, name string, step *v1.CoreActivityStep) *Span {
+	if step.StartedTimestamp == nil || step.StartedTimestamp.IsZero() {
+		return nil
+	}
+	span := &Span{
+		TraceID:      parent.TraceID,
+		SpanID:       NewSpanID(parent.TraceID, key),
+		ParentSpanID: parent.SpanID,
+		Name:         name,
+		Status:       statusCode(step.Status),
+		Attributes:   map[string]string{},
+	}
+	setTimes(span, step.StartedTimestamp, step.CompletedTimestamp, parent)
+	return span
+}
+
+// setTimes sets the start and end of the span. If the step has not completed it ends with its parent
+func setTimes(span *Span, started *metav1.Time, completed *metav1.Time, parent *Span) {
+	if started != nil {
+		span.Start = started.Time
+	}
+	if completed != nil {
+		span.End = completed.Time
+	}
+	if span.End.IsZero() && parent != nil {
+		span.End = parent.End
+	}
+	if span.End.Before(span.Start) {
+		span.End = span.Start
+	}
+}
+
+// statusCode converts the status of an activity step into the status of its span
+func statusCode(status v1.ActivityStatusType) StatusCode {
+	switch status {
+	case v1.ActivityStatusTypeSucceeded:
+		return StatusOK
+	case v1.ActivityStatusTypeFailed, v1.ActivityStatusTypeError, v1.ActivityStatusTypeAborted:
+		return StatusError
+	default:
+		return StatusUnset
+	}
+}
+
+// attributes returns the attributes without any blank values
+func attributes(values map[string]string) map[string]string {
+	answer := map[string]string{}
+	for k, v := range values {
+		if v != "" {
+			answer[k] = v
+		}
+	}
+	return answer
+}
+
+// PipelineTracer exports the trace of each pipeline run once its PipelineActivity has completed. The traces are
+// exported by a worker so that slow exporters do not block the caller
+type PipelineTracer struct {
+	exporter Exporter
+	resource map[string]string
+	since    time.Time
+	queue    chan exportRequest
+	lock     sync.Mutex
+	queued   map[string]bool
+	exported map[string]time.Time
+	now      func() time.Time
+}
+
+// exportRequest a pipeline run waiting to be exported
+type exportRequest struct {
+	runName  string
+	activity *v1.PipelineActivity
+}
+
+// NewPipelineTracer creates a tracer which exports the pipeline traces with the given resource attributes. Pipeline
+// runs completed before since are not exported as they were exported before a restart
+func NewPipelineTracer(exporter Exporter, resource map[string]string, since time.Time) *PipelineTracer {
+	return &PipelineTracer{
+		exporter: exporter,
+		resource: resource,
+		since:    since,
+		queue:    make(chan exportRequest, exportQueueSize),
+		queued:   map[string]bool{},
+		exported: map[string]time.Time{},
+		now:      time.Now,
+	}
+}
+
+// Enqueue queues the trace of the pipeline run to be exported if its activity has completed since the tracer was
+// created and it has not already been queued or exported. It never blocks and returns true if the trace was queued
+func (t *PipelineTracer) Enqueue(runName string, activity *v1.PipelineActivity) bool {
+	completed := activity.Spec.CompletedTimestamp
+	if !activity.Spec.Status.IsTerminated() || completed == nil || completed.Time.Before(t.since) {
+		return false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	for name, exported := range t.exported {
+		if now.Sub(exported) > exportedRunExpiry {
+			delete(t.exported, name)
+		}
+	}
+	if _, ok := t.exported[runName]; ok || t.queued[runName] {
+		return false
+	}
+	select {
+	case t.queue <- exportRequest{runName: runName, activity: activity.DeepCopy()}:
+		t.queued[runName] = true
+		return true
+	default:
+		// the queue is full so the run is queued again on its next update
+		return false
+	}
+}
+
+// Run exports the queued traces until stop is closed, calling done with the result of each export. Runs which
+// failed to export are queued again on their next update
+func (t *PipelineTracer) Run(stop <-chan struct{}, done func(runName string, err error)) {
+	for {
+		select {
+		case <-stop:
+			return
+		case request := <-t.queue:
+			err := t.export(request)
+			if done != nil {
+				done(request.runName, err)
+			}
+		}
+	}
+}
+
+func (t *PipelineTracer) export(request exportRequest) error {
+	trace := PipelineRunTrace(request.runName, request.activity)
+	trace.Resource = t.resource
+	err := t.exporter.Export(trace)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.queued, request.runName)
+	if err != nil {
+		return err
+	}
+	t.exported[request.runName] = t.now()
+	return nil
+}
@@ -0,0 +1,235 @@
+// +build unit
+
+package tracing_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/tracing"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const runName = "jstrachan-myapp-master-1"
+
+var start = time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func at(seconds int) *metav1.Time {
+	t := metav1.NewTime(start.Add(time.Duration(seconds) * time.Second))
+	return &t
+}
+
+func step(name string, started int, completed int, status v1.ActivityStatusType) v1.CoreActivityStep {
+	answer := v1.CoreActivityStep{
+		Name:             name,
+		Status:           status,
+		StartedTimestamp: at(started),
+	}
+	if completed > 0 {
+		answer.CompletedTimestamp = at(completed)
+	}
+	return answer
+}
+
+func newActivity() *v1.PipelineActivity {
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "jstrachan-myapp-master-1"},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:           "jstrachan/myapp/master",
+			Build:              "1",
+			Status:             v1.ActivityStatusTypeFailed,
+			StartedTimestamp:   at(0),
+			CompletedTimestamp: at(300),
+			GitOwner:           "jstrachan",
+			GitRepository:      "myapp",
+			GitBranch:          "master",
+			LastCommitSHA:      "abc",
+			LastCommitURL:      "https://github.com/jstrachan/myapp/commit/abc",
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: step("build", 10, 200, v1.ActivityStatusTypeSucceeded),
+					},
+				},
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: step("build / compile", 10, 200, v1.ActivityStatusTypeSucceeded),
+						Steps: []v1.CoreActivityStep{
+							step("Git Clone", 10, 20, v1.ActivityStatusTypeSucceeded),
+							step("Build Mvn", 20, 200, v1.ActivityStatusTypeSucceeded),
+							{Name: "Skipped", Status: v1.ActivityStatusTypeNotExecuted},
+						},
+					},
+				},
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						CoreActivityStep: step("promote: staging", 200, 300, v1.ActivityStatusTypeFailed),
+						Environment:      "staging",
+						PullRequest: &v1.PromotePullRequestStep{
+							CoreActivityStep: step("PullRequest", 200, 0, v1.ActivityStatusTypeFailed),
+							PullRequestURL:   "https://github.com/jstrachan/environment-staging/pull/7",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPipelineRunTrace(t *testing.T) {
+	t.Parallel()
+
+	trace := tracing.PipelineRunTrace(runName, newActivity())
+	assert.Equal(t, tracing.NewTraceID(runName), trace.ID)
+
+	spans := map[string]*tracing.Span{}
+	for _, s := range trace.Spans {
+		assert.Equal(t, trace.ID, s.TraceID)
+		spans[s.Name] = s
+	}
+	require.Len(t, spans, 7, "the skipped step should not have a span")
+
+	root := spans["jstrachan/myapp/master #1"]
+	require.NotNil(t, root)
+	assert.True(t, root.ParentSpanID.IsEmpty())
+	assert.Equal(t, tracing.StatusError, root.Status)
+	assert.Equal(t, runName, root.Attributes[tracing.AttributePipelineRun])
+	assert.Equal(t, "abc", root.Attributes[tracing.AttributeCommitSHA])
+	assert.Equal(t, 300*time.Second, root.End.Sub(root.Start))
+	require.Len(t, root.Links, 1)
+	trigger := tracing.NewLink(tracing.TriggerKey("jstrachan", "myapp", "abc"), nil)
+	assert.Equal(t, trigger.TraceID, root.Links[0].TraceID)
+	assert.Equal(t, trigger.SpanID, root.Links[0].SpanID)
+	assert.Equal(t, tracing.LinkTypeTrigger, root.Links[0].Attributes[tracing.AttributeLinkType])
+
+	build := spans["build"]
+	compile := spans["build / compile"]
+	require.NotNil(t, build)
+	require.NotNil(t, compile)
+	assert.Equal(t, root.SpanID, build.ParentSpanID)
+	assert.Equal(t, build.SpanID, compile.ParentSpanID, "nested stages should be children of their parent stage")
+	assert.Equal(t, compile.SpanID, spans["Git Clone"].ParentSpanID)
+	assert.Equal(t, tracing.StatusOK, spans["Build Mvn"].Status)
+	assert.Equal(t, 180*time.Second, spans["Build Mvn"].End.Sub(spans["Build Mvn"].Start))
+
+	promote := spans["promote staging"]
+	require.NotNil(t, promote)
+	assert.Equal(t, "staging", promote.Attributes[tracing.AttributeEnvironment])
+	pr := spans["pull request"]
+	require.NotNil(t, pr)
+	assert.Equal(t, promote.SpanID, pr.ParentSpanID)
+	assert.Equal(t, root.End, pr.End, "an incomplete step should end with its parent")
+	require.Len(t, pr.Links, 1)
+	assert.Equal(t, tracing.NewTraceID("https://github.com/jstrachan/environment-staging/pull/7"), pr.Links[0].TraceID)
+	assert.Equal(t, tracing.LinkTypePromote, pr.Links[0].Attributes[tracing.AttributeLinkType])
+
+	again := tracing.PipelineRunTrace(runName, newActivity())
+	assert.Equal(t, trace.Spans[2].SpanID, again.Spans[2].SpanID, "the span IDs should be deterministic")
+}
+
+func TestPipelineTracer(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-traces")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "traces", "traces.json")
+
+	tracer := tracing.NewPipelineTracer(tracing.NewFileExporter(file), map[string]string{
+		tracing.AttributeServiceName: "jx-pipelines",
+	}, start)
+	stop := make(chan struct{})
+	defer close(stop)
+	results := make(chan error)
+	go tracer.Run(stop, func(name string, err error) {
+		assert.Equal(t, runName, name)
+		results <- err
+	})
+
+	activity := newActivity()
+	activity.Spec.Status = v1.ActivityStatusTypeRunning
+	assert.False(t, tracer.Enqueue(runName, activity), "running pipelines should not be exported")
+
+	activity.Spec.Status = v1.ActivityStatusTypeSucceeded
+	assert.True(t, tracer.Enqueue(runName, activity))
+	assert.False(t, tracer.Enqueue(runName, activity), "queued pipeline runs should not be queued again")
+	require.NoError(t, <-results)
+	assert.False(t, tracer.Enqueue(runName, activity), "each pipeline run should only be exported once")
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+	var requests []tracing.ExportTraceServiceRequest
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		request := tracing.ExportTraceServiceRequest{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &request))
+		requests = append(requests, request)
+	}
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].ResourceSpans, 1)
+	rs := requests[0].ResourceSpans[0]
+	assert.Equal(t, []tracing.KeyValue{{Key: "service.name", Value: tracing.AnyValue{StringValue: "jx-pipelines"}}}, rs.Resource.Attributes)
+	require.Len(t, rs.ScopeSpans, 1)
+	spans := rs.ScopeSpans[0].Spans
+	require.Len(t, spans, 7)
+	assert.Equal(t, tracing.NewTraceID(runName).String(), spans[0].TraceID)
+	assert.Empty(t, spans[0].ParentSpanID)
+	assert.Equal(t, spans[0].SpanID, spans[1].ParentSpanID)
+	assert.Equal(t, tracing.StatusOK, spans[0].Status.Code)
+	assert.Equal(t, "1583053200000000000", spans[0].StartTimeUnixNano)
+}
+
+type failingExporter struct {
+	failures int
+	exported int
+}
+
+func (e *failingExporter) Export(trace *tracing.Trace) error {
+	if e.failures > 0 {
+		e.failures--
+		return errors.New("collector unavailable")
+	}
+	e.exported++
+	return nil
+}
+
+func TestPipelineTracerRetriesFailedExports(t *testing.T) {
+	t.Parallel()
+
+	exporter := &failingExporter{failures: 1}
+	tracer := tracing.NewPipelineTracer(exporter, nil, start)
+	stop := make(chan struct{})
+	defer close(stop)
+	results := make(chan error)
+	go tracer.Run(stop, func(name string, err error) {
+		results <- err
+	})
+
+	activity := newActivity()
+	require.True(t, tracer.Enqueue(runName, activity))
+	require.Error(t, <-results)
+	require.True(t, tracer.Enqueue(runName, activity), "a failed export should be queued again on the next update")
+	require.NoError(t, <-results)
+	assert.Equal(t, 1, exporter.exported)
+}
+
+func TestPipelineTracerSkipsRunsCompletedBeforeStart(t *testing.T) {
+	t.Parallel()
+
+	exporter := &failingExporter{}
+	tracer := tracing.NewPipelineTracer(exporter, nil, start.Add(time.Hour))
+	assert.False(t, tracer.Enqueue(runName, newActivity()), "runs completed before the tracer started should not be exported")
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// StatusCode the status of a span as defined by OpenTelemetry
+type StatusCode int
+
+const (
+	// StatusUnset the status of a span which did not complete or whose outcome is unknown
+	StatusUnset StatusCode = 0
+	// StatusOK the status of a span which succeeded
+	StatusOK StatusCode = 1
+	// StatusError the status of a span which failed
+	StatusError StatusCode = 2
+)
+
+// TraceID the 16 byte identifier of a trace
+type TraceID [16]byte
+
+// SpanID the 8 byte identifier of a span
+type SpanID [8]byte
+
+// String returns the lower case hex encoding of the trace ID
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsEmpty returns true if the trace ID is all zeros
+func (t TraceID) IsEmpty() bool {
+	return t == TraceID{}
+}
+
+// String returns the lower case hex encoding of the span ID
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsEmpty returns true if the span ID is all zeros
+func (s SpanID) IsEmpty() bool {
+	return s == SpanID{}
+}
+
+// Trace the spans of a single trace along with the attributes of the resource which produced them
+type Trace struct {
+	ID       TraceID
+	Resource map[string]string
+	Spans    []*Span
+}
+
+// Span a timed operation within a trace
+type Span struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Status       StatusCode
+	Attributes   map[string]string
+	Links        []Link
+}
+
+// Link a reference from a span to a span in another trace, such as the webhook which triggered a pipeline
+type Link struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Attributes map[string]string
+}
+
+// NewTraceID derives a trace ID from the given key so that the same key always results in the same trace
+func NewTraceID(key string) TraceID {
+	var answer TraceID
+	sum := sha256.Sum256([]byte("trace:" + key))
+	copy(answer[:], sum[:])
+	return answer
+}
+
+// NewSpanID derives the ID of a span from its trace and a key which is unique within the trace
+func NewSpanID(traceID TraceID, key string) SpanID {
+	var answer SpanID
+	sum := sha256.Sum256(append(traceID[:], []byte("span:"+key)...))
+	copy(answer[:], sum[:])
+	return answer
+}
+
+// NewLink creates a link to the root span of the trace derived from the given key. Other components which trace
+// the same webhook or pull request can use NewTraceID and NewSpanID with the same key to join the traces
+func NewLink(key string, attributes map[string]string) Link {
+	traceID := NewTraceID(key)
+	return Link{
+		TraceID:    traceID,
+		SpanID:     NewSpanID(traceID, ""),
+		Attributes: attributes,
+	}
+}
@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DynamicSecretURIScheme the URI scheme used to reference dynamic secrets such as
+	// `vault-dynamic:database/creds/readonly:username`
+	DynamicSecretURIScheme = "vault-dynamic:"
+
+	// DynamicSecretModeEnv the dynamic secret value is injected as the value of the environment variable
+	DynamicSecretModeEnv = "env"
+
+	// DynamicSecretModeFile the dynamic secret value is written to a file and the environment variable contains its path
+	DynamicSecretModeFile = "file"
+)
+
+// DynamicSecret a short lived secret generated by a Vault secrets engine such as the database, aws, gcp or pki engines
+type DynamicSecret struct {
+	LeaseID       string                 `json:"leaseId,omitempty"`
+	LeaseDuration int                    `json:"leaseDuration,omitempty"`
+	Renewable     bool                   `json:"renewable,omitempty"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+}
+
+// DynamicSecretRef a reference to a value of a dynamic secret of the form
+// `vault-dynamic:<path>[?param=value&...]:<key>[:env|file]`
+type DynamicSecretRef struct {
+	Path   string
+	Params map[string]interface{}
+	Key    string
+	Mode   string
+}
+
+// IsDynamicSecretRef returns true if the given text is a reference to a dynamic secret
+func IsDynamicSecretRef(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), DynamicSecretURIScheme)
+}
+
+// ParseDynamicSecretRef parses a reference to a value of a dynamic secret
+func ParseDynamicSecretRef(text string) (*DynamicSecretRef, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, DynamicSecretURIScheme) {
+		return nil, fmt.Errorf("dynamic secret reference %q does not start with %s", text, DynamicSecretURIScheme)
+	}
+	parts := strings.Split(strings.TrimPrefix(text, DynamicSecretURIScheme), ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid dynamic secret reference %q should be of the form %s<path>:<key>[:env|file]", text, DynamicSecretURIScheme)
+	}
+	ref := &DynamicSecretRef{
+		Path: parts[0],
+		Key:  parts[1],
+		Mode: DynamicSecretModeEnv,
+	}
+	if len(parts) == 3 {
+		ref.Mode = parts[2]
+		if ref.Mode != DynamicSecretModeEnv && ref.Mode != DynamicSecretModeFile {
+			return nil, fmt.Errorf("invalid mode %q of dynamic secret reference %q should be %s or %s", ref.Mode, text, DynamicSecretModeEnv, DynamicSecretModeFile)
+		}
+	}
+	idx := strings.Index(ref.Path, "?")
+	if idx >= 0 {
+		values, err := url.ParseQuery(ref.Path[idx+1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing the parameters of dynamic secret reference %q", text)
+		}
+		ref.Path = ref.Path[:idx]
+		ref.Params = map[string]interface{}{}
+		for k, v := range values {
+			if len(v) > 0 {
+				ref.Params[k] = v[0]
+			}
+		}
+	}
+	return ref, nil
+}
+
+// LeaseKey returns a key which is the same for all references which can share the same lease
+func (r *DynamicSecretRef) LeaseKey() string {
+	if len(r.Params) == 0 {
+		return r.Path
+	}
+	keys := []string{}
+	for k := range r.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := []string{}
+	for _, k := range keys {
+		values = append(values, fmt.Sprintf("%s=%v", k, r.Params[k]))
+	}
+	return r.Path + "?" + strings.Join(values, "&")
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/secreturl"
 
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/vault"
 	"github.com/pkg/errors"
 )
 
@@ -21,12 +22,24 @@ var vaultURIRegex = regexp.MustCompile(`vault:[-_.\w\/:]*`)
 // FakeVaultClient is an in memory implementation of vault, useful for testing
 type FakeVaultClient struct {
 	Data map[string]map[string]interface{}
+
+	// DynamicData the data returned for dynamic secrets indexed by path
+	DynamicData map[string]map[string]interface{}
+
+	// Leases the active leases of dynamic secrets indexed by lease ID
+	Leases map[string]string
+
+	// LeaseParams the parameters of the last lease of each dynamic secret path
+	LeaseParams map[string]map[string]interface{}
 }
 
-//NewFakeVaultClient creates a new FakeVaultClient
+// NewFakeVaultClient creates a new FakeVaultClient
 func NewFakeVaultClient() FakeVaultClient {
 	return FakeVaultClient{
-		Data: make(map[string]map[string]interface{}),
+		Data:        make(map[string]map[string]interface{}),
+		DynamicData: make(map[string]map[string]interface{}),
+		Leases:      make(map[string]string),
+		LeaseParams: make(map[string]map[string]interface{}),
 	}
 }
 
@@ -123,3 +136,29 @@ func (f FakeVaultClient) Config() (vaultURL url.URL, vaultToken string, err erro
 func (f FakeVaultClient) ReplaceURIs(text string) (string, error) {
 	return secreturl.ReplaceURIs(text, f, vaultURIRegex, "vault:")
 }
+
+// LeaseDynamic returns the dynamic data registered for the path with a new lease
+func (f FakeVaultClient) LeaseDynamic(path string, params map[string]interface{}) (*vault.DynamicSecret, error) {
+	data, ok := f.DynamicData[path]
+	if !ok {
+		return nil, errors.Errorf("no dynamic secret engine at path %s", path)
+	}
+	leaseID := fmt.Sprintf("%s/%d", path, len(f.Leases)+1)
+	f.Leases[leaseID] = path
+	f.LeaseParams[path] = params
+	return &vault.DynamicSecret{
+		LeaseID:       leaseID,
+		LeaseDuration: 3600,
+		Renewable:     true,
+		Data:          data,
+	}, nil
+}
+
+// RevokeLease removes the lease
+func (f FakeVaultClient) RevokeLease(leaseID string) error {
+	if _, ok := f.Leases[leaseID]; !ok {
+		return errors.Errorf("lease %s does not exist", leaseID)
+	}
+	delete(f.Leases, leaseID)
+	return nil
+}
@@ -3,6 +3,8 @@ package vault
 import (
 	"io/ioutil"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/util"
@@ -58,3 +60,29 @@ func WriteMap(client Client, path string, secret map[string]interface{}) error {
 	}
 	return nil
 }
+
+// ListRecursive returns the paths of all the secrets under the given path, walking any sub paths
+func ListRecursive(client Client, path string) ([]string, error) {
+	names, err := client.List(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing secrets in vault at path '%s'", path)
+	}
+	answer := []string{}
+	for _, name := range names {
+		child := name
+		if path != "" {
+			child = strings.TrimSuffix(path, "/") + "/" + name
+		}
+		if strings.HasSuffix(name, "/") {
+			children, err := ListRecursive(client, strings.TrimSuffix(child, "/"))
+			if err != nil {
+				return nil, err
+			}
+			answer = append(answer, children...)
+			continue
+		}
+		answer = append(answer, child)
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
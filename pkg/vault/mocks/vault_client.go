@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"time"
 
+	vault "github.com/jenkins-x/jx/v2/pkg/vault"
 	pegomock "github.com/petergtz/pegomock"
 )
 
@@ -140,6 +141,40 @@ func (mock *MockClient) ReplaceURIs(_param0 string) (string, error) {
 	return ret0, ret1
 }
 
+func (mock *MockClient) LeaseDynamic(_param0 string, _param1 map[string]interface{}) (*vault.DynamicSecret, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockClient().")
+	}
+	params := []pegomock.Param{_param0, _param1}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("LeaseDynamic", params, []reflect.Type{reflect.TypeOf((**vault.DynamicSecret)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *vault.DynamicSecret
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*vault.DynamicSecret)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockClient) RevokeLease(_param0 string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockClient().")
+	}
+	params := []pegomock.Param{_param0}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("RevokeLease", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockClient) Write(_param0 string, _param1 map[string]interface{}) (map[string]interface{}, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockClient().")
@@ -390,6 +425,64 @@ func (c *MockClient_ReplaceURIs_OngoingVerification) GetAllCapturedArguments() (
 	return
 }
 
+func (verifier *VerifierMockClient) LeaseDynamic(_param0 string, _param1 map[string]interface{}) *MockClient_LeaseDynamic_OngoingVerification {
+	params := []pegomock.Param{_param0, _param1}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "LeaseDynamic", params, verifier.timeout)
+	return &MockClient_LeaseDynamic_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockClient_LeaseDynamic_OngoingVerification struct {
+	mock              *MockClient
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockClient_LeaseDynamic_OngoingVerification) GetCapturedArguments() (string, map[string]interface{}) {
+	_param0, _param1 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1], _param1[len(_param1)-1]
+}
+
+func (c *MockClient_LeaseDynamic_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []map[string]interface{}) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]map[string]interface{}, len(c.methodInvocations))
+		for u, param := range params[1] {
+			_param1[u] = param.(map[string]interface{})
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockClient) RevokeLease(_param0 string) *MockClient_RevokeLease_OngoingVerification {
+	params := []pegomock.Param{_param0}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RevokeLease", params, verifier.timeout)
+	return &MockClient_RevokeLease_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockClient_RevokeLease_OngoingVerification struct {
+	mock              *MockClient
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockClient_RevokeLease_OngoingVerification) GetCapturedArguments() string {
+	_param0 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1]
+}
+
+func (c *MockClient_RevokeLease_OngoingVerification) GetAllCapturedArguments() (_param0 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockClient) Write(_param0 string, _param1 map[string]interface{}) *MockClient_Write_OngoingVerification {
 	params := []pegomock.Param{_param0, _param1}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Write", params, verifier.timeout)
@@ -49,6 +49,13 @@ type Client interface {
 
 	// ReplaceURIs will replace any vault: URIs in a string (or whatever URL scheme the secret URL client supports
 	ReplaceURIs(text string) (string, error)
+
+	// LeaseDynamic generates a dynamic secret from the secrets engine at the given path such as `database/creds/readonly`.
+	// If any parameters are specified they are written to the path, otherwise the path is read
+	LeaseDynamic(path string, params map[string]interface{}) (*DynamicSecret, error)
+
+	// RevokeLease revokes the lease of a dynamic secret
+	RevokeLease(leaseID string) error
 }
 
 // client is a hand wrapper around the official Vault API
@@ -191,3 +198,35 @@ func (v *client) Config() (vaultURL url.URL, vaultToken string, err error) {
 func (v *client) ReplaceURIs(s string) (string, error) {
 	return secreturl.ReplaceURIs(s, v, vaultURIRegex, "vault:")
 }
+
+// LeaseDynamic generates a dynamic secret from the secrets engine at the given path
+func (v *client) LeaseDynamic(path string, params map[string]interface{}) (*DynamicSecret, error) {
+	var secret *api.Secret
+	var err error
+	if len(params) == 0 {
+		secret, err = v.client.Logical().Read(path)
+	} else {
+		secret, err = v.client.Logical().Write(path, params)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "leasing dynamic secret %q from vault", path)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("no dynamic secret returned from vault for %q", path)
+	}
+	return &DynamicSecret{
+		LeaseID:       secret.LeaseID,
+		LeaseDuration: secret.LeaseDuration,
+		Renewable:     secret.Renewable,
+		Data:          secret.Data,
+	}, nil
+}
+
+// RevokeLease revokes the lease of a dynamic secret
+func (v *client) RevokeLease(leaseID string) error {
+	err := v.client.Sys().Revoke(leaseID)
+	if err != nil {
+		return errors.Wrapf(err, "revoking vault lease %q", leaseID)
+	}
+	return nil
+}
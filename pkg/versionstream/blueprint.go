@@ -0,0 +1,127 @@
+package versionstream
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// BlueprintsDir is the directory in the version stream containing the environment blueprints
+const BlueprintsDir = "environments"
+
+// EnvironmentBlueprint a versioned template for creating permanent environments
+type EnvironmentBlueprint struct {
+	// Name the name of the blueprint which defaults to the file name
+	Name string `json:"name,omitempty"`
+	// Version the version of the blueprint which is used to detect blueprint updates
+	Version string `json:"version,omitempty"`
+	// Description a textual description of the blueprint
+	Description string `json:"description,omitempty"`
+	// Label the default label of environments created from the blueprint
+	Label string `json:"label,omitempty"`
+	// Order the default order of environments created from the blueprint
+	Order int32 `json:"order,omitempty"`
+	// PromotionStrategy the default promotion strategy of environments created from the blueprint
+	PromotionStrategy string `json:"promotionStrategy,omitempty"`
+	// Charts the charts which are added to the environment git repository
+	Charts []BlueprintChart `json:"charts,omitempty"`
+	// Values the helm values which are merged into the values of the environment git repository. Merging the values
+	// rewrites the values file which loses any comments in it
+	Values map[string]interface{} `json:"values,omitempty"`
+	// RoleBindings the roles which are bound in the environment
+	RoleBindings []BlueprintRoleBinding `json:"roleBindings,omitempty"`
+	// ResourceQuota the resource quota of the environment namespace
+	ResourceQuota *corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+}
+
+// BlueprintChart a chart which is included in the environments created from a blueprint
+type BlueprintChart struct {
+	// Name the name of the chart which can include the repository prefix such as 'stable/postgresql'
+	Name string `json:"name"`
+	// Repository the URL of the chart repository
+	Repository string `json:"repository,omitempty"`
+	// Version the chart version. If blank the version in the version stream is used
+	Version string `json:"version,omitempty"`
+	// Alias the optional alias of the chart
+	Alias string `json:"alias,omitempty"`
+}
+
+// BlueprintRoleBinding a role which is bound to the subjects in environments created from a blueprint
+type BlueprintRoleBinding struct {
+	// Name the name of the binding which is used as the suffix of the EnvironmentRoleBinding name
+	Name string `json:"name"`
+	// RoleRef the Role or ClusterRole to bind
+	RoleRef rbacv1.RoleRef `json:"roleRef"`
+	// Subjects the users, groups or service accounts the role is bound to
+	Subjects []rbacv1.Subject `json:"subjects,omitempty"`
+}
+
+// ChartName returns the name of the chart without any repository prefix
+func (c *BlueprintChart) ChartName() string {
+	idx := strings.LastIndex(c.Name, "/")
+	if idx >= 0 {
+		return c.Name[idx+1:]
+	}
+	return c.Name
+}
+
+// LoadEnvironmentBlueprint loads the environment blueprint with the given name from the version stream directory.
+// The chart versions which are not specified are resolved from the version stream
+func LoadEnvironmentBlueprint(dir string, name string) (*EnvironmentBlueprint, error) {
+	path := filepath.Join(dir, BlueprintsDir, name+".yml")
+	exists, err := util.FileExists(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if !exists {
+		names, _ := GetEnvironmentBlueprintNames(dir)
+		return nil, util.InvalidArg(name, names)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", path)
+	}
+	blueprint := &EnvironmentBlueprint{}
+	err = yaml.Unmarshal(data, blueprint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML in file %s", path)
+	}
+	if blueprint.Name == "" {
+		blueprint.Name = name
+	}
+	for i := range blueprint.Charts {
+		chart := &blueprint.Charts[i]
+		if chart.Name == "" {
+			return nil, fmt.Errorf("missing chart name in blueprint %s", path)
+		}
+		if chart.Version == "" {
+			chart.Version, err = LoadStableVersionNumber(dir, KindChart, chart.Name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to resolve the version of chart %s in blueprint %s", chart.Name, name)
+			}
+		}
+	}
+	return blueprint, nil
+}
+
+// GetEnvironmentBlueprintNames returns the sorted names of the environment blueprints in the version stream directory
+func GetEnvironmentBlueprintNames(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, BlueprintsDir, "*.yml"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find blueprints in %s", dir)
+	}
+	answer := []string{}
+	for _, f := range files {
+		answer = append(answer, strings.TrimSuffix(filepath.Base(f), ".yml"))
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
@@ -158,3 +158,27 @@ func TestConvertToVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadEnvironmentBlueprint(t *testing.T) {
+	names, err := GetEnvironmentBlueprintNames(dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"perf-test"}, names)
+
+	blueprint, err := LoadEnvironmentBlueprint(dataDir, "perf-test")
+	require.NoError(t, err)
+	assert.Equal(t, "perf-test", blueprint.Name)
+	assert.Equal(t, "1.2.0", blueprint.Version)
+	assert.Equal(t, "Manual", blueprint.PromotionStrategy)
+	require.Len(t, blueprint.Charts, 2)
+	assert.Equal(t, "prow", blueprint.Charts[0].ChartName())
+	assert.Equal(t, "0.0.176", blueprint.Charts[0].Version, "chart version should be resolved from the version stream")
+	assert.Equal(t, "8.6.4", blueprint.Charts[1].Version)
+	require.Len(t, blueprint.RoleBindings, 1)
+	assert.Equal(t, "edit", blueprint.RoleBindings[0].RoleRef.Name)
+	require.NotNil(t, blueprint.ResourceQuota)
+	memory := blueprint.ResourceQuota.Hard["requests.memory"]
+	assert.Equal(t, "16Gi", memory.String())
+
+	_, err = LoadEnvironmentBlueprint(dataDir, "perf-tests")
+	require.Error(t, err)
+}